@@ -2,25 +2,61 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/lcarva/tektor/cmd/validate"
+	"github.com/lcarva/tektor/internal/logging"
+)
+
+var (
+	verbose bool
+	quiet   bool
 )
 
 var rootCmd = &cobra.Command{
 	Use:          "tektor",
 	Short:        "Tektor is a validator for Tekton resources.",
 	SilenceUsage: true,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		switch {
+		case verbose:
+			logging.SetLevel(logging.LevelDebug)
+		case quiet:
+			logging.SetLevel(logging.LevelWarn)
+		}
+	},
 }
 
 func Execute() {
 	if err := rootCmd.ExecuteContext(context.Background()); err != nil {
+		var exitErr *validate.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		os.Exit(1)
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
+		"Log debug messages too, e.g. the PipelineTasks being processed")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false,
+		"Only log warnings and errors, leaving progress messages out")
+	rootCmd.MarkFlagsMutuallyExclusive("verbose", "quiet")
+
 	rootCmd.AddCommand(validate.ValidateCmd)
+	rootCmd.AddCommand(validate.ActionCmd)
+	rootCmd.AddCommand(validate.SelftestCmd)
+	rootCmd.AddCommand(validate.ServeCmd)
+	rootCmd.AddCommand(validate.FixCmd)
+	rootCmd.AddCommand(validate.FmtCmd)
+	rootCmd.AddCommand(validate.GraphCmd)
+	rootCmd.AddCommand(validate.DiffCmd)
+	rootCmd.AddCommand(validate.RenderCmd)
+	rootCmd.AddCommand(validate.DocsCmd)
+	rootCmd.AddCommand(validate.DescribeCmd)
+	rootCmd.AddCommand(validate.BundleCmd)
 }
@@ -0,0 +1,292 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+
+	"github.com/lcarva/tektor/internal/logging"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+// tektonAPIVersionRegex matches the apiVersion of Tekton resources
+var tektonAPIVersionRegex = regexp.MustCompile(`(?m)^\s*apiVersion:\s*["']?tekton\.dev/`)
+
+var ActionCmd = &cobra.Command{
+	Use:   "action [FILE...]",
+	Short: "Validate Tekton resources from a GitHub Actions workflow",
+	Long: `Validate Tekton resources like the validate command does, reporting the results in the format
+expected by GitHub Actions when running in a workflow:
+- Errors and warnings are reported as annotations on the offending file and line
+- The output of each file is grouped in the log
+- A summary of the results is added to the job summary
+- The validated-files, validation-results, error-count, and warning-count step outputs are set
+
+Without FILE arguments, every YAML file of the workspace declaring a Tekton resource is validated.
+Outside of GitHub Actions, the results are reported like the validate command does.`,
+	Example: `  # Validate the Tekton resources of the repository from a workflow step
+  - run: tektor action
+
+  # Only validate the resources changed by a pull request
+  - run: tektor action --changed-only --base-ref origin/${{ github.base_ref }}`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			var err error
+			if args, err = discoverTektonFiles(actionWorkspace()); err != nil {
+				return err
+			}
+		}
+		ctx, params, files, err := setup(cmd.Context(), args)
+		if err != nil {
+			return err
+		}
+		return runAction(ctx, cmd.OutOrStdout(), files, params)
+	},
+}
+
+func init() {
+	addValidationFlags(ActionCmd)
+}
+
+// actionWorkspace returns the directory holding the repository checked out by the workflow
+func actionWorkspace() string {
+	if workspace := os.Getenv("GITHUB_WORKSPACE"); workspace != "" {
+		return workspace
+	}
+	return "."
+}
+
+// discoverTektonFiles returns the YAML files within dir which declare a Tekton resource. Hidden
+// directories are skipped, except for the .tekton directory used by Pipelines as Code.
+func discoverTektonFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			name := entry.Name()
+			if path != dir && strings.HasPrefix(name, ".") && name != ".tekton" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ext := filepath.Ext(path); ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if tektonAPIVersionRegex.Match(content) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("discovering Tekton resources in %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// fileReport gathers the errors and warnings reported for a file
+type fileReport struct {
+	File     string
+	Errors   []string
+	Warnings []string
+}
+
+func runAction(ctx context.Context, out io.Writer, files []string, runtimeParams validator.RuntimeParams) error {
+	if os.Getenv("GITHUB_ACTIONS") != "true" {
+		var allErrors error
+		for _, fname := range files {
+			if err := run(ctx, fname, runtimeParams); err != nil {
+				allErrors = multierror.Append(allErrors, fmt.Errorf("%s: %w", fname, err))
+			}
+		}
+		return allErrors
+	}
+
+	// Keep the log of each file within its group.
+	log.SetOutput(out)
+	log.SetFlags(0)
+
+	var reports []fileReport
+	errorCount, warningCount := 0, 0
+	for _, fname := range files {
+		fmt.Fprintf(out, "::group::%s\n", escapeData(fname))
+		report := validateForAction(ctx, out, fname, runtimeParams)
+		fmt.Fprintln(out, "::endgroup::")
+
+		reports = append(reports, report)
+		errorCount += len(report.Errors)
+		warningCount += len(report.Warnings)
+	}
+
+	var err *multierror.Error
+	if summary := os.Getenv("GITHUB_STEP_SUMMARY"); summary != "" {
+		err = multierror.Append(err, appendToFile(summary, actionSummary(reports)))
+	}
+	if output := os.Getenv("GITHUB_OUTPUT"); output != "" {
+		err = multierror.Append(err, appendToFile(output, actionOutputs(reports, errorCount, warningCount)))
+	}
+	if err := err.ErrorOrNil(); err != nil {
+		return fmt.Errorf("writing GitHub Actions results: %w", err)
+	}
+
+	if errorCount > 0 {
+		return fmt.Errorf("%d validation error(s) found", errorCount)
+	}
+	return nil
+}
+
+// validateForAction validates a file, annotating the errors and warnings on the file and line of
+// the field, or else of the resource, they are reported for
+func validateForAction(ctx context.Context, out io.Writer, fname string, runtimeParams validator.RuntimeParams) fileReport {
+	report := fileReport{File: annotationPath(fname)}
+	location := fmt.Sprintf("file=%s", escapeProperty(report.File))
+
+	logging.Infof("Validating %s", fname)
+	if len(runtimeParams) > 0 {
+		logRuntimeParameters(runtimeParams)
+	}
+	results, err := validateFile(ctx, fname, runtimeParams)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+		fmt.Fprintf(out, "::error %s,title=tektor::%s\n", location, escapeData(err.Error()))
+		return report
+	}
+
+	for _, result := range results {
+		for _, finding := range result.Findings {
+			findingLocation := fmt.Sprintf("%s,line=%d", location, finding.Line)
+			msg := fmt.Sprintf("line %d: %s", finding.Line, finding)
+			if finding.Severity == validator.SeverityWarning {
+				report.Warnings = append(report.Warnings, msg)
+			} else {
+				report.Errors = append(report.Errors, msg)
+			}
+			fmt.Fprintf(out, "::%s %s,title=%s::%s\n", finding.Severity, findingLocation, escapeProperty(annotationTitle(finding)), escapeData(finding.String()))
+		}
+	}
+
+	if len(report.Errors) == 0 {
+		logging.Infof("✅ Validation successful for %s", fname)
+	}
+	return report
+}
+
+// annotationTitle returns the title of the annotation of a finding, naming its rule
+func annotationTitle(finding validator.Finding) string {
+	if rule, ok := validator.LookupRule(finding.Rule); ok {
+		return fmt.Sprintf("tektor %s (%s)", rule.ID, rule.Name)
+	}
+	if finding.Rule != "" {
+		// Custom rules are only known by their ID.
+		return fmt.Sprintf("tektor %s", finding.Rule)
+	}
+	return "tektor"
+}
+
+// annotationPath returns the path of a file relative to the workspace, as expected by annotations
+func annotationPath(fname string) string {
+	workspace, err := filepath.Abs(actionWorkspace())
+	if err != nil {
+		return fname
+	}
+	abs, err := filepath.Abs(fname)
+	if err != nil {
+		return fname
+	}
+	rel, err := filepath.Rel(workspace, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fname
+	}
+	return filepath.ToSlash(rel)
+}
+
+// escapeData escapes the message of a workflow command
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a property value of a workflow command
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// actionSummary renders the reports as the markdown of the job summary
+func actionSummary(reports []fileReport) string {
+	var b strings.Builder
+	b.WriteString("## Tektor validation\n\n")
+	if len(reports) == 0 {
+		b.WriteString("No Tekton resources found.\n")
+		return b.String()
+	}
+
+	b.WriteString("| File | Result | Errors | Warnings |\n| --- | --- | --- | --- |\n")
+	for _, report := range reports {
+		result := "✅ Passed"
+		if len(report.Errors) > 0 {
+			result = "❌ Failed"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %d | %d |\n", report.File, result, len(report.Errors), len(report.Warnings))
+	}
+
+	for _, report := range reports {
+		if len(report.Errors) == 0 && len(report.Warnings) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n<details><summary><code>%s</code></summary>\n\n```\n", report.File)
+		for _, msg := range report.Errors {
+			fmt.Fprintf(&b, "error: %s\n", msg)
+		}
+		for _, msg := range report.Warnings {
+			fmt.Fprintf(&b, "warning: %s\n", msg)
+		}
+		b.WriteString("```\n\n</details>\n")
+	}
+	return b.String()
+}
+
+// actionOutputs renders the step outputs declared by action.yml
+func actionOutputs(reports []fileReport, errorCount, warningCount int) string {
+	var validated []string
+	for _, report := range reports {
+		if len(report.Errors) == 0 {
+			validated = append(validated, report.File)
+		}
+	}
+
+	results := "All validations passed"
+	switch {
+	case len(reports) == 0:
+		results = "No Tekton resources found"
+	case errorCount > 0:
+		results = fmt.Sprintf("%d validation error(s) found", errorCount)
+	}
+
+	return fmt.Sprintf("validated-files=%s\nvalidation-results=%s\nerror-count=%d\nwarning-count=%d\n",
+		strings.Join(validated, ","), results, errorCount, warningCount)
+}
+
+func appendToFile(fname, content string) error {
+	f, err := os.OpenFile(fname, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
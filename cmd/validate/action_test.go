@@ -0,0 +1,137 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validActionTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  steps:
+    - name: hello
+      image: alpine:latest
+      script: echo hello
+`
+
+const invalidActionTasks = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  steps:
+    - name: hello
+      image: alpine:latest
+      script: echo hello
+---
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: goodbye
+spec:
+  steps:
+    - name: goodbye
+      image: alpine:latest
+      args: ["$(params.message)", "$(results.missing.path)"]
+`
+
+func TestDiscoverTektonFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"task.yaml":                 validActionTask,
+		"nested/task.yml":           validActionTask,
+		".tekton/pipelinerun.yaml":  "apiVersion: \"tekton.dev/v1\"\nkind: PipelineRun\n",
+		".github/workflows/ci.yaml": validActionTask,
+		"config.yaml":               "apiVersion: v1\nkind: ConfigMap\n",
+		"task.json":                 `{"apiVersion": "tekton.dev/v1", "kind": "Task"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	discovered, err := discoverTektonFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, ".tekton/pipelinerun.yaml"),
+		filepath.Join(dir, "nested/task.yml"),
+		filepath.Join(dir, "task.yaml"),
+	}, discovered)
+}
+
+func TestRunAction(t *testing.T) {
+	workspace := t.TempDir()
+	validPath := filepath.Join(workspace, "valid.yaml")
+	invalidPath := filepath.Join(workspace, "tasks, invalid.yaml")
+	require.NoError(t, os.WriteFile(validPath, []byte(validActionTask), 0644))
+	require.NoError(t, os.WriteFile(invalidPath, []byte(invalidActionTasks), 0644))
+
+	summaryPath := filepath.Join(t.TempDir(), "summary.md")
+	outputPath := filepath.Join(t.TempDir(), "output")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_WORKSPACE", workspace)
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+	t.Setenv("GITHUB_OUTPUT", outputPath)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	var out bytes.Buffer
+	err := runAction(context.Background(), &out, []string{validPath, invalidPath}, nil)
+	require.Error(t, err)
+	assert.Equal(t, "2 validation error(s) found", err.Error())
+
+	assert.Equal(t, "::group::"+validPath+"\n"+
+		"Validating "+validPath+"\n"+
+		"✅ Validation successful for "+validPath+"\n"+
+		"::endgroup::\n"+
+		"::group::"+invalidPath+"\n"+
+		"Validating "+invalidPath+"\n"+
+		`::error file=tasks%2C invalid.yaml,line=19,title=tektor TEK0101 (schema)::non-existent variable in "$(params.message)": spec.steps[0].args[0] [TEK0101]`+"\n"+
+		`::error file=tasks%2C invalid.yaml,line=19,title=tektor TEK0301 (results)::non-existent result in "$(results.missing.path)": spec.steps[0].args[1] [TEK0301]`+"\n"+
+		"::endgroup::\n", out.String())
+
+	summary, err := os.ReadFile(summaryPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(summary), "| `valid.yaml` | ✅ Passed | 0 | 0 |\n")
+	assert.Contains(t, string(summary), "| `tasks, invalid.yaml` | ❌ Failed | 2 | 0 |\n")
+	assert.Contains(t, string(summary), `error: line 19: non-existent result in "$(results.missing.path)": spec.steps[0].args[1]`)
+
+	output, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	assert.Equal(t, "validated-files=valid.yaml\n"+
+		"validation-results=2 validation error(s) found\n"+
+		"error-count=2\n"+
+		"warning-count=0\n", string(output))
+}
+
+func TestRunActionOutsideGitHubActions(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	invalidPath := filepath.Join(t.TempDir(), "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalidPath, []byte(invalidActionTasks), 0644))
+
+	var out bytes.Buffer
+	err := runAction(context.Background(), &out, []string{invalidPath}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `non-existent result in "$(results.missing.path)": spec.steps[0].args[1]`)
+	assert.Empty(t, out.String())
+}
+
+func TestEscapeWorkflowCommand(t *testing.T) {
+	assert.Equal(t, "50%25 done%0Aline two%0D", escapeData("50% done\nline two\r"))
+	assert.Equal(t, "C%3A/tasks%2C v2/50%25.yaml", escapeProperty("C:/tasks, v2/50%.yaml"))
+}
+
+func TestActionSummaryWithoutFiles(t *testing.T) {
+	assert.Equal(t, "## Tektor validation\n\nNo Tekton resources found.\n", actionSummary(nil))
+	assert.Equal(t, "validated-files=\nvalidation-results=No Tekton resources found\nerror-count=0\nwarning-count=0\n",
+		actionOutputs(nil, 0, 0))
+}
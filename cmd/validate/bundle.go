@@ -0,0 +1,85 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+var BundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Inspect Tekton bundles",
+}
+
+var bundleLsCmd = &cobra.Command{
+	Use:   "ls REF",
+	Short: "List the Tasks and Pipelines of a Tekton bundle",
+	Long: `List the Tasks and Pipelines contained in the Tekton bundle at an image reference, along with
+the params and results they declare. Registries are authenticated with the credentials of the
+configuration, or else with the Docker credentials. Bundles saved locally are listed with an
+oci-layout: reference to an OCI image layout directory or tarball.`,
+	Example: `  # List the Tasks of a bundle
+  tektor bundle ls quay.io/konflux-ci/tekton-catalog/task-buildah:0.4
+
+  # List the Tasks of a bundle saved with docker save
+  tektor bundle ls oci-layout:./bundles/buildah.tar`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		ctx := validator.WithOptions(cmd.Context(), validator.Options{Credentials: cfg.Credentials})
+		return listBundle(ctx, args[0], cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	bundleLsCmd.Flags().StringVar(&configFile, "config", "",
+		fmt.Sprintf("Configuration file, defaults to $%s or to %s if it exists", config.FileEnv, config.DefaultFile))
+	BundleCmd.AddCommand(bundleLsCmd)
+}
+
+// listBundle writes the entries of the Tekton bundle at ref to out
+func listBundle(ctx context.Context, ref string, out io.Writer) error {
+	entries, err := validator.ListBundle(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s: no Task nor Pipeline found", ref)
+	}
+	for i, entry := range entries {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+		writeBundleEntry(out, entry)
+	}
+	return nil
+}
+
+// writeBundleEntry writes the kind and name of an entry of a bundle and its interface
+func writeBundleEntry(out io.Writer, entry validator.BundleEntry) {
+	fmt.Fprintf(out, "%s %s (%s)\n", entry.Kind, entry.Name, entry.APIVersion)
+	if entry.Err != nil {
+		fmt.Fprintf(out, "  ❌ Not decoded: %v\n", entry.Err)
+		return
+	}
+	if len(entry.Params) > 0 {
+		fmt.Fprintln(out, "  Params:")
+		for _, param := range entry.Params {
+			fmt.Fprintf(out, "    %s\n", describeParam(param))
+		}
+	}
+	if len(entry.Results) > 0 {
+		fmt.Fprintln(out, "  Results:")
+		for _, result := range entry.Results {
+			fmt.Fprintf(out, "    %s (%s)\n", result.Name, result.Type)
+		}
+	}
+}
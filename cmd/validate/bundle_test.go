@@ -0,0 +1,36 @@
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+func TestWriteBundleEntry(t *testing.T) {
+	var out bytes.Buffer
+	writeBundleEntry(&out, validator.BundleEntry{
+		Kind:       "task",
+		Name:       "build",
+		APIVersion: "tekton.dev/v1",
+		Params: v1.ParamSpecs{
+			{Name: "url"},
+			{Name: "args", Type: v1.ParamTypeArray, Default: v1.NewStructuredValues("--quiet", "--tls")},
+		},
+		Results: []validator.BundleResult{{Name: "digest", Type: "string"}},
+	})
+	writeBundleEntry(&out, validator.BundleEntry{Kind: "pipeline", Name: "release", APIVersion: "tekton.dev/v1", Err: errors.New("malformed")})
+	assert.Equal(t, `task build (tekton.dev/v1)
+  Params:
+    url (string, required)
+    args (array, default ["--quiet","--tls"])
+  Results:
+    digest (string)
+pipeline release (tekton.dev/v1)
+  ❌ Not decoded: malformed
+`, out.String())
+}
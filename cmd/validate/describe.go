@@ -0,0 +1,206 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/document"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+var DescribeCmd = &cobra.Command{
+	Use:   "describe FILE",
+	Short: "Show where the tasks of Pipelines come from",
+	Long: `Show every PipelineTask of the Pipelines, and of the PipelineRuns embedding a pipeline spec,
+of a file, along with where its spec comes from and the interface it resolves to, to aid
+supply-chain reviews:
+- Embedded specs
+- Bundles, git repositories, and hubs, flagging the references which are not pinned
+- Local files, from --task-dir and --pipeline-dir directories or the .tekton directory of the
+  repository
+- The params, results, and workspaces of the resolved Tasks`,
+	Example: `  # Describe the tasks of a Pipeline
+  tektor describe pipeline.yaml --task-dir tasks`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, params, _, err := setup(cmd.Context(), nil)
+		if err != nil {
+			return err
+		}
+		return describe(ctx, args[0], params, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	addValidationFlags(DescribeCmd)
+}
+
+// describe writes the provenance of the PipelineTasks of the pipelines of a file to out
+func describe(ctx context.Context, fname string, runtimeParams validator.RuntimeParams, out io.Writer) error {
+	docs, err := document.SplitFile(fname)
+	if err != nil {
+		return err
+	}
+	ctx, err = withPaCTaskIndex(ctx, fname)
+	if err != nil {
+		return fmt.Errorf("indexing PAC tasks: %w", err)
+	}
+	ctx = validator.WithBaseDir(ctx, filepath.Dir(fname))
+
+	described := 0
+	for _, doc := range docs {
+		var pipelineSpec *v1.PipelineSpec
+		var annotations map[string]string
+		switch doc.Key() {
+		case "tekton.dev/v1/Pipeline":
+			var p v1.Pipeline
+			if err := yaml.Unmarshal(doc.Content, &p); err != nil {
+				return fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+			}
+			pipelineSpec = &p.Spec
+		case "tekton.dev/v1/PipelineRun":
+			var pr v1.PipelineRun
+			if err := yaml.Unmarshal(doc.Content, &pr); err != nil {
+				return fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+			}
+			pipelineSpec = pr.Spec.PipelineSpec
+			annotations = pr.Annotations
+		}
+		if pipelineSpec == nil {
+			continue
+		}
+
+		if described > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "%s %s\n", doc.Kind, doc.Name)
+		taskSpecs := make(map[string]*v1.TaskSpec)
+		for _, provenance := range validator.PipelineTaskProvenances(ctx, *pipelineSpec, runtimeParams.Strings()) {
+			writeProvenance(out, provenance)
+			if provenance.Spec != nil {
+				taskSpecs[provenance.Name] = provenance.Spec
+			}
+		}
+		steps, total := validator.ResourceReport(annotations, *pipelineSpec, taskSpecs)
+		writeResourceReport(out, steps, total)
+		described++
+	}
+	if described == 0 {
+		return fmt.Errorf("%s: no Pipeline, nor PipelineRun embedding a pipeline spec, found", fname)
+	}
+	return nil
+}
+
+// writeProvenance writes where the spec of a PipelineTask comes from and its interface
+func writeProvenance(out io.Writer, provenance validator.Provenance) {
+	fmt.Fprintf(out, "  %s (%s)\n", provenance.Name, provenance.Section)
+	fmt.Fprintf(out, "    Source: %s\n", provenance.Source)
+	if provenance.Resolved != "" {
+		fmt.Fprintf(out, "    Resolved: %s\n", provenance.Resolved)
+	}
+	if provenance.Unpinned != "" {
+		fmt.Fprintf(out, "    ⚠️  Not pinned: %s\n", provenance.Unpinned)
+	}
+	if provenance.Err != nil {
+		fmt.Fprintf(out, "    ❌ Not resolved: %v\n", provenance.Err)
+		return
+	}
+
+	spec := provenance.Spec
+	if len(spec.Params) > 0 {
+		fmt.Fprintln(out, "    Params:")
+		for _, param := range spec.Params {
+			fmt.Fprintf(out, "      %s\n", describeParam(param))
+		}
+	}
+	if len(spec.Results) > 0 {
+		fmt.Fprintln(out, "    Results:")
+		for _, result := range spec.Results {
+			resultType := result.Type
+			if resultType == "" {
+				resultType = v1.ResultsTypeString
+			}
+			fmt.Fprintf(out, "      %s (%s)\n", result.Name, resultType)
+		}
+	}
+	if len(spec.Workspaces) > 0 {
+		fmt.Fprintln(out, "    Workspaces:")
+		for _, workspace := range spec.Workspaces {
+			if workspace.Optional {
+				fmt.Fprintf(out, "      %s (optional)\n", workspace.Name)
+			} else {
+				fmt.Fprintf(out, "      %s\n", workspace.Name)
+			}
+		}
+	}
+}
+
+// writeResourceReport writes the compute resources of the steps of a pipeline, with the resource
+// override annotations of the PipelineRun applied, and their total. Nothing is written if no step
+// sets compute resources.
+func writeResourceReport(out io.Writer, steps []validator.StepResources, total corev1.ResourceRequirements) {
+	if len(total.Requests) == 0 && len(total.Limits) == 0 {
+		return
+	}
+	fmt.Fprintln(out, "  Compute resources:")
+	for _, step := range steps {
+		if len(step.Resources.Requests) == 0 && len(step.Resources.Limits) == 0 {
+			continue
+		}
+		line := fmt.Sprintf("    %s/%s: %s", step.PipelineTask, step.Step, describeResources(step.Resources))
+		if step.Overridden != "" {
+			line += fmt.Sprintf(" (overridden by %s)", step.Overridden)
+		}
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprintf(out, "    Total: %s\n", describeResources(total))
+}
+
+// describeResources returns the requests and limits of compute resources, e.g. "requests cpu=1,
+// memory=4Gi; limits memory=8Gi"
+func describeResources(resources corev1.ResourceRequirements) string {
+	var parts []string
+	for _, list := range []struct {
+		name      string
+		resources corev1.ResourceList
+	}{
+		{name: "requests", resources: resources.Requests},
+		{name: "limits", resources: resources.Limits},
+	} {
+		if len(list.resources) == 0 {
+			continue
+		}
+		var quantities []string
+		for _, name := range validator.SortedKeys(list.resources) {
+			quantity := list.resources[name]
+			quantities = append(quantities, fmt.Sprintf("%s=%s", name, quantity.String()))
+		}
+		parts = append(parts, list.name+" "+strings.Join(quantities, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// describeParam returns the name of a param along with its type, and its default or whether it is
+// required
+func describeParam(param v1.ParamSpec) string {
+	paramType := param.Type
+	if paramType == "" {
+		paramType = v1.ParamTypeString
+	}
+	details := []string{string(paramType)}
+	if param.Default == nil {
+		details = append(details, "required")
+	} else if def, err := json.Marshal(param.Default); err == nil {
+		details = append(details, "default "+string(def))
+	}
+	return fmt.Sprintf("%s (%s)", param.Name, strings.Join(details, ", "))
+}
@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+func TestDescribe(t *testing.T) {
+	dir := t.TempDir()
+	tasksDir := filepath.Join(dir, "tasks")
+	require.NoError(t, os.Mkdir(tasksDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tasksDir, "build.yaml"), []byte(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: tags
+      type: array
+      default: [latest]
+  results:
+    - name: digest
+  workspaces:
+    - name: source
+    - name: cache
+      optional: true
+  steps:
+    - name: build
+      image: alpine:latest
+`), 0o600))
+	fname := filepath.Join(dir, "pipeline.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+    - name: deploy
+      taskRef:
+        name: deploy
+  finally:
+    - name: notify
+      taskSpec:
+        params:
+          - name: message
+            type: string
+        steps:
+          - name: notify
+            image: alpine:latest
+`), 0o600))
+
+	index, err := buildTaskIndex(context.Background(), []string{tasksDir})
+	require.NoError(t, err)
+	ctx := validator.WithOptions(context.Background(), validator.Options{TaskIndex: index})
+
+	var out bytes.Buffer
+	require.NoError(t, describe(ctx, fname, nil, &out))
+	assert.Equal(t, `Pipeline release
+  build (tasks)
+    Source: `+filepath.Join(tasksDir, "build.yaml")+`:1 (Task build)
+    Params:
+      tags (array, default ["latest"])
+    Results:
+      digest (string)
+    Workspaces:
+      source
+      cache (optional)
+  deploy (tasks)
+    Source: Task deploy from the cluster
+    ❌ Not resolved: Task "deploy" not found in task directories
+  notify (finally)
+    Source: embedded
+    Params:
+      message (string, required)
+`, out.String())
+
+	err = describe(ctx, filepath.Join(tasksDir, "build.yaml"), nil, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Pipeline, nor PipelineRun embedding a pipeline spec, found")
+}
+
+func TestDescribeComputeResources(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "pipelinerun.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(`apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+  annotations:
+    build.appstudio.openshift.io/compute-resources.build.build: limits.memory=8Gi
+spec:
+  pipelineSpec:
+    tasks:
+      - name: build
+        taskSpec:
+          stepTemplate:
+            computeResources:
+              requests:
+                cpu: 500m
+          steps:
+            - name: build
+              image: alpine:latest
+              computeResources:
+                requests:
+                  memory: 4Gi
+            - name: push
+              image: alpine:latest
+`), 0o600))
+
+	var out bytes.Buffer
+	require.NoError(t, describe(context.Background(), fname, nil, &out))
+	assert.Equal(t, `PipelineRun build
+  build (tasks)
+    Source: embedded
+  Compute resources:
+    build/build: requests cpu=500m, memory=4Gi; limits memory=8Gi (overridden by build.appstudio.openshift.io/compute-resources.build.build)
+    build/push: requests cpu=500m
+    Total: requests cpu=1, memory=4Gi; limits memory=8Gi
+`, out.String())
+}
@@ -0,0 +1,146 @@
+package validate
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/diff"
+	"github.com/lcarva/tektor/internal/document"
+	"github.com/lcarva/tektor/internal/logging"
+)
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff OLD NEW",
+	Short: "Report the breaking changes between two versions of Pipelines and Tasks",
+	Long: `Report the changes between two versions of the Pipelines and Tasks of a file which break
+their users, failing if there is any:
+- Pipelines and Tasks removed
+- Params removed, retyped, or losing their default, and params added without a default
+- Results removed or retyped
+- Workspaces removed or renamed, made required, and required workspaces added
+
+Resources are matched by kind and name. Resources only found in the new version are not breaking.`,
+	Example: `  # Gate the changes of a catalog Task
+  git show main:task/build/build.yaml > /tmp/build.yaml
+  tektor diff /tmp/build.yaml task/build/build.yaml`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.SetFlags(0)
+		return diffFiles(cmd.Context(), args[0], args[1])
+	},
+}
+
+// interfaceSpec is the spec of a Pipeline or Task, which defines its interface
+type interfaceSpec struct {
+	kind         string
+	name         string
+	pipelineSpec *v1.PipelineSpec
+	taskSpec     *v1.TaskSpec
+}
+
+func (s interfaceSpec) String() string {
+	return fmt.Sprintf("%s %s", s.kind, s.name)
+}
+
+// diffFiles returns the breaking changes between the Pipelines and Tasks of two files as errors
+func diffFiles(ctx context.Context, oldFile, newFile string) error {
+	oldSpecs, keys, err := interfaceSpecs(ctx, oldFile)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("%s: no Pipeline or Task found", oldFile)
+	}
+	newSpecs, _, err := interfaceSpecs(ctx, newFile)
+	if err != nil {
+		return err
+	}
+
+	var changesErr error
+	for _, key := range keys {
+		oldSpec := oldSpecs[key]
+		newSpec, ok := newSpecs[key]
+		if !ok {
+			changesErr = multierror.Append(changesErr, fmt.Errorf("%s was removed", oldSpec))
+			continue
+		}
+		var changes []diff.Change
+		if oldSpec.pipelineSpec != nil {
+			changes = diff.Pipelines(*oldSpec.pipelineSpec, *newSpec.pipelineSpec)
+		} else {
+			changes = diff.Tasks(*oldSpec.taskSpec, *newSpec.taskSpec)
+		}
+		for _, change := range changes {
+			changesErr = multierror.Append(changesErr, fmt.Errorf("%s: %s", oldSpec, change))
+		}
+	}
+	if changesErr != nil {
+		return changesErr
+	}
+	logging.Infof("✅ No breaking changes from %s to %s", oldFile, newFile)
+	return nil
+}
+
+// interfaceSpecs returns the specs of the Pipelines and Tasks of a file, by kind and name, along with
+// the keys in the order the resources are declared. v1beta1 resources are converted to v1.
+func interfaceSpecs(ctx context.Context, fname string) (map[string]interfaceSpec, []string, error) {
+	docs, err := document.SplitFile(fname)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	specs := map[string]interfaceSpec{}
+	var keys []string
+	for _, doc := range docs {
+		spec := interfaceSpec{kind: doc.Kind, name: doc.Name}
+		switch doc.Key() {
+		case "tekton.dev/v1/Pipeline":
+			var p v1.Pipeline
+			if err := yaml.Unmarshal(doc.Content, &p); err != nil {
+				return nil, nil, fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+			}
+			spec.pipelineSpec = &p.Spec
+		case "tekton.dev/v1beta1/Pipeline":
+			var p v1beta1.Pipeline
+			if err := yaml.Unmarshal(doc.Content, &p); err != nil {
+				return nil, nil, fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+			}
+			var converted v1.Pipeline
+			if err := p.ConvertTo(ctx, &converted); err != nil {
+				return nil, nil, fmt.Errorf("converting %s to v1: %w", doc, err)
+			}
+			spec.pipelineSpec = &converted.Spec
+		case "tekton.dev/v1/Task":
+			var t v1.Task
+			if err := yaml.Unmarshal(doc.Content, &t); err != nil {
+				return nil, nil, fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+			}
+			spec.taskSpec = &t.Spec
+		case "tekton.dev/v1beta1/Task":
+			var t v1beta1.Task
+			if err := yaml.Unmarshal(doc.Content, &t); err != nil {
+				return nil, nil, fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+			}
+			var converted v1.Task
+			if err := t.ConvertTo(ctx, &converted); err != nil {
+				return nil, nil, fmt.Errorf("converting %s to v1: %w", doc, err)
+			}
+			spec.taskSpec = &converted.Spec
+		default:
+			continue
+		}
+		key := fmt.Sprintf("%s/%s", doc.Kind, doc.Name)
+		if _, ok := specs[key]; !ok {
+			keys = append(keys, key)
+		}
+		specs[key] = spec
+	}
+	return specs, keys, nil
+}
@@ -0,0 +1,103 @@
+package validate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		fname := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(fname, []byte(content), 0o600))
+		return fname
+	}
+
+	oldFile := write("old.yaml", `apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: string
+  results:
+    - name: digest
+  steps:
+    - name: build
+      image: alpine:latest
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  workspaces:
+    - name: source
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`)
+	compatibleFile := write("compatible.yaml", `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: string
+    - name: revision
+      type: string
+      default: main
+  results:
+    - name: digest
+  steps:
+    - name: build
+      image: alpine:latest
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  workspaces:
+    - name: source
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`)
+	breakingFile := write("breaking.yaml", `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: array
+  steps:
+    - name: build
+      image: alpine:latest
+`)
+
+	ctx := context.Background()
+	require.NoError(t, diffFiles(ctx, oldFile, compatibleFile))
+	require.NoError(t, diffFiles(ctx, oldFile, oldFile))
+
+	err := diffFiles(ctx, oldFile, breakingFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "3 errors occurred")
+	assert.Contains(t, err.Error(), "Task build: url param changed type from string to array: spec.params[0]")
+	assert.Contains(t, err.Error(), "Task build: digest result was removed: spec.results[0]")
+	assert.Contains(t, err.Error(), "Pipeline release was removed")
+
+	err = diffFiles(ctx, write("empty.yaml", ""), breakingFile)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Pipeline or Task found")
+}
@@ -0,0 +1,69 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcarva/tektor/internal/docs"
+)
+
+var docsOutput string
+
+var DocsCmd = &cobra.Command{
+	Use:   "docs FILE",
+	Short: "Generate the Markdown documentation of Pipelines and Tasks",
+	Long: `Generate the Markdown documentation of the Pipelines and Tasks of a file, e.g. for the README
+of a catalog. The documentation of each resource holds its description and tables of:
+- Its params, with their type, default, and description
+- Its workspaces and results
+- The PipelineTasks of Pipelines, with where their Task comes from and what they run after
+- The steps of Tasks, with their image`,
+	Example: `  # Generate the README of a catalog Task
+  tektor docs task/build/build.yaml -o task/build/README.md`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		content, err := generateDocs(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		if docsOutput != "" {
+			return os.WriteFile(docsOutput, content, 0o644)
+		}
+		_, err = cmd.OutOrStdout().Write(content)
+		return err
+	},
+}
+
+func init() {
+	DocsCmd.Flags().StringVarP(&docsOutput, "output", "o", "",
+		"File to write the documentation to, instead of stdout")
+}
+
+// generateDocs returns the documentation of the Pipelines and Tasks of a file
+func generateDocs(ctx context.Context, fname string) ([]byte, error) {
+	specs, keys, err := interfaceSpecs(ctx, fname)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no Pipeline or Task found", fname)
+	}
+
+	var buf bytes.Buffer
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		spec := specs[key]
+		if spec.pipelineSpec != nil {
+			buf.WriteString(docs.Pipeline(spec.name, *spec.pipelineSpec))
+		} else {
+			buf.WriteString(docs.Task(spec.name, *spec.taskSpec))
+		}
+	}
+	return buf.Bytes(), nil
+}
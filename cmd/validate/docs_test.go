@@ -0,0 +1,47 @@
+package validate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "resources.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(`apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: build
+spec:
+  description: Builds the image
+  steps:
+    - name: build
+      image: alpine:latest
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`), 0o600))
+
+	content, err := generateDocs(context.Background(), fname)
+	require.NoError(t, err)
+	assert.Equal(t, "# build\n\nBuilds the image\n\n## Steps\n\n| Name | Image |\n| --- | --- |\n| `build` | `alpine:latest` |\n"+
+		"\n# release\n\n## Tasks\n\n| Name | Task | Run after | Description |\n| --- | --- | --- | --- |\n| `build` | `build` |  |  |\n", string(content))
+
+	empty := filepath.Join(dir, "empty.yaml")
+	require.NoError(t, os.WriteFile(empty, nil, 0o600))
+	_, err = generateDocs(context.Background(), empty)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Pipeline or Task found")
+}
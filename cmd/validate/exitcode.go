@@ -0,0 +1,41 @@
+package validate
+
+import "github.com/lcarva/tektor/internal/config"
+
+// ExitError is the error of a command which exits with Code rather than 1
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// exitError attaches an exit code to err, or returns nil if err is nil
+func exitError(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// exitCode returns the exit code of an outcome of the validation, as given by --exit-code, the
+// configuration file, or config.DefaultExitCodes. Successful validations without warnings, the empty
+// outcome, exit with 0.
+func exitCode(outcome string) int {
+	if outcome == "" {
+		return 0
+	}
+	if code, ok := exitCodeFlags[outcome]; ok {
+		return code
+	}
+	if code, ok := configExitCodes[outcome]; ok {
+		return code
+	}
+	return config.DefaultExitCodes[outcome]
+}
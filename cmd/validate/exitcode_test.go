@@ -0,0 +1,55 @@
+package validate
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lcarva/tektor/internal/config"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    map[string]int
+		config   map[string]int
+		outcome  string
+		expected int
+	}{
+		{name: "success", flags: map[string]int{config.ExitErrors: 4}, expected: 0},
+		{name: "default of errors", outcome: config.ExitErrors, expected: 1},
+		{name: "default of warnings", outcome: config.ExitWarnings, expected: 0},
+		{name: "configured", config: map[string]int{config.ExitWarnings: 3}, outcome: config.ExitWarnings, expected: 3},
+		{
+			name:     "flag overriding the configuration",
+			flags:    map[string]int{config.ExitUnsupported: 2},
+			config:   map[string]int{config.ExitUnsupported: 5},
+			outcome:  config.ExitUnsupported,
+			expected: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldFlags, oldConfig := exitCodeFlags, configExitCodes
+			t.Cleanup(func() { exitCodeFlags, configExitCodes = oldFlags, oldConfig })
+			exitCodeFlags, configExitCodes = tt.flags, tt.config
+
+			assert.Equal(t, tt.expected, exitCode(tt.outcome))
+		})
+	}
+}
+
+func TestExitError(t *testing.T) {
+	assert.NoError(t, exitError(3, nil))
+
+	cause := errors.New("validation passed with 1 warnings")
+	err := exitError(3, cause)
+	var exitErr *ExitError
+	if assert.ErrorAs(t, err, &exitErr) {
+		assert.Equal(t, 3, exitErr.Code)
+	}
+	assert.ErrorIs(t, err, cause)
+	assert.EqualError(t, err, cause.Error())
+}
@@ -0,0 +1,79 @@
+package validate
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcarva/tektor/internal/fix"
+	"github.com/lcarva/tektor/internal/logging"
+)
+
+var fixDryRun bool
+
+var FixCmd = &cobra.Command{
+	Use:   "fix FILE...",
+	Short: "Apply safe automatic fixes to Tekton resources",
+	Long: `Apply safe automatic fixes for a subset of the findings of the validation:
+- Remove the workspaces declared by Pipelines but never used
+- Add the type missing from param declarations, inferred from their default
+- Add the kind param required by the bundles resolver, and by the hub resolver for Pipelines
+
+Files are rewritten in place, preserving comments and the order of fields, unless --dry-run writes
+the fixed YAML to stdout instead. Files with nothing to fix are left untouched.`,
+	Example: `  # Fix a Pipeline
+  tektor fix pipeline.yaml
+
+  # Preview the fixes
+  tektor fix --dry-run pipeline.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.SetFlags(0)
+		for _, fname := range args {
+			if err := fixFile(fname, fixDryRun, cmd.OutOrStdout()); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	FixCmd.Flags().BoolVar(&fixDryRun, "dry-run", false,
+		"Write the fixed YAML to stdout instead of rewriting the files")
+}
+
+// fixFile applies the fixes to a file, rewriting it unless dryRun is set, in which case the fixed
+// content is written to out
+func fixFile(fname string, dryRun bool, out io.Writer) error {
+	info, err := os.Stat(fname)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	fixed, fixes, err := fix.Apply(content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+
+	for _, f := range fixes {
+		logging.Infof("🔧 %s: %s", fname, f)
+	}
+	if len(fixes) == 0 {
+		logging.Infof("✅ Nothing to fix in %s", fname)
+	}
+	if dryRun {
+		_, err := out.Write(fixed)
+		return err
+	}
+	if len(fixes) == 0 {
+		return nil
+	}
+	return os.WriteFile(fname, fixed, info.Mode().Perm())
+}
@@ -0,0 +1,63 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixFile(t *testing.T) {
+	const task = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  params:
+    - name: greeting
+  steps:
+    - name: hello
+      image: alpine:latest
+      script: echo $(params.greeting)
+`
+	const fixedTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  params:
+    - name: greeting
+      type: string
+  steps:
+    - name: hello
+      image: alpine:latest
+      script: echo $(params.greeting)
+`
+	fname := filepath.Join(t.TempDir(), "task.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(task), 0o600))
+
+	var out bytes.Buffer
+	require.NoError(t, fixFile(fname, true, &out))
+	assert.Equal(t, fixedTask, out.String())
+	content, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.Equal(t, task, string(content), "Expected --dry-run to leave the file untouched")
+
+	out.Reset()
+	require.NoError(t, fixFile(fname, false, &out))
+	assert.Empty(t, out.String())
+	content, err = os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.Equal(t, fixedTask, string(content))
+	info, err := os.Stat(fname)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	assert.NoError(t, run(context.Background(), fname, nil), "Expected the fixed Task to be valid")
+
+	require.Error(t, fixFile(filepath.Join(t.TempDir(), "missing.yaml"), false, &out))
+}
@@ -0,0 +1,95 @@
+package validate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcarva/tektor/internal/format"
+	"github.com/lcarva/tektor/internal/logging"
+)
+
+var (
+	fmtCheck  bool
+	fmtStdout bool
+)
+
+var FmtCmd = &cobra.Command{
+	Use:   "fmt FILE...",
+	Short: "Format Tekton resources canonically",
+	Long: `Format the YAML of Tekton resources canonically, so that diffs stay clean across a team:
+- Lead the entries of params, workspaces, and results with their name, then type and description
+- Lead resources with apiVersion, kind, metadata, and spec, and metadata with name
+- Remove null fields, and empty fields such as creationTimestamp: null or metadata: {}
+- Indent with two spaces, including sequences
+
+Files are rewritten in place, preserving comments, unless --check lists the files which are not
+formatted instead, or --stdout writes the formatted YAML to stdout.`,
+	Example: `  # Format the resources of a repository
+  tektor fmt .tekton/*.yaml
+
+  # Fail if a resource is not formatted, e.g. in CI
+  tektor fmt --check .tekton/*.yaml`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.SetFlags(0)
+		var unformatted []string
+		for _, fname := range args {
+			changed, err := formatFile(fname, fmtCheck, fmtStdout, cmd.OutOrStdout())
+			if err != nil {
+				return err
+			}
+			if changed {
+				unformatted = append(unformatted, fname)
+			}
+		}
+		if fmtCheck && len(unformatted) > 0 {
+			return fmt.Errorf("%d file(s) not formatted, run tektor fmt to format them", len(unformatted))
+		}
+		return nil
+	},
+}
+
+func init() {
+	FmtCmd.Flags().BoolVar(&fmtCheck, "check", false,
+		"List the files which are not formatted, failing if any, instead of rewriting them")
+	FmtCmd.Flags().BoolVar(&fmtStdout, "stdout", false,
+		"Write the formatted YAML to stdout instead of rewriting the files")
+}
+
+// formatFile formats a file, rewriting it unless check or stdout is set, and tells whether its
+// formatting changed. With stdout, the formatted content is written to out.
+func formatFile(fname string, check, stdout bool, out io.Writer) (bool, error) {
+	info, err := os.Stat(fname)
+	if err != nil {
+		return false, err
+	}
+	content, err := os.ReadFile(fname)
+	if err != nil {
+		return false, err
+	}
+	formatted, err := format.Format(content)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", fname, err)
+	}
+	changed := !bytes.Equal(content, formatted)
+
+	switch {
+	case check:
+		if changed {
+			logging.Errorf("❌ %s is not formatted", fname)
+		}
+		return changed, nil
+	case stdout:
+		_, err := out.Write(formatted)
+		return changed, err
+	case !changed:
+		return false, nil
+	}
+	logging.Infof("🎨 Formatted %s", fname)
+	return true, os.WriteFile(fname, formatted, info.Mode().Perm())
+}
@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFile(t *testing.T) {
+	const task = `kind: Task
+apiVersion: tekton.dev/v1
+metadata:
+  creationTimestamp: null
+  name: hello
+spec:
+  params:
+  - type: string
+    name: greeting
+  steps:
+  - name: hello
+    image: alpine:latest
+`
+	const formattedTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  params:
+    - name: greeting
+      type: string
+  steps:
+    - name: hello
+      image: alpine:latest
+`
+	fname := filepath.Join(t.TempDir(), "task.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(task), 0o600))
+
+	var out bytes.Buffer
+	changed, err := formatFile(fname, true, false, &out)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, out.String())
+
+	changed, err = formatFile(fname, false, true, &out)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, formattedTask, out.String())
+	content, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.Equal(t, task, string(content), "Expected --check and --stdout to leave the file untouched")
+
+	out.Reset()
+	changed, err = formatFile(fname, false, false, &out)
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Empty(t, out.String())
+	content, err = os.ReadFile(fname)
+	require.NoError(t, err)
+	assert.Equal(t, formattedTask, string(content))
+	info, err := os.Stat(fname)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	changed, err = formatFile(fname, true, false, &out)
+	require.NoError(t, err)
+	assert.False(t, changed, "Expected the formatted file to pass --check")
+
+	_, err = formatFile(filepath.Join(t.TempDir(), "missing.yaml"), false, false, &out)
+	require.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package validate
+
+import (
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/spf13/cobra"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/document"
+	"github.com/lcarva/tektor/internal/graph"
+	"github.com/lcarva/tektor/internal/logging"
+)
+
+var graphFormat string
+
+var GraphCmd = &cobra.Command{
+	Use:   "graph FILE",
+	Short: "Render the dependency graph of the tasks of a Pipeline",
+	Long: `Render the dependency graph of the PipelineTasks of the Pipelines, and the PipelineRuns
+embedding a pipeline spec, of a file. PipelineTasks depend on the ones they run after, and on the
+ones whose results they refer to, in params or when expressions. Finally tasks are grouped, and
+PipelineTasks isolated from all the others are highlighted and reported.`,
+	Example: `  # Render a Pipeline with Graphviz
+  tektor graph pipeline.yaml | dot -Tsvg > pipeline.svg
+
+  # Render a Pipeline as a Mermaid flowchart, e.g. for a Markdown document
+  tektor graph --format mermaid pipeline.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.SetFlags(0)
+		return graphFile(args[0], graphFormat, cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	GraphCmd.Flags().StringVar(&graphFormat, "format", "dot", "Format of the graph, dot or mermaid")
+}
+
+// graphFile writes the dependency graphs of the pipelines of a file to out in the given format
+func graphFile(fname, format string, out io.Writer) error {
+	if format != "dot" && format != "mermaid" {
+		return fmt.Errorf("unknown format %q, expected dot or mermaid", format)
+	}
+	docs, err := document.SplitFile(fname)
+	if err != nil {
+		return err
+	}
+
+	rendered := 0
+	for _, doc := range docs {
+		var pipelineSpec *v1.PipelineSpec
+		switch doc.Key() {
+		case "tekton.dev/v1/Pipeline":
+			var p v1.Pipeline
+			if err := yaml.Unmarshal(doc.Content, &p); err != nil {
+				return fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+			}
+			pipelineSpec = &p.Spec
+		case "tekton.dev/v1/PipelineRun":
+			var pr v1.PipelineRun
+			if err := yaml.Unmarshal(doc.Content, &pr); err != nil {
+				return fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+			}
+			pipelineSpec = pr.Spec.PipelineSpec
+		}
+		if pipelineSpec == nil {
+			continue
+		}
+
+		g := graph.New(*pipelineSpec)
+		for _, task := range g.Isolated() {
+			logging.Warnf("⚠️  %s: %s PipelineTask is isolated, it neither depends on nor is depended on by another PipelineTask", doc, task)
+		}
+		if rendered > 0 {
+			fmt.Fprintln(out)
+		}
+		if format == "mermaid" {
+			_, err = io.WriteString(out, g.Mermaid())
+		} else {
+			_, err = io.WriteString(out, g.DOT(doc.Name))
+		}
+		if err != nil {
+			return err
+		}
+		rendered++
+	}
+	if rendered == 0 {
+		return fmt.Errorf("%s: no Pipeline, nor PipelineRun embedding a pipeline spec, found", fname)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package validate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphFile(t *testing.T) {
+	const resources = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        name: git-clone
+    - name: build
+      runAfter: [clone]
+      taskRef:
+        name: buildah
+---
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: git-clone
+spec:
+  steps:
+    - name: clone
+      image: alpine:latest
+---
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: release
+spec:
+  pipelineSpec:
+    tasks:
+      - name: release
+        taskRef:
+          name: release
+`
+	fname := filepath.Join(t.TempDir(), "pipeline.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(resources), 0o600))
+
+	var out bytes.Buffer
+	require.NoError(t, graphFile(fname, "mermaid", &out))
+	assert.Equal(t, `flowchart LR
+  t0["clone"]
+  t1["build"]
+  t0 --> t1
+
+flowchart LR
+  t0["release"]
+`, out.String())
+
+	out.Reset()
+	require.NoError(t, graphFile(fname, "dot", &out))
+	assert.Contains(t, out.String(), `digraph "build" {`)
+	assert.Contains(t, out.String(), `digraph "release" {`)
+
+	err := graphFile(fname, "svg", &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown format "svg", expected dot or mermaid`)
+
+	taskFile := filepath.Join(t.TempDir(), "task.yaml")
+	require.NoError(t, os.WriteFile(taskFile, []byte("apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: git-clone\n"), 0o600))
+	err = graphFile(taskFile, "dot", &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no Pipeline, nor PipelineRun embedding a pipeline spec, found")
+}
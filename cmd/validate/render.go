@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/spf13/cobra"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/document"
+	"github.com/lcarva/tektor/internal/format"
+	"github.com/lcarva/tektor/internal/logging"
+	"github.com/lcarva/tektor/internal/pac"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+var renderOutput string
+
+var RenderCmd = &cobra.Command{
+	Use:   "render FILE",
+	Short: "Print the fully resolved PipelineRuns of a file",
+	Long: `Print the PipelineRuns of a file as they are once fully resolved:
+- Pipelines as Code annotations are resolved, as when validating
+- Runtime parameter values given with --param are substituted
+- The Pipeline referred to with pipelineRef is embedded as the pipelineSpec
+- The Tasks referred to with taskRef are embedded as taskSpec, whether they are resolved through the
+  bundles, git, or hub resolver, or by name from --task-dir directories
+
+Custom Tasks and child Pipelines are left as they are.`,
+	Example: `  # Print the resolved PipelineRun
+  tektor render .tekton/pull-request.yaml
+
+  # Write the resolved PipelineRun to a file
+  tektor render .tekton/pull-request.yaml -o /tmp/pull-request.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		log.SetFlags(0)
+		ctx, params, _, err := setup(cmd.Context(), nil)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := render(ctx, args[0], params, &buf); err != nil {
+			return err
+		}
+		if renderOutput != "" {
+			return os.WriteFile(renderOutput, buf.Bytes(), 0o644)
+		}
+		_, err = cmd.OutOrStdout().Write(buf.Bytes())
+		return err
+	},
+}
+
+func init() {
+	addValidationFlags(RenderCmd)
+	RenderCmd.Flags().StringVarP(&renderOutput, "output", "o", "",
+		"File to write the resolved PipelineRuns to, instead of stdout")
+}
+
+// render writes the fully resolved PipelineRuns of a file to out
+func render(ctx context.Context, fname string, runtimeParams validator.RuntimeParams, out io.Writer) error {
+	docs, err := document.SplitFile(fname)
+	if err != nil {
+		return err
+	}
+
+	var rendered []document.Document
+	var allErrors error
+	for _, doc := range docs {
+		if doc.Kind != "PipelineRun" {
+			continue
+		}
+		content, err := renderPipelineRun(ctx, doc, runtimeParams)
+		if err != nil {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("%s: %w", doc, err))
+			continue
+		}
+		rendered = append(rendered, document.Document{Content: content})
+	}
+	if allErrors != nil {
+		return allErrors
+	}
+	if len(rendered) == 0 {
+		return fmt.Errorf("%s: no PipelineRun found", fname)
+	}
+	_, err = out.Write(document.Join(rendered))
+	return err
+}
+
+// renderPipelineRun resolves a PipelineRun with Pipelines as Code, substitutes the runtime parameter
+// values, and embeds the Pipeline and Tasks it refers to
+func renderPipelineRun(ctx context.Context, doc document.Document, runtimeParams validator.RuntimeParams) ([]byte, error) {
+	if doc.Err != nil {
+		return nil, doc.Err
+	}
+	logging.Infof("Rendering %s", doc)
+	f, err := pac.ResolvePipelineRun(ctx, doc.Source, doc.Name, pacOptions())
+	if err != nil {
+		return nil, fmt.Errorf("resolving with PAC: %w", err)
+	}
+	ctx, err = withPaCTaskIndex(ctx, doc.Source)
+	if err != nil {
+		return nil, fmt.Errorf("indexing PAC tasks: %w", err)
+	}
+	ctx = validator.WithBaseDir(ctx, filepath.Dir(doc.Source))
+	if len(runtimeParams) > 0 {
+		f = substituteParameters(f, runtimeParams)
+	}
+
+	var pr v1.PipelineRun
+	if err := yaml.Unmarshal(f, &pr); err != nil {
+		return nil, fmt.Errorf("unmarshalling as %s: %w", doc.Key(), err)
+	}
+	pr, err = validator.InlinePipelineRun(ctx, pr, runtimeParams.Strings())
+	if err != nil {
+		return nil, err
+	}
+	content, err := yaml.Marshal(pr)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling the resolved PipelineRun: %w", err)
+	}
+	return format.Clean(content)
+}
@@ -0,0 +1,113 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	dir := t.TempDir()
+	tektonDir := filepath.Join(dir, ".tekton")
+	require.NoError(t, os.Mkdir(tektonDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(tektonDir, "build.yaml"), []byte(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: image
+      type: string
+  steps:
+    - name: build
+      image: alpine:latest
+      script: echo $(params.image)
+`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(tektonDir, "pipeline.yaml"), []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  params:
+    - name: image
+      type: string
+  tasks:
+    - name: build
+      params:
+        - name: image
+          value: $(params.image)
+      taskRef:
+        name: build
+`), 0o600))
+	fname := filepath.Join(tektonDir, "run.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(`apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: release-run
+spec:
+  params:
+    - name: image
+      value: quay.io/example/app:latest
+  pipelineRef:
+    name: release
+`), 0o600))
+	commitRepository(t, dir)
+
+	var out bytes.Buffer
+	require.NoError(t, render(context.Background(), fname, nil, &out))
+	assert.Equal(t, `---
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  labels:
+    pipelinesascode.tekton.dev/original-prname: release-run
+  name: release-run
+spec:
+  params:
+    - name: image
+      value: quay.io/example/app:latest
+  pipelineSpec:
+    params:
+      - name: image
+        type: string
+    tasks:
+      - name: build
+        params:
+          - name: image
+            value: $(params.image)
+        taskSpec:
+          params:
+            - name: image
+              type: string
+          steps:
+            - image: alpine:latest
+              name: build
+              script: echo $(params.image)
+`, out.String())
+}
+
+func TestRenderWithoutPipelineRun(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "task.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  steps:
+    - name: build
+      image: alpine:latest
+`), 0o600))
+
+	var out bytes.Buffer
+	err := render(context.Background(), fname, nil, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no PipelineRun found")
+	assert.Empty(t, out.String())
+
+	require.Error(t, render(context.Background(), filepath.Join(t.TempDir(), "missing.yaml"), nil, &out))
+}
@@ -0,0 +1,211 @@
+package validate
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/spf13/cobra"
+	"github.com/tektoncd/pipeline/pkg/resolution/resolver/bundle"
+
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+//go:embed selftest/*.yaml
+var selftestFixtures embed.FS
+
+// registryPlaceholder is replaced in the fixtures with the address of the mock bundle registry
+const registryPlaceholder = "{{registry}}"
+
+// selftestCase is a resource of the self-test suite along with the outcome expected from validating it
+type selftestCase struct {
+	Name   string
+	File   string // Fixture within the selftest directory
+	Error  string // Part of the expected error, or empty if the resource is valid
+	Remote bool   // Whether the resource resolves Tasks from remote services rather than mocks
+}
+
+var selftestCases = []selftestCase{
+	{Name: "valid Task", File: "task.yaml"},
+	{Name: "Task using an undeclared parameter", File: "task-undeclared-param.yaml",
+		Error: `non-existent variable in "echo \"Hello $(params.name)\"\n": spec.steps[0].script`},
+	{Name: "valid Pipeline", File: "pipeline.yaml"},
+	{Name: "Pipeline using a missing result", File: "pipeline-missing-result.yaml",
+		Error: "non-existent farewell result from greet PipelineTask"},
+	{Name: "Pipeline with a Task from a bundle", File: "bundle-pipeline.yaml"},
+	{Name: "Pipeline missing a parameter of a Task from a bundle", File: "bundle-pipeline-missing-param.yaml",
+		Error: `"name" parameter is required`},
+	{Name: "Pipeline with a Task from a git repository", File: "git-pipeline.yaml", Remote: true},
+}
+
+var selftestRemote bool
+
+var SelftestCmd = &cobra.Command{
+	Use:   "selftest",
+	Short: "Verify tektor works by validating a bundled suite of resources",
+	Long: `Validate a suite of known-good and known-bad resources bundled with tektor, and report whether
+each validation has the expected outcome.
+
+Tasks referenced from bundles are resolved from a mock registry served locally. With --remote, Tasks
+are also resolved from public git repositories, which verifies network access, proxies, and
+credentials are functional before wiring tektor into CI. The credentials are those of the
+configuration file, and of the environment with --git-env-tokens.`,
+	Example: `  # Verify the installation
+  tektor selftest
+
+  # Also verify remote Tasks can be resolved
+  tektor selftest --remote`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSelftest(cmd.Context(), cmd.OutOrStdout(), selftestRemote)
+	},
+}
+
+func init() {
+	SelftestCmd.Flags().BoolVar(&selftestRemote, "remote", false,
+		"Also resolve Tasks from remote services, which requires network access")
+	SelftestCmd.Flags().StringVar(&configFile, "config", "",
+		fmt.Sprintf("Configuration file, defaults to $%s or to %s if it exists", config.FileEnv, config.DefaultFile))
+	SelftestCmd.Flags().BoolVar(&gitEnvTokens, "git-env-tokens", false,
+		"Authenticate the HTTPS URLs of github.com and gitlab.com without configured credentials with GITHUB_TOKEN and GITLAB_TOKEN")
+}
+
+func runSelftest(ctx context.Context, out io.Writer, withRemote bool) error {
+	// The logs of the validations would be mistaken for the results of the suite.
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	registryHost, stop, err := startBundleRegistry()
+	if err != nil {
+		return fmt.Errorf("starting mock bundle registry: %w", err)
+	}
+	defer stop()
+
+	dir, err := os.MkdirTemp("", "tektor-selftest-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	opts, err := selftestOptions()
+	if err != nil {
+		return err
+	}
+	ctx = validator.WithOptions(ctx, opts)
+
+	failed, total := 0, 0
+	for _, tc := range selftestCases {
+		if tc.Remote && !withRemote {
+			continue
+		}
+		total++
+		if err := runSelftestCase(ctx, dir, registryHost, tc); err != nil {
+			failed++
+			fmt.Fprintf(out, "❌ FAIL %s: %v\n", tc.Name, err)
+			continue
+		}
+		fmt.Fprintf(out, "✅ PASS %s\n", tc.Name)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d self-test(s) failed", failed, total)
+	}
+	fmt.Fprintf(out, "All %d self-tests passed\n", total)
+	return nil
+}
+
+// selftestOptions returns the validation options of the self-test suite. Tasks are resolved the same
+// way regardless of the flags of other commands, but with the credentials of the configuration, as
+// setup does, since the suite verifies they are functional.
+func selftestOptions() (validator.Options, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return validator.Options{}, err
+	}
+	return validator.Options{Credentials: cfg.Credentials, GitEnvTokens: gitEnvTokens}, nil
+}
+
+// runSelftestCase validates the fixture of tc, returning an error if the outcome is not the expected one
+func runSelftestCase(ctx context.Context, dir, registryHost string, tc selftestCase) error {
+	content, err := selftestFixtures.ReadFile("selftest/" + tc.File)
+	if err != nil {
+		return err
+	}
+	fname := filepath.Join(dir, tc.File)
+	content = []byte(strings.ReplaceAll(string(content), registryPlaceholder, registryHost))
+	if err := os.WriteFile(fname, content, 0o644); err != nil {
+		return err
+	}
+
+	results, err := validateFile(ctx, fname, nil)
+	if err != nil {
+		return err
+	}
+	var validationErrs []string
+	for _, result := range results {
+		for _, finding := range result.errors() {
+			validationErrs = append(validationErrs, finding.String())
+		}
+	}
+
+	switch {
+	case tc.Error == "" && len(validationErrs) > 0:
+		return fmt.Errorf("expected no error, got: %s", strings.Join(validationErrs, "; "))
+	case tc.Error != "" && len(validationErrs) == 0:
+		return fmt.Errorf("expected error %q, got none", tc.Error)
+	case tc.Error != "" && !strings.Contains(strings.Join(validationErrs, "\n"), tc.Error):
+		return fmt.Errorf("expected error %q, got: %s", tc.Error, strings.Join(validationErrs, "; "))
+	}
+	return nil
+}
+
+// startBundleRegistry serves a registry holding the Task of the selftest/bundle-task.yaml fixture
+// in the selftest/greet:latest bundle. It returns the host of the registry and a function stopping it.
+func startBundleRegistry() (string, func(), error) {
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	host := strings.TrimPrefix(server.URL, "http://")
+	if err := pushSelftestBundle(host); err != nil {
+		server.Close()
+		return "", nil, err
+	}
+	return host, server.Close, nil
+}
+
+// pushSelftestBundle pushes the Task of the selftest/bundle-task.yaml fixture to the
+// selftest/greet:latest bundle of the registry at host
+func pushSelftestBundle(host string, options ...remote.Option) error {
+	task, err := selftestFixtures.ReadFile("selftest/bundle-task.yaml")
+	if err != nil {
+		return err
+	}
+	img, err := mutate.Append(empty.Image, mutate.Addendum{
+		Layer: static.NewLayer(task, types.MediaType("application/vnd.tekton.task+yaml")),
+		Annotations: map[string]string{
+			bundle.BundleAnnotationAPIVersion: "tekton.dev/v1",
+			bundle.BundleAnnotationKind:       "task",
+			bundle.BundleAnnotationName:       "greet",
+		},
+	})
+	if err != nil {
+		return err
+	}
+	ref, err := name.ParseReference(host + "/selftest/greet:latest")
+	if err != nil {
+		return err
+	}
+	return remote.Write(ref, img, options...)
+}
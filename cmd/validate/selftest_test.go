@@ -0,0 +1,108 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+func TestRunSelftest(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, runSelftest(context.Background(), &out, false))
+	assert.Contains(t, out.String(), "✅ PASS Pipeline with a Task from a bundle\n")
+	assert.Contains(t, out.String(), "✅ PASS Pipeline missing a parameter of a Task from a bundle\n")
+	assert.NotContains(t, out.String(), "git repository")
+	assert.Contains(t, out.String(), "All 6 self-tests passed\n")
+}
+
+func TestSelftestOptions(t *testing.T) {
+	// The registry only serves the bundle to the credentials of the configuration.
+	handler := registry.New(registry.Logger(log.New(io.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "ci" || password != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="selftest"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	require.NoError(t, pushSelftestBundle(host, remote.WithAuth(&authn.Basic{Username: "ci", Password: "secret"})))
+
+	dir := t.TempDir()
+	previous := configFile
+	t.Cleanup(func() {
+		configFile = previous
+	})
+	configFile = filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`credentials:
+  registries:
+    - registry: `+host+`
+      username: ci
+      password: secret
+`), 0o600))
+
+	opts, err := selftestOptions()
+	require.NoError(t, err)
+	tc := selftestCase{File: "bundle-pipeline.yaml"}
+	assert.NoError(t, runSelftestCase(validator.WithOptions(context.Background(), opts), dir, host, tc))
+	assert.ErrorContains(t, runSelftestCase(validator.WithOptions(context.Background(), validator.Options{}), dir, host, tc),
+		"401 Unauthorized")
+}
+
+func TestRunSelftestCase(t *testing.T) {
+	ctx := validator.WithOptions(context.Background(), validator.Options{})
+	tests := []struct {
+		name          string
+		tc            selftestCase
+		errorContains string
+	}{
+		{
+			name: "expected error",
+			tc:   selftestCase{File: "task-undeclared-param.yaml", Error: "non-existent variable"},
+		},
+		{
+			name:          "unexpected error",
+			tc:            selftestCase{File: "task-undeclared-param.yaml"},
+			errorContains: "expected no error, got: non-existent variable",
+		},
+		{
+			name:          "missing error",
+			tc:            selftestCase{File: "task.yaml", Error: "non-existent variable"},
+			errorContains: `expected error "non-existent variable", got none`,
+		},
+		{
+			name:          "other error",
+			tc:            selftestCase{File: "task-undeclared-param.yaml", Error: "non-existent result"},
+			errorContains: `expected error "non-existent result", got: non-existent variable`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runSelftestCase(ctx, t.TempDir(), "localhost", tt.tc)
+			if tt.errorContains == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errorContains)
+		})
+	}
+}
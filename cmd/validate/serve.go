@@ -0,0 +1,216 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lcarva/tektor/internal/logging"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+// paramHeader carries runtime parameter values, in the key=value format, to the validate endpoint
+const paramHeader = "X-Tektor-Param"
+
+// requestSource replaces the path of the temporary file holding a request body in findings
+const requestSource = "request"
+
+var (
+	serveAddr     string
+	trustRequests bool
+)
+
+var ServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the validation over HTTP",
+	Long: `Serve the validation over HTTP, so that web UIs and bots can validate Tekton resources without
+installing tektor.
+
+POST /validate validates the YAML resources of the request body like the validate command validates
+a file. Runtime parameter values are given in the key=value format with the param query parameter or
+the X-Tektor-Param header, both of which can be repeated. The response is a JSON object holding the
+findings of the validation, and whether the resources are valid:
+
+  {"valid": false, "findings": [{"rule": "TEK0201", "severity": "error", "message": "...", "line": 1}]}
+
+The validation flags apply to every request. Requests are validated without the credentials, the
+cluster objects, the Pipelines of --cluster, the plugins of the configuration, and the cached
+resolutions unless --trust-requests is given, since anyone able to reach the server could otherwise
+have them resolve or verify arbitrary references. GET /healthz reports whether the server is up.`,
+	Example: `  # Serve on port 8080 of the loopback interface
+  tektor serve --addr 127.0.0.1:8080
+
+  # Validate a Pipeline
+  curl --data-binary @pipeline.yaml 'http://localhost:8080/validate?param=gitUrl=https://github.com/example/repo.git'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, params, _, err := setup(cmd.Context(), nil)
+		if err != nil {
+			return err
+		}
+		if !trustRequests {
+			ctx = validator.WithUntrustedInput(ctx)
+		}
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return serve(ctx, serveAddr, newServeHandler(ctx, params))
+	},
+}
+
+func init() {
+	addValidationFlags(ServeCmd)
+	ServeCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8080",
+		"Address to listen on, e.g. :8080 to listen on every interface")
+	ServeCmd.Flags().BoolVar(&trustRequests, "trust-requests", false,
+		"Validate requests with the credentials, the cluster objects, and the plugins of the configuration")
+}
+
+// serve serves handler on addr until ctx is done
+func serve(ctx context.Context, addr string, handler http.Handler) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() {
+		logging.Infof("Serving on %s", addr)
+		errc <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	}
+}
+
+// validateResponse is the body of the responses of the validate endpoint
+type validateResponse struct {
+	Valid    bool                `json:"valid"`
+	Findings []validator.Finding `json:"findings"`
+}
+
+// errorResponse is the body of the responses to requests which cannot be validated
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// newServeHandler returns the handler of the server. Requests are validated with the options carried
+// by ctx, and with the runtime parameter values of params unless they override them.
+func newServeHandler(ctx context.Context, params validator.RuntimeParams) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/validate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "only POST is supported"})
+			return
+		}
+
+		requestParams, err := parseParamValues(append(r.URL.Query()["param"], r.Header.Values(paramHeader)...))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("error parsing parameter values: %v", err)})
+			return
+		}
+		runtimeParams := make(validator.RuntimeParams, len(params)+len(requestParams))
+		for key, value := range params {
+			runtimeParams[key] = value
+		}
+		for key, value := range requestParams {
+			runtimeParams[key] = value
+		}
+
+		body := io.Reader(r.Body)
+		if limits.MaxBytes > 0 {
+			body = http.MaxBytesReader(w, r.Body, limits.MaxBytes)
+		}
+		data, err := io.ReadAll(body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				writeJSON(w, http.StatusRequestEntityTooLarge, errorResponse{Error: fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit)})
+				return
+			}
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("reading request body: %v", err)})
+			return
+		}
+
+		// The validation options are carried by ctx, but the validation stops with the request, and
+		// each request is a new run which does not reuse the checkouts of branches and tags.
+		requestCtx, cancel := context.WithCancel(validator.WithNewRun(ctx))
+		defer cancel()
+		stop := context.AfterFunc(r.Context(), cancel)
+		defer stop()
+
+		findings, err := validateRequestBody(requestCtx, data, runtimeParams)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+			return
+		}
+		response := validateResponse{Valid: true, Findings: findings}
+		for _, finding := range findings {
+			if finding.Severity == validator.SeverityError {
+				response.Valid = false
+			}
+		}
+		if response.Findings == nil {
+			response.Findings = []validator.Finding{}
+		}
+		writeJSON(w, http.StatusOK, response)
+	})
+	return mux
+}
+
+// validateRequestBody validates the resources of a request body. Validation goes through a temporary
+// file since resolving PipelineRuns with Pipelines as Code reads them from disk.
+func validateRequestBody(ctx context.Context, data []byte, runtimeParams validator.RuntimeParams) ([]validator.Finding, error) {
+	dir, err := os.MkdirTemp("", "tektor-serve-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "resource.yaml")
+	if err := os.WriteFile(fname, data, 0o600); err != nil {
+		return nil, err
+	}
+
+	results, err := validateFile(ctx, fname, runtimeParams)
+	if err != nil {
+		return nil, err
+	}
+	var findings []validator.Finding
+	for _, result := range results {
+		for _, finding := range result.Findings {
+			finding.Message = strings.ReplaceAll(finding.Message, fname, requestSource)
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// writeJSON writes v as the JSON body of a response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Warnf("⚠️  Writing response: %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lcarva/tektor/internal/cluster"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+const serveTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  params:
+    - name: name
+      type: string
+      enum: ["world", "tekton"]
+  steps:
+    - name: hello
+      image: alpine:latest
+      script: echo hello $(params.name)
+`
+
+const servePipeline = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: hello
+spec:
+  params:
+    - name: name
+      type: string
+  tasks:
+    - name: hello
+      params:
+        - name: name
+          value: $(params.name)
+      taskSpec:
+        params:
+          - name: name
+            type: string
+            enum: ["world", "tekton"]
+        steps:
+          - name: hello
+            image: alpine:latest
+            script: echo hello $(params.name)
+`
+
+func TestServeValidate(t *testing.T) {
+	handler := newServeHandler(context.Background(), validator.StringParams(map[string]string{"name": "world"}))
+
+	tests := []struct {
+		name             string
+		method           string
+		target           string
+		header           http.Header
+		body             string
+		expectedStatus   int
+		expectedValid    bool
+		expectedMessages []string
+		expectedError    string
+	}{
+		{
+			name:           "valid task",
+			target:         "/validate",
+			body:           serveTask,
+			expectedStatus: http.StatusOK,
+			expectedValid:  true,
+		},
+		{
+			name:             "invalid task",
+			target:           "/validate",
+			body:             strings.ReplaceAll(serveTask, "$(params.name)", "$(params.missing)"),
+			expectedStatus:   http.StatusOK,
+			expectedMessages: []string{`non-existent variable in "echo hello $(params.missing)"`},
+		},
+		{
+			name:             "param from the query overriding the flags",
+			target:           "/validate?param=name=nobody",
+			body:             servePipeline,
+			expectedStatus:   http.StatusOK,
+			expectedMessages: []string{`"nobody" is not in the enum`},
+		},
+		{
+			name:           "param from a header",
+			target:         "/validate?param=name=nobody",
+			header:         http.Header{paramHeader: []string{"name=tekton"}},
+			body:           servePipeline,
+			expectedStatus: http.StatusOK,
+			expectedValid:  true,
+		},
+		{
+			name:             "unsupported resource",
+			target:           "/validate",
+			body:             "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n",
+			expectedStatus:   http.StatusOK,
+			expectedMessages: []string{"v1/ConfigMap is not supported"},
+		},
+		{
+			name:             "malformed resource",
+			target:           "/validate",
+			body:             "apiVersion: tekton.dev/v1\nkind: Task\nspec: [\n",
+			expectedStatus:   http.StatusOK,
+			expectedMessages: []string{"unmarshalling request as k8s resource"},
+		},
+		{
+			name:           "malformed param",
+			target:         "/validate?param=name",
+			body:           serveTask,
+			expectedStatus: http.StatusBadRequest,
+			expectedError:  `invalid parameter format "name"`,
+		},
+		{
+			name:           "wrong method",
+			method:         http.MethodGet,
+			target:         "/validate",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedError:  "only POST is supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, tt.target, strings.NewReader(tt.body))
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code, rec.Body.String())
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			if tt.expectedError != "" {
+				var response errorResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+				assert.Contains(t, response.Error, tt.expectedError)
+				return
+			}
+
+			var response validateResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, tt.expectedValid, response.Valid, rec.Body.String())
+			var messages []string
+			for _, finding := range response.Findings {
+				if finding.Severity == validator.SeverityError {
+					messages = append(messages, finding.String())
+				}
+			}
+			for _, expected := range tt.expectedMessages {
+				assert.Contains(t, strings.Join(messages, "\n"), expected)
+			}
+		})
+	}
+}
+
+func TestServeWithClusterPipeline(t *testing.T) {
+	t.Cleanup(func() {
+		clusterClient = nil
+	})
+	tekton := fake.NewSimpleClientset(&v1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ci"},
+		Spec: v1.PipelineSpec{
+			Tasks: []v1.PipelineTask{{
+				Name: "build",
+				TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+					Steps: []v1.Step{{Name: "build", Image: "alpine:latest", Script: "make"}},
+				}},
+			}},
+		},
+	})
+	clusterClient = cluster.NewWithClientsets(kubefake.NewSimpleClientset(), tekton, "ci")
+	body := `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build-run
+  namespace: ci
+spec:
+  pipelineRef:
+    name: build
+`
+	validate := func(ctx context.Context) {
+		rec := httptest.NewRecorder()
+		newServeHandler(ctx, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	// Requests are not trusted to read the Pipelines of the cluster.
+	validate(validator.WithUntrustedInput(context.Background()))
+	assert.Empty(t, tekton.Actions())
+
+	validate(context.Background())
+	require.Len(t, tekton.Actions(), 1)
+	assert.True(t, tekton.Actions()[0].Matches("get", "pipelines"))
+}
+
+func TestServeHealthz(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newServeHandler(context.Background(), nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
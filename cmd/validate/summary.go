@@ -0,0 +1,121 @@
+package validate
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+// summary gathers the statistics of the validation of several files
+type summary struct {
+	files    int
+	kinds    map[string]int
+	rules    map[string]*ruleCounts
+	errors   int
+	warnings int
+	// failed counts the unreadable files and the resources failing the validation, and unsupported
+	// the resources of unsupported kinds.
+	failed      int
+	unsupported int
+}
+
+// ruleCounts are the numbers of errors and warnings attributed to a rule
+type ruleCounts struct {
+	errors   int
+	warnings int
+}
+
+func newSummary() *summary {
+	return &summary{kinds: map[string]int{}, rules: map[string]*ruleCounts{}}
+}
+
+// add accounts for the resources of a validated file and their findings, or for the error reading
+// the file. A nil summary accounts for nothing.
+func (s *summary) add(results []documentResult, err error) {
+	if s == nil {
+		return
+	}
+	s.files++
+	if err != nil {
+		s.failed++
+		s.errors++
+		s.count("").errors++
+	}
+	for _, result := range results {
+		kind := result.Document.Kind
+		if kind == "" {
+			kind = "unknown"
+		}
+		s.kinds[kind]++
+		switch {
+		case result.Unsupported:
+			s.unsupported++
+		case len(result.errors()) > 0:
+			s.failed++
+		}
+		for _, finding := range result.Findings {
+			counts := s.count(finding.Rule)
+			// Deduplicated findings account for each of their occurrences.
+			n := max(finding.Occurrences, 1)
+			if finding.Severity == validator.SeverityWarning {
+				counts.warnings += n
+				s.warnings += n
+			} else {
+				counts.errors += n
+				s.errors += n
+			}
+		}
+	}
+}
+
+// count returns the counts of the findings of a rule
+func (s *summary) count(rule string) *ruleCounts {
+	counts, ok := s.rules[rule]
+	if !ok {
+		counts = &ruleCounts{}
+		s.rules[rule] = counts
+	}
+	return counts
+}
+
+// outcome returns the most severe outcome of the validation, one of the keys of
+// config.DefaultExitCodes, or an empty string for successful validations without warnings
+func (s *summary) outcome() string {
+	switch {
+	case s.failed > 0:
+		return config.ExitErrors
+	case s.unsupported > 0:
+		return config.ExitUnsupported
+	case s.warnings > 0:
+		return config.ExitWarnings
+	}
+	return ""
+}
+
+// write renders the summary as tables of the resources by kind and the findings by rule
+func (s *summary) write(out io.Writer, elapsed time.Duration) {
+	fmt.Fprintf(out, "\nValidated %d files in %s: %d errors, %d warnings\n", s.files, elapsed.Round(time.Millisecond), s.errors, s.warnings)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nKIND\tRESOURCES")
+	for _, kind := range validator.SortedKeys(s.kinds) {
+		fmt.Fprintf(w, "%s\t%d\n", kind, s.kinds[kind])
+	}
+	if len(s.rules) > 0 {
+		fmt.Fprintln(w, "\nRULE\tERRORS\tWARNINGS")
+		for _, id := range validator.SortedKeys(s.rules) {
+			label := id
+			if rule, ok := validator.LookupRule(id); ok {
+				label = fmt.Sprintf("%s %s", rule.ID, rule.Name)
+			} else if id == "" {
+				label = "unattributed"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\n", label, s.rules[id].errors, s.rules[id].warnings)
+		}
+	}
+	w.Flush()
+}
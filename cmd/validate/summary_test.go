@@ -0,0 +1,155 @@
+package validate
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/document"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+func TestSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    [][]documentResult
+		expected string
+	}{
+		{
+			name:  "no findings",
+			files: [][]documentResult{{{Document: document.Document{Kind: "Task"}}}},
+			expected: `
+Validated 1 files in 1.5s: 0 errors, 0 warnings
+
+KIND  RESOURCES
+Task  1
+`,
+		},
+		{
+			name: "deduplicated findings",
+			files: [][]documentResult{{{
+				Document: document.Document{Kind: "Task"},
+				Findings: []validator.Finding{{Rule: validator.RuleParamReferences.ID, Severity: validator.SeverityError, Occurrences: 3}},
+			}}},
+			expected: `
+Validated 1 files in 1.5s: 3 errors, 0 warnings
+
+KIND  RESOURCES
+Task  1
+
+RULE                      ERRORS  WARNINGS
+TEK0201 param-references  3       0
+`,
+		},
+		{
+			name: "findings of several files",
+			files: [][]documentResult{
+				{
+					{
+						Document: document.Document{Kind: "Pipeline"},
+						Findings: []validator.Finding{
+							{Rule: validator.RuleUnusedWorkspace.ID, Severity: validator.SeverityError},
+							{Rule: validator.RuleUnusedParams.ID, Severity: validator.SeverityWarning},
+						},
+					},
+					{Document: document.Document{Kind: "Task"}},
+				},
+				{
+					{
+						Document: document.Document{Kind: "Task"},
+						Findings: []validator.Finding{
+							{Rule: validator.RuleUnusedParams.ID, Severity: validator.SeverityWarning},
+							{Severity: validator.SeverityError},
+						},
+					},
+					{Document: document.Document{}},
+				},
+				nil,
+			},
+			expected: `
+Validated 3 files in 1.5s: 2 errors, 2 warnings
+
+KIND      RESOURCES
+Pipeline  1
+Task      2
+unknown   1
+
+RULE                      ERRORS  WARNINGS
+unattributed              1       0
+TEK0402 unused-workspace  1       0
+TEK1003 unused-params     0       2
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSummary()
+			for _, results := range tt.files {
+				s.add(results, nil)
+			}
+			var out bytes.Buffer
+			s.write(&out, 1500*time.Millisecond)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestSummaryNil(t *testing.T) {
+	var s *summary
+	assert.NotPanics(t, func() { s.add([]documentResult{{Document: document.Document{Kind: "Task"}}}, nil) })
+}
+
+func TestSummaryOutcome(t *testing.T) {
+	warning := validator.Finding{Severity: validator.SeverityWarning}
+	failure := validator.Finding{Severity: validator.SeverityError}
+	tests := []struct {
+		name     string
+		results  []documentResult
+		err      error
+		expected string
+	}{
+		{
+			name:    "success",
+			results: []documentResult{{}},
+		},
+		{
+			name:     "warnings",
+			results:  []documentResult{{Findings: []validator.Finding{warning}}},
+			expected: config.ExitWarnings,
+		},
+		{
+			name: "unsupported resource",
+			results: []documentResult{
+				{Findings: []validator.Finding{failure}, Unsupported: true},
+				{Findings: []validator.Finding{warning}},
+			},
+			expected: config.ExitUnsupported,
+		},
+		{
+			name: "errors",
+			results: []documentResult{
+				{Findings: []validator.Finding{failure}, Unsupported: true},
+				{Findings: []validator.Finding{warning, failure}},
+			},
+			expected: config.ExitErrors,
+		},
+		{
+			name:     "unreadable file",
+			err:      errors.New("no such file"),
+			expected: config.ExitErrors,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSummary()
+			s.add(tt.results, tt.err)
+			assert.Equal(t, tt.expected, s.outcome())
+		})
+	}
+}
@@ -2,37 +2,123 @@ package validate
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/spf13/cobra"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"golang.org/x/term"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 
+	"github.com/lcarva/tektor/internal/celrule"
+	"github.com/lcarva/tektor/internal/changes"
+	"github.com/lcarva/tektor/internal/cluster"
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/document"
+	"github.com/lcarva/tektor/internal/logging"
 	"github.com/lcarva/tektor/internal/pac"
+	"github.com/lcarva/tektor/internal/plugin"
+	"github.com/lcarva/tektor/internal/policy"
+	"github.com/lcarva/tektor/internal/remotecache"
+	"github.com/lcarva/tektor/internal/taskindex"
 	"github.com/lcarva/tektor/internal/validator"
 )
 
 var (
-	paramValues []string
-	verbose     bool
+	paramValues        []string
+	paramFiles         []string
+	withRunFile        string
+	useCluster         bool
+	clusterChecks      bool
+	checkImages        bool
+	checkUnusedResults bool
+	changedOnly        bool
+	baseRef            string
+	profiles           []string
+	taskDirs           []string
+	pipelineDirs       []string
+	pacExclude         []string
+	gitProvider        string
+	pacRepositoryFile  string
+	hubURL             string
+	noCache            bool
+	cacheDir           string
+	cacheTTL           time.Duration
+	gitCacheDir        string
+	gitEnvTokens       bool
+	configFile         string
+	resolveTimeout     time.Duration
+	resolveRetries     int
+	resolveBackoff     time.Duration
+	kind               string
+	apiVersion         string
+	limits             document.Limits
+	policyPaths        []string
+	pluginDirs         []string
+	strict             bool
+	summaryOnly        bool
+	pacDir             string
+	exitCodeFlags      map[string]int
+	// ruleSettings are the rules of the configuration file, loaded by setup
+	ruleSettings map[string]config.RuleSetting
+	// configExitCodes are the exit codes of the configuration file, loaded by setup
+	configExitCodes map[string]int
+	// pacRepository is the Repository given with --pac-repository, loaded by setup
+	pacRepository *pac.Repository
+	// withRun is the PipelineRun given with --with-run, loaded by setup
+	withRun *v1.PipelineRun
+	// pairedPipelines are the Pipelines of the files which PipelineRuns of the files refer to by
+	// name, found by setup
+	pairedPipelines []document.Document
+	// clusterClient fetches the Pipelines of the cluster given with --cluster, created by setup
+	clusterClient *cluster.Client
 )
 
+// taskCache is shared by the Task indexes built during a run, so that each Task file is parsed once
+// even though the .tekton directory of a repository is indexed for each of its PipelineRuns
+var taskCache = taskindex.NewCache()
+
+// fragmentPathRegex matches field paths into the spec of a resource, e.g. spec.tasks[0].name
+var fragmentPathRegex = regexp.MustCompile(`(^|[^\w.])spec\.`)
+
+// errUnsupported is the error of resources of unsupported kinds
+var errUnsupported = errors.New("not supported")
+
+// assertableKinds are the kinds which can be asserted with --kind
+var assertableKinds = []string{"Pipeline", "Task"}
+
 var ValidateCmd = &cobra.Command{
-	Use:   "validate",
-	Short: "Validate a Tekton resource",
+	Use:   "validate FILE...",
+	Short: "Validate Tekton resources",
 	Long: `Validate a Tekton resource including:
 - Pipeline parameter validation
 - Task parameter validation  
-- Git resolver support for remote task references
+- Git resolver support for remote task references, cloning each repository once per revision
 - Bundle resolver support for OCI-based tasks
+- Hub resolver support for Artifact Hub and Tekton Hub tasks
+- On-disk cache of bundle and git resolutions (disable with --no-cache)
 - Result reference validation
 - Result type validation
 - Workspace usage validation
+- Local Task references resolved from --task-dir directories
+- Local Pipeline references resolved from --pipeline-dir directories
+- Standalone pipelineSpec and taskSpec fragments
+- TaskRun validation, including debug breakpoints that block automation
+- Step image entrypoint checks (with --check-images)
+- Results of PipelineTasks which nothing consumes (with --check-unused-results)
+- A summary of the resources and findings by rule when validating several files
+- Every PipelineRun of a .tekton directory, as Pipelines as Code consumes it (with --pac-dir)
 
 You can provide runtime parameter values to substitute parameter references during validation.`,
 	Example: `  # Validate a pipeline with embedded tasks
@@ -45,27 +131,438 @@ You can provide runtime parameter values to substitute parameter references duri
   tektor validate /tmp/pipelinerun.yaml
   
   # Validate with runtime parameters
-  tektor validate /tmp/pipeline.yaml --param taskGitUrl=https://github.com/example/repo.git --param taskGitRevision=main`,
-	Args: cobra.ExactArgs(1),
+  tektor validate /tmp/pipeline.yaml --param taskGitUrl=https://github.com/example/repo.git --param taskGitRevision=main
+
+  # Resolve Tasks referenced by name from local directories
+  tektor validate /tmp/pipeline.yaml --task-dir /tmp/tasks
+
+  # Validate a PipelineRun against the Pipeline it references by name
+  tektor validate .tekton/pipelinerun.yaml --pipeline-dir pipelines
+
+  # Validate a bare Pipeline spec generated by a templating tool
+  tektor validate /tmp/pipeline-spec.yaml --kind Pipeline
+
+  # Only validate the resources changed by a pull request
+  tektor validate .tekton/*.yaml --changed-only --base-ref origin/main
+
+  # Validate every PipelineRun of the .tekton directory
+  tektor validate --pac-dir .tekton`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if pacDir != "" {
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		params, err := parseParamValues(paramValues)
+		if err := config.VerifyExitCodes(exitCodeFlags, "--exit-code"); err != nil {
+			return err
+		}
+		if pacDir != "" {
+			files, err := pacDirFiles(pacDir)
+			if err != nil {
+				return err
+			}
+			args = append(slices.Clone(args), files...)
+		}
+		ctx, params, files, err := setup(cmd.Context(), args)
 		if err != nil {
-			return fmt.Errorf("error parsing parameter values: %w", err)
+			return err
+		}
+		if showProgress() {
+			ctx = validator.WithProgress(ctx, os.Stderr)
+		}
+
+		start := time.Now()
+		s := newSummary()
+		var allErrors error
+		failed := 0
+		for _, fname := range files {
+			if err := runWithSummary(ctx, fname, params, s); err != nil {
+				if len(files) == 1 {
+					allErrors = err
+				} else {
+					allErrors = multierror.Append(allErrors, fmt.Errorf("%s: %w", fname, err))
+				}
+				failed++
+			}
+		}
+		if len(files) > 1 || summaryOnly {
+			s.write(cmd.OutOrStdout(), time.Since(start))
+		}
+		if summaryOnly && allErrors != nil {
+			allErrors = fmt.Errorf("validation failed with %d errors in %d files", s.errors, failed)
+		}
+		outcome := s.outcome()
+		code := exitCode(outcome)
+		if outcome == config.ExitWarnings && code != 0 {
+			allErrors = fmt.Errorf("validation passed with %d warnings", s.warnings)
 		}
-		return run(cmd.Context(), args[0], params)
+		return exitError(code, allErrors)
 	},
 }
 
 func init() {
-	ValidateCmd.Flags().StringArrayVarP(&paramValues, "param", "p", []string{},
-		"Parameter values in the format key=value (can be specified multiple times)")
-	ValidateCmd.Flags().BoolVarP(&verbose, "verbose", "v", false,
-		"Enable verbose logging output")
+	addValidationFlags(ValidateCmd)
+	ValidateCmd.Flags().BoolVar(&summaryOnly, "summary-only", false,
+		"Only print the summary of the validation, leaving the individual findings out")
+	ValidateCmd.Flags().StringToIntVar(&exitCodeFlags, "exit-code", nil,
+		fmt.Sprintf("Exit code of an outcome of the validation, e.g. %s=3 (can be specified multiple times), overriding the exit codes of the configuration file", config.ExitWarnings))
+	ValidateCmd.Flags().StringVar(&pacDir, "pac-dir", "",
+		"The .tekton directory of a repository, whose PipelineRuns are all validated in addition to the given files, leaving out the files matching --pac-exclude")
+}
+
+// pacDirFiles returns the files of a .tekton directory defining PipelineRuns, failing if there are
+// none since the directory is then most likely not the one intended
+func pacDirFiles(dir string) ([]string, error) {
+	files, err := pac.PipelineRunFiles(dir, pacExclude)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: no PipelineRun found", dir)
+	}
+	return files, nil
+}
+
+// pacOptions returns the options of Pipelines as Code resolution given by the flags
+func pacOptions() pac.Options {
+	return pac.Options{Exclude: pacExclude, GitProvider: gitProvider, Repository: pacRepository, Pipelines: pairedPipelines}
+}
+
+// addValidationFlags adds the flags configuring the validation to cmd
+func addValidationFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVarP(&paramValues, "param", "p", []string{},
+		"Parameter values in the format key=value (can be specified multiple times), JSON arrays and objects of strings giving array and object params")
+	cmd.Flags().StringArrayVar(&paramFiles, "param-file", []string{},
+		"YAML or JSON file mapping params to their values, overridden by --param (can be specified multiple times)")
+	cmd.Flags().StringVar(&withRunFile, "with-run", "",
+		"File defining a PipelineRun whose params, workspaces, and taskRunSpecs the Pipelines it runs are validated with, its params overridden by --param-file and --param")
+	cmd.Flags().BoolVar(&useCluster, "cluster", false,
+		"Fetch the Pipelines PipelineRuns refer to by name, unless given along with them or defined in the .tekton directory, from the cluster of the current kube context")
+	cmd.Flags().BoolVar(&clusterChecks, "cluster-checks", false,
+		"Verify the ServiceAccounts, workspace Secrets and ConfigMaps, and env valueFrom references of runs and steps exist in the namespace of the run in the cluster of the current kube context")
+	cmd.Flags().BoolVar(&checkImages, "check-images", false,
+		"Fetch step image configs from their registry to check entrypoint usage")
+	cmd.Flags().BoolVar(&checkUnusedResults, "check-unused-results", false,
+		"Warn about results of PipelineTasks which no PipelineTask or pipeline result consumes")
+	cmd.Flags().BoolVar(&changedOnly, "changed-only", false,
+		"Only validate files that changed, or whose local dependencies changed, relative to --base-ref")
+	cmd.Flags().StringVar(&baseRef, "base-ref", "origin/main",
+		"Git ref used to compute changed files with --changed-only")
+	cmd.Flags().StringSliceVar(&profiles, "profile", []string{},
+		fmt.Sprintf("Enable additional rule profiles (%s), overriding the profiles of the configuration file (can be specified multiple times)", strings.Join(validator.Profiles, ", ")))
+	cmd.Flags().StringVar(&kind, "kind", "",
+		fmt.Sprintf("Kind of resources lacking one, bare specs are wrapped in a resource of this kind (%s)", strings.Join(assertableKinds, ", ")))
+	cmd.Flags().StringVar(&apiVersion, "api-version", "",
+		"API version of resources lacking one, defaults to tekton.dev/v1 when --kind is set")
+	cmd.Flags().StringArrayVar(&taskDirs, "task-dir", []string{},
+		"Directory containing Task definitions used to resolve Tasks referenced by name (can be specified multiple times)")
+	cmd.Flags().StringVar(&hubURL, "hub-url", "",
+		"URL of the hub, Artifact Hub or Tekton Hub, which Tasks referenced through the hub resolver are fetched from")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false,
+		"Resolve Tasks and Pipelines from bundles and git repositories without the on-disk cache")
+	cmd.Flags().StringVar(&cacheDir, "cache-dir", "",
+		"Directory of the on-disk cache of Tasks and Pipelines resolved from bundles and git repositories, defaults to $XDG_CACHE_HOME/tektor")
+	cmd.Flags().DurationVar(&cacheTTL, "cache-ttl", remotecache.DefaultTTL,
+		"How long cached resolutions of tags and branches are reused, references pinned to a digest or commit are reused indefinitely")
+	cmd.Flags().StringVar(&gitCacheDir, "git-cache-dir", "",
+		"Directory keeping the checkouts of the commits of the git repositories Tasks and Pipelines are resolved from, for later runs")
+	cmd.Flags().BoolVar(&gitEnvTokens, "git-env-tokens", false,
+		"Authenticate the HTTPS URLs of github.com and gitlab.com without configured credentials with GITHUB_TOKEN and GITLAB_TOKEN")
+	cmd.Flags().StringArrayVar(&pipelineDirs, "pipeline-dir", []string{},
+		"Directory containing Pipeline definitions used to resolve Pipelines referenced by name (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&pacExclude, "pac-exclude", []string{},
+		"Glob pattern of files in the .tekton directory to leave out of Pipelines as Code resolution, matched against their path relative to the directory or their name (can be specified multiple times)")
+	cmd.Flags().StringVar(&gitProvider, "git-provider", "",
+		fmt.Sprintf("Git provider hosting the repository, whose conventions Pipelines as Code resolution follows (%s), defaults to the one of --pac-repository or else to %s", strings.Join(pac.GitProviders, ", "), pac.GitProviderGitHub))
+	cmd.Flags().StringVar(&pacRepositoryFile, "pac-repository", "",
+		"File defining the Repository of Pipelines as Code of the repository, whose URL, git provider, and custom params are used to resolve PipelineRuns")
+	cmd.Flags().DurationVar(&resolveTimeout, "resolve-timeout", 2*time.Minute,
+		"Maximum duration of each attempt to resolve a remote Task or Pipeline, or 0 for no limit")
+	cmd.Flags().IntVar(&resolveRetries, "resolve-retries", 2,
+		"Number of times a remote resolution failing for a transient reason, e.g. a timeout, is retried")
+	cmd.Flags().DurationVar(&resolveBackoff, "resolve-backoff", time.Second,
+		"Delay before retrying a remote resolution, doubled for each further retry")
+	cmd.Flags().StringArrayVar(&policyPaths, "policy", []string{},
+		"Rego policy file, or directory of policy files, evaluated against Pipelines and PipelineRuns (can be specified multiple times)")
+	cmd.Flags().StringArrayVar(&pluginDirs, "plugin-dir", []string{},
+		fmt.Sprintf("Directory of %s* plugins run against Pipelines and PipelineRuns (can be specified multiple times)", plugin.Prefix))
+	cmd.Flags().BoolVar(&strict, "strict", false,
+		"Report warnings as errors, and verify descriptions, pinned remote references, and unused params")
+	cmd.Flags().StringVar(&configFile, "config", "",
+		fmt.Sprintf("Configuration file, defaults to $%s or to %s if it exists", config.FileEnv, config.DefaultFile))
+	cmd.Flags().Int64Var(&limits.MaxBytes, "max-input-bytes", document.DefaultLimits.MaxBytes,
+		"Maximum size of an input file, or 0 for no limit")
+	cmd.Flags().IntVar(&limits.MaxNodes, "max-yaml-nodes", document.DefaultLimits.MaxNodes,
+		"Maximum number of YAML nodes of a resource once aliases are expanded, or 0 for no limit")
+}
+
+// showProgress tells whether to show the progress of remote resolutions, which is shown on
+// terminals unless the log is quiet, or verbose and already shows each PipelineTask
+func showProgress() bool {
+	return term.IsTerminal(int(os.Stderr.Fd())) && logging.Enabled(logging.LevelInfo) && !logging.Enabled(logging.LevelDebug)
+}
+
+// setup parses the flags configuring the validation. It returns the context to validate with, the
+// runtime parameter values, and the files to validate.
+func setup(ctx context.Context, args []string) (context.Context, validator.RuntimeParams, []string, error) {
+	params := make(validator.RuntimeParams)
+	withRun = nil
+	if withRunFile != "" {
+		run, err := loadRunFile(ctx, withRunFile)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		withRun = run
+	}
+	fileParams, err := loadParamFiles(paramFiles)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	maps.Copy(params, fileParams)
+	flagParams, err := parseParamValues(paramValues)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error parsing parameter values: %w", err)
+	}
+	maps.Copy(params, flagParams)
+	if kind != "" && !slices.Contains(assertableKinds, kind) {
+		return nil, nil, nil, fmt.Errorf("unsupported kind %q, expected one of: %s", kind, strings.Join(assertableKinds, ", "))
+	}
+	if gitProvider != "" && !pac.IsKnownGitProvider(gitProvider) {
+		return nil, nil, nil, fmt.Errorf("unknown git provider %q, expected one of: %s", gitProvider, strings.Join(pac.GitProviders, ", "))
+	}
+	clusterClient = nil
+	var clusterObjects validator.ObjectLookup
+	if useCluster || clusterChecks {
+		client, err := cluster.New()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if useCluster {
+			clusterClient = client
+		}
+		if clusterChecks {
+			clusterObjects = client
+		}
+	}
+	pacRepository = nil
+	if pacRepositoryFile != "" {
+		if pacRepository, err = pac.LoadRepository(pacRepositoryFile); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	document.DefaultLimits = limits
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	selectedProfiles := profiles
+	if len(selectedProfiles) == 0 {
+		selectedProfiles = cfg.Profiles
+		if cfg.Profile != "" {
+			selectedProfiles = append([]string{cfg.Profile}, selectedProfiles...)
+		}
+	}
+	for _, name := range selectedProfiles {
+		if !validator.IsKnownProfile(name) {
+			return nil, nil, nil, fmt.Errorf("unknown profile %q, expected one of: %s", name, strings.Join(validator.Profiles, ", "))
+		}
+	}
+	index, err := buildTaskIndex(ctx, append(slices.Clone(taskDirs), pipelineDirs...))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var policies *policy.Engine
+	if len(policyPaths) > 0 {
+		if policies, err = policy.Load(ctx, policyPaths); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	customRules, err := celrule.Compile(cfg.CustomRules)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ruleSettings = cfg.Rules
+	configExitCodes = cfg.ExitCodes
+	for _, id := range unknownRules(cfg) {
+		logging.Warnf("⚠️  Unknown rule %s in the rules of the configuration", id)
+	}
+	plugins := plugin.Discover(pluginDirs)
+	for _, p := range plugins {
+		logging.Infof("Using the %s plugin: %s", p.Name, p.Path)
+	}
+	ctx = validator.WithOptions(ctx, validator.Options{
+		CheckImages:        checkImages,
+		CheckUnusedResults: checkUnusedResults,
+		Profiles:           validator.NewProfileSet(selectedProfiles...),
+		TaskIndex:          index,
+		HubURL:             hubURL,
+		Cache:              remoteCache(),
+		GitRepos:           validator.NewGitRepoCache(gitCacheDir),
+		GitEnvTokens:       gitEnvTokens,
+		Credentials:        cfg.Credentials,
+		ResolveTimeout:     resolveTimeout,
+		ResolveRetries:     resolveRetries,
+		ResolveBackoff:     resolveBackoff,
+		Policies:           policies,
+		CustomRules:        customRules,
+		Plugins:            plugins,
+		ClusterObjects:     clusterObjects,
+		Strict:             strict,
+	})
+
+	files := args
+	if changedOnly {
+		files, err = filterChangedFiles(ctx, args, baseRef)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	pairedPipelines = findPairedPipelines(files)
+	return ctx, params, files, nil
+}
+
+// findPairedPipelines returns the Pipelines of files which PipelineRuns of files refer to by name,
+// so that the PipelineRuns are resolved with the Pipelines given along with them. Resources which
+// cannot be decoded are left to their own validation.
+func findPairedPipelines(files []string) []document.Document {
+	var pipelines []document.Document
+	referenced := map[string]bool{}
+	for _, fname := range files {
+		docs, err := document.SplitFile(fname)
+		if err != nil {
+			continue
+		}
+		for _, doc := range docs {
+			if doc.Err != nil {
+				continue
+			}
+			if taskindex.IsPipelineDocument(doc) {
+				pipelines = append(pipelines, doc)
+				continue
+			}
+			if doc.Kind != "PipelineRun" {
+				continue
+			}
+			var pr v1.PipelineRun
+			if err := yaml.Unmarshal(doc.Content, &pr); err != nil {
+				continue
+			}
+			if ref := pr.Spec.PipelineRef; ref != nil && ref.Resolver == "" && ref.Name != "" {
+				referenced[ref.Name] = true
+			}
+		}
+	}
+
+	var paired []document.Document
+	for _, doc := range pipelines {
+		if referenced[doc.Name] {
+			paired = append(paired, doc)
+		}
+	}
+	return paired
+}
+
+// buildTaskIndex indexes the Tasks and Pipelines found in the given directories. It returns nil if
+// no directories are given.
+func buildTaskIndex(ctx context.Context, dirs []string) (*taskindex.Index, error) {
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+	index := taskindex.NewWithCache(taskCache)
+	for _, dir := range dirs {
+		if err := index.AddDir(ctx, dir); err != nil {
+			return nil, err
+		}
+	}
+	for _, duplicate := range index.Duplicates() {
+		logging.Warnf("⚠️  %s is defined more than once in task or pipeline directories", duplicate)
+	}
+	return index, nil
+}
+
+// loadConfig loads the configuration file given with --config, which must exist, or else the one
+// given by the environment or in the working directory, if any
+func loadConfig() (config.Config, error) {
+	if configFile != "" {
+		return config.Load(configFile, true)
+	}
+	path := config.Path()
+	return config.Load(path, path != config.DefaultFile)
+}
+
+// remoteCache returns the on-disk cache of remote resolutions in the directory given with
+// --cache-dir, or else in the user cache directory, or nil if it is disabled or if there is no user
+// cache directory
+func remoteCache() *remotecache.Cache {
+	if noCache {
+		return nil
+	}
+	if cacheDir != "" {
+		return remotecache.New(cacheDir, cacheTTL)
+	}
+	dir, err := remotecache.DefaultDir()
+	if err != nil {
+		logging.Warnf("⚠️  Not caching remote resolutions: %v", err)
+		return nil
+	}
+	return remotecache.New(dir, cacheTTL)
+}
+
+// withPaCTaskIndex extends the TaskIndex carried by ctx with the Tasks from the .tekton directory of
+// the repository containing fname, which Pipelines as Code uses to resolve Tasks referenced by name
+func withPaCTaskIndex(ctx context.Context, fname string) (context.Context, error) {
+	dir := pac.TektonDir(fname)
+	if dir == "" {
+		return ctx, nil
+	}
+	if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+		return ctx, nil
+	}
+
+	index := validator.TaskIndexFromContext(ctx)
+	if index == nil {
+		index = taskindex.NewWithCache(taskCache)
+	} else {
+		index = index.Clone()
+	}
+	excluded := func(path string) bool { return pac.IsExcluded(dir, path, pacExclude) }
+	if err := index.AddDirExcluding(ctx, dir, excluded); err != nil {
+		return nil, err
+	}
+	return validator.WithTaskIndex(ctx, index), nil
+}
+
+// filterChangedFiles returns the files affected by changes relative to the given git ref
+func filterChangedFiles(ctx context.Context, fnames []string, ref string) ([]string, error) {
+	var filtered []string
+	var changeSet *changes.Set
+	for _, fname := range fnames {
+		if changeSet == nil {
+			var err error
+			changeSet, err = changes.Detect(ctx, filepath.Dir(fname), ref)
+			if err != nil {
+				return nil, fmt.Errorf("detecting changed files: %w", err)
+			}
+		}
+
+		affected, err := changeSet.Affects(fname)
+		if err != nil {
+			return nil, err
+		}
+		if !affected {
+			logging.Infof("Skipping %s: unchanged relative to %s", fname, changeSet.BaseRef())
+			continue
+		}
+		filtered = append(filtered, fname)
+	}
+	return filtered, nil
 }
 
-// parseParamValues parses command-line parameter values in key=value format
-func parseParamValues(paramStrs []string) (map[string]string, error) {
-	params := make(map[string]string)
+// parseParamValues parses command-line parameter values in key=value format. Values which are JSON
+// arrays or objects of strings are the values of array and object params.
+func parseParamValues(paramStrs []string) (validator.RuntimeParams, error) {
+	params := make(validator.RuntimeParams)
 	for _, paramStr := range paramStrs {
 		parts := strings.SplitN(paramStr, "=", 2)
 		if len(parts) != 2 {
@@ -76,41 +573,285 @@ func parseParamValues(paramStrs []string) (map[string]string, error) {
 		if key == "" {
 			return nil, fmt.Errorf("empty parameter key in %q", paramStr)
 		}
-		params[key] = value
+		paramValue, err := validator.ParseRuntimeParam(value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", key, err)
+		}
+		params[key] = paramValue
 	}
 	return params, nil
 }
 
-// substituteParameters replaces parameter references in YAML content with provided values
-func substituteParameters(yamlContent []byte, params map[string]string) []byte {
-	content := string(yamlContent)
+// loadParamFiles loads the runtime parameter values of YAML or JSON files, those of later files
+// overriding those of earlier ones
+func loadParamFiles(fnames []string) (validator.RuntimeParams, error) {
+	params := make(validator.RuntimeParams)
+	for _, fname := range fnames {
+		content, err := os.ReadFile(fname)
+		if err != nil {
+			return nil, fmt.Errorf("reading parameter file: %w", err)
+		}
+		fileParams, err := validator.ParseRuntimeParams(content)
+		if err != nil {
+			return nil, fmt.Errorf("parsing parameter file %s: %w", fname, err)
+		}
+		maps.Copy(params, fileParams)
+	}
+	return params, nil
+}
 
-	for key, value := range params {
-		// Replace $(params.key) with the actual value
-		paramRef := fmt.Sprintf("$(params.%s)", key)
-		content = strings.ReplaceAll(content, paramRef, value)
+// loadRunFile loads the PipelineRun of a file, converting a v1beta1 PipelineRun to v1
+func loadRunFile(ctx context.Context, fname string) (*v1.PipelineRun, error) {
+	docs, err := document.SplitFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var runs []document.Document
+	for _, doc := range docs {
+		if doc.Kind == "PipelineRun" {
+			runs = append(runs, doc)
+		}
 	}
+	if len(runs) != 1 {
+		return nil, fmt.Errorf("%s: expected a single PipelineRun, found %d", fname, len(runs))
+	}
+	doc := runs[0]
+	switch doc.Key() {
+	case "tekton.dev/v1/PipelineRun":
+		var pr v1.PipelineRun
+		if err := yaml.Unmarshal(doc.Content, &pr); err != nil {
+			return nil, fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+		}
+		return &pr, nil
+	case "tekton.dev/v1beta1/PipelineRun":
+		var pr v1beta1.PipelineRun
+		if err := yaml.Unmarshal(doc.Content, &pr); err != nil {
+			return nil, fmt.Errorf("unmarshalling %s as %s: %w", doc, doc.Key(), err)
+		}
+		var converted v1.PipelineRun
+		if err := pr.ConvertTo(ctx, &converted); err != nil {
+			return nil, fmt.Errorf("converting %s to v1: %w", doc, err)
+		}
+		return &converted, nil
+	}
+	return nil, fmt.Errorf("%s: unsupported %s", doc, doc.Key())
+}
 
-	return []byte(content)
+// runsPipeline tells whether the PipelineRun given with --with-run runs a Pipeline, which is the
+// case when it refers to the Pipeline by name, or neither names the Pipeline it runs nor embeds it
+func runsPipeline(p v1.Pipeline) bool {
+	if withRun == nil {
+		return false
+	}
+	ref := withRun.Spec.PipelineRef
+	if ref == nil {
+		return withRun.Spec.PipelineSpec == nil
+	}
+	return ref.Name == "" || ref.Name == p.Name
 }
 
-func run(ctx context.Context, fname string, runtimeParams map[string]string) error {
-	// Configure logging based on verbose flag
-	if !verbose {
-		log.SetOutput(os.Stderr)
-		log.SetFlags(0) // Remove timestamp for cleaner output
+// clusterPipeline returns the Pipeline a PipelineRun refers to by name from the cluster given with
+// --cluster, in the namespace of the PipelineRun, or nil if the PipelineRun does not refer to a
+// Pipeline by name or if the Pipeline is given along with the PipelineRun or defined in the .tekton
+// directory
+func clusterPipeline(ctx context.Context, content []byte) (*document.Document, error) {
+	var pr v1.PipelineRun
+	if err := yaml.Unmarshal(content, &pr); err != nil {
+		return nil, err
+	}
+	ref := pr.Spec.PipelineRef
+	if ref == nil || ref.Resolver != "" || ref.Name == "" {
+		return nil, nil
+	}
+	for _, doc := range pairedPipelines {
+		if doc.Name == ref.Name {
+			return nil, nil
+		}
 	}
+	if index := validator.TaskIndexFromContext(ctx); index != nil {
+		if _, err := index.Lookup("Pipeline", ref.Name); err == nil {
+			return nil, nil
+		}
+	}
+	pipeline, err := clusterClient.Pipeline(ctx, pr.Namespace, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &pipeline, nil
+}
 
-	log.Printf("Validating %s", fname)
+// substituteParameters replaces parameter references in YAML content with provided values
+func substituteParameters(yamlContent []byte, params validator.RuntimeParams) []byte {
+	return validator.SubstituteRuntimeParams(yamlContent, params)
+}
+
+func run(ctx context.Context, fname string, runtimeParams validator.RuntimeParams) error {
+	return runWithSummary(ctx, fname, runtimeParams, nil)
+}
+
+// runWithSummary is like run but also accounts for the resources of the file and their findings in
+// s, unless it is nil
+func runWithSummary(ctx context.Context, fname string, runtimeParams validator.RuntimeParams, s *summary) error {
+	log.SetFlags(0)
+
+	logging.Infof("Validating %s", fname)
 	if len(runtimeParams) > 0 {
 		logRuntimeParameters(runtimeParams)
 	}
 
-	f, err := os.ReadFile(fname)
+	results, err := validateFile(ctx, fname, runtimeParams)
+	s.add(results, err)
+	if err != nil {
+		return err
+	}
+
+	var allErrors error
+	for _, result := range results {
+		prefix := ""
+		if len(results) > 1 {
+			prefix = fmt.Sprintf("%s: ", result.Document)
+		}
+
+		for _, finding := range result.Findings {
+			if finding.Severity == validator.SeverityWarning && !summaryOnly {
+				logging.Warnf("⚠️  %s%s", prefix, finding)
+			}
+		}
+		if resultErr := findingsError(result.errors()); resultErr != nil {
+			if len(results) == 1 {
+				return resultErr
+			}
+			allErrors = multierror.Append(allErrors, fmt.Errorf("%s%w", prefix, resultErr))
+		}
+	}
+	if allErrors != nil {
+		return allErrors
+	}
+
+	logging.Infof("✅ Validation successful for %s", fname)
+	return nil
+}
+
+// unknownRules returns the sorted keys of the rules of cfg which are neither built-in nor custom
+// rules. Plugins report rules of their own, so these are only worth a warning.
+func unknownRules(cfg config.Config) []string {
+	var unknown []string
+	for id := range cfg.Rules {
+		if _, ok := validator.LookupRule(id); ok || strings.HasPrefix(id, plugin.Prefix) {
+			continue
+		}
+		if slices.ContainsFunc(cfg.CustomRules, func(rule config.CustomRule) bool { return rule.ID == id }) {
+			continue
+		}
+		unknown = append(unknown, id)
+	}
+	slices.Sort(unknown)
+	return unknown
+}
+
+// documentResult is the outcome of validating a single resource of a file
+type documentResult struct {
+	Document document.Document
+	Findings []validator.Finding
+	// Unsupported tells the resource is of a kind which is not supported.
+	Unsupported bool
+}
+
+// errors returns the findings of the result which fail the validation
+func (r documentResult) errors() []validator.Finding {
+	var errs []validator.Finding
+	for _, finding := range r.Findings {
+		if finding.Severity == validator.SeverityError {
+			errs = append(errs, finding)
+		}
+	}
+	return errs
+}
+
+// findingsError renders findings as an error, or returns nil if there are none
+func findingsError(findings []validator.Finding) error {
+	if len(findings) == 1 {
+		return errors.New(findings[0].String())
+	}
+	var err error
+	for _, finding := range findings {
+		err = multierror.Append(err, errors.New(finding.String()))
+	}
+	return err
+}
+
+// validateFile validates every resource of a file. The returned error is only set if the file
+// cannot be read.
+func validateFile(ctx context.Context, fname string, runtimeParams validator.RuntimeParams) ([]documentResult, error) {
+	docs, err := document.SplitFile(fname)
 	if err != nil {
-		return fmt.Errorf("reading %s: %w", fname, err)
+		return nil, err
+	}
+	// An empty file is reported as an unsupported resource.
+	if len(docs) == 0 {
+		docs = []document.Document{{Source: fname, Line: 1}}
 	}
 
+	results := make([]documentResult, 0, len(docs))
+	for _, doc := range docs {
+		err := validateTypedDocument(ctx, doc, runtimeParams)
+		unsupported := errors.Is(err, errUnsupported)
+		if strict {
+			err = validator.PromoteWarnings(err)
+		}
+		// The settings of the configuration override strict mode, which only sets a default.
+		err = validator.OverrideRules(err, ruleSettings)
+		findings := validator.DeduplicatedFindings(err)
+		for i := range findings {
+			findings[i].Line = doc.FieldLine(findings[i].ResourcePath)
+		}
+		results = append(results, documentResult{Document: doc, Findings: findings, Unsupported: unsupported})
+	}
+	return results, nil
+}
+
+// validateTypedDocument validates a single resource of a file after asserting its apiVersion and
+// kind with the values of --api-version and --kind
+func validateTypedDocument(ctx context.Context, doc document.Document, runtimeParams validator.RuntimeParams) error {
+	// Never decode documents exceeding the input limits, e.g. YAML bombs.
+	var limitErr *document.LimitError
+	if errors.As(doc.Err, &limitErr) {
+		return limitErr
+	}
+
+	version := apiVersion
+	if version == "" && doc.APIVersion == "" {
+		version = v1.SchemeGroupVersion.String()
+	}
+
+	if fragment, key, ok := doc.Fragment(version); ok {
+		if kind != "" && kind != fragment.Kind {
+			return fmt.Errorf("%s: %s fragment is not a %s", doc, key, kind)
+		}
+		err := validateDocument(ctx, fragment, runtimeParams)
+		// Point field paths at the fragment instead of the synthetic resource wrapping it.
+		return validator.RewriteErrors(err, func(msg string) string {
+			return fragmentPathRegex.ReplaceAllString(msg, "${1}"+key+".")
+		})
+	}
+
+	if kind == "" && apiVersion == "" {
+		return validateDocument(ctx, doc, runtimeParams)
+	}
+	typed, err := doc.WithType(version, kind)
+	if err != nil {
+		return err
+	}
+	return validateDocument(ctx, typed, runtimeParams)
+}
+
+// validateDocument validates a single resource of a file. The returned error may contain warnings.
+func validateDocument(ctx context.Context, doc document.Document, runtimeParams validator.RuntimeParams) error {
+	fname := doc.Source
+	f := doc.Content
+	// Local bundles are read relative to the file referring to them.
+	ctx = validator.WithBaseDir(ctx, filepath.Dir(fname))
+
 	// Substitute runtime parameters if provided
 	originalContent := f
 	if len(runtimeParams) > 0 {
@@ -122,6 +863,7 @@ func run(ctx context.Context, fname string, runtimeParams map[string]string) err
 		return fmt.Errorf("unmarshalling %s as k8s resource: %w", fname, err)
 	}
 
+	var validationErr error
 	key := fmt.Sprintf("%s/%s", o.APIVersion, o.Kind)
 	switch key {
 	case "tekton.dev/v1/Pipeline":
@@ -129,13 +871,60 @@ func run(ctx context.Context, fname string, runtimeParams map[string]string) err
 		if err := yaml.Unmarshal(f, &p); err != nil {
 			return fmt.Errorf("unmarshalling %s as %s: %w", fname, key, err)
 		}
-		if err := validator.ValidatePipelineWithYAMLAndParams(ctx, p, originalContent, runtimeParams); err != nil {
+		if runsPipeline(p) {
+			validationErr = validator.ValidatePipelineWithRun(ctx, p, originalContent, *withRun, runtimeParams)
+		} else {
+			validationErr = validator.ValidatePipelineWithYAMLAndParams(ctx, p, originalContent, runtimeParams)
+		}
+	case "tekton.dev/v1/PipelineRun", "tekton.dev/v1beta1/PipelineRun":
+		// Malformed durations and quantities would fail decoding the PipelineRun without pointing at
+		// the field.
+		if err := multierror.Append(validator.ValidatePipelineRunTimeoutDurations(f), validator.ValidateWorkspaceBindingQuantities(f)).ErrorOrNil(); err != nil {
 			return err
 		}
-	case "tekton.dev/v1/PipelineRun":
-		f, err = pac.ResolvePipelineRun(ctx, fname, o.Name)
+
+		// PAC resolution converts v1beta1 PipelineRuns to v1, so check the fields that do not
+		// survive the conversion beforehand.
+		var timeoutsErr error
+		if o.APIVersion == v1beta1.SchemeGroupVersion.String() {
+			var pr v1beta1.PipelineRun
+			if err := yaml.Unmarshal(f, &pr); err != nil {
+				return fmt.Errorf("unmarshalling %s as %s: %w", fname, key, err)
+			}
+			timeoutsErr = validator.ValidatePipelineRunV1Beta1Timeouts(pr.Spec)
+		}
+
+		ctx, err := withPaCTaskIndex(ctx, fname)
+		if err != nil {
+			return fmt.Errorf("indexing PAC tasks: %w", err)
+		}
+
+		opts := pacOptions()
+		// Untrusted input could otherwise read the Pipelines of any namespace of the cluster.
+		if clusterClient != nil && !validator.IsUntrustedInput(ctx) {
+			pipeline, err := clusterPipeline(ctx, f)
+			if err != nil {
+				err = fmt.Errorf("resolving pipelineRef from the cluster: %w", err)
+				if timeoutsErr != nil {
+					return multierror.Append(timeoutsErr, err)
+				}
+				return err
+			}
+			if pipeline != nil {
+				opts.Pipelines = append(slices.Clone(opts.Pipelines), *pipeline)
+			}
+		}
+
+		// The template variables of Pipelines as Code only apply to the PipelineRun itself, not to the
+		// Tasks and Pipelines its resolution embeds.
+		pipelineRunContent := f
+		f, err := pac.ResolvePipelineRun(ctx, fname, o.Name, opts)
 		if err != nil {
-			return fmt.Errorf("resolving with PAC: %w", err)
+			err = fmt.Errorf("resolving with PAC: %w", err)
+			if timeoutsErr != nil {
+				return multierror.Append(timeoutsErr, err)
+			}
+			return err
 		}
 
 		// Apply parameter substitution to resolved content too
@@ -148,44 +937,57 @@ func run(ctx context.Context, fname string, runtimeParams map[string]string) err
 			return fmt.Errorf("unmarshalling %s as %s: %w", fname, key, err)
 		}
 
-		if err := validator.ValidatePipelineRunWithYAML(ctx, pr, originalContent); err != nil {
+		validationErr = validator.ValidatePipelineRunWithYAML(ctx, pr, originalContent)
+		if err := validator.ValidatePipelinesAsCodeVariables(pipelineRunContent, pacRepository.ParamNames()); err != nil {
+			validationErr = multierror.Append(validationErr, err).ErrorOrNil()
+		}
+		if timeoutsErr != nil {
+			validationErr = multierror.Append(timeoutsErr, validationErr).ErrorOrNil()
+		}
+	case "tekton.dev/v1/TaskRun":
+		// Malformed quantities would fail decoding the TaskRun without pointing at the field.
+		if err := validator.ValidateWorkspaceBindingQuantities(f); err != nil {
 			return err
 		}
+		var tr v1.TaskRun
+		if err := yaml.Unmarshal(f, &tr); err != nil {
+			return fmt.Errorf("unmarshaling %s as %s: %w", fname, key, err)
+		}
+		validationErr = validator.ValidateTaskRun(ctx, tr)
 	case "tekton.dev/v1/Task":
 		var t v1.Task
 		if err := yaml.Unmarshal(f, &t); err != nil {
 			return fmt.Errorf("unmarshaling %s as %s: %w", fname, key, err)
 		}
-		if err := validator.ValidateTaskV1(ctx, t); err != nil {
-			return err
-		}
+		validationErr = validator.ValidateTaskV1(ctx, t)
 	case "tekton.dev/v1beta1/Task":
 		var t v1beta1.Task
 		if err := yaml.Unmarshal(f, &t); err != nil {
 			return fmt.Errorf("unmarshaling %s as %s: %w", fname, key, err)
 		}
-		if err := validator.ValidateTaskV1Beta1(ctx, t); err != nil {
-			return err
-		}
+		validationErr = validator.ValidateTaskV1Beta1(ctx, t)
 	default:
-		return fmt.Errorf("%s is not supported", key)
+		return fmt.Errorf("%s is %w", key, errUnsupported)
 	}
 
-	log.Printf("✅ Validation successful for %s", fname)
-	return nil
+	// Decoding drops unknown fields, so they are looked for in the content as written.
+	if err := validator.ValidatePodFields(originalContent); err != nil {
+		validationErr = multierror.Append(validationErr, err).ErrorOrNil()
+	}
+	return validationErr
 }
 
 // logRuntimeParameters logs runtime parameters in a verbose and pretty format
-func logRuntimeParameters(params map[string]string) {
+func logRuntimeParameters(params validator.RuntimeParams) {
 	if len(params) == 1 {
 		for key, value := range params {
-			log.Printf("Using runtime parameter: %s=%s", key, value)
+			logging.Infof("Using runtime parameter: %s=%s", key, validator.FormatRuntimeParam(value))
 		}
 		return
 	}
 
-	log.Printf("Using %d runtime parameters:", len(params))
+	logging.Infof("Using %d runtime parameters:", len(params))
 	for key, value := range params {
-		log.Printf("  • %s: %s", key, value)
+		logging.Infof("  • %s: %s", key, validator.FormatRuntimeParam(value))
 	}
 }
@@ -5,25 +5,51 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	gogit "github.com/go-git/go-git/v5"
+	gitcfg "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lcarva/tektor/internal/cluster"
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/document"
+	"github.com/lcarva/tektor/internal/validator"
 )
 
+// TestMain caches remote resolutions in a temporary directory rather than in the user cache
+// directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "tektor-cache-")
+	if err != nil {
+		panic(err)
+	}
+	cacheDir = dir
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func TestParseParamValues(t *testing.T) {
 	tests := []struct {
 		name           string
 		paramStrs      []string
-		expectedParams map[string]string
+		expectedParams validator.RuntimeParams
 		expectedError  bool
 		errorContains  string
 	}{
 		{
 			name:      "valid single parameter",
 			paramStrs: []string{"gitUrl=https://github.com/example/repo.git"},
-			expectedParams: map[string]string{
+			expectedParams: validator.StringParams(map[string]string{
 				"gitUrl": "https://github.com/example/repo.git",
-			},
+			}),
 			expectedError: false,
 		},
 		{
@@ -33,45 +59,72 @@ func TestParseParamValues(t *testing.T) {
 				"gitRevision=main",
 				"buildArgs=--verbose",
 			},
-			expectedParams: map[string]string{
+			expectedParams: validator.StringParams(map[string]string{
 				"gitUrl":      "https://github.com/example/repo.git",
 				"gitRevision": "main",
 				"buildArgs":   "--verbose",
-			},
+			}),
 			expectedError: false,
 		},
 		{
 			name:      "parameter with spaces around equals",
 			paramStrs: []string{"gitUrl = https://github.com/example/repo.git"},
-			expectedParams: map[string]string{
+			expectedParams: validator.StringParams(map[string]string{
 				"gitUrl": "https://github.com/example/repo.git",
-			},
+			}),
 			expectedError: false,
 		},
 		{
 			name:      "parameter with empty value",
 			paramStrs: []string{"emptyParam="},
-			expectedParams: map[string]string{
+			expectedParams: validator.StringParams(map[string]string{
 				"emptyParam": "",
-			},
+			}),
 			expectedError: false,
 		},
 		{
 			name:      "parameter with equals in value",
 			paramStrs: []string{"complexParam=key=value"},
-			expectedParams: map[string]string{
+			expectedParams: validator.StringParams(map[string]string{
 				"complexParam": "key=value",
-			},
+			}),
 			expectedError: false,
 		},
 		{
 			name:      "parameter with special characters",
 			paramStrs: []string{"specialParam=value-with_special.chars@123"},
-			expectedParams: map[string]string{
+			expectedParams: validator.StringParams(map[string]string{
 				"specialParam": "value-with_special.chars@123",
-			},
+			}),
 			expectedError: false,
 		},
+		{
+			name:      "array and object parameters",
+			paramStrs: []string{`platforms=["linux/amd64","linux/arm64"]`, `repo={"url":"https://github.com/example/repo.git"}`},
+			expectedParams: validator.RuntimeParams{
+				"platforms": *v1.NewStructuredValues("linux/amd64", "linux/arm64"),
+				"repo":      *v1.NewObject(map[string]string{"url": "https://github.com/example/repo.git"}),
+			},
+		},
+		{
+			name:      "string parameter starting like an array",
+			paramStrs: []string{"msg=[skip ci] fix"},
+			expectedParams: validator.StringParams(map[string]string{
+				"msg": "[skip ci] fix",
+			}),
+		},
+		{
+			name:          "invalid array parameter",
+			paramStrs:     []string{"platforms=[1]"},
+			expectedError: true,
+			errorContains: "parameter platforms: invalid array [1], expected a JSON array of strings",
+		},
+		{
+			name:          "invalid object parameter",
+			paramStrs:     []string{`repo={"url":1}`},
+			expectedError: true,
+			errorContains: `parameter repo: invalid object {"url":1}, expected a JSON object of strings`,
+		},
 		{
 			name:          "invalid parameter format - no equals",
 			paramStrs:     []string{"invalidParam"},
@@ -102,7 +155,7 @@ func TestParseParamValues(t *testing.T) {
 		{
 			name:           "empty parameter list",
 			paramStrs:      []string{},
-			expectedParams: map[string]string{},
+			expectedParams: validator.RuntimeParams{},
 			expectedError:  false,
 		},
 	}
@@ -343,7 +396,7 @@ spec:
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := substituteParameters(tt.yamlContent, tt.params)
+			result := substituteParameters(tt.yamlContent, validator.StringParams(tt.params))
 			assert.Equal(t, tt.expectedResult, result,
 				"Expected substituted content to match for test case: %s", tt.name)
 		})
@@ -402,8 +455,7 @@ spec:
 			runtimeParams: map[string]string{
 				"gitUrl": "https://github.com/example/repo.git",
 			},
-			expectedError: true,
-			errorContains: "parameter reference $(params.url) not defined in pipeline spec",
+			expectedError: false,
 		},
 		{
 			name:     "valid task file",
@@ -531,8 +583,7 @@ spec:
 				"gitUrl":      "https://github.com/example/repo.git",
 				"gitRevision": "feature-branch",
 			},
-			expectedError: true,
-			errorContains: "parameter reference $(params.url) not defined in pipeline spec",
+			expectedError: false,
 		},
 		{
 			name:     "pipeline with parameter validation errors",
@@ -606,6 +657,27 @@ data:
 			expectedError: true,
 			errorContains: "not supported",
 		},
+		{
+			name:     "taskrun with breakpoint before unknown step",
+			fileName: "taskrun.yaml",
+			fileContent: []byte(`apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  name: test-taskrun
+spec:
+  debug:
+    breakpoints:
+      beforeSteps: [missing]
+  taskSpec:
+    steps:
+      - name: hello
+        image: alpine:latest
+        script: echo hello
+`),
+			runtimeParams: map[string]string{},
+			expectedError: true,
+			errorContains: `breakpoint before step "missing" which does not exist`,
+		},
 		{
 			name:          "empty file",
 			fileName:      "empty.yaml",
@@ -614,6 +686,78 @@ data:
 			expectedError: true,
 			errorContains: "is not supported",
 		},
+		{
+			name:     "v1beta1 pipelinerun with timeout and timeouts",
+			fileName: "v1beta1-pipelinerun.yaml",
+			fileContent: []byte(`apiVersion: tekton.dev/v1beta1
+kind: PipelineRun
+metadata:
+  name: test-pipelinerun
+spec:
+  timeout: 1h
+  timeouts:
+    tasks: 30m
+  pipelineSpec:
+    tasks:
+      - name: hello
+        taskSpec:
+          steps:
+            - name: hello
+              image: alpine:latest
+              script: echo hello
+`),
+			runtimeParams: map[string]string{},
+			expectedError: true,
+			errorContains: "spec.timeout and spec.timeouts are mutually exclusive",
+		},
+		{
+			name:     "multiple valid documents",
+			fileName: "multi-valid.yaml",
+			fileContent: []byte(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: first
+spec:
+  steps:
+    - name: step
+      image: alpine:latest
+      script: echo first
+---
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: second
+spec:
+  steps:
+    - name: step
+      image: alpine:latest
+      script: echo second
+`),
+			runtimeParams: map[string]string{},
+			expectedError: false,
+		},
+		{
+			name:     "multiple documents with an invalid one",
+			fileName: "multi-invalid.yaml",
+			fileContent: []byte(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: first
+spec:
+  steps:
+    - name: step
+      image: alpine:latest
+      script: echo first
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-config
+`),
+			runtimeParams: map[string]string{},
+			expectedError: true,
+			errorContains: "multi-invalid.yaml:11 (ConfigMap test-config): v1/ConfigMap is not supported",
+		},
 	}
 
 	for _, tt := range tests {
@@ -626,7 +770,7 @@ data:
 				require.NoError(t, err)
 			}
 
-			err := run(ctx, filePath, tt.runtimeParams)
+			err := run(ctx, filePath, validator.StringParams(tt.runtimeParams))
 
 			if tt.expectedError {
 				require.Error(t, err, "Expected error for test case: %s", tt.name)
@@ -819,8 +963,7 @@ spec:
           value: $(tasks.clone.results.commit)
 `),
 			runtimeParams: map[string]string{},
-			expectedError: true,
-			errorContains: "parameter reference $(params.commit) not defined in pipeline spec",
+			expectedError: false,
 		},
 		{
 			name:     "pipeline with finally tasks",
@@ -866,8 +1009,7 @@ spec:
               echo "Pipeline completed"
 `),
 			runtimeParams: map[string]string{},
-			expectedError: true,
-			errorContains: "parameter reference $(params.buildStatus) not defined in pipeline spec",
+			expectedError: false,
 		},
 	}
 
@@ -881,7 +1023,7 @@ spec:
 				require.NoError(t, err)
 			}
 
-			err := run(ctx, filePath, tt.runtimeParams)
+			err := run(ctx, filePath, validator.StringParams(tt.runtimeParams))
 
 			if tt.expectedError {
 				require.Error(t, err, "Expected error for test case: %s", tt.name)
@@ -926,7 +1068,7 @@ func TestLogRuntimeParameters(t *testing.T) {
 			// This test just ensures the function doesn't panic
 			// In a real scenario, you might want to capture and verify log output
 			assert.NotPanics(t, func() {
-				logRuntimeParameters(tt.params)
+				logRuntimeParameters(validator.StringParams(tt.params))
 			}, "logRuntimeParameters should not panic")
 		})
 	}
@@ -1113,7 +1255,753 @@ spec:
 		"runTests":    "true",
 	}
 
-	err = run(ctx, filePath, runtimeParams)
+	err = run(ctx, filePath, validator.StringParams(runtimeParams))
 	assert.Error(t, err, "Complex pipeline validation should fail due to parameter validation issues")
-	assert.Contains(t, err.Error(), "parameter reference validation", "Should contain parameter validation errors")
+	assert.Contains(t, err.Error(), `"buildArgs" parameter has the incorrect type`, "Should contain parameter validation errors")
+	// Params declared by the embedded task specs are not pipeline params.
+	assert.NotContains(t, err.Error(), "parameter reference validation", "Should accept references to task spec params")
+}
+
+func TestRunWithTaskDir(t *testing.T) {
+	tempDir := t.TempDir()
+	taskDir := filepath.Join(tempDir, "tasks")
+	require.NoError(t, os.Mkdir(taskDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "hello.yaml"), []byte(`apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: hello
+spec:
+  params:
+    - name: name
+  steps:
+    - name: hello
+      image: alpine:latest
+      script: echo hello $(params.name)
+`), 0644))
+
+	pipelinePath := filepath.Join(tempDir, "pipeline.yaml")
+	require.NoError(t, os.WriteFile(pipelinePath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: hello
+spec:
+  tasks:
+    - name: hello
+      params:
+        - name: name
+          value: world
+      taskRef:
+        name: hello
+`), 0644))
+
+	index, err := buildTaskIndex(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Nil(t, index, "Expected no index without task directories")
+
+	_, err = buildTaskIndex(context.Background(), []string{filepath.Join(tempDir, "missing")})
+	require.Error(t, err)
+
+	index, err = buildTaskIndex(context.Background(), []string{taskDir})
+	require.NoError(t, err)
+	ctx := validator.WithOptions(context.Background(), validator.Options{TaskIndex: index})
+	assert.NoError(t, run(ctx, pipelinePath, nil))
+
+	err = run(context.Background(), pipelinePath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to retrieve spec for pipeline task")
+}
+
+func TestPacDirFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".tekton")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tasks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "push.yaml"), []byte("apiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: push\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pull-request.yaml"), []byte("apiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: pull-request\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tasks", "build.yaml"), []byte("apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n"), 0644))
+
+	pacExclude = []string{"push.yaml"}
+	defer func() { pacExclude = nil }()
+	files, err := pacDirFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "pull-request.yaml")}, files)
+
+	_, err = pacDirFiles(filepath.Join(dir, "tasks"))
+	assert.EqualError(t, err, filepath.Join(dir, "tasks")+": no PipelineRun found")
+}
+
+func TestRunWithKind(t *testing.T) {
+	tempDir := t.TempDir()
+	specPath := filepath.Join(tempDir, "pipeline-spec.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(`params:
+  - name: message
+    type: string
+tasks:
+  - name: hello
+    params:
+      - name: message
+        value: $(params.message)
+    taskSpec:
+      params:
+        - name: message
+          type: string
+      steps:
+        - name: hello
+          image: alpine:latest
+          script: echo $(params.message)
+`), 0644))
+
+	t.Cleanup(func() {
+		kind, apiVersion = "", ""
+	})
+
+	err := run(context.Background(), specPath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/ is not supported")
+
+	kind = "Pipeline"
+	assert.NoError(t, run(context.Background(), specPath, nil))
+
+	kind = "Task"
+	err = run(context.Background(), specPath, nil)
+	require.Error(t, err, "Expected a Pipeline spec to be invalid as a Task spec")
+}
+
+func TestRunWithPipelineParameterReferences(t *testing.T) {
+	pipelinePath := filepath.Join(t.TempDir(), "pipeline.yaml")
+	require.NoError(t, os.WriteFile(pipelinePath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: hello
+spec:
+  params:
+    - name: message
+      type: string
+  tasks:
+    - name: hello
+      params:
+        - name: message
+          value: $(params.message) $(params.missing)
+      taskSpec:
+        params:
+          - name: message
+            type: string
+        steps:
+          - name: hello
+            image: alpine:latest
+            script: echo $(params.message)
+`), 0644))
+
+	for _, runtimeParams := range []map[string]string{{}, {"message": "hi", "missing": "there"}} {
+		err := run(context.Background(), pipelinePath, validator.StringParams(runtimeParams))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parameter reference $(params.missing) not defined in pipeline spec [TEK0201]")
+		assert.NotContains(t, err.Error(), "$(params.message) not defined")
+	}
+}
+
+func TestRunWithTypedParameters(t *testing.T) {
+	pipelinePath := filepath.Join(t.TempDir(), "pipeline.yaml")
+	require.NoError(t, os.WriteFile(pipelinePath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  params:
+    - name: platforms
+      type: array
+    - name: repo
+      type: object
+      properties:
+        url:
+          type: string
+  tasks:
+    - name: build
+      matrix:
+        params:
+          - name: PLATFORM
+            value: $(params.platforms[*])
+      params:
+        - name: url
+          value: $(params.repo.url)
+      taskSpec:
+        params:
+          - name: PLATFORM
+            type: string
+          - name: url
+            type: string
+        steps:
+          - name: build
+            image: alpine:latest
+            script: echo $(params.url) $(params.PLATFORM)
+`), 0644))
+
+	params, err := parseParamValues([]string{`platforms=["linux/amd64","linux/arm64"]`, `repo={"url":"https://github.com/example/repo.git"}`})
+	require.NoError(t, err)
+	assert.NoError(t, run(context.Background(), pipelinePath, params))
+
+	params, err = parseParamValues([]string{"platforms=linux/amd64", `repo={"url":"https://github.com/example/repo.git","branch":"main"}`})
+	require.NoError(t, err)
+	err = run(context.Background(), pipelinePath, params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "platforms param is of type array, but its runtime value linux/amd64 is of type string: spec.params[0] [TEK0202]")
+	assert.Contains(t, err.Error(), "repo param has no branch property, but its runtime value sets it: spec.params[1] [TEK0202]")
+}
+
+func TestRunWithRun(t *testing.T) {
+	tempDir := t.TempDir()
+	pipelinePath := filepath.Join(tempDir, "pipeline.yaml")
+	require.NoError(t, os.WriteFile(pipelinePath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  params:
+    - name: revision
+      type: string
+  workspaces:
+    - name: source
+  tasks:
+    - name: build
+      params:
+        - name: revision
+          value: $(params.revision)
+      workspaces:
+        - name: source
+          workspace: source
+      taskSpec:
+        params:
+          - name: revision
+            type: string
+        workspaces:
+          - name: source
+        steps:
+          - name: build
+            image: alpine:latest
+            script: cd $(workspaces.source.path) && git checkout $(params.revision)
+`), 0644))
+	runPath := filepath.Join(tempDir, "run.yaml")
+	require.NoError(t, os.WriteFile(runPath, []byte(`apiVersion: tekton.dev/v1beta1
+kind: PipelineRun
+metadata:
+  name: build-run
+spec:
+  pipelineRef:
+    name: build
+  params:
+    - name: revision
+      value: main
+  workspaces:
+    - name: source
+      emptyDir: {}
+  taskRunSpecs:
+    - pipelineTaskName: build
+      taskServiceAccountName: builder
+`), 0644))
+
+	t.Cleanup(func() {
+		withRunFile, withRun, paramValues = "", nil, nil
+	})
+	withRunFile = runPath
+	ctx, params, _, err := setup(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, params, "Expected the params of the PipelineRun to only apply to the Pipeline it runs")
+	assert.NoError(t, run(ctx, pipelinePath, params))
+
+	// Pipelines the PipelineRun does not run are validated without its params.
+	otherPath := filepath.Join(tempDir, "other.yaml")
+	require.NoError(t, os.WriteFile(otherPath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: other
+spec:
+  params:
+    - name: revision
+      type: array
+  tasks:
+    - name: build
+      params:
+        - name: revisions
+          value: $(params.revision[*])
+      taskSpec:
+        params:
+          - name: revisions
+            type: array
+        steps:
+          - name: build
+            image: alpine:latest
+            args: ["$(params.revisions[*])"]
+`), 0644))
+	assert.NoError(t, run(ctx, otherPath, params))
+
+	paramValues = []string{`revision=["main"]`}
+	ctx, params, _, err = setup(context.Background(), nil)
+	require.NoError(t, err)
+	err = run(ctx, pipelinePath, params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"revision" parameter has the incorrect type, got "array", want "string"`)
+
+	require.NoError(t, os.WriteFile(runPath, []byte(`apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build-run
+spec:
+  pipelineRef:
+    name: build
+  taskRunSpecs:
+    - pipelineTaskName: deploy
+`), 0644))
+	paramValues = nil
+	ctx, params, _, err = setup(context.Background(), nil)
+	require.NoError(t, err)
+	err = run(ctx, pipelinePath, params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"revision" parameter is required`)
+	assert.Contains(t, err.Error(), `"source" workspace is required`)
+	assert.Contains(t, err.Error(), `PipelineTask "deploy" does not exist: spec.taskRunSpecs[0].pipelineTaskName`)
+
+	require.NoError(t, os.WriteFile(runPath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+`), 0644))
+	_, _, _, err = setup(context.Background(), nil)
+	assert.ErrorContains(t, err, runPath+": expected a single PipelineRun, found 0")
+}
+
+func TestRunWithPipelineAndPipelineRun(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "pipelines"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, ".tekton"), 0755))
+	pipelinePath := filepath.Join(tempDir, "pipelines", "pipeline.yaml")
+	require.NoError(t, os.WriteFile(pipelinePath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  params:
+    - name: revision
+      type: string
+  workspaces:
+    - name: source
+  tasks:
+    - name: build
+      params:
+        - name: revision
+          value: $(params.revision)
+      workspaces:
+        - name: source
+          workspace: source
+      taskSpec:
+        params:
+          - name: revision
+            type: string
+        workspaces:
+          - name: source
+        steps:
+          - name: build
+            image: alpine:latest
+            script: cd $(workspaces.source.path) && git checkout $(params.revision)
+`), 0644))
+	runPath := filepath.Join(tempDir, ".tekton", "run.yaml")
+	require.NoError(t, os.WriteFile(runPath, []byte(`apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build-run
+spec:
+  pipelineRef:
+    name: build
+  params:
+    - name: revision
+      value: [main]
+`), 0644))
+	commitRepository(t, tempDir)
+
+	t.Cleanup(func() {
+		pairedPipelines = nil
+	})
+
+	// Pipelines as Code cannot resolve the PipelineRun without the Pipeline it refers to.
+	ctx, params, _, err := setup(context.Background(), []string{runPath})
+	require.NoError(t, err)
+	assert.ErrorContains(t, run(ctx, runPath, params), "cannot find referenced pipeline build")
+
+	ctx, params, files, err := setup(context.Background(), []string{pipelinePath, runPath})
+	require.NoError(t, err)
+	assert.Equal(t, []string{pipelinePath, runPath}, files)
+	assert.NoError(t, run(ctx, pipelinePath, params))
+	err = run(ctx, runPath, params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"revision" parameter has the incorrect type, got "array", want "string"`)
+	assert.Contains(t, err.Error(), `"source" workspace is required`)
+}
+
+func TestRunWithPipelinesAsCodeVariables(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, ".tekton"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, ".tekton", "task.yaml"), []byte(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: render
+spec:
+  steps:
+    - name: render
+      image: alpine:latest
+      script: |
+        #!/bin/sh
+        echo '{{ .Values.image }}' > values.tpl
+`), 0644))
+	runPath := filepath.Join(tempDir, ".tekton", "run.yaml")
+	require.NoError(t, os.WriteFile(runPath, []byte(`apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: render-run
+  annotations:
+    example.com/revision: '{{ revison }}'
+spec:
+  pipelineSpec:
+    tasks:
+      - name: render
+        taskRef:
+          name: render
+`), 0644))
+	commitRepository(t, tempDir)
+
+	// The Task embedded by the resolution is not templated by Pipelines as Code.
+	ctx, params, _, err := setup(context.Background(), []string{runPath})
+	require.NoError(t, err)
+	results, err := validateFile(ctx, runPath, params)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	var messages []string
+	for _, finding := range results[0].Findings {
+		if finding.Rule == validator.RulePACVariables.ID {
+			messages = append(messages, finding.Message)
+		}
+	}
+	assert.Equal(t, []string{
+		`unknown Pipelines as Code variable "revison", which it leaves as is unless the Repository defines it as a custom param (did you mean "revision")`,
+	}, messages)
+}
+
+// commitRepository commits the files of dir to a new git repository cloned from GitHub, as Pipelines
+// as Code expects of the repository of PipelineRuns
+func commitRepository(t *testing.T, dir string) {
+	repository, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+	_, err = repository.CreateRemote(&gitcfg.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/example/repo"}})
+	require.NoError(t, err)
+	worktree, err := repository.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add(".")
+	require.NoError(t, err)
+	_, err = worktree.Commit("pipelines", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "tektor", Email: "tektor@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+}
+
+func TestRunWithClusterPipeline(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, ".tekton"), 0755))
+	runPath := filepath.Join(tempDir, ".tekton", "run.yaml")
+	require.NoError(t, os.WriteFile(runPath, []byte(`apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build-run
+spec:
+  pipelineRef:
+    name: build
+  params:
+    - name: revision
+      value: [main]
+`), 0644))
+	commitRepository(t, tempDir)
+
+	ctx, params, _, err := setup(context.Background(), []string{runPath})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		clusterClient = nil
+	})
+
+	clusterClient = cluster.NewWithClientsets(kubefake.NewSimpleClientset(), fake.NewSimpleClientset(), "ci")
+	assert.ErrorContains(t, run(ctx, runPath, params),
+		`resolving pipelineRef from the cluster: fetching Pipeline build from namespace ci: pipelines.tekton.dev "build" not found`)
+
+	clusterClient = cluster.NewWithClientsets(kubefake.NewSimpleClientset(), fake.NewSimpleClientset(&v1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ci"},
+		Spec: v1.PipelineSpec{
+			Params: v1.ParamSpecs{{Name: "revision", Type: v1.ParamTypeString}},
+			Tasks: []v1.PipelineTask{{
+				Name:   "build",
+				Params: v1.Params{{Name: "revision", Value: *v1.NewStructuredValues("$(params.revision)")}},
+				TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+					Params: v1.ParamSpecs{{Name: "revision", Type: v1.ParamTypeString}},
+					Steps:  []v1.Step{{Name: "build", Image: "alpine:latest", Script: "git checkout $(params.revision)"}},
+				}},
+			}},
+		},
+	}), "ci")
+	err = run(ctx, runPath, params)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"revision" parameter has the incorrect type, got "array", want "string"`)
+}
+
+func TestRunWithUnknownPodFields(t *testing.T) {
+	taskPath := filepath.Join(t.TempDir(), "task.yaml")
+	require.NoError(t, os.WriteFile(taskPath, []byte(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  volumes:
+    - name: cache
+      emptyDir: {}
+  steps:
+    - name: hello
+      image: alpine:latest
+      script: echo hello > /cache/hello
+      volumeMount:
+        - name: cache
+          mountPath: /cache
+`), 0644))
+
+	err := run(context.Background(), taskPath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown field "volumeMount", which Tekton ignores (did you mean "volumeMounts"): spec.steps[0].volumeMount [TEK0101]`)
+}
+
+func TestRunWithFragment(t *testing.T) {
+	tempDir := t.TempDir()
+
+	validPath := filepath.Join(tempDir, "valid-fragment.yaml")
+	require.NoError(t, os.WriteFile(validPath, []byte(`taskSpec:
+  steps:
+    - name: hello
+      image: alpine:latest
+      script: echo hello
+`), 0644))
+	assert.NoError(t, run(context.Background(), validPath, nil))
+
+	invalidPath := filepath.Join(tempDir, "invalid-fragment.yaml")
+	require.NoError(t, os.WriteFile(invalidPath, []byte(`pipelineSpec:
+  tasks:
+    - name: hello
+      taskSpec:
+        steps:
+          - name: hello
+            image: alpine:latest
+            script: echo hello
+          - name: hello
+            image: alpine:latest
+            script: echo hello again
+`), 0644))
+	err := run(context.Background(), invalidPath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "pipelineSpec.tasks[0].taskSpec.steps[1].name")
+	assert.NotContains(t, err.Error(), " spec.tasks")
+
+	t.Cleanup(func() {
+		kind = ""
+	})
+	kind = "Pipeline"
+	err = run(context.Background(), validPath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "taskSpec fragment is not a Pipeline")
+}
+
+func TestRunWithStrict(t *testing.T) {
+	tempDir := t.TempDir()
+	pipelinePath := filepath.Join(tempDir, "pipeline.yaml")
+	require.NoError(t, os.WriteFile(pipelinePath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: hello
+spec:
+  description: Says hello
+  tasks:
+    - name: hello
+      pipelineSpec:
+        description: Says hello
+        tasks:
+          - name: hello
+            taskSpec:
+              steps:
+                - name: hello
+                  image: alpine:latest
+                  script: echo hello
+`), 0644))
+	assert.NoError(t, run(context.Background(), pipelinePath, nil), "Expected nesting a Pipeline to be a warning")
+
+	t.Cleanup(func() {
+		strict = false
+	})
+	strict = true
+	ctx, _, _, err := setup(context.Background(), nil)
+	require.NoError(t, err)
+	err = run(ctx, pipelinePath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PipelineTask \"hello\" nests a Pipeline, which Tekton does not run yet")
+
+	taskPath := filepath.Join(tempDir, "task.yaml")
+	require.NoError(t, os.WriteFile(taskPath, []byte(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+spec:
+  params:
+    - name: greeting
+      type: string
+  steps:
+    - name: hello
+      image: alpine:latest
+      script: echo hello
+`), 0644))
+	err = run(ctx, taskPath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Task has no description: spec.description [TEK1001]")
+	assert.Contains(t, err.Error(), "greeting param has no description: spec.params[0] [TEK1001]")
+	assert.Contains(t, err.Error(), "greeting param is declared but never referenced: spec.params[0] [TEK1003]")
+}
+
+func TestRunWithRuleSettings(t *testing.T) {
+	tempDir := t.TempDir()
+	pipelinePath := filepath.Join(tempDir, "pipeline.yaml")
+	require.NoError(t, os.WriteFile(pipelinePath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: hello
+spec:
+  workspaces:
+    - name: cache
+  tasks:
+    - name: hello
+      taskSpec:
+        steps:
+          - name: hello
+            image: alpine:latest
+            script: echo hello
+`), 0644))
+	assert.NoError(t, run(context.Background(), pipelinePath, nil), "Expected the unused workspace to be a warning")
+
+	configPath := filepath.Join(tempDir, "tektor.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`rules:
+  unused-workspace: error
+  TEK9999: off
+`), 0644))
+	t.Cleanup(func() {
+		configFile = ""
+		ruleSettings = nil
+	})
+	configFile = configPath
+	ctx, _, _, err := setup(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TEK9999"}, unknownRules(config.Config{Rules: ruleSettings}))
+	err = run(ctx, pipelinePath, nil)
+	require.Error(t, err, "Expected the unused workspace to be an error")
+	assert.Contains(t, err.Error(), `pipeline workspace "cache" is declared but never used`)
+
+	ruleSettings = map[string]config.RuleSetting{"TEK0402": config.RuleOff}
+	results, err := validateFile(ctx, pipelinePath, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Empty(t, results[0].Findings)
+}
+
+func TestRunWithLimits(t *testing.T) {
+	tempDir := t.TempDir()
+	bombPath := filepath.Join(tempDir, "bomb.yaml")
+	require.NoError(t, os.WriteFile(bombPath, []byte(`apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: bomb
+a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol"]
+b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a]
+c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b]
+d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c]
+e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d]
+f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e]
+g: &g [*f,*f,*f,*f,*f,*f,*f,*f,*f]
+h: &h [*g,*g,*g,*g,*g,*g,*g,*g,*g]
+spec:
+  tasks: *h
+`), 0644))
+
+	err := run(context.Background(), bombPath, nil)
+	require.Error(t, err)
+	assert.Equal(t, bombPath+":1: exceeds the expanded node limit of 1000000 nodes", err.Error())
+
+	previous := document.DefaultLimits
+	t.Cleanup(func() { document.DefaultLimits = previous })
+	document.DefaultLimits = document.Limits{MaxBytes: 100}
+	err = run(context.Background(), bombPath, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the size limit of 100 bytes")
+}
+
+func TestLoadConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, "tektor.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`credentials:
+  git:
+    - host: github.com
+      token: secret
+`), 0644))
+
+	t.Cleanup(func() {
+		configFile = ""
+	})
+	t.Setenv(config.FileEnv, "")
+	cfg, err := loadConfig()
+	require.NoError(t, err, "Expected a missing .tektor.yaml to be ignored")
+	assert.Empty(t, cfg.Credentials.Git)
+
+	t.Setenv(config.FileEnv, configPath)
+	cfg, err = loadConfig()
+	require.NoError(t, err)
+	assert.NotNil(t, cfg.Credentials.GitCredential("https://github.com/org/tasks.git"))
+
+	configFile = filepath.Join(tempDir, "missing.yaml")
+	_, err = loadConfig()
+	require.Error(t, err, "Expected a missing --config file to be reported")
+}
+
+func TestSetupWithParamFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	yamlPath := filepath.Join(tempDir, "params.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`gitUrl: https://github.com/example/repo.git
+gitRevision: main
+replicas: 3
+platforms:
+  - linux/amd64
+  - linux/arm64
+repo:
+  url: https://github.com/example/repo.git
+`), 0644))
+	jsonPath := filepath.Join(tempDir, "params.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"gitRevision": "release", "debug": true}`), 0644))
+
+	t.Cleanup(func() {
+		paramFiles, paramValues = nil, nil
+	})
+	paramFiles = []string{yamlPath, jsonPath}
+	paramValues = []string{"gitRevision=feature", `platforms=["linux/s390x"]`}
+	_, params, _, err := setup(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, validator.RuntimeParams{
+		"gitUrl":      *v1.NewStructuredValues("https://github.com/example/repo.git"),
+		"gitRevision": *v1.NewStructuredValues("feature"),
+		"replicas":    *v1.NewStructuredValues("3"),
+		"debug":       *v1.NewStructuredValues("true"),
+		"platforms":   {Type: v1.ParamTypeArray, ArrayVal: []string{"linux/s390x"}},
+		"repo":        *v1.NewObject(map[string]string{"url": "https://github.com/example/repo.git"}),
+	}, params)
+
+	invalidPath := filepath.Join(tempDir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalidPath, []byte("- gitUrl\n"), 0644))
+	paramFiles = []string{invalidPath}
+	_, _, _, err = setup(context.Background(), nil)
+	assert.ErrorContains(t, err, "parsing parameter file "+invalidPath+": expected a map of param values")
+
+	paramFiles = []string{filepath.Join(tempDir, "missing.yaml")}
+	_, _, _, err = setup(context.Background(), nil)
+	assert.ErrorContains(t, err, "reading parameter file")
 }
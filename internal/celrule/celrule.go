@@ -0,0 +1,139 @@
+// Package celrule evaluates the custom rules of the configuration, CEL expressions over Tekton
+// resources.
+package celrule
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/google/cel-go/cel"
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/lcarva/tektor/internal/config"
+)
+
+// variables are the fields of resources declared in the environment of the expressions. The whole
+// resource is also available as object.
+var variables = []string{"apiVersion", "kind", "metadata", "spec"}
+
+// Violation is a resource not satisfying a custom rule
+type Violation struct {
+	// Rule is the ID of the custom rule.
+	Rule    string
+	Message string
+	// Warning is set for the rules whose severity is warning.
+	Warning bool
+}
+
+// rule is a compiled custom rule
+type rule struct {
+	config.CustomRule
+	program cel.Program
+	message *template.Template
+}
+
+// Set is a set of compiled custom rules
+type Set struct {
+	rules []rule
+}
+
+// Compile compiles custom rules. It returns nil if there are none.
+func Compile(rules []config.CustomRule) (*Set, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	options := []cel.EnvOption{cel.Variable("object", cel.DynType)}
+	for _, name := range variables {
+		options = append(options, cel.Variable(name, cel.DynType))
+	}
+	env, err := cel.NewEnv(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &Set{}
+	var allErrors error
+	for _, r := range rules {
+		ast, issues := env.Compile(r.Expression)
+		if issues != nil && issues.Err() != nil {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("custom rule %s: compiling expression: %w", r.ID, issues.Err()))
+			continue
+		}
+		if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("custom rule %s: expression evaluates to %s, expected bool", r.ID, ast.OutputType()))
+			continue
+		}
+		program, err := env.Program(ast)
+		if err != nil {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("custom rule %s: %w", r.ID, err))
+			continue
+		}
+		message := r.Message
+		if message == "" {
+			message = fmt.Sprintf("%s is not satisfied", r.Expression)
+		}
+		tmpl, err := template.New(r.ID).Option("missingkey=zero").Parse(message)
+		if err != nil {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("custom rule %s: parsing message: %w", r.ID, err))
+			continue
+		}
+		set.rules = append(set.rules, rule{CustomRule: r, program: program, message: tmpl})
+	}
+	if allErrors != nil {
+		return nil, allErrors
+	}
+	return set, nil
+}
+
+// Evaluate returns the violations of the rules by a resource, whose fields are converted to JSON
+// before evaluating the rules applying to its kind. Rules failing to evaluate, e.g. because they
+// access a missing field without has(), are reported as violations.
+func (s *Set) Evaluate(ctx context.Context, resource any) ([]Violation, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	activation := map[string]any{"object": object}
+	for _, name := range variables {
+		activation[name] = object[name]
+	}
+	kind, _ := object["kind"].(string)
+
+	var violations []Violation
+	for _, r := range s.rules {
+		if len(r.Kinds) > 0 && !slices.Contains(r.Kinds, kind) {
+			continue
+		}
+		violation := Violation{Rule: r.ID, Warning: r.Severity == config.SeverityWarning}
+		out, _, err := r.program.ContextEval(ctx, activation)
+		if err != nil {
+			violation.Message = fmt.Sprintf("evaluating %s: %v", r.Expression, err)
+			violations = append(violations, violation)
+			continue
+		}
+		satisfied, ok := out.Value().(bool)
+		if !ok {
+			violation.Message = fmt.Sprintf("%s evaluates to %v, expected a bool", r.Expression, out.Value())
+			violations = append(violations, violation)
+			continue
+		}
+		if satisfied {
+			continue
+		}
+		var message strings.Builder
+		if err := r.message.Execute(&message, object); err != nil {
+			return nil, fmt.Errorf("custom rule %s: rendering message: %w", r.ID, err)
+		}
+		violation.Message = message.String()
+		violations = append(violations, violation)
+	}
+	return violations, nil
+}
@@ -0,0 +1,115 @@
+package celrule
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcarva/tektor/internal/config"
+)
+
+func TestEvaluate(t *testing.T) {
+	set, err := Compile([]config.CustomRule{
+		{
+			ID:         "task-timeouts",
+			Kinds:      []string{"Pipeline"},
+			Expression: "spec.tasks.all(t, has(t.timeout))",
+			Message:    "{{.metadata.name}}: every PipelineTask sets a timeout",
+		},
+		{
+			ID:         "team-prefix",
+			Expression: `metadata.name.startsWith("team-")`,
+			Severity:   config.SeverityWarning,
+		},
+		{
+			ID:         "finally",
+			Kinds:      []string{"Pipeline"},
+			Expression: "spec.finally.size() > 0",
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		resource map[string]any
+		expected []Violation
+	}{
+		{
+			name: "compliant",
+			resource: map[string]any{
+				"kind":     "Pipeline",
+				"metadata": map[string]any{"name": "team-build"},
+				"spec": map[string]any{
+					"tasks":   []any{map[string]any{"name": "build", "timeout": "1h"}},
+					"finally": []any{map[string]any{"name": "notify"}},
+				},
+			},
+		},
+		{
+			name: "violations",
+			resource: map[string]any{
+				"kind":     "Pipeline",
+				"metadata": map[string]any{"name": "build"},
+				"spec":     map[string]any{"tasks": []any{map[string]any{"name": "build"}}},
+			},
+			expected: []Violation{
+				{Rule: "task-timeouts", Message: "build: every PipelineTask sets a timeout"},
+				{Rule: "team-prefix", Message: `metadata.name.startsWith("team-") is not satisfied`, Warning: true},
+				{Rule: "finally", Message: "evaluating spec.finally.size() > 0: no such key: finally"},
+			},
+		},
+		{
+			name: "rules of other kinds",
+			resource: map[string]any{
+				"kind":     "PipelineRun",
+				"metadata": map[string]any{"name": "team-run"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := set.Evaluate(context.Background(), tt.resource)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, violations)
+		})
+	}
+}
+
+func TestCompile(t *testing.T) {
+	set, err := Compile(nil)
+	require.NoError(t, err)
+	assert.Nil(t, set)
+
+	tests := []struct {
+		name          string
+		rule          config.CustomRule
+		expectedError string
+	}{
+		{
+			name:          "syntax error",
+			rule:          config.CustomRule{ID: "broken", Expression: "spec.tasks.all(t,"},
+			expectedError: "custom rule broken: compiling expression",
+		},
+		{
+			name:          "not a bool",
+			rule:          config.CustomRule{ID: "count", Expression: "1 + 1"},
+			expectedError: "custom rule count: expression evaluates to int, expected bool",
+		},
+		{
+			name:          "malformed message",
+			rule:          config.CustomRule{ID: "message", Expression: "true", Message: "{{.metadata"},
+			expectedError: "custom rule message: parsing message",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile([]config.CustomRule{tt.rule})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package changes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// pacDependencyAnnotations are the Pipelines as Code annotations which reference files in the
+// repository that are inlined into a PipelineRun during resolution.
+var pacDependencyAnnotations = []string{
+	"pipelinesascode.tekton.dev/task",
+	"pipelinesascode.tekton.dev/pipeline",
+}
+
+// Set holds the files of a git repository which changed relative to a base ref
+type Set struct {
+	root    string
+	baseRef string
+	files   map[string]bool
+}
+
+// Detect computes the files changed in the git repository containing dir relative to the merge
+// base with baseRef. Uncommitted and untracked files are considered changed.
+func Detect(ctx context.Context, dir, baseRef string) (*Set, error) {
+	root, err := gitOutput(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("finding git repository root: %w", err)
+	}
+	root = strings.TrimSpace(root)
+
+	diff, err := gitOutput(ctx, root, "diff", "--name-only", "--merge-base", baseRef)
+	if err != nil {
+		return nil, fmt.Errorf("listing files changed relative to %s: %w", baseRef, err)
+	}
+	untracked, err := gitOutput(ctx, root, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("listing untracked files: %w", err)
+	}
+
+	files := make(map[string]bool)
+	for _, line := range strings.Split(diff+"\n"+untracked, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files[filepath.Join(root, line)] = true
+		}
+	}
+
+	return &Set{root: root, baseRef: baseRef, files: files}, nil
+}
+
+// BaseRef returns the git ref the set of changes was computed against
+func (s *Set) BaseRef() string {
+	return s.baseRef
+}
+
+// Contains reports whether fname is one of the changed files
+func (s *Set) Contains(fname string) bool {
+	abs, err := filepath.Abs(fname)
+	if err != nil {
+		return false
+	}
+	// Resolve symlinks, e.g. /tmp on macOS, so paths match the ones reported by git.
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	return s.files[abs]
+}
+
+// Affects reports whether fname, or any repository file it depends on, changed
+func (s *Set) Affects(fname string) (bool, error) {
+	if s.Contains(fname) {
+		return true, nil
+	}
+
+	deps, err := Dependencies(fname)
+	if err != nil {
+		return false, err
+	}
+	for _, dep := range deps {
+		if s.Contains(filepath.Join(s.root, dep)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Dependencies returns the repository relative paths of the local files a resource depends on,
+// as referenced by Pipelines as Code annotations.
+func Dependencies(fname string) ([]string, error) {
+	content, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", fname, err)
+	}
+
+	var o metav1.PartialObjectMetadata
+	if err := yaml.Unmarshal(content, &o); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s as k8s resource: %w", fname, err)
+	}
+
+	var deps []string
+	for _, annotation := range pacDependencyAnnotations {
+		value, ok := o.Annotations[annotation]
+		if !ok {
+			continue
+		}
+		for _, ref := range splitAnnotationList(value) {
+			if isLocalReference(ref) {
+				deps = append(deps, filepath.Clean(ref))
+			}
+		}
+	}
+	return deps, nil
+}
+
+// splitAnnotationList parses PaC list annotations such as "[task-a, ./tasks/b.yaml]"
+func splitAnnotationList(value string) []string {
+	value = strings.TrimSpace(value)
+	value = strings.TrimPrefix(value, "[")
+	value = strings.TrimSuffix(value, "]")
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.Trim(strings.TrimSpace(item), `"'`)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isLocalReference reports whether a PaC task or pipeline reference points to a repository file
+// rather than a URL or a Tekton Hub name
+func isLocalReference(ref string) bool {
+	if strings.Contains(ref, "://") {
+		return false
+	}
+	ext := filepath.Ext(ref)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
@@ -0,0 +1,155 @@
+package changes
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// initRepo creates a git repository with the given files committed on a "main" branch
+func initRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	dir, err := filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "test")
+	for name, content := range files {
+		writeFile(t, dir, name, content)
+	}
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %v: %s", args, out)
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+const pipelineRunWithLocalTask = `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: on-push
+  annotations:
+    pipelinesascode.tekton.dev/task: "[git-clone, .tekton/tasks/build.yaml, https://example.com/task.yaml]"
+spec:
+  pipelineRef:
+    name: build
+`
+
+func TestDetect(t *testing.T) {
+	ctx := context.Background()
+	dir := initRepo(t, map[string]string{
+		".tekton/on-push.yaml":     pipelineRunWithLocalTask,
+		".tekton/on-pr.yaml":       "apiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: on-pr\n",
+		".tekton/tasks/build.yaml": "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n",
+		"pipelines/unchanged.yaml": "apiVersion: tekton.dev/v1\nkind: Pipeline\nmetadata:\n  name: unchanged\n",
+	})
+	runGit(t, dir, "checkout", "-q", "-b", "feature")
+
+	// A committed change, an uncommitted change, and an untracked file.
+	writeFile(t, dir, ".tekton/tasks/build.yaml", "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build-v2\n")
+	runGit(t, dir, "commit", "-q", "-am", "change task")
+	writeFile(t, dir, ".tekton/on-pr.yaml", "apiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: on-pr-v2\n")
+	writeFile(t, dir, "pipelines/new.yaml", "apiVersion: tekton.dev/v1\nkind: Pipeline\nmetadata:\n  name: new\n")
+
+	set, err := Detect(ctx, filepath.Join(dir, "pipelines"), "main")
+	require.NoError(t, err)
+	assert.Equal(t, "main", set.BaseRef())
+
+	assert.True(t, set.Contains(filepath.Join(dir, ".tekton/tasks/build.yaml")), "committed change")
+	assert.True(t, set.Contains(filepath.Join(dir, ".tekton/on-pr.yaml")), "uncommitted change")
+	assert.True(t, set.Contains(filepath.Join(dir, "pipelines/new.yaml")), "untracked file")
+	assert.False(t, set.Contains(filepath.Join(dir, "pipelines/unchanged.yaml")))
+	assert.False(t, set.Contains(filepath.Join(dir, ".tekton/on-push.yaml")))
+
+	tests := []struct {
+		name     string
+		fname    string
+		expected bool
+	}{
+		{name: "changed file", fname: ".tekton/on-pr.yaml", expected: true},
+		{name: "changed dependency", fname: ".tekton/on-push.yaml", expected: true},
+		{name: "unchanged file", fname: "pipelines/unchanged.yaml", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			affected, err := set.Affects(filepath.Join(dir, tt.fname))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, affected)
+		})
+	}
+}
+
+func TestDetectErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not a git repository", func(t *testing.T) {
+		_, err := Detect(ctx, t.TempDir(), "main")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "finding git repository root")
+	})
+
+	t.Run("unknown base ref", func(t *testing.T) {
+		dir := initRepo(t, map[string]string{"README.md": "hello"})
+		_, err := Detect(ctx, dir, "origin/does-not-exist")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "listing files changed relative to origin/does-not-exist")
+	})
+}
+
+func TestDependencies(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "run.yaml", `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: run
+  annotations:
+    pipelinesascode.tekton.dev/task: "[git-clone, ./tasks/build.yaml]"
+    pipelinesascode.tekton.dev/pipeline: "pipelines/build.yml"
+`)
+
+	deps, err := Dependencies(filepath.Join(dir, "run.yaml"))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"tasks/build.yaml", "pipelines/build.yml"}, deps)
+
+	_, err = Dependencies(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestSplitAnnotationList(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{value: "git-clone", expected: []string{"git-clone"}},
+		{value: "[git-clone, ./tasks/build.yaml]", expected: []string{"git-clone", "./tasks/build.yaml"}},
+		{value: `["a", 'b']`, expected: []string{"a", "b"}},
+		{value: "[]", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.expected, splitAnnotationList(tt.value))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+// Package cluster fetches the Tekton resources PipelineRuns refer to by name from the cluster of
+// the current kube context, so that PipelineRuns are validated against the Pipelines they would run,
+// and looks up the ServiceAccounts, Secrets, and ConfigMaps runs refer to.
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/document"
+)
+
+// Client fetches Tekton resources from a cluster, and looks up the objects runs refer to
+type Client struct {
+	kube   kubernetes.Interface
+	tekton versioned.Interface
+	// namespace is the namespace of resources which do not set one.
+	namespace string
+}
+
+// New returns a Client of the cluster and namespace of the current kube context, found as kubectl
+// does, e.g. in $KUBECONFIG or in ~/.kube/config
+func New() (*Client, error) {
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
+	namespace, _, err := loader.Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("loading the kube context: %w", err)
+	}
+	config, err := loader.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading the kube context: %w", err)
+	}
+	kube, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating the Kubernetes client: %w", err)
+	}
+	tekton, err := versioned.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("creating the Tekton client: %w", err)
+	}
+	return NewWithClientsets(kube, tekton, namespace), nil
+}
+
+// NewWithClientsets returns a Client fetching resources with a Kubernetes and a Tekton clientset,
+// from namespace unless they set one
+func NewWithClientsets(kube kubernetes.Interface, tekton versioned.Interface, namespace string) *Client {
+	return &Client{kube: kube, tekton: tekton, namespace: namespace}
+}
+
+// DefaultNamespace returns the namespace of resources which do not set one
+func (c *Client) DefaultNamespace() string {
+	return c.namespace
+}
+
+// Exists reports whether the ServiceAccount, Secret, or ConfigMap named name exists in namespace
+func (c *Client) Exists(ctx context.Context, kind, namespace, name string) (bool, error) {
+	var err error
+	switch kind {
+	case "ServiceAccount":
+		_, err = c.kube.CoreV1().ServiceAccounts(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Secret":
+		_, err = c.kube.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	case "ConfigMap":
+		_, err = c.kube.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	default:
+		return false, fmt.Errorf("unsupported kind %s", kind)
+	}
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Pipeline returns the document of the Pipeline named name in namespace, or in the namespace of the
+// Client if namespace is empty. The document only holds the name, namespace, and spec of the
+// Pipeline, leaving out the fields the cluster manages.
+func (c *Client) Pipeline(ctx context.Context, namespace, name string) (document.Document, error) {
+	if namespace == "" {
+		namespace = c.namespace
+	}
+	p, err := c.tekton.TektonV1().Pipelines(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return document.Document{}, fmt.Errorf("fetching Pipeline %s from namespace %s: %w", name, namespace, err)
+	}
+
+	fetched := v1.Pipeline{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "Pipeline"},
+		ObjectMeta: metav1.ObjectMeta{Name: p.Name, Namespace: p.Namespace},
+		Spec:       p.Spec,
+	}
+	content, err := yaml.Marshal(fetched)
+	if err != nil {
+		return document.Document{}, fmt.Errorf("marshalling Pipeline %s: %w", name, err)
+	}
+	docs := document.Split(fmt.Sprintf("cluster:%s/%s", namespace, name), content)
+	if len(docs) != 1 {
+		return document.Document{}, fmt.Errorf("marshalling Pipeline %s: got %d documents", name, len(docs))
+	}
+	return docs[0], nil
+}
@@ -0,0 +1,87 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPipeline(t *testing.T) {
+	client := NewWithClientsets(kubefake.NewSimpleClientset(), fake.NewSimpleClientset(&v1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "build", Namespace: "ci", ResourceVersion: "42", UID: "1234"},
+		Spec:       v1.PipelineSpec{Params: v1.ParamSpecs{{Name: "revision", Type: v1.ParamTypeString}}},
+	}), "ci")
+
+	doc, err := client.Pipeline(context.Background(), "", "build")
+	require.NoError(t, err)
+	assert.Equal(t, "tekton.dev/v1/Pipeline", doc.Key())
+	assert.Equal(t, "build", doc.Name)
+	assert.Equal(t, "cluster:ci/build:1 (Pipeline build)", doc.String())
+	assert.Contains(t, string(doc.Content), "name: revision")
+	assert.NotContains(t, string(doc.Content), "resourceVersion")
+
+	_, err = client.Pipeline(context.Background(), "other", "build")
+	assert.ErrorContains(t, err, `fetching Pipeline build from namespace other: pipelines.tekton.dev "build" not found`)
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+current-context: c
+contexts:
+- name: c
+  context:
+    cluster: k
+    namespace: ci
+clusters:
+- name: k
+  cluster:
+    server: https://127.0.0.1:1
+`), 0600))
+	t.Setenv("KUBECONFIG", kubeconfig)
+	client, err := New()
+	require.NoError(t, err)
+	assert.Equal(t, "ci", client.namespace)
+
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	_, err = New()
+	assert.ErrorContains(t, err, "loading the kube context")
+}
+
+func TestExists(t *testing.T) {
+	client := NewWithClientsets(kubefake.NewSimpleClientset(
+		&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "builder", Namespace: "ci"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "ci"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "other"}},
+	), fake.NewSimpleClientset(), "ci")
+	assert.Equal(t, "ci", client.DefaultNamespace())
+
+	for _, tt := range []struct {
+		kind, namespace, name string
+		exists                bool
+	}{
+		{"ServiceAccount", "ci", "builder", true},
+		{"Secret", "ci", "token", true},
+		{"Secret", "ci", "missing", false},
+		{"ConfigMap", "ci", "settings", false},
+		{"ConfigMap", "other", "settings", true},
+	} {
+		exists, err := client.Exists(context.Background(), tt.kind, tt.namespace, tt.name)
+		require.NoError(t, err)
+		assert.Equal(t, tt.exists, exists, "%s %s/%s", tt.kind, tt.namespace, tt.name)
+	}
+
+	_, err := client.Exists(context.Background(), "Pod", "ci", "builder")
+	assert.ErrorContains(t, err, "unsupported kind Pod")
+}
@@ -0,0 +1,337 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/hashicorp/go-multierror"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFile is the configuration file looked up in the working directory
+const DefaultFile = ".tektor.yaml"
+
+// FileEnv names the environment variable overriding the path of the configuration file
+const FileEnv = "TEKTOR_CONFIG"
+
+// Config is the content of the configuration file
+type Config struct {
+	// Credentials authenticate the resolution of remote Tasks and Pipelines.
+	Credentials Credentials `json:"credentials"`
+	// CustomRules are evaluated against the resolved Pipelines and PipelineRuns.
+	CustomRules []CustomRule `json:"customRules"`
+	// Profile enables an additional rule profile, unless the --profile flag selects others.
+	Profile string `json:"profile"`
+	// Profiles enables additional rule profiles along with Profile, unless the --profile flag
+	// selects others.
+	Profiles []string `json:"profiles"`
+	// Rules override the severity of the findings of rules, keyed by rule ID or name.
+	Rules map[string]RuleSetting `json:"rules"`
+	// ExitCodes override the exit codes of the outcomes of tektor validate, keyed by outcome, see
+	// DefaultExitCodes.
+	ExitCodes map[string]int `json:"exitCodes"`
+}
+
+// Outcomes of a validation, from the most to the least severe
+const (
+	// ExitErrors is the outcome of validations reporting errors.
+	ExitErrors = "errors"
+	// ExitUnsupported is the outcome of validations only failing on resources of unsupported kinds.
+	ExitUnsupported = "unsupported"
+	// ExitWarnings is the outcome of successful validations reporting warnings.
+	ExitWarnings = "warnings"
+)
+
+// DefaultExitCodes are the exit codes of the outcomes of a validation. Successful validations
+// without warnings exit with 0.
+var DefaultExitCodes = map[string]int{
+	ExitErrors:      1,
+	ExitUnsupported: 1,
+	ExitWarnings:    0,
+}
+
+// Severities of the findings of custom rules
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// RuleOff disables a rule, see Config.Rules
+const RuleOff = "off"
+
+// RuleSetting is the severity of the findings of a rule, or RuleOff
+type RuleSetting string
+
+// UnmarshalJSON reads off, which YAML parses as the false boolean, as RuleOff
+func (s *RuleSetting) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value {
+	case false, "false":
+		*s = RuleOff
+	default:
+		*s = RuleSetting(fmt.Sprint(value))
+	}
+	return nil
+}
+
+// CustomRule is a rule defined with a CEL expression, which resources satisfy when it evaluates to
+// true
+type CustomRule struct {
+	// ID identifies the findings of the rule.
+	ID string `json:"id"`
+	// Kinds of the resources the rule applies to, defaults to every kind.
+	Kinds []string `json:"kinds"`
+	// Expression is evaluated with the fields of the resource as variables, e.g.
+	// spec.tasks.all(t, has(t.timeout)).
+	Expression string `json:"expression"`
+	// Message is a text/template rendered with the resource, e.g. "{{.metadata.name}} has no timeout".
+	Message string `json:"message"`
+	// Severity of the findings, SeverityError by default.
+	Severity string `json:"severity"`
+}
+
+// Credentials used by the git and bundles resolvers. Their secret fields expand environment
+// variables, e.g. ${GITHUB_TOKEN}, so secrets need not be stored in the file.
+type Credentials struct {
+	Git        []GitCredential      `json:"git"`
+	Registries []RegistryCredential `json:"registries"`
+}
+
+// GitCredential authenticates the git repositories of a host, either with a token over HTTPS or
+// with a private key or the SSH agent over SSH
+type GitCredential struct {
+	// Host is the host name of the repositories, e.g. github.com.
+	Host string `json:"host"`
+	// Username defaults to git.
+	Username string `json:"username"`
+	// Token is used as the password of HTTPS URLs.
+	Token string `json:"token"`
+	// SSHKey is the path of the private key used for SSH URLs, which use the SSH agent without one.
+	SSHKey string `json:"sshKey"`
+	// SSHKeyPassphrase decrypts SSHKey, if encrypted.
+	SSHKeyPassphrase string `json:"sshKeyPassphrase"`
+}
+
+// RegistryCredential authenticates the bundles of an OCI registry
+type RegistryCredential struct {
+	// Registry is the host of the registry, e.g. quay.io.
+	Registry string `json:"registry"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Path returns the path of the configuration file, which is given by FileEnv or is DefaultFile
+func Path() string {
+	if path := os.Getenv(FileEnv); path != "" {
+		return path
+	}
+	return DefaultFile
+}
+
+// Load reads the configuration file at path. A missing file yields an empty configuration, unless
+// required is set.
+func Load(path string, required bool) (Config, error) {
+	var cfg Config
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("reading configuration: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(content, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing configuration %s: %w", path, err)
+	}
+	if err := cfg.Credentials.expand(); err != nil {
+		return cfg, fmt.Errorf("configuration %s: %w", path, err)
+	}
+	if err := verifyCustomRules(cfg.CustomRules); err != nil {
+		return cfg, fmt.Errorf("configuration %s: %w", path, err)
+	}
+	if err := verifyRules(cfg.Rules); err != nil {
+		return cfg, fmt.Errorf("configuration %s: %w", path, err)
+	}
+	if err := VerifyExitCodes(cfg.ExitCodes, "exitCodes"); err != nil {
+		return cfg, fmt.Errorf("configuration %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// VerifyExitCodes verifies each exit code is of a known outcome, and fits within 0 and 255. Errors
+// point at path, the field holding the exit codes.
+func VerifyExitCodes(codes map[string]int, path string) error {
+	var allErrors error
+	outcomes := make([]string, 0, len(codes))
+	for outcome := range codes {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+	for _, outcome := range outcomes {
+		if _, ok := DefaultExitCodes[outcome]; !ok {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("unknown outcome %q, expected %s, %s, or %s: %s.%s", outcome, ExitErrors, ExitUnsupported, ExitWarnings, path, outcome))
+			continue
+		}
+		if code := codes[outcome]; code < 0 || code > 255 {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("exit code %d is not within 0 and 255: %s.%s", code, path, outcome))
+		}
+	}
+	return allErrors
+}
+
+// verifyRules verifies each rule setting is known. Rule IDs are verified by the validation, which
+// knows the rules.
+func verifyRules(rules map[string]RuleSetting) error {
+	var allErrors error
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		switch rules[id] {
+		case RuleOff, SeverityWarning, SeverityError:
+		default:
+			allErrors = multierror.Append(allErrors, fmt.Errorf("unknown setting %q, expected %s, %s, or %s: rules.%s", rules[id], RuleOff, SeverityWarning, SeverityError, id))
+		}
+	}
+	return allErrors
+}
+
+// verifyCustomRules verifies each custom rule has an ID, unique among them, an expression, and a
+// known severity
+func verifyCustomRules(rules []CustomRule) error {
+	var allErrors error
+	ids := map[string]bool{}
+	for i, rule := range rules {
+		path := fmt.Sprintf("customRules[%d]", i)
+		switch {
+		case rule.ID == "":
+			allErrors = multierror.Append(allErrors, fmt.Errorf("missing id: %s.id", path))
+		case ids[rule.ID]:
+			allErrors = multierror.Append(allErrors, fmt.Errorf("duplicate id %q: %s.id", rule.ID, path))
+		}
+		ids[rule.ID] = true
+		if rule.Expression == "" {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("missing expression: %s.expression", path))
+		}
+		if rule.Severity != "" && rule.Severity != SeverityError && rule.Severity != SeverityWarning {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("unknown severity %q, expected %s or %s: %s.severity", rule.Severity, SeverityError, SeverityWarning, path))
+		}
+	}
+	return allErrors
+}
+
+// envRegex matches the references to environment variables expanded by the secret fields
+var envRegex = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// expandEnv expands the references to environment variables of value, reporting unset ones
+func expandEnv(value, path string) (string, error) {
+	var err error
+	expanded := envRegex.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envRegex.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			err = multierror.Append(err, fmt.Errorf("environment variable %s is not set: %s", name, path))
+		}
+		return v
+	})
+	return expanded, err
+}
+
+// expand expands the environment variables of the secret fields and verifies each credential
+// names what it authenticates
+func (c *Credentials) expand() error {
+	var allErrors error
+	var err error
+	for i := range c.Git {
+		cred := &c.Git[i]
+		path := fmt.Sprintf("credentials.git[%d]", i)
+		if cred.Host == "" {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("missing host: %s.host", path))
+		}
+		if cred.Token, err = expandEnv(cred.Token, path+".token"); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+		if cred.SSHKey, err = expandEnv(cred.SSHKey, path+".sshKey"); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+		if cred.SSHKeyPassphrase, err = expandEnv(cred.SSHKeyPassphrase, path+".sshKeyPassphrase"); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+	for i := range c.Registries {
+		cred := &c.Registries[i]
+		path := fmt.Sprintf("credentials.registries[%d]", i)
+		if cred.Registry == "" {
+			allErrors = multierror.Append(allErrors, fmt.Errorf("missing registry: %s.registry", path))
+		}
+		if cred.Username, err = expandEnv(cred.Username, path+".username"); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+		if cred.Password, err = expandEnv(cred.Password, path+".password"); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+	return allErrors
+}
+
+// GitCredential returns the credential of the host of the git repository URL, if any. Both URLs
+// and the scp-like syntax of SSH, e.g. git@github.com:org/repo.git, are supported.
+func (c Credentials) GitCredential(repoURL string) *GitCredential {
+	host := gitHost(repoURL)
+	for i, cred := range c.Git {
+		if strings.EqualFold(cred.Host, host) {
+			return &c.Git[i]
+		}
+	}
+	return nil
+}
+
+// gitHost returns the host name of a git repository URL
+func gitHost(repoURL string) string {
+	if u, err := url.Parse(repoURL); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	// scp-like syntax: [user@]host:path
+	host, _, found := strings.Cut(repoURL, ":")
+	if !found {
+		return ""
+	}
+	if _, after, found := strings.Cut(host, "@"); found {
+		host = after
+	}
+	return host
+}
+
+// Keychain returns a keychain authenticating the configured registries, which falls back to
+// fallback, e.g. authn.DefaultKeychain, for other registries
+func (c Credentials) Keychain(fallback authn.Keychain) authn.Keychain {
+	if len(c.Registries) == 0 {
+		return fallback
+	}
+	return authn.NewMultiKeychain(registryKeychain(c.Registries), fallback)
+}
+
+// registryKeychain authenticates the configured registries
+type registryKeychain []RegistryCredential
+
+// Resolve implements authn.Keychain. Unknown registries are anonymous, so that the next keychain
+// of an authn.NewMultiKeychain is used.
+func (k registryKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	for _, cred := range k {
+		if cred.Registry == target.RegistryStr() {
+			return authn.FromConfig(authn.AuthConfig{Username: cred.Username, Password: cred.Password}), nil
+		}
+	}
+	return authn.Anonymous, nil
+}
@@ -0,0 +1,233 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Setenv("TEKTOR_TEST_TOKEN", "secret")
+
+	tests := []struct {
+		name          string
+		content       string
+		required      bool
+		expected      Config
+		expectedError string
+	}{
+		{
+			name: "credentials",
+			content: `
+credentials:
+  git:
+    - host: github.com
+      token: ${TEKTOR_TEST_TOKEN}
+    - host: gitlab.example.com
+      username: deploy
+      sshKey: /keys/id_ed25519
+  registries:
+    - registry: quay.io
+      username: robot
+      password: prefix-${TEKTOR_TEST_TOKEN}
+`,
+			expected: Config{Credentials: Credentials{
+				Git: []GitCredential{
+					{Host: "github.com", Token: "secret"},
+					{Host: "gitlab.example.com", Username: "deploy", SSHKey: "/keys/id_ed25519"},
+				},
+				Registries: []RegistryCredential{{Registry: "quay.io", Username: "robot", Password: "prefix-secret"}},
+			}},
+		},
+		{
+			name:     "profile",
+			content:  "profile: konflux\n",
+			expected: Config{Profile: "konflux"},
+		},
+		{
+			name:     "profiles",
+			content:  "profiles: [konflux, lint]\n",
+			expected: Config{Profiles: []string{"konflux", "lint"}},
+		},
+		{
+			name: "unset environment variable",
+			content: `
+credentials:
+  git:
+    - host: github.com
+      token: ${TEKTOR_TEST_UNSET}
+`,
+			expectedError: "environment variable TEKTOR_TEST_UNSET is not set: credentials.git[0].token",
+		},
+		{
+			name: "missing host and registry",
+			content: `
+credentials:
+  git:
+    - token: secret
+  registries:
+    - username: robot
+`,
+			expectedError: "missing host: credentials.git[0].host",
+		},
+		{
+			name: "custom rules",
+			content: `
+customRules:
+  - id: task-timeouts
+    kinds: [Pipeline]
+    expression: spec.tasks.all(t, has(t.timeout))
+    message: "{{.metadata.name}} has PipelineTasks without a timeout"
+    severity: warning
+`,
+			expected: Config{CustomRules: []CustomRule{{
+				ID:         "task-timeouts",
+				Kinds:      []string{"Pipeline"},
+				Expression: "spec.tasks.all(t, has(t.timeout))",
+				Message:    "{{.metadata.name}} has PipelineTasks without a timeout",
+				Severity:   SeverityWarning,
+			}}},
+		},
+		{
+			name: "malformed custom rules",
+			content: `
+customRules:
+  - id: timeouts
+    expression: "true"
+  - id: timeouts
+    severity: fatal
+`,
+			expectedError: `duplicate id "timeouts": customRules[1].id`,
+		},
+		{
+			name: "rules",
+			content: `
+rules:
+  TEK0101: off
+  unused-workspace: warning
+  TEK0804: error
+`,
+			expected: Config{Rules: map[string]RuleSetting{"TEK0101": RuleOff, "unused-workspace": SeverityWarning, "TEK0804": SeverityError}},
+		},
+		{
+			name:          "unknown rule setting",
+			content:       "rules:\n  TEK0101: info\n",
+			expectedError: `unknown setting "info", expected off, warning, or error: rules.TEK0101`,
+		},
+		{
+			name:     "exit codes",
+			content:  "exitCodes:\n  warnings: 3\n  unsupported: 2\n",
+			expected: Config{ExitCodes: map[string]int{ExitWarnings: 3, ExitUnsupported: 2}},
+		},
+		{
+			name:          "unknown outcome",
+			content:       "exitCodes:\n  failures: 3\n",
+			expectedError: `unknown outcome "failures", expected errors, unsupported, or warnings: exitCodes.failures`,
+		},
+		{
+			name:          "exit code out of range",
+			content:       "exitCodes:\n  errors: 256\n",
+			expectedError: `exit code 256 is not within 0 and 255: exitCodes.errors`,
+		},
+		{
+			name:          "rule setting of another type",
+			content:       "rules:\n  TEK0101: true\n",
+			expectedError: `unknown setting "true", expected off, warning, or error: rules.TEK0101`,
+		},
+		{
+			name:          "unknown field",
+			content:       "credential: {}",
+			expectedError: `unknown field "credential"`,
+		},
+		{
+			name:     "missing optional file",
+			expected: Config{},
+		},
+		{
+			name:          "missing required file",
+			required:      true,
+			expectedError: "reading configuration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultFile)
+			if tt.content != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+			}
+
+			cfg, err := Load(path, tt.required)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg)
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Setenv(FileEnv, "")
+	assert.Equal(t, DefaultFile, Path())
+	t.Setenv(FileEnv, "/etc/tektor.yaml")
+	assert.Equal(t, "/etc/tektor.yaml", Path())
+}
+
+func TestGitCredential(t *testing.T) {
+	creds := Credentials{Git: []GitCredential{{Host: "github.com", Token: "secret"}}}
+
+	tests := []struct {
+		repoURL  string
+		expected bool
+	}{
+		{repoURL: "https://github.com/org/tasks.git", expected: true},
+		{repoURL: "https://GitHub.com/org/tasks.git", expected: true},
+		{repoURL: "ssh://git@github.com:22/org/tasks.git", expected: true},
+		{repoURL: "git@github.com:org/tasks.git", expected: true},
+		{repoURL: "https://gitlab.com/org/tasks.git"},
+		{repoURL: "/local/tasks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repoURL, func(t *testing.T) {
+			cred := creds.GitCredential(tt.repoURL)
+			if !tt.expected {
+				assert.Nil(t, cred)
+				return
+			}
+			require.NotNil(t, cred)
+			assert.Equal(t, "secret", cred.Token)
+		})
+	}
+}
+
+func TestKeychain(t *testing.T) {
+	fallback := authn.NewMultiKeychain()
+	assert.Equal(t, fallback, Credentials{}.Keychain(fallback), "the fallback is used without registries")
+
+	keychain := Credentials{Registries: []RegistryCredential{
+		{Registry: "quay.io", Username: "robot", Password: "secret"},
+	}}.Keychain(fallback)
+
+	repo, err := name.NewRepository("quay.io/org/tasks")
+	require.NoError(t, err)
+	auth, err := keychain.Resolve(repo)
+	require.NoError(t, err)
+	authConfig, err := auth.Authorization()
+	require.NoError(t, err)
+	assert.Equal(t, &authn.AuthConfig{Username: "robot", Password: "secret"}, authConfig)
+
+	repo, err = name.NewRepository("ghcr.io/org/tasks")
+	require.NoError(t, err)
+	auth, err = keychain.Resolve(repo)
+	require.NoError(t, err)
+	assert.Equal(t, authn.Anonymous, auth)
+}
@@ -0,0 +1,184 @@
+// Package diff reports the changes between two versions of a Pipeline or Task which break their
+// users, e.g. PipelineRuns or PipelineTasks providing params and workspaces, and consuming results.
+package diff
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// Change is a breaking change of the interface of a Pipeline or Task
+type Change struct {
+	// Message describes the change, e.g. "url param was removed".
+	Message string
+	// Path is the path of the field the change is about, within the version after the change if it
+	// still holds the field, e.g. spec.params[0].
+	Path string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s", c.Message, c.Path)
+}
+
+// Pipelines returns the breaking changes from the before to the after version of a pipeline spec
+func Pipelines(before, after v1.PipelineSpec) []Change {
+	var changes []Change
+	changes = append(changes, params(before.Params, after.Params)...)
+	changes = append(changes, pipelineResults(before.Results, after.Results)...)
+	changes = append(changes, pipelineWorkspaces(before.Workspaces, after.Workspaces)...)
+	return changes
+}
+
+// Tasks returns the breaking changes from the before to the after version of a task spec
+func Tasks(before, after v1.TaskSpec) []Change {
+	var changes []Change
+	changes = append(changes, params(before.Params, after.Params)...)
+	changes = append(changes, taskResults(before.Results, after.Results)...)
+	changes = append(changes, taskWorkspaces(before.Workspaces, after.Workspaces)...)
+	return changes
+}
+
+// params reports the params removed, retyped, or losing their default, and the params added without
+// a default
+func params(before, after v1.ParamSpecs) []Change {
+	var changes []Change
+	for i, oldParam := range before {
+		j, newParam := find(after, func(p v1.ParamSpec) string { return p.Name }, oldParam.Name)
+		if j < 0 {
+			changes = append(changes, Change{Message: fmt.Sprintf("%s param was removed", oldParam.Name), Path: fmt.Sprintf("spec.params[%d]", i)})
+			continue
+		}
+		path := fmt.Sprintf("spec.params[%d]", j)
+		if oldType, newType := paramType(oldParam), paramType(newParam); oldType != newType {
+			changes = append(changes, Change{Message: fmt.Sprintf("%s param changed type from %s to %s", oldParam.Name, oldType, newType), Path: path})
+		}
+		if oldParam.Default != nil && newParam.Default == nil {
+			changes = append(changes, Change{Message: fmt.Sprintf("%s param no longer has a default", oldParam.Name), Path: path})
+		}
+		keys := make([]string, 0, len(oldParam.Properties))
+		for key := range oldParam.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := newParam.Properties[key]; !ok {
+				changes = append(changes, Change{Message: fmt.Sprintf("%s key of the %s param was removed", key, oldParam.Name), Path: path + ".properties"})
+			}
+		}
+	}
+	for j, newParam := range after {
+		if i, _ := find(before, func(p v1.ParamSpec) string { return p.Name }, newParam.Name); i < 0 && newParam.Default == nil {
+			changes = append(changes, Change{Message: fmt.Sprintf("%s param was added without a default", newParam.Name), Path: fmt.Sprintf("spec.params[%d]", j)})
+		}
+	}
+	return changes
+}
+
+// paramType returns the type of a param, which defaults to string
+func paramType(param v1.ParamSpec) v1.ParamType {
+	if param.Type == "" {
+		return v1.ParamTypeString
+	}
+	return param.Type
+}
+
+// pipelineResults reports the results removed or retyped
+func pipelineResults(before, after []v1.PipelineResult) []Change {
+	var changes []Change
+	for i, oldResult := range before {
+		j, newResult := find(after, func(r v1.PipelineResult) string { return r.Name }, oldResult.Name)
+		changes = append(changes, result(oldResult.Name, i, oldResult.Type, j, newResult.Type)...)
+	}
+	return changes
+}
+
+// taskResults reports the results removed or retyped
+func taskResults(before, after []v1.TaskResult) []Change {
+	var changes []Change
+	for i, oldResult := range before {
+		j, newResult := find(after, func(r v1.TaskResult) string { return r.Name }, oldResult.Name)
+		changes = append(changes, result(oldResult.Name, i, oldResult.Type, j, newResult.Type)...)
+	}
+	return changes
+}
+
+// result reports the removal of the name result, found at index i of the results before and j of the
+// results after, or the change of its type. Types default to string.
+func result(name string, i int, oldType v1.ResultsType, j int, newType v1.ResultsType) []Change {
+	if j < 0 {
+		return []Change{{Message: fmt.Sprintf("%s result was removed", name), Path: fmt.Sprintf("spec.results[%d]", i)}}
+	}
+	if oldType == "" {
+		oldType = v1.ResultsTypeString
+	}
+	if newType == "" {
+		newType = v1.ResultsTypeString
+	}
+	if oldType != newType {
+		return []Change{{Message: fmt.Sprintf("%s result changed type from %s to %s", name, oldType, newType), Path: fmt.Sprintf("spec.results[%d]", j)}}
+	}
+	return nil
+}
+
+// pipelineWorkspaces reports the workspaces removed, or renamed, and the required workspaces added
+func pipelineWorkspaces(before, after []v1.PipelineWorkspaceDeclaration) []Change {
+	var oldDecls, newDecls []workspaceDeclaration
+	for _, w := range before {
+		oldDecls = append(oldDecls, workspaceDeclaration{name: w.Name, optional: w.Optional})
+	}
+	for _, w := range after {
+		newDecls = append(newDecls, workspaceDeclaration{name: w.Name, optional: w.Optional})
+	}
+	return workspaces(oldDecls, newDecls)
+}
+
+// taskWorkspaces reports the workspaces removed, or renamed, and the required workspaces added
+func taskWorkspaces(before, after []v1.WorkspaceDeclaration) []Change {
+	var oldDecls, newDecls []workspaceDeclaration
+	for _, w := range before {
+		oldDecls = append(oldDecls, workspaceDeclaration{name: w.Name, optional: w.Optional})
+	}
+	for _, w := range after {
+		newDecls = append(newDecls, workspaceDeclaration{name: w.Name, optional: w.Optional})
+	}
+	return workspaces(oldDecls, newDecls)
+}
+
+// workspaceDeclaration is what matters to the users of a workspace declared by a Pipeline or Task
+type workspaceDeclaration struct {
+	name     string
+	optional bool
+}
+
+// workspaces reports the workspaces removed, or renamed, made required, or added as required
+func workspaces(before, after []workspaceDeclaration) []Change {
+	var changes []Change
+	for i, oldWorkspace := range before {
+		j, newWorkspace := find(after, func(w workspaceDeclaration) string { return w.name }, oldWorkspace.name)
+		switch {
+		case j < 0:
+			changes = append(changes, Change{Message: fmt.Sprintf("%s workspace was removed or renamed", oldWorkspace.name), Path: fmt.Sprintf("spec.workspaces[%d]", i)})
+		case oldWorkspace.optional && !newWorkspace.optional:
+			changes = append(changes, Change{Message: fmt.Sprintf("%s workspace is no longer optional", oldWorkspace.name), Path: fmt.Sprintf("spec.workspaces[%d]", j)})
+		}
+	}
+	for j, newWorkspace := range after {
+		if i, _ := find(before, func(w workspaceDeclaration) string { return w.name }, newWorkspace.name); i < 0 && !newWorkspace.optional {
+			changes = append(changes, Change{Message: fmt.Sprintf("%s workspace was added and is not optional", newWorkspace.name), Path: fmt.Sprintf("spec.workspaces[%d]", j)})
+		}
+	}
+	return changes
+}
+
+// find returns the index of the first item named name, along with the item, or -1
+func find[T any](items []T, nameOf func(T) string, name string) (int, T) {
+	for i, item := range items {
+		if nameOf(item) == name {
+			return i, item
+		}
+	}
+	var zero T
+	return -1, zero
+}
@@ -0,0 +1,156 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestTasks(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		expected []Change
+	}{
+		{
+			name: "compatible",
+			before: `
+params:
+  - name: url
+  - name: revision
+    default: main
+results:
+  - name: digest
+workspaces:
+  - name: source
+`,
+			after: `
+params:
+  - name: revision
+    type: string
+    default: main
+  - name: url
+  - name: depth
+    default: "1"
+results:
+  - name: digest
+    type: string
+  - name: url
+workspaces:
+  - name: source
+  - name: cache
+    optional: true
+`,
+		},
+		{
+			name: "params",
+			before: `
+params:
+  - name: url
+  - name: tags
+    type: array
+  - name: revision
+    default: main
+  - name: config
+    type: object
+    properties:
+      env: {}
+      region: {}
+`,
+			after: `
+params:
+  - name: tags
+  - name: revision
+  - name: config
+    type: object
+    properties:
+      env: {}
+  - name: depth
+`,
+			expected: []Change{
+				{Message: "url param was removed", Path: "spec.params[0]"},
+				{Message: "tags param changed type from array to string", Path: "spec.params[0]"},
+				{Message: "revision param no longer has a default", Path: "spec.params[1]"},
+				{Message: "region key of the config param was removed", Path: "spec.params[2].properties"},
+				{Message: "depth param was added without a default", Path: "spec.params[3]"},
+			},
+		},
+		{
+			name: "results",
+			before: `
+results:
+  - name: digest
+  - name: tags
+    type: array
+`,
+			after: `
+results:
+  - name: tags
+`,
+			expected: []Change{
+				{Message: "digest result was removed", Path: "spec.results[0]"},
+				{Message: "tags result changed type from array to string", Path: "spec.results[0]"},
+			},
+		},
+		{
+			name: "workspaces",
+			before: `
+workspaces:
+  - name: source
+  - name: cache
+    optional: true
+`,
+			after: `
+workspaces:
+  - name: sources
+  - name: cache
+`,
+			expected: []Change{
+				{Message: "source workspace was removed or renamed", Path: "spec.workspaces[0]"},
+				{Message: "cache workspace is no longer optional", Path: "spec.workspaces[1]"},
+				{Message: "sources workspace was added and is not optional", Path: "spec.workspaces[0]"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before, after v1.TaskSpec
+			require.NoError(t, yaml.Unmarshal([]byte(tt.before), &before))
+			require.NoError(t, yaml.Unmarshal([]byte(tt.after), &after))
+			assert.Equal(t, tt.expected, Tasks(before, after))
+		})
+	}
+}
+
+func TestPipelines(t *testing.T) {
+	var before, after v1.PipelineSpec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+params:
+  - name: url
+results:
+  - name: digest
+    value: $(tasks.build.results.digest)
+workspaces:
+  - name: source
+`), &before))
+	require.NoError(t, yaml.Unmarshal([]byte(`
+params:
+  - name: url
+    type: array
+workspaces:
+  - name: source
+    optional: true
+`), &after))
+
+	assert.Equal(t, []Change{
+		{Message: "url param changed type from string to array", Path: "spec.params[0]"},
+		{Message: "digest result was removed", Path: "spec.results[0]"},
+	}, Pipelines(before, after))
+	assert.Empty(t, Pipelines(before, before))
+	assert.Equal(t, "digest result was removed: spec.results[0]", Pipelines(before, after)[1].String())
+}
@@ -0,0 +1,221 @@
+// Package docs generates the Markdown documentation of Pipelines and Tasks from their spec, e.g. for
+// the README of a catalog.
+package docs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// Pipeline returns the documentation of a Pipeline: its description, params, workspaces, results,
+// and the PipelineTasks it runs
+func Pipeline(name string, spec v1.PipelineSpec) string {
+	var b strings.Builder
+	heading(&b, name, spec.Description)
+	params(&b, spec.Params)
+
+	var rows [][]string
+	for _, w := range spec.Workspaces {
+		rows = append(rows, []string{code(w.Name), yesNo(w.Optional), w.Description})
+	}
+	table(&b, "Workspaces", []string{"Name", "Optional", "Description"}, rows)
+
+	rows = nil
+	for _, r := range spec.Results {
+		rows = append(rows, []string{code(r.Name), resultType(r.Type), r.Description})
+	}
+	table(&b, "Results", []string{"Name", "Type", "Description"}, rows)
+
+	pipelineTasks(&b, "Tasks", spec.Tasks)
+	pipelineTasks(&b, "Finally", spec.Finally)
+	return b.String()
+}
+
+// Task returns the documentation of a Task: its description, params, workspaces, results, and steps
+func Task(name string, spec v1.TaskSpec) string {
+	var b strings.Builder
+	heading(&b, name, spec.Description)
+	params(&b, spec.Params)
+
+	var rows [][]string
+	for _, w := range spec.Workspaces {
+		rows = append(rows, []string{code(w.Name), yesNo(w.Optional), yesNo(w.ReadOnly), w.Description})
+	}
+	table(&b, "Workspaces", []string{"Name", "Optional", "Read only", "Description"}, rows)
+
+	rows = nil
+	for _, r := range spec.Results {
+		rows = append(rows, []string{code(r.Name), resultType(r.Type), r.Description})
+	}
+	table(&b, "Results", []string{"Name", "Type", "Description"}, rows)
+
+	rows = nil
+	for _, s := range spec.Steps {
+		rows = append(rows, []string{code(s.Name), code(s.Image)})
+	}
+	table(&b, "Steps", []string{"Name", "Image"}, rows)
+	return b.String()
+}
+
+// heading writes the title of a resource followed by its description
+func heading(b *strings.Builder, name, description string) {
+	fmt.Fprintf(b, "# %s\n", name)
+	if description = strings.TrimSpace(description); description != "" {
+		fmt.Fprintf(b, "\n%s\n", description)
+	}
+}
+
+// params writes the table of the params of a resource
+func params(b *strings.Builder, params v1.ParamSpecs) {
+	var rows [][]string
+	for _, p := range params {
+		paramType := string(p.Type)
+		if paramType == "" {
+			paramType = string(v1.ParamTypeString)
+		}
+		def := "*required*"
+		if p.Default != nil {
+			def = code(paramValue(*p.Default))
+		}
+		rows = append(rows, []string{code(p.Name), paramType, def, p.Description})
+	}
+	table(b, "Params", []string{"Name", "Type", "Default", "Description"}, rows)
+}
+
+// pipelineTasks writes the table of the PipelineTasks of a section of a Pipeline
+func pipelineTasks(b *strings.Builder, title string, pipelineTasks []v1.PipelineTask) {
+	var rows [][]string
+	for _, pt := range pipelineTasks {
+		var runAfter []string
+		for _, name := range pt.RunAfter {
+			runAfter = append(runAfter, code(name))
+		}
+		rows = append(rows, []string{code(pt.Name), source(pt), strings.Join(runAfter, ", "), pt.Description})
+	}
+	table(b, title, []string{"Name", "Task", "Run after", "Description"}, rows)
+}
+
+// source describes where the spec of a PipelineTask comes from, e.g. embedded, or the bundle and the
+// name of the Task for the bundles resolver
+func source(pt v1.PipelineTask) string {
+	switch {
+	case pt.TaskSpec != nil:
+		return "embedded"
+	case pt.PipelineSpec != nil:
+		return "embedded Pipeline"
+	case pt.TaskRef != nil && pt.TaskRef.Resolver == "":
+		if pt.TaskRef.APIVersion != "" {
+			return fmt.Sprintf("%s %s Custom Task", pt.TaskRef.APIVersion, pt.TaskRef.Kind)
+		}
+		return code(pt.TaskRef.Name)
+	case pt.TaskRef != nil:
+		return resolverSource(pt.TaskRef.ResolverRef)
+	case pt.PipelineRef != nil && pt.PipelineRef.Resolver == "":
+		return code(pt.PipelineRef.Name) + " Pipeline"
+	case pt.PipelineRef != nil:
+		return resolverSource(pt.PipelineRef.ResolverRef) + " Pipeline"
+	}
+	return ""
+}
+
+// resolverSource describes a reference through a remote resolver by its main params
+func resolverSource(ref v1.ResolverRef) string {
+	param := func(name string) string {
+		for _, p := range ref.Params {
+			if p.Name == name {
+				return paramValue(p.Value)
+			}
+		}
+		return ""
+	}
+	var parts []string
+	switch ref.Resolver {
+	case "bundles":
+		parts = []string{param("bundle"), param("name")}
+	case "git":
+		parts = []string{param("url"), param("revision"), param("pathInRepo")}
+	case "hub":
+		parts = []string{param("name"), param("version")}
+	default:
+		for _, p := range ref.Params {
+			parts = append(parts, fmt.Sprintf("%s=%s", p.Name, paramValue(p.Value)))
+		}
+	}
+	var described []string
+	for _, part := range parts {
+		if part != "" {
+			described = append(described, code(part))
+		}
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s", ref.Resolver, strings.Join(described, " ")))
+}
+
+// paramValue returns a param value as written in YAML for strings, or as JSON otherwise
+func paramValue(value v1.ParamValue) string {
+	if value.Type == v1.ParamTypeString {
+		return value.StringVal
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// resultType returns the type of a result, which defaults to string
+func resultType(resultsType v1.ResultsType) string {
+	if resultsType == "" {
+		return string(v1.ResultsTypeString)
+	}
+	return string(resultsType)
+}
+
+// table writes a section holding a Markdown table, unless there are no rows
+func table(b *strings.Builder, title string, header []string, rows [][]string) {
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n\n", title)
+	fmt.Fprintf(b, "| %s |\n", strings.Join(header, " | "))
+	separators := make([]string, len(header))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	fmt.Fprintf(b, "| %s |\n", strings.Join(separators, " | "))
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = escape(cell)
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
+	}
+}
+
+// escape makes text fit a cell of a Markdown table
+func escape(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// code formats text as inline code, or returns an empty string for empty text
+func code(text string) string {
+	if text == "" {
+		return ""
+	}
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
+
+// yesNo formats a boolean for a table
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,144 @@
+package docs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestPipeline(t *testing.T) {
+	var spec v1.PipelineSpec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+description: |
+  Builds and pushes the image.
+params:
+  - name: url
+    description: URL of the repository
+  - name: tags
+    type: array
+    default: [latest]
+    description: Tags of the image, e.g. latest | stable
+workspaces:
+  - name: source
+    description: Cloned sources
+  - name: cache
+    optional: true
+results:
+  - name: digest
+    description: |
+      Digest of
+      the image
+tasks:
+  - name: clone
+    taskRef:
+      resolver: git
+      params:
+        - name: url
+          value: https://github.com/example/tasks.git
+        - name: revision
+          value: 0123456789abcdef0123456789abcdef01234567
+        - name: pathInRepo
+          value: task/git-clone.yaml
+  - name: build
+    runAfter: [clone]
+    taskRef:
+      resolver: bundles
+      params:
+        - name: bundle
+          value: quay.io/example/task-build:0.1
+        - name: name
+          value: build
+        - name: kind
+          value: task
+  - name: scan
+    description: Scans the image
+    taskRef:
+      name: scan
+  - name: approve
+    taskRef:
+      apiVersion: example.dev/v1
+      kind: Approval
+finally:
+  - name: notify
+    taskSpec:
+      steps:
+        - image: alpine:latest
+`), &spec))
+
+	assert.Equal(t, "# build\n"+`
+Builds and pushes the image.
+
+## Params
+
+| Name | Type | Default | Description |
+| --- | --- | --- | --- |
+| `+"`url`"+` | string | *required* | URL of the repository |
+| `+"`tags`"+` | array | `+"`[\"latest\"]`"+` | Tags of the image, e.g. latest \| stable |
+
+## Workspaces
+
+| Name | Optional | Description |
+| --- | --- | --- |
+| `+"`source`"+` | no | Cloned sources |
+| `+"`cache`"+` | yes |  |
+
+## Results
+
+| Name | Type | Description |
+| --- | --- | --- |
+| `+"`digest`"+` | string | Digest of<br>the image |
+
+## Tasks
+
+| Name | Task | Run after | Description |
+| --- | --- | --- | --- |
+| `+"`clone`"+` | git `+"`https://github.com/example/tasks.git` `0123456789abcdef0123456789abcdef01234567` `task/git-clone.yaml`"+` |  |  |
+| `+"`build`"+` | bundles `+"`quay.io/example/task-build:0.1` `build`"+` | `+"`clone`"+` |  |
+| `+"`scan`"+` | `+"`scan`"+` |  | Scans the image |
+| `+"`approve`"+` | example.dev/v1 Approval Custom Task |  |  |
+
+## Finally
+
+| Name | Task | Run after | Description |
+| --- | --- | --- | --- |
+| `+"`notify`"+` | embedded |  |  |
+`, Pipeline("build", spec))
+}
+
+func TestTask(t *testing.T) {
+	assert.Equal(t, "# git-clone\n"+`
+## Params
+
+| Name | Type | Default | Description |
+| --- | --- | --- | --- |
+| `+"`revision`"+` | string | `+"`main`"+` |  |
+
+## Workspaces
+
+| Name | Optional | Read only | Description |
+| --- | --- | --- | --- |
+| `+"`output`"+` | no | no | Where the repository is cloned |
+
+## Results
+
+| Name | Type | Description |
+| --- | --- | --- |
+| `+"`commit`"+` | string |  |
+
+## Steps
+
+| Name | Image |
+| --- | --- |
+| `+"`clone`"+` | `+"`alpine/git:latest`"+` |
+`, Task("git-clone", v1.TaskSpec{
+		Params:     v1.ParamSpecs{{Name: "revision", Default: v1.NewStructuredValues("main")}},
+		Workspaces: []v1.WorkspaceDeclaration{{Name: "output", Description: "Where the repository is cloned"}},
+		Results:    []v1.TaskResult{{Name: "commit"}},
+		Steps:      []v1.Step{{Name: "clone", Image: "alpine/git:latest"}},
+	}))
+
+	assert.Equal(t, "# empty\n", Task("empty", v1.TaskSpec{}))
+}
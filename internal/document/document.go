@@ -0,0 +1,339 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+	yamlv3 "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// Document is a single YAML document from a, possibly multi-document, source along with the
+// information needed to point users back to it
+type Document struct {
+	Source string // Name of the file, or other source, the document was read from
+	Index  int    // Position of the document within the source, starting at 0
+	Offset int    // Byte offset of the first byte of the document within the source
+	End    int    // Byte offset following the last byte of the document within the source
+	Line   int    // Line number of the first line of the document within the source, starting at 1
+
+	APIVersion string
+	Kind       string
+	Name       string
+
+	Content []byte
+
+	// Err is set when the document could not be decoded as a Kubernetes resource. This is not
+	// fatal to splitting since some sources, e.g. Pipelines as Code templates, are only valid
+	// YAML after further processing.
+	Err error
+}
+
+// Key returns the apiVersion and kind of the document, e.g. tekton.dev/v1/Pipeline
+func (d Document) Key() string {
+	return fmt.Sprintf("%s/%s", d.APIVersion, d.Kind)
+}
+
+// String describes where the document comes from, e.g. "pipelines.yaml:12 (Pipeline build)"
+func (d Document) String() string {
+	location := fmt.Sprintf("%s:%d", d.Source, d.Line)
+	if d.Kind == "" {
+		return location
+	}
+	if d.Name == "" {
+		return fmt.Sprintf("%s (%s)", location, d.Kind)
+	}
+	return fmt.Sprintf("%s (%s %s)", location, d.Kind, d.Name)
+}
+
+// FieldLine returns the line of the field at path, e.g. spec.steps[0].image, within the source of
+// the document. Items of lists are selected by index, or by name as in spec.params[image]. Paths
+// leading to no field, e.g. into resolved content which the document does not hold, give the line of
+// the closest field enclosing them, down to the start of the document.
+func (d Document) FieldLine(path string) int {
+	var root yamlv3.Node
+	if path == "" || d.Err != nil || yamlv3.Unmarshal(d.Content, &root) != nil || len(root.Content) == 0 {
+		return d.Line
+	}
+	node, line := root.Content[0], 0
+	for _, segment := range fieldPathSegments(path) {
+		child, childLine := childNode(node, segment)
+		if child == nil {
+			break
+		}
+		node, line = child, childLine
+	}
+	if line == 0 {
+		return d.Line
+	}
+	return d.Line + line - 1
+}
+
+// fieldPathSegments splits a field path into the keys and indexes it is made of, e.g.
+// metadata.annotations[example.com/key] into metadata, annotations, and example.com/key
+func fieldPathSegments(path string) []string {
+	var segments []string
+	for path != "" {
+		if rest, ok := strings.CutPrefix(path, "["); ok {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return append(segments, rest)
+			}
+			segments = append(segments, rest[:end])
+			path = strings.TrimPrefix(rest[end+1:], ".")
+			continue
+		}
+		end := strings.IndexAny(path, ".[")
+		if end < 0 {
+			return append(segments, path)
+		}
+		segments = append(segments, path[:end])
+		path = strings.TrimPrefix(path[end:], ".")
+	}
+	return segments
+}
+
+// childNode returns the value of the key segment of a mapping node, or the item of a sequence node
+// at the index segment or named segment, along with the line of the key or item, or nil if there is
+// none
+func childNode(node *yamlv3.Node, segment string) (*yamlv3.Node, int) {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == segment {
+				return node.Content[i+1], node.Content[i].Line
+			}
+		}
+	case yamlv3.SequenceNode:
+		if index, err := strconv.Atoi(segment); err == nil {
+			if index >= 0 && index < len(node.Content) {
+				return node.Content[index], node.Content[index].Line
+			}
+			return nil, 0
+		}
+		for _, item := range node.Content {
+			if name, _ := childNode(item, "name"); name != nil && name.Value == segment {
+				return item, item.Line
+			}
+		}
+	}
+	return nil, 0
+}
+
+// placeholderName is the name given to resources which are wrapped around a bare spec
+const placeholderName = "noname"
+
+// WithType returns a copy of the document asserted to have the given apiVersion and kind. It is
+// meant for generated YAML which lacks them. A resource missing apiVersion or kind has them set,
+// while a bare spec, e.g. the content of a Pipeline spec on its own, is wrapped in a resource.
+// Empty arguments leave the respective field untouched. It is an error for the document to declare
+// a different apiVersion or kind.
+func (d Document) WithType(apiVersion, kind string) (Document, error) {
+	if d.Err != nil {
+		return d, fmt.Errorf("%s: %w", d, d.Err)
+	}
+	if kind != "" && d.Kind != "" && kind != d.Kind {
+		return d, fmt.Errorf("%s: declares kind %s, not %s", d, d.Kind, kind)
+	}
+	if apiVersion != "" && d.APIVersion != "" && apiVersion != d.APIVersion {
+		return d, fmt.Errorf("%s: declares apiVersion %s, not %s", d, d.APIVersion, apiVersion)
+	}
+	if (kind == "" || d.Kind != "") && (apiVersion == "" || d.APIVersion != "") {
+		return d, nil
+	}
+
+	var obj map[string]any
+	if err := yaml.Unmarshal(d.Content, &obj); err != nil {
+		return d, fmt.Errorf("%s: %w", d, err)
+	}
+	if obj == nil {
+		obj = map[string]any{}
+	}
+
+	_, hasSpec := obj["spec"]
+	_, hasMetadata := obj["metadata"]
+	if !hasSpec && !hasMetadata && d.Kind == "" && d.APIVersion == "" {
+		obj = map[string]any{
+			"metadata": map[string]any{"name": placeholderName},
+			"spec":     obj,
+		}
+	}
+	if apiVersion != "" {
+		obj["apiVersion"] = apiVersion
+	}
+	if kind != "" {
+		obj["kind"] = kind
+	}
+
+	content, err := yaml.Marshal(obj)
+	if err != nil {
+		return d, fmt.Errorf("%s: %w", d, err)
+	}
+
+	wrapped := Split(d.Source, content)
+	if len(wrapped) != 1 {
+		return d, fmt.Errorf("%s: unable to assert apiVersion and kind", d)
+	}
+	typed := d
+	typed.Content = wrapped[0].Content
+	typed.APIVersion, typed.Kind, typed.Name = wrapped[0].APIVersion, wrapped[0].Kind, wrapped[0].Name
+	typed.Err = wrapped[0].Err
+	return typed, nil
+}
+
+// fragmentKinds maps the keys under which specs are embedded in other resources, and which some
+// templating systems store on their own, to the kind of resource the spec belongs to
+var fragmentKinds = map[string]string{
+	"pipelineSpec": "Pipeline",
+	"taskSpec":     "Task",
+}
+
+// Fragment reports whether the document is a spec fragment, i.e. a single pipelineSpec or taskSpec
+// block stored on its own. If so, it returns a copy of the document wrapped in a resource of the
+// matching kind with the given apiVersion, along with the key of the fragment.
+func (d Document) Fragment(apiVersion string) (Document, string, bool) {
+	if d.Err != nil || d.Kind != "" || d.APIVersion != "" {
+		return d, "", false
+	}
+
+	var obj map[string]any
+	if err := yaml.Unmarshal(d.Content, &obj); err != nil || len(obj) != 1 {
+		return d, "", false
+	}
+	for key, spec := range obj {
+		kind, ok := fragmentKinds[key]
+		if !ok {
+			return d, "", false
+		}
+		content, err := yaml.Marshal(map[string]any{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]any{"name": placeholderName},
+			"spec":       spec,
+		})
+		if err != nil {
+			return d, "", false
+		}
+		wrapped := Split(d.Source, content)
+		if len(wrapped) != 1 {
+			return d, "", false
+		}
+		fragment := d
+		fragment.Content = wrapped[0].Content
+		fragment.APIVersion, fragment.Kind, fragment.Name = wrapped[0].APIVersion, wrapped[0].Kind, wrapped[0].Name
+		return fragment, key, true
+	}
+	return d, "", false
+}
+
+// SplitFile reads a file and splits it into its YAML documents. It is an error for the file to
+// exceed the size limit of DefaultLimits.
+func SplitFile(fname string) ([]Document, error) {
+	stat, err := os.Stat(fname)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", fname, err)
+	}
+	if err := DefaultLimits.checkSize(fname, stat.Size()); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", fname, err)
+	}
+	return Split(fname, data), nil
+}
+
+// Split splits a YAML stream into its documents, detecting the kind of each one. Documents which
+// only contain whitespace or comments are skipped. Inputs exceeding DefaultLimits are returned as
+// a single document, or documents, whose Err is a LimitError.
+func Split(source string, data []byte) []Document {
+	if err := DefaultLimits.checkSize(source, int64(len(data))); err != nil {
+		return []Document{{Source: source, Line: 1, End: len(data), Err: err}}
+	}
+
+	var docs []Document
+
+	offset, line := 0, 1
+	start, startLine := 0, 1
+	flush := func(end int) {
+		content := data[start:end]
+		if isEmptyDocument(content) {
+			return
+		}
+
+		doc := Document{
+			Source:  source,
+			Index:   len(docs),
+			Offset:  start,
+			End:     end,
+			Line:    startLine,
+			Content: content,
+		}
+		var o metav1.PartialObjectMetadata
+		if err := DefaultLimits.checkNodes(doc.String(), content); err != nil {
+			doc.Err = err
+		} else if err := yaml.Unmarshal(content, &o); err != nil {
+			doc.Err = err
+		} else {
+			doc.APIVersion, doc.Kind, doc.Name = o.APIVersion, o.Kind, o.Name
+		}
+		docs = append(docs, doc)
+	}
+
+	for offset < len(data) {
+		lineEnd := bytes.IndexByte(data[offset:], '\n')
+		next := len(data)
+		if lineEnd >= 0 {
+			next = offset + lineEnd + 1
+		}
+
+		if isSeparator(data[offset:next]) {
+			flush(offset)
+			start, startLine = next, line+1
+		}
+
+		offset = next
+		line++
+	}
+	flush(len(data))
+
+	return docs
+}
+
+// Join concatenates documents into a single multi-document YAML stream
+func Join(docs []Document) []byte {
+	var buf bytes.Buffer
+	for _, doc := range docs {
+		buf.WriteString("---\n")
+		buf.Write(doc.Content)
+		if !bytes.HasSuffix(doc.Content, []byte("\n")) {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+// isSeparator reports whether a line is a YAML document separator, optionally followed by a comment
+func isSeparator(line []byte) bool {
+	s := strings.TrimRight(string(line), " \t\r\n")
+	if !strings.HasPrefix(s, "---") {
+		return false
+	}
+	rest := strings.TrimLeft(s[len("---"):], " \t")
+	return rest == "" || strings.HasPrefix(rest, "#")
+}
+
+// isEmptyDocument reports whether a document only contains whitespace and comments
+func isEmptyDocument(content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
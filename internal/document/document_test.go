@@ -0,0 +1,296 @@
+package document
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit(t *testing.T) {
+	data := []byte(`# leading comment
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+---
+# only a comment
+---  # separator with a comment
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: git-clone
+---
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: run
+spec:
+  params:
+    - name: revision
+      value: {{ revision }}
+`)
+
+	docs := Split("resources.yaml", data)
+	require.Len(t, docs, 3)
+
+	assert.Equal(t, 0, docs[0].Index)
+	assert.Equal(t, 1, docs[0].Line)
+	assert.Equal(t, 0, docs[0].Offset)
+	assert.Equal(t, "tekton.dev/v1/Pipeline", docs[0].Key())
+	assert.Equal(t, "build", docs[0].Name)
+	assert.NoError(t, docs[0].Err)
+	assert.Equal(t, "resources.yaml:1 (Pipeline build)", docs[0].String())
+
+	assert.Equal(t, 1, docs[1].Index)
+	assert.Equal(t, 9, docs[1].Line)
+	assert.Equal(t, "Task", docs[1].Kind)
+	assert.Equal(t, "git-clone", docs[1].Name)
+	assert.Equal(t, "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: git-clone\n", string(docs[1].Content))
+	assert.Equal(t, string(docs[1].Content), string(data[docs[1].Offset:docs[1].End]))
+
+	// Templates which are not valid YAML yet are still split.
+	assert.Equal(t, 2, docs[2].Index)
+	assert.Equal(t, 14, docs[2].Line)
+	assert.Error(t, docs[2].Err)
+	assert.Empty(t, docs[2].Kind)
+	assert.Equal(t, "resources.yaml:14", docs[2].String())
+	assert.Equal(t, len(data), docs[2].End)
+}
+
+func TestSplitEmpty(t *testing.T) {
+	assert.Empty(t, Split("empty.yaml", nil))
+	assert.Empty(t, Split("empty.yaml", []byte("---\n# nothing here\n---\n")))
+}
+
+func TestSplitFile(t *testing.T) {
+	dir := t.TempDir()
+	fname := filepath.Join(dir, "task.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte("apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: task\n"), 0644))
+
+	docs, err := SplitFile(fname)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, fname, docs[0].Source)
+
+	_, err = SplitFile(filepath.Join(dir, "missing.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no such file or directory")
+}
+
+func TestJoin(t *testing.T) {
+	docs := []Document{
+		{Content: []byte("kind: Task\n")},
+		{Content: []byte("kind: Pipeline")},
+	}
+	assert.Equal(t, "---\nkind: Task\n---\nkind: Pipeline\n", string(Join(docs)))
+	assert.Empty(t, Join(nil))
+}
+
+func TestIsSeparator(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected bool
+	}{
+		{line: "---\n", expected: true},
+		{line: "---", expected: true},
+		{line: "---  \r\n", expected: true},
+		{line: "--- # comment\n", expected: true},
+		{line: "----\n", expected: false},
+		{line: "--- foo\n", expected: false},
+		{line: "  ---\n", expected: false},
+		{line: "key: ---\n", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isSeparator([]byte(tt.line)))
+		})
+	}
+}
+
+func TestWithType(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		apiVersion      string
+		kind            string
+		expectedContent string
+		expectedName    string
+		errorContains   string
+	}{
+		{
+			name:            "bare spec is wrapped",
+			content:         "tasks:\n  - name: build\n",
+			apiVersion:      "tekton.dev/v1",
+			kind:            "Pipeline",
+			expectedContent: "apiVersion: tekton.dev/v1\nkind: Pipeline\nmetadata:\n  name: noname\nspec:\n  tasks:\n  - name: build\n",
+			expectedName:    "noname",
+		},
+		{
+			name:            "resource missing apiVersion and kind",
+			content:         "metadata:\n  name: build\nspec:\n  tasks: []\n",
+			apiVersion:      "tekton.dev/v1",
+			kind:            "Pipeline",
+			expectedContent: "apiVersion: tekton.dev/v1\nkind: Pipeline\nmetadata:\n  name: build\nspec:\n  tasks: []\n",
+			expectedName:    "build",
+		},
+		{
+			name:            "resource missing kind",
+			content:         "apiVersion: tekton.dev/v1beta1\nmetadata:\n  name: build\n",
+			kind:            "Task",
+			expectedContent: "apiVersion: tekton.dev/v1beta1\nkind: Task\nmetadata:\n  name: build\n",
+			expectedName:    "build",
+		},
+		{
+			name:            "complete resource is untouched",
+			content:         "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n",
+			apiVersion:      "tekton.dev/v1",
+			kind:            "Task",
+			expectedContent: "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n",
+			expectedName:    "build",
+		},
+		{
+			name:          "conflicting kind",
+			content:       "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n",
+			apiVersion:    "tekton.dev/v1",
+			kind:          "Pipeline",
+			errorContains: "spec.yaml:1 (Task build): declares kind Task, not Pipeline",
+		},
+		{
+			name:          "conflicting apiVersion",
+			content:       "apiVersion: tekton.dev/v1beta1\nmetadata:\n  name: build\n",
+			apiVersion:    "tekton.dev/v1",
+			kind:          "Task",
+			errorContains: "declares apiVersion tekton.dev/v1beta1, not tekton.dev/v1",
+		},
+		{
+			name:          "invalid YAML",
+			content:       "tasks: [",
+			kind:          "Pipeline",
+			errorContains: "spec.yaml:1: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs := Split("spec.yaml", []byte(tt.content))
+			require.Len(t, docs, 1)
+
+			typed, err := docs[0].WithType(tt.apiVersion, tt.kind)
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedContent, string(typed.Content))
+			assert.Equal(t, tt.expectedName, typed.Name)
+			assert.Equal(t, docs[0].Line, typed.Line)
+			assert.NoError(t, typed.Err)
+		})
+	}
+}
+
+func TestFragment(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		expectFragment  bool
+		expectedKey     string
+		expectedContent string
+	}{
+		{
+			name:            "pipelineSpec fragment",
+			content:         "pipelineSpec:\n  tasks:\n  - name: build\n",
+			expectFragment:  true,
+			expectedKey:     "pipelineSpec",
+			expectedContent: "apiVersion: tekton.dev/v1\nkind: Pipeline\nmetadata:\n  name: noname\nspec:\n  tasks:\n  - name: build\n",
+		},
+		{
+			name:            "taskSpec fragment",
+			content:         "taskSpec:\n  steps:\n  - name: build\n",
+			expectFragment:  true,
+			expectedKey:     "taskSpec",
+			expectedContent: "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: noname\nspec:\n  steps:\n  - name: build\n",
+		},
+		{
+			name:    "resource",
+			content: "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n",
+		},
+		{
+			name:    "fragment with other keys",
+			content: "taskSpec:\n  steps: []\nparams: []\n",
+		},
+		{
+			name:    "bare spec",
+			content: "steps:\n  - name: build\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs := Split("fragment.yaml", []byte(tt.content))
+			require.Len(t, docs, 1)
+
+			fragment, key, ok := docs[0].Fragment("tekton.dev/v1")
+			assert.Equal(t, tt.expectFragment, ok)
+			if !tt.expectFragment {
+				assert.Equal(t, docs[0], fragment)
+				return
+			}
+			assert.Equal(t, tt.expectedKey, key)
+			assert.Equal(t, tt.expectedContent, string(fragment.Content))
+			assert.Equal(t, "noname", fragment.Name)
+			assert.Equal(t, docs[0].Line, fragment.Line)
+		})
+	}
+}
+
+func TestFieldLine(t *testing.T) {
+	docs := Split("pipeline.yaml", []byte(`apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: hello
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+  annotations:
+    example.com/owner: ci
+spec:
+  params:
+    - name: image
+      type: string
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`))
+	require.Len(t, docs, 2)
+	doc := docs[1]
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{path: "", expected: 6},
+		{path: "spec", expected: 12},
+		{path: "spec.params[0].type", expected: 15},
+		{path: "spec.params[image]", expected: 14},
+		{path: "spec.tasks[0].taskRef.name", expected: 19},
+		{path: "metadata.annotations[example.com/owner]", expected: 11},
+		// Fields the document does not hold are located at the closest field enclosing them.
+		{path: "spec.tasks[0].taskSpec.steps[0].image", expected: 17},
+		{path: "spec.finally[0]", expected: 12},
+		{path: "status", expected: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, doc.FieldLine(tt.path))
+		})
+	}
+}
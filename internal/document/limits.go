@@ -0,0 +1,99 @@
+package document
+
+import (
+	"fmt"
+
+	yamlv3 "sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// Limits bound the YAML accepted by SplitFile and Split, so that enormous inputs and YAML bombs,
+// i.e. documents whose aliases expand exponentially, are rejected before being decoded. A zero
+// value disables the respective limit.
+type Limits struct {
+	MaxBytes int64 // Maximum size of a source, in bytes
+	MaxNodes int   // Maximum number of YAML nodes of a document, once its aliases are expanded
+}
+
+// DefaultLimits are the limits enforced by SplitFile and Split. They comfortably fit the largest
+// Pipelines found in practice.
+var DefaultLimits = Limits{
+	MaxBytes: 16 << 20,
+	MaxNodes: 1_000_000,
+}
+
+// LimitError reports an input exceeding one of the Limits
+type LimitError struct {
+	Source string
+	Limit  string // Name of the limit, e.g. "size"
+	Value  int64  // Measured value, or -1 when measuring stopped at the limit
+	Max    int64
+	Unit   string
+}
+
+func (e *LimitError) Error() string {
+	if e.Value < 0 {
+		return fmt.Sprintf("%s: exceeds the %s limit of %d %s", e.Source, e.Limit, e.Max, e.Unit)
+	}
+	return fmt.Sprintf("%s: %d %s exceeds the %s limit of %d %s", e.Source, e.Value, e.Unit, e.Limit, e.Max, e.Unit)
+}
+
+// checkSize returns a LimitError if size exceeds MaxBytes
+func (l Limits) checkSize(source string, size int64) error {
+	if l.MaxBytes > 0 && size > l.MaxBytes {
+		return &LimitError{Source: source, Limit: "size", Value: size, Max: l.MaxBytes, Unit: "bytes"}
+	}
+	return nil
+}
+
+// checkNodes returns a LimitError if the content, once its aliases are expanded, has more than
+// MaxNodes nodes. Content that is not valid YAML is left for the decoder to report.
+func (l Limits) checkNodes(source string, content []byte) error {
+	if l.MaxNodes <= 0 {
+		return nil
+	}
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &root); err != nil {
+		return nil
+	}
+	counter := nodeCounter{max: l.MaxNodes, sizes: map[*yamlv3.Node]int{}, pending: map[*yamlv3.Node]bool{}}
+	if count := counter.count(&root); count > l.MaxNodes {
+		return &LimitError{Source: source, Limit: "expanded node", Value: -1, Max: int64(l.MaxNodes), Unit: "nodes"}
+	}
+	return nil
+}
+
+// nodeCounter counts the nodes of a YAML tree as if its aliases were expanded. Counting stops as
+// soon as the maximum is exceeded.
+type nodeCounter struct {
+	max     int
+	sizes   map[*yamlv3.Node]int  // Expanded size of the nodes counted so far
+	pending map[*yamlv3.Node]bool // Nodes being counted, used to detect recursive aliases
+}
+
+func (c *nodeCounter) count(node *yamlv3.Node) int {
+	if node == nil {
+		return 0
+	}
+	if size, ok := c.sizes[node]; ok {
+		return size
+	}
+	if c.pending[node] {
+		// A recursive alias expands indefinitely.
+		return c.max + 1
+	}
+	c.pending[node] = true
+	defer delete(c.pending, node)
+
+	size := 1
+	if node.Kind == yamlv3.AliasNode {
+		size += c.count(node.Alias)
+	}
+	for _, child := range node.Content {
+		if size > c.max {
+			break
+		}
+		size += c.count(child)
+	}
+	c.sizes[node] = size
+	return size
+}
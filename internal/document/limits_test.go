@@ -0,0 +1,115 @@
+package document
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// yamlBomb is a "billion laughs" document whose aliases expand to 9^8 nodes
+const yamlBomb = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: bomb
+a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol"]
+b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a]
+c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b]
+d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c]
+e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d]
+f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e]
+g: &g [*f,*f,*f,*f,*f,*f,*f,*f,*f]
+h: &h [*g,*g,*g,*g,*g,*g,*g,*g,*g]
+spec:
+  tasks: *h
+`
+
+func withLimits(t *testing.T, limits Limits) {
+	previous := DefaultLimits
+	DefaultLimits = limits
+	t.Cleanup(func() { DefaultLimits = previous })
+}
+
+func TestSplitLimits(t *testing.T) {
+	tests := []struct {
+		name          string
+		limits        Limits
+		data          string
+		errorContains []string
+	}{
+		{
+			name:   "within limits",
+			limits: DefaultLimits,
+			data:   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata: &d {x: y}\ncopy: *d\n",
+		},
+		{
+			name:          "yaml bomb",
+			limits:        DefaultLimits,
+			data:          "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\n" + yamlBomb,
+			errorContains: []string{"", "input.yaml:6: exceeds the expanded node limit of 1000000 nodes"},
+		},
+		{
+			name:          "too many nodes",
+			limits:        Limits{MaxNodes: 10},
+			data:          "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  a: b\n  c: d\n",
+			errorContains: []string{"input.yaml:1: exceeds the expanded node limit of 10 nodes"},
+		},
+		{
+			name:          "too large",
+			limits:        Limits{MaxBytes: 10},
+			data:          "apiVersion: v1\nkind: ConfigMap\n",
+			errorContains: []string{"input.yaml: 31 bytes exceeds the size limit of 10 bytes"},
+		},
+		{
+			name:          "recursive alias",
+			limits:        DefaultLimits,
+			data:          "a: &a [*a]\n",
+			errorContains: []string{"input.yaml:1: exceeds the expanded node limit of 1000000 nodes"},
+		},
+		{
+			name:   "no limits",
+			limits: Limits{},
+			data:   strings.Repeat("# padding\n", 100) + "apiVersion: v1\nkind: ConfigMap\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withLimits(t, tt.limits)
+
+			docs := Split("input.yaml", []byte(tt.data))
+			if len(tt.errorContains) == 0 {
+				for _, doc := range docs {
+					assert.NoError(t, doc.Err)
+				}
+				return
+			}
+			require.Len(t, docs, len(tt.errorContains))
+			for i, expectedErr := range tt.errorContains {
+				if expectedErr == "" {
+					assert.NoError(t, docs[i].Err)
+					continue
+				}
+				require.Error(t, docs[i].Err)
+				assert.Contains(t, docs[i].Err.Error(), expectedErr)
+			}
+		})
+	}
+}
+
+func TestSplitFileSizeLimit(t *testing.T) {
+	withLimits(t, Limits{MaxBytes: 10})
+	fname := filepath.Join(t.TempDir(), "large.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte("apiVersion: v1\nkind: ConfigMap\n"), 0644))
+
+	_, err := SplitFile(fname)
+	require.Error(t, err)
+	var limitErr *LimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, "size", limitErr.Limit)
+	assert.Equal(t, fname+": 31 bytes exceeds the size limit of 10 bytes", err.Error())
+}
@@ -0,0 +1,283 @@
+// Package fix applies safe automatic fixes to the YAML of Tekton resources. Fixes edit the YAML
+// nodes of the resources, so that their comments and the order of their fields are preserved.
+package fix
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// Fix is a change applied to a resource
+type Fix struct {
+	// Document is the position of the resource within its file, starting at 0.
+	Document int
+	// Message describes the change, e.g. "added the type string to the url param".
+	Message string
+	// Path is the path of the field the change is about, e.g. spec.params[0].
+	Path string
+}
+
+func (f Fix) String() string {
+	return fmt.Sprintf("%s: %s", f.Message, f.Path)
+}
+
+// Apply applies the fixes to the resources of a, possibly multi-document, YAML file. It returns the
+// fixed content along with the fixes applied, or the content unchanged if there is nothing to fix.
+func Apply(content []byte) ([]byte, []Fix, error) {
+	var docs []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("parsing YAML: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+
+	var fixes []Fix
+	for i, doc := range docs {
+		if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+			continue
+		}
+		f := &fixer{document: i}
+		f.resource(doc.Content[0])
+		fixes = append(fixes, f.fixes...)
+	}
+	if len(fixes) == 0 {
+		return content, nil, nil
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, nil, fmt.Errorf("writing YAML: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, fmt.Errorf("writing YAML: %w", err)
+	}
+	return buf.Bytes(), fixes, nil
+}
+
+// fixer collects the fixes applied to a resource
+type fixer struct {
+	document int
+	fixes    []Fix
+}
+
+func (f *fixer) add(path, format string, args ...any) {
+	f.fixes = append(f.fixes, Fix{Document: f.document, Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+// resource fixes a resource according to its kind
+func (f *fixer) resource(node *yaml.Node) {
+	spec := mappingValue(node, "spec")
+	if spec == nil || spec.Kind != yaml.MappingNode {
+		return
+	}
+	switch scalarValue(mappingValue(node, "kind")) {
+	case "Pipeline":
+		f.pipelineSpec(spec, "spec", true)
+	case "PipelineRun":
+		f.resolverRef(mappingValue(spec, "pipelineRef"), "pipeline", "spec.pipelineRef")
+		if pipelineSpec := mappingValue(spec, "pipelineSpec"); pipelineSpec != nil {
+			// The PipelineRun may bind the workspaces its pipeline spec does not use.
+			f.pipelineSpec(pipelineSpec, "spec.pipelineSpec", false)
+		}
+	case "Task":
+		f.params(mappingValue(spec, "params"), "spec")
+	case "TaskRun":
+		f.resolverRef(mappingValue(spec, "taskRef"), "task", "spec.taskRef")
+		if taskSpec := mappingValue(spec, "taskSpec"); taskSpec != nil {
+			f.params(mappingValue(taskSpec, "params"), "spec.taskSpec")
+		}
+	}
+}
+
+// pipelineSpec fixes a pipeline spec, removing its unused workspaces if told so
+func (f *fixer) pipelineSpec(spec *yaml.Node, path string, removeUnusedWorkspaces bool) {
+	if spec.Kind != yaml.MappingNode {
+		return
+	}
+	f.params(mappingValue(spec, "params"), path)
+	for _, section := range []string{"tasks", "finally"} {
+		pipelineTasks := mappingValue(spec, section)
+		if pipelineTasks == nil || pipelineTasks.Kind != yaml.SequenceNode {
+			continue
+		}
+		for i, pipelineTask := range pipelineTasks.Content {
+			taskPath := fmt.Sprintf("%s.%s[%d]", path, section, i)
+			f.resolverRef(mappingValue(pipelineTask, "taskRef"), "task", taskPath+".taskRef")
+			f.resolverRef(mappingValue(pipelineTask, "pipelineRef"), "pipeline", taskPath+".pipelineRef")
+			if taskSpec := mappingValue(pipelineTask, "taskSpec"); taskSpec != nil {
+				f.params(mappingValue(taskSpec, "params"), taskPath+".taskSpec")
+			}
+			if pipelineSpec := mappingValue(pipelineTask, "pipelineSpec"); pipelineSpec != nil {
+				// The PipelineTask binds the workspaces of the child pipeline.
+				f.pipelineSpec(pipelineSpec, taskPath+".pipelineSpec", false)
+			}
+		}
+	}
+	if removeUnusedWorkspaces {
+		f.unusedWorkspaces(spec, path)
+	}
+}
+
+// params adds the type missing from the params declared by a spec, inferred from their default
+func (f *fixer) params(params *yaml.Node, path string) {
+	if params == nil || params.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, param := range params.Content {
+		if param.Kind != yaml.MappingNode || mappingValue(param, "type") != nil {
+			continue
+		}
+		paramType := "string"
+		if mappingValue(param, "properties") != nil {
+			paramType = "object"
+		} else if def := mappingValue(param, "default"); def != nil {
+			switch def.Kind {
+			case yaml.SequenceNode:
+				paramType = "array"
+			case yaml.MappingNode:
+				paramType = "object"
+			}
+		}
+		// The type goes after the name, where it is usually declared.
+		at := len(param.Content)
+		for j := 0; j+1 < len(param.Content); j += 2 {
+			if param.Content[j].Value == "name" {
+				at = j + 2
+			}
+		}
+		param.Content = append(param.Content[:at], append(keyValue("type", paramType), param.Content[at:]...)...)
+		f.add(fmt.Sprintf("%s.params[%d]", path, i), "added the type %s to the %s param", paramType, scalarValue(mappingValue(param, "name")))
+	}
+}
+
+// resolverRef adds the kind param required by the bundles resolver, and by the hub resolver to
+// resolve Pipelines, to a taskRef or pipelineRef
+func (f *fixer) resolverRef(ref *yaml.Node, kind string, path string) {
+	if ref == nil || ref.Kind != yaml.MappingNode {
+		return
+	}
+	resolver := scalarValue(mappingValue(ref, "resolver"))
+	if resolver != "bundles" && !(resolver == "hub" && kind == "pipeline") {
+		return
+	}
+	params := mappingValue(ref, "params")
+	if params == nil {
+		params = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		ref.Content = append(ref.Content, scalar("params"), params)
+	}
+	if params.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, param := range params.Content {
+		if scalarValue(mappingValue(param, "name")) == "kind" {
+			return
+		}
+	}
+	params.Content = append(params.Content, &yaml.Node{
+		Kind:    yaml.MappingNode,
+		Tag:     "!!map",
+		Content: append(keyValue("name", "kind"), keyValue("value", kind)...),
+	})
+	f.add(path+".params", "added the kind param required by the %s resolver", resolver)
+}
+
+// unusedWorkspaces removes the workspaces declared by a pipeline spec which no PipelineTask binds
+// nor any variable refers to
+func (f *fixer) unusedWorkspaces(spec *yaml.Node, path string) {
+	workspaces := mappingValue(spec, "workspaces")
+	if workspaces == nil || workspaces.Kind != yaml.SequenceNode {
+		return
+	}
+
+	used := map[string]bool{}
+	for _, section := range []string{"tasks", "finally"} {
+		pipelineTasks := mappingValue(spec, section)
+		if pipelineTasks == nil {
+			continue
+		}
+		for _, pipelineTask := range pipelineTasks.Content {
+			bindings := mappingValue(pipelineTask, "workspaces")
+			if bindings == nil {
+				continue
+			}
+			for _, binding := range bindings.Content {
+				if name := scalarValue(mappingValue(binding, "workspace")); name != "" {
+					used[name] = true
+				}
+			}
+		}
+	}
+	content, err := yaml.Marshal(spec)
+	if err != nil {
+		return
+	}
+
+	kept := workspaces.Content[:0]
+	for i, workspace := range workspaces.Content {
+		name := scalarValue(mappingValue(workspace, "name"))
+		if name == "" || used[name] || strings.Contains(string(content), "$(workspaces."+name+".") {
+			kept = append(kept, workspace)
+			continue
+		}
+		f.add(fmt.Sprintf("%s.workspaces[%d]", path, i), "removed the unused %s workspace", name)
+	}
+	workspaces.Content = kept
+	if len(kept) == 0 {
+		removeKey(spec, "workspaces")
+	}
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// scalarValue returns the value of a scalar node, or an empty string
+func scalarValue(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
+
+// scalar returns a string scalar node
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// keyValue returns the nodes of a mapping entry with a string value
+func keyValue(key, value string) []*yaml.Node {
+	return []*yaml.Node{scalar(key), scalar(value)}
+}
+
+// removeKey removes the entry of key from a mapping node
+func removeKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package fix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      string
+		expectedFixes []Fix
+	}{
+		{
+			name: "param types",
+			content: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    # The repository
+    - name: url
+      description: URL of the repository
+    - description: Tags of the image
+      name: tags
+      default:
+        - latest
+    - name: config
+      properties:
+        env: {}
+    - name: labels
+      default:
+        app: build
+    - name: revision
+      type: string
+  steps:
+    - name: build
+      image: alpine:latest
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    # The repository
+    - name: url
+      type: string
+      description: URL of the repository
+    - description: Tags of the image
+      name: tags
+      type: array
+      default:
+        - latest
+    - name: config
+      type: object
+      properties:
+        env: {}
+    - name: labels
+      type: object
+      default:
+        app: build
+    - name: revision
+      type: string
+  steps:
+    - name: build
+      image: alpine:latest
+`,
+			expectedFixes: []Fix{
+				{Message: "added the type string to the url param", Path: "spec.params[0]"},
+				{Message: "added the type array to the tags param", Path: "spec.params[1]"},
+				{Message: "added the type object to the config param", Path: "spec.params[2]"},
+				{Message: "added the type object to the labels param", Path: "spec.params[3]"},
+			},
+		},
+		{
+			name: "unused workspaces",
+			content: `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  workspaces:
+    - name: source
+    - name: cache
+    - name: config
+  tasks:
+    - name: build
+      workspaces:
+        - name: source
+          workspace: source
+      taskSpec:
+        steps:
+          - name: build
+            image: alpine:latest
+            script: cat $(workspaces.config.path)/config.yaml
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  workspaces:
+    - name: source
+    - name: config
+  tasks:
+    - name: build
+      workspaces:
+        - name: source
+          workspace: source
+      taskSpec:
+        steps:
+          - name: build
+            image: alpine:latest
+            script: cat $(workspaces.config.path)/config.yaml
+`,
+			expectedFixes: []Fix{{Message: "removed the unused cache workspace", Path: "spec.workspaces[1]"}},
+		},
+		{
+			name: "only unused workspaces",
+			content: `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  workspaces:
+    - name: cache
+  tasks: []
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks: []
+`,
+			expectedFixes: []Fix{{Message: "removed the unused cache workspace", Path: "spec.workspaces[0]"}},
+		},
+		{
+			name: "resolver kinds",
+			content: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  pipelineRef:
+    resolver: hub
+    params:
+      - name: name
+        value: buildpacks
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: quay.io/example/task-build:0.1
+          - name: name
+            value: build
+    - name: clone
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: git-clone
+    - name: test
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: quay.io/example/task-test:0.1
+          - name: name
+            value: test
+          - name: kind
+            value: task
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  pipelineRef:
+    resolver: hub
+    params:
+      - name: name
+        value: buildpacks
+      - name: kind
+        value: pipeline
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: quay.io/example/task-build:0.1
+          - name: name
+            value: build
+          - name: kind
+            value: task
+    - name: clone
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: git-clone
+    - name: test
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: quay.io/example/task-test:0.1
+          - name: name
+            value: test
+          - name: kind
+            value: task
+`,
+			expectedFixes: []Fix{
+				{Message: "added the kind param required by the hub resolver", Path: "spec.pipelineRef.params"},
+				{Document: 1, Message: "added the kind param required by the bundles resolver", Path: "spec.tasks[0].taskRef.params"},
+			},
+		},
+		{
+			name: "embedded specs",
+			content: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  workspaces:
+    - name: cache
+      emptyDir: {}
+  pipelineSpec:
+    workspaces:
+      - name: cache
+    tasks:
+      - name: build
+        taskSpec:
+          params:
+            - name: url
+          steps:
+            - name: build
+              image: alpine:latest
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  workspaces:
+    - name: cache
+      emptyDir: {}
+  pipelineSpec:
+    workspaces:
+      - name: cache
+    tasks:
+      - name: build
+        taskSpec:
+          params:
+            - name: url
+              type: string
+          steps:
+            - name: build
+              image: alpine:latest
+`,
+			expectedFixes: []Fix{{Message: "added the type string to the url param", Path: "spec.pipelineSpec.tasks[0].taskSpec.params[0]"}},
+		},
+		{
+			name: "nothing to fix",
+			content: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build  # unchanged formatting
+spec:
+  steps: [{name: build, image: alpine:latest}]
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build  # unchanged formatting
+spec:
+  steps: [{name: build, image: alpine:latest}]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixed, fixes, err := Apply([]byte(tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(fixed))
+			assert.Equal(t, tt.expectedFixes, fixes)
+		})
+	}
+}
+
+func TestApplyInvalidYAML(t *testing.T) {
+	_, _, err := Apply([]byte("spec: [unterminated"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parsing YAML")
+}
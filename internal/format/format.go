@@ -0,0 +1,167 @@
+// Package format formats the YAML of Tekton resources canonically, so that diffs stay clean
+// whoever edits the resources. Comments are preserved.
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"sigs.k8s.io/yaml/goyaml.v3"
+)
+
+// fieldOrders are the fields leading the entries of lists, by the key of the list, in the order they
+// are written. The other fields follow in their original order.
+var fieldOrders = map[string][]string{
+	"params":     {"name", "type", "description", "properties", "enum", "default", "value"},
+	"workspaces": {"name", "description", "workspace", "subPath", "mountPath", "readOnly", "optional"},
+	"results":    {"name", "type", "description", "properties", "value"},
+}
+
+// resourceOrder and metadataOrder are the fields leading resources and their metadata
+var (
+	resourceOrder = []string{"apiVersion", "kind", "metadata", "spec"}
+	metadataOrder = []string{"name", "generateName", "namespace", "labels", "annotations"}
+)
+
+// emptyFields are the fields removed when empty, as left behind by marshaling Go structs, e.g.
+// creationTimestamp: null or metadata: {}
+var emptyFields = []string{"creationTimestamp", "spec", "taskRunTemplate", "metadata", "computeResources", "status"}
+
+// Format formats the resources of a, possibly multi-document, YAML file:
+//   - the entries of params, workspaces, and results lead with their name, followed by the fields
+//     of fieldOrders;
+//   - resources lead with apiVersion, kind, metadata, and spec, and metadata with name;
+//   - null fields, and the empty fields left behind by marshaling Go structs, are removed;
+//   - mappings are indented with two spaces, as are the sequences they hold.
+func Format(content []byte) ([]byte, error) {
+	return rewrite(content, func(node *yaml.Node) {
+		clean(node)
+		order(node, "")
+		orderFields(node, resourceOrder)
+		orderFields(mappingValue(node, "metadata"), metadataOrder)
+	})
+}
+
+// Clean removes the null fields of the resources of a YAML file, and the empty fields left behind by
+// marshaling Go structs, without formatting them otherwise
+func Clean(content []byte) ([]byte, error) {
+	return rewrite(content, clean)
+}
+
+// rewrite applies fn to the root node of every document of content
+func rewrite(content []byte, fn func(*yaml.Node)) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		if err := decoder.Decode(&doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("parsing YAML: %w", err)
+		}
+		if len(doc.Content) == 0 || isNull(doc.Content[0]) {
+			// Empty documents are dropped.
+			continue
+		}
+		fn(doc.Content[0])
+		if err := encoder.Encode(&doc); err != nil {
+			return nil, fmt.Errorf("writing YAML: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("writing YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// clean removes the null fields of node and the fields it holds, and the empty ones of emptyFields
+func clean(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			clean(value)
+			if isNull(value) || (slices.Contains(emptyFields, key.Value) && isEmpty(value)) {
+				continue
+			}
+			content = append(content, key, value)
+		}
+		node.Content = content
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			clean(item)
+		}
+	}
+}
+
+// order orders the fields of the entries of the lists of fieldOrders held by node, which is the value
+// of key
+func order(node *yaml.Node, key string) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			order(node.Content[i+1], node.Content[i].Value)
+		}
+	case yaml.SequenceNode:
+		fields, ok := fieldOrders[key]
+		for _, item := range node.Content {
+			if ok {
+				orderFields(item, fields)
+			}
+			order(item, "")
+		}
+	}
+}
+
+// orderFields moves the given fields of a mapping node first, in the given order
+func orderFields(node *yaml.Node, fields []string) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	rank := func(key string) int {
+		if i := slices.Index(fields, key); i >= 0 {
+			return i
+		}
+		return len(fields)
+	}
+	entries := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		entries = append(entries, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	slices.SortStableFunc(entries, func(a, b [2]*yaml.Node) int {
+		return rank(a[0].Value) - rank(b[0].Value)
+	})
+	node.Content = node.Content[:0]
+	for _, entry := range entries {
+		node.Content = append(node.Content, entry[0], entry[1])
+	}
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// isNull tells whether node is a null scalar, e.g. null, ~, or an empty value
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
+
+// isEmpty tells whether node is an empty mapping
+func isEmpty(node *yaml.Node) bool {
+	return node.Kind == yaml.MappingNode && len(node.Content) == 0
+}
@@ -0,0 +1,224 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name: "formatted",
+			content: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: string
+  steps:
+    - name: build
+      image: alpine:latest
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: string
+  steps:
+    - name: build
+      image: alpine:latest
+`,
+		},
+		{
+			name: "field order",
+			content: `spec:
+  workspaces:
+    - optional: true
+      description: Cloned sources
+      name: source
+  params:
+    - default: main
+      description: Revision to build
+      type: string
+      name: revision
+  results:
+    - description: Digest of the image
+      name: digest
+  steps:
+    - image: alpine:latest
+      name: build
+metadata:
+  labels:
+    app: build
+  name: build
+kind: Task
+apiVersion: tekton.dev/v1
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+  labels:
+    app: build
+spec:
+  workspaces:
+    - name: source
+      description: Cloned sources
+      optional: true
+  params:
+    - name: revision
+      type: string
+      description: Revision to build
+      default: main
+  results:
+    - name: digest
+      description: Digest of the image
+  steps:
+    - image: alpine:latest
+      name: build
+`,
+		},
+		{
+			name: "null and empty fields",
+			content: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  creationTimestamp: null
+  name: build
+spec:
+  pipelineSpec:
+    tasks:
+      - name: build
+        params:
+          - name: url
+            value: ~
+        taskSpec:
+          metadata: {}
+          spec: null
+          steps:
+            - computeResources: {}
+              env: []
+              image: alpine:latest
+              name: build
+  taskRunTemplate: {}
+status: {}
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  pipelineSpec:
+    tasks:
+      - name: build
+        params:
+          - name: url
+        taskSpec:
+          steps:
+            - env: []
+              image: alpine:latest
+              name: build
+`,
+		},
+		{
+			name: "comments and indentation",
+			content: `# The build Pipeline
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+    name: build
+spec:
+    params:
+    # The repository
+    -   type: string
+        name: url # cloned by the clone task
+    tasks:
+    -   name: clone
+        taskRef:
+            name: git-clone
+`,
+			expected: `# The build Pipeline
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  params:
+    # The repository
+    - name: url # cloned by the clone task
+      type: string
+  tasks:
+    - name: clone
+      taskRef:
+        name: git-clone
+`,
+		},
+		{
+			name: "multiple documents",
+			content: `kind: Task
+apiVersion: tekton.dev/v1
+---
+---
+kind: Pipeline
+apiVersion: tekton.dev/v1
+`,
+			expected: `apiVersion: tekton.dev/v1
+kind: Task
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := Format([]byte(tt.content))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(formatted))
+
+			again, err := Format(formatted)
+			require.NoError(t, err)
+			assert.Equal(t, string(formatted), string(again), "Expected formatting to be idempotent")
+		})
+	}
+}
+
+func TestClean(t *testing.T) {
+	cleaned, err := Clean([]byte(`kind: PipelineRun
+metadata:
+  creationTimestamp: null
+  name: build
+spec:
+  params:
+    - value: main
+      name: revision
+  taskRunTemplate: {}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, `kind: PipelineRun
+metadata:
+  name: build
+spec:
+  params:
+    - value: main
+      name: revision
+`, string(cleaned))
+}
+
+func TestFormatInvalidYAML(t *testing.T) {
+	_, err := Format([]byte("spec: [\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parsing YAML")
+}
@@ -0,0 +1,181 @@
+// Package graph renders the dependency graph of the PipelineTasks of a Pipeline, as Graphviz DOT or
+// Mermaid, to help understanding the order in which they run.
+package graph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// EdgeKind tells what makes a PipelineTask depend on another
+type EdgeKind string
+
+const (
+	// EdgeRunAfter is a dependency declared by runAfter.
+	EdgeRunAfter EdgeKind = "runAfter"
+	// EdgeResults is a dependency on the results of a PipelineTask, e.g. through a param.
+	EdgeResults EdgeKind = "results"
+	// EdgeWhen is a dependency on the results of a PipelineTask through a when expression.
+	EdgeWhen EdgeKind = "when"
+)
+
+// Edge is a dependency of the To PipelineTask on the From one
+type Edge struct {
+	From string
+	To   string
+	Kind EdgeKind
+}
+
+// Graph is the dependency graph of a Pipeline
+type Graph struct {
+	// Tasks and Finally are the names of the PipelineTasks, in the order they are declared.
+	Tasks   []string
+	Finally []string
+	Edges   []Edge
+}
+
+// New builds the dependency graph of a pipeline spec. Dependencies on PipelineTasks the spec does
+// not declare are ignored.
+func New(pipelineSpec v1.PipelineSpec) Graph {
+	var g Graph
+	declared := map[string]bool{}
+	for _, pipelineTask := range pipelineSpec.Tasks {
+		g.Tasks = append(g.Tasks, pipelineTask.Name)
+		declared[pipelineTask.Name] = true
+	}
+	for _, pipelineTask := range pipelineSpec.Finally {
+		g.Finally = append(g.Finally, pipelineTask.Name)
+		declared[pipelineTask.Name] = true
+	}
+
+	seen := map[Edge]bool{}
+	add := func(from, to string, kind EdgeKind) {
+		edge := Edge{From: from, To: to, Kind: kind}
+		if declared[from] && from != to && !seen[edge] {
+			seen[edge] = true
+			g.Edges = append(g.Edges, edge)
+		}
+	}
+	for _, pipelineTask := range append(slices.Clone(pipelineSpec.Tasks), pipelineSpec.Finally...) {
+		for _, runAfter := range pipelineTask.RunAfter {
+			add(runAfter, pipelineTask.Name, EdgeRunAfter)
+		}
+		for _, when := range pipelineTask.When {
+			expressions, _ := when.GetVarSubstitutionExpressions()
+			for _, ref := range v1.NewResultRefs(expressions) {
+				add(ref.PipelineTask, pipelineTask.Name, EdgeWhen)
+			}
+		}
+		// The result references of when expressions are already covered.
+		withoutWhen := pipelineTask
+		withoutWhen.When = nil
+		for _, ref := range v1.PipelineTaskResultRefs(&withoutWhen) {
+			add(ref.PipelineTask, pipelineTask.Name, EdgeResults)
+		}
+	}
+	return g
+}
+
+// Isolated returns the PipelineTasks of tasks which neither depend on another PipelineTask nor have
+// one depending on them, when there are several. Finally tasks are never isolated since they run
+// after all the others.
+func (g Graph) Isolated() []string {
+	if len(g.Tasks) < 2 {
+		return nil
+	}
+	connected := map[string]bool{}
+	for _, edge := range g.Edges {
+		if !slices.Contains(g.Finally, edge.To) {
+			connected[edge.From] = true
+			connected[edge.To] = true
+		}
+	}
+	var isolated []string
+	for _, name := range g.Tasks {
+		if !connected[name] {
+			isolated = append(isolated, name)
+		}
+	}
+	return isolated
+}
+
+// DOT renders the graph in the Graphviz DOT language. Finally tasks are grouped in a cluster, and
+// isolated tasks are drawn in red.
+func (g Graph) DOT(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", name)
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	isolated := g.Isolated()
+	for _, task := range g.Tasks {
+		if slices.Contains(isolated, task) {
+			fmt.Fprintf(&b, "  %q [color=red, tooltip=\"isolated\"];\n", task)
+		} else {
+			fmt.Fprintf(&b, "  %q;\n", task)
+		}
+	}
+	if len(g.Finally) > 0 {
+		b.WriteString("  subgraph cluster_finally {\n")
+		b.WriteString("    label=\"finally\";\n")
+		b.WriteString("    style=dashed;\n")
+		for _, task := range g.Finally {
+			fmt.Fprintf(&b, "    %q;\n", task)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, edge := range g.Edges {
+		switch edge.Kind {
+		case EdgeRunAfter:
+			fmt.Fprintf(&b, "  %q -> %q;\n", edge.From, edge.To)
+		case EdgeWhen:
+			fmt.Fprintf(&b, "  %q -> %q [label=%q, style=dashed];\n", edge.From, edge.To, edge.Kind)
+		default:
+			fmt.Fprintf(&b, "  %q -> %q [label=%q];\n", edge.From, edge.To, edge.Kind)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart. Nodes are identified by their position since
+// the names of PipelineTasks are not valid Mermaid identifiers. Finally tasks are grouped in a
+// subgraph, and isolated tasks are styled with the isolated class.
+func (g Graph) Mermaid() string {
+	ids := map[string]string{}
+	for i, task := range append(slices.Clone(g.Tasks), g.Finally...) {
+		ids[task] = fmt.Sprintf("t%d", i)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, task := range g.Tasks {
+		fmt.Fprintf(&b, "  %s[%q]\n", ids[task], task)
+	}
+	if len(g.Finally) > 0 {
+		b.WriteString("  subgraph finally\n")
+		for _, task := range g.Finally {
+			fmt.Fprintf(&b, "    %s[%q]\n", ids[task], task)
+		}
+		b.WriteString("  end\n")
+	}
+	for _, edge := range g.Edges {
+		switch edge.Kind {
+		case EdgeRunAfter:
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[edge.From], ids[edge.To])
+		case EdgeWhen:
+			fmt.Fprintf(&b, "  %s -.->|%s| %s\n", ids[edge.From], edge.Kind, ids[edge.To])
+		default:
+			fmt.Fprintf(&b, "  %s -->|%s| %s\n", ids[edge.From], edge.Kind, ids[edge.To])
+		}
+	}
+	if isolated := g.Isolated(); len(isolated) > 0 {
+		b.WriteString("  classDef isolated stroke:#d00,stroke-width:2px\n")
+		for _, task := range isolated {
+			fmt.Fprintf(&b, "  class %s isolated\n", ids[task])
+		}
+	}
+	return b.String()
+}
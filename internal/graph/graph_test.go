@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const pipelineYAML = `
+tasks:
+  - name: clone
+  - name: build
+    runAfter:
+      - clone
+    params:
+      - name: source
+        value: $(tasks.clone.results.path)
+  - name: scan
+    params:
+      - name: image
+        value: $(tasks.build.results.digest)
+    when:
+      - input: $(tasks.build.results.pushed)
+        operator: in
+        values: ["true"]
+  - name: docs
+finally:
+  - name: notify
+    params:
+      - name: digest
+        value: $(tasks.build.results.digest)
+      - name: missing
+        value: $(tasks.missing.results.value)
+`
+
+func pipelineSpecFromYAML(t *testing.T, content string) v1.PipelineSpec {
+	var spec v1.PipelineSpec
+	require.NoError(t, yaml.Unmarshal([]byte(content), &spec))
+	return spec
+}
+
+func TestNew(t *testing.T) {
+	g := New(pipelineSpecFromYAML(t, pipelineYAML))
+	assert.Equal(t, []string{"clone", "build", "scan", "docs"}, g.Tasks)
+	assert.Equal(t, []string{"notify"}, g.Finally)
+	assert.Equal(t, []Edge{
+		{From: "clone", To: "build", Kind: EdgeRunAfter},
+		{From: "clone", To: "build", Kind: EdgeResults},
+		{From: "build", To: "scan", Kind: EdgeWhen},
+		{From: "build", To: "scan", Kind: EdgeResults},
+		{From: "build", To: "notify", Kind: EdgeResults},
+	}, g.Edges)
+}
+
+func TestIsolated(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "isolated task",
+			content:  pipelineYAML,
+			expected: []string{"docs"},
+		},
+		{
+			name: "single task",
+			content: `
+tasks:
+  - name: build
+`,
+		},
+		{
+			name: "tasks only referred to by finally",
+			content: `
+tasks:
+  - name: build
+  - name: test
+finally:
+  - name: notify
+    params:
+      - name: digest
+        value: $(tasks.build.results.digest)
+`,
+			expected: []string{"build", "test"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, New(pipelineSpecFromYAML(t, tt.content)).Isolated())
+		})
+	}
+}
+
+func TestDOT(t *testing.T) {
+	assert.Equal(t, `digraph "build" {
+  rankdir=LR;
+  node [shape=box];
+  "clone";
+  "build";
+  "scan";
+  "docs" [color=red, tooltip="isolated"];
+  subgraph cluster_finally {
+    label="finally";
+    style=dashed;
+    "notify";
+  }
+  "clone" -> "build";
+  "clone" -> "build" [label="results"];
+  "build" -> "scan" [label="when", style=dashed];
+  "build" -> "scan" [label="results"];
+  "build" -> "notify" [label="results"];
+}
+`, New(pipelineSpecFromYAML(t, pipelineYAML)).DOT("build"))
+}
+
+func TestMermaid(t *testing.T) {
+	assert.Equal(t, `flowchart LR
+  t0["clone"]
+  t1["build"]
+  t2["scan"]
+  t3["docs"]
+  subgraph finally
+    t4["notify"]
+  end
+  t0 --> t1
+  t0 -->|results| t1
+  t1 -.->|when| t2
+  t1 -->|results| t2
+  t1 -->|results| t4
+  classDef isolated stroke:#d00,stroke-width:2px
+  class t3 isolated
+`, New(pipelineSpecFromYAML(t, pipelineYAML)).Mermaid())
+}
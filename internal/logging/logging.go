@@ -0,0 +1,109 @@
+// Package logging writes the progress and diagnostic messages of tektor by level. Messages go
+// through the standard logger, which writes to stderr, so that the output of commands, e.g. JSON or
+// SARIF reports, stays machine-readable.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+)
+
+// Level is the severity of a message
+type Level int32
+
+// Levels of messages, from the most to the least verbose
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var level atomic.Int32
+
+func init() {
+	level.Store(int32(LevelInfo))
+}
+
+// SetLevel sets the level below which messages are discarded
+func SetLevel(l Level) {
+	level.Store(int32(l))
+}
+
+// Enabled tells whether messages of level l are written
+func Enabled(l Level) bool {
+	return l >= Level(level.Load())
+}
+
+// Logger writes messages by level like the functions of the package, above the line of its
+// Progress, if any
+type Logger struct {
+	progress *Progress
+}
+
+// FromContext returns the Logger writing messages above the line of the Progress ctx carries, see
+// WithProgress
+func FromContext(ctx context.Context) Logger {
+	p, _ := ctx.Value(progressKey{}).(*Progress)
+	return Logger{progress: p}
+}
+
+// Debugf writes a message about the inner workings of tektor, e.g. the PipelineTask being
+// processed
+func Debugf(format string, args ...any) {
+	Logger{}.Debugf(format, args...)
+}
+
+// Infof writes a progress message, e.g. the file being validated
+func Infof(format string, args ...any) {
+	Logger{}.Infof(format, args...)
+}
+
+// Warnf writes a warning, e.g. a finding of the validation which is not an error
+func Warnf(format string, args ...any) {
+	Logger{}.Warnf(format, args...)
+}
+
+// Errorf writes an error which does not stop the command, e.g. a file which is not formatted
+func Errorf(format string, args ...any) {
+	Logger{}.Errorf(format, args...)
+}
+
+// Debugf is like the Debugf function of the package
+func (l Logger) Debugf(format string, args ...any) {
+	l.logf(LevelDebug, format, args...)
+}
+
+// Infof is like the Infof function of the package
+func (l Logger) Infof(format string, args ...any) {
+	l.logf(LevelInfo, format, args...)
+}
+
+// Warnf is like the Warnf function of the package
+func (l Logger) Warnf(format string, args ...any) {
+	l.logf(LevelWarn, format, args...)
+}
+
+// Errorf is like the Errorf function of the package
+func (l Logger) Errorf(format string, args ...any) {
+	l.logf(LevelError, format, args...)
+}
+
+func (l Logger) logf(lvl Level, format string, args ...any) {
+	if !Enabled(lvl) {
+		return
+	}
+	if p := l.progress; p != nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if !p.closed {
+			fmt.Fprint(p.out, "\r\033[K")
+			log.Printf(format, args...)
+			p.render()
+			return
+		}
+	}
+	log.Printf(format, args...)
+}
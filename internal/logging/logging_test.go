@@ -0,0 +1,47 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    Level
+		expected string
+	}{
+		{name: "debug", level: LevelDebug, expected: "debug\ninfo\nwarn\nerror\n"},
+		{name: "info", level: LevelInfo, expected: "info\nwarn\nerror\n"},
+		{name: "warn", level: LevelWarn, expected: "warn\nerror\n"},
+		{name: "error", level: LevelError, expected: "error\n"},
+	}
+
+	var buf bytes.Buffer
+	output, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(output)
+		log.SetFlags(flags)
+		SetLevel(LevelInfo)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			SetLevel(tt.level)
+
+			Debugf("debug")
+			Infof("info")
+			Warnf("%s", "warn")
+			Errorf("error")
+
+			assert.Equal(t, tt.expected, buf.String())
+			assert.Equal(t, tt.level <= LevelWarn, Enabled(LevelWarn))
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress reports on a line of a terminal how far a series of steps went, e.g. the resolution of
+// the remote Tasks of a Pipeline. The line is refreshed every second, so that a long step shows the
+// time elapsed rather than looking hung. The methods of a nil Progress do nothing.
+type Progress struct {
+	mu      sync.Mutex
+	out     io.Writer
+	title   string
+	total   int
+	done    int
+	current string
+	start   time.Time
+	stop    chan struct{}
+	stopped chan struct{}
+	closed  bool
+}
+
+// progressKey is the key of the Progress carried by a context
+type progressKey struct{}
+
+// WithProgress returns a copy of ctx carrying p, which the Logger of the context writes messages
+// above of
+func WithProgress(ctx context.Context, p *Progress) context.Context {
+	return context.WithValue(ctx, progressKey{}, p)
+}
+
+// NewProgress returns a Progress of total steps written to out, or nil if out is nil or there are
+// no steps. Messages logged through the Logger of a context carrying it, see WithProgress, are
+// written above the line of the Progress until it is closed.
+func NewProgress(out io.Writer, title string, total int) *Progress {
+	if out == nil || total == 0 {
+		return nil
+	}
+	p := &Progress{
+		out:     out,
+		title:   title,
+		total:   total,
+		start:   time.Now(),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.mu.Lock()
+				p.render()
+				p.mu.Unlock()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+	return p
+}
+
+// Start reports the step starting, described by label
+func (p *Progress) Start(label string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = label
+	p.render()
+}
+
+// Done reports the current step as done
+func (p *Progress) Done() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.current = ""
+	p.render()
+}
+
+// Close stops refreshing the line and clears it
+func (p *Progress) Close() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	<-p.stopped
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	fmt.Fprint(p.out, "\r\033[K")
+}
+
+func (p *Progress) render() {
+	line := fmt.Sprintf("⏳ %s %d/%d", p.title, p.done, p.total)
+	if p.current != "" {
+		line += ": " + p.current
+	}
+	fmt.Fprintf(p.out, "\r\033[K%s (%s)", line, time.Since(p.start).Round(time.Second))
+}
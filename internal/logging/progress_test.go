@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	var buf bytes.Buffer
+	output, flags := log.Writer(), log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(output)
+		log.SetFlags(flags)
+	})
+
+	p := NewProgress(&buf, "Resolving", 2)
+	logger := FromContext(WithProgress(context.Background(), p))
+	p.Start("bundle quay.io/example/build:1.0, Task build")
+	p.Done()
+	logger.Warnf("⚠️  Retrying")
+	p.Start("hub git-clone 0.9")
+	p.Done()
+	p.Close()
+
+	assert.Equal(t, "\r\033[K⏳ Resolving 0/2: bundle quay.io/example/build:1.0, Task build (0s)"+
+		"\r\033[K⏳ Resolving 1/2 (0s)"+
+		"\r\033[K⚠️  Retrying\n"+
+		"\r\033[K⏳ Resolving 1/2 (0s)"+
+		"\r\033[K⏳ Resolving 1/2: hub git-clone 0.9 (0s)"+
+		"\r\033[K⏳ Resolving 2/2 (0s)"+
+		"\r\033[K", buf.String())
+
+	buf.Reset()
+	logger.Warnf("⚠️  Done")
+	assert.Equal(t, "⚠️  Done\n", buf.String(), "Expected messages to be written as is once the progress is closed")
+
+	// Messages logged without the context of the Progress, e.g. by another validation, leave its line
+	// alone.
+	p = NewProgress(&buf, "Resolving", 1)
+	buf.Reset()
+	Warnf("⚠️  Elsewhere")
+	assert.Equal(t, "⚠️  Elsewhere\n", buf.String())
+	p.Close()
+}
+
+func TestNilProgress(t *testing.T) {
+	assert.Nil(t, NewProgress(nil, "Resolving", 2))
+	var buf bytes.Buffer
+	p := NewProgress(&buf, "Resolving", 0)
+	assert.Nil(t, p)
+
+	p.Start("bundle quay.io/example/build:1.0, Task build")
+	p.Done()
+	p.Close()
+	assert.Empty(t, buf.String())
+}
@@ -2,25 +2,38 @@ package pac
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/openshift-pipelines/pipelines-as-code/pkg/formatting"
+	pacfake "github.com/openshift-pipelines/pipelines-as-code/pkg/generated/clientset/versioned/fake"
 	"github.com/openshift-pipelines/pipelines-as-code/pkg/git"
 	"github.com/openshift-pipelines/pipelines-as-code/pkg/params"
+	"github.com/openshift-pipelines/pipelines-as-code/pkg/params/clients"
 	"github.com/openshift-pipelines/pipelines-as-code/pkg/params/info"
 	"github.com/openshift-pipelines/pipelines-as-code/pkg/params/settings"
+	"github.com/openshift-pipelines/pipelines-as-code/pkg/provider"
+	"github.com/openshift-pipelines/pipelines-as-code/pkg/provider/bitbucketcloud"
+	"github.com/openshift-pipelines/pipelines-as-code/pkg/provider/gitea"
 	"github.com/openshift-pipelines/pipelines-as-code/pkg/provider/github"
+	"github.com/openshift-pipelines/pipelines-as-code/pkg/provider/gitlab"
 	"github.com/openshift-pipelines/pipelines-as-code/pkg/resolve"
 	"github.com/openshift-pipelines/pipelines-as-code/pkg/templates"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
 	"go.uber.org/zap"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/document"
+	"github.com/lcarva/tektor/internal/format"
+	"github.com/lcarva/tektor/internal/logging"
 )
 
 /*
@@ -31,27 +44,57 @@ The implementation in github.com/openshift-pipelines/pipelines-as-code is not do
 manner. As such, the majority of the code here was copied and pasted from that repo.
 */
 
-func ResolvePipelineRun(ctx context.Context, fname string, prName string) ([]byte, error) {
+// Git providers Pipelines as Code resolves PipelineRuns for
+const (
+	GitProviderGitHub    = "github"
+	GitProviderGitLab    = "gitlab"
+	GitProviderGitea     = "gitea"
+	GitProviderBitbucket = "bitbucket"
+)
+
+// GitProviders lists the git providers which can be set in Options
+var GitProviders = []string{GitProviderGitHub, GitProviderGitLab, GitProviderGitea, GitProviderBitbucket}
+
+// IsKnownGitProvider reports whether name is one of GitProviders
+func IsKnownGitProvider(name string) bool {
+	return slices.Contains(GitProviders, name)
+}
+
+// Options controls the resolution of PipelineRuns
+type Options struct {
+	// Exclude lists the patterns of the files of the .tekton directory to leave out, see IsExcluded.
+	Exclude []string
+	// GitProvider is the git provider hosting the repository, see GitProviders, defaults to the one of
+	// the Repository, or else to GitHub.
+	GitProvider string
+	// Repository is the Repository of Pipelines as Code of the git repository, if any, whose URL and
+	// custom params are variables of the PipelineRuns.
+	Repository *Repository
+	// Pipelines are Pipelines found outside of the .tekton directory, which PipelineRuns may refer to
+	// by name as if they were defined in the directory.
+	Pipelines []document.Document
+}
+
+// ResolvePipelineRun resolves the PipelineRun named prName in fname with the files of the .tekton
+// directory of its repository, as Pipelines as Code does for the git provider of opts.
+func ResolvePipelineRun(ctx context.Context, fname string, prName string, opts Options) ([]byte, error) {
+	gitProvider := opts.GitProvider
+	if gitProvider == "" && opts.Repository != nil {
+		gitProvider = opts.Repository.GitProvider
+	}
+	providerintf, err := newProvider(gitProvider)
+	if err != nil {
+		return nil, err
+	}
+
 	run := params.New()
-	errc := run.Clients.NewClients(ctx, &run.Info)
 	zaplog, err := zap.NewProduction(
 		zap.IncreaseLevel(zap.FatalLevel),
 	)
 	if err != nil {
 		return nil, err
 	}
-	run.Clients.Log = zaplog.Sugar()
-
-	if errc != nil {
-		// Allow resolve to be run without a kubeconfig
-		noConfigErr := strings.Contains(errc.Error(), "Couldn't get kubeConfiguration namespace")
-		if !noConfigErr {
-			return nil, errc
-		}
-	} else {
-		// It's OK  if pac is not installed, ignore the error
-		_ = run.UpdatePACInfo(ctx)
-	}
+	run.Clients = offlineClients(zaplog.Sugar())
 
 	pacConfig := map[string]string{}
 	if err := settings.ConfigToSettings(run.Clients.Log, run.Info.Pac.Settings, pacConfig); err != nil {
@@ -64,34 +107,34 @@ func ResolvePipelineRun(ctx context.Context, fname string, prName string) ([]byt
 	if gitinfo.SHA != "" {
 		params["revision"] = gitinfo.SHA
 	}
-	if gitinfo.URL != "" {
-		params["repo_url"] = gitinfo.URL
-		repoOwner, err := formatting.GetRepoOwnerFromURL(gitinfo.URL)
+	// In the cluster, the variables of the repository come from the URL of its Repository.
+	repoURL := gitinfo.URL
+	if opts.Repository != nil && opts.Repository.URL != "" {
+		repoURL = opts.Repository.URL
+	}
+	if repoURL != "" {
+		params["repo_url"] = repoURL
+		owner, name, err := repoOwnerAndName(repoURL, gitProvider)
 		if err != nil {
 			return nil, fmt.Errorf("getting git repo owner: %w", err)
 		}
-		params["repo_owner"] = strings.Split(repoOwner, "/")[0]
-		params["repo_name"] = strings.Split(repoOwner, "/")[1]
+		params["repo_owner"] = owner
+		params["repo_name"] = name
+	}
+	if opts.Repository != nil {
+		opts.Repository.variables(params)
 	}
 
 	pacDir := path.Join(gitinfo.TopLevelPath, ".tekton")
-	allTemplates := templates.ReplacePlaceHoldersVariables(enumerateFiles([]string{pacDir}), params)
+	allTemplates := templates.ReplacePlaceHoldersVariables(enumerateFiles([]string{pacDir}, opts.Exclude)+string(document.Join(opts.Pipelines)), params)
 
-	// We use github here but since we don't do remotetask we would not care
-	providerintf := github.New()
 	event := info.NewEvent()
-	// Must change working dir to git repo so local fs resolver works
+	// Remote Tasks referred to by a path are fetched from the repository by the provider once the
+	// event has a revision, which reads them relative to the root of the repository rather than the
+	// working directory, so that resolutions can run concurrently.
 	if gitinfo.TopLevelPath != "" {
-		wd, err := os.Getwd()
-		if err != nil {
-			return nil, fmt.Errorf("getting current working directory: %w", err)
-		}
-		if err := os.Chdir(gitinfo.TopLevelPath); err != nil {
-			return nil, fmt.Errorf("changing working directory: %w", err)
-		}
-		defer func(wd string) {
-			_ = os.Chdir(wd)
-		}(wd)
+		event.SHA = gitinfo.SHA
+		providerintf = repoFileProvider{Interface: providerintf, root: gitinfo.TopLevelPath}
 	}
 
 	ropt := &resolve.Opts{RemoteTasks: true}
@@ -117,43 +160,195 @@ func ResolvePipelineRun(ctx context.Context, fname string, prName string) ([]byt
 		return nil, fmt.Errorf("marshaling pac resolved pipelinerun: %w", err)
 	}
 
-	return cleanRe.ReplaceAll(d, []byte("\n")), nil
+	return format.Clean(d)
+}
+
+// offlineClients returns the clients of Pipelines as Code backed by fake clientsets. The resolution
+// never reaches a cluster, so neither a kubeconfig nor a cluster are needed, and the settings of
+// Pipelines as Code are the defaults rather than those of whichever cluster the environment points
+// to.
+func offlineClients(log *zap.SugaredLogger) clients.Clients {
+	return clients.Clients{
+		ClientInitialized: true,
+		Kube:              kubefake.NewSimpleClientset(),
+		Tekton:            tektonfake.NewSimpleClientset(),
+		PipelineAsCode:    pacfake.NewSimpleClientset(),
+		Log:               log,
+	}
+}
+
+// newProvider returns the Pipelines as Code provider of a git provider, see GitProviders. Only
+// GitHub fetches remote Tasks hosted on the repository through its API, the others fetch them over
+// HTTP like Tasks hosted elsewhere.
+func newProvider(gitProvider string) (provider.Interface, error) {
+	switch gitProvider {
+	case "", GitProviderGitHub:
+		return github.New(), nil
+	case GitProviderGitLab:
+		return &gitlab.Provider{}, nil
+	case GitProviderGitea:
+		return &gitea.Provider{}, nil
+	case GitProviderBitbucket:
+		return &bitbucketcloud.Provider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown git provider %q, expected one of: %s", gitProvider, strings.Join(GitProviders, ", "))
+	}
+}
+
+// repoFileProvider is a provider reading the files of the repository from its local clone at root
+type repoFileProvider struct {
+	provider.Interface
+	root string
+}
+
+// GetFileInsideRepo reads the file at path, relative to the root of the repository. Like Pipelines as
+// Code resolving from the local filesystem, a missing file is read as empty.
+func (p repoFileProvider) GetFileInsideRepo(_ context.Context, _ *info.Event, path, _ string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(p.root, path)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// repoOwnerAndName returns the repo_owner and repo_name variables of the repository at a URL. GitLab
+// nests projects in groups and subgroups, which make up the owner, while the other providers only
+// consider the first two parts of the path.
+func repoOwnerAndName(repoURL, gitProvider string) (string, string, error) {
+	repoOwner, err := formatting.GetRepoOwnerFromURL(repoURL)
+	if err != nil {
+		return "", "", err
+	}
+	if gitProvider == GitProviderGitLab {
+		separator := strings.LastIndex(repoOwner, "/")
+		return repoOwner[:separator], repoOwner[separator+1:], nil
+	}
+	parts := strings.Split(repoOwner, "/")
+	return parts[0], parts[1], nil
+}
+
+// TektonDir returns the .tekton directory of the git repository containing fname, or an empty
+// string if fname is not part of a git repository
+func TektonDir(fname string) string {
+	gitinfo := git.GetGitInfo(path.Dir(fname))
+	if gitinfo.TopLevelPath == "" {
+		return ""
+	}
+	return path.Join(gitinfo.TopLevelPath, ".tekton")
 }
 
-// cleanedup regexp do as much as we can but really it's a lost game to try this
-var cleanRe = regexp.MustCompile(`\n(\t|\s)*(creationTimestamp|spec|taskRunTemplate|metadata|computeResources):\s*(null|{})\n`)
+// IsExcluded tells whether the file at path within the .tekton directory dir matches one of the
+// patterns. Patterns use the filepath.Match syntax, and are matched against the path of the file
+// relative to dir as well as against its name.
+func IsExcluded(dir, path string, patterns []string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, rel); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return true
+		}
+	}
+	return false
+}
 
-func enumerateFiles(filenames []string) string {
-	var yamlDoc string
+// PipelineRunFiles returns the YAML files of the .tekton directory dir, and of its subdirectories,
+// which define at least one PipelineRun, in lexical order. Files matching one of the exclude
+// patterns, see IsExcluded, are left out, as they are of the resolution.
+func PipelineRunFiles(dir string, exclude []string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isYAMLFile(path) || IsExcluded(dir, path, exclude) {
+			return nil
+		}
+		docs, err := document.SplitFile(path)
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			if doc.Kind == "PipelineRun" {
+				files = append(files, path)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing the PipelineRuns of %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// isYAMLFile tells whether path has the extension of a YAML file
+func isYAMLFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func enumerateFiles(filenames []string, exclude []string) string {
+	var docs []document.Document
 	for _, paths := range filenames {
 		if stat, err := os.Stat(paths); err == nil && !stat.IsDir() {
-			yamlDoc += appendYaml(paths)
+			docs = append(docs, readDocuments(paths)...)
 			continue
 		}
 
-		// walk dir getting all yamls
-		err := filepath.Walk(paths, func(path string, fi os.FileInfo, err error) error {
-			if filepath.Ext(path) == ".yaml" {
-				yamlDoc += appendYaml(path)
+		// walk dir getting all yamls, including those of nested directories
+		_ = filepath.WalkDir(paths, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == paths && errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				// Unreadable entries are left out rather than failing the resolution.
+				logging.Warnf("⚠️  Skipping %s: %v", path, err)
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
 			}
+			if d.IsDir() || !isYAMLFile(path) || IsExcluded(paths, path, exclude) {
+				return nil
+			}
+			docs = append(docs, readDocuments(path)...)
 			return nil
 		})
-		if err != nil {
-			log.Fatalf("Error enumerating files: %v", err)
-		}
 	}
 
-	return yamlDoc
+	return string(document.Join(docs))
 }
 
-func appendYaml(filename string) string {
-	b, err := os.ReadFile(filename)
+// readDocuments returns the documents of a file. Files which cannot be read are left out rather
+// than failing the resolution.
+func readDocuments(filename string) []document.Document {
+	docs, err := document.SplitFile(filename)
 	if err != nil {
-		log.Fatal(err)
+		logging.Warnf("⚠️  Skipping %s", err)
+		return nil
 	}
-	s := string(b)
-	if strings.HasPrefix(s, "---") {
-		return s
+
+	// Documents exceeding the input limits are left out rather than handed to the resolver.
+	var limitErr *document.LimitError
+	var accepted []document.Document
+	for _, doc := range docs {
+		if errors.As(doc.Err, &limitErr) {
+			logging.Warnf("⚠️  Skipping %s", doc.Err)
+			continue
+		}
+		accepted = append(accepted, doc)
 	}
-	return fmt.Sprintf("---\n%s", s)
+	return accepted
 }
@@ -5,7 +5,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	gogit "github.com/go-git/go-git/v5"
+	gitcfg "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/openshift-pipelines/pipelines-as-code/pkg/provider/github"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -226,7 +231,7 @@ spec:
 				require.NoError(t, err)
 			}
 
-			result, err := ResolvePipelineRun(ctx, filePath, tt.pipelineRunName)
+			result, err := ResolvePipelineRun(ctx, filePath, tt.pipelineRunName, Options{})
 
 			if tt.expectedError {
 				require.Error(t, err, "Expected error for test case: %s", tt.name)
@@ -535,7 +540,7 @@ spec:
 				require.NoError(t, err)
 			}
 
-			result, err := ResolvePipelineRun(ctx, filePath, tt.pipelineRunName)
+			result, err := ResolvePipelineRun(ctx, filePath, tt.pipelineRunName, Options{})
 
 			if tt.expectedError {
 				require.Error(t, err, "Expected error for test case: %s", tt.name)
@@ -725,7 +730,7 @@ spec:
 				require.NoError(t, err)
 			}
 
-			result, err := ResolvePipelineRun(ctx, filePath, tt.pipelineRunName)
+			result, err := ResolvePipelineRun(ctx, filePath, tt.pipelineRunName, Options{})
 
 			if tt.expectedError {
 				require.Error(t, err, "Expected error for test case: %s", tt.name)
@@ -873,7 +878,7 @@ spec:
 				require.NoError(t, err)
 			}
 
-			result, err := ResolvePipelineRun(ctx, filePath, tt.pipelineRunName)
+			result, err := ResolvePipelineRun(ctx, filePath, tt.pipelineRunName, Options{})
 
 			if tt.expectedError {
 				require.Error(t, err, "Expected error for test case: %s", tt.name)
@@ -893,3 +898,202 @@ spec:
 		})
 	}
 }
+
+func TestIsExcluded(t *testing.T) {
+	dir := filepath.Join("repo", ".tekton")
+	tests := []struct {
+		name     string
+		path     string
+		patterns []string
+		expected bool
+	}{
+		{name: "no patterns", path: filepath.Join(dir, "push.yaml")},
+		{name: "file name", path: filepath.Join(dir, "config", "values.yaml"), patterns: []string{"values.yaml"}, expected: true},
+		{name: "relative path", path: filepath.Join(dir, "config", "values.yaml"), patterns: []string{"config/*"}, expected: true},
+		{name: "other directory", path: filepath.Join(dir, "tasks", "values.yaml"), patterns: []string{"config/*"}},
+		{name: "malformed pattern", path: filepath.Join(dir, "push.yaml"), patterns: []string{"["}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsExcluded(dir, tt.path, tt.patterns))
+		})
+	}
+}
+
+func TestEnumerateFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".tekton")
+	files := map[string]string{
+		"push.yaml":           "apiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: push\n",
+		"tasks/build.yml":     "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n",
+		"config/values.yaml":  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: values\n",
+		"README.md":           "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: readme\n",
+		"tasks/deep/lint.yml": "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: lint\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	enumerated := enumerateFiles([]string{dir}, []string{"config/*"})
+	assert.Contains(t, enumerated, "name: push")
+	assert.Contains(t, enumerated, "name: build")
+	assert.Contains(t, enumerated, "name: lint")
+	assert.NotContains(t, enumerated, "name: values")
+	assert.NotContains(t, enumerated, "name: readme")
+
+	// A missing directory contributes no files.
+	assert.Empty(t, enumerateFiles([]string{filepath.Join(dir, "missing")}, nil))
+
+	// Nor do files which cannot be read.
+	require.NoError(t, os.Symlink(filepath.Join(dir, "missing.yaml"), filepath.Join(dir, "dangling.yaml")))
+	enumerated = enumerateFiles([]string{dir}, nil)
+	assert.Contains(t, enumerated, "name: push")
+	assert.Empty(t, enumerateFiles([]string{filepath.Join(dir, "dangling.yaml")}, nil))
+}
+
+func TestPipelineRunFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".tekton")
+	files := map[string]string{
+		"push.yaml":              "apiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: push\n",
+		"pull-request.yml":       "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: lint\n---\napiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: pull-request\n",
+		"tasks/build.yaml":       "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n",
+		"nested/release.yaml":    "apiVersion: tekton.dev/v1beta1\nkind: PipelineRun\nmetadata:\n  name: release\n",
+		"config/pipelinerun.yml": "apiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: excluded\n",
+		"notes.txt":              "apiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: notes\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	found, err := PipelineRunFiles(dir, []string{"config/*"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "nested", "release.yaml"),
+		filepath.Join(dir, "pull-request.yml"),
+		filepath.Join(dir, "push.yaml"),
+	}, found)
+
+	_, err = PipelineRunFiles(filepath.Join(dir, "missing"), nil)
+	assert.ErrorContains(t, err, "listing the PipelineRuns of")
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, gitProvider := range append([]string{""}, GitProviders...) {
+		providerintf, err := newProvider(gitProvider)
+		require.NoError(t, err, gitProvider)
+		assert.NotNil(t, providerintf, gitProvider)
+	}
+
+	_, err := newProvider("svn")
+	assert.EqualError(t, err, `unknown git provider "svn", expected one of: github, gitlab, gitea, bitbucket`)
+}
+
+func TestRepoOwnerAndName(t *testing.T) {
+	tests := []struct {
+		name          string
+		url           string
+		gitProvider   string
+		expectedOwner string
+		expectedName  string
+	}{
+		{name: "github", url: "https://github.com/Example/Repo", gitProvider: GitProviderGitHub, expectedOwner: "example", expectedName: "repo"},
+		{name: "gitlab", url: "https://gitlab.com/example/repo", gitProvider: GitProviderGitLab, expectedOwner: "example", expectedName: "repo"},
+		{name: "gitlab subgroup", url: "https://gitlab.com/example/team/repo", gitProvider: GitProviderGitLab, expectedOwner: "example/team", expectedName: "repo"},
+		{name: "gitea", url: "https://gitea.example.com/example/repo", gitProvider: GitProviderGitea, expectedOwner: "example", expectedName: "repo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner, name, err := repoOwnerAndName(tt.url, tt.gitProvider)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOwner, owner)
+			assert.Equal(t, tt.expectedName, name)
+		})
+	}
+
+	_, _, err := repoOwnerAndName("https://github.com/repo", GitProviderGitHub)
+	assert.Error(t, err)
+}
+
+func TestRepoFileProvider(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "tasks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "tasks", "hello.yaml"), []byte("kind: Task\n"), 0644))
+	p := repoFileProvider{Interface: github.New(), root: root}
+
+	data, err := p.GetFileInsideRepo(context.Background(), nil, "tasks/hello.yaml", "")
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Task\n", data)
+
+	data, err = p.GetFileInsideRepo(context.Background(), nil, filepath.Join(root, "tasks", "hello.yaml"), "")
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Task\n", data)
+
+	// Like Pipelines as Code, a missing file is read as empty.
+	data, err = p.GetFileInsideRepo(context.Background(), nil, "tasks/missing.yaml", "")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestResolvePipelineRunWithoutCluster(t *testing.T) {
+	dir := t.TempDir()
+	repository, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+	_, err = repository.CreateRemote(&gitcfg.RemoteConfig{Name: "origin", URLs: []string{"https://github.com/example/repo"}})
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".tekton"), 0755))
+	fname := filepath.Join(dir, ".tekton", "push.yaml")
+	require.NoError(t, os.WriteFile(fname, []byte(`apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: push
+spec:
+  pipelineSpec:
+    tasks:
+      - name: hello
+        taskSpec:
+          steps:
+            - name: hello
+              image: alpine:latest
+              script: echo {{ revision }}
+`), 0644))
+	worktree, err := repository.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add(".tekton/push.yaml")
+	require.NoError(t, err)
+	_, err = worktree.Commit("push", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "tektor", Email: "tektor@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	// Neither a kubeconfig nor a cluster are needed, whatever the environment points to.
+	kubeconfigs := map[string]string{
+		"empty":       "",
+		"no context":  "apiVersion: v1\nkind: Config\nclusters: []\ncontexts: []\n",
+		"bad context": "apiVersion: v1\nkind: Config\ncurrent-context: c\ncontexts:\n- name: c\n  context:\n    cluster: missing\n    namespace: ns\n",
+		"unreachable": "apiVersion: v1\nkind: Config\ncurrent-context: c\ncontexts:\n- name: c\n  context:\n    cluster: k\n    namespace: ns\nclusters:\n- name: k\n  cluster:\n    server: https://127.0.0.1:1\n",
+	}
+	envs := map[string]map[string]string{
+		"missing kubeconfig": {"KUBECONFIG": filepath.Join(dir, "missing")},
+		"in cluster":         {"KUBECONFIG": filepath.Join(dir, "missing"), "KUBERNETES_SERVICE_HOST": "127.0.0.1", "KUBERNETES_SERVICE_PORT": "1"},
+	}
+	for name, content := range kubeconfigs {
+		kubeconfig := filepath.Join(t.TempDir(), "config")
+		require.NoError(t, os.WriteFile(kubeconfig, []byte(content), 0600))
+		envs[name+" kubeconfig"] = map[string]string{"KUBECONFIG": kubeconfig, "SYSTEM_NAMESPACE": "pipelines-as-code"}
+	}
+	for name, env := range envs {
+		t.Run(name, func(t *testing.T) {
+			for key, value := range env {
+				t.Setenv(key, value)
+			}
+			result, err := ResolvePipelineRun(context.Background(), fname, "push", Options{})
+			require.NoError(t, err)
+			assert.Contains(t, string(result), "name: push")
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package pac
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/openshift-pipelines/pipelines-as-code/pkg/apis/pipelinesascode"
+	"sigs.k8s.io/yaml"
+)
+
+// Repository holds the parts of a Repository of Pipelines as Code which the resolution of the
+// PipelineRuns of its git repository depends on
+type Repository struct {
+	// URL is the URL of the git repository, which the repo_url, repo_owner, and repo_name
+	// variables are taken from.
+	URL string
+	// GitProvider is the git provider of the repository, see GitProviders, or an empty string.
+	GitProvider string
+	// Params are the custom params of the Repository, which are variables of the PipelineRuns.
+	Params []RepositoryParam
+}
+
+// RepositoryParam is a custom param of a Repository
+type RepositoryParam struct {
+	Name  string
+	Value string
+	// Secret tells the value is read from a Secret of the cluster, so it is not known locally.
+	Secret bool
+	// Filter is the CEL expression an event must match for the param to be set. Filters are not
+	// evaluated, params are set for every PipelineRun.
+	Filter string
+}
+
+// repositoryGitProviders maps the git provider types of Repositories to GitProviders
+var repositoryGitProviders = map[string]string{
+	"github":          GitProviderGitHub,
+	"gitlab":          GitProviderGitLab,
+	"gitea":           GitProviderGitea,
+	"bitbucket-cloud": GitProviderBitbucket,
+}
+
+// LoadRepository reads the Repository defined in fname
+func LoadRepository(fname string) (*Repository, error) {
+	content, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, fmt.Errorf("reading the Repository: %w", err)
+	}
+	var raw struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Spec       struct {
+			URL         string `json:"url"`
+			GitProvider *struct {
+				Type string `json:"type"`
+			} `json:"git_provider"`
+			Params []struct {
+				Name      string `json:"name"`
+				Value     string `json:"value"`
+				SecretRef *struct {
+					Name string `json:"name"`
+					Key  string `json:"key"`
+				} `json:"secret_ref"`
+				Filter string `json:"filter"`
+			} `json:"params"`
+		} `json:"spec"`
+	}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s as a Repository: %w", fname, err)
+	}
+	if raw.Kind != "Repository" || raw.APIVersion != pipelinesascode.GroupName+"/v1alpha1" {
+		return nil, fmt.Errorf("%s is a %s/%s, not a %s/v1alpha1/Repository", fname, raw.APIVersion, raw.Kind, pipelinesascode.GroupName)
+	}
+
+	repo := &Repository{URL: raw.Spec.URL}
+	if raw.Spec.GitProvider != nil && raw.Spec.GitProvider.Type != "" {
+		gitProvider, ok := repositoryGitProviders[raw.Spec.GitProvider.Type]
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported git provider %q: spec.git_provider.type", fname, raw.Spec.GitProvider.Type)
+		}
+		repo.GitProvider = gitProvider
+	}
+	for i, param := range raw.Spec.Params {
+		if param.Name == "" {
+			return nil, fmt.Errorf("%s: custom param without a name: spec.params[%d]", fname, i)
+		}
+		repo.Params = append(repo.Params, RepositoryParam{
+			Name:   param.Name,
+			Value:  param.Value,
+			Secret: param.SecretRef != nil,
+			Filter: param.Filter,
+		})
+	}
+	return repo, nil
+}
+
+// ParamNames returns the names of the custom params of the Repository, which may be nil
+func (r *Repository) ParamNames() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, 0, len(r.Params))
+	for _, param := range r.Params {
+		names = append(names, param.Name)
+	}
+	return names
+}
+
+// variables adds the variables the Repository sets to those of a PipelineRun. The variables of
+// Pipelines as Code take precedence over custom params, which cannot override them.
+func (r *Repository) variables(variables map[string]string) {
+	for _, param := range r.Params {
+		if _, ok := variables[param.Name]; ok || param.Secret {
+			continue
+		}
+		variables[param.Name] = param.Value
+	}
+}
@@ -0,0 +1,108 @@
+package pac
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expected      *Repository
+		errorContains string
+	}{
+		{
+			name: "repository",
+			content: `apiVersion: pipelinesascode.tekton.dev/v1alpha1
+kind: Repository
+metadata:
+  name: repo
+spec:
+  url: https://gitlab.com/example/team/repo
+  git_provider:
+    type: gitlab
+  params:
+    - name: image_repo
+      value: quay.io/example/repo
+    - name: registry_token
+      secret_ref:
+        name: registry
+        key: token
+    - name: channel
+      value: pull-requests
+      filter: pac.event_type == "pull_request"
+`,
+			expected: &Repository{
+				URL:         "https://gitlab.com/example/team/repo",
+				GitProvider: GitProviderGitLab,
+				Params: []RepositoryParam{
+					{Name: "image_repo", Value: "quay.io/example/repo"},
+					{Name: "registry_token", Secret: true},
+					{Name: "channel", Value: "pull-requests", Filter: `pac.event_type == "pull_request"`},
+				},
+			},
+		},
+		{
+			name:     "minimal repository",
+			content:  "apiVersion: pipelinesascode.tekton.dev/v1alpha1\nkind: Repository\nspec:\n  url: https://github.com/example/repo\n",
+			expected: &Repository{URL: "https://github.com/example/repo"},
+		},
+		{
+			name:          "other kind",
+			content:       "apiVersion: tekton.dev/v1\nkind: PipelineRun\n",
+			errorContains: "is a tekton.dev/v1/PipelineRun, not a pipelinesascode.tekton.dev/v1alpha1/Repository",
+		},
+		{
+			name:          "unsupported git provider",
+			content:       "apiVersion: pipelinesascode.tekton.dev/v1alpha1\nkind: Repository\nspec:\n  git_provider:\n    type: svn\n",
+			errorContains: `unsupported git provider "svn": spec.git_provider.type`,
+		},
+		{
+			name:          "param without a name",
+			content:       "apiVersion: pipelinesascode.tekton.dev/v1alpha1\nkind: Repository\nspec:\n  params:\n    - value: x\n",
+			errorContains: "custom param without a name: spec.params[0]",
+		},
+		{
+			name:          "malformed content",
+			content:       "spec: [",
+			errorContains: "as a Repository",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fname := filepath.Join(t.TempDir(), "repository.yaml")
+			require.NoError(t, os.WriteFile(fname, []byte(tt.content), 0644))
+
+			repo, err := LoadRepository(fname)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, repo)
+		})
+	}
+
+	_, err := LoadRepository(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "reading the Repository")
+}
+
+func TestRepositoryVariables(t *testing.T) {
+	repo := &Repository{Params: []RepositoryParam{
+		{Name: "image_repo", Value: "quay.io/example/repo"},
+		{Name: "registry_token", Secret: true},
+		{Name: "revision", Value: "main"},
+	}}
+	variables := map[string]string{"revision": "abc123"}
+	repo.variables(variables)
+	assert.Equal(t, map[string]string{"revision": "abc123", "image_repo": "quay.io/example/repo"}, variables)
+
+	assert.Equal(t, []string{"image_repo", "registry_token", "revision"}, repo.ParamNames())
+	assert.Nil(t, (*Repository)(nil).ParamNames())
+}
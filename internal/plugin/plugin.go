@@ -0,0 +1,109 @@
+// Package plugin runs external validators, tektor-validate-* executables found in the plugin
+// directories given by the user, in the fashion of kubectl plugins. Plugins are never looked up on
+// the PATH, so that validating does not run executables the user did not opt in to.
+//
+// A plugin receives the resource to validate, a resolved Pipeline or PipelineRun, as JSON on its
+// standard input, and writes the findings of its validation as a JSON array to its standard output:
+//
+//	[{"rule": "ORG001", "severity": "error", "message": "...", "resourcePath": "spec.tasks[0]"}]
+//
+// An empty output means no findings. Plugins exiting with a non-zero status are reported as failed.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Prefix of the names of plugin executables
+const Prefix = "tektor-validate-"
+
+// Plugin is an external validator
+type Plugin struct {
+	// Name is the name of the executable without Prefix.
+	Name string
+	Path string
+}
+
+// Finding is a problem reported by a plugin, in the JSON format of the findings of tektor
+type Finding struct {
+	Rule         string `json:"rule"`
+	Severity     string `json:"severity"`
+	Message      string `json:"message"`
+	ResourcePath string `json:"resourcePath"`
+}
+
+// Discover returns the plugins found in dirs. A plugin found in several directories is only
+// returned for the first one.
+func Discover(dirs []string) []Plugin {
+	var plugins []Plugin
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), Prefix)
+			if !ok || name == "" || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, Plugin{Name: name, Path: path})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// Run runs the plugin against a resource, which is passed as JSON on its standard input
+func (p Plugin) Run(ctx context.Context, resource any) ([]Finding, error) {
+	input, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s plugin failed: %w: %s", p.Name, err, msg)
+		}
+		return nil, fmt.Errorf("%s plugin failed: %w", p.Name, err)
+	}
+
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return nil, nil
+	}
+	var findings []Finding
+	if err := json.Unmarshal(stdout.Bytes(), &findings); err != nil {
+		return nil, fmt.Errorf("%s plugin: decoding findings: %w", p.Name, err)
+	}
+	for i, finding := range findings {
+		if finding.Message == "" {
+			return nil, fmt.Errorf("%s plugin: finding %d has no message", p.Name, i)
+		}
+		if finding.Severity != "" && finding.Severity != "error" && finding.Severity != "warning" {
+			return nil, fmt.Errorf("%s plugin: finding %d has the unknown severity %q", p.Name, i, finding.Severity)
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeExecutable writes a shell script to dir
+func writeExecutable(t *testing.T, dir, name, script string, mode os.FileMode) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), mode))
+	return path
+}
+
+func TestDiscover(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	naming := writeExecutable(t, first, Prefix+"naming", "", 0o755)
+	writeExecutable(t, second, Prefix+"naming", "", 0o755)
+	images := writeExecutable(t, second, Prefix+"images", "", 0o755)
+	writeExecutable(t, first, Prefix+"disabled", "", 0o644)
+	writeExecutable(t, first, "tektor", "", 0o755)
+	require.NoError(t, os.Mkdir(filepath.Join(first, Prefix+"dir"), 0o755))
+
+	plugins := Discover([]string{first, filepath.Join(first, "missing"), second})
+	assert.Equal(t, []Plugin{
+		{Name: "images", Path: images},
+		{Name: "naming", Path: naming},
+	}, plugins)
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name          string
+		script        string
+		expected      []Finding
+		expectedError string
+	}{
+		{
+			name: "findings",
+			// The plugin echoes the name of the resource it reads from stdin.
+			script: `name=$(sed -n 's/.*"name":"\([^"]*\)".*/\1/p')
+echo '[{"rule": "ORG001", "severity": "warning", "message": "'"$name"' is not prefixed", "resourcePath": "metadata.name"}]'`,
+			expected: []Finding{{Rule: "ORG001", Severity: "warning", Message: "build is not prefixed", ResourcePath: "metadata.name"}},
+		},
+		{
+			name:   "no findings",
+			script: "cat >/dev/null",
+		},
+		{
+			name:          "failure",
+			script:        "echo 'cannot validate' >&2; exit 3",
+			expectedError: "naming plugin failed: exit status 3: cannot validate",
+		},
+		{
+			name:          "malformed output",
+			script:        "echo 'not json'",
+			expectedError: "naming plugin: decoding findings",
+		},
+		{
+			name:          "unknown severity",
+			script:        `echo '[{"severity": "fatal", "message": "boom"}]'`,
+			expectedError: `naming plugin: finding 0 has the unknown severity "fatal"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Plugin{Name: "naming", Path: writeExecutable(t, t.TempDir(), Prefix+"naming", tt.script, 0o755)}
+			findings, err := p.Run(context.Background(), map[string]any{"metadata": map[string]any{"name": "build"}})
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, findings)
+		})
+	}
+}
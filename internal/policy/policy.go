@@ -0,0 +1,156 @@
+// Package policy evaluates user-supplied Rego policies against Tekton resources.
+//
+// Policies are Rego modules of the tektor package. Its deny rules report violations failing the
+// validation, and its warn rules violations only worth a warning. Violations are either messages,
+// or objects with a msg and, optionally, the path of the offending field:
+//
+//	package tektor
+//
+//	import rego.v1
+//
+//	deny contains {"msg": msg, "path": sprintf("spec.tasks[%d]", [i])} if {
+//		some i, task in input.spec.tasks
+//		not task.timeout
+//		msg := sprintf("PipelineTask %s has no timeout", [task.name])
+//	}
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// query evaluates the rules of the tektor package
+const query = "data.tektor"
+
+// Violation is a breach of a policy
+type Violation struct {
+	Message string
+	// Path is the path of the offending field, if the policy tells it.
+	Path string
+	// Warning is set for the violations of warn rules, which do not fail the validation.
+	Warning bool
+}
+
+// Engine evaluates a set of policies
+type Engine struct {
+	query rego.PreparedEvalQuery
+}
+
+// Load compiles the policies of the given .rego files, and of the .rego files found in the given
+// directories and their subdirectories. Rego tests, i.e. _test.rego files, are left out.
+func Load(ctx context.Context, paths []string) (*Engine, error) {
+	var files []string
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading policies: %w", err)
+		}
+		if !stat.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(file, ".rego") && !strings.HasSuffix(file, "_test.rego") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("loading policies: %w", err)
+		}
+	}
+	sort.Strings(files)
+
+	options := []func(*rego.Rego){rego.Query(query)}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("loading policies: %w", err)
+		}
+		options = append(options, rego.Module(file, string(content)))
+	}
+	prepared, err := rego.New(options...).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("compiling policies: %w", err)
+	}
+	return &Engine{query: prepared}, nil
+}
+
+// Evaluate returns the violations of the policies by a resource, which is evaluated as the input
+// document once converted to JSON
+func (e *Engine) Evaluate(ctx context.Context, resource any) ([]Violation, error) {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+	var input any
+	if err := json.Unmarshal(data, &input); err != nil {
+		return nil, err
+	}
+
+	results, err := e.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, fmt.Errorf("evaluating policies: %w", err)
+	}
+	var violations []Violation
+	for _, result := range results {
+		for _, expression := range result.Expressions {
+			rules, ok := expression.Value.(map[string]any)
+			if !ok {
+				continue
+			}
+			for _, rule := range []string{"deny", "warn"} {
+				found, err := decodeViolations(rules[rule], rule == "warn")
+				if err != nil {
+					return nil, fmt.Errorf("evaluating policies: %s rule: %w", rule, err)
+				}
+				violations = append(violations, found...)
+			}
+		}
+	}
+	return violations, nil
+}
+
+// decodeViolations decodes the value of a deny or warn rule, a set of messages or objects
+func decodeViolations(value any, warning bool) ([]Violation, error) {
+	if value == nil {
+		return nil, nil
+	}
+	values, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected a set, got %T", value)
+	}
+	violations := make([]Violation, 0, len(values))
+	for _, v := range values {
+		violation := Violation{Warning: warning}
+		switch v := v.(type) {
+		case string:
+			violation.Message = v
+		case map[string]any:
+			msg, ok := v["msg"].(string)
+			if !ok {
+				return nil, fmt.Errorf("violation without a msg string: %v", v)
+			}
+			violation.Message = msg
+			if path, ok := v["path"].(string); ok {
+				violation.Path = path
+			}
+		default:
+			return nil, fmt.Errorf("expected a message or an object, got %T", v)
+		}
+		violations = append(violations, violation)
+	}
+	return violations, nil
+}
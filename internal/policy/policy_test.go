@@ -0,0 +1,132 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const timeoutPolicy = `package tektor
+
+import rego.v1
+
+deny contains {"msg": msg, "path": sprintf("spec.tasks[%d]", [i])} if {
+	some i, task in input.spec.tasks
+	not task.timeout
+	msg := sprintf("PipelineTask %s has no timeout", [task.name])
+}
+`
+
+const namingPolicy = `package tektor
+
+import rego.v1
+
+warn contains msg if {
+	not startswith(input.metadata.name, "team-")
+	msg := sprintf("%s is not prefixed with team-", [input.metadata.name])
+}
+`
+
+func writePolicies(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestEvaluate(t *testing.T) {
+	dir := writePolicies(t, map[string]string{
+		"timeout.rego":            timeoutPolicy,
+		"naming/naming.rego":      namingPolicy,
+		"naming/naming_test.rego": "package tektor\n\nthis is not rego\n",
+		"README.md":               "not a policy",
+	})
+	engine, err := Load(context.Background(), []string{dir})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		resource any
+		expected []Violation
+	}{
+		{
+			name: "compliant",
+			resource: map[string]any{
+				"metadata": map[string]any{"name": "team-build"},
+				"spec":     map[string]any{"tasks": []any{map[string]any{"name": "build", "timeout": "1h"}}},
+			},
+		},
+		{
+			name: "violations",
+			resource: map[string]any{
+				"metadata": map[string]any{"name": "build"},
+				"spec": map[string]any{"tasks": []any{
+					map[string]any{"name": "clone", "timeout": "1h"},
+					map[string]any{"name": "build"},
+				}},
+			},
+			expected: []Violation{
+				{Message: "PipelineTask build has no timeout", Path: "spec.tasks[1]"},
+				{Message: "build is not prefixed with team-", Warning: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := engine.Evaluate(context.Background(), tt.resource)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, violations)
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		path          string
+		expectedError string
+	}{
+		{
+			name:          "missing path",
+			path:          "missing.rego",
+			expectedError: "loading policies",
+		},
+		{
+			name:          "syntax error",
+			files:         map[string]string{"broken.rego": "package tektor\n\ndeny contains msg if {\n"},
+			expectedError: "compiling policies",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writePolicies(t, tt.files)
+			path := dir
+			if tt.path != "" {
+				path = filepath.Join(dir, tt.path)
+			}
+			_, err := Load(context.Background(), []string{path})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestEvaluateMalformedViolation(t *testing.T) {
+	dir := writePolicies(t, map[string]string{"policy.rego": "package tektor\n\nimport rego.v1\n\ndeny contains 42 if true\n"})
+	engine, err := Load(context.Background(), []string{dir})
+	require.NoError(t, err)
+
+	_, err = engine.Evaluate(context.Background(), map[string]any{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "deny rule: expected a message or an object")
+}
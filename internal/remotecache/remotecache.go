@@ -0,0 +1,122 @@
+package remotecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTTL is how long resolutions of mutable references, e.g. tags and branches, are reused
+const DefaultTTL = 24 * time.Hour
+
+// Cache stores the content of Tasks and Pipelines resolved from remote services on disk, so that
+// repeated validations do not fetch them again. Contents are stored once under their sha256 digest,
+// and each resolved reference points to the digest of its content. References pinned to a digest or
+// a commit never change, so they are reused indefinitely, while other references are resolved again
+// once older than the TTL.
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// DefaultDir returns the tektor directory of the user cache directory, i.e. $XDG_CACHE_HOME/tektor
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tektor"), nil
+}
+
+// New returns a Cache storing its entries in dir. Resolutions of mutable references are reused for
+// ttl, or not at all if ttl is not positive.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+// Get returns the content cached for key, which is pinned if it refers to immutable content. It
+// reports false if the content is not cached, or if key is not pinned and its entry expired.
+func (c *Cache) Get(key string, pinned bool) ([]byte, bool) {
+	if !pinned && c.ttl <= 0 {
+		return nil, false
+	}
+	refFile := c.refPath(key)
+	info, err := os.Stat(refFile)
+	if err != nil {
+		return nil, false
+	}
+	if !pinned && time.Since(info.ModTime()) > c.ttl {
+		return nil, false
+	}
+	digest, err := os.ReadFile(refFile)
+	if err != nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.blobPath(strings.TrimSpace(string(digest))))
+	if err != nil {
+		return nil, false
+	}
+	// A corrupted blob is not reused.
+	if contentDigest(data) != strings.TrimSpace(string(digest)) {
+		return nil, false
+	}
+	return data, true
+}
+
+// Put caches data as the content of key
+func (c *Cache) Put(key string, data []byte) error {
+	digest := contentDigest(data)
+	if err := writeFileAtomic(c.blobPath(digest), data); err != nil {
+		return fmt.Errorf("caching %s: %w", key, err)
+	}
+	if err := writeFileAtomic(c.refPath(key), []byte(digest)); err != nil {
+		return fmt.Errorf("caching %s: %w", key, err)
+	}
+	return nil
+}
+
+// refPath returns the file pointing key to the digest of its content
+func (c *Cache) refPath(key string) string {
+	return filepath.Join(c.dir, "refs", contentDigest([]byte(key)))
+}
+
+// blobPath returns the file holding the content of the given digest
+func (c *Cache) blobPath(digest string) string {
+	return filepath.Join(c.dir, "blobs", "sha256", digest)
+}
+
+// contentDigest returns the hex encoded sha256 digest of data
+func contentDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic writes data to fname through a temporary file, so that concurrent runs never read
+// a partially written entry
+func writeFileAtomic(fname string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(fname), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(fname), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), fname); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
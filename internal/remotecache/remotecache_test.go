@@ -0,0 +1,74 @@
+package remotecache
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		age      time.Duration
+		pinned   bool
+		expected bool
+	}{
+		{name: "fresh entry", ttl: time.Hour, expected: true},
+		{name: "expired entry", ttl: time.Hour, age: 2 * time.Hour},
+		{name: "expired pinned entry", ttl: time.Hour, age: 2 * time.Hour, pinned: true, expected: true},
+		{name: "no TTL", ttl: 0},
+		{name: "pinned entry without TTL", ttl: 0, pinned: true, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cache := New(dir, tt.ttl)
+			key := "bundle registry.local/task:latest task build"
+			require.NoError(t, cache.Put(key, []byte("kind: Task")))
+			modTime := time.Now().Add(-tt.age)
+			require.NoError(t, os.Chtimes(cache.refPath(key), modTime, modTime))
+
+			data, ok := cache.Get(key, tt.pinned)
+			assert.Equal(t, tt.expected, ok)
+			if tt.expected {
+				assert.Equal(t, "kind: Task", string(data))
+			}
+		})
+	}
+}
+
+func TestCacheContentAddressing(t *testing.T) {
+	dir := t.TempDir()
+	cache := New(dir, time.Hour)
+
+	require.NoError(t, cache.Put("git https://example.com/a.git main task.yaml", []byte("kind: Task")))
+	require.NoError(t, cache.Put("git https://example.com/b.git main task.yaml", []byte("kind: Task")))
+	blobs, err := os.ReadDir(filepath.Join(dir, "blobs", "sha256"))
+	require.NoError(t, err)
+	assert.Len(t, blobs, 1, "identical contents are stored once")
+
+	_, ok := cache.Get("git https://example.com/c.git main task.yaml", false)
+	assert.False(t, ok, "unknown keys are not cached")
+
+	// A corrupted blob is resolved again.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "blobs", "sha256", blobs[0].Name()), []byte("oops"), 0o644))
+	_, ok = cache.Get("git https://example.com/a.git main task.yaml", false)
+	assert.False(t, ok)
+}
+
+func TestDefaultDir(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG_CACHE_HOME only applies to Linux")
+	}
+	t.Setenv("XDG_CACHE_HOME", "/tmp/cache")
+	dir, err := DefaultDir()
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/cache/tektor", dir)
+}
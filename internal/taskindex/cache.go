@@ -0,0 +1,57 @@
+package taskindex
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache holds the Task definitions parsed from files, so that large Task directories are only
+// parsed once per run even when they are indexed for every validated file. A file is parsed again
+// when its modification time or size changes.
+type Cache struct {
+	mu    sync.Mutex
+	files map[string]cachedFile
+}
+
+// cachedFile holds the outcome of parsing a file as it was when last parsed
+type cachedFile struct {
+	modTime time.Time
+	size    int64
+	entries []Entry
+	err     error
+}
+
+// NewCache returns an empty Cache
+func NewCache() *Cache {
+	return &Cache{files: map[string]cachedFile{}}
+}
+
+// fileEntries returns the Task definitions of a file, parsing it only if it is not cached or if it
+// changed since it was cached
+func (c *Cache) fileEntries(ctx context.Context, fname string) ([]Entry, error) {
+	abs, err := filepath.Abs(fname)
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s: %w", fname, err)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	cached, found := c.files[abs]
+	c.mu.Unlock()
+	if found && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.entries, cached.err
+	}
+
+	entries, err := decodeFile(ctx, fname)
+	c.mu.Lock()
+	c.files[abs] = cachedFile{modTime: info.ModTime(), size: info.Size(), entries: entries, err: err}
+	c.mu.Unlock()
+	return entries, err
+}
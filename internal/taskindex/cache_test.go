@@ -0,0 +1,61 @@
+package taskindex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{"clone.yaml": v1Task})
+	fname := filepath.Join(dir, "clone.yaml")
+	info, err := os.Stat(fname)
+	require.NoError(t, err)
+
+	cache := NewCache()
+	index := NewWithCache(cache)
+	require.NoError(t, index.AddDir(ctx, dir))
+	_, err = index.Lookup("Task", "git-clone")
+	require.NoError(t, err)
+
+	// Unchanged files are not parsed again, even by other indexes sharing the cache.
+	require.NoError(t, os.WriteFile(fname, []byte(strings.Repeat("#", len(v1Task))), 0644))
+	require.NoError(t, os.Chtimes(fname, info.ModTime(), info.ModTime()))
+	index = NewWithCache(cache)
+	require.NoError(t, index.AddDir(ctx, dir))
+	_, err = index.Lookup("Task", "git-clone")
+	require.NoError(t, err)
+
+	// Modified files are parsed again.
+	require.NoError(t, os.WriteFile(fname, []byte(v1beta1Task), 0644))
+	modTime := info.ModTime().Add(time.Second)
+	require.NoError(t, os.Chtimes(fname, modTime, modTime))
+	index = NewWithCache(cache)
+	require.NoError(t, index.AddDir(ctx, dir))
+	_, err = index.Lookup("Task", "buildah")
+	require.NoError(t, err)
+	_, err = index.Lookup("Task", "git-clone")
+	require.Error(t, err)
+
+	// Clones share the cache.
+	assert.Same(t, cache, index.Clone().cache)
+}
+
+func TestCacheErrors(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{"invalid.yaml": "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: invalid\nspec:\n  steps: oops\n"})
+
+	cache := NewCache()
+	for i := 0; i < 2; i++ {
+		err := NewWithCache(cache).AddDir(ctx, dir)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid.yaml")
+	}
+}
@@ -0,0 +1,294 @@
+package taskindex
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/document"
+)
+
+// Entry is a Task, or Pipeline, definition known to the Index
+type Entry struct {
+	Kind         string // Task, ClusterTask, or Pipeline
+	Name         string
+	Source       string // Where the definition comes from, e.g. "tasks/clone.yaml:1 (Task git-clone)"
+	Spec         v1.TaskSpec
+	PipelineSpec v1.PipelineSpec // Set instead of Spec for Pipelines
+}
+
+// Index holds Task and Pipeline definitions by kind and name. It is used to resolve PipelineTasks
+// which refer to a Task, or to a child Pipeline, by name only, e.g. definitions found in local
+// directories or in the .tekton directory used by Pipelines as Code. Definitions resolved through
+// the bundles, git, or hub resolvers are not indexed: Tekton never resolves a reference by name to
+// them, and their resolutions are shared through the caches of the validation instead.
+type Index struct {
+	entries map[string][]Entry
+	dirs    map[string]bool
+	cache   *Cache
+}
+
+// New returns an empty Index
+func New() *Index {
+	return &Index{entries: map[string][]Entry{}, dirs: map[string]bool{}}
+}
+
+// NewWithCache returns an empty Index which reads the Task definitions of files through cache, so
+// that indexes sharing the cache only parse each file once
+func NewWithCache(cache *Cache) *Index {
+	idx := New()
+	idx.cache = cache
+	return idx
+}
+
+// Clone returns a copy of the Index which can be extended without affecting the original. The copy
+// shares the cache of the Index, if any.
+func (idx *Index) Clone() *Index {
+	clone := NewWithCache(idx.cache)
+	for key, entries := range idx.entries {
+		clone.entries[key] = append([]Entry{}, entries...)
+	}
+	for dir := range idx.dirs {
+		clone.dirs[dir] = true
+	}
+	return clone
+}
+
+// AddDir indexes the Tasks defined in the YAML files of dir and its subdirectories. Directories
+// which were already indexed are skipped.
+func (idx *Index) AddDir(ctx context.Context, dir string) error {
+	return idx.AddDirExcluding(ctx, dir, nil)
+}
+
+// AddDirExcluding is like AddDir, but leaves out the files for which exclude, if not nil, returns
+// true.
+func (idx *Index) AddDirExcluding(ctx context.Context, dir string, exclude func(path string) bool) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", dir, err)
+	}
+	if idx.dirs[abs] {
+		return nil
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !isYAMLFile(path) || (exclude != nil && exclude(path)) {
+			return nil
+		}
+		return idx.AddFile(ctx, path)
+	})
+	if err != nil {
+		return fmt.Errorf("indexing %s: %w", dir, err)
+	}
+
+	idx.dirs[abs] = true
+	return nil
+}
+
+// AddFile indexes the Tasks and Pipelines defined in a, possibly multi-document, YAML file. Other
+// kinds of resources are ignored.
+func (idx *Index) AddFile(ctx context.Context, fname string) error {
+	var entries []Entry
+	var err error
+	if idx.cache != nil {
+		entries, err = idx.cache.fileEntries(ctx, fname)
+	} else {
+		entries, err = decodeFile(ctx, fname)
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		idx.Add(entry)
+	}
+	return nil
+}
+
+// decodeFile returns the Task and Pipeline definitions of a, possibly multi-document, YAML file
+func decodeFile(ctx context.Context, fname string) ([]Entry, error) {
+	docs, err := document.SplitFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, doc := range docs {
+		var entry Entry
+		switch {
+		case IsTaskDocument(doc):
+			entry, err = Decode(ctx, doc.String(), doc.Content)
+		case IsPipelineDocument(doc):
+			entry, err = DecodePipeline(ctx, doc.String(), doc.Content)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Add adds a Task, or Pipeline, definition to the Index
+func (idx *Index) Add(entry Entry) {
+	key := indexKey(entry.Kind, entry.Name)
+	idx.entries[key] = append(idx.entries[key], entry)
+}
+
+// Lookup returns the definition of the given kind and name. An empty kind means Task. It is an
+// error for the definition to be unknown, or to be defined more than once.
+func (idx *Index) Lookup(kind, name string) (*Entry, error) {
+	if kind == "" {
+		kind = string(v1.NamespacedTaskKind)
+	}
+	entries := idx.entries[indexKey(kind, name)]
+	switch len(entries) {
+	case 0:
+		if kind == "Pipeline" {
+			return nil, fmt.Errorf("%s %q not found in task or pipeline directories", kind, name)
+		}
+		return nil, fmt.Errorf("%s %q not found in task directories", kind, name)
+	case 1:
+		return &entries[0], nil
+	default:
+		sources := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			sources = append(sources, entry.Source)
+		}
+		return nil, fmt.Errorf("%s %q is defined more than once: %s", kind, name, strings.Join(sources, ", "))
+	}
+}
+
+// Duplicates returns the Tasks and Pipelines which are defined more than once, sorted by kind and
+// name
+func (idx *Index) Duplicates() []string {
+	var duplicates []string
+	for key, entries := range idx.entries {
+		if len(entries) > 1 {
+			duplicates = append(duplicates, key)
+		}
+	}
+	sort.Strings(duplicates)
+	return duplicates
+}
+
+// Len returns the number of Task and Pipeline definitions in the Index
+func (idx *Index) Len() int {
+	count := 0
+	for _, entries := range idx.entries {
+		count += len(entries)
+	}
+	return count
+}
+
+// IsTaskDocument reports whether doc holds a Task, or ClusterTask, definition of a supported
+// apiVersion
+func IsTaskDocument(doc document.Document) bool {
+	switch doc.Key() {
+	case "tekton.dev/v1/Task", "tekton.dev/v1beta1/Task", "tekton.dev/v1beta1/ClusterTask":
+		return true
+	}
+	return false
+}
+
+// Decode parses a Task, or ClusterTask, definition. Definitions using the v1beta1 API are converted
+// to v1. Source is used to describe the definition in errors and in the returned Entry.
+func Decode(ctx context.Context, source string, data []byte) (Entry, error) {
+	var doc document.Document
+	if docs := document.Split(source, data); len(docs) == 1 {
+		doc = docs[0]
+	} else {
+		return Entry{}, fmt.Errorf("%s: expected a single Task definition, got %d documents", source, len(docs))
+	}
+	if doc.Err != nil {
+		return Entry{}, fmt.Errorf("%s: %w", source, doc.Err)
+	}
+
+	entry := Entry{Kind: doc.Kind, Name: doc.Name, Source: source}
+	switch doc.Key() {
+	case "tekton.dev/v1/Task":
+		var t v1.Task
+		if err := yaml.Unmarshal(data, &t); err != nil {
+			return Entry{}, fmt.Errorf("unmarshalling %s as %s: %w", source, doc.Key(), err)
+		}
+		entry.Spec = t.Spec
+	case "tekton.dev/v1beta1/Task", "tekton.dev/v1beta1/ClusterTask":
+		var t v1beta1.Task
+		if err := yaml.Unmarshal(data, &t); err != nil {
+			return Entry{}, fmt.Errorf("unmarshalling %s as %s: %w", source, doc.Key(), err)
+		}
+		var converted v1.Task
+		if err := t.ConvertTo(ctx, &converted); err != nil {
+			return Entry{}, fmt.Errorf("converting %s to %s: %w", source, v1.SchemeGroupVersion, err)
+		}
+		entry.Spec = converted.Spec
+	default:
+		return Entry{}, fmt.Errorf("%s: %s is not a supported Task definition", source, doc.Key())
+	}
+	return entry, nil
+}
+
+// IsPipelineDocument reports whether doc holds a Pipeline definition of a supported apiVersion
+func IsPipelineDocument(doc document.Document) bool {
+	switch doc.Key() {
+	case "tekton.dev/v1/Pipeline", "tekton.dev/v1beta1/Pipeline":
+		return true
+	}
+	return false
+}
+
+// DecodePipeline parses a Pipeline definition. Definitions using the v1beta1 API are converted to
+// v1. Source is used to describe the definition in errors and in the returned Entry.
+func DecodePipeline(ctx context.Context, source string, data []byte) (Entry, error) {
+	var doc document.Document
+	if docs := document.Split(source, data); len(docs) == 1 {
+		doc = docs[0]
+	} else {
+		return Entry{}, fmt.Errorf("%s: expected a single Pipeline definition, got %d documents", source, len(docs))
+	}
+	if doc.Err != nil {
+		return Entry{}, fmt.Errorf("%s: %w", source, doc.Err)
+	}
+
+	entry := Entry{Kind: doc.Kind, Name: doc.Name, Source: source}
+	switch doc.Key() {
+	case "tekton.dev/v1/Pipeline":
+		var p v1.Pipeline
+		if err := yaml.Unmarshal(data, &p); err != nil {
+			return Entry{}, fmt.Errorf("unmarshalling %s as %s: %w", source, doc.Key(), err)
+		}
+		entry.PipelineSpec = p.Spec
+	case "tekton.dev/v1beta1/Pipeline":
+		var p v1beta1.Pipeline
+		if err := yaml.Unmarshal(data, &p); err != nil {
+			return Entry{}, fmt.Errorf("unmarshalling %s as %s: %w", source, doc.Key(), err)
+		}
+		var converted v1.Pipeline
+		if err := p.ConvertTo(ctx, &converted); err != nil {
+			return Entry{}, fmt.Errorf("converting %s to %s: %w", source, v1.SchemeGroupVersion, err)
+		}
+		entry.PipelineSpec = converted.Spec
+	default:
+		return Entry{}, fmt.Errorf("%s: %s is not a supported Pipeline definition", source, doc.Key())
+	}
+	return entry, nil
+}
+
+func indexKey(kind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+func isYAMLFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
@@ -0,0 +1,261 @@
+package taskindex
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const v1Task = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: git-clone
+spec:
+  params:
+    - name: url
+  steps:
+    - name: clone
+      image: alpine:latest
+      script: git clone $(params.url)
+`
+
+const v1beta1Task = `apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: buildah
+spec:
+  params:
+    - name: IMAGE
+  steps:
+    - name: build
+      image: quay.io/buildah/stable:latest
+      script: buildah bud -t $(params.IMAGE) .
+`
+
+const v1beta1ClusterTask = `apiVersion: tekton.dev/v1beta1
+kind: ClusterTask
+metadata:
+  name: buildah
+spec:
+  steps:
+    - name: build
+      image: quay.io/buildah/stable:latest
+      script: buildah bud .
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		fname := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fname), 0755))
+		require.NoError(t, os.WriteFile(fname, []byte(content), 0644))
+	}
+	return dir
+}
+
+func TestAddDir(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{
+		"clone.yaml":             v1Task,
+		"nested/buildah.yml":     v1beta1Task + "---\n" + v1beta1ClusterTask,
+		"pipeline.yaml":          "apiVersion: tekton.dev/v1\nkind: Pipeline\nmetadata:\n  name: build\n",
+		"template.yaml":          "apiVersion: tekton.dev/v1\nkind: PipelineRun\nmetadata:\n  name: {{ name }}\n",
+		"README.md":              "not yaml",
+		"nested/notes.txt":       "kind: Task",
+		"nested/deep/empty.yaml": "",
+	})
+
+	index := New()
+	require.NoError(t, index.AddDir(ctx, dir))
+	assert.Equal(t, 4, index.Len())
+	assert.Empty(t, index.Duplicates())
+
+	entry, err := index.Lookup("", "git-clone")
+	require.NoError(t, err)
+	assert.Equal(t, "Task", entry.Kind)
+	assert.Equal(t, filepath.Join(dir, "clone.yaml")+":1 (Task git-clone)", entry.Source)
+	require.Len(t, entry.Spec.Params, 1)
+	assert.Equal(t, "url", entry.Spec.Params[0].Name)
+
+	entry, err = index.Lookup("Task", "buildah")
+	require.NoError(t, err)
+	require.Len(t, entry.Spec.Params, 1, "Expected the v1beta1 Task to be converted")
+	assert.Equal(t, "IMAGE", entry.Spec.Params[0].Name)
+
+	entry, err = index.Lookup("ClusterTask", "buildah")
+	require.NoError(t, err)
+	assert.Empty(t, entry.Spec.Params)
+
+	_, err = index.Lookup("Task", "build")
+	require.Error(t, err)
+	assert.Equal(t, `Task "build" not found in task directories`, err.Error())
+
+	entry, err = index.Lookup("Pipeline", "build")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "pipeline.yaml")+":1 (Pipeline build)", entry.Source)
+
+	// Indexing the same directory again does not create duplicates.
+	require.NoError(t, index.AddDir(ctx, dir))
+	assert.Equal(t, 4, index.Len())
+}
+
+func TestAddDirDuplicates(t *testing.T) {
+	ctx := context.Background()
+	first := writeFiles(t, map[string]string{"clone.yaml": v1Task})
+	second := writeFiles(t, map[string]string{"clone.yaml": v1Task})
+
+	index := New()
+	require.NoError(t, index.AddDir(ctx, first))
+	require.NoError(t, index.AddDir(ctx, second))
+	assert.Equal(t, []string{"Task/git-clone"}, index.Duplicates())
+
+	_, err := index.Lookup("Task", "git-clone")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Task "git-clone" is defined more than once: `)
+	assert.Contains(t, err.Error(), filepath.Join(first, "clone.yaml")+":1 (Task git-clone)")
+	assert.Contains(t, err.Error(), filepath.Join(second, "clone.yaml")+":1 (Task git-clone)")
+}
+
+func TestAddDirExcluding(t *testing.T) {
+	ctx := context.Background()
+	dir := writeFiles(t, map[string]string{
+		"clone.yaml":         v1Task,
+		"nested/buildah.yml": v1beta1Task,
+		"values.yaml":        "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: values\nspec:\n  steps: oops\n",
+	})
+
+	index := New()
+	excluded := func(path string) bool { return filepath.Base(path) == "values.yaml" }
+	require.NoError(t, index.AddDirExcluding(ctx, dir, excluded))
+	assert.Equal(t, 2, index.Len())
+
+	require.Error(t, New().AddDir(ctx, dir), "Expected the malformed Task to be indexed without exclusion")
+}
+
+func TestAddDirMissing(t *testing.T) {
+	err := New().AddDir(context.Background(), filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "indexing ")
+}
+
+func TestClone(t *testing.T) {
+	ctx := context.Background()
+	index := New()
+	require.NoError(t, index.AddDir(ctx, writeFiles(t, map[string]string{"clone.yaml": v1Task})))
+
+	clone := index.Clone()
+	require.NoError(t, clone.AddDir(ctx, writeFiles(t, map[string]string{"buildah.yaml": v1beta1Task})))
+
+	assert.Equal(t, 1, index.Len())
+	assert.Equal(t, 2, clone.Len())
+}
+
+func TestDecode(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		data          string
+		expectedKind  string
+		expectedName  string
+		errorContains string
+	}{
+		{
+			name:         "v1 Task",
+			data:         v1Task,
+			expectedKind: "Task",
+			expectedName: "git-clone",
+		},
+		{
+			name:         "v1beta1 Task",
+			data:         v1beta1Task,
+			expectedKind: "Task",
+			expectedName: "buildah",
+		},
+		{
+			name:         "v1beta1 ClusterTask",
+			data:         v1beta1ClusterTask,
+			expectedKind: "ClusterTask",
+			expectedName: "buildah",
+		},
+		{
+			name:          "Pipeline",
+			data:          "apiVersion: tekton.dev/v1\nkind: Pipeline\nmetadata:\n  name: build\n",
+			errorContains: "remote: tekton.dev/v1/Pipeline is not a supported Task definition",
+		},
+		{
+			name:          "multiple documents",
+			data:          v1Task + "---\n" + v1beta1Task,
+			errorContains: "remote: expected a single Task definition, got 2 documents",
+		},
+		{
+			name:          "invalid YAML",
+			data:          "kind: [",
+			errorContains: "remote: ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := Decode(ctx, "remote", []byte(tt.data))
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedKind, entry.Kind)
+			assert.Equal(t, tt.expectedName, entry.Name)
+			assert.Equal(t, "remote", entry.Source)
+			assert.NotEmpty(t, entry.Spec.Steps)
+		})
+	}
+}
+
+func TestDecodePipeline(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name          string
+		data          string
+		errorContains string
+	}{
+		{
+			name: "v1 Pipeline",
+			data: "apiVersion: tekton.dev/v1\nkind: Pipeline\nmetadata:\n  name: build\nspec:\n  params:\n    - name: url\n      type: string\n",
+		},
+		{
+			name: "v1beta1 Pipeline",
+			data: "apiVersion: tekton.dev/v1beta1\nkind: Pipeline\nmetadata:\n  name: build\nspec:\n  params:\n    - name: url\n      type: string\n",
+		},
+		{
+			name:          "Task",
+			data:          v1Task,
+			errorContains: "remote: tekton.dev/v1/Task is not a supported Pipeline definition",
+		},
+		{
+			name:          "multiple documents",
+			data:          v1Task + "---\n" + v1beta1Task,
+			errorContains: "remote: expected a single Pipeline definition, got 2 documents",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := DecodePipeline(ctx, "remote", []byte(tt.data))
+			if tt.errorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Pipeline", entry.Kind)
+			assert.Equal(t, "build", entry.Name)
+			require.Len(t, entry.PipelineSpec.Params, 1)
+			assert.Equal(t, "url", entry.PipelineSpec.Params[0].Name)
+		})
+	}
+}
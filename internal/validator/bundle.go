@@ -0,0 +1,412 @@
+package validator
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/resolution/resolver/bundle"
+
+	"github.com/lcarva/tektor/internal/logging"
+	"github.com/lcarva/tektor/internal/taskindex"
+)
+
+// fetchBundleImage retrieves the image of a Tekton bundle from its registry, it is a variable so
+// that tests can stub registries
+var fetchBundleImage = func(ctx context.Context, ref name.Reference) (ggcrv1.Image, error) {
+	return remote.Image(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(keychain(ctx)))
+}
+
+// fetchBundleDigest retrieves the digest of the image a reference to a Tekton bundle points to,
+// without downloading the image, it is a variable so that tests can stub registries
+var fetchBundleDigest = func(ctx context.Context, ref name.Reference) (string, error) {
+	descriptor, err := remote.Head(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(keychain(ctx)))
+	if err != nil {
+		return "", err
+	}
+	return descriptor.Digest.String(), nil
+}
+
+// BundleEntry is a Task or Pipeline of a Tekton bundle, along with its interface
+type BundleEntry struct {
+	Kind       string
+	Name       string
+	APIVersion string
+	Params     v1.ParamSpecs
+	Results    []BundleResult
+	// Err is set when the content of the entry could not be decoded.
+	Err error
+}
+
+// BundleResult is a result declared by a Task or Pipeline of a Tekton bundle
+type BundleResult struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// ListBundle returns the Tasks and Pipelines of the Tekton bundle at ref, in the order of its layers
+func ListBundle(ctx context.Context, ref string) ([]BundleEntry, error) {
+	image, cleanup, err := bundleImage(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	layers, err := bundleLayers(image, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []BundleEntry
+	for _, layer := range layers {
+		entry := BundleEntry{
+			Kind:       layer.annotations[bundle.BundleAnnotationKind],
+			Name:       layer.annotations[bundle.BundleAnnotationName],
+			APIVersion: layer.annotations[bundle.BundleAnnotationAPIVersion],
+		}
+		data, err := readBundleLayer(layer.layer)
+		if err == nil {
+			err = entry.decodeInterface(ctx, data)
+		}
+		entry.Err = err
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// bundleImage returns the image of the Tekton bundle at ref, read from the filesystem if it is local,
+// along with a function releasing the files it is read from once it is no longer used
+func bundleImage(ctx context.Context, ref string) (ggcrv1.Image, func(), error) {
+	if path, digest, ok := localBundle(ref); ok {
+		return localBundleImage(path, digest)
+	}
+	imageRef, err := name.ParseReference(ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is an unparseable image reference: %w", ref, err)
+	}
+	image, err := fetchBundleImage(ctx, imageRef)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot retrieve the oci image: %w", err)
+	}
+	return image, func() {}, nil
+}
+
+// decodeInterface sets the params and results of the entry from its content, which is decoded like
+// the Tasks and Pipelines resolved from bundles during validation, v1beta1 ones being converted
+func (e *BundleEntry) decodeInterface(ctx context.Context, data []byte) error {
+	source := fmt.Sprintf("%s %s", e.Kind, e.Name)
+	switch e.Kind {
+	case "task":
+		entry, err := taskindex.Decode(ctx, source, data)
+		if err != nil {
+			return err
+		}
+		e.Params = entry.Spec.Params
+		for _, result := range entry.Spec.Results {
+			e.Results = append(e.Results, BundleResult{Name: result.Name, Type: resultTypeName(string(result.Type)), Description: result.Description})
+		}
+	case "pipeline":
+		entry, err := taskindex.DecodePipeline(ctx, source, data)
+		if err != nil {
+			return err
+		}
+		e.Params = entry.PipelineSpec.Params
+		for _, result := range entry.PipelineSpec.Results {
+			e.Results = append(e.Results, BundleResult{Name: result.Name, Type: resultTypeName(string(result.Type)), Description: result.Description})
+		}
+	}
+	return nil
+}
+
+// resultTypeName returns the name of the type of a result, which defaults to string
+func resultTypeName(resultType string) string {
+	if resultType == "" {
+		return string(v1.ResultsTypeString)
+	}
+	return resultType
+}
+
+// resolveBundleEntry returns the content of the entry of a Tekton bundle. Entries of remote bundles
+// are cached by the digest of their bundle: a bundle referred to by tag is reused while the TTL of
+// the cache runs, and then only downloaded again if the tag points to another digest. Local bundles
+// are read every time.
+func resolveBundleEntry(ctx context.Context, opts bundle.RequestOptions) ([]byte, error) {
+	if path, digest, ok := localBundle(opts.Bundle); ok {
+		path, err := localBundlePath(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		image, cleanup, err := localBundleImage(path, digest)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		return bundleEntry(image, opts)
+	}
+	imageRef, err := name.ParseReference(opts.Bundle)
+	if err != nil {
+		return nil, fmt.Errorf("%s is an unparseable image reference: %w", opts.Bundle, err)
+	}
+	key := func(ref name.Reference) string {
+		return fmt.Sprintf("bundle %s %s %s", ref, opts.Kind, opts.EntryName)
+	}
+	fetch := func(ref name.Reference) func(context.Context) ([]byte, error) {
+		return func(ctx context.Context) ([]byte, error) {
+			image, err := fetchBundleImage(ctx, ref)
+			if err != nil {
+				return nil, fmt.Errorf("cannot retrieve the oci image: %w", err)
+			}
+			return bundleEntry(image, opts)
+		}
+	}
+	if _, pinned := imageRef.(name.Digest); pinned {
+		return resolveCached(ctx, key(imageRef), true, fetch(imageRef))
+	}
+
+	cache := optionsFromContext(ctx).Cache
+	if cache == nil {
+		return resolveWithRetries(ctx, fetch(imageRef))
+	}
+	if data, ok := cache.Get(key(imageRef), false); ok {
+		return data, nil
+	}
+	digest, err := resolveWithRetries(ctx, func(ctx context.Context) ([]byte, error) {
+		digest, err := fetchBundleDigest(ctx, imageRef)
+		return []byte(digest), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot retrieve the digest of the oci image: %w", err)
+	}
+	digestRef := imageRef.Context().Digest(string(digest))
+	data, err := resolveCached(ctx, key(digestRef), true, fetch(digestRef))
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.Put(key(imageRef), data); err != nil {
+		logging.FromContext(ctx).Warnf("⚠️  %v", err)
+	}
+	return data, nil
+}
+
+// localBundlePrefix prefixes the bundles read from the filesystem rather than from a registry, e.g.
+// "oci-layout:./bundles/buildah"
+const localBundlePrefix = "oci-layout:"
+
+// localBundle returns the path of a bundle read from the filesystem, and the digest selecting its
+// image if any, e.g. "oci-layout:./bundles/buildah@sha256:...", or false if ref is an image reference
+func localBundle(ref string) (string, string, bool) {
+	path, ok := strings.CutPrefix(ref, localBundlePrefix)
+	if !ok {
+		return "", "", false
+	}
+	if path, digest, found := strings.Cut(path, "@"); found {
+		return path, digest, true
+	}
+	return path, "", true
+}
+
+// localBundlePath returns the path a local bundle referred to during validation is read from:
+// relative paths are relative to the directory of the file being validated, see WithBaseDir. Local
+// bundles of untrusted input are not read, since they would expose the files of the host.
+func localBundlePath(ctx context.Context, path string) (string, error) {
+	opts := optionsFromContext(ctx)
+	if opts.Untrusted {
+		return "", fmt.Errorf("local bundle %s%s is not read from untrusted input", localBundlePrefix, path)
+	}
+	if opts.BaseDir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(opts.BaseDir, path)
+	}
+	return path, nil
+}
+
+// localBundleImage reads the image of a Tekton bundle from an OCI image layout at path, either a
+// directory or a tarball archiving one, as written by docker save since Docker 25, and returns a
+// function removing the files extracted from the tarball. A layout may hold several images, one of
+// which is then selected by its digest. Relative paths are relative to the working directory.
+func localBundleImage(path, digest string) (ggcrv1.Image, func(), error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read the local bundle: %w", err)
+	}
+	dir, cleanup := path, func() {}
+	if !info.IsDir() {
+		if dir, err = extractLayout(path); err != nil {
+			return nil, nil, fmt.Errorf("cannot read the local bundle %s: %w", path, err)
+		}
+		cleanup = func() { os.RemoveAll(dir) }
+	}
+	image, err := layoutImage(dir, path, digest)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return image, cleanup, nil
+}
+
+// layoutImage returns the image of the OCI image layout directory dir with the digest, or its only
+// image if digest is empty. Errors refer to the path of the local bundle dir is read from.
+func layoutImage(dir, path, digest string) (ggcrv1.Image, error) {
+	index, err := layout.ImageIndexFromPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the local bundle %s: %w", path, err)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the local bundle %s: %w", path, err)
+	}
+	var images []ggcrv1.Descriptor
+	for _, descriptor := range manifest.Manifests {
+		if descriptor.MediaType.IsImage() && (digest == "" || descriptor.Digest.String() == digest) {
+			images = append(images, descriptor)
+		}
+	}
+	switch {
+	case len(images) == 0 && digest != "":
+		return nil, fmt.Errorf("the OCI image layout %s holds no image %s", path, digest)
+	case len(images) == 0:
+		return nil, fmt.Errorf("the OCI image layout %s holds no image", path)
+	case len(images) > 1:
+		return nil, fmt.Errorf("the OCI image layout %s holds %d images, select one with %s%s@<digest>", path, len(images), localBundlePrefix, path)
+	}
+	image, err := index.Image(images[0].Digest)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the local bundle %s: %w", path, err)
+	}
+	return image, nil
+}
+
+// extractLayout extracts the OCI image layout archived by the tarball at path to a temporary
+// directory, which it returns
+func extractLayout(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	dir, err := os.MkdirTemp("", "tektor-bundle-")
+	if err != nil {
+		return "", err
+	}
+
+	hasIndex := false
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+		// Only the files of the layout are extracted, none escaping the directory.
+		if header.Typeflag != tar.TypeReg || !filepath.IsLocal(header.Name) {
+			continue
+		}
+		fname := filepath.Join(dir, header.Name)
+		if err := os.MkdirAll(filepath.Dir(fname), 0o700); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+		out, err := os.OpenFile(fname, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+		_, err = io.Copy(out, reader)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+		hasIndex = hasIndex || filepath.Clean(header.Name) == "index.json"
+	}
+	if !hasIndex {
+		os.RemoveAll(dir)
+		return "", errors.New("not an archive of an OCI image layout, it has no index.json")
+	}
+	return dir, nil
+}
+
+// bundleLayer is a layer of a Tekton bundle along with the annotations telling its kind and name
+type bundleLayer struct {
+	layer       ggcrv1.Layer
+	annotations map[string]string
+}
+
+// bundleLayers returns the layers of the image of a Tekton bundle, after verifying the image
+// complies with the format of bundles
+func bundleLayers(image ggcrv1.Image, ref string) ([]bundleLayer, error) {
+	manifest, err := image.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("could not parse image manifest: %w", err)
+	}
+	if len(manifest.Layers) > bundle.MaximumBundleObjects {
+		return nil, fmt.Errorf("invalid tekton bundle %s, error: contained more than the maximum %d allow objects", ref, bundle.MaximumBundleObjects)
+	}
+	var layers []bundleLayer
+	for i, descriptor := range manifest.Layers {
+		for _, annotation := range []string{bundle.BundleAnnotationAPIVersion, bundle.BundleAnnotationName, bundle.BundleAnnotationKind} {
+			if _, ok := descriptor.Annotations[annotation]; !ok {
+				return nil, fmt.Errorf("invalid tekton bundle %s, error: the layer %v does not contain a %s annotation", ref, i, annotation)
+			}
+		}
+		layer, err := image.LayerByDigest(descriptor.Digest)
+		if err != nil {
+			return nil, fmt.Errorf("could not read image layers: %w", err)
+		}
+		layers = append(layers, bundleLayer{layer: layer, annotations: descriptor.Annotations})
+	}
+	return layers, nil
+}
+
+// bundleEntry returns the content of the entry of the image of a Tekton bundle of the kind and name
+// of opts
+func bundleEntry(image ggcrv1.Image, opts bundle.RequestOptions) ([]byte, error) {
+	layers, err := bundleLayers(image, opts.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	for _, layer := range layers {
+		if strings.EqualFold(opts.Kind, layer.annotations[bundle.BundleAnnotationKind]) && opts.EntryName == layer.annotations[bundle.BundleAnnotationName] {
+			return readBundleLayer(layer.layer)
+		}
+	}
+	return nil, fmt.Errorf("could not find object in image with kind: %s and name: %s", opts.Kind, opts.EntryName)
+}
+
+// readBundleLayer returns the content of a layer of a Tekton bundle, a tarball holding a single
+// file, or else the raw content of the layer
+func readBundleLayer(layer ggcrv1.Layer) ([]byte, error) {
+	rc, err := layer.Uncompressed()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image layer: %w", err)
+	}
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("could not read contents of image layer: %w", err)
+	}
+
+	reader := tar.NewReader(strings.NewReader(string(content)))
+	if _, err := reader.Next(); err != nil {
+		return content, nil
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read tar bundle: %w", err)
+	}
+	return data, nil
+}
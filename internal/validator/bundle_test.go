@@ -0,0 +1,354 @@
+package validator
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/resolution/resolver/bundle"
+
+	"github.com/lcarva/tektor/internal/remotecache"
+	"github.com/lcarva/tektor/internal/taskindex"
+)
+
+// bundleObject is an object of a Tekton bundle built by testBundle
+type bundleObject struct {
+	kind, name, apiVersion, content string
+}
+
+// testBundle builds the image of a Tekton bundle holding objects, each in a tarball layer whose
+// annotations are left out when empty
+func testBundle(t *testing.T, objects ...bundleObject) ggcrv1.Image {
+	t.Helper()
+	image := empty.Image
+	for _, object := range objects {
+		var buf bytes.Buffer
+		w := tar.NewWriter(&buf)
+		require.NoError(t, w.WriteHeader(&tar.Header{Name: object.name, Mode: 0600, Size: int64(len(object.content))}))
+		_, err := w.Write([]byte(object.content))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		annotations := map[string]string{}
+		for annotation, value := range map[string]string{
+			bundle.BundleAnnotationKind:       object.kind,
+			bundle.BundleAnnotationName:       object.name,
+			bundle.BundleAnnotationAPIVersion: object.apiVersion,
+		} {
+			if value != "" {
+				annotations[annotation] = value
+			}
+		}
+		image, err = mutate.Append(image, mutate.Addendum{
+			Layer:       static.NewLayer(buf.Bytes(), types.DockerLayer),
+			Annotations: annotations,
+		})
+		require.NoError(t, err)
+	}
+	return image
+}
+
+// useFakeBundles replaces the retrieval of Tekton bundles with a static set of images, by tag or
+// digest, for the duration of a test. It returns the number of images retrieved so far.
+func useFakeBundles(t *testing.T, images map[string]ggcrv1.Image) func() int {
+	t.Helper()
+	originalImage, originalDigest := fetchBundleImage, fetchBundleDigest
+	fetched := 0
+	fetchBundleImage = func(_ context.Context, ref name.Reference) (ggcrv1.Image, error) {
+		if image, ok := images[ref.String()]; ok {
+			fetched++
+			return image, nil
+		}
+		return nil, errors.New("image not found")
+	}
+	fetchBundleDigest = func(_ context.Context, ref name.Reference) (string, error) {
+		if image, ok := images[ref.String()]; ok {
+			digest, err := image.Digest()
+			return digest.String(), err
+		}
+		return "", errors.New("image not found")
+	}
+	t.Cleanup(func() {
+		fetchBundleImage, fetchBundleDigest = originalImage, originalDigest
+	})
+	return func() int { return fetched }
+}
+
+const bundleTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      description: The URL of the repository
+    - name: args
+      type: array
+      default: []
+  results:
+    - name: digest
+      description: The digest of the image
+    - name: refs
+      type: array
+  steps:
+    - name: build
+      image: alpine:latest
+      script: echo $(params.url)
+`
+
+const bundlePipeline = `apiVersion: tekton.dev/v1beta1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  params:
+    - name: version
+  results:
+    - name: url
+      value: $(tasks.build.results.digest)
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`
+
+func TestListBundle(t *testing.T) {
+	useFakeBundles(t, map[string]ggcrv1.Image{
+		"registry.local/bundles/ci:1.0": testBundle(t,
+			bundleObject{"task", "build", "tekton.dev/v1", bundleTask},
+			bundleObject{"pipeline", "release", "tekton.dev/v1beta1", bundlePipeline},
+			bundleObject{"task", "broken", "tekton.dev/v1", "spec: ["},
+		),
+		"registry.local/bundles/invalid:1.0": testBundle(t, bundleObject{"task", "", "tekton.dev/v1", bundleTask}),
+	})
+
+	entries, err := ListBundle(context.Background(), "registry.local/bundles/ci:1.0")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "task", entries[0].Kind)
+	assert.Equal(t, "build", entries[0].Name)
+	assert.Equal(t, "tekton.dev/v1", entries[0].APIVersion)
+	require.NoError(t, entries[0].Err)
+	require.Len(t, entries[0].Params, 2)
+	assert.Equal(t, "url", entries[0].Params[0].Name)
+	assert.Equal(t, v1.ParamTypeArray, entries[0].Params[1].Type)
+	assert.Equal(t, []BundleResult{
+		{Name: "digest", Type: "string", Description: "The digest of the image"},
+		{Name: "refs", Type: "array"},
+	}, entries[0].Results)
+
+	assert.Equal(t, "pipeline", entries[1].Kind)
+	require.NoError(t, entries[1].Err)
+	require.Len(t, entries[1].Params, 1)
+	assert.Equal(t, "version", entries[1].Params[0].Name)
+	assert.Equal(t, []BundleResult{{Name: "url", Type: "string"}}, entries[1].Results)
+
+	assert.Equal(t, "broken", entries[2].Name)
+	assert.Error(t, entries[2].Err)
+
+	// A layer without a name is not part of a valid bundle.
+	_, err = ListBundle(context.Background(), "registry.local/bundles/invalid:1.0")
+	assert.ErrorContains(t, err, "does not contain a dev.tekton.image.name annotation")
+
+	_, err = ListBundle(context.Background(), "registry.local/bundles/missing:1.0")
+	assert.ErrorContains(t, err, "cannot retrieve the oci image: image not found")
+
+	_, err = ListBundle(context.Background(), "registry.local/bundles/ci:1.0:2.0")
+	assert.ErrorContains(t, err, "unparseable image reference")
+}
+
+func TestResolveTaskRefLeavesRemoteTasksOutOfTheIndex(t *testing.T) {
+	useFakeBundles(t, map[string]ggcrv1.Image{
+		"registry.local/bundles/ci:1.0": testBundle(t, bundleObject{"task", "build", "tekton.dev/v1", bundleTask}),
+	})
+	index := taskindex.New()
+	ctx := WithOptions(context.Background(), Options{TaskIndex: index})
+
+	spec, err := resolveTaskRef(ctx, v1.TaskRef{ResolverRef: v1.ResolverRef{Resolver: "bundles", Params: v1.Params{
+		{Name: "bundle", Value: *v1.NewStructuredValues("registry.local/bundles/ci:1.0")},
+		{Name: "name", Value: *v1.NewStructuredValues("build")},
+		{Name: "kind", Value: *v1.NewStructuredValues("task")},
+	}}}, nil, nil)
+	require.NoError(t, err)
+	assert.Len(t, spec.Params, 2)
+
+	// The Task of the bundle is not the Task the cluster knows by its name.
+	assert.Zero(t, index.Len())
+	_, err = resolveTaskRef(ctx, v1.TaskRef{Name: "build"}, nil, nil)
+	assert.EqualError(t, err, `Task "build" not found in task directories`)
+}
+
+func TestResolveBundleEntry(t *testing.T) {
+	image := testBundle(t, bundleObject{"task", "build", "tekton.dev/v1", bundleTask})
+	digest, err := image.Digest()
+	require.NoError(t, err)
+	fetched := useFakeBundles(t, map[string]ggcrv1.Image{
+		"registry.local/bundles/ci:1.0":                image,
+		"registry.local/bundles/ci@" + digest.String(): image,
+	})
+	opts := bundle.RequestOptions{Bundle: "registry.local/bundles/ci:1.0", EntryName: "build", Kind: "task"}
+
+	// Without a cache, the bundle is retrieved every time.
+	data, err := resolveBundleEntry(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, bundleTask, string(data))
+	_, err = resolveBundleEntry(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetched())
+
+	// A tag is reused until the TTL of the cache runs out.
+	dir := t.TempDir()
+	ctx := WithOptions(context.Background(), Options{Cache: remotecache.New(dir, time.Hour)})
+	_, err = resolveBundleEntry(ctx, opts)
+	require.NoError(t, err)
+	_, err = resolveBundleEntry(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 3, fetched())
+
+	// Then the entry cached for the digest the tag points to is reused.
+	cache := remotecache.New(dir, 0)
+	ctx = WithOptions(context.Background(), Options{Cache: cache})
+	data, err = resolveBundleEntry(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, bundleTask, string(data))
+	assert.Equal(t, 3, fetched())
+	cached, ok := cache.Get("bundle registry.local/bundles/ci@"+digest.String()+" task build", true)
+	assert.True(t, ok)
+	assert.Equal(t, bundleTask, string(cached))
+
+	opts.EntryName = "missing"
+	_, err = resolveBundleEntry(ctx, opts)
+	assert.ErrorContains(t, err, "could not find object in image with kind: task and name: missing")
+}
+
+func TestLocalBundles(t *testing.T) {
+	build := testBundle(t, bundleObject{"task", "build", "tekton.dev/v1", bundleTask})
+	release := testBundle(t, bundleObject{"pipeline", "release", "tekton.dev/v1beta1", bundlePipeline})
+	buildDigest, err := build.Digest()
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	single := filepath.Join(dir, "single")
+	index, err := layout.Write(single, empty.Index)
+	require.NoError(t, err)
+	require.NoError(t, index.AppendImage(build))
+
+	several := filepath.Join(dir, "several")
+	index, err = layout.Write(several, empty.Index)
+	require.NoError(t, err)
+	require.NoError(t, index.AppendImage(build))
+	require.NoError(t, index.AppendImage(release))
+
+	archive := filepath.Join(dir, "build.tar")
+	writeTarball(t, archive, single)
+
+	legacyArchive := filepath.Join(dir, "legacy.tar")
+	require.NoError(t, tarball.WriteToFile(legacyArchive, name.MustParseReference("registry.local/bundles/build:1.0"), build))
+
+	noImage := filepath.Join(dir, "empty")
+	_, err = layout.Write(noImage, empty.Index)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		bundle        string
+		errorContains string
+	}{
+		{name: "OCI image layout", bundle: "oci-layout:" + single},
+		{name: "image selected by digest", bundle: "oci-layout:" + several + "@" + buildDigest.String()},
+		{name: "archived OCI image layout", bundle: "oci-layout:" + archive},
+		{
+			name:          "several images",
+			bundle:        "oci-layout:" + several,
+			errorContains: "the OCI image layout " + several + " holds 2 images, select one with oci-layout:" + several + "@<digest>",
+		},
+		{
+			name:          "unknown digest",
+			bundle:        "oci-layout:" + single + "@sha256:" + strings.Repeat("0", 64),
+			errorContains: "holds no image sha256:",
+		},
+		{
+			name:          "no image",
+			bundle:        "oci-layout:" + noImage,
+			errorContains: "the OCI image layout " + noImage + " holds no image",
+		},
+		{name: "archived image selected by digest", bundle: "oci-layout:" + archive + "@" + buildDigest.String()},
+		{
+			name:          "legacy docker save tarball",
+			bundle:        "oci-layout:" + legacyArchive,
+			errorContains: "not an archive of an OCI image layout, it has no index.json",
+		},
+		{
+			name:          "missing path",
+			bundle:        "oci-layout:" + filepath.Join(dir, "missing"),
+			errorContains: "cannot read the local bundle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := v1.Params{
+				{Name: "bundle", Value: *v1.NewStructuredValues(tt.bundle)},
+				{Name: "name", Value: *v1.NewStructuredValues("build")},
+				{Name: "kind", Value: *v1.NewStructuredValues("task")},
+			}
+			source, data, err := resolveRemoteResource(context.Background(), "task", v1.ResolverRef{Resolver: "bundles", Params: params}, nil, nil)
+			if tt.errorContains != "" {
+				assert.ErrorContains(t, err, tt.errorContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "bundle "+tt.bundle, source)
+			assert.Equal(t, bundleTask, string(data))
+		})
+	}
+
+	ref := func(bundle string) v1.ResolverRef {
+		return v1.ResolverRef{Resolver: "bundles", Params: v1.Params{
+			{Name: "bundle", Value: *v1.NewStructuredValues(bundle)},
+			{Name: "name", Value: *v1.NewStructuredValues("build")},
+			{Name: "kind", Value: *v1.NewStructuredValues("task")},
+		}}
+	}
+	// Relative paths are relative to the directory of the file being validated.
+	_, data, err := resolveRemoteResource(WithBaseDir(context.Background(), dir), "task", ref("oci-layout:./single"), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, bundleTask, string(data))
+	// Untrusted input is not given the files of the host.
+	_, _, err = resolveRemoteResource(WithUntrustedInput(context.Background()), "task", ref("oci-layout:"+single), nil, nil)
+	assert.ErrorContains(t, err, "local bundle oci-layout:"+single+" is not read from untrusted input")
+
+	entries, err := ListBundle(context.Background(), "oci-layout:"+several+"@"+buildDigest.String())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "build", entries[0].Name)
+}
+
+// writeTarball archives the files of dir to the tarball fname
+func writeTarball(t *testing.T, fname, dir string) {
+	t.Helper()
+	f, err := os.Create(fname)
+	require.NoError(t, err)
+	defer f.Close()
+	w := tar.NewWriter(f)
+	require.NoError(t, w.AddFS(os.DirFS(dir)))
+	require.NoError(t, w.Close())
+}
@@ -0,0 +1,135 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ObjectLookup tells whether the ServiceAccounts, Secrets, and ConfigMaps runs refer to exist in
+// the cluster they run on
+type ObjectLookup interface {
+	// Exists reports whether the object of the given kind, e.g. Secret, and name exists in
+	// namespace.
+	Exists(ctx context.Context, kind, namespace, name string) (bool, error)
+	// DefaultNamespace is the namespace of resources which do not set one.
+	DefaultNamespace() string
+}
+
+// objectRef is a reference of a resource to an object of the cluster, at path
+type objectRef struct {
+	kind string
+	name string
+	path string
+}
+
+type clusterNamespaceKey struct{}
+
+// withClusterNamespace returns a copy of ctx where the objects the steps of Tasks refer to are
+// looked up in namespace, the one of the run being validated
+func withClusterNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, clusterNamespaceKey{}, namespace)
+}
+
+// validateClusterObjects verifies the objects refs refer to exist in namespace, or else in the
+// namespace carried by ctx, or in the default namespace of the ObjectLookup of the validation
+// options. Nothing is verified without an ObjectLookup. Objects which cannot be looked up are
+// reported as warnings.
+func validateClusterObjects(ctx context.Context, namespace string, refs []objectRef) error {
+	lookup := optionsFromContext(ctx).ClusterObjects
+	if lookup == nil {
+		return nil
+	}
+	if namespace == "" {
+		namespace, _ = ctx.Value(clusterNamespaceKey{}).(string)
+	}
+	if namespace == "" {
+		namespace = lookup.DefaultNamespace()
+	}
+
+	var err error
+	for _, ref := range refs {
+		// Names which depend on variable substitution can only be checked at runtime.
+		if ref.name == "" || strings.Contains(ref.name, "$(") {
+			continue
+		}
+		exists, lookupErr := lookup.Exists(ctx, ref.kind, namespace, ref.name)
+		if lookupErr != nil {
+			err = multierror.Append(err, withPath(ref.path, warningf("unable to look up %s %q in namespace %s: %s", ref.kind, ref.name, namespace, lookupErr)))
+			continue
+		}
+		if !exists {
+			err = multierror.Append(err, withPath(ref.path, fmt.Errorf("%s %q does not exist in namespace %s", ref.kind, ref.name, namespace)))
+		}
+	}
+	return err
+}
+
+// workspaceObjectRefs returns the references of workspace bindings to Secrets and ConfigMaps
+func workspaceObjectRefs(bindings []v1.WorkspaceBinding, path string) []objectRef {
+	var refs []objectRef
+	for i, binding := range bindings {
+		if binding.Secret != nil {
+			refs = append(refs, objectRef{kind: "Secret", name: binding.Secret.SecretName, path: fmt.Sprintf("%s.workspaces[%d].secret.secretName", path, i)})
+		}
+		if binding.ConfigMap != nil {
+			refs = append(refs, objectRef{kind: "ConfigMap", name: binding.ConfigMap.Name, path: fmt.Sprintf("%s.workspaces[%d].configMap.name", path, i)})
+		}
+	}
+	return refs
+}
+
+// pipelineRunObjectRefs returns the references of a PipelineRun to ServiceAccounts, and those of
+// its workspace bindings to Secrets and ConfigMaps
+func pipelineRunObjectRefs(spec v1.PipelineRunSpec) []objectRef {
+	var refs []objectRef
+	if template := spec.TaskRunTemplate; template.ServiceAccountName != "" {
+		refs = append(refs, objectRef{kind: "ServiceAccount", name: template.ServiceAccountName, path: "spec.taskRunTemplate.serviceAccountName"})
+	}
+	for i, runSpec := range spec.TaskRunSpecs {
+		if runSpec.ServiceAccountName != "" {
+			refs = append(refs, objectRef{kind: "ServiceAccount", name: runSpec.ServiceAccountName, path: fmt.Sprintf("spec.taskRunSpecs[%d].serviceAccountName", i)})
+		}
+	}
+	return append(refs, workspaceObjectRefs(spec.Workspaces, "spec")...)
+}
+
+// taskRunObjectRefs returns the references of a TaskRun to its ServiceAccount, and those of its
+// workspace bindings to Secrets and ConfigMaps
+func taskRunObjectRefs(spec v1.TaskRunSpec) []objectRef {
+	var refs []objectRef
+	if spec.ServiceAccountName != "" {
+		refs = append(refs, objectRef{kind: "ServiceAccount", name: spec.ServiceAccountName, path: "spec.serviceAccountName"})
+	}
+	return append(refs, workspaceObjectRefs(spec.Workspaces, "spec")...)
+}
+
+// stepObjectRefs returns the references of the env of the steps of a task spec at path, and of its
+// step template, to Secrets and ConfigMaps. Optional references are left out.
+func stepObjectRefs(taskSpec v1.TaskSpec, path string) []objectRef {
+	var refs []objectRef
+	envRefs := func(env []corev1.EnvVar, stepPath string) {
+		for i, variable := range env {
+			if variable.ValueFrom == nil {
+				continue
+			}
+			if selector := variable.ValueFrom.SecretKeyRef; selector != nil && (selector.Optional == nil || !*selector.Optional) {
+				refs = append(refs, objectRef{kind: "Secret", name: selector.Name, path: fmt.Sprintf("%s.env[%d].valueFrom.secretKeyRef.name", stepPath, i)})
+			}
+			if selector := variable.ValueFrom.ConfigMapKeyRef; selector != nil && (selector.Optional == nil || !*selector.Optional) {
+				refs = append(refs, objectRef{kind: "ConfigMap", name: selector.Name, path: fmt.Sprintf("%s.env[%d].valueFrom.configMapKeyRef.name", stepPath, i)})
+			}
+		}
+	}
+	if taskSpec.StepTemplate != nil {
+		envRefs(taskSpec.StepTemplate.Env, path+".stepTemplate")
+	}
+	for i, step := range taskSpec.Steps {
+		envRefs(step.Env, fmt.Sprintf("%s.steps[%d]", path, i))
+	}
+	return refs
+}
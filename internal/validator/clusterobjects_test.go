@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// fakeObjectLookup knows the objects of a cluster by kind, namespace, and name, e.g. Secret/ci/token
+type fakeObjectLookup map[string]bool
+
+func (f fakeObjectLookup) Exists(_ context.Context, kind, namespace, name string) (bool, error) {
+	if name == "unreachable" {
+		return false, errors.New("connection refused")
+	}
+	return f[fmt.Sprintf("%s/%s/%s", kind, namespace, name)], nil
+}
+
+func (f fakeObjectLookup) DefaultNamespace() string {
+	return "default"
+}
+
+func TestValidatePipelineRunClusterObjects(t *testing.T) {
+	pr, err := pipelineRunFromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+  namespace: ci
+spec:
+  taskRunTemplate:
+    serviceAccountName: builder
+  taskRunSpecs:
+    - pipelineTaskName: build
+      serviceAccountName: pusher
+  workspaces:
+    - name: credentials
+      secret:
+        secretName: registry
+    - name: settings
+      configMap:
+        name: settings
+  pipelineSpec:
+    workspaces:
+      - name: credentials
+      - name: settings
+    tasks:
+      - name: build
+        workspaces:
+          - name: credentials
+            workspace: credentials
+          - name: settings
+            workspace: settings
+        taskSpec:
+          workspaces:
+            - name: credentials
+            - name: settings
+          steps:
+            - name: build
+              image: alpine:latest
+              env:
+                - name: TOKEN
+                  valueFrom:
+                    secretKeyRef:
+                      name: token
+                      key: token
+                - name: OPTIONAL
+                  valueFrom:
+                    secretKeyRef:
+                      name: optional
+                      key: token
+                      optional: true
+                - name: LEVEL
+                  valueFrom:
+                    configMapKeyRef:
+                      name: unreachable
+                      key: level
+              script: ls $(workspaces.credentials.path) $(workspaces.settings.path)
+`)
+	require.NoError(t, err)
+
+	ctx := WithOptions(context.Background(), Options{ClusterObjects: fakeObjectLookup{
+		"ServiceAccount/ci/builder": true,
+		"ConfigMap/ci/settings":     true,
+	}})
+	err = ValidatePipelineRun(ctx, pr)
+	require.Error(t, err)
+	var messages []string
+	for _, finding := range Findings(err) {
+		if finding.Rule == RuleClusterObjects.ID {
+			message := fmt.Sprintf("%s %s", finding.Severity, finding.Message)
+			if finding.ResourcePath != "" {
+				message += ": " + finding.ResourcePath
+			}
+			messages = append(messages, message)
+		}
+	}
+	assert.Equal(t, []string{
+		`error ServiceAccount "pusher" does not exist in namespace ci: spec.taskRunSpecs[0].serviceAccountName`,
+		`error Secret "registry" does not exist in namespace ci: spec.workspaces[0].secret.secretName`,
+		`error build PipelineTask: Secret "token" does not exist in namespace ci: spec.steps[0].env[0].valueFrom.secretKeyRef.name`,
+		`warning build PipelineTask: unable to look up ConfigMap "unreachable" in namespace ci: connection refused: spec.steps[0].env[2].valueFrom.configMapKeyRef.name`,
+	}, messages)
+
+	// Nothing is looked up without --cluster-checks.
+	assert.NoError(t, ValidatePipelineRun(context.Background(), pr))
+}
+
+func TestValidateTaskRunClusterObjects(t *testing.T) {
+	var tr v1.TaskRun
+	require.NoError(t, yaml.Unmarshal([]byte(`
+apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  name: build
+spec:
+  serviceAccountName: builder
+  taskSpec:
+    stepTemplate:
+      env:
+        - name: LEVEL
+          valueFrom:
+            configMapKeyRef:
+              name: $(params.settings)
+              key: level
+    steps:
+      - name: build
+        image: alpine:latest
+        script: echo build
+`), &tr))
+
+	ctx := WithOptions(context.Background(), Options{ClusterObjects: fakeObjectLookup{}})
+	err := ValidateTaskRun(ctx, tr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `ServiceAccount "builder" does not exist in namespace default: spec.serviceAccountName`)
+	assert.NotContains(t, err.Error(), "ConfigMap")
+}
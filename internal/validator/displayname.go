@@ -0,0 +1,95 @@
+package validator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// ValidateDisplayNames verifies the variables substituted in the displayName of the PipelineTasks of
+// a pipeline spec: params must be declared by the pipeline spec, and results must be declared by the
+// PipelineTask they refer to. A regular PipelineTask is warned about when it refers to the results of
+// a PipelineTask which does not run before it, since they are not substituted then. Finally tasks run
+// after all the regular ones. The path is the one of the pipeline spec.
+func ValidateDisplayNames(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec, path string) error {
+	return validateDisplayNames(pipelineSpec, allTaskSpecs, path, nil)
+}
+
+// validateDisplayNames is like ValidateDisplayNames but also accepts references to the given
+// parameters, which are propagated from a PipelineRun embedding the pipeline spec
+func validateDisplayNames(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec, path string, propagatedParams []string) error {
+	var err error
+
+	defined := make(map[string]bool)
+	for _, param := range pipelineSpec.Params {
+		defined[param.Name] = true
+	}
+	for _, name := range propagatedParams {
+		defined[name] = true
+	}
+	tasks := make(map[string]bool)
+	for _, pipelineTask := range pipelineSpec.Tasks {
+		tasks[pipelineTask.Name] = true
+	}
+	deps := v1.PipelineTaskList(pipelineSpec.Tasks).Deps()
+
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		for i, pipelineTask := range section.pipelineTasks {
+			if !strings.Contains(pipelineTask.DisplayName, "$(") {
+				continue
+			}
+			fieldPath := fmt.Sprintf("%s.%s[%d].displayName", path, section.name, i)
+
+			for _, match := range paramRefRegex.FindAllStringSubmatch(pipelineTask.DisplayName, -1) {
+				if ref := strings.TrimSpace(match[1]); ref == "" || !defined[paramRefName(ref)] {
+					err = multierror.Append(err, withRule(RuleParamReferences, withPath(fieldPath, fmt.Errorf(
+						"parameter reference validation: parameter reference $(params.%s) not defined in pipeline spec", ref))))
+				}
+			}
+
+			for _, match := range resultConsumptionRegex.FindAllStringSubmatch(pipelineTask.DisplayName, -1) {
+				producer, result := match[1], match[2]
+				switch {
+				case producer == pipelineTask.Name:
+					err = multierror.Append(err, withRule(RuleResults, withPath(fieldPath, fmt.Errorf(
+						"displayName of the %s PipelineTask refers to its own result %q, which is not available yet", pipelineTask.Name, result))))
+				case !tasks[producer]:
+					err = multierror.Append(err, withRule(RuleResults, withPath(fieldPath, fmt.Errorf(
+						"displayName of the %s PipelineTask refers to the results of the non-existent %s PipelineTask", pipelineTask.Name, producer))))
+				case !declaresResult(allTaskSpecs[producer], result):
+					err = multierror.Append(err, withRule(RuleResults, withPath(fieldPath, fmt.Errorf(
+						"non-existent result in %q", match[0]))))
+				case section.name == "tasks" && !runsAfter(pipelineTask.Name, producer, deps):
+					err = multierror.Append(err, withRule(RuleResults, withPath(fieldPath, warningf(
+						"displayName of the %s PipelineTask refers to a result of the %s PipelineTask, which does not run before it, so it is not substituted, order them with runAfter",
+						pipelineTask.Name, producer))))
+				}
+			}
+		}
+	}
+	return err
+}
+
+// declaresResult tells whether a Task spec declares a result. Results of unknown specs, e.g. of
+// Custom Tasks, are assumed to exist.
+func declaresResult(taskSpec *v1.TaskSpec, name string) bool {
+	if taskSpec == nil {
+		return true
+	}
+	return slices.ContainsFunc(taskSpec.Results, func(result v1.TaskResult) bool { return result.Name == name })
+}
+
+// displayNameParameterReferences counts the parameter references in the displayName of the
+// PipelineTasks of a pipeline spec
+func displayNameParameterReferences(pipelineSpec v1.PipelineSpec) map[string]int {
+	refs := make(map[string]int)
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		for ref, count := range countParameterReferences(pipelineTask.DisplayName) {
+			refs[ref] += count
+		}
+	}
+	return refs
+}
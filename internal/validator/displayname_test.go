@@ -0,0 +1,122 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+func TestValidateDisplayNames(t *testing.T) {
+	buildSpec := &v1.TaskSpec{Results: []v1.TaskResult{{Name: "digest"}}}
+
+	tests := []struct {
+		name             string
+		pipelineSpecYAML string
+		allTaskSpecs     map[string]*v1.TaskSpec
+		propagatedParams []string
+		expected         []string
+	}{
+		{
+			name: "valid substitutions",
+			pipelineSpecYAML: `
+params:
+  - name: env
+tasks:
+  - name: build
+    displayName: Build for $(params.env) in $(params.region) of $(context.pipelineRun.name)
+    taskRef:
+      name: build
+  - name: deploy
+    displayName: Deploy $(tasks.build.results.digest) to $(params.env)
+    runAfter: [build]
+    taskRef:
+      name: deploy
+  - name: scan
+    displayName: Scan $(tasks.custom.results.report)
+    params:
+      - name: report
+        value: $(tasks.custom.results.report)
+    taskRef:
+      name: scan
+  - name: custom
+    taskRef:
+      apiVersion: example.dev/v1
+      kind: Scanner
+finally:
+  - name: notify
+    displayName: Notify about $(tasks.build.results.digest), $(tasks.status)
+    taskRef:
+      name: notify
+`,
+			allTaskSpecs:     map[string]*v1.TaskSpec{"build": buildSpec},
+			propagatedParams: []string{"region"},
+		},
+		{
+			name: "broken substitutions",
+			pipelineSpecYAML: `
+params:
+  - name: env
+tasks:
+  - name: build
+    displayName: Build $(params.envv) $(tasks.build.results.digest)
+    taskRef:
+      name: build
+  - name: deploy
+    displayName: Deploy $(tasks.build.results.digests) $(tasks.test.results.report)
+    runAfter: [build]
+    taskRef:
+      name: deploy
+finally:
+  - name: notify
+    displayName: Notify about $(tasks.cleanup.results.report)
+    taskRef:
+      name: notify
+  - name: cleanup
+    taskRef:
+      name: cleanup
+`,
+			allTaskSpecs: map[string]*v1.TaskSpec{"build": buildSpec},
+			expected: []string{
+				"parameter reference validation: parameter reference $(params.envv) not defined in pipeline spec: spec.tasks[0].displayName [TEK0201]",
+				`displayName of the build PipelineTask refers to its own result "digest", which is not available yet: spec.tasks[0].displayName [TEK0301]`,
+				`non-existent result in "$(tasks.build.results.digests)": spec.tasks[1].displayName [TEK0301]`,
+				"displayName of the deploy PipelineTask refers to the results of the non-existent test PipelineTask: spec.tasks[1].displayName [TEK0301]",
+				"displayName of the notify PipelineTask refers to the results of the non-existent cleanup PipelineTask: spec.finally[0].displayName [TEK0301]",
+			},
+		},
+		{
+			name: "result of a PipelineTask which does not run before",
+			pipelineSpecYAML: `
+tasks:
+  - name: build
+    taskRef:
+      name: build
+  - name: deploy
+    displayName: Deploy $(tasks.build.results.digest)
+    taskRef:
+      name: deploy
+`,
+			allTaskSpecs: map[string]*v1.TaskSpec{"build": buildSpec},
+			expected: []string{
+				"displayName of the deploy PipelineTask refers to a result of the build PipelineTask, which does not run before it, so it is not substituted, order them with runAfter: spec.tasks[1].displayName [TEK0301]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipelineSpec, err := pipelineSpecFromYAML(tt.pipelineSpecYAML)
+			require.NoError(t, err)
+
+			err = validateDisplayNames(pipelineSpec, tt.allTaskSpecs, "spec", tt.propagatedParams)
+
+			var messages []string
+			for _, finding := range Findings(err) {
+				messages = append(messages, finding.String())
+			}
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/tektoncd/pipeline/pkg/apis/config"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// withParamEnums enables the Tekton feature flag that allows the use of enum in ParamSpecs. Without
+// it, Tekton's own validation rejects any enum outright instead of checking default values against
+// it. Clusters running Pipelines that use enum must have the feature flag enabled anyways.
+func withParamEnums(ctx context.Context) context.Context {
+	cfg := config.FromContextOrDefaults(ctx)
+	if cfg.FeatureFlags != nil && cfg.FeatureFlags.EnableParamEnum {
+		return ctx
+	}
+	updated := *cfg
+	if cfg.FeatureFlags != nil {
+		updated.FeatureFlags = cfg.FeatureFlags.DeepCopy()
+	} else {
+		updated.FeatureFlags = config.DefaultFeatureFlags.DeepCopy()
+	}
+	updated.FeatureFlags.EnableParamEnum = true
+	return config.ToContext(ctx, &updated)
+}
+
+// ValidateParamEnums verifies the values of params fall within the enum of their ParamSpec. Values
+// that still contain variable references cannot be verified and are ignored.
+func ValidateParamEnums(params v1.Params, specs v1.ParamSpecs) error {
+	var err error
+	for _, param := range params {
+		spec, found := getTaskParam(param.Name, specs)
+		if !found || len(spec.Enum) == 0 {
+			continue
+		}
+		if param.Value.Type != "" && param.Value.Type != v1.ParamTypeString {
+			continue
+		}
+		value := param.Value.StringVal
+		if strings.Contains(value, "$(") {
+			continue
+		}
+		if !slices.Contains(spec.Enum, value) {
+			err = multierror.Append(err, fmt.Errorf(
+				"%q parameter value %q is not in the enum list [%s]",
+				param.Name, value, strings.Join(spec.Enum, ", ")))
+		}
+	}
+	return err
+}
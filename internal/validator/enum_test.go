@@ -0,0 +1,286 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tektoncd/pipeline/pkg/apis/config"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+func TestValidateParamEnums(t *testing.T) {
+	specs := v1.ParamSpecs{
+		{Name: "environment", Type: v1.ParamTypeString, Enum: []string{"dev", "prod"}},
+		{Name: "message", Type: v1.ParamTypeString},
+	}
+
+	tests := []struct {
+		name           string
+		params         v1.Params
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "value in enum",
+			params: v1.Params{
+				{Name: "environment", Value: *v1.NewStructuredValues("prod")},
+			},
+			expectNoError: true,
+		},
+		{
+			name: "value not in enum",
+			params: v1.Params{
+				{Name: "environment", Value: *v1.NewStructuredValues("staging")},
+			},
+			expectedErrors: []string{`"environment" parameter value "staging" is not in the enum list [dev, prod]`},
+		},
+		{
+			name: "param without enum",
+			params: v1.Params{
+				{Name: "message", Value: *v1.NewStructuredValues("anything")},
+			},
+			expectNoError: true,
+		},
+		{
+			name: "unresolved reference",
+			params: v1.Params{
+				{Name: "environment", Value: *v1.NewStructuredValues("$(params.env)")},
+			},
+			expectNoError: true,
+		},
+		{
+			name: "unknown param",
+			params: v1.Params{
+				{Name: "unknown", Value: *v1.NewStructuredValues("staging")},
+			},
+			expectNoError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParamEnums(tt.params, specs)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+			}
+		})
+	}
+}
+
+func TestWithParamEnums(t *testing.T) {
+	ctx := withParamEnums(context.Background())
+	assert.True(t, config.FromContextOrDefaults(ctx).FeatureFlags.EnableParamEnum)
+	assert.False(t, config.FromContextOrDefaults(context.Background()).FeatureFlags.EnableParamEnum,
+		"Expected the defaults to be left untouched")
+}
+
+func TestValidatePipelineWithParamEnums(t *testing.T) {
+	tests := []struct {
+		name           string
+		pipelineYAML   string
+		runtimeParams  map[string]string
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "valid enum values",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: deploy
+spec:
+  params:
+    - name: environment
+      type: string
+      enum: [dev, prod]
+      default: dev
+  tasks:
+    - name: deploy
+      params:
+        - name: environment
+          value: $(params.environment)
+        - name: region
+          value: us-east-1
+      taskSpec:
+        params:
+          - name: environment
+            type: string
+            enum: [dev, prod]
+          - name: region
+            type: string
+            enum: [us-east-1, eu-west-1]
+        steps:
+          - name: deploy
+            image: alpine:latest
+            script: echo $(params.environment) $(params.region)
+`,
+			expectNoError: true,
+		},
+		{
+			name: "pipeline default not in enum",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: deploy
+spec:
+  params:
+    - name: environment
+      type: string
+      enum: [dev, prod]
+      default: staging
+  tasks:
+    - name: deploy
+      taskSpec:
+        steps:
+          - name: deploy
+            image: alpine:latest
+            script: echo deploy
+`,
+			expectedErrors: []string{"param default value staging not in the enum list"},
+		},
+		{
+			name: "literal PipelineTask value not in enum",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: deploy
+spec:
+  tasks:
+    - name: deploy
+      params:
+        - name: region
+          value: ap-south-1
+      taskSpec:
+        params:
+          - name: region
+            type: string
+            enum: [us-east-1, eu-west-1]
+        steps:
+          - name: deploy
+            image: alpine:latest
+            script: echo $(params.region)
+`,
+			expectedErrors: []string{`ERROR: deploy PipelineTask: 1 error occurred:` + "\n\t* " + `"region" parameter value "ap-south-1" is not in the enum list [us-east-1, eu-west-1]`},
+		},
+		{
+			name: "pipeline default flows into Task enum",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: deploy
+spec:
+  params:
+    - name: region
+      type: string
+      default: ap-south-1
+  tasks:
+    - name: deploy
+      params:
+        - name: region
+          value: $(params.region)
+      taskSpec:
+        params:
+          - name: region
+            type: string
+            enum: [us-east-1, eu-west-1]
+        steps:
+          - name: deploy
+            image: alpine:latest
+            script: echo $(params.region)
+`,
+			expectedErrors: []string{`"region" parameter value "ap-south-1" is not in the enum list [us-east-1, eu-west-1]`},
+		},
+		{
+			name: "runtime value flows into Task enum",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: deploy
+spec:
+  params:
+    - name: region
+      type: string
+  tasks:
+    - name: deploy
+      params:
+        - name: region
+          value: $(params.region)
+      taskSpec:
+        params:
+          - name: region
+            type: string
+            enum: [us-east-1, eu-west-1]
+        steps:
+          - name: deploy
+            image: alpine:latest
+            script: echo $(params.region)
+`,
+			runtimeParams:  map[string]string{"region": "mars-1"},
+			expectedErrors: []string{`"region" parameter value "mars-1" is not in the enum list [us-east-1, eu-west-1]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := pipelineFromYAML(tt.pipelineYAML)
+			require.NoError(t, err)
+
+			err = ValidatePipelineWithYAMLAndParams(context.Background(), p, nil, StringParams(tt.runtimeParams))
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				assert.NotContains(t, errStr, config.EnableParamEnum, "Expected the enum feature flag to be enabled")
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePipelineRunWithParamEnums(t *testing.T) {
+	pr, err := pipelineRunFromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: deploy
+spec:
+  params:
+    - name: environment
+      value: staging
+  pipelineSpec:
+    params:
+      - name: environment
+        type: string
+        enum: [dev, prod]
+    tasks:
+      - name: deploy
+        taskSpec:
+          steps:
+            - name: deploy
+              image: alpine:latest
+              script: echo deploy
+`)
+	require.NoError(t, err)
+
+	err = ValidatePipelineRun(context.Background(), pr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `PipelineRun params: 1 error occurred:`+"\n\t* "+`"environment" parameter value "staging" is not in the enum list [dev, prod]`)
+}
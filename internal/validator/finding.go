@@ -0,0 +1,194 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Severity tells whether a Finding fails the validation
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem reported by the validation
+type Finding struct {
+	// Rule is the ID of the rule reporting the problem, if any.
+	Rule     string   `json:"rule,omitempty"`
+	Severity Severity `json:"severity"`
+	// Message describes the problem, including the context added by the errors wrapping it.
+	Message string `json:"message"`
+	// ResourcePath is the path of the field of the resource the problem is about, e.g.
+	// spec.steps[0].image, if known.
+	ResourcePath string `json:"resourcePath,omitempty"`
+	// Line is the line of the file where the field of ResourcePath is, or else where the resource
+	// starts, if known. It is set by the callers which parsed the file.
+	Line int `json:"line,omitempty"`
+	// Occurrences counts the findings DeduplicatedFindings collapsed into this one, and Locations
+	// lists where they occur. They are only set for findings occurring more than once.
+	Occurrences int      `json:"occurrences,omitempty"`
+	Locations   []string `json:"locations,omitempty"`
+}
+
+// String renders the Finding as the validation used to report it, followed by its rule
+func (f Finding) String() string {
+	s := f.Message
+	if f.Occurrences > 1 {
+		s = fmt.Sprintf("%s (%d occurrences)", s, f.Occurrences)
+		if len(f.Locations) > 0 {
+			s = fmt.Sprintf("%s: %s", s, strings.Join(f.Locations, ", "))
+		}
+	} else if f.ResourcePath != "" {
+		s = fmt.Sprintf("%s: %s", s, f.ResourcePath)
+	}
+	if f.Rule != "" {
+		s = fmt.Sprintf("%s [%s]", s, f.Rule)
+	}
+	return s
+}
+
+// pathError is an error about the field at path of a resource, e.g. spec.steps[0].script, which
+// its message is followed by
+type pathError struct {
+	path string
+	err  error
+}
+
+func (e *pathError) Error() string {
+	return fmt.Sprintf("%v: %s", e.err, e.path)
+}
+
+func (e *pathError) Unwrap() error {
+	return e.err
+}
+
+// withPath attributes the errors of err to the field at path. Like withRule, the errors of a
+// multierror are attributed one by one, and a Warning stays a Warning. It returns err as is if
+// path is empty, and nil if err is nil.
+func withPath(path string, err error) error {
+	if err == nil || path == "" {
+		return err
+	}
+	if merr, ok := err.(*multierror.Error); ok {
+		var result error
+		for _, e := range merr.Errors {
+			result = multierror.Append(result, withPath(path, e))
+		}
+		return result
+	}
+	if w, ok := err.(*Warning); ok {
+		return &Warning{Err: withPath(path, w.Err)}
+	}
+	return &pathError{path: path, err: err}
+}
+
+// leafPathError returns the pathError a leaf error, or the Warning it is, is
+func leafPathError(leaf error) (*pathError, bool) {
+	if w, ok := leaf.(*Warning); ok {
+		leaf = w.Err
+	}
+	perr, ok := leaf.(*pathError)
+	return perr, ok
+}
+
+// resourcePathRegex matches the path of a field ending the message of an error, e.g.
+// `non-existent variable in "$(params.url)": spec.steps[0].script`. It finds the paths of the
+// errors reported without withPath, e.g. by the validation of the Tekton API.
+var resourcePathRegex = regexp.MustCompile(`^(.+): ((?:metadata|spec|pipelineSpec|taskSpec)(?:[.\[][^\s:]*)?)$`)
+
+// Findings returns a Finding for every error and warning contained in an error returned by the
+// validation functions, in the order they were reported
+func Findings(err error) []Finding {
+	var findings []Finding
+	for _, o := range occurrences(err) {
+		findings = append(findings, o.finding)
+	}
+	return findings
+}
+
+// DeduplicatedFindings is like Findings but collapses the findings reporting the same problem in
+// several places, e.g. an undefined param referenced by several steps, or a missing Task referenced
+// by several PipelineTasks, into the first of them, which counts their Occurrences and lists their
+// Locations. Locations are resource paths, preceded by the context of the message when it differs
+// between the occurrences, e.g. the PipelineTask referencing the missing Task.
+func DeduplicatedFindings(err error) []Finding {
+	type key struct {
+		rule     string
+		severity Severity
+		problem  string
+	}
+	groups := map[key][]occurrence{}
+	var keys []key
+	for _, o := range occurrences(err) {
+		k := key{o.finding.Rule, o.finding.Severity, o.problem}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], o)
+	}
+
+	var findings []Finding
+	for _, k := range keys {
+		group := groups[k]
+		finding := group[0].finding
+		if len(group) > 1 {
+			sameContext := !slices.ContainsFunc(group, func(o occurrence) bool { return o.context != group[0].context })
+			if !sameContext {
+				finding.Message = k.problem
+			}
+			finding.Occurrences = len(group)
+			for _, o := range group {
+				location := o.finding.ResourcePath
+				if context := strings.TrimSuffix(strings.TrimSpace(o.context), ":"); !sameContext && context != "" {
+					location = context
+					if o.finding.ResourcePath != "" {
+						location = fmt.Sprintf("%s at %s", context, o.finding.ResourcePath)
+					}
+				}
+				if location != "" {
+					finding.Locations = append(finding.Locations, location)
+				}
+			}
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// occurrence is a Finding along with the context added to its message by the errors wrapping it,
+// and the rest of its message, the problem
+type occurrence struct {
+	finding Finding
+	context string
+	problem string
+}
+
+// occurrences returns an occurrence for every error and warning contained in err, in the order
+// they were reported
+func occurrences(err error) []occurrence {
+	var occurrences []occurrence
+	walkRuleErrors(err, "", nil, func(prefix string, rule *Rule, leaf error) {
+		finding := Finding{Severity: SeverityError, Message: leaf.Error()}
+		if isWarning(leaf) {
+			finding.Severity = SeverityWarning
+		}
+		if rule != nil {
+			finding.Rule = rule.ID
+		}
+		if perr, ok := leafPathError(leaf); ok {
+			finding.Message, finding.ResourcePath = perr.err.Error(), perr.path
+		} else if match := resourcePathRegex.FindStringSubmatch(finding.Message); match != nil {
+			finding.Message, finding.ResourcePath = match[1], match[2]
+		}
+		problem := finding.Message
+		finding.Message = prefix + finding.Message
+		occurrences = append(occurrences, occurrence{finding: finding, context: prefix, problem: problem})
+	})
+	return occurrences
+}
@@ -0,0 +1,227 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected []Finding
+	}{
+		{
+			name: "nil error",
+		},
+		{
+			name:     "unattributed error",
+			err:      errors.New("v1/ConfigMap is not supported"),
+			expected: []Finding{{Severity: SeverityError, Message: "v1/ConfigMap is not supported"}},
+		},
+		{
+			name: "error with a resource path",
+			err:  withRule(RuleResults, fmt.Errorf("non-existent result in %q: %s", "$(results.digest.path)", "spec.steps[0].script")),
+			expected: []Finding{{
+				Rule:         RuleResults.ID,
+				Severity:     SeverityError,
+				Message:      `non-existent result in "$(results.digest.path)"`,
+				ResourcePath: "spec.steps[0].script",
+			}},
+		},
+		{
+			name: "error and warning at a path",
+			err: multierror.Append(
+				withRule(RuleResults, withPath("spec.steps[0].script", fmt.Errorf("non-existent result in %q", "$(results.digest.path)"))),
+				fmt.Errorf("lint: %w", withPath("spec.steps[1]", warningf("step: has no name"))),
+			),
+			expected: []Finding{
+				{
+					Rule:         RuleResults.ID,
+					Severity:     SeverityError,
+					Message:      `non-existent result in "$(results.digest.path)"`,
+					ResourcePath: "spec.steps[0].script",
+				},
+				{Severity: SeverityWarning, Message: "lint: step: has no name", ResourcePath: "spec.steps[1]"},
+			},
+		},
+		{
+			name: "innermost rule wins",
+			err: withRule(RuleWorkspaces, fmt.Errorf("workspace validation: %w", multierror.Append(
+				errors.New(`workspace "cache" is not bound`),
+				withRule(RuleUnusedWorkspace, errors.New(`pipeline workspace "source" is declared but never used`)),
+			))),
+			expected: []Finding{
+				{Rule: RuleWorkspaces.ID, Severity: SeverityError, Message: `workspace validation: workspace "cache" is not bound`},
+				{Rule: RuleUnusedWorkspace.ID, Severity: SeverityError, Message: `workspace validation: pipeline workspace "source" is declared but never used`},
+			},
+		},
+		{
+			name: "warning",
+			err:  multierror.Append(errors.New("boom"), withRule(RuleDebug, warningf("spec.debug pauses the run"))),
+			expected: []Finding{
+				{Severity: SeverityError, Message: "boom"},
+				{Rule: RuleDebug.ID, Severity: SeverityWarning, Message: "spec.debug pauses the run"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Findings(tt.err))
+		})
+	}
+}
+
+func TestFindingString(t *testing.T) {
+	assert.Equal(t, "boom", Finding{Message: "boom"}.String())
+	assert.Equal(t, "missing field(s): spec.steps [TEK0101]", Finding{Rule: "TEK0101", Message: "missing field(s)", ResourcePath: "spec.steps"}.String())
+	assert.Equal(t, "boom (2 occurrences)", Finding{Message: "boom", Occurrences: 2}.String())
+	assert.Equal(t, "missing field(s) (2 occurrences): spec.steps, spec.sidecars [TEK0101]", Finding{
+		Rule:         "TEK0101",
+		Message:      "missing field(s)",
+		ResourcePath: "spec.steps",
+		Occurrences:  2,
+		Locations:    []string{"spec.steps", "spec.sidecars"},
+	}.String())
+}
+
+func TestDeduplicatedFindings(t *testing.T) {
+	undefined := func(path string) error {
+		return withRule(RuleParamReferences, fmt.Errorf("parameter validation: %w", fmt.Errorf(`undefined param "url": %s`, path)))
+	}
+	missing := func(pipelineTask string) error {
+		return withRule(RuleTaskResolution, fmt.Errorf("retrieving task spec from %s pipeline task: %w", pipelineTask, errors.New(`Task "build" not found`)))
+	}
+	tests := []struct {
+		name     string
+		err      error
+		expected []Finding
+	}{
+		{
+			name: "nil error",
+		},
+		{
+			name: "distinct findings",
+			err:  multierror.Append(undefined("spec.steps[0].script"), missing("build")),
+			expected: []Finding{
+				{Rule: RuleParamReferences.ID, Severity: SeverityError, Message: `parameter validation: undefined param "url"`, ResourcePath: "spec.steps[0].script"},
+				{Rule: RuleTaskResolution.ID, Severity: SeverityError, Message: `retrieving task spec from build pipeline task: Task "build" not found`},
+			},
+		},
+		{
+			name: "same problem at several paths",
+			err:  multierror.Append(undefined("spec.steps[0].script"), errors.New("boom"), undefined("spec.steps[1].args[0]"), undefined("spec.steps[2].env[URL]")),
+			expected: []Finding{
+				{
+					Rule:         RuleParamReferences.ID,
+					Severity:     SeverityError,
+					Message:      `parameter validation: undefined param "url"`,
+					ResourcePath: "spec.steps[0].script",
+					Occurrences:  3,
+					Locations:    []string{"spec.steps[0].script", "spec.steps[1].args[0]", "spec.steps[2].env[URL]"},
+				},
+				{Severity: SeverityError, Message: "boom"},
+			},
+		},
+		{
+			name: "same problem in several contexts",
+			err:  multierror.Append(missing("build"), missing("test")),
+			expected: []Finding{{
+				Rule:        RuleTaskResolution.ID,
+				Severity:    SeverityError,
+				Message:     `Task "build" not found`,
+				Occurrences: 2,
+				Locations:   []string{"retrieving task spec from build pipeline task", "retrieving task spec from test pipeline task"},
+			}},
+		},
+		{
+			name: "same problem with and without context",
+			err:  multierror.Append(undefined("spec.steps[0].script"), withRule(RuleParamReferences, errors.New(`undefined param "url": spec.params[0]`))),
+			expected: []Finding{{
+				Rule:         RuleParamReferences.ID,
+				Severity:     SeverityError,
+				Message:      `undefined param "url"`,
+				ResourcePath: "spec.steps[0].script",
+				Occurrences:  2,
+				Locations:    []string{"parameter validation at spec.steps[0].script", "spec.params[0]"},
+			}},
+		},
+		{
+			name: "same message with another severity",
+			err:  multierror.Append(errors.New("boom"), warningf("boom")),
+			expected: []Finding{
+				{Severity: SeverityError, Message: "boom"},
+				{Severity: SeverityWarning, Message: "boom"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DeduplicatedFindings(tt.err))
+		})
+	}
+}
+
+func TestFindingsOfPipeline(t *testing.T) {
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  workspaces:
+    - name: source
+  tasks:
+    - name: build
+      taskSpec:
+        steps:
+          - name: build
+            image: alpine:latest
+`)
+	require.NoError(t, err)
+
+	findings := Findings(ValidatePipelineWithYAML(context.Background(), p, nil))
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleUnusedWorkspace.ID, findings[0].Rule)
+	assert.Contains(t, findings[0].Message, `pipeline workspace "source" is declared but never used`)
+}
+
+func TestFindingsSurviveWithoutWarnings(t *testing.T) {
+	err := multierror.Append(warningf("careful"), withRule(RuleSteps, errors.New("boom")))
+	assert.Equal(t, []Finding{{Rule: RuleSteps.ID, Severity: SeverityError, Message: "boom"}}, Findings(WithoutWarnings(err)))
+	assert.Equal(t, []Finding{{Rule: RuleSteps.ID, Severity: SeverityError, Message: "BOOM"}}, Findings(WithoutWarnings(RewriteErrors(err, func(s string) string {
+		if s == "boom" {
+			return "BOOM"
+		}
+		return s
+	}))))
+}
+
+func TestWithPath(t *testing.T) {
+	assert.Nil(t, withPath("spec.steps[0]", nil))
+	assert.Equal(t, errors.New("boom"), withPath("", errors.New("boom")))
+
+	err := withPath("spec.steps[0].image", multierror.Append(errors.New("boom"), warningf("careful")))
+	assert.EqualError(t, WithoutWarnings(err), "1 error occurred:\n\t* boom: spec.steps[0].image\n\n")
+	assert.Equal(t, []string{"careful: spec.steps[0].image"}, Warnings(err))
+
+	// The path is rewritten along with the message, as for fragments.
+	rewritten := RewriteErrors(err, func(s string) string { return strings.Replace(s, "spec.", "taskSpec.", 1) })
+	assert.Equal(t, []Finding{
+		{Severity: SeverityError, Message: "boom", ResourcePath: "taskSpec.steps[0].image"},
+		{Severity: SeverityWarning, Message: "careful", ResourcePath: "taskSpec.steps[0].image"},
+	}, Findings(rewritten))
+	assert.Equal(t, []Finding{
+		{Severity: SeverityError, Message: "boom", ResourcePath: "spec.steps[0].image"},
+		{Severity: SeverityError, Message: "careful", ResourcePath: "spec.steps[0].image"},
+	}, Findings(PromoteWarnings(err)))
+}
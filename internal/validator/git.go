@@ -0,0 +1,397 @@
+package validator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
+	gogit "github.com/go-git/go-git/v5"
+	gitcfg "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/logging"
+)
+
+// gitTokenEnv maps well-known git hosts to the environment variable holding a token for their HTTPS
+// URLs, and the username going with it, used unless a credential is configured for the host
+var gitTokenEnv = map[string]struct{ env, username string }{
+	"github.com": {env: "GITHUB_TOKEN", username: "git"},
+	"gitlab.com": {env: "GITLAB_TOKEN", username: "oauth2"},
+}
+
+// repoGitAuth returns the authentication of a git repository URL: the credential of the validation
+// options for its host, or else the SSH agent for SSH URLs, and, if the options enable GitEnvTokens,
+// the token of the environment of well-known hosts, see gitTokenEnv, for HTTPS URLs. Untrusted input
+// uses neither the SSH agent nor the environment. It returns nil for anonymous access.
+func repoGitAuth(ctx context.Context, repoURL string) (transport.AuthMethod, error) {
+	opts := optionsFromContext(ctx)
+	if cred := opts.Credentials.GitCredential(repoURL); cred != nil {
+		auth, err := gitAuth(repoURL, *cred)
+		if err != nil {
+			return nil, fmt.Errorf("credentials of %s: %w", cred.Host, err)
+		}
+		return auth, nil
+	}
+	if opts.Untrusted {
+		return nil, nil
+	}
+	endpoint, err := transport.NewEndpoint(repoURL)
+	if err != nil {
+		// The clone reports the invalid URL.
+		return nil, nil
+	}
+	switch endpoint.Protocol {
+	case "ssh":
+		if os.Getenv(sshAgentEnv) != "" {
+			return gitssh.NewSSHAgentAuth(sshUser(endpoint, ""))
+		}
+	case "https":
+		if token, ok := gitTokenEnv[endpoint.Host]; ok && opts.GitEnvTokens && os.Getenv(token.env) != "" {
+			return &githttp.BasicAuth{Username: token.username, Password: os.Getenv(token.env)}, nil
+		}
+	}
+	return nil, nil
+}
+
+// sshAgentEnv names the environment variable locating the socket of the SSH agent
+const sshAgentEnv = "SSH_AUTH_SOCK"
+
+// gitAuth returns the authentication of a git repository URL for the given credential: the private
+// key, or else the SSH agent, for SSH URLs, the token otherwise
+func gitAuth(repoURL string, cred config.GitCredential) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Protocol == "ssh" {
+		username := sshUser(endpoint, cred.Username)
+		if cred.SSHKey != "" {
+			return gitssh.NewPublicKeysFromFile(username, cred.SSHKey, cred.SSHKeyPassphrase)
+		}
+		if os.Getenv(sshAgentEnv) == "" {
+			return nil, fmt.Errorf("no sshKey configured for %s, and no SSH agent is running", cred.Host)
+		}
+		return gitssh.NewSSHAgentAuth(username)
+	}
+	if cred.Token == "" {
+		return nil, fmt.Errorf("no token configured for %s", cred.Host)
+	}
+	username := cred.Username
+	if username == "" {
+		username = "git"
+	}
+	return &githttp.BasicAuth{Username: username, Password: cred.Token}, nil
+}
+
+// sshUser returns the user authenticating to an SSH endpoint: the configured username, or else the
+// user of the URL, e.g. git in git@github.com:org/repo.git, defaulting to git
+func sshUser(endpoint *transport.Endpoint, username string) string {
+	switch {
+	case username != "":
+		return username
+	case endpoint.User != "":
+		return endpoint.User
+	default:
+		return "git"
+	}
+}
+
+// GitRepoCache shares the checkouts of git repositories between the resolutions of the Tasks and
+// Pipelines of a run, keyed by URL and revision, so that a repository is cloned once per revision
+// however many files are resolved from it. Checkouts of commits are also kept in a directory, if
+// one is given, which later runs reuse; branches and tags move, so they are cloned again.
+type GitRepoCache struct {
+	dir       string
+	mu        sync.Mutex
+	checkouts map[string]*gitCheckout
+}
+
+// gitCheckout is a checkout of a git repository, which is ready once done is closed
+type gitCheckout struct {
+	done chan struct{}
+	fs   billy.Filesystem
+	err  error
+}
+
+// NewGitRepoCache returns a GitRepoCache keeping the checkouts of commits in dir, or only in memory
+// if dir is empty
+func NewGitRepoCache(dir string) *GitRepoCache {
+	return &GitRepoCache{dir: dir, checkouts: map[string]*gitCheckout{}}
+}
+
+// newRun returns a GitRepoCache for a later run, which only shares the checkouts of commits kept in
+// the directory of c, if any
+func (c *GitRepoCache) newRun() *GitRepoCache {
+	if c == nil {
+		return nil
+	}
+	return NewGitRepoCache(c.dir)
+}
+
+// checkout returns the checkout of the revision of the git repository at repoURL, cloning it unless
+// it was already. Failed checkouts are not kept, so that they are attempted again.
+func (c *GitRepoCache) checkout(ctx context.Context, repoURL, revision string, auth transport.AuthMethod) (billy.Filesystem, error) {
+	key := repoURL + " " + revision
+	c.mu.Lock()
+	entry, ok := c.checkouts[key]
+	if !ok {
+		entry = &gitCheckout{done: make(chan struct{})}
+		c.checkouts[key] = entry
+	}
+	c.mu.Unlock()
+	if ok {
+		select {
+		case <-entry.done:
+			return entry.fs, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	entry.fs, entry.err = c.load(ctx, key, repoURL, revision, auth)
+	if entry.err != nil {
+		c.mu.Lock()
+		delete(c.checkouts, key)
+		c.mu.Unlock()
+	}
+	close(entry.done)
+	return entry.fs, entry.err
+}
+
+// load returns the checkout of a commit kept in the directory of the cache, or else clones the
+// revision, keeping the checkout in the directory if the revision is a commit
+func (c *GitRepoCache) load(ctx context.Context, key, repoURL, revision string, auth transport.AuthMethod) (billy.Filesystem, error) {
+	if c.dir == "" || !gitCommitRegex.MatchString(revision) {
+		return checkoutRevision(ctx, repoURL, revision, auth)
+	}
+	sum := sha256.Sum256([]byte(key))
+	checkoutDir := filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+	if info, err := os.Stat(checkoutDir); err == nil && info.IsDir() {
+		return osfs.New(checkoutDir), nil
+	}
+	fs, err := checkoutRevision(ctx, repoURL, revision, auth)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveCheckout(fs, checkoutDir); err != nil {
+		logging.FromContext(ctx).Warnf("⚠️  Not keeping the checkout of %s at %s: %v", repoURL, revision, err)
+	}
+	return fs, nil
+}
+
+// saveCheckout copies the files of a checkout to dir, through a temporary directory so that
+// concurrent runs never read a partial copy
+func saveCheckout(fs billy.Filesystem, dir string) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), ".checkout-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	err = util.Walk(fs, "/", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !info.Mode().IsRegular() {
+			return err
+		}
+		data, err := util.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		fname := filepath.Join(tmp, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(fname), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(fname, data, 0o644)
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		// Another run kept the same checkout first.
+		if _, statErr := os.Stat(dir); statErr == nil {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// resolveGit fetches the file at path of a git repository at the given revision, a branch, tag, or
+// commit, or the default branch if it is empty, authenticating with auth unless it is nil. The
+// checkout is shared through the GitRepoCache of the validation options, if any.
+func resolveGit(ctx context.Context, repoURL, revision, path string, auth transport.AuthMethod) ([]byte, error) {
+	var fs billy.Filesystem
+	var err error
+	if repos := optionsFromContext(ctx).GitRepos; repos != nil {
+		fs, err = repos.checkout(ctx, repoURL, revision, auth)
+	} else {
+		fs, err = checkoutRevision(ctx, repoURL, revision, auth)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file %q: %w", path, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %q: %w", path, err)
+	}
+	return data, nil
+}
+
+// fetchGitCommit returns the commit a branch or tag of the git repository at repoURL points to, or
+// its default branch if revision is empty, without cloning the repository, it is a variable so that
+// tests can stub git servers
+var fetchGitCommit = func(ctx context.Context, repoURL, revision string, auth transport.AuthMethod) (string, error) {
+	remote := gogit.NewRemote(memory.NewStorage(), &gitcfg.RemoteConfig{Name: gogit.DefaultRemoteName, URLs: []string{repoURL}})
+	refs, err := remote.ListContext(ctx, &gogit.ListOptions{Auth: auth, PeelingOption: gogit.AppendPeeled})
+	if err != nil {
+		return "", fmt.Errorf("listing references: %w", err)
+	}
+	hashes := map[plumbing.ReferenceName]string{}
+	targets := map[plumbing.ReferenceName]plumbing.ReferenceName{}
+	for _, ref := range refs {
+		if ref.Type() == plumbing.SymbolicReference {
+			targets[ref.Name()] = ref.Target()
+		} else {
+			hashes[ref.Name()] = ref.Hash().String()
+		}
+	}
+
+	names := []plumbing.ReferenceName{plumbing.HEAD}
+	if revision != "" {
+		tag := plumbing.NewTagReferenceName(revision)
+		// Annotated tags are peeled to the commit they point to.
+		names = []plumbing.ReferenceName{plumbing.NewBranchReferenceName(revision), tag + "^{}", tag}
+	}
+	for _, name := range names {
+		if target, ok := targets[name]; ok {
+			name = target
+		}
+		if hash, ok := hashes[name]; ok {
+			return hash, nil
+		}
+	}
+	if revision == "" {
+		return "", errors.New("no default branch")
+	}
+	return "", fmt.Errorf("no branch or tag %s", revision)
+}
+
+// checkoutRevision checks out the revision of the git repository at repoURL in memory. It fetches
+// the revision alone, without its history, and falls back to a full clone when the server cannot
+// send a shallow history or the revision is neither a branch, a tag, nor a full commit hash, e.g.
+// an abbreviated one.
+func checkoutRevision(ctx context.Context, repoURL, revision string, auth transport.AuthMethod) (billy.Filesystem, error) {
+	fs, err := shallowCheckout(ctx, repoURL, revision, auth)
+	if err == nil || ctx.Err() != nil || isTransientError(err) ||
+		errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+		return fs, err
+	}
+	return fullCheckout(ctx, repoURL, revision, auth)
+}
+
+// shallowCheckout checks out the revision of the git repository at repoURL fetched with a depth of 1
+func shallowCheckout(ctx context.Context, repoURL, revision string, auth transport.AuthMethod) (billy.Filesystem, error) {
+	if gitCommitRegex.MatchString(revision) {
+		fs := memfs.New()
+		repository, err := gogit.Init(memory.NewStorage(), fs)
+		if err != nil {
+			return nil, err
+		}
+		remote, err := repository.CreateRemote(&gitcfg.RemoteConfig{Name: gogit.DefaultRemoteName, URLs: []string{repoURL}})
+		if err != nil {
+			return nil, err
+		}
+		refSpec := gitcfg.RefSpec(fmt.Sprintf("%s:refs/heads/revision", revision))
+		if err := remote.FetchContext(ctx, &gogit.FetchOptions{RefSpecs: []gitcfg.RefSpec{refSpec}, Depth: 1, Auth: auth}); err != nil {
+			return nil, fmt.Errorf("fetch error: %w", err)
+		}
+		worktree, err := repository.Worktree()
+		if err != nil {
+			return nil, fmt.Errorf("worktree error: %w", err)
+		}
+		if err := worktree.Checkout(&gogit.CheckoutOptions{Hash: plumbing.NewHash(revision)}); err != nil {
+			return nil, fmt.Errorf("checkout error: %w", err)
+		}
+		return fs, nil
+	}
+
+	refs := []plumbing.ReferenceName{""}
+	if revision != "" {
+		refs = []plumbing.ReferenceName{plumbing.NewBranchReferenceName(revision), plumbing.NewTagReferenceName(revision)}
+	}
+	var err error
+	for _, ref := range refs {
+		fs := memfs.New()
+		_, err = gogit.CloneContext(ctx, memory.NewStorage(), fs, &gogit.CloneOptions{
+			URL:           repoURL,
+			Auth:          auth,
+			ReferenceName: ref,
+			SingleBranch:  true,
+			Depth:         1,
+		})
+		if err == nil {
+			return fs, nil
+		}
+		var noMatchErr gogit.NoMatchingRefSpecError
+		if !errors.As(err, &noMatchErr) && !errors.Is(err, plumbing.ErrReferenceNotFound) {
+			break
+		}
+	}
+	return nil, fmt.Errorf("clone error: %w", err)
+}
+
+// fullCheckout checks out the revision of the git repository at repoURL after cloning its whole
+// history. It mirrors the anonymous clone of the git resolver.
+func fullCheckout(ctx context.Context, repoURL, revision string, auth transport.AuthMethod) (billy.Filesystem, error) {
+	filesystem := memfs.New()
+	repository, err := gogit.CloneContext(ctx, memory.NewStorage(), filesystem, &gogit.CloneOptions{URL: repoURL, Auth: auth})
+	if err != nil {
+		return nil, fmt.Errorf("clone error: %w", err)
+	}
+	if revision == "" {
+		return filesystem, nil
+	}
+
+	// Branches other than the default one are not cloned.
+	refSpec := gitcfg.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/%s", revision, revision))
+	err = repository.FetchContext(ctx, &gogit.FetchOptions{RefSpecs: []gitcfg.RefSpec{refSpec}, Auth: auth})
+	var noMatchErr gogit.NoMatchingRefSpecError
+	if err != nil && !errors.As(err, &noMatchErr) && !errors.Is(err, gogit.NoErrAlreadyUpToDate) {
+		return nil, fmt.Errorf("unexpected fetch error: %w", err)
+	}
+
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return nil, fmt.Errorf("worktree error: %w", err)
+	}
+	hash, err := repository.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("revision error: %w", err)
+	}
+	if err := worktree.Checkout(&gogit.CheckoutOptions{Hash: *hash}); err != nil {
+		return nil, fmt.Errorf("checkout error: %w", err)
+	}
+	return filesystem, nil
+}
@@ -0,0 +1,313 @@
+package validator
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/lcarva/tektor/internal/config"
+)
+
+func TestGitAuth(t *testing.T) {
+	t.Setenv(sshAgentEnv, "")
+	tests := []struct {
+		name          string
+		repoURL       string
+		cred          config.GitCredential
+		expectedAuth  *githttp.BasicAuth
+		expectedError string
+	}{
+		{
+			name:         "token over HTTPS",
+			repoURL:      "https://github.com/org/tasks.git",
+			cred:         config.GitCredential{Host: "github.com", Token: "secret"},
+			expectedAuth: &githttp.BasicAuth{Username: "git", Password: "secret"},
+		},
+		{
+			name:         "token with a username",
+			repoURL:      "https://gitlab.com/org/tasks.git",
+			cred:         config.GitCredential{Host: "gitlab.com", Username: "oauth2", Token: "secret"},
+			expectedAuth: &githttp.BasicAuth{Username: "oauth2", Password: "secret"},
+		},
+		{
+			name:          "missing token",
+			repoURL:       "https://github.com/org/tasks.git",
+			cred:          config.GitCredential{Host: "github.com", SSHKey: "/tmp/id_ed25519"},
+			expectedError: "no token configured for github.com",
+		},
+		{
+			name:          "missing SSH key",
+			repoURL:       "git@github.com:org/tasks.git",
+			cred:          config.GitCredential{Host: "github.com", Token: "secret"},
+			expectedError: "no sshKey configured for github.com, and no SSH agent is running",
+		},
+		{
+			name:          "unreadable SSH key",
+			repoURL:       "ssh://git@github.com/org/tasks.git",
+			cred:          config.GitCredential{Host: "github.com", SSHKey: "/does/not/exist"},
+			expectedError: "/does/not/exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := gitAuth(tt.repoURL, tt.cred)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAuth, auth)
+		})
+	}
+}
+
+// startSSHAgent serves an SSH agent holding no key for the duration of a test, and points
+// SSH_AUTH_SOCK to it
+func startSSHAgent(t *testing.T) {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	keyring := agent.NewKeyring()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_ = agent.ServeAgent(keyring, conn)
+			}()
+		}
+	}()
+	t.Setenv(sshAgentEnv, socket)
+}
+
+func TestGitAuthWithSSHAgent(t *testing.T) {
+	startSSHAgent(t)
+
+	auth, err := gitAuth("deploy@gitlab.example.com:org/tasks.git", config.GitCredential{Host: "gitlab.example.com"})
+	require.NoError(t, err)
+	require.IsType(t, &gitssh.PublicKeysCallback{}, auth)
+	assert.Equal(t, "deploy", auth.(*gitssh.PublicKeysCallback).User)
+
+	auth, err = gitAuth("ssh://git@github.com/org/tasks.git", config.GitCredential{Host: "github.com", Username: "bot"})
+	require.NoError(t, err)
+	assert.Equal(t, "bot", auth.(*gitssh.PublicKeysCallback).User)
+}
+
+func TestRepoGitAuth(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "from-env")
+	t.Setenv("GITLAB_TOKEN", "")
+	t.Setenv(sshAgentEnv, "")
+	configured := WithOptions(context.Background(), Options{Credentials: config.Credentials{
+		Git: []config.GitCredential{{Host: "github.com", Token: "from-config"}},
+	}})
+	envTokens := WithOptions(context.Background(), Options{GitEnvTokens: true})
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		repoURL       string
+		expectedAuth  transport.AuthMethod
+		expectedError string
+	}{
+		{
+			name:         "configured credential",
+			ctx:          configured,
+			repoURL:      "https://github.com/org/tasks.git",
+			expectedAuth: &githttp.BasicAuth{Username: "git", Password: "from-config"},
+		},
+		{
+			name:          "invalid configured credential",
+			ctx:           configured,
+			repoURL:       "git@github.com:org/tasks.git",
+			expectedError: "credentials of github.com: no sshKey configured for github.com",
+		},
+		{
+			name:         "token of the environment",
+			ctx:          envTokens,
+			repoURL:      "https://github.com/org/tasks.git",
+			expectedAuth: &githttp.BasicAuth{Username: "git", Password: "from-env"},
+		},
+		{
+			name:    "token of the environment not enabled",
+			ctx:     context.Background(),
+			repoURL: "https://github.com/org/tasks.git",
+		},
+		{
+			name:    "token of the environment for untrusted input",
+			ctx:     WithUntrustedInput(envTokens),
+			repoURL: "https://github.com/org/tasks.git",
+		},
+		{
+			name:    "unset token of the environment",
+			ctx:     envTokens,
+			repoURL: "https://gitlab.com/org/tasks.git",
+		},
+		{
+			name:    "other host",
+			ctx:     context.Background(),
+			repoURL: "https://git.example.com/org/tasks.git",
+		},
+		{
+			name:    "SSH without agent",
+			ctx:     context.Background(),
+			repoURL: "git@github.com:org/tasks.git",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := repoGitAuth(tt.ctx, tt.repoURL)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAuth, auth)
+		})
+	}
+
+	startSSHAgent(t)
+	auth, err := repoGitAuth(context.Background(), "git@github.com:org/tasks.git")
+	require.NoError(t, err)
+	require.IsType(t, &gitssh.PublicKeysCallback{}, auth)
+	assert.Equal(t, "git", auth.(*gitssh.PublicKeysCallback).User)
+
+	auth, err = repoGitAuth(WithUntrustedInput(context.Background()), "git@github.com:org/tasks.git")
+	require.NoError(t, err)
+	assert.Nil(t, auth, "Expected untrusted input not to use the SSH agent")
+}
+
+func TestResolveGit(t *testing.T) {
+	dir := t.TempDir()
+	repository, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repository.Worktree()
+	require.NoError(t, err)
+	commit := func(content string) plumbing.Hash {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "task.yaml"), []byte(content), 0o644))
+		_, err := worktree.Add("task.yaml")
+		require.NoError(t, err)
+		hash, err := worktree.Commit(content, &gogit.CommitOptions{
+			Author: &object.Signature{Name: "tektor", Email: "tektor@example.com", When: time.Now()},
+		})
+		require.NoError(t, err)
+		return hash
+	}
+	first := commit("kind: Task")
+	_, err = repository.CreateTag("v0.1", first, nil)
+	require.NoError(t, err)
+	commit("kind: Pipeline")
+	head, err := repository.Head()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		revision      string
+		path          string
+		expected      string
+		expectedError string
+	}{
+		{name: "branch", revision: head.Name().Short(), path: "task.yaml", expected: "kind: Pipeline"},
+		{name: "default branch", path: "task.yaml", expected: "kind: Pipeline"},
+		{name: "tag", revision: "v0.1", path: "task.yaml", expected: "kind: Task"},
+		{name: "commit", revision: first.String(), path: "task.yaml", expected: "kind: Task"},
+		{name: "abbreviated commit", revision: first.String()[:7], path: "task.yaml", expected: "kind: Task"},
+		{name: "missing file", revision: first.String(), path: "pipeline.yaml", expectedError: `error opening file "pipeline.yaml"`},
+		{name: "missing revision", revision: "nope", path: "task.yaml", expectedError: "revision error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := resolveGit(context.Background(), dir, tt.revision, tt.path, nil)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(data))
+		})
+	}
+}
+
+// initGitRepo creates a git repository in dir with a commit adding files, and returns the commit and
+// the name of its branch
+func initGitRepo(t *testing.T, dir string, files map[string]string) (plumbing.Hash, string) {
+	t.Helper()
+	repository, err := gogit.PlainInit(dir, false)
+	require.NoError(t, err)
+	worktree, err := repository.Worktree()
+	require.NoError(t, err)
+	for fname, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fname), []byte(content), 0o644))
+		_, err = worktree.Add(fname)
+		require.NoError(t, err)
+	}
+	commit, err := worktree.Commit("tasks", &gogit.CommitOptions{
+		Author: &object.Signature{Name: "tektor", Email: "tektor@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	head, err := repository.Head()
+	require.NoError(t, err)
+	return commit, head.Name().Short()
+}
+
+func TestGitRepoCache(t *testing.T) {
+	files := map[string]string{"build.yaml": "name: build", "test.yaml": "name: test"}
+	dir := t.TempDir()
+	commit, branch := initGitRepo(t, dir, files)
+
+	// The repository is cloned once per revision, so the files of a checkout are resolved even once the
+	// repository is gone.
+	cacheDir := t.TempDir()
+	ctx := WithOptions(context.Background(), Options{GitRepos: NewGitRepoCache(cacheDir)})
+	for _, revision := range []string{branch, commit.String()} {
+		data, err := resolveGit(ctx, dir, revision, "build.yaml", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "name: build", string(data))
+	}
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, ".git")))
+	for _, revision := range []string{branch, commit.String()} {
+		data, err := resolveGit(ctx, dir, revision, "test.yaml", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "name: test", string(data))
+	}
+
+	// Later runs only reuse the checkouts of commits.
+	ctx = WithNewRun(ctx)
+	data, err := resolveGit(ctx, dir, commit.String(), "test.yaml", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "name: test", string(data))
+	_, err = resolveGit(ctx, dir, branch, "test.yaml", nil)
+	assert.ErrorContains(t, err, "clone error")
+
+	// Failed checkouts are attempted again.
+	later := filepath.Join(t.TempDir(), "later")
+	_, err = resolveGit(ctx, later, "", "build.yaml", nil)
+	assert.ErrorContains(t, err, "clone error")
+	require.NoError(t, os.Mkdir(later, 0o755))
+	initGitRepo(t, later, files)
+	data, err = resolveGit(ctx, later, "", "build.yaml", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "name: build", string(data))
+}
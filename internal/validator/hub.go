@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/resolution/resolver/framework"
+	"github.com/tektoncd/pipeline/pkg/resolution/resolver/hub"
+)
+
+// DefaultTektonHubURL is the URL of the public Tekton Hub API, used by the hub resolver for
+// references of the tekton type
+const DefaultTektonHubURL = "https://api.hub.tekton.dev"
+
+// hubResolverConfig returns the configuration of the hub resolver as installed by default with
+// Tekton. References which do not set a kind default to the kind being resolved.
+func hubResolverConfig(kind string) map[string]string {
+	return map[string]string{
+		hub.ConfigType:                       hub.ArtifactHubType,
+		hub.ConfigKind:                       kind,
+		hub.ConfigTektonHubCatalog:           "Tekton",
+		hub.ConfigArtifactHubTaskCatalog:     "tekton-catalog-tasks",
+		hub.ConfigArtifactHubPipelineCatalog: "tekton-catalog-pipelines",
+	}
+}
+
+// resolveHubResource fetches the definition of a task or pipeline, as told by kind, from Artifact
+// Hub or Tekton Hub. The HubURL option, if set, overrides the URL of either hub.
+func resolveHubResource(ctx context.Context, kind string, params v1.Params) (string, []byte, error) {
+	artifactHubURL, tektonHubURL := hub.DefaultArtifactHubURL, DefaultTektonHubURL
+	if url := optionsFromContext(ctx).HubURL; url != "" {
+		artifactHubURL, tektonHubURL = url, url
+	}
+
+	ctx = framework.InjectResolverConfigToContext(ctx, hubResolverConfig(kind))
+	data, err := resolveWithRetries(ctx, func(ctx context.Context) ([]byte, error) {
+		resolvedResource, err := hub.Resolve(ctx, params, tektonHubURL, artifactHubURL)
+		if err != nil {
+			return nil, err
+		}
+		return resolvedResource.Data(), nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to resolve %s from hub: %w", kind, err)
+	}
+	return fmt.Sprintf("hub %s %s", getParamValue(params, hub.ParamName), getParamValue(params, hub.ParamVersion)), data, nil
+}
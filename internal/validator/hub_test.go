@@ -0,0 +1,160 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const hubGitCloneTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: git-clone
+spec:
+  params:
+    - name: url
+      type: string
+  results:
+    - name: commit
+  steps:
+    - name: clone
+      image: alpine:latest
+      script: git clone $(params.url) && git rev-parse HEAD > $(results.commit.path)
+`
+
+// newArtifactHub serves the git-clone Task from the tekton-catalog-tasks catalog like Artifact Hub
+func newArtifactHub(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/packages/tekton-task/tekton-catalog-tasks/git-clone", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"available_versions": []map[string]any{{"version": "0.9.0"}, {"version": "0.10.0-rc1", "prerelease": true}},
+		})
+	})
+	mux.HandleFunc("/api/v1/packages/tekton-task/tekton-catalog-tasks/git-clone/0.9.0", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"manifestRaw": hubGitCloneTask}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestValidatePipelineWithHubResolver(t *testing.T) {
+	ctx := WithOptions(context.Background(), Options{HubURL: newArtifactHub(t).URL})
+
+	tests := []struct {
+		name           string
+		pipelineYAML   string
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "task from hub",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: clone
+spec:
+  results:
+    - name: commit
+      value: $(tasks.clone.results.commit)
+  tasks:
+    - name: clone
+      params:
+        - name: url
+          value: https://github.com/example/repo.git
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: git-clone
+          - name: version
+            value: "0.9"
+`,
+			expectNoError: true,
+		},
+		{
+			name: "params and results validated against the task from hub",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: clone
+spec:
+  results:
+    - name: digest
+      value: $(tasks.clone.results.digest)
+  tasks:
+    - name: clone
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: git-clone
+          - name: version
+            value: "0.9.0"
+`,
+			expectedErrors: []string{`"url" parameter is required`, "non-existent digest result from clone PipelineTask"},
+		},
+		{
+			name: "unknown task",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: clone
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: buildah
+          - name: version
+            value: "0.9.0"
+`,
+			expectedErrors: []string{"retrieving task spec from clone pipeline task: failed to resolve task from hub: fail to fetch Artifact Hub resource: requested resource"},
+		},
+		{
+			name: "missing version",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: clone
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: git-clone
+`,
+			expectedErrors: []string{"missing required hub resolver params: version"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := pipelineFromYAML(tt.pipelineYAML)
+			require.NoError(t, err)
+
+			err = ValidatePipeline(ctx, p)
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
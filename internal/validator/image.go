@@ -0,0 +1,121 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// knownBuilderImages lists images which only work when their own entrypoint is executed. Steps
+// using them must pass arguments via args rather than replace the entrypoint with command.
+var knownBuilderImages = []string{
+	"gcr.io/kaniko-project/executor",
+	"moby/buildkit",
+}
+
+// fetchImageConfig retrieves the config of an image. It is a variable so tests can avoid
+// reaching out to a registry.
+var fetchImageConfig = func(ctx context.Context, ref name.Reference) (*ggcrv1.Config, error) {
+	img, err := remote.Image(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(keychain(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg.Config, nil
+}
+
+// imageConfigCache avoids fetching the same image more than once per process
+var imageConfigCache sync.Map
+
+type imageConfigResult struct {
+	config *ggcrv1.Config
+	err    error
+}
+
+// ValidateStepImages fetches the config of every step image and checks that the step can
+// actually be started with it. Findings are reported as warnings since image metadata may change
+// independently of the Task definition.
+func ValidateStepImages(ctx context.Context, taskSpec v1.TaskSpec) error {
+	var err error
+
+	for i, step := range taskSpec.Steps {
+		stepName := step.Name
+		if stepName == "" {
+			stepName = fmt.Sprintf("#%d", i)
+		}
+
+		image := step.Image
+		if image == "" && taskSpec.StepTemplate != nil {
+			image = taskSpec.StepTemplate.Image
+		}
+		// Images which depend on variable substitution can only be checked at runtime.
+		if image == "" || strings.Contains(image, "$(") {
+			continue
+		}
+
+		ref, parseErr := name.ParseReference(image)
+		if parseErr != nil {
+			err = multierror.Append(err, fmt.Errorf("step %s: invalid image reference %q: %w", stepName, image, parseErr))
+			continue
+		}
+
+		cfg, fetchErr := getImageConfig(ctx, ref)
+		if fetchErr != nil {
+			err = multierror.Append(err, warningf("step %s: unable to fetch config of image %q: %s", stepName, image, fetchErr))
+			continue
+		}
+
+		if step.Script == "" && len(step.Command) == 0 && len(cfg.Entrypoint) == 0 && len(cfg.Cmd) == 0 {
+			err = multierror.Append(err, warningf(
+				"step %s: provides neither script nor command and image %q has no entrypoint", stepName, image))
+		}
+
+		if len(step.Command) > 0 && len(cfg.Entrypoint) > 0 && isKnownBuilderImage(ref) &&
+			!slices.Equal(step.Command, cfg.Entrypoint) {
+			err = multierror.Append(err, warningf(
+				"step %s: command %v overrides the entrypoint %v required by builder image %q, use args instead",
+				stepName, step.Command, cfg.Entrypoint, image))
+		}
+	}
+
+	return err
+}
+
+// getImageConfig returns the config of an image, using the per process cache when possible
+func getImageConfig(ctx context.Context, ref name.Reference) (*ggcrv1.Config, error) {
+	key := ref.String()
+	if cached, ok := imageConfigCache.Load(key); ok {
+		result := cached.(imageConfigResult)
+		return result.config, result.err
+	}
+
+	cfg, err := fetchImageConfig(ctx, ref)
+	imageConfigCache.Store(key, imageConfigResult{config: cfg, err: err})
+	return cfg, err
+}
+
+// isKnownBuilderImage reports whether ref points to one of knownBuilderImages
+func isKnownBuilderImage(ref name.Reference) bool {
+	repository := ref.Context().Name()
+	for _, known := range knownBuilderImages {
+		knownRepository, err := name.NewRepository(known)
+		if err != nil {
+			continue
+		}
+		if knownRepository.Name() == repository {
+			return true
+		}
+	}
+	return false
+}
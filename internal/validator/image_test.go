@@ -0,0 +1,179 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrv1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/stretchr/testify/assert"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// useFakeImageConfigs replaces image config retrieval with a static set of configs for the
+// duration of a test
+func useFakeImageConfigs(t *testing.T, configs map[string]*ggcrv1.Config) {
+	t.Helper()
+	original := fetchImageConfig
+	fetchImageConfig = func(_ context.Context, ref name.Reference) (*ggcrv1.Config, error) {
+		if cfg, ok := configs[ref.String()]; ok {
+			return cfg, nil
+		}
+		return nil, errors.New("image not found")
+	}
+	imageConfigCache = sync.Map{}
+	t.Cleanup(func() {
+		fetchImageConfig = original
+		imageConfigCache = sync.Map{}
+	})
+}
+
+func TestValidateStepImages(t *testing.T) {
+	useFakeImageConfigs(t, map[string]*ggcrv1.Config{
+		"scratch-tool:latest":                    {},
+		"alpine:latest":                          {Cmd: []string{"/bin/sh"}},
+		"gcr.io/kaniko-project/executor:v1.23.2": {Entrypoint: []string{"/kaniko/executor"}},
+		"registry.local/entrypoint-only:1.0":     {Entrypoint: []string{"/usr/bin/tool"}},
+		"moby/buildkit:v0.16.0-rootless":         {Entrypoint: []string{"rootlesskit", "buildkitd"}},
+	})
+
+	tests := []struct {
+		name             string
+		taskSpec         v1.TaskSpec
+		expectedWarnings []string
+		expectedErrors   []string
+		expectNoError    bool
+	}{
+		{
+			name: "steps with script, command, or image defaults",
+			taskSpec: v1.TaskSpec{
+				Steps: []v1.Step{
+					{Name: "script", Image: "scratch-tool:latest", Script: "echo hi"},
+					{Name: "command", Image: "scratch-tool:latest", Command: []string{"/tool"}},
+					{Name: "cmd", Image: "alpine:latest"},
+					{Name: "entrypoint", Image: "registry.local/entrypoint-only:1.0"},
+					{Name: "builder-args", Image: "gcr.io/kaniko-project/executor:v1.23.2", Args: []string{"--no-push"}},
+				},
+			},
+			expectNoError: true,
+		},
+		{
+			name: "step without script, command, or image entrypoint",
+			taskSpec: v1.TaskSpec{
+				Steps: []v1.Step{
+					{Name: "noop", Image: "scratch-tool:latest"},
+				},
+			},
+			expectedWarnings: []string{
+				`step noop: provides neither script nor command and image "scratch-tool:latest" has no entrypoint`,
+			},
+		},
+		{
+			name: "image inherited from stepTemplate",
+			taskSpec: v1.TaskSpec{
+				StepTemplate: &v1.StepTemplate{Image: "scratch-tool:latest"},
+				Steps:        []v1.Step{{}},
+			},
+			expectedWarnings: []string{
+				`step #0: provides neither script nor command and image "scratch-tool:latest" has no entrypoint`,
+			},
+		},
+		{
+			name: "command overrides builder entrypoint",
+			taskSpec: v1.TaskSpec{
+				Steps: []v1.Step{
+					{Name: "build", Image: "gcr.io/kaniko-project/executor:v1.23.2", Command: []string{"/busybox/sh"}},
+					{Name: "buildkit", Image: "moby/buildkit:v0.16.0-rootless", Command: []string{"buildctl"}},
+				},
+			},
+			expectedWarnings: []string{
+				`step build: command [/busybox/sh] overrides the entrypoint [/kaniko/executor] required by builder image "gcr.io/kaniko-project/executor:v1.23.2", use args instead`,
+				`step buildkit: command [buildctl] overrides the entrypoint [rootlesskit buildkitd] required by builder image "moby/buildkit:v0.16.0-rootless", use args instead`,
+			},
+		},
+		{
+			name: "command overrides entrypoint of other images",
+			taskSpec: v1.TaskSpec{
+				Steps: []v1.Step{
+					{Name: "tool", Image: "registry.local/entrypoint-only:1.0", Command: []string{"/bin/sh"}},
+				},
+			},
+			expectNoError: true,
+		},
+		{
+			name: "images with variables are skipped",
+			taskSpec: v1.TaskSpec{
+				Steps: []v1.Step{
+					{Name: "dynamic", Image: "$(params.image)"},
+				},
+			},
+			expectNoError: true,
+		},
+		{
+			name: "unreachable image",
+			taskSpec: v1.TaskSpec{
+				Steps: []v1.Step{
+					{Name: "missing", Image: "registry.local/missing:1.0", Script: "echo hi"},
+				},
+			},
+			expectedWarnings: []string{
+				`step missing: unable to fetch config of image "registry.local/missing:1.0": image not found`,
+			},
+		},
+		{
+			name: "invalid image reference",
+			taskSpec: v1.TaskSpec{
+				Steps: []v1.Step{
+					{Name: "invalid", Image: "UPPERCASE:latest", Script: "echo hi"},
+				},
+			},
+			expectedErrors: []string{`step invalid: invalid image reference "UPPERCASE:latest"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStepImages(context.Background(), tt.taskSpec)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, WithoutWarnings(err), "Expected only warnings for test case: %s", tt.name)
+			}
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, WithoutWarnings(err).Error(), expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidateTaskV1WithCheckImages(t *testing.T) {
+	useFakeImageConfigs(t, map[string]*ggcrv1.Config{
+		"scratch-tool:latest": {},
+	})
+
+	task, err := taskFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: noop
+spec:
+  steps:
+    - name: noop
+      image: scratch-tool:latest
+`)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ValidateTaskV1(context.Background(), task), "Expected image checks to be disabled by default")
+
+	ctx := WithOptions(context.Background(), Options{CheckImages: true})
+	assert.Equal(t,
+		[]string{`image validation: step noop: provides neither script nor command and image "scratch-tool:latest" has no entrypoint`},
+		Warnings(ValidateTaskV1(ctx, task)))
+}
@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// InlinePipelineRun returns a copy of a PipelineRun with the Pipeline it refers to embedded as its
+// pipelineSpec, and the Tasks its PipelineTasks refer to embedded as their taskSpec. References are
+// resolved like during validation, through the bundles, git, and hub resolvers, or by name from the
+// TaskIndex of the validation options. Custom Tasks and child Pipelines are left as they are.
+func InlinePipelineRun(ctx context.Context, pr v1.PipelineRun, runtimeParams map[string]string) (v1.PipelineRun, error) {
+	pr = *pr.DeepCopy()
+	if pr.Spec.PipelineSpec == nil && pr.Spec.PipelineRef != nil {
+		pipelineSpec, err := pipelineSpecFromPipelineTask(ctx, v1.PipelineTask{PipelineRef: pr.Spec.PipelineRef}, nil, runtimeParams)
+		if err != nil {
+			return pr, fmt.Errorf("resolving the pipelineRef of the PipelineRun: %w", err)
+		}
+		pr.Spec.PipelineSpec = pipelineSpec.DeepCopy()
+		pr.Spec.PipelineRef = nil
+	}
+	if pr.Spec.PipelineSpec == nil {
+		return pr, nil
+	}
+
+	var allErrors error
+	pipelineSpec := pr.Spec.PipelineSpec
+	for _, section := range pipelineTaskSections(*pipelineSpec) {
+		for i := range section.pipelineTasks {
+			pipelineTask := &section.pipelineTasks[i]
+			// Custom Tasks are referred to by apiVersion and kind.
+			if pipelineTask.TaskRef == nil || pipelineTask.TaskRef.APIVersion != "" {
+				continue
+			}
+			taskSpec, err := taskResolverFromContext(ctx).ResolveTask(ctx, *pipelineTask.TaskRef, pipelineSpec.Params, runtimeParams)
+			if err != nil {
+				allErrors = multierror.Append(allErrors, fmt.Errorf("resolving the taskRef of the %s PipelineTask: %w", pipelineTask.Name, err))
+				continue
+			}
+			pipelineTask.TaskSpec = &v1.EmbeddedTask{TaskSpec: *taskSpec.DeepCopy()}
+			pipelineTask.TaskRef = nil
+		}
+	}
+	return pr, allErrors
+}
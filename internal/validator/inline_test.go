@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/lcarva/tektor/internal/taskindex"
+)
+
+func TestInlinePipelineRun(t *testing.T) {
+	build := v1.TaskSpec{Steps: []v1.Step{{Name: "build", Image: "alpine:latest"}}}
+	fake := TaskResolverFunc(func(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error) {
+		if ref.Name == "build" {
+			return &build, nil
+		}
+		return nil, fmt.Errorf("task %q not found", ref.Name)
+	})
+	index := taskindex.New()
+	index.Add(taskindex.Entry{
+		Kind:   "Pipeline",
+		Name:   "release",
+		Source: "pipelines/release.yaml:1 (Pipeline release)",
+		PipelineSpec: v1.PipelineSpec{
+			Tasks: []v1.PipelineTask{
+				{Name: "build", TaskRef: &v1.TaskRef{Name: "build"}},
+				{Name: "approve", TaskRef: &v1.TaskRef{APIVersion: "example.dev/v1", Kind: "Approval"}},
+			},
+			Finally: []v1.PipelineTask{{Name: "notify", TaskSpec: &v1.EmbeddedTask{TaskSpec: build}}},
+		},
+	})
+	ctx := WithOptions(context.Background(), Options{TaskResolver: fake, TaskIndex: index})
+
+	pr := v1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run"},
+		Spec:       v1.PipelineRunSpec{PipelineRef: &v1.PipelineRef{Name: "release"}},
+	}
+	inlined, err := InlinePipelineRun(ctx, pr, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, pr.Spec.PipelineRef, "Expected the PipelineRun to be left untouched")
+	assert.Nil(t, inlined.Spec.PipelineRef)
+	require.NotNil(t, inlined.Spec.PipelineSpec)
+	assert.Equal(t, []v1.PipelineTask{
+		{Name: "build", TaskSpec: &v1.EmbeddedTask{TaskSpec: build}},
+		{Name: "approve", TaskRef: &v1.TaskRef{APIVersion: "example.dev/v1", Kind: "Approval"}},
+	}, inlined.Spec.PipelineSpec.Tasks)
+	assert.Equal(t, []v1.PipelineTask{{Name: "notify", TaskSpec: &v1.EmbeddedTask{TaskSpec: build}}}, inlined.Spec.PipelineSpec.Finally)
+	entry, err := index.Lookup("Pipeline", "release")
+	require.NoError(t, err)
+	assert.NotNil(t, entry.PipelineSpec.Tasks[0].TaskRef, "Expected the indexed Pipeline to be left untouched")
+
+	_, err = InlinePipelineRun(ctx, v1.PipelineRun{Spec: v1.PipelineRunSpec{PipelineRef: &v1.PipelineRef{Name: "missing"}}}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "resolving the pipelineRef of the PipelineRun")
+
+	_, err = InlinePipelineRun(ctx, v1.PipelineRun{Spec: v1.PipelineRunSpec{PipelineSpec: &v1.PipelineSpec{
+		Tasks: []v1.PipelineTask{{Name: "deploy", TaskRef: &v1.TaskRef{Name: "deploy"}}},
+	}}}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `resolving the taskRef of the deploy PipelineTask: task "deploy" not found`)
+}
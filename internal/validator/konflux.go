@@ -0,0 +1,353 @@
+package validator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// konfluxRequiredResult is a Pipeline result that Enterprise Contract and the Konflux release
+// tooling expect from build pipelines
+type konfluxRequiredResult struct {
+	Name         string // Name of the Pipeline result
+	TaskResult   string // Name of the Task result which usually supplies the value
+	ExpectedTask string // Name of the PipelineTask which conventionally produces TaskResult
+}
+
+var konfluxRequiredResults = []konfluxRequiredResult{
+	{Name: "IMAGE_URL", TaskResult: "IMAGE_URL", ExpectedTask: "build-container"},
+	{Name: "IMAGE_DIGEST", TaskResult: "IMAGE_DIGEST", ExpectedTask: "build-container"},
+	{Name: "CHAINS-GIT_URL", TaskResult: "url", ExpectedTask: "clone-repository"},
+	{Name: "CHAINS-GIT_COMMIT", TaskResult: "commit", ExpectedTask: "clone-repository"},
+}
+
+// ValidateKonfluxBuildResults verifies that a Konflux build pipeline declares the Pipeline results
+// required by Enterprise Contract and the release tooling. Pipelines that do not build an image
+// are ignored.
+func ValidateKonfluxBuildResults(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec) error {
+	if !isKonfluxBuildPipeline(pipelineSpec, allTaskSpecs) {
+		return nil
+	}
+
+	declared := make(map[string]bool)
+	for _, result := range pipelineSpec.Results {
+		declared[result.Name] = true
+	}
+
+	var err error
+	for _, required := range konfluxRequiredResults {
+		if declared[required.Name] {
+			continue
+		}
+
+		suppliers := pipelineTasksProducing(required.TaskResult, allTaskSpecs)
+		if len(suppliers) == 0 {
+			err = multierror.Append(err, fmt.Errorf(
+				"build pipeline must declare the %s result, but no PipelineTask produces a %s result (usually the %s task)",
+				required.Name, required.TaskResult, required.ExpectedTask))
+			continue
+		}
+
+		supplier := suppliers[0]
+		for _, name := range suppliers {
+			if name == required.ExpectedTask {
+				supplier = name
+				break
+			}
+		}
+		err = multierror.Append(err, fmt.Errorf(
+			"build pipeline must declare the %s result, e.g. with value $(tasks.%s.results.%s)",
+			required.Name, supplier, required.TaskResult))
+	}
+
+	return err
+}
+
+// isKonfluxBuildPipeline reports whether the pipeline builds a container image, i.e. one of its
+// PipelineTasks produces an image result or follows the Konflux naming of build tasks
+func isKonfluxBuildPipeline(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec) bool {
+	for _, pipelineTask := range pipelineSpec.Tasks {
+		if pipelineTask.Name == "build-container" {
+			return true
+		}
+	}
+	return len(pipelineTasksProducing("IMAGE_DIGEST", allTaskSpecs)) > 0 ||
+		len(pipelineTasksProducing("IMAGE_URL", allTaskSpecs)) > 0
+}
+
+// pipelineTasksProducing returns the names of the PipelineTasks whose Task declares the given
+// result, sorted by name
+func pipelineTasksProducing(resultName string, allTaskSpecs map[string]*v1.TaskSpec) []string {
+	var names []string
+	for pipelineTaskName, taskSpec := range allTaskSpecs {
+		for _, result := range taskSpec.Results {
+			if result.Name == resultName {
+				names = append(names, pipelineTaskName)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// konfluxRequiredLabels are the labels Konflux relies on to attribute a PipelineRun to the
+// application and component it builds
+var konfluxRequiredLabels = []string{
+	"appstudio.openshift.io/application",
+	"appstudio.openshift.io/component",
+	"pipelines.appstudio.openshift.io/type",
+}
+
+// konfluxTriggerAnnotations are the Pipelines as Code annotations, one of which triggers a
+// PipelineRun on events of the repository
+var konfluxTriggerAnnotations = []string{
+	"pipelinesascode.tekton.dev/on-cel-expression",
+	"pipelinesascode.tekton.dev/on-event",
+}
+
+// ValidateKonfluxPipelineRunMetadata verifies that a PipelineRun carries the labels Konflux relies on,
+// and warns about PipelineRuns which Pipelines as Code never triggers
+func ValidateKonfluxPipelineRunMetadata(metadata metav1.ObjectMeta) error {
+	var err error
+	for _, label := range konfluxRequiredLabels {
+		if metadata.Labels[label] == "" {
+			err = multierror.Append(err, fmt.Errorf("PipelineRun must set the %s label: metadata.labels", label))
+		}
+	}
+	triggered := false
+	for _, annotation := range konfluxTriggerAnnotations {
+		if metadata.Annotations[annotation] != "" {
+			triggered = true
+		}
+	}
+	if !triggered {
+		err = multierror.Append(err, warningf("PipelineRun sets neither the %s nor the %s annotation, so Pipelines as Code never triggers it: metadata.annotations",
+			konfluxTriggerAnnotations[0], konfluxTriggerAnnotations[1]))
+	}
+	return err
+}
+
+// konfluxSourceArtifactParam is the param of some trusted artifact Tasks taking the source
+// artifact, to be passed the SOURCE_ARTIFACT result
+const konfluxSourceArtifactParam = "sourceArtifact"
+
+// konfluxOCIStorageParam is the param of trusted artifact Tasks naming the repository their
+// artifacts are pushed to
+const konfluxOCIStorageParam = "ociStorage"
+
+// trustedArtifactResult returns the name of the result a trusted artifact param is passed, and
+// whether the param carries a trusted artifact
+func trustedArtifactResult(param string) (string, bool) {
+	if param == konfluxSourceArtifactParam {
+		return "SOURCE_ARTIFACT", true
+	}
+	return param, strings.HasSuffix(param, "_ARTIFACT")
+}
+
+// ValidateKonfluxTrustedArtifacts verifies that the params of PipelineTasks carrying trusted
+// artifacts, i.e. whose name ends with _ARTIFACT or sourceArtifact, are passed the result of the
+// same name of another PipelineTask, so that the artifact is the one produced within the Pipeline.
+// The resolved Tasks, by PipelineTask name, tell moreover which trusted artifact params a
+// PipelineTask leaves out although the Pipeline produces their artifact, and which PipelineTasks
+// produce artifacts without an ociStorage to push them to. PipelineTasks whose Task is not resolved
+// are only checked for the params they pass. path is the one of the pipeline spec, e.g.
+// spec.pipelineSpec for a PipelineRun embedding it.
+func ValidateKonfluxTrustedArtifacts(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec, path string) error {
+	// The PipelineTasks producing each trusted artifact, by result name
+	producers := map[string][]string{}
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		if taskSpec := allTaskSpecs[pipelineTask.Name]; taskSpec != nil {
+			for _, artifact := range producedArtifacts(*taskSpec) {
+				producers[artifact] = append(producers[artifact], pipelineTask.Name)
+			}
+		}
+	}
+
+	var err error
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		for i, pipelineTask := range section.pipelineTasks {
+			paramsPath := fmt.Sprintf("%s.%s[%d].params", path, section.name, i)
+			for _, param := range pipelineTask.Params {
+				expected, ok := trustedArtifactResult(param.Name)
+				if !ok {
+					continue
+				}
+				expressions, _ := param.GetVarSubstitutionExpressions()
+				refs := v1.NewResultRefs(expressions)
+				if len(refs) != 1 || param.Value.StringVal != fmt.Sprintf("$(tasks.%s.results.%s)", refs[0].PipelineTask, refs[0].Result) {
+					err = multierror.Append(err, fmt.Errorf(
+						"%s PipelineTask must pass the %s trusted artifact param a result of another PipelineTask, e.g. $(tasks.prefetch-dependencies.results.%s), not %q",
+						pipelineTask.Name, param.Name, expected, param.Value.StringVal))
+					continue
+				}
+				if refs[0].Result != expected {
+					err = multierror.Append(err, fmt.Errorf(
+						"%s PipelineTask passes the %s result of the %s PipelineTask to the %s trusted artifact param, expected the %s result",
+						pipelineTask.Name, refs[0].Result, refs[0].PipelineTask, param.Name, expected))
+				}
+			}
+
+			taskSpec := allTaskSpecs[pipelineTask.Name]
+			if taskSpec == nil {
+				continue
+			}
+			for _, paramSpec := range taskSpec.Params {
+				expected, ok := trustedArtifactResult(paramSpec.Name)
+				if !ok || getParamValue(pipelineTask.Params, paramSpec.Name) != "" {
+					continue
+				}
+				others := slices.DeleteFunc(slices.Clone(producers[expected]), func(producer string) bool { return producer == pipelineTask.Name })
+				if len(others) > 0 {
+					err = multierror.Append(err, withPath(paramsPath, fmt.Errorf(
+						"%s PipelineTask does not pass the %s trusted artifact param, so it misses the %s result of the %s PipelineTask",
+						pipelineTask.Name, paramSpec.Name, expected, strings.Join(others, ", "))))
+				}
+			}
+			if artifacts := producedArtifacts(*taskSpec); len(artifacts) > 0 {
+				if storage := paramSpecValue(taskSpec.Params, pipelineTask.Params, konfluxOCIStorageParam); storage != nil && *storage == "" {
+					err = multierror.Append(err, withPath(paramsPath, fmt.Errorf(
+						"%s PipelineTask must pass the %s param the repository its %s trusted artifacts are pushed to, e.g. $(params.output-image).git",
+						pipelineTask.Name, konfluxOCIStorageParam, strings.Join(artifacts, ", "))))
+				}
+			}
+		}
+	}
+	return err
+}
+
+// producedArtifacts returns the names of the trusted artifact results of a Task
+func producedArtifacts(taskSpec v1.TaskSpec) []string {
+	var artifacts []string
+	for _, result := range taskSpec.Results {
+		if strings.HasSuffix(result.Name, "_ARTIFACT") {
+			artifacts = append(artifacts, result.Name)
+		}
+	}
+	return artifacts
+}
+
+// paramSpecValue returns the string value of the param name of a Task, the one passed by params or
+// else its default, or nil if the Task declares no such param
+func paramSpecValue(paramSpecs v1.ParamSpecs, params v1.Params, name string) *string {
+	for _, paramSpec := range paramSpecs {
+		if paramSpec.Name != name {
+			continue
+		}
+		for _, param := range params {
+			if param.Name == name {
+				return &param.Value.StringVal
+			}
+		}
+		value := ""
+		if paramSpec.Default != nil {
+			value = paramSpec.Default.StringVal
+		}
+		return &value
+	}
+	return nil
+}
+
+// konfluxBuildPlatformsParam is the Pipeline param listing the platforms of multi-platform builds
+const konfluxBuildPlatformsParam = "build-platforms"
+
+// konfluxPlatformParam is the param of the Konflux build tasks which a matrix fans out over the
+// build platforms
+const konfluxPlatformParam = "PLATFORM"
+
+// ValidateKonfluxBuildPlatforms verifies that the build-platforms param of multi-platform build
+// pipelines is an array which a matrix fans out over the PLATFORM param of a PipelineTask
+func ValidateKonfluxBuildPlatforms(pipelineSpec v1.PipelineSpec) error {
+	var platforms *v1.ParamSpec
+	for i := range pipelineSpec.Params {
+		if pipelineSpec.Params[i].Name == konfluxBuildPlatformsParam {
+			platforms = &pipelineSpec.Params[i]
+		}
+	}
+	if platforms == nil {
+		return nil
+	}
+
+	var err error
+	if platforms.Type != v1.ParamTypeArray {
+		err = multierror.Append(err, fmt.Errorf("%s param must be an array of platforms, e.g. [linux/x86_64, linux/arm64]", konfluxBuildPlatformsParam))
+	}
+
+	// The array is fanned out over whether it is expanded, e.g. ["$(params.build-platforms[*])"], or
+	// passed through whole, e.g. $(params.build-platforms).
+	ref := fmt.Sprintf("$(params.%s[*])", konfluxBuildPlatformsParam)
+	refs := []string{ref, fmt.Sprintf("$(params.%s)", konfluxBuildPlatformsParam)}
+	fannedOut := false
+	for _, pipelineTask := range pipelineSpec.Tasks {
+		if !pipelineTask.IsMatrixed() {
+			continue
+		}
+		for _, param := range pipelineTask.Matrix.Params {
+			refersToPlatforms := slices.Contains(refs, param.Value.StringVal)
+			for _, item := range param.Value.ArrayVal {
+				refersToPlatforms = refersToPlatforms || slices.Contains(refs, item)
+			}
+			if !refersToPlatforms {
+				continue
+			}
+			fannedOut = true
+			if param.Name != konfluxPlatformParam {
+				err = multierror.Append(err, fmt.Errorf("%s PipelineTask fans out %s over the %s param, Konflux build tasks expect %s",
+					pipelineTask.Name, konfluxBuildPlatformsParam, param.Name, konfluxPlatformParam))
+			}
+		}
+	}
+	if !fannedOut {
+		err = multierror.Append(err, fmt.Errorf("%s param is declared but no PipelineTask fans out over it with a matrix, e.g. a %s param with value [\"%s\"]",
+			konfluxBuildPlatformsParam, konfluxPlatformParam, ref))
+	}
+	return err
+}
+
+// konfluxFinallyTasks are the Tasks which build pipelines run in finally
+var konfluxFinallyTasks = []string{"show-sbom"}
+
+// ValidateKonfluxFinallyTasks verifies that a Konflux build pipeline runs the Tasks Konflux expects
+// in finally. A Task is recognized by the name of the PipelineTask running it or of its taskRef.
+// Pipelines that do not build an image are ignored.
+func ValidateKonfluxFinallyTasks(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec) error {
+	if !isKonfluxBuildPipeline(pipelineSpec, allTaskSpecs) {
+		return nil
+	}
+
+	var err error
+	for _, required := range konfluxFinallyTasks {
+		found := false
+		for _, pipelineTask := range pipelineSpec.Finally {
+			if pipelineTask.Name == required || referencedTaskName(pipelineTask) == required {
+				found = true
+			}
+		}
+		if !found {
+			err = multierror.Append(err, fmt.Errorf("build pipeline must run the %s task in finally: spec.finally", required))
+		}
+	}
+	return err
+}
+
+// referencedTaskName returns the name of the Task a PipelineTask refers to, either by name or through
+// the name param of a resolver
+func referencedTaskName(pipelineTask v1.PipelineTask) string {
+	if pipelineTask.TaskRef == nil {
+		return ""
+	}
+	if pipelineTask.TaskRef.Name != "" {
+		return pipelineTask.TaskRef.Name
+	}
+	for _, param := range pipelineTask.TaskRef.Params {
+		if param.Name == "name" {
+			return param.Value.StringVal
+		}
+	}
+	return ""
+}
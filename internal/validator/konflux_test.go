@@ -0,0 +1,507 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateKonfluxBuildResults(t *testing.T) {
+	cloneSpec := &v1.TaskSpec{Results: []v1.TaskResult{{Name: "url"}, {Name: "commit"}}}
+	buildSpec := &v1.TaskSpec{Results: []v1.TaskResult{{Name: "IMAGE_URL"}, {Name: "IMAGE_DIGEST"}}}
+
+	tests := []struct {
+		name           string
+		pipelineSpec   v1.PipelineSpec
+		allTaskSpecs   map[string]*v1.TaskSpec
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "not a build pipeline",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{{Name: "test"}},
+			},
+			allTaskSpecs:  map[string]*v1.TaskSpec{"test": {}},
+			expectNoError: true,
+		},
+		{
+			name: "build pipeline with all required results",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{{Name: "clone-repository"}, {Name: "build-container"}},
+				Results: []v1.PipelineResult{
+					{Name: "IMAGE_URL", Value: *v1.NewStructuredValues("$(tasks.build-container.results.IMAGE_URL)")},
+					{Name: "IMAGE_DIGEST", Value: *v1.NewStructuredValues("$(tasks.build-container.results.IMAGE_DIGEST)")},
+					{Name: "CHAINS-GIT_URL", Value: *v1.NewStructuredValues("$(tasks.clone-repository.results.url)")},
+					{Name: "CHAINS-GIT_COMMIT", Value: *v1.NewStructuredValues("$(tasks.clone-repository.results.commit)")},
+				},
+			},
+			allTaskSpecs: map[string]*v1.TaskSpec{
+				"clone-repository": cloneSpec,
+				"build-container":  buildSpec,
+			},
+			expectNoError: true,
+		},
+		{
+			name: "build pipeline missing results",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{{Name: "clone"}, {Name: "build-image-index"}, {Name: "build-container"}},
+			},
+			allTaskSpecs: map[string]*v1.TaskSpec{
+				"clone":             cloneSpec,
+				"build-image-index": buildSpec,
+				"build-container":   buildSpec,
+			},
+			expectedErrors: []string{
+				"build pipeline must declare the IMAGE_URL result, e.g. with value $(tasks.build-container.results.IMAGE_URL)",
+				"build pipeline must declare the IMAGE_DIGEST result, e.g. with value $(tasks.build-container.results.IMAGE_DIGEST)",
+				"build pipeline must declare the CHAINS-GIT_URL result, e.g. with value $(tasks.clone.results.url)",
+				"build pipeline must declare the CHAINS-GIT_COMMIT result, e.g. with value $(tasks.clone.results.commit)",
+			},
+		},
+		{
+			name: "build pipeline without a clone task",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{{Name: "build-container"}},
+				Results: []v1.PipelineResult{
+					{Name: "IMAGE_URL", Value: *v1.NewStructuredValues("$(tasks.build-container.results.IMAGE_URL)")},
+					{Name: "IMAGE_DIGEST", Value: *v1.NewStructuredValues("$(tasks.build-container.results.IMAGE_DIGEST)")},
+				},
+			},
+			allTaskSpecs: map[string]*v1.TaskSpec{"build-container": {}},
+			expectedErrors: []string{
+				"build pipeline must declare the CHAINS-GIT_URL result, but no PipelineTask produces a url result (usually the clone-repository task)",
+				"build pipeline must declare the CHAINS-GIT_COMMIT result, but no PipelineTask produces a commit result (usually the clone-repository task)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKonfluxBuildResults(tt.pipelineSpec, tt.allTaskSpecs)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+			}
+		})
+	}
+}
+
+func TestValidatePipelineWithKonfluxProfile(t *testing.T) {
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: docker-build
+spec:
+  tasks:
+    - name: build-container
+      taskSpec:
+        results:
+          - name: IMAGE_URL
+            type: string
+          - name: IMAGE_DIGEST
+            type: string
+        steps:
+          - name: build
+            image: alpine:latest
+            script: echo build
+`)
+	require.NoError(t, err)
+
+	assert.NoError(t, ValidatePipeline(context.Background(), p), "Expected Konflux rules to be disabled by default")
+
+	ctx := WithOptions(context.Background(), Options{Profiles: NewProfileSet(ProfileKonflux)})
+	err = ValidatePipeline(ctx, p)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "konflux profile: 4 errors occurred")
+}
+
+func TestValidateKonfluxPipelineRunMetadata(t *testing.T) {
+	labels := map[string]string{
+		"appstudio.openshift.io/application":    "app",
+		"appstudio.openshift.io/component":      "component",
+		"pipelines.appstudio.openshift.io/type": "build",
+	}
+
+	tests := []struct {
+		name             string
+		metadata         metav1.ObjectMeta
+		expectedErrors   []string
+		expectedWarnings []string
+	}{
+		{
+			name: "labeled and triggered",
+			metadata: metav1.ObjectMeta{
+				Labels:      labels,
+				Annotations: map[string]string{"pipelinesascode.tekton.dev/on-cel-expression": `event == "push"`},
+			},
+		},
+		{
+			name:     "triggered on events",
+			metadata: metav1.ObjectMeta{Labels: labels, Annotations: map[string]string{"pipelinesascode.tekton.dev/on-event": "[push]"}},
+		},
+		{
+			name:     "missing labels",
+			metadata: metav1.ObjectMeta{Annotations: map[string]string{"pipelinesascode.tekton.dev/on-event": "[push]"}},
+			expectedErrors: []string{
+				"PipelineRun must set the appstudio.openshift.io/application label: metadata.labels",
+				"PipelineRun must set the appstudio.openshift.io/component label: metadata.labels",
+				"PipelineRun must set the pipelines.appstudio.openshift.io/type label: metadata.labels",
+			},
+		},
+		{
+			name:     "not triggered",
+			metadata: metav1.ObjectMeta{Labels: labels},
+			expectedWarnings: []string{
+				"PipelineRun sets neither the pipelinesascode.tekton.dev/on-cel-expression nor the pipelinesascode.tekton.dev/on-event annotation, so Pipelines as Code never triggers it: metadata.annotations",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKonfluxPipelineRunMetadata(tt.metadata)
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+
+			err = WithoutWarnings(err)
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidateKonfluxTrustedArtifacts(t *testing.T) {
+	tests := []struct {
+		name           string
+		params         v1.Params
+		expectedErrors []string
+	}{
+		{
+			name:   "result of the same name",
+			params: v1.Params{{Name: "SOURCE_ARTIFACT", Value: *v1.NewStructuredValues("$(tasks.clone-repository.results.SOURCE_ARTIFACT)")}},
+		},
+		{
+			name:   "other params are ignored",
+			params: v1.Params{{Name: "IMAGE", Value: *v1.NewStructuredValues("quay.io/example/app:latest")}},
+		},
+		{
+			name:   "result of another name",
+			params: v1.Params{{Name: "SOURCE_ARTIFACT", Value: *v1.NewStructuredValues("$(tasks.clone-repository.results.CACHI2_ARTIFACT)")}},
+			expectedErrors: []string{
+				"build PipelineTask passes the CACHI2_ARTIFACT result of the clone-repository PipelineTask to the SOURCE_ARTIFACT trusted artifact param, expected the SOURCE_ARTIFACT result",
+			},
+		},
+		{
+			name:   "not a result",
+			params: v1.Params{{Name: "SOURCE_ARTIFACT", Value: *v1.NewStructuredValues("oci:quay.io/example/artifacts@sha256:abc")}},
+			expectedErrors: []string{
+				`build PipelineTask must pass the SOURCE_ARTIFACT trusted artifact param a result of another PipelineTask, e.g. $(tasks.prefetch-dependencies.results.SOURCE_ARTIFACT), not "oci:quay.io/example/artifacts@sha256:abc"`,
+			},
+		},
+		{
+			name:   "source artifact",
+			params: v1.Params{{Name: "sourceArtifact", Value: *v1.NewStructuredValues("$(tasks.clone-repository.results.SOURCE_ARTIFACT)")}},
+		},
+		{
+			name:   "source artifact of another name",
+			params: v1.Params{{Name: "sourceArtifact", Value: *v1.NewStructuredValues("$(tasks.clone-repository.results.sourceArtifact)")}},
+			expectedErrors: []string{
+				"build PipelineTask passes the sourceArtifact result of the clone-repository PipelineTask to the sourceArtifact trusted artifact param, expected the SOURCE_ARTIFACT result",
+			},
+		},
+		{
+			name:   "result embedded in a string",
+			params: v1.Params{{Name: "SOURCE_ARTIFACT", Value: *v1.NewStructuredValues("oci:$(tasks.clone-repository.results.SOURCE_ARTIFACT)")}},
+			expectedErrors: []string{
+				`build PipelineTask must pass the SOURCE_ARTIFACT trusted artifact param a result of another PipelineTask`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKonfluxTrustedArtifacts(v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{{Name: "build", Params: tt.params}},
+			}, nil, "spec")
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidateKonfluxTrustedArtifactChains(t *testing.T) {
+	clone := &v1.TaskSpec{
+		Params:  v1.ParamSpecs{{Name: "url"}, {Name: "ociStorage"}},
+		Results: []v1.TaskResult{{Name: "SOURCE_ARTIFACT"}},
+	}
+	prefetch := &v1.TaskSpec{
+		Params:  v1.ParamSpecs{{Name: "SOURCE_ARTIFACT"}, {Name: "ociStorage", Default: v1.NewStructuredValues("")}},
+		Results: []v1.TaskResult{{Name: "SOURCE_ARTIFACT"}, {Name: "CACHI2_ARTIFACT"}},
+	}
+	build := &v1.TaskSpec{
+		Params: v1.ParamSpecs{{Name: "SOURCE_ARTIFACT"}, {Name: "CACHI2_ARTIFACT", Default: v1.NewStructuredValues("")}},
+	}
+	allTaskSpecs := map[string]*v1.TaskSpec{"clone-repository": clone, "prefetch-dependencies": prefetch, "build-container": build}
+	artifact := func(task, result string) v1.ParamValue {
+		return *v1.NewStructuredValues(fmt.Sprintf("$(tasks.%s.results.%s)", task, result))
+	}
+
+	tests := []struct {
+		name           string
+		pipelineTasks  []v1.PipelineTask
+		expectedErrors []string
+	}{
+		{
+			name: "chained",
+			pipelineTasks: []v1.PipelineTask{
+				{Name: "clone-repository", Params: v1.Params{{Name: "ociStorage", Value: *v1.NewStructuredValues("$(params.output-image).git")}}},
+				{Name: "prefetch-dependencies", Params: v1.Params{
+					{Name: "SOURCE_ARTIFACT", Value: artifact("clone-repository", "SOURCE_ARTIFACT")},
+					{Name: "ociStorage", Value: *v1.NewStructuredValues("$(params.output-image).prefetch")},
+				}},
+				{Name: "build-container", Params: v1.Params{
+					{Name: "SOURCE_ARTIFACT", Value: artifact("prefetch-dependencies", "SOURCE_ARTIFACT")},
+					{Name: "CACHI2_ARTIFACT", Value: artifact("prefetch-dependencies", "CACHI2_ARTIFACT")},
+				}},
+			},
+		},
+		{
+			name: "broken chain",
+			pipelineTasks: []v1.PipelineTask{
+				{Name: "clone-repository", Params: v1.Params{{Name: "ociStorage", Value: *v1.NewStructuredValues("$(params.output-image).git")}}},
+				{Name: "prefetch-dependencies"},
+				{Name: "build-container", Params: v1.Params{{Name: "SOURCE_ARTIFACT", Value: artifact("clone-repository", "SOURCE_ARTIFACT")}}},
+			},
+			expectedErrors: []string{
+				"prefetch-dependencies PipelineTask does not pass the SOURCE_ARTIFACT trusted artifact param, so it misses the SOURCE_ARTIFACT result of the clone-repository PipelineTask: spec.tasks[1].params",
+				"prefetch-dependencies PipelineTask must pass the ociStorage param the repository its SOURCE_ARTIFACT, CACHI2_ARTIFACT trusted artifacts are pushed to, e.g. $(params.output-image).git: spec.tasks[1].params",
+				"build-container PipelineTask does not pass the CACHI2_ARTIFACT trusted artifact param, so it misses the CACHI2_ARTIFACT result of the prefetch-dependencies PipelineTask: spec.tasks[2].params",
+			},
+		},
+		{
+			name: "empty ociStorage",
+			pipelineTasks: []v1.PipelineTask{
+				{Name: "clone-repository", Params: v1.Params{{Name: "ociStorage", Value: *v1.NewStructuredValues("")}}},
+			},
+			expectedErrors: []string{
+				"clone-repository PipelineTask must pass the ociStorage param the repository its SOURCE_ARTIFACT trusted artifacts are pushed to, e.g. $(params.output-image).git: spec.tasks[0].params",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKonfluxTrustedArtifacts(v1.PipelineSpec{Tasks: tt.pipelineTasks}, allTaskSpecs, "spec")
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			assert.Len(t, err.(*multierror.Error).Errors, len(tt.expectedErrors))
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+
+	// The findings of a pipeline spec embedded in a PipelineRun point into it.
+	err := ValidateKonfluxTrustedArtifacts(v1.PipelineSpec{Finally: []v1.PipelineTask{
+		{Name: "clone-repository", Params: v1.Params{{Name: "ociStorage", Value: *v1.NewStructuredValues("")}}},
+	}}, allTaskSpecs, "spec.pipelineSpec")
+	assert.ErrorContains(t, err, "e.g. $(params.output-image).git: spec.pipelineSpec.finally[0].params")
+}
+
+func TestValidateKonfluxBuildPlatforms(t *testing.T) {
+	platforms := v1.ParamSpec{Name: "build-platforms", Type: v1.ParamTypeArray}
+	fanOutValue := func(name string, value v1.ParamValue) []v1.PipelineTask {
+		return []v1.PipelineTask{{
+			Name:   "build-images",
+			Matrix: &v1.Matrix{Params: v1.Params{{Name: name, Value: value}}},
+		}}
+	}
+	fanOut := func(name string) []v1.PipelineTask {
+		return fanOutValue(name, *v1.NewStructuredValues("$(params.build-platforms[*])"))
+	}
+
+	tests := []struct {
+		name           string
+		pipelineSpec   v1.PipelineSpec
+		expectedErrors []string
+	}{
+		{
+			name:         "single-platform pipeline",
+			pipelineSpec: v1.PipelineSpec{Tasks: []v1.PipelineTask{{Name: "build-container"}}},
+		},
+		{
+			name:         "fanned out over PLATFORM",
+			pipelineSpec: v1.PipelineSpec{Params: []v1.ParamSpec{platforms}, Tasks: fanOut("PLATFORM")},
+		},
+		{
+			name: "fanned out over the whole array",
+			pipelineSpec: v1.PipelineSpec{
+				Params: []v1.ParamSpec{platforms},
+				Tasks:  fanOutValue("PLATFORM", v1.ParamValue{Type: v1.ParamTypeArray, ArrayVal: []string{"$(params.build-platforms)"}}),
+			},
+		},
+		{
+			name: "array passed through",
+			pipelineSpec: v1.PipelineSpec{
+				Params: []v1.ParamSpec{platforms},
+				Tasks:  fanOutValue("PLATFORM", *v1.NewStructuredValues("$(params.build-platforms)")),
+			},
+		},
+		{
+			name:           "whole array fanned out over another param",
+			pipelineSpec:   v1.PipelineSpec{Params: []v1.ParamSpec{platforms}, Tasks: fanOutValue("ARCH", v1.ParamValue{Type: v1.ParamTypeArray, ArrayVal: []string{"$(params.build-platforms)"}})},
+			expectedErrors: []string{"build-images PipelineTask fans out build-platforms over the ARCH param, Konflux build tasks expect PLATFORM"},
+		},
+		{
+			name: "string param",
+			pipelineSpec: v1.PipelineSpec{
+				Params: []v1.ParamSpec{{Name: "build-platforms", Type: v1.ParamTypeString}},
+				Tasks:  fanOut("PLATFORM"),
+			},
+			expectedErrors: []string{"build-platforms param must be an array of platforms"},
+		},
+		{
+			name:           "fanned out over another param",
+			pipelineSpec:   v1.PipelineSpec{Params: []v1.ParamSpec{platforms}, Tasks: fanOut("ARCH")},
+			expectedErrors: []string{"build-images PipelineTask fans out build-platforms over the ARCH param, Konflux build tasks expect PLATFORM"},
+		},
+		{
+			name: "not fanned out",
+			pipelineSpec: v1.PipelineSpec{
+				Params: []v1.ParamSpec{platforms},
+				Tasks:  []v1.PipelineTask{{Name: "build-container"}},
+			},
+			expectedErrors: []string{"build-platforms param is declared but no PipelineTask fans out over it with a matrix"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKonfluxBuildPlatforms(tt.pipelineSpec)
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidateKonfluxFinallyTasks(t *testing.T) {
+	buildSpec := &v1.TaskSpec{Results: []v1.TaskResult{{Name: "IMAGE_URL"}, {Name: "IMAGE_DIGEST"}}}
+	allTaskSpecs := map[string]*v1.TaskSpec{"build": buildSpec, "show-summary": {}}
+
+	tests := []struct {
+		name          string
+		finally       []v1.PipelineTask
+		allTaskSpecs  map[string]*v1.TaskSpec
+		expectNoError bool
+	}{
+		{
+			name:          "named show-sbom",
+			finally:       []v1.PipelineTask{{Name: "show-sbom"}},
+			allTaskSpecs:  allTaskSpecs,
+			expectNoError: true,
+		},
+		{
+			name: "bundle of show-sbom",
+			finally: []v1.PipelineTask{{
+				Name: "sbom",
+				TaskRef: &v1.TaskRef{ResolverRef: v1.ResolverRef{Resolver: "bundles", Params: v1.Params{
+					{Name: "name", Value: *v1.NewStructuredValues("show-sbom")},
+					{Name: "bundle", Value: *v1.NewStructuredValues("quay.io/konflux-ci/tekton-catalog/task-show-sbom:0.1")},
+				}}},
+			}},
+			allTaskSpecs:  allTaskSpecs,
+			expectNoError: true,
+		},
+		{
+			name:          "not a build pipeline",
+			allTaskSpecs:  map[string]*v1.TaskSpec{"build": {}},
+			expectNoError: true,
+		},
+		{
+			name:         "missing show-sbom",
+			finally:      []v1.PipelineTask{{Name: "show-summary", TaskRef: &v1.TaskRef{Name: "summary"}}},
+			allTaskSpecs: allTaskSpecs,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKonfluxFinallyTasks(v1.PipelineSpec{
+				Tasks:   []v1.PipelineTask{{Name: "build"}},
+				Finally: tt.finally,
+			}, tt.allTaskSpecs)
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			assert.Contains(t, err.Error(), "build pipeline must run the show-sbom task in finally: spec.finally")
+		})
+	}
+}
+
+func TestValidatePipelineRunWithKonfluxProfile(t *testing.T) {
+	pr, err := pipelineRunFromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: component-on-push
+spec:
+  pipelineSpec:
+    tasks:
+      - name: test
+        taskSpec:
+          steps:
+            - name: test
+              image: alpine:latest
+              script: echo test
+`)
+	require.NoError(t, err)
+	rawYAML, err := yaml.Marshal(pr)
+	require.NoError(t, err)
+
+	assert.NoError(t, ValidatePipelineRunWithYAML(context.Background(), pr, rawYAML), "Expected Konflux rules to be disabled by default")
+
+	ctx := WithOptions(context.Background(), Options{Profiles: NewProfileSet(ProfileKonflux)})
+	err = ValidatePipelineRunWithYAML(ctx, pr, rawYAML)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PipelineRun must set the appstudio.openshift.io/application label: metadata.labels")
+	for _, finding := range Findings(err) {
+		assert.Equal(t, RuleKonfluxMetadata.ID, finding.Rule)
+	}
+}
@@ -0,0 +1,154 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// Rules of the lint profile
+const (
+	RuleMissingDescription = "missing-description"
+	RuleUnnamedStep        = "unnamed-step"
+	RuleMissingShebang     = "missing-shebang"
+	RuleLatestImage        = "latest-image"
+	RuleLongScript         = "long-script"
+	RuleLargeResult        = "large-result"
+)
+
+// LintRules lists the rules of the lint profile
+var LintRules = []string{
+	RuleMissingDescription, RuleUnnamedStep, RuleMissingShebang, RuleLatestImage, RuleLongScript, RuleLargeResult,
+}
+
+// lintRules maps the names of the rules of the lint profile to their Rule. Missing descriptions are
+// those of strict mode, reported as warnings.
+var lintRules = map[string]Rule{
+	RuleMissingDescription: RuleDescriptions,
+	RuleUnnamedStep:        RuleLintStepNames,
+	RuleMissingShebang:     RuleLintShebangs,
+	RuleLatestImage:        RuleLintLatestImages,
+	RuleLongScript:         RuleLintLongScripts,
+	RuleLargeResult:        RuleLintLargeResults,
+}
+
+// maxScriptLines is the number of lines above which a script is better kept in an image or a
+// StepAction than inline
+const maxScriptLines = 50
+
+// ValidateTaskBestPractices warns about standalone Tasks which stray from the best practices: the
+// Task, its params, its results, or its workspaces lack a description, steps lack a name, scripts lack a shebang,
+// are longer than maxScriptLines, or write output likely too large for results, and images are used
+// by their latest tag. The suppressed rules are not verified.
+func ValidateTaskBestPractices(taskSpec v1.TaskSpec, suppressed []string) error {
+	var err error
+	report := func(rule, path, format string, args ...any) {
+		if !slices.Contains(suppressed, rule) {
+			err = multierror.Append(err, withRule(lintRules[rule], withPath(path, warningf("%s (%s rule)", fmt.Sprintf(format, args...), rule))))
+		}
+	}
+
+	for _, item := range taskDescribedItems(taskSpec, "spec") {
+		if item.description == "" {
+			report(RuleMissingDescription, item.path, "%s has no description", item.name)
+		}
+	}
+
+	for i, step := range taskSpec.Steps {
+		path := fmt.Sprintf("spec.steps[%d]", i)
+		if step.Name == "" {
+			report(RuleUnnamedStep, path, "step has no name")
+		}
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		if step.Script != "" && !strings.HasPrefix(strings.TrimLeft(step.Script, " \t\r\n"), "#!") {
+			report(RuleMissingShebang, path+".script", "script of step %s has no shebang, so it runs with sh", name)
+		}
+		if lines := strings.Count(strings.TrimRight(step.Script, "\n"), "\n") + 1; step.Script != "" && lines > maxScriptLines {
+			report(RuleLongScript, path+".script", "script of step %s is %d lines long, more than %d, consider moving it to an image or a StepAction", name, lines, maxScriptLines)
+		}
+		for _, write := range largeResultWrites(step.Script) {
+			report(RuleLargeResult, path+".script", "step %s writes the output of %s to the %s result, which Tekton limits to about 4KB, consider a workspace or the logs of a sidecar instead", name, write.command, write.result)
+		}
+		if isLatestImage(step.Image) {
+			report(RuleLatestImage, path+".image", "step %s uses the latest tag of %s, pin a version or digest", name, step.Image)
+		}
+	}
+	for i, sidecar := range taskSpec.Sidecars {
+		if isLatestImage(sidecar.Image) {
+			report(RuleLatestImage, fmt.Sprintf("spec.sidecars[%d].image", i), "sidecar %s uses the latest tag of %s, pin a version or digest", sidecar.Name, sidecar.Image)
+		}
+	}
+	return err
+}
+
+// isLatestImage tells whether an image reference uses the latest tag, explicitly or by omitting the
+// tag. References substituting params and references by digest are not.
+func isLatestImage(image string) bool {
+	if image == "" || strings.Contains(image, "$(") || strings.Contains(image, "@") {
+		return false
+	}
+	// The tag follows the last path component, which excludes the port of registries.
+	_, tag, tagged := strings.Cut(image[strings.LastIndex(image, "/")+1:], ":")
+	return !tagged || tag == "latest"
+}
+
+// resultWriteRegex matches the redirection of the output of a command to the file of a result,
+// e.g. > $(results.digest.path) or | tee $(results.digest.path)
+var resultWriteRegex = regexp.MustCompile(`(?:>>?|\btee\s+(?:-a\s+)?)\s*["']?\$\(results\.([^.)]+)\.path\)`)
+
+// largeOutputCommandRegex matches commands whose output is likely larger than a result can hold:
+// cat of globs or directories, find, recursive ls, tree, base64, and git log. The cat of a single
+// file is left alone, since writing a small file, e.g. an image digest, to a result is common.
+var largeOutputCommandRegex = regexp.MustCompile(`^(?:sudo\s+)?(cat\s+[^<\s].*(?:\*|/["']?(?:\s|$))|find\b|ls\s+(?:\S+\s+)*-[a-zA-Z]*R|tree\b|base64\b|git\s+log\b)`)
+
+// limitedGitLogRegex matches the options of git log limiting the number of commits it outputs
+var limitedGitLogRegex = regexp.MustCompile(`\s(-\d+|-n\s*\d+|--max-count)\b`)
+
+// commandSeparatorRegex matches the separators of the commands of a shell line
+var commandSeparatorRegex = regexp.MustCompile(`;|&&|\|\|`)
+
+// resultWrite is a command of a script whose output is written to the file of a result
+type resultWrite struct {
+	command string
+	result  string
+}
+
+// largeResultWrites returns the commands of a script writing output likely larger than a result
+// can hold to the file of a result. Only the last command of a pipeline is considered, since the
+// commands it pipes to, e.g. wc or sha256sum, usually shrink the output. This is a heuristic
+// working line by line.
+func largeResultWrites(script string) []resultWrite {
+	var writes []resultWrite
+	for _, line := range strings.Split(script, "\n") {
+		match := resultWriteRegex.FindStringSubmatchIndex(line)
+		if match == nil {
+			continue
+		}
+		commands := commandSeparatorRegex.Split(line[:match[0]], -1)
+		pipeline := strings.Split(commands[len(commands)-1], "|")
+		command := strings.TrimSpace(pipeline[len(pipeline)-1])
+		if command == "" && len(pipeline) > 1 {
+			// The output is piped to tee.
+			command = strings.TrimSpace(pipeline[len(pipeline)-2])
+		}
+		if !largeOutputCommandRegex.MatchString(command) {
+			continue
+		}
+		name := strings.Fields(strings.TrimPrefix(command, "sudo "))[0]
+		if name == "git" {
+			if limitedGitLogRegex.MatchString(command) {
+				continue
+			}
+			name = "git log"
+		}
+		writes = append(writes, resultWrite{command: name, result: line[match[2]:match[3]]})
+	}
+	return writes
+}
@@ -0,0 +1,227 @@
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateTaskBestPractices(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskSpecYAML   string
+		suppressed     []string
+		expectedErrors []string
+	}{
+		{
+			name: "best practices",
+			taskSpecYAML: `
+description: Builds the image
+params:
+  - name: url
+    description: URL of the repository
+results:
+  - name: digest
+    description: Digest of the image
+steps:
+  - name: build
+    image: registry.example.com:5000/builder:1.2@sha256:0123456789abcdef
+    script: |
+      #!/usr/bin/env bash
+      make build
+  - name: push
+    image: $(params.image)
+sidecars:
+  - name: registry
+    image: registry:2
+`,
+		},
+		{
+			name: "stray from the best practices",
+			taskSpecYAML: `
+params:
+  - name: url
+results:
+  - name: digest
+steps:
+  - image: alpine
+    script: make build
+  - name: push
+    image: registry.example.com:5000/pusher:latest
+sidecars:
+  - name: registry
+    image: registry
+`,
+			expectedErrors: []string{
+				"Task has no description (missing-description rule): spec.description",
+				"url param has no description (missing-description rule): spec.params[0]",
+				"digest result has no description (missing-description rule): spec.results[0]",
+				"step has no name (unnamed-step rule): spec.steps[0]",
+				"script of step #0 has no shebang, so it runs with sh (missing-shebang rule): spec.steps[0].script",
+				"step #0 uses the latest tag of alpine, pin a version or digest (latest-image rule): spec.steps[0].image",
+				"step push uses the latest tag of registry.example.com:5000/pusher:latest, pin a version or digest (latest-image rule): spec.steps[1].image",
+				"sidecar registry uses the latest tag of registry, pin a version or digest (latest-image rule): spec.sidecars[0].image",
+			},
+		},
+		{
+			name: "suppressed rules",
+			taskSpecYAML: `
+description: Builds the image
+steps:
+  - image: alpine
+    script: make build
+`,
+			suppressed: []string{RuleUnnamedStep, RuleMissingShebang, RuleLatestImage},
+		},
+		{
+			name: "long script",
+			taskSpecYAML: `
+description: Builds the image
+steps:
+  - name: build
+    image: alpine:3.20
+    script: |
+      #!/bin/sh
+` + strings.Repeat("      echo build\n", maxScriptLines),
+			expectedErrors: []string{
+				"script of step build is 51 lines long, more than 50, consider moving it to an image or a StepAction (long-script rule): spec.steps[0].script",
+			},
+		},
+		{
+			name: "large results",
+			taskSpecYAML: `
+description: Lists the sources
+results:
+  - name: files
+    description: Files of the sources
+  - name: count
+    description: Number of files
+  - name: commits
+    description: Commits of the sources
+  - name: revision
+    description: Revision of the sources
+  - name: manifest
+    description: Manifest of the sources
+  - name: IMAGE_DIGEST
+    description: Digest of the image
+steps:
+  - name: list
+    image: alpine:3.20
+    script: |
+      #!/bin/sh
+      find /workspace/source -type f > $(results.files.path)
+      find /workspace/source -type f | wc -l > $(results.count.path)
+      cd /workspace/source && git log --oneline | tee "$(results.commits.path)"
+      git log -1 --format=%H > $(results.revision.path)
+      cat manifests/*.json > $(results.manifest.path)
+      cat "$(workspaces.source.path)/image-digest" > $(results.IMAGE_DIGEST.path)
+      cat <<EOF > $(results.manifest.path)
+      {}
+      EOF
+`,
+			expectedErrors: []string{
+				"step list writes the output of find to the files result, which Tekton limits to about 4KB, consider a workspace or the logs of a sidecar instead (large-result rule): spec.steps[0].script",
+				"step list writes the output of git log to the commits result, which Tekton limits to about 4KB, consider a workspace or the logs of a sidecar instead (large-result rule): spec.steps[0].script",
+				"step list writes the output of cat to the manifest result, which Tekton limits to about 4KB, consider a workspace or the logs of a sidecar instead (large-result rule): spec.steps[0].script",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var taskSpec v1.TaskSpec
+			require.NoError(t, yaml.Unmarshal([]byte(tt.taskSpecYAML), &taskSpec))
+			err := ValidateTaskBestPractices(taskSpec, tt.suppressed)
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			findings := Findings(err)
+			require.Len(t, findings, len(tt.expectedErrors))
+			for i, expectedErr := range tt.expectedErrors {
+				assert.Equal(t, SeverityWarning, findings[i].Severity)
+				assert.Equal(t, expectedErr, findings[i].Message+": "+findings[i].ResourcePath)
+			}
+		})
+	}
+}
+
+func TestValidateTaskWithLintProfile(t *testing.T) {
+	task, err := taskFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+  annotations:
+    tektor.dev/suppress-rules: missing-description, unknown
+spec:
+  steps:
+    - name: build
+      image: alpine
+`)
+	require.NoError(t, err)
+
+	assert.NoError(t, ValidateTaskV1(context.Background(), task), "Expected the lint profile to be disabled by default")
+
+	ctx := WithOptions(context.Background(), Options{Profiles: NewProfileSet(ProfileLint)})
+	err = ValidateTaskV1(ctx, task)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown rule "unknown", expected one of: missing-description, unnamed-step, missing-shebang, latest-image, long-script, large-result: metadata.annotations.tektor.dev/suppress-rules`)
+	var lintFindings []Finding
+	for _, finding := range Findings(err) {
+		if finding.Rule == RuleLintLatestImages.ID {
+			lintFindings = append(lintFindings, finding)
+		}
+	}
+	assert.Equal(t, []Finding{{
+		Rule:         RuleLintLatestImages.ID,
+		Severity:     SeverityWarning,
+		Message:      "lint profile: step build uses the latest tag of alpine, pin a version or digest (latest-image rule)",
+		ResourcePath: "spec.steps[0].image",
+	}}, lintFindings)
+}
+
+func TestValidateTaskWithCombinedProfiles(t *testing.T) {
+	task, err := taskFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  description: Builds the image
+  params:
+    - name: url
+      type: string
+  steps:
+    - name: build
+      image: alpine:3.20
+      script: |
+        #!/bin/sh
+        git clone $(params.url) .
+      securityContext:
+        privileged: true
+`)
+	require.NoError(t, err)
+
+	findingsOf := func(opts Options) []Finding {
+		return Findings(ValidateTaskV1(WithOptions(context.Background(), opts), task))
+	}
+
+	// The lint and security profiles combine, and the lint profile reports the missing descriptions
+	// of strict mode as warnings.
+	assert.Equal(t, []Finding{
+		{Rule: RuleSecurityPrivileged.ID, Severity: SeverityError, Message: "security profile: container runs privileged (privileged rule)", ResourcePath: "spec.steps[0].securityContext.privileged"},
+		{Rule: RuleDescriptions.ID, Severity: SeverityWarning, Message: "lint profile: url param has no description (missing-description rule)", ResourcePath: "spec.params[0]"},
+	}, findingsOf(Options{Profiles: NewProfileSet(ProfileLint, ProfileSecurity)}))
+
+	// Strict mode reports them once, as errors.
+	assert.Equal(t, []Finding{
+		{Rule: RuleDescriptions.ID, Severity: SeverityError, Message: "url param has no description", ResourcePath: "spec.params[0]"},
+	}, findingsOf(Options{Profiles: NewProfileSet(ProfileLint), Strict: true}))
+}
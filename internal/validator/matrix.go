@@ -0,0 +1,207 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// resultConsumptionRegex matches result references along with an optional index or star suffix,
+// e.g. $(tasks.build.results.digest[*])
+var resultConsumptionRegex = regexp.MustCompile(`\$\(tasks\.([^.]+)\.results\.([^).\[\s]+)(\[[^\]]*\])?[^)]*\)`)
+
+// ValidateMatrix validates the matrix of a PipelineTask against the parameters declared by the Task
+func ValidateMatrix(matrix *v1.Matrix, specs v1.ParamSpecs) error {
+	if matrix == nil {
+		return nil
+	}
+
+	var err error
+
+	fannedOut := make(map[string]bool, len(matrix.Params))
+	for _, param := range matrix.Params {
+		fannedOut[param.Name] = true
+		taskParam, found := getTaskParam(param.Name, specs)
+		if !found {
+			err = multierror.Append(err, fmt.Errorf(
+				"%q matrix parameter is not defined by the Task", param.Name))
+			continue
+		}
+
+		// Each array element is substituted into a string Task parameter.
+		if paramType := paramSpecType(taskParam); paramType != string(v1.ParamTypeString) {
+			err = multierror.Append(err, fmt.Errorf(
+				"%q matrix parameter must fan out into a string Task parameter, got %q",
+				param.Name, paramType))
+		}
+		if valueType := paramValueType(param); valueType != string(v1.ParamTypeArray) {
+			err = multierror.Append(err, fmt.Errorf(
+				"%q matrix parameter must be an array, got %q", param.Name, valueType))
+		}
+	}
+
+	for i, include := range matrix.Include {
+		includeName := include.Name
+		if includeName == "" {
+			includeName = fmt.Sprintf("#%d", i)
+		}
+
+		seen := make(map[string]bool)
+		for _, param := range include.Params {
+			if seen[param.Name] {
+				err = multierror.Append(err, fmt.Errorf(
+					"matrix include %s: %q parameter is specified more than once", includeName, param.Name))
+				continue
+			}
+			seen[param.Name] = true
+			if fannedOut[param.Name] {
+				err = multierror.Append(err, fmt.Errorf(
+					"matrix include %s: %q parameter is already fanned out by the matrix params", includeName, param.Name))
+				continue
+			}
+
+			taskParam, found := getTaskParam(param.Name, specs)
+			if !found {
+				err = multierror.Append(err, fmt.Errorf(
+					"matrix include %s: %q parameter is not defined by the Task", includeName, param.Name))
+				continue
+			}
+			if valueType := paramValueType(param); valueType != string(v1.ParamTypeString) {
+				err = multierror.Append(err, fmt.Errorf(
+					"matrix include %s: %q parameter must be a string, got %q", includeName, param.Name, valueType))
+				continue
+			}
+			if paramType := paramSpecType(taskParam); paramType != string(v1.ParamTypeString) {
+				err = multierror.Append(err, fmt.Errorf(
+					"matrix include %s: %q parameter has the incorrect type, got %q, want %q",
+					includeName, param.Name, string(v1.ParamTypeString), paramType))
+			}
+		}
+	}
+
+	return err
+}
+
+// ValidateMatrixResultConsumption validates that the results of matrixed PipelineTasks are only
+// consumed as aggregated arrays, e.g. $(tasks.build.results.digest[*]). Tekton rejects any other
+// usage at runtime since a matrixed PipelineTask produces one value per combination.
+func ValidateMatrixResultConsumption(pipelineSpec v1.PipelineSpec) error {
+	matrixedTasks := matrixedPipelineTasks(pipelineSpec)
+	if len(matrixedTasks) == 0 {
+		return nil
+	}
+
+	var err error
+	check := func(location, value string) {
+		for _, match := range resultConsumptionRegex.FindAllStringSubmatch(value, -1) {
+			taskName, resultName, suffix := match[1], match[2], match[3]
+			if !matrixedTasks[taskName] || suffix == "[*]" {
+				continue
+			}
+			err = multierror.Append(err, fmt.Errorf(
+				"%s result from matrixed %s PipelineTask must be consumed as an array with $(tasks.%s.results.%s[*]), got %q in %s",
+				resultName, taskName, taskName, resultName, match[0], location))
+		}
+	}
+
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		for _, param := range pipelineTask.Params {
+			for _, value := range paramValueStrings(param.Value) {
+				check(fmt.Sprintf("PipelineTask %s parameter %s", pipelineTask.Name, param.Name), value)
+			}
+		}
+		if pipelineTask.Matrix != nil {
+			for _, param := range pipelineTask.Matrix.GetAllParams() {
+				for _, value := range paramValueStrings(param.Value) {
+					check(fmt.Sprintf("PipelineTask %s matrix parameter %s", pipelineTask.Name, param.Name), value)
+				}
+			}
+		}
+		for i, when := range pipelineTask.When {
+			location := fmt.Sprintf("PipelineTask %s when expression #%d", pipelineTask.Name, i)
+			check(location, when.Input)
+			check(location, when.CEL)
+			for _, value := range when.Values {
+				check(location, value)
+			}
+		}
+	}
+
+	for _, pipelineResult := range pipelineSpec.Results {
+		for _, value := range paramValueStrings(pipelineResult.Value) {
+			check(fmt.Sprintf("Pipeline result %s", pipelineResult.Name), value)
+		}
+	}
+
+	return err
+}
+
+// matrixedPipelineTasks returns the names of the PipelineTasks that fan out with a matrix
+func matrixedPipelineTasks(pipelineSpec v1.PipelineSpec) map[string]bool {
+	matrixed := make(map[string]bool)
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		if pipelineTask.IsMatrixed() {
+			matrixed[pipelineTask.Name] = true
+		}
+	}
+	return matrixed
+}
+
+// paramValueStrings returns every string held by a ParamValue, in a stable order
+func paramValueStrings(value v1.ParamValue) []string {
+	switch value.Type {
+	case v1.ParamTypeArray:
+		return value.ArrayVal
+	case v1.ParamTypeObject:
+		keys := SortedKeys(value.ObjectVal)
+		values := make([]string, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, value.ObjectVal[key])
+		}
+		return values
+	default:
+		return []string{value.StringVal}
+	}
+}
+
+// matrixParams returns the Task parameters supplied by a matrix so they count towards the
+// required parameters of the Task. Undeclared parameters are left out and the values take the
+// type of the Task parameter, since ValidateMatrix already reports those problems.
+func matrixParams(matrix *v1.Matrix, specs v1.ParamSpecs) v1.Params {
+	if matrix == nil {
+		return nil
+	}
+
+	var params v1.Params
+	seen := make(map[string]bool)
+	for _, param := range matrix.GetAllParams() {
+		if seen[param.Name] {
+			continue
+		}
+		seen[param.Name] = true
+		taskParam, found := getTaskParam(param.Name, specs)
+		if !found {
+			continue
+		}
+		params = append(params, v1.Param{Name: param.Name, Value: v1.ParamValue{Type: taskParam.Type}})
+	}
+	return params
+}
+
+// paramSpecType returns the type of a ParamSpec, defaulting to "string" as Tekton does
+func paramSpecType(spec v1.ParamSpec) string {
+	if spec.Type == "" {
+		return string(v1.ParamTypeString)
+	}
+	return string(spec.Type)
+}
+
+// paramValueType returns the type of a Param value, defaulting to "string" as Tekton does
+func paramValueType(param v1.Param) string {
+	if param.Value.Type == "" {
+		return string(v1.ParamTypeString)
+	}
+	return string(param.Value.Type)
+}
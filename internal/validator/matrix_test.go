@@ -0,0 +1,478 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+func TestValidateMatrix(t *testing.T) {
+	specs := v1.ParamSpecs{
+		{Name: "platform", Type: v1.ParamTypeString},
+		{Name: "flavor"},
+		{Name: "args", Type: v1.ParamTypeArray},
+	}
+
+	tests := []struct {
+		name           string
+		matrix         *v1.Matrix
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name:          "nil matrix",
+			matrix:        nil,
+			expectNoError: true,
+		},
+		{
+			name: "valid matrix params and include",
+			matrix: &v1.Matrix{
+				Params: v1.Params{
+					{Name: "platform", Value: *v1.NewStructuredValues("linux/amd64", "linux/arm64")},
+				},
+				Include: v1.IncludeParamsList{
+					{Name: "fast", Params: v1.Params{
+						{Name: "flavor", Value: *v1.NewStructuredValues("fast")},
+					}},
+				},
+			},
+			expectNoError: true,
+		},
+		{
+			name: "matrix param not defined by task",
+			matrix: &v1.Matrix{
+				Params: v1.Params{
+					{Name: "unknown", Value: *v1.NewStructuredValues("a", "b")},
+				},
+			},
+			expectedErrors: []string{`"unknown" matrix parameter is not defined by the Task`},
+		},
+		{
+			name: "matrix param targets array task param",
+			matrix: &v1.Matrix{
+				Params: v1.Params{
+					{Name: "args", Value: *v1.NewStructuredValues("a", "b")},
+				},
+			},
+			expectedErrors: []string{`"args" matrix parameter must fan out into a string Task parameter, got "array"`},
+		},
+		{
+			name: "matrix param with string value",
+			matrix: &v1.Matrix{
+				Params: v1.Params{
+					{Name: "platform", Value: *v1.NewStructuredValues("linux/amd64")},
+				},
+			},
+			expectedErrors: []string{`"platform" matrix parameter must be an array, got "string"`},
+		},
+		{
+			name: "include param not defined by task",
+			matrix: &v1.Matrix{
+				Include: v1.IncludeParamsList{
+					{Name: "extra", Params: v1.Params{
+						{Name: "unknown", Value: *v1.NewStructuredValues("value")},
+					}},
+				},
+			},
+			expectedErrors: []string{`matrix include extra: "unknown" parameter is not defined by the Task`},
+		},
+		{
+			name: "include param with array value",
+			matrix: &v1.Matrix{
+				Include: v1.IncludeParamsList{
+					{Params: v1.Params{
+						{Name: "flavor", Value: *v1.NewStructuredValues("a", "b")},
+					}},
+				},
+			},
+			expectedErrors: []string{`matrix include #0: "flavor" parameter must be a string, got "array"`},
+		},
+		{
+			name: "include param targets array task param",
+			matrix: &v1.Matrix{
+				Include: v1.IncludeParamsList{
+					{Name: "extra", Params: v1.Params{
+						{Name: "args", Value: *v1.NewStructuredValues("value")},
+					}},
+				},
+			},
+			expectedErrors: []string{`matrix include extra: "args" parameter has the incorrect type, got "string", want "array"`},
+		},
+		{
+			name: "include param specified more than once",
+			matrix: &v1.Matrix{
+				Include: v1.IncludeParamsList{
+					{Name: "extra", Params: v1.Params{
+						{Name: "flavor", Value: *v1.NewStructuredValues("a")},
+						{Name: "flavor", Value: *v1.NewStructuredValues("b")},
+					}},
+				},
+			},
+			expectedErrors: []string{`matrix include extra: "flavor" parameter is specified more than once`},
+		},
+		{
+			name: "include param duplicating a matrix param",
+			matrix: &v1.Matrix{
+				Params: v1.Params{
+					{Name: "platform", Value: *v1.NewStructuredValues("linux/amd64", "linux/arm64")},
+				},
+				Include: v1.IncludeParamsList{
+					{Name: "s390x", Params: v1.Params{
+						{Name: "platform", Value: *v1.NewStructuredValues("linux/s390x")},
+						{Name: "flavor", Value: *v1.NewStructuredValues("fast")},
+					}},
+				},
+			},
+			expectedErrors: []string{`matrix include s390x: "platform" parameter is already fanned out by the matrix params`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMatrix(tt.matrix, specs)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+			}
+		})
+	}
+}
+
+func TestMatrixParams(t *testing.T) {
+	specs := v1.ParamSpecs{
+		{Name: "platform"},
+		{Name: "flavor", Type: v1.ParamTypeString},
+	}
+	matrix := &v1.Matrix{
+		Params: v1.Params{
+			{Name: "platform", Value: *v1.NewStructuredValues("linux/amd64", "linux/arm64")},
+			{Name: "unknown", Value: *v1.NewStructuredValues("a", "b")},
+		},
+		Include: v1.IncludeParamsList{
+			{Params: v1.Params{
+				{Name: "platform", Value: *v1.NewStructuredValues("linux/s390x")},
+				{Name: "flavor", Value: *v1.NewStructuredValues("fast")},
+			}},
+		},
+	}
+
+	params := matrixParams(matrix, specs)
+
+	var names []string
+	for _, param := range params {
+		names = append(names, param.Name)
+	}
+	assert.Equal(t, []string{"platform", "flavor"}, names)
+	assert.Nil(t, matrixParams(nil, specs))
+}
+
+func TestValidatePipelineWithMatrix(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		pipelineYAML  string
+		expectedError bool
+		errorContains string
+	}{
+		{
+			name: "matrix params satisfy required task params",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: matrix-pipeline
+spec:
+  tasks:
+    - name: build
+      matrix:
+        params:
+          - name: platform
+            value:
+              - linux/amd64
+              - linux/arm64
+        include:
+          - name: s390x
+            params:
+              - name: flavor
+                value: slow
+      taskSpec:
+        params:
+          - name: platform
+            type: string
+          - name: flavor
+            type: string
+            default: fast
+        steps:
+          - name: build
+            image: alpine:latest
+            script: echo $(params.platform) $(params.flavor)
+`,
+			expectedError: false,
+		},
+		{
+			name: "matrix include references unknown task param",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: matrix-pipeline
+spec:
+  tasks:
+    - name: build
+      matrix:
+        include:
+          - name: extra
+            params:
+              - name: missing
+                value: value
+      taskSpec:
+        steps:
+          - name: build
+            image: alpine:latest
+            script: echo build
+`,
+			expectedError: true,
+			errorContains: `build PipelineTask matrix: 1 error occurred:
+	* matrix include extra: "missing" parameter is not defined by the Task`,
+		},
+		{
+			name: "matrixed results consumed as array parameter",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: matrix-pipeline
+spec:
+  tasks:
+    - name: build
+      matrix:
+        params:
+          - name: platform
+            value: [linux/amd64, linux/arm64]
+      taskSpec:
+        params:
+          - name: platform
+            type: string
+        results:
+          - name: digest
+            type: string
+        steps:
+          - name: build
+            image: alpine:latest
+            script: echo $(params.platform) > $(results.digest.path)
+    - name: manifest
+      params:
+        - name: digests
+          value: $(tasks.build.results.digest[*])
+      taskSpec:
+        params:
+          - name: digests
+            type: array
+        steps:
+          - name: manifest
+            image: alpine:latest
+            args: ["$(params.digests[*])"]
+`,
+			expectedError: false,
+		},
+		{
+			name: "matrixed results consumed as string parameter",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: matrix-pipeline
+spec:
+  tasks:
+    - name: build
+      matrix:
+        params:
+          - name: platform
+            value: [linux/amd64, linux/arm64]
+      taskSpec:
+        params:
+          - name: platform
+            type: string
+        results:
+          - name: digest
+            type: string
+        steps:
+          - name: build
+            image: alpine:latest
+            script: echo $(params.platform) > $(results.digest.path)
+    - name: deploy
+      params:
+        - name: digest
+          value: $(tasks.build.results.digest)
+      taskSpec:
+        params:
+          - name: digest
+            type: string
+        steps:
+          - name: deploy
+            image: alpine:latest
+            script: echo $(params.digest)
+`,
+			expectedError: true,
+			errorContains: "digest result from matrixed build PipelineTask must be consumed as an array",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := pipelineFromYAML(tt.pipelineYAML)
+			require.NoError(t, err, "Failed to parse pipeline YAML")
+
+			err = ValidatePipeline(ctx, p)
+
+			if tt.expectedError {
+				require.Error(t, err, "Expected validation error but got none")
+				assert.Contains(t, err.Error(), tt.errorContains)
+			} else {
+				assert.NoError(t, err, "Expected no validation error")
+			}
+		})
+	}
+}
+
+func TestValidateMatrixResultConsumption(t *testing.T) {
+	tests := []struct {
+		name           string
+		pipelineYAML   string
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "no matrixed tasks",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: plain
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+    - name: deploy
+      params:
+        - name: digest
+          value: $(tasks.build.results.digest)
+      taskRef:
+        name: deploy
+`,
+			expectNoError: true,
+		},
+		{
+			name: "matrixed results consumed as arrays",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: aggregated
+spec:
+  tasks:
+    - name: build
+      matrix:
+        params:
+          - name: platform
+            value: [linux/amd64, linux/arm64]
+      taskRef:
+        name: build
+    - name: manifest
+      params:
+        - name: digests
+          value: $(tasks.build.results.digest[*])
+      taskRef:
+        name: manifest
+  results:
+    - name: digests
+      value: $(tasks.build.results.digest[*])
+`,
+			expectNoError: true,
+		},
+		{
+			name: "matrixed results consumed as scalars",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: scalar
+spec:
+  tasks:
+    - name: build
+      matrix:
+        params:
+          - name: platform
+            value: [linux/amd64, linux/arm64]
+      taskRef:
+        name: build
+    - name: deploy
+      when:
+        - input: $(tasks.build.results.status)
+          operator: in
+          values: ["ok"]
+      params:
+        - name: digest
+          value: $(tasks.build.results.digest)
+        - name: first
+          value: $(tasks.build.results.digest[0])
+      taskRef:
+        name: deploy
+  finally:
+    - name: notify
+      params:
+        - name: args
+          value:
+            - $(tasks.build.results.url)
+      taskRef:
+        name: notify
+  results:
+    - name: digest
+      value: $(tasks.build.results.digest)
+`,
+			expectedErrors: []string{
+				`digest result from matrixed build PipelineTask must be consumed as an array with $(tasks.build.results.digest[*]), got "$(tasks.build.results.digest)" in PipelineTask deploy parameter digest`,
+				`digest result from matrixed build PipelineTask must be consumed as an array with $(tasks.build.results.digest[*]), got "$(tasks.build.results.digest[0])" in PipelineTask deploy parameter first`,
+				`status result from matrixed build PipelineTask must be consumed as an array with $(tasks.build.results.status[*]), got "$(tasks.build.results.status)" in PipelineTask deploy when expression #0`,
+				`url result from matrixed build PipelineTask must be consumed as an array with $(tasks.build.results.url[*]), got "$(tasks.build.results.url)" in PipelineTask notify parameter args`,
+				`digest result from matrixed build PipelineTask must be consumed as an array with $(tasks.build.results.digest[*]), got "$(tasks.build.results.digest)" in Pipeline result digest`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := pipelineFromYAML(tt.pipelineYAML)
+			require.NoError(t, err, "Failed to parse pipeline YAML")
+
+			err = ValidateMatrixResultConsumption(p.Spec)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+			}
+		})
+	}
+}
+
+func TestParamValueStrings(t *testing.T) {
+	assert.Equal(t, []string{"a"}, paramValueStrings(*v1.NewStructuredValues("a")))
+	assert.Equal(t, []string{"a", "b"}, paramValueStrings(*v1.NewStructuredValues("a", "b")))
+	assert.Equal(t, []string{"1", "2"}, paramValueStrings(*v1.NewObject(map[string]string{"y": "2", "x": "1"})))
+}
@@ -0,0 +1,138 @@
+package validator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// maxNameLength is the maximum length of the names of Tekton resources, which end up in the values
+// of labels, e.g. tekton.dev/pipelineRun
+const maxNameLength = validation.DNS1123LabelMaxLength
+
+// generatedSuffixLength is the length of the random suffix Kubernetes appends to a generateName,
+// which it truncates to fit maxNameLength first
+const generatedSuffixLength = 5
+
+// ValidateObjectMetadata verifies that the name or generateName, the labels, and the annotations of
+// a resource follow the syntax and the length limits of Kubernetes. Values templated by Pipelines as
+// Code, e.g. {{ repo_name }}, are only known once the resource is created, so they are not verified.
+func ValidateObjectMetadata(meta metav1.ObjectMeta) error {
+	var err error
+
+	switch {
+	case isTemplated(meta.Name):
+	case meta.Name != "":
+		for _, msg := range validation.IsDNS1123Subdomain(meta.Name) {
+			err = multierror.Append(err, fmt.Errorf("invalid resource name %q, %s: metadata.name", meta.Name, msg))
+		}
+		if len(meta.Name) > maxNameLength {
+			err = multierror.Append(err, fmt.Errorf("invalid resource name %q, must be no more than %d characters: metadata.name", meta.Name, maxNameLength))
+		}
+	case isTemplated(meta.GenerateName):
+	case meta.GenerateName != "":
+		// The random suffix makes up for a generateName ending with a dash.
+		for _, msg := range validation.IsDNS1123Subdomain(meta.GenerateName + strings.Repeat("x", generatedSuffixLength)) {
+			err = multierror.Append(err, fmt.Errorf("invalid resource generateName %q, %s: metadata.generateName", meta.GenerateName, msg))
+		}
+		if length := maxNameLength - generatedSuffixLength; len(meta.GenerateName) > length {
+			err = multierror.Append(err, warningf(
+				"generateName %q is longer than %d characters, so Kubernetes truncates it to %q: metadata.generateName",
+				meta.GenerateName, length, meta.GenerateName[:length]))
+		}
+	default:
+		err = multierror.Append(err, fmt.Errorf("name or generateName is required: metadata.name"))
+	}
+
+	for _, key := range SortedKeys(meta.Labels) {
+		value := meta.Labels[key]
+		if isTemplated(key) || isTemplated(value) {
+			continue
+		}
+		for _, msg := range validation.IsQualifiedName(key) {
+			err = multierror.Append(err, fmt.Errorf("invalid label key %q, %s: metadata.labels", key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			err = multierror.Append(err, fmt.Errorf("invalid label value %q, %s: metadata.labels[%s]", value, msg, key))
+		}
+	}
+
+	for _, key := range SortedKeys(meta.Annotations) {
+		if isTemplated(key) {
+			continue
+		}
+		// Kubernetes accepts uppercase letters in the names of annotations.
+		for _, msg := range validation.IsQualifiedName(strings.ToLower(key)) {
+			err = multierror.Append(err, fmt.Errorf("invalid annotation key %q, %s: metadata.annotations", key, msg))
+		}
+	}
+	if sizeErr := apivalidation.ValidateAnnotationsSize(meta.Annotations); sizeErr != nil {
+		err = multierror.Append(err, fmt.Errorf("%v: metadata.annotations", sizeErr))
+	}
+
+	return err
+}
+
+// isObjectNameError tells whether a Tekton validation error reports an invalid resource name, which
+// ValidateObjectMetadata reports in more detail, and without rejecting resources with a generateName
+func isObjectNameError(message, path string) bool {
+	return path == "metadata.name" &&
+		(strings.HasPrefix(message, "invalid resource name ") || strings.HasPrefix(message, "Invalid resource name: "))
+}
+
+// ValidatePipelineRunChildNames warns about the PipelineTasks of a pipeline spec whose TaskRuns get
+// a name longer than maxNameLength. Tekton names them after the PipelineRun and the PipelineTask,
+// followed by the index of the combination for a matrix, and replaces the end of longer names with a
+// hash, so they no longer tell which PipelineTask they run. For a generateName, the length of the
+// name is estimated.
+func ValidatePipelineRunChildNames(meta metav1.ObjectMeta, pipelineSpec v1.PipelineSpec) error {
+	runName, path := meta.Name, "metadata.name"
+	if runName == "" && meta.GenerateName != "" {
+		path = "metadata.generateName"
+		runName = meta.GenerateName
+		if length := maxNameLength - generatedSuffixLength; len(runName) > length {
+			runName = runName[:length]
+		}
+		runName += strings.Repeat("x", generatedSuffixLength)
+	}
+	if runName == "" || isTemplated(runName) {
+		return nil
+	}
+
+	var err error
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		childName := runName + "-" + pipelineTask.Name
+		if pipelineTask.IsMatrixed() {
+			// Matrix params referring to params or results have an unknown length, so at least one
+			// digit is assumed.
+			childName += fmt.Sprintf("-%d", max(pipelineTask.Matrix.CountCombinations()-1, 0))
+		}
+		if len(childName) > maxNameLength {
+			err = multierror.Append(err, withPath(path, warningf(
+				"TaskRuns of the %s PipelineTask are named after the PipelineRun in %d characters, more than %d, so Tekton truncates their names with a hash",
+				pipelineTask.Name, len(childName), maxNameLength)))
+		}
+	}
+	return err
+}
+
+// isTemplated tells whether a value is templated by Pipelines as Code
+func isTemplated(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+// SortedKeys returns the keys of a map, e.g. of labels, annotations, or resources, in order
+func SortedKeys[K ~string, V any](values map[K]V) []K {
+	keys := make([]K, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,180 @@
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateObjectMetadata(t *testing.T) {
+	tests := []struct {
+		name             string
+		meta             metav1.ObjectMeta
+		expectedErrors   []string
+		expectedWarnings []string
+	}{
+		{
+			name: "valid metadata",
+			meta: metav1.ObjectMeta{
+				Name:        "build.v1",
+				Labels:      map[string]string{"app.kubernetes.io/name": "build", "empty": ""},
+				Annotations: map[string]string{"example.com/Owner": "Team A!"},
+			},
+		},
+		{
+			name: "generateName",
+			meta: metav1.ObjectMeta{GenerateName: "build-"},
+		},
+		{
+			name: "templated by Pipelines as Code",
+			meta: metav1.ObjectMeta{
+				Name:   "{{ repo_name }}-on-push",
+				Labels: map[string]string{"branch": "{{ source_branch }}"},
+			},
+		},
+		{
+			name: "invalid name",
+			meta: metav1.ObjectMeta{Name: "Build_" + strings.Repeat("a", 60)},
+			expectedErrors: []string{
+				`invalid resource name "Build_` + strings.Repeat("a", 60) + `", a lowercase RFC 1123 subdomain`,
+				`must be no more than 63 characters: metadata.name`,
+			},
+		},
+		{
+			name:           "invalid generateName",
+			meta:           metav1.ObjectMeta{GenerateName: "Build-"},
+			expectedErrors: []string{`invalid resource generateName "Build-", a lowercase RFC 1123 subdomain`},
+		},
+		{
+			name: "long generateName",
+			meta: metav1.ObjectMeta{GenerateName: strings.Repeat("a", 60) + "-"},
+			expectedWarnings: []string{
+				`generateName "` + strings.Repeat("a", 60) + `-" is longer than 58 characters, so Kubernetes truncates it to "` + strings.Repeat("a", 58) + `": metadata.generateName`,
+			},
+		},
+		{
+			name:           "missing name",
+			expectedErrors: []string{"name or generateName is required: metadata.name"},
+		},
+		{
+			name: "invalid labels and annotations",
+			meta: metav1.ObjectMeta{
+				Name:        "build",
+				Labels:      map[string]string{"bad key": "ok", "app": "not ok!", "long": strings.Repeat("a", 64)},
+				Annotations: map[string]string{"a/b/c": "x"},
+			},
+			expectedErrors: []string{
+				`invalid label value "not ok!"`,
+				`: metadata.labels[app]`,
+				`invalid label key "bad key"`,
+				`must be no more than 63 characters: metadata.labels[long]`,
+				`invalid annotation key "a/b/c"`,
+			},
+		},
+		{
+			name: "large annotations",
+			meta: metav1.ObjectMeta{
+				Name:        "build",
+				Annotations: map[string]string{"example.com/data": strings.Repeat("a", 256*1024)},
+			},
+			expectedErrors: []string{"is larger than limit 262144: metadata.annotations"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateObjectMetadata(tt.meta)
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, WithoutWarnings(err))
+			} else {
+				require.Error(t, WithoutWarnings(err))
+				for _, expected := range tt.expectedErrors {
+					assert.Contains(t, WithoutWarnings(err).Error(), expected)
+				}
+			}
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+		})
+	}
+}
+
+func TestValidatePipelineRunChildNames(t *testing.T) {
+	pipelineSpec := v1.PipelineSpec{
+		Tasks: []v1.PipelineTask{
+			{Name: "build"},
+			{Name: "build-container-image-for-every-platform"},
+			{Name: "test", Matrix: &v1.Matrix{Params: v1.Params{{
+				Name:  "platform",
+				Value: *v1.NewStructuredValues("a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"),
+			}}}},
+		},
+		Finally: []v1.PipelineTask{{Name: "notify-slack-channel"}},
+	}
+
+	tests := []struct {
+		name     string
+		meta     metav1.ObjectMeta
+		expected []string
+	}{
+		{
+			name: "short name",
+			meta: metav1.ObjectMeta{Name: "run"},
+		},
+		{
+			name: "long name",
+			meta: metav1.ObjectMeta{Name: "my-application-on-pull-request"},
+			expected: []string{
+				"TaskRuns of the build-container-image-for-every-platform PipelineTask are named after the PipelineRun in 71 characters, more than 63, so Tekton truncates their names with a hash: metadata.name",
+			},
+		},
+		{
+			name: "long generateName",
+			meta: metav1.ObjectMeta{GenerateName: strings.Repeat("a", 50) + "-"},
+			expected: []string{
+				"TaskRuns of the build-container-image-for-every-platform PipelineTask are named after the PipelineRun in 97 characters, more than 63, so Tekton truncates their names with a hash: metadata.generateName",
+				"TaskRuns of the test PipelineTask are named after the PipelineRun in 64 characters, more than 63, so Tekton truncates their names with a hash: metadata.generateName",
+				"TaskRuns of the notify-slack-channel PipelineTask are named after the PipelineRun in 77 characters, more than 63, so Tekton truncates their names with a hash: metadata.generateName",
+			},
+		},
+		{
+			name: "templated name",
+			meta: metav1.ObjectMeta{Name: "{{ repo_name }}-on-pull-request-for-the-main-branch"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelineRunChildNames(tt.meta, pipelineSpec)
+
+			assert.NoError(t, WithoutWarnings(err))
+			assert.Equal(t, tt.expected, Warnings(err))
+		})
+	}
+}
+
+func TestValidateTaskRunGenerateName(t *testing.T) {
+	tr := v1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "build-"},
+		Spec: v1.TaskRunSpec{
+			TaskSpec: &v1.TaskSpec{Steps: []v1.Step{{Name: "build", Image: "registry.io/build:1.0", Script: "build"}}},
+		},
+	}
+	assert.NoError(t, ValidateTaskRun(context.Background(), tr))
+
+	tr.ObjectMeta = metav1.ObjectMeta{Name: "Build"}
+	assert.Equal(t, []string{RuleMetadata.ID}, findingRules(ValidateTaskRun(context.Background(), tr)))
+}
+
+// findingRules returns the rules of the findings of an error
+func findingRules(err error) []string {
+	var rules []string
+	for _, finding := range Findings(err) {
+		rules = append(rules, finding.Rule)
+	}
+	return rules
+}
@@ -0,0 +1,189 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/taskindex"
+)
+
+// nestedPipelineGuidance explains the supported alternatives to nesting Pipelines
+const nestedPipelineGuidance = "instead, reference a Task with taskRef or embed one with taskSpec, and run other Pipelines with their own PipelineRun"
+
+// nestedPipelineField returns the field nesting a Pipeline in a PipelineTask, if any
+func nestedPipelineField(pipelineTask v1.PipelineTask) string {
+	switch {
+	case pipelineTask.PipelineRef != nil:
+		return "pipelineRef"
+	case pipelineTask.PipelineSpec != nil:
+		return "pipelineSpec"
+	}
+	return ""
+}
+
+// ValidateNestedPipelines warns about PipelineTasks nesting a Pipeline, i.e. pipelines-in-pipelines.
+// Tekton only accepts them with the alpha feature gate and does not run them yet.
+func ValidateNestedPipelines(pipelineSpec v1.PipelineSpec) error {
+	var err error
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		for i, pipelineTask := range section.pipelineTasks {
+			if field := nestedPipelineField(pipelineTask); field != "" {
+				err = multierror.Append(err, warningf(
+					"PipelineTask %q nests a Pipeline, which Tekton does not run yet; %s: spec.%s[%d].%s",
+					pipelineTask.Name, nestedPipelineGuidance, section.name, i, field))
+			}
+		}
+	}
+	return err
+}
+
+// maxPipelineNesting bounds how deep child Pipelines are validated, which also stops the validation
+// of Pipelines referring to themselves
+const maxPipelineNesting = 10
+
+type pipelineNestingKey struct{}
+
+// pipelineSpecFromPipelineTask returns the spec of the child Pipeline of a PipelineTask. It is either
+// embedded, resolved through the bundles, git, or hub resolver, or resolved by name from the
+// TaskIndex.
+func pipelineSpecFromPipelineTask(ctx context.Context, pipelineTask v1.PipelineTask, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.PipelineSpec, error) {
+	if pipelineTask.PipelineSpec != nil {
+		return pipelineTask.PipelineSpec, nil
+	}
+
+	ref := pipelineTask.PipelineRef
+	if ref != nil && isRemoteResolver(ref.Resolver) {
+		source, data, err := resolveRemoteResource(ctx, "pipeline", ref.ResolverRef, pipelineParams, runtimeParams)
+		if err != nil {
+			return nil, err
+		}
+		entry, err := taskindex.DecodePipeline(ctx, source, data)
+		if err != nil {
+			return nil, err
+		}
+		return &entry.PipelineSpec, nil
+	}
+
+	if ref != nil && ref.Resolver == "" && ref.Name != "" {
+		if index := optionsFromContext(ctx).TaskIndex; index != nil {
+			entry, err := index.Lookup("Pipeline", ref.Name)
+			if err != nil {
+				return nil, err
+			}
+			return &entry.PipelineSpec, nil
+		}
+	}
+
+	return nil, errors.New("unable to retrieve pipeline spec for pipeline task")
+}
+
+// validateChildPipeline validates the child Pipeline run by the named PipelineTask like a standalone
+// Pipeline
+func validateChildPipeline(ctx context.Context, name string, pipelineSpec v1.PipelineSpec) error {
+	depth, _ := ctx.Value(pipelineNestingKey{}).(int)
+	if depth >= maxPipelineNesting {
+		return fmt.Errorf("child pipelines are nested more than %d levels deep, does a Pipeline refer to itself?", maxPipelineNesting)
+	}
+	ctx = context.WithValue(ctx, pipelineNestingKey{}, depth+1)
+	// The child Pipeline only receives the params and workspaces passed by the PipelineTask, rather
+	// than those propagated from a PipelineRun.
+	ctx = context.WithValue(ctx, propagationKey{}, nil)
+	// Policies are evaluated against the parent Pipeline only.
+	ctx = withoutPolicies(ctx)
+
+	p := v1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       pipelineSpec,
+	}
+	return ValidatePipeline(ctx, p)
+}
+
+// pipelineBoundarySpec describes the params, results, and workspaces of a child Pipeline as a Task
+// would, so that the PipelineTask running it is checked like the PipelineTasks running Tasks
+func pipelineBoundarySpec(pipelineSpec v1.PipelineSpec) *v1.TaskSpec {
+	taskSpec := &v1.TaskSpec{Params: pipelineSpec.Params}
+	for _, result := range pipelineSpec.Results {
+		taskSpec.Results = append(taskSpec.Results, v1.TaskResult{Name: result.Name, Type: result.Type})
+	}
+	for _, workspace := range pipelineSpec.Workspaces {
+		taskSpec.Workspaces = append(taskSpec.Workspaces, v1.WorkspaceDeclaration{
+			Name:     workspace.Name,
+			Optional: workspace.Optional,
+		})
+	}
+	return taskSpec
+}
+
+// isNestedPipelineGateError tells whether a Tekton validation error only reports that nesting a
+// Pipeline requires the alpha feature gate, which ValidateNestedPipelines reports in more detail
+func isNestedPipelineGateError(message string) bool {
+	return strings.HasPrefix(message, "pipelineRef requires ") || strings.HasPrefix(message, "pipelineSpec requires ")
+}
+
+// ValidatePipelineTaskNesting reports Pipelines nested under the taskRef or taskSpec of a
+// PipelineTask in the raw YAML of a Pipeline, or of a PipelineRun embedding a pipeline spec. Such
+// fields are unknown to Tekton, so they would otherwise be dropped silently.
+func ValidatePipelineTaskNesting(rawYAML []byte) error {
+	type rawPipelineSpec struct {
+		Tasks   []map[string]any `json:"tasks"`
+		Finally []map[string]any `json:"finally"`
+	}
+	var raw struct {
+		Kind string `json:"kind"`
+		Spec struct {
+			rawPipelineSpec
+			PipelineSpec *rawPipelineSpec `json:"pipelineSpec"`
+		} `json:"spec"`
+	}
+	if yaml.Unmarshal(rawYAML, &raw) != nil {
+		// Tasks or finally which are not lists of objects fail decoding the pipeline spec instead.
+		return nil
+	}
+
+	spec, prefix := &raw.Spec.rawPipelineSpec, "spec"
+	if raw.Kind == "PipelineRun" {
+		spec, prefix = raw.Spec.PipelineSpec, "spec.pipelineSpec"
+	}
+	if spec == nil {
+		return nil
+	}
+
+	var err error
+	check := func(section string, pipelineTasks []map[string]any) {
+		for i, pipelineTask := range pipelineTasks {
+			path := fmt.Sprintf("%s.%s[%d]", prefix, section, i)
+			if taskRef, ok := pipelineTask["taskRef"].(map[string]any); ok {
+				for _, field := range []string{"pipelineRef", "pipelineSpec"} {
+					if _, found := taskRef[field]; found {
+						err = multierror.Append(err, fmt.Errorf(
+							"%s is not a field of taskRef, which only refers to Tasks; %s: %s.taskRef.%s",
+							field, nestedPipelineGuidance, path, field))
+					}
+				}
+				if kind, _ := taskRef["kind"].(string); kind == "Pipeline" {
+					err = multierror.Append(err, fmt.Errorf(
+						"taskRef cannot refer to a Pipeline; %s: %s.taskRef.kind", nestedPipelineGuidance, path))
+				}
+			}
+			if taskSpec, ok := pipelineTask["taskSpec"].(map[string]any); ok {
+				for _, field := range []string{"pipelineRef", "pipelineSpec", "tasks"} {
+					if _, found := taskSpec[field]; found {
+						err = multierror.Append(err, fmt.Errorf(
+							"%s is not a field of taskSpec, which embeds a Task; %s: %s.taskSpec.%s",
+							field, nestedPipelineGuidance, path, field))
+					}
+				}
+			}
+		}
+	}
+	check("tasks", spec.Tasks)
+	check("finally", spec.Finally)
+	return err
+}
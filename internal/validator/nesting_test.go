@@ -0,0 +1,288 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+
+	"github.com/lcarva/tektor/internal/taskindex"
+)
+
+func TestValidatePipelineTaskNesting(t *testing.T) {
+	tests := []struct {
+		name           string
+		rawYAML        string
+		expectedErrors []string
+	}{
+		{
+			name: "no nesting",
+			rawYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+    - name: test
+      taskSpec:
+        steps:
+          - name: test
+            image: alpine:latest
+`,
+		},
+		{
+			name: "pipelines nested under taskRef and taskSpec",
+			rawYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+        pipelineRef:
+          name: build-pipeline
+    - name: deploy
+      taskRef:
+        name: deploy-pipeline
+        kind: Pipeline
+  finally:
+    - name: notify
+      taskSpec:
+        tasks:
+          - name: slack
+            taskRef:
+              name: slack
+`,
+			expectedErrors: []string{
+				"pipelineRef is not a field of taskRef, which only refers to Tasks; instead, reference a Task with taskRef or embed one with taskSpec, and run other Pipelines with their own PipelineRun: spec.tasks[0].taskRef.pipelineRef",
+				"taskRef cannot refer to a Pipeline; instead, reference a Task with taskRef or embed one with taskSpec, and run other Pipelines with their own PipelineRun: spec.tasks[1].taskRef.kind",
+				"tasks is not a field of taskSpec, which embeds a Task; instead, reference a Task with taskRef or embed one with taskSpec, and run other Pipelines with their own PipelineRun: spec.finally[0].taskSpec.tasks",
+			},
+		},
+		{
+			name: "PipelineRun embedding a pipeline spec",
+			rawYAML: `
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+spec:
+  pipelineSpec:
+    tasks:
+      - name: build
+        taskSpec:
+          pipelineSpec:
+            tasks: []
+`,
+			expectedErrors: []string{
+				"pipelineSpec is not a field of taskSpec, which embeds a Task; instead, reference a Task with taskRef or embed one with taskSpec, and run other Pipelines with their own PipelineRun: spec.pipelineSpec.tasks[0].taskSpec.pipelineSpec",
+			},
+		},
+		{
+			name: "PipelineRun referencing a pipeline",
+			rawYAML: `
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+spec:
+  pipelineRef:
+    name: build
+`,
+		},
+		{
+			name:    "malformed YAML",
+			rawYAML: "spec: [",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelineTaskNesting([]byte(tt.rawYAML))
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidateNestedPipelines(t *testing.T) {
+	index := taskindex.New()
+	index.Add(taskindex.Entry{
+		Kind:   "Pipeline",
+		Name:   "build",
+		Source: "pipelines/build.yaml:1 (Pipeline build)",
+		PipelineSpec: v1.PipelineSpec{
+			Params:     v1.ParamSpecs{{Name: "url", Type: v1.ParamTypeString}},
+			Workspaces: []v1.PipelineWorkspaceDeclaration{{Name: "source"}},
+			Results: []v1.PipelineResult{{
+				Name: "digest", Type: v1.ResultsTypeString, Value: *v1.NewStructuredValues("$(tasks.build.results.digest)"),
+			}},
+			Tasks: []v1.PipelineTask{{
+				Name:       "build",
+				Workspaces: []v1.WorkspacePipelineTaskBinding{{Name: "source", Workspace: "source"}},
+				TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+					Workspaces: []v1.WorkspaceDeclaration{{Name: "source"}},
+					Results:    []v1.TaskResult{{Name: "digest"}},
+					Steps: []v1.Step{{
+						Name: "build", Image: "alpine:latest", Script: "make -C $(workspaces.source.path) > $(results.digest.path)",
+					}},
+				}},
+			}},
+		},
+	})
+	index.Add(taskindex.Entry{
+		Kind: "Pipeline",
+		Name: "loop",
+		PipelineSpec: v1.PipelineSpec{
+			Tasks: []v1.PipelineTask{{Name: "again", PipelineRef: &v1.PipelineRef{Name: "loop"}}},
+		},
+	})
+	ctx := WithOptions(context.Background(), Options{TaskIndex: index})
+
+	tests := []struct {
+		name           string
+		pipelineYAML   string
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "child pipeline from the index",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: parent
+spec:
+  workspaces:
+    - name: shared
+  results:
+    - name: digest
+      value: $(tasks.child.results.digest)
+  tasks:
+    - name: child
+      params:
+        - name: url
+          value: https://github.com/example/repo.git
+      workspaces:
+        - name: source
+          workspace: shared
+      pipelineRef:
+        name: build
+`,
+			expectNoError: true,
+		},
+		{
+			name: "params and results across the boundary",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: parent
+spec:
+  workspaces:
+    - name: shared
+  results:
+    - name: image
+      value: $(tasks.child.results.image)
+  tasks:
+    - name: child
+      workspaces:
+        - name: source
+          workspace: shared
+      pipelineRef:
+        name: build
+`,
+			expectedErrors: []string{
+				"ERROR: child PipelineTask: ",
+				`"url" parameter is required`,
+				"non-existent image result from child PipelineTask",
+			},
+		},
+		{
+			name: "embedded child pipeline",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: parent
+spec:
+  finally:
+    - name: cleanup
+      pipelineSpec:
+        tasks:
+          - name: cleanup
+            taskSpec:
+              steps:
+                - name: cleanup
+                  image: alpine:latest
+                  script: echo $(params.missing)
+`,
+			expectedErrors: []string{
+				`cleanup PipelineTask child pipeline: `,
+				`non-existent variable in "echo $(params.missing)"`,
+			},
+		},
+		{
+			name: "unknown child pipeline",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: parent
+spec:
+  tasks:
+    - name: child
+      pipelineRef:
+        name: test
+`,
+			expectedErrors: []string{`retrieving pipeline spec from child pipeline task: Pipeline "test" not found in task or pipeline directories`},
+		},
+		{
+			name: "pipeline referring to itself",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: parent
+spec:
+  tasks:
+    - name: child
+      pipelineRef:
+        name: loop
+`,
+			expectedErrors: []string{"child pipelines are nested more than 10 levels deep, does a Pipeline refer to itself?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := pipelineFromYAML(tt.pipelineYAML)
+			require.NoError(t, err)
+
+			err = ValidatePipelineWithYAML(ctx, p, []byte(tt.pipelineYAML))
+			// Tekton does not run nested Pipelines yet, which is always reported as a warning.
+			require.NotEmpty(t, Warnings(err))
+			assert.Contains(t, Warnings(err)[0], "nests a Pipeline, which Tekton does not run yet; "+nestedPipelineGuidance)
+			// The generic feature gate error is superseded.
+			assert.NotContains(t, err.Error(), "feature gate")
+
+			err = WithoutWarnings(err)
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package validator
+
+import (
+	"context"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+
+	"github.com/lcarva/tektor/internal/celrule"
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/plugin"
+	"github.com/lcarva/tektor/internal/policy"
+	"github.com/lcarva/tektor/internal/remotecache"
+	"github.com/lcarva/tektor/internal/taskindex"
+)
+
+// ProfileKonflux enables the rules encoding the conventions of Konflux build pipelines
+const ProfileKonflux = "konflux"
+
+// ProfileSecurity enables the rules verifying steps do not weaken the isolation of their pod, see
+// SecurityRules
+const ProfileSecurity = "security"
+
+// ProfileLint enables the rules verifying standalone Tasks follow the best practices, see LintRules
+const ProfileLint = "lint"
+
+// Profiles lists the rule profiles that can be enabled via Options
+var Profiles = []string{ProfileKonflux, ProfileSecurity, ProfileLint}
+
+// ProfileSet is a set of rule profiles, by name
+type ProfileSet map[string]bool
+
+// NewProfileSet returns the ProfileSet of the given profiles
+func NewProfileSet(names ...string) ProfileSet {
+	if len(names) == 0 {
+		return nil
+	}
+	set := make(ProfileSet, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// Options controls optional validation behavior that is not enabled by default
+type Options struct {
+	// CheckImages enables checks that fetch the configuration of step images from their registry.
+	CheckImages bool
+	// CheckUnusedResults enables the check of the results of PipelineTasks which nothing consumes.
+	CheckUnusedResults bool
+	// Profiles enables additional sets of rules, see the Profiles variable. Profiles combine, e.g.
+	// lint with konflux.
+	Profiles ProfileSet
+	// TaskIndex resolves PipelineTasks which refer to a Task by name only.
+	TaskIndex *taskindex.Index
+	// TaskResolver retrieves the Tasks PipelineTasks refer to, defaults to DefaultTaskResolver.
+	TaskResolver TaskResolver
+	// HubURL overrides the URL of Artifact Hub, or of Tekton Hub for references of the tekton type,
+	// from which the hub resolver fetches Tasks and Pipelines.
+	HubURL string
+	// Cache stores the Tasks and Pipelines resolved from bundles and git repositories across runs.
+	Cache *remotecache.Cache
+	// GitRepos shares the checkouts of the git repositories Tasks and Pipelines are resolved from,
+	// if set, otherwise each resolution clones its repository.
+	GitRepos *GitRepoCache
+	// Credentials authenticate the git repositories and registries remote Tasks and Pipelines are
+	// resolved from. Registries without credentials use the ambient docker keychain.
+	Credentials config.Credentials
+	// GitEnvTokens authenticates the HTTPS URLs of the well-known git hosts without credentials with
+	// the token of the environment, e.g. GITHUB_TOKEN, see gitTokenEnv.
+	GitEnvTokens bool
+	// ResolveTimeout bounds each attempt to resolve a remote Task or Pipeline, unless zero.
+	ResolveTimeout time.Duration
+	// ResolveRetries is how many times a resolution failing for a transient reason is retried.
+	ResolveRetries int
+	// ResolveBackoff is the delay before the first retry, which doubles for each further retry.
+	ResolveBackoff time.Duration
+	// Policies are evaluated against the resolved Pipelines and PipelineRuns, if set.
+	Policies *policy.Engine
+	// CustomRules are evaluated against the resolved Pipelines and PipelineRuns, if set.
+	CustomRules *celrule.Set
+	// Plugins are run against the resolved Pipelines and PipelineRuns.
+	Plugins []plugin.Plugin
+	// Progress, if set, receives a progress indicator of the resolution of the remote Tasks and
+	// Pipelines of each Pipeline, e.g. a terminal.
+	Progress io.Writer
+	// ClusterObjects, if set, verifies the ServiceAccounts, Secrets, and ConfigMaps runs and the env
+	// of steps refer to exist.
+	ClusterObjects ObjectLookup
+	// Strict enables the pedantic rules, e.g. RuleDescriptions. Promoting warnings to errors is left
+	// to the callers, see PromoteWarnings.
+	Strict bool
+	// Untrusted marks input coming from someone else than the user running tektor, e.g. the bodies
+	// of the requests of the server, see WithUntrustedInput.
+	Untrusted bool
+	// BaseDir is the directory of the file being validated, which the relative paths of local
+	// bundles are relative to. The working directory is used if empty.
+	BaseDir string
+}
+
+// IsKnownProfile reports whether name is one of Profiles
+func IsKnownProfile(name string) bool {
+	return slices.Contains(Profiles, name)
+}
+
+// hasProfile tells whether the validation options carried by ctx enable the rule profile name
+func hasProfile(ctx context.Context, name string) bool {
+	return optionsFromContext(ctx).Profiles[name]
+}
+
+type optionsKey struct{}
+
+// WithOptions returns a copy of ctx carrying the given validation options
+func WithOptions(ctx context.Context, opts Options) context.Context {
+	return context.WithValue(ctx, optionsKey{}, opts)
+}
+
+// WithTaskIndex returns a copy of ctx whose validation options use the given TaskIndex
+func WithTaskIndex(ctx context.Context, index *taskindex.Index) context.Context {
+	opts := optionsFromContext(ctx)
+	opts.TaskIndex = index
+	return WithOptions(ctx, opts)
+}
+
+// WithProgress returns a copy of ctx whose validation options report the progress of remote
+// resolutions to w
+func WithProgress(ctx context.Context, w io.Writer) context.Context {
+	opts := optionsFromContext(ctx)
+	opts.Progress = w
+	return WithOptions(ctx, opts)
+}
+
+// WithBaseDir returns a copy of ctx whose validation options resolve the relative paths of local
+// bundles against dir, the directory of the file being validated
+func WithBaseDir(ctx context.Context, dir string) context.Context {
+	opts := optionsFromContext(ctx)
+	opts.BaseDir = dir
+	return WithOptions(ctx, opts)
+}
+
+// WithNewRun returns a copy of ctx whose validation options start a new run, e.g. for each request
+// of the server, which does not reuse the checkouts of the branches and tags of the git
+// repositories of the previous runs, since they move
+func WithNewRun(ctx context.Context) context.Context {
+	opts := optionsFromContext(ctx)
+	opts.GitRepos = opts.GitRepos.newRun()
+	return WithOptions(ctx, opts)
+}
+
+// WithUntrustedInput returns a copy of ctx whose validation options do not hand the credentials,
+// including those of the environment, the cluster objects, the plugins, nor the files of the user,
+// as local bundles, to the input, since it may come from anyone. Neither do they read the remote
+// cache nor the checkouts kept on disk, which hold what credentials resolved before.
+func WithUntrustedInput(ctx context.Context) context.Context {
+	opts := optionsFromContext(ctx)
+	opts.Credentials = config.Credentials{}
+	opts.GitEnvTokens = false
+	opts.ClusterObjects = nil
+	opts.Plugins = nil
+	opts.Cache = nil
+	if opts.GitRepos != nil {
+		opts.GitRepos = NewGitRepoCache("")
+	}
+	opts.Untrusted = true
+	return WithOptions(ctx, opts)
+}
+
+// IsUntrustedInput tells whether the validation options carried by ctx mark the input as untrusted,
+// see WithUntrustedInput
+func IsUntrustedInput(ctx context.Context) bool {
+	return optionsFromContext(ctx).Untrusted
+}
+
+// TaskIndexFromContext returns the TaskIndex of the validation options carried by ctx, if any
+func TaskIndexFromContext(ctx context.Context) *taskindex.Index {
+	return optionsFromContext(ctx).TaskIndex
+}
+
+// keychain returns the keychain authenticating the registries of the validation options carried by
+// ctx. Untrusted input does not fall back to the docker keychain.
+func keychain(ctx context.Context) authn.Keychain {
+	opts := optionsFromContext(ctx)
+	if opts.Untrusted {
+		return opts.Credentials.Keychain(authn.NewMultiKeychain())
+	}
+	return opts.Credentials.Keychain(authn.DefaultKeychain)
+}
+
+// optionsFromContext returns the validation options carried by ctx, or the defaults
+func optionsFromContext(ctx context.Context) Options {
+	if opts, ok := ctx.Value(optionsKey{}).(Options); ok {
+		return opts
+	}
+	return Options{}
+}
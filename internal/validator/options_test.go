@@ -0,0 +1,44 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/plugin"
+	"github.com/lcarva/tektor/internal/remotecache"
+)
+
+func TestOptionsFromContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, Options{}, optionsFromContext(ctx), "Expected default options without WithOptions")
+
+	opts := Options{CheckImages: true}
+	assert.Equal(t, opts, optionsFromContext(WithOptions(ctx, opts)))
+}
+
+func TestWithUntrustedInput(t *testing.T) {
+	ctx := WithOptions(context.Background(), Options{
+		CheckImages:    true,
+		Credentials:    config.Credentials{Git: []config.GitCredential{{Host: "github.com", Token: "secret"}}},
+		ClusterObjects: fakeObjectLookup{},
+		GitEnvTokens:   true,
+		Plugins:        []plugin.Plugin{{Name: "org", Path: "/bin/true"}},
+		Cache:          remotecache.New(t.TempDir(), 0),
+	})
+	assert.False(t, IsUntrustedInput(ctx))
+	untrusted := WithUntrustedInput(ctx)
+	assert.True(t, IsUntrustedInput(untrusted))
+	assert.Equal(t, Options{CheckImages: true, Untrusted: true}, optionsFromContext(untrusted))
+
+	// The checkouts are still shared within a run, but not those kept on disk.
+	ctx = WithOptions(context.Background(), Options{GitRepos: NewGitRepoCache(t.TempDir())})
+	assert.Equal(t, NewGitRepoCache(""), optionsFromContext(WithUntrustedInput(ctx)).GitRepos)
+}
+
+func TestIsKnownProfile(t *testing.T) {
+	assert.True(t, IsKnownProfile(ProfileKonflux))
+	assert.False(t, IsKnownProfile("unknown"))
+}
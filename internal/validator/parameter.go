@@ -3,35 +3,70 @@ package validator
 import (
 	"fmt"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
 )
 
 // paramRefRegex matches parameter references in the format $(params.param-name)
 var paramRefRegex = regexp.MustCompile(`\$\(params\.([^)]*)\)`)
 
+// wholeValueRefRegex matches values made up of a single star reference, e.g.
+// $(tasks.build.results.digests[*]), which Tekton expands into an array or object
+var wholeValueRefRegex = regexp.MustCompile(`^\$\([^()]+\[\*\]\)$`)
+
 // ValidateParameterReferences validates that all parameter references in the pipeline YAML
-// match the defined parameters in the pipeline spec
+// match the defined parameters in the pipeline spec. References within an embedded taskSpec or
+// pipelineSpec may also refer to the parameters declared by that spec.
 func ValidateParameterReferences(pipelineSpec v1.PipelineSpec, rawYAML []byte) error {
+	return validateParameterReferences(pipelineSpec, rawYAML, nil)
+}
+
+// validateParameterReferences is like ValidateParameterReferences but also accepts references to
+// the given parameters, which are propagated from a PipelineRun embedding the pipeline spec
+func validateParameterReferences(pipelineSpec v1.PipelineSpec, rawYAML []byte, propagatedParams []string) error {
 	var err error
 
-	// Extract all parameter references from the YAML content
-	paramRefs := extractParameterReferences(string(rawYAML))
+	// Count the parameter references in the YAML content
+	paramRefs := countParameterReferences(string(rawYAML))
 
 	// Create a map of defined parameters for quick lookup
 	definedParams := make(map[string]bool)
 	for _, param := range pipelineSpec.Params {
 		definedParams[param.Name] = true
 	}
+	for _, name := range propagatedParams {
+		definedParams[name] = true
+	}
+
+	// References satisfied by the parameters of the embedded taskSpec or pipelineSpec they appear in
+	scopedRefs := embeddedParameterReferences(pipelineSpec)
+	// References in when expressions and displayNames are verified by ValidateWhenParameterReferences
+	// and ValidateDisplayNames, with their path.
+	for _, ref := range whenParameterReferences(pipelineSpec, "spec") {
+		scopedRefs[ref.ref]++
+	}
+	for ref, count := range displayNameParameterReferences(pipelineSpec) {
+		scopedRefs[ref] += count
+	}
+
+	refNames := make([]string, 0, len(paramRefs))
+	for paramRef := range paramRefs {
+		refNames = append(refNames, paramRef)
+	}
+	sort.Strings(refNames)
 
 	// Check each parameter reference
-	for _, paramRef := range paramRefs {
-		if paramRef == "" {
+	for _, paramRef := range refNames {
+		if paramRef == "" && scopedRefs[paramRef] < paramRefs[paramRef] {
 			err = multierror.Append(err, fmt.Errorf(
 				"parameter reference $(params.) not defined in pipeline spec"))
-		} else if !definedParams[paramRef] {
+		} else if !definedParams[paramRefName(paramRef)] && scopedRefs[paramRef] < paramRefs[paramRef] {
 			err = multierror.Append(err, fmt.Errorf(
 				"parameter reference $(params.%s) not defined in pipeline spec",
 				paramRef))
@@ -41,6 +76,63 @@ func ValidateParameterReferences(pipelineSpec v1.PipelineSpec, rawYAML []byte) e
 	return err
 }
 
+// embeddedParameterReferences counts the parameter references within the embedded taskSpecs and
+// pipelineSpecs of the pipeline spec which refer to a parameter declared by that same spec, or by
+// a spec embedded in it
+func embeddedParameterReferences(pipelineSpec v1.PipelineSpec) map[string]int {
+	scopedRefs := make(map[string]int)
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		var params v1.ParamSpecs
+		var spec any
+		nestedRefs := map[string]int{}
+		switch {
+		case pipelineTask.TaskSpec != nil:
+			params, spec = pipelineTask.TaskSpec.Params, pipelineTask.TaskSpec
+		case pipelineTask.PipelineSpec != nil:
+			params, spec = pipelineTask.PipelineSpec.Params, pipelineTask.PipelineSpec
+			nestedRefs = embeddedParameterReferences(*pipelineTask.PipelineSpec)
+		default:
+			continue
+		}
+		declared := make(map[string]bool)
+		for _, param := range params {
+			declared[param.Name] = true
+		}
+		content, err := yaml.Marshal(spec)
+		if err != nil {
+			continue
+		}
+		for paramRef, count := range countParameterReferences(string(content)) {
+			if declared[paramRefName(paramRef)] {
+				scopedRefs[paramRef] += count
+			} else {
+				scopedRefs[paramRef] += min(nestedRefs[paramRef], count)
+			}
+		}
+	}
+	return scopedRefs
+}
+
+// countParameterReferences counts the occurrences of each parameter reference in the YAML content
+func countParameterReferences(yamlContent string) map[string]int {
+	counts := make(map[string]int)
+	for _, match := range paramRefRegex.FindAllStringSubmatch(yamlContent, -1) {
+		if len(match) > 1 {
+			counts[strings.TrimSpace(match[1])]++
+		}
+	}
+	return counts
+}
+
+// paramRefName returns the name of the parameter a reference refers to, e.g. "config" for
+// $(params.config.env) and "tags" for $(params.tags[*])
+func paramRefName(paramRef string) string {
+	if i := strings.IndexAny(paramRef, ".["); i >= 0 {
+		return paramRef[:i]
+	}
+	return paramRef
+}
+
 // extractParameterReferences extracts all unique parameter references from the YAML content
 func extractParameterReferences(yamlContent string) []string {
 	matches := paramRefRegex.FindAllStringSubmatch(yamlContent, -1)
@@ -88,6 +180,11 @@ func validatePipelineTaskParameters(pipelineTaskParams []v1.Param, taskParams []
 			pipelineTaskParamType = "string"
 		}
 
+		if pipelineTaskParamType == "string" && (taskParamType == "array" || taskParamType == "object") &&
+			wholeValueRefRegex.MatchString(strings.TrimSpace(pipelineTaskParam.Value.StringVal)) {
+			pipelineTaskParamType = taskParamType
+		}
+
 		if pipelineTaskParamType != taskParamType {
 			err = multierror.Append(err, fmt.Errorf(
 				"%q parameter has the incorrect type, got %q, want %q",
@@ -126,3 +223,247 @@ func getTaskParam(name string, taskParams []v1.ParamSpec) (v1.ParamSpec, bool) {
 	}
 	return v1.ParamSpec{}, false
 }
+
+// ValidateUnusedPipelineParams warns about the params a Pipeline declares but never references,
+// whether in the params, when expressions, or matrices of its PipelineTasks, in its results, or in
+// the specs it embeds. The path is the one of the pipeline spec.
+func ValidateUnusedPipelineParams(pipelineSpec v1.PipelineSpec, path string) error {
+	unused, err := unusedParams(pipelineSpec.Params, pipelineSpec)
+	if err != nil {
+		return err
+	}
+	var unusedErr error
+	for _, i := range unused {
+		unusedErr = multierror.Append(unusedErr, warningf("%s param is declared but never referenced: %s.params[%d]", pipelineSpec.Params[i].Name, path, i))
+	}
+	return unusedErr
+}
+
+// starParamRefRegex matches references expanding a whole param, e.g. $(params.platforms[*])
+var starParamRefRegex = regexp.MustCompile(`\$\(params\.([^.()\[\]]+)\[\*\]\)`)
+
+// isolatedFieldPath matches the paths of the command and args items of steps and sidecars, the only
+// fields of containers where Tekton expands whole array params, provided they are used in
+// isolation
+var isolatedFieldPath = regexp.MustCompile(`\.(command|args)\[\d+\]$`)
+
+// starParamRefError returns the error of a reference expanding the whole param named name, at path,
+// when the param is declared of type string, or nil, along with the declaration of the param
+func starParamRefError(ref, name string, paramTypes map[string]v1.ParamType, path string) (v1.ParamType, error) {
+	paramType, declared := paramTypes[name]
+	if !declared {
+		// Undeclared params are reported by the validation of parameter references.
+		return "", nil
+	}
+	if paramType == "" || paramType == v1.ParamTypeString {
+		return paramType, withPath(path, fmt.Errorf("%s expands the %s param, which is of type string, not array or object", ref, name))
+	}
+	return paramType, nil
+}
+
+// declaredParamTypes returns the types of params by name
+func declaredParamTypes(params v1.ParamSpecs) map[string]v1.ParamType {
+	types := make(map[string]v1.ParamType, len(params))
+	for _, param := range params {
+		types[param.Name] = param.Type
+	}
+	return types
+}
+
+// ValidateStarParameterReferences verifies the references of the steps and sidecars of a Task
+// expanding a whole param, e.g. $(params.platforms[*]). The param must be an array or an object.
+// Arrays are only expanded as an item of command or args made up of the reference alone, and
+// objects nowhere in steps and sidecars. The upstream Tekton validation checks the arrays and
+// objects referenced by steps, but neither those referenced by sidecars nor the type of the params
+// expanded. The path is the one of the task spec.
+func ValidateStarParameterReferences(taskSpec v1.TaskSpec, path string) error {
+	var err error
+	paramTypes := declaredParamTypes(taskSpec.Params)
+	stepFields, sidecarFields := taskContainerFields(taskSpec, path)
+	for _, field := range append(stepFields, sidecarFields...) {
+		for _, match := range starParamRefRegex.FindAllStringSubmatch(field.value, -1) {
+			paramType, typeErr := starParamRefError(match[0], match[1], paramTypes, field.path)
+			switch {
+			case typeErr != nil:
+				err = multierror.Append(err, typeErr)
+			case paramType == v1.ParamTypeObject:
+				err = multierror.Append(err, withPath(field.path, fmt.Errorf(
+					"%s expands the %s object param, which is only expanded whole as the value of a param", match[0], match[1])))
+			case paramType == v1.ParamTypeArray && !isolatedFieldPath.MatchString(field.path):
+				err = multierror.Append(err, withPath(field.path, fmt.Errorf(
+					"%s expands the %s array param, which is only expanded in isolation as an item of command or args", match[0], match[1])))
+			case paramType == v1.ParamTypeArray && field.value != match[0]:
+				err = multierror.Append(err, withPath(field.path, fmt.Errorf(
+					"%s expands the %s array param, which must not be interpolated into a string, as in %q", match[0], match[1], field.value)))
+			}
+		}
+	}
+	return err
+}
+
+// ValidatePipelineStarParameterReferences verifies the references of the params, matrices, and
+// when expressions of the PipelineTasks of a pipeline spec expanding a whole param of the
+// pipeline, e.g. $(params.platforms[*]), refer to an array or an object. The path is the one of
+// the pipeline spec.
+func ValidatePipelineStarParameterReferences(pipelineSpec v1.PipelineSpec, path string) error {
+	var err error
+	paramTypes := declaredParamTypes(pipelineSpec.Params)
+	forEachPipelineTaskValue(pipelineSpec, path, func(value, valuePath string) {
+		for _, match := range starParamRefRegex.FindAllStringSubmatch(value, -1) {
+			if _, typeErr := starParamRefError(match[0], match[1], paramTypes, valuePath); typeErr != nil {
+				err = multierror.Append(err, typeErr)
+			}
+		}
+	})
+	return err
+}
+
+// forEachPipelineTaskValue calls fn with each value of the params, matrices, and when expressions
+// of the PipelineTasks of a pipeline spec, along with its path, starting at path, the path of the
+// pipeline spec
+func forEachPipelineTaskValue(pipelineSpec v1.PipelineSpec, path string, fn func(value, valuePath string)) {
+	forEachParam := func(params v1.Params, paramsPath string) {
+		for _, param := range params {
+			paramPath := fmt.Sprintf("%s[%s]", paramsPath, param.Name)
+			fn(param.Value.StringVal, paramPath)
+			for _, item := range param.Value.ArrayVal {
+				fn(item, paramPath)
+			}
+			keys := SortedKeys(param.Value.ObjectVal)
+			for _, key := range keys {
+				fn(param.Value.ObjectVal[key], paramPath)
+			}
+		}
+	}
+
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		for i, pipelineTask := range section.pipelineTasks {
+			taskPath := fmt.Sprintf("%s.%s[%d]", path, section.name, i)
+			forEachParam(pipelineTask.Params, taskPath+".params")
+			if matrix := pipelineTask.Matrix; matrix != nil {
+				forEachParam(matrix.Params, taskPath+".matrix.params")
+				for j, include := range matrix.Include {
+					forEachParam(include.Params, fmt.Sprintf("%s.matrix.include[%d].params", taskPath, j))
+				}
+			}
+			for j, when := range pipelineTask.When {
+				fn(when.Input, fmt.Sprintf("%s.when[%d].input", taskPath, j))
+				for k, value := range when.Values {
+					fn(value, fmt.Sprintf("%s.when[%d].values[%d]", taskPath, j, k))
+				}
+			}
+		}
+	}
+}
+
+// isStarParameterSchemaError tells whether the schema error with message at path reports a
+// reference of a step expanding a whole param where Tekton does not allow it, which
+// ValidateStarParameterReferences reports instead
+func isStarParameterSchemaError(message, path string) bool {
+	return (strings.HasPrefix(message, "variable type invalid in ") || strings.HasPrefix(message, "variable is not properly isolated in ")) &&
+		strings.Contains(message, "[*])") && starParameterSchemaPath.MatchString(path)
+}
+
+// starParameterSchemaPath matches the paths of the fields of steps ValidateStarParameterReferences
+// verifies
+var starParameterSchemaPath = regexp.MustCompile(`(^|\.)steps\[\d+\]\.(image|script|workingDir|command\[\d+\]|args\[\d+\]|env\[[^\]]+\])$`)
+
+// indexedParamRefRegex matches references to an item of an array param, e.g. $(params.platforms[1])
+var indexedParamRefRegex = regexp.MustCompile(`\$\(params\.([^.()\[\]]+)\[(\d+)\]\)`)
+
+// arrayParamLengths returns the number of items of the array params declared by params, as given
+// by values, or else by their default. Values made up of references are only known at runtime, so
+// the params they are passed to are left out, as are params without a default.
+func arrayParamLengths(params v1.ParamSpecs, values v1.Params) map[string]int {
+	lengths := make(map[string]int)
+	for _, param := range params {
+		if param.Type == v1.ParamTypeArray && param.Default != nil {
+			lengths[param.Name] = len(param.Default.ArrayVal)
+		}
+	}
+	for _, value := range values {
+		if !isArrayParam(params, value.Name) {
+			continue
+		}
+		delete(lengths, value.Name)
+		if value.Value.Type != v1.ParamTypeArray || slices.ContainsFunc(value.Value.ArrayVal, func(item string) bool {
+			return strings.Contains(item, "$(")
+		}) {
+			continue
+		}
+		lengths[value.Name] = len(value.Value.ArrayVal)
+	}
+	return lengths
+}
+
+// isArrayParam tells whether params declare the param named name as an array
+func isArrayParam(params v1.ParamSpecs, name string) bool {
+	return slices.ContainsFunc(params, func(param v1.ParamSpec) bool {
+		return param.Name == name && param.Type == v1.ParamTypeArray
+	})
+}
+
+// indexedParamRefErrors returns the errors of the references of value, at path, to an item of a
+// param: the param must be an array, and the index must be within the number of items given by
+// lengths, if known
+func indexedParamRefErrors(value, path string, paramTypes map[string]v1.ParamType, lengths map[string]int) []error {
+	var errs []error
+	for _, match := range indexedParamRefRegex.FindAllStringSubmatch(value, -1) {
+		ref, name := match[0], match[1]
+		paramType, declared := paramTypes[name]
+		if !declared {
+			// Undeclared params are reported by the validation of parameter references.
+			continue
+		}
+		if paramType != v1.ParamTypeArray {
+			if paramType == "" {
+				paramType = v1.ParamTypeString
+			}
+			errs = append(errs, withPath(path, fmt.Errorf("%s indexes the %s param, which is of type %s, not array", ref, name, paramType)))
+			continue
+		}
+		index, err := strconv.Atoi(match[2])
+		if length, known := lengths[name]; known && (err != nil || index >= length) {
+			items := "items"
+			if length == 1 {
+				items = "item"
+			}
+			errs = append(errs, withPath(path, fmt.Errorf("%s is out of bounds of the %s param, which has %d %s", ref, name, length, items)))
+		}
+	}
+	return errs
+}
+
+// ValidateIndexedParameterReferences verifies the references of the steps and sidecars of a Task
+// to an item of a param, e.g. $(params.platforms[1]). The param must be an array, and the index
+// must be within its items, as passed by values, e.g. the params of a TaskRun or a PipelineTask, or
+// else as given by its default. The upstream Tekton validation only checks indices when running
+// the Task. The path is the one of the task spec.
+func ValidateIndexedParameterReferences(taskSpec v1.TaskSpec, values v1.Params, path string) error {
+	var err error
+	paramTypes := declaredParamTypes(taskSpec.Params)
+	lengths := arrayParamLengths(taskSpec.Params, values)
+	stepFields, sidecarFields := taskContainerFields(taskSpec, path)
+	for _, field := range append(stepFields, sidecarFields...) {
+		for _, indexErr := range indexedParamRefErrors(field.value, field.path, paramTypes, lengths) {
+			err = multierror.Append(err, indexErr)
+		}
+	}
+	return err
+}
+
+// ValidatePipelineIndexedParameterReferences verifies the references of the params, matrices, and
+// when expressions of the PipelineTasks of a pipeline spec to an item of a param of the pipeline,
+// e.g. $(params.platforms[1]), like ValidateIndexedParameterReferences, values being those passed
+// to the pipeline by a PipelineRun or at runtime. The path is the one of the pipeline spec.
+func ValidatePipelineIndexedParameterReferences(pipelineSpec v1.PipelineSpec, values v1.Params, path string) error {
+	var err error
+	paramTypes := declaredParamTypes(pipelineSpec.Params)
+	lengths := arrayParamLengths(pipelineSpec.Params, values)
+	forEachPipelineTaskValue(pipelineSpec, path, func(value, valuePath string) {
+		for _, indexErr := range indexedParamRefErrors(value, valuePath, paramTypes, lengths) {
+			err = multierror.Append(err, indexErr)
+		}
+	})
+	return err
+}
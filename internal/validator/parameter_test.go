@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -166,6 +167,86 @@ spec:
 				"parameter reference $(params.) not defined in pipeline spec",
 			},
 		},
+		{
+			name: "references to params declared by an embedded task spec",
+			pipelineSpec: v1.PipelineSpec{
+				Params: []v1.ParamSpec{
+					{Name: "gitUrl", Type: v1.ParamTypeString},
+				},
+				Tasks: []v1.PipelineTask{
+					{
+						Name: "clone",
+						TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+							Params: []v1.ParamSpec{
+								{Name: "url", Type: v1.ParamTypeString},
+								{Name: "tags", Type: v1.ParamTypeArray},
+							},
+							Steps: []v1.Step{{Name: "clone", Script: "git clone $(params.url) $(params.tags[*])"}},
+						}},
+					},
+				},
+			},
+			rawYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  params:
+    - name: gitUrl
+      type: string
+  tasks:
+    - name: clone
+      params:
+        - name: url
+          value: $(params.gitUrl)
+      taskSpec:
+        params:
+          - name: url
+            type: string
+          - name: tags
+            type: array
+        steps:
+          - name: clone
+            script: git clone $(params.url) $(params.tags[*])
+`,
+			expectNoError: true,
+		},
+		{
+			name: "task spec param referenced outside of the task spec",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{
+					{
+						Name: "clone",
+						Params: []v1.Param{
+							{Name: "url", Value: v1.ParamValue{Type: v1.ParamTypeString, StringVal: "$(params.url)"}},
+						},
+						TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+							Params: []v1.ParamSpec{{Name: "url", Type: v1.ParamTypeString}},
+							Steps:  []v1.Step{{Name: "clone", Script: "git clone $(params.url)"}},
+						}},
+					},
+				},
+			},
+			rawYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: clone
+      params:
+        - name: url
+          value: $(params.url)
+      taskSpec:
+        params:
+          - name: url
+            type: string
+        steps:
+          - name: clone
+            script: git clone $(params.url)
+`,
+			expectedErrors: []string{
+				"parameter reference $(params.url) not defined in pipeline spec",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -185,6 +266,60 @@ spec:
 	}
 }
 
+func TestValidateParameterReferencesPropagated(t *testing.T) {
+	pipelineSpec := v1.PipelineSpec{}
+	rawYAML := []byte(`
+spec:
+  tasks:
+    - name: echo
+      taskSpec:
+        steps:
+          - name: echo
+            script: echo $(params.message) $(params.config.env) $(params.other)
+`)
+
+	err := validateParameterReferences(pipelineSpec, rawYAML, []string{"message", "config"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parameter reference $(params.other) not defined in pipeline spec")
+	assert.NotContains(t, err.Error(), "$(params.message)")
+	assert.NotContains(t, err.Error(), "$(params.config.env)")
+}
+
+func TestValidateParameterReferencesNestedPipelineSpec(t *testing.T) {
+	specYAML := `
+params:
+  - name: top
+tasks:
+  - name: child
+    params:
+      - name: inner
+        value: $(params.top)
+    pipelineSpec:
+      params:
+        - name: inner
+      tasks:
+        - name: echo
+          params:
+            - name: message
+              value: $(params.inner) $(params.top)
+          taskSpec:
+            params:
+              - name: message
+            steps:
+              - name: echo
+                script: echo $(params.message) $(params.undefined)
+`
+	pipelineSpec, err := pipelineSpecFromYAML(specYAML)
+	require.NoError(t, err)
+
+	err = ValidateParameterReferences(pipelineSpec, []byte(specYAML))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "parameter reference $(params.undefined) not defined in pipeline spec")
+	for _, ref := range []string{"$(params.top)", "$(params.inner)", "$(params.message)"} {
+		assert.NotContains(t, err.Error(), ref)
+	}
+}
+
 func TestExtractParameterReferences(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -513,3 +648,356 @@ func TestGetTaskParam(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateParametersStarReferences(t *testing.T) {
+	specs := v1.ParamSpecs{
+		{Name: "digests", Type: v1.ParamTypeArray},
+		{Name: "labels", Type: v1.ParamTypeObject},
+	}
+
+	tests := []struct {
+		name          string
+		params        v1.Params
+		expectedError bool
+		errorContains string
+	}{
+		{
+			name: "whole array and object references",
+			params: v1.Params{
+				{Name: "digests", Value: *v1.NewStructuredValues("$(tasks.build.results.digest[*])")},
+				{Name: "labels", Value: *v1.NewStructuredValues("$(params.labels[*])")},
+			},
+			expectedError: false,
+		},
+		{
+			name: "star reference interpolated in a string",
+			params: v1.Params{
+				{Name: "digests", Value: *v1.NewStructuredValues("sha256:$(tasks.build.results.digest[*])")},
+				{Name: "labels", Value: *v1.NewStructuredValues("$(params.labels[*])")},
+			},
+			expectedError: true,
+			errorContains: `"digests" parameter has the incorrect type, got "string", want "array"`,
+		},
+		{
+			name: "indexed reference",
+			params: v1.Params{
+				{Name: "digests", Value: *v1.NewStructuredValues("$(tasks.build.results.digest[0])")},
+				{Name: "labels", Value: *v1.NewStructuredValues("$(params.labels[*])")},
+			},
+			expectedError: true,
+			errorContains: `"digests" parameter has the incorrect type, got "string", want "array"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateParameters(tt.params, specs)
+
+			if tt.expectedError {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				assert.Contains(t, err.Error(), tt.errorContains)
+			} else {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestValidateUnusedPipelineParams(t *testing.T) {
+	var pipelineSpec v1.PipelineSpec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+params:
+  - name: url
+  - name: deploy
+  - name: platforms
+    type: array
+  - name: prefix
+  - name: image
+  - name: unused
+  - name: forgotten
+tasks:
+  - name: build
+    params:
+      - name: url
+        value: $(params.url)
+    matrix:
+      params:
+        - name: PLATFORM
+          value: $(params.platforms[*])
+    taskSpec:
+      steps:
+        - name: build
+          image: $(params.image)
+          script: echo build
+  - name: deploy
+    when:
+      - input: $(params.deploy)
+        operator: in
+        values: ["true"]
+    taskRef:
+      name: deploy
+results:
+  - name: url
+    value: $(params.prefix)-$(tasks.build.results.url)
+`), &pipelineSpec))
+
+	err := ValidateUnusedPipelineParams(pipelineSpec, "spec")
+	assert.NoError(t, WithoutWarnings(err))
+	assert.Equal(t, []string{
+		"unused param is declared but never referenced: spec.params[5]",
+		"forgotten param is declared but never referenced: spec.params[6]",
+	}, Warnings(err))
+
+	assert.NoError(t, ValidateUnusedPipelineParams(v1.PipelineSpec{}, "spec"))
+}
+
+func TestValidateStarParameterReferences(t *testing.T) {
+	var taskSpec v1.TaskSpec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+params:
+  - name: revision
+    type: string
+  - name: flags
+    type: array
+  - name: repo
+    type: object
+    properties:
+      url: {type: string}
+steps:
+  - name: build
+    image: alpine:latest
+    command: [build]
+    args: ["$(params.flags[*])", "--revision=$(params.revision)", "--flags=$(params.flags[*])"]
+    script: echo $(params.flags[*]) $(params.revision[*]) $(params.unknown[*])
+sidecars:
+  - name: proxy
+    image: proxy:latest
+    args: ["$(params.repo[*])"]
+    env:
+      - name: FLAGS
+        value: $(params.flags[*])
+`), &taskSpec))
+
+	err := ValidateStarParameterReferences(taskSpec, "spec")
+	require.Error(t, err)
+	var messages []string
+	for _, finding := range Findings(err) {
+		messages = append(messages, finding.Message+": "+finding.ResourcePath)
+	}
+	assert.Equal(t, []string{
+		`$(params.flags[*]) expands the flags array param, which must not be interpolated into a string, as in "--flags=$(params.flags[*])": spec.steps[0].args[2]`,
+		`$(params.flags[*]) expands the flags array param, which is only expanded in isolation as an item of command or args: spec.steps[0].script`,
+		`$(params.revision[*]) expands the revision param, which is of type string, not array or object: spec.steps[0].script`,
+		`$(params.repo[*]) expands the repo object param, which is only expanded whole as the value of a param: spec.sidecars[0].args[0]`,
+		`$(params.flags[*]) expands the flags array param, which is only expanded in isolation as an item of command or args: spec.sidecars[0].env[FLAGS]`,
+	}, messages)
+}
+
+func TestValidatePipelineStarParameterReferences(t *testing.T) {
+	var pipelineSpec v1.PipelineSpec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+params:
+  - name: revision
+  - name: platforms
+    type: array
+  - name: repo
+    type: object
+    properties:
+      url: {type: string}
+tasks:
+  - name: build
+    params:
+      - name: revisions
+        value: $(params.revision[*])
+      - name: repo
+        value: $(params.repo[*])
+    matrix:
+      params:
+        - name: PLATFORM
+          value: $(params.platforms[*])
+    when:
+      - input: $(params.revision)
+        operator: in
+        values: ["$(params.revision[*])"]
+    taskRef:
+      name: build
+finally:
+  - name: notify
+    params:
+      - name: tags
+        value: ["$(params.revision[*])", "$(params.platforms[*])"]
+    taskRef:
+      name: notify
+`), &pipelineSpec))
+
+	err := ValidatePipelineStarParameterReferences(pipelineSpec, "spec")
+	require.Error(t, err)
+	var messages []string
+	for _, finding := range Findings(err) {
+		messages = append(messages, finding.Message+": "+finding.ResourcePath)
+	}
+	assert.Equal(t, []string{
+		"$(params.revision[*]) expands the revision param, which is of type string, not array or object: spec.tasks[0].params[revisions]",
+		"$(params.revision[*]) expands the revision param, which is of type string, not array or object: spec.tasks[0].when[0].values[0]",
+		"$(params.revision[*]) expands the revision param, which is of type string, not array or object: spec.finally[0].params[tags]",
+	}, messages)
+}
+
+func TestValidateTaskStarParameterReferences(t *testing.T) {
+	task, err := taskFromYAMLForParam(`
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: flags
+      type: array
+  steps:
+    - name: build
+      image: alpine:latest
+      command: [build]
+      args: ["--flags=$(params.flags[*])"]
+`)
+	require.NoError(t, err)
+
+	// The expansion is reported once, instead of by the upstream Tekton validation.
+	err = ValidateTaskV1(context.Background(), task)
+	require.Error(t, err)
+	findings := Findings(err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, RuleParams.ID, findings[0].Rule)
+	assert.Equal(t, `$(params.flags[*]) expands the flags array param, which must not be interpolated into a string, as in "--flags=$(params.flags[*])"`, findings[0].Message)
+}
+
+func TestValidateIndexedParameterReferences(t *testing.T) {
+	var taskSpec v1.TaskSpec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+params:
+  - name: revision
+    type: string
+  - name: flags
+    type: array
+    default: [--verbose, --debug]
+  - name: platforms
+    type: array
+  - name: repo
+    type: object
+    properties:
+      url: {type: string}
+steps:
+  - name: build
+    image: alpine:latest
+    args: ["$(params.flags[1])", "$(params.flags[2])", "$(params.platforms[5])"]
+    script: echo $(params.revision[0]) $(params.repo[0]) $(params.unknown[0])
+`), &taskSpec))
+
+	var messages []string
+	for _, finding := range Findings(ValidateIndexedParameterReferences(taskSpec, nil, "spec")) {
+		messages = append(messages, finding.Message+": "+finding.ResourcePath)
+	}
+	assert.Equal(t, []string{
+		"$(params.flags[2]) is out of bounds of the flags param, which has 2 items: spec.steps[0].args[1]",
+		"$(params.revision[0]) indexes the revision param, which is of type string, not array: spec.steps[0].script",
+		"$(params.repo[0]) indexes the repo param, which is of type object, not array: spec.steps[0].script",
+	}, messages)
+
+	// The values passed to the Task override the defaults, unless they are only known at runtime.
+	messages = nil
+	err := ValidateIndexedParameterReferences(taskSpec, v1.Params{
+		{Name: "flags", Value: *v1.NewStructuredValues("$(params.flags[*])")},
+		{Name: "platforms", Value: *v1.NewStructuredValues("linux/amd64", "linux/arm64")},
+	}, "spec")
+	for _, finding := range Findings(err) {
+		messages = append(messages, finding.Message+": "+finding.ResourcePath)
+	}
+	assert.Equal(t, []string{
+		"$(params.platforms[5]) is out of bounds of the platforms param, which has 2 items: spec.steps[0].args[2]",
+		"$(params.revision[0]) indexes the revision param, which is of type string, not array: spec.steps[0].script",
+		"$(params.repo[0]) indexes the repo param, which is of type object, not array: spec.steps[0].script",
+	}, messages)
+}
+
+func TestValidatePipelineIndexedParameterReferences(t *testing.T) {
+	var pipelineSpec v1.PipelineSpec
+	require.NoError(t, yaml.Unmarshal([]byte(`
+params:
+  - name: revision
+  - name: platforms
+    type: array
+    default: [linux/amd64]
+tasks:
+  - name: build
+    params:
+      - name: platform
+        value: $(params.platforms[1])
+      - name: commit
+        value: $(params.revision[0])
+    when:
+      - input: $(params.platforms[0])
+        operator: in
+        values: [linux/amd64]
+    taskRef:
+      name: build
+`), &pipelineSpec))
+
+	var messages []string
+	for _, finding := range Findings(ValidatePipelineIndexedParameterReferences(pipelineSpec, nil, "spec")) {
+		messages = append(messages, finding.Message+": "+finding.ResourcePath)
+	}
+	assert.Equal(t, []string{
+		"$(params.platforms[1]) is out of bounds of the platforms param, which has 1 item: spec.tasks[0].params[platform]",
+		"$(params.revision[0]) indexes the revision param, which is of type string, not array: spec.tasks[0].params[commit]",
+	}, messages)
+
+	// A runtime value with more items brings the index within bounds.
+	runtimeParams := RuntimeParams{"platforms": *v1.NewStructuredValues("linux/amd64", "linux/arm64")}
+	err := ValidatePipelineIndexedParameterReferences(pipelineSpec, runtimeParams.Params(), "spec")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "out of bounds")
+}
+
+func TestValidatePipelineRunIndexedParameterReferences(t *testing.T) {
+	pr, err := pipelineRunFromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  params:
+    - name: platforms
+      value: [linux/amd64, linux/arm64]
+  pipelineSpec:
+    params:
+      - name: platforms
+        type: array
+        default: [linux/amd64]
+    tasks:
+      - name: build
+        params:
+          - name: platform
+            value: $(params.platforms[1])
+        taskSpec:
+          params:
+            - name: platform
+              type: string
+          steps:
+            - name: build
+              image: alpine:latest
+              script: echo $(params.platform)
+`)
+	require.NoError(t, err)
+
+	// The embedded Pipeline runs with the params of the PipelineRun, which bring the index within
+	// bounds of the platforms param.
+	assert.NoError(t, ValidatePipelineRun(context.Background(), pr))
+
+	pr.Spec.Params = nil
+	var messages []string
+	for _, finding := range Findings(ValidatePipelineRun(context.Background(), pr)) {
+		messages = append(messages, finding.Message+": "+finding.ResourcePath)
+	}
+	assert.Equal(t, []string{
+		"$(params.platforms[1]) is out of bounds of the platforms param, which has 1 item: spec.pipelineSpec.tasks[0].params[platform]",
+	}, messages)
+}
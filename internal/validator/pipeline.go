@@ -4,16 +4,22 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"net"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/hashicorp/go-multierror"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/validate"
 	"github.com/tektoncd/pipeline/pkg/resolution/resolver/bundle"
 	"github.com/tektoncd/pipeline/pkg/resolution/resolver/git"
-	"sigs.k8s.io/yaml"
+	"knative.dev/pkg/apis"
+
+	"github.com/lcarva/tektor/internal/logging"
+	"github.com/lcarva/tektor/internal/taskindex"
 )
 
 func ValidatePipeline(ctx context.Context, p v1.Pipeline) error {
@@ -24,32 +30,113 @@ func ValidatePipelineWithYAML(ctx context.Context, p v1.Pipeline, rawYAML []byte
 	return ValidatePipelineWithYAMLAndParams(ctx, p, rawYAML, nil)
 }
 
-func ValidatePipelineWithYAMLAndParams(ctx context.Context, p v1.Pipeline, rawYAML []byte, runtimeParams map[string]string) error {
+func ValidatePipelineWithYAMLAndParams(ctx context.Context, p v1.Pipeline, rawYAML []byte, params RuntimeParams) error {
+	return validatePipeline(ctx, p, rawYAML, params, nil)
+}
+
+// ValidatePipelineWithRun validates a Pipeline as the PipelineRun pr runs it. The params of pr are
+// given at run time, overridden by params, and the params of pr, along with those of params the
+// Pipeline declares, its workspaces, taskRunSpecs, timeouts, and the compute resource overrides of
+// its annotations are verified against the Pipeline.
+func ValidatePipelineWithRun(ctx context.Context, p v1.Pipeline, rawYAML []byte, pr v1.PipelineRun, params RuntimeParams) error {
 	var allErrors error
+	overrides, err := parseResourceOverrides(pr.Annotations)
+	if err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleRunSpecs, fmt.Errorf("resource overrides: %w", err)))
+	}
+	ctx = withResourceOverrides(ctx, overrides)
+
+	runParams := make(RuntimeParams, len(pr.Spec.Params)+len(params))
+	spec := *pr.Spec.DeepCopy()
+	for i, param := range spec.Params {
+		if value, ok := params[param.Name]; ok {
+			spec.Params[i].Value = value
+		}
+		runParams[param.Name] = spec.Params[i].Value
+	}
+	for _, paramSpec := range p.Spec.Params {
+		if value, ok := params[paramSpec.Name]; ok {
+			if _, set := runParams[paramSpec.Name]; !set {
+				spec.Params = append(spec.Params, v1.Param{Name: paramSpec.Name, Value: value})
+			}
+		}
+	}
+	for name, value := range params {
+		runParams[name] = value
+	}
+
+	if err := validatePipeline(ctx, p, rawYAML, runParams, &spec); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	return allErrors
+}
+
+// validatePipeline validates a Pipeline with the values of params given at run time. They are
+// verified against the Pipeline, unless run is set, in which case the spec of the PipelineRun
+// running the Pipeline is.
+func validatePipeline(ctx context.Context, p v1.Pipeline, rawYAML []byte, params RuntimeParams, run *v1.PipelineRunSpec) error {
+	ctx = withParamEnums(ctx)
+	// Remote references substitute the strings params resolve to.
+	runtimeParams := params.Strings()
+	var allErrors error
+	prop := propagationFromContext(ctx)
+	ctx, err := withSuppressedRules(ctx, p.Annotations)
+	if err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
 
 	// Validate parameter references in the raw YAML content
 	if rawYAML != nil {
-		if err := ValidateParameterReferences(p.Spec, rawYAML); err != nil {
-			allErrors = multierror.Append(allErrors, fmt.Errorf("parameter reference validation: %w", err))
+		if err := validateParameterReferences(p.Spec, rawYAML, prop.params); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleParamReferences, fmt.Errorf("parameter reference validation: %w", err)))
+		}
+		if err := ValidatePipelineTaskNesting(rawYAML); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleNestedPipelines, err))
 		}
 	}
+	if err := ValidateNestedPipelines(p.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleNestedPipelines, err))
+	}
 
-	if err := p.Validate(ctx); err != nil {
-		var validationErrors error
-		for _, e := range err.WrappedErrors() {
-			details := e.Details
-			if len(details) > 0 {
-				details = " " + details
-			}
-			message := strings.TrimSuffix(e.Message, ": ")
-			for _, p := range e.Paths {
-				validationErrors = multierror.Append(validationErrors, fmt.Errorf("%v: %v%v", message, p, details))
-			}
-			if len(e.Paths) == 0 {
-				validationErrors = multierror.Append(validationErrors, fmt.Errorf("%v: %v", message, details))
-			}
+	var fieldErr *apis.FieldError
+	specPath := "spec"
+	if _, embedded := ctx.Value(propagationKey{}).(propagation); embedded {
+		specPath = "spec.pipelineSpec"
+		// Params and workspaces may be propagated to a pipeline spec embedded in a PipelineRun, so
+		// their usage is not checked against the pipeline declarations.
+		fieldErr = validate.ObjectMetadata(p.GetObjectMeta()).ViaField("metadata").
+			Also(p.Spec.Validate(apis.WithinSpec(ctx)).ViaField("spec"))
+	} else {
+		fieldErr = p.Validate(ctx)
+		if err := ValidateObjectMetadata(p.ObjectMeta); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleMetadata, err))
 		}
-		allErrors = multierror.Append(allErrors, validationErrors)
+	}
+	// Nesting Pipelines is reported by ValidateNestedPipelines, the parameter references of when
+	// expressions by ValidateWhenParameterReferences, names by ValidateObjectMetadata, and whole
+	// params expanded by steps by ValidateStarParameterReferences.
+	isWhenError := isWhenParameterReferenceError(undefinedWhenParameterReferences(p.Spec, specPath, prop.params))
+	skip := func(message, path string) bool {
+		return isNestedPipelineGateError(message) || isWhenError(message, path) || isTaskSchemaErrorReported(message, path)
+	}
+	if err := schemaErrors(fieldErr, skip); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := validateWhenParameterReferences(p.Spec, specPath, prop.params); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleParamReferences, fmt.Errorf("parameter reference validation: %w", err)))
+	}
+	if err := ValidatePipelineStarParameterReferences(p.Spec, specPath); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleParams, err))
+	}
+	if err := ValidatePipelineIndexedParameterReferences(p.Spec, params.Params(), specPath); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleParams, err))
+	}
+	if run != nil {
+		if err := validatePipelineRunAgainstSpec(*run, p.Spec, specPath); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	} else if err := ValidateRuntimeParams(params, p.Spec.Params, specPath); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleParams, fmt.Errorf("runtime parameters: %w", err)))
 	}
 
 	allTaskResults := map[string][]v1.TaskResult{}
@@ -63,23 +150,99 @@ func ValidatePipelineWithYAMLAndParams(ctx context.Context, p v1.Pipeline, rawYA
 	// Collect parameter type information for result validation
 	parameterTypeContexts := make(map[string]resultUsageContext)
 
+	// Results of matrixed PipelineTasks are validated by ValidateMatrixResultConsumption.
+	matrixedTasks := matrixedPipelineTasks(p.Spec)
+
+	// Child pipelines are resolved as part of their PipelineTask, so their progress is not reported.
+	progress := logging.NewProgress(optionsFromContext(ctx).Progress, "Resolving", countRemotePipelineTasks(pipelineTasks))
+	ctx = WithProgress(ctx, nil)
+	if progress != nil {
+		ctx = logging.WithProgress(ctx, progress)
+	}
+
 	for i, pipelineTask := range pipelineTasks {
-		log.Printf("Processing pipeline task %d: %s", i, pipelineTask.Name)
+		logging.FromContext(ctx).Debugf("Processing pipeline task %d: %s", i, pipelineTask.Name)
+		remote := isRemotePipelineTask(pipelineTask)
+		if remote {
+			source, _ := pipelineTaskSource(ctx, pipelineTask, p.Spec.Params, runtimeParams)
+			progress.Start(source)
+		}
 		allTaskResultRefs[pipelineTask.Name] = v1.PipelineTaskResultRefs(&pipelineTask)
 		params := pipelineTask.Params
 
-		taskSpec, err := taskSpecFromPipelineTaskWithParams(ctx, pipelineTask, p.Spec.Params, runtimeParams)
-		if err != nil {
-			allErrors = multierror.Append(allErrors, fmt.Errorf("retrieving task spec from %s pipeline task: %w", pipelineTask.Name, err))
-			continue
+		var taskSpec *v1.TaskSpec
+		if nestedPipelineField(pipelineTask) != "" {
+			childSpec, err := pipelineSpecFromPipelineTask(ctx, pipelineTask, p.Spec.Params, runtimeParams)
+			if remote {
+				progress.Done()
+			}
+			if err != nil {
+				allErrors = multierror.Append(allErrors, withRule(RuleTaskResolution, fmt.Errorf("retrieving pipeline spec from %s pipeline task: %w", pipelineTask.Name, err)))
+				continue
+			}
+			if err := validateChildPipeline(ctx, pipelineTask.Name, *childSpec); err != nil {
+				allErrors = multierror.Append(allErrors, fmt.Errorf("%s PipelineTask child pipeline: %w", pipelineTask.Name, err))
+			}
+			// The params, results, and workspaces passed across the boundary are checked below.
+			taskSpec = pipelineBoundarySpec(*childSpec)
+		} else {
+			taskSpec, err = taskSpecFromPipelineTaskWithParams(ctx, pipelineTask, p.Spec.Params, runtimeParams)
+			if remote {
+				progress.Done()
+			}
+			if err != nil {
+				allErrors = multierror.Append(allErrors, withRule(RuleTaskResolution, fmt.Errorf("retrieving task spec from %s pipeline task: %w", pipelineTask.Name, err)))
+				continue
+			}
+
+			taskCtx := ctx
+			if pipelineTask.TaskSpec != nil {
+				if taskCtx, err = withSuppressedRules(ctx, pipelineTask.TaskSpec.Metadata.Annotations); err != nil {
+					allErrors = multierror.Append(allErrors, fmt.Errorf("%s PipelineTask: %w", pipelineTask.Name, err))
+				}
+			}
+			if err := validateTaskSpec(taskCtx, *taskSpec); err != nil {
+				allErrors = multierror.Append(allErrors, fmt.Errorf("%s PipelineTask: %w", pipelineTask.Name, err))
+			}
+			if err := ValidateIndexedParameterReferences(*taskSpec, pipelineTask.Params, "spec"); err != nil {
+				allErrors = multierror.Append(allErrors, withRule(RuleParams, fmt.Errorf("%s PipelineTask: %w", pipelineTask.Name, err)))
+			}
+			if pipelineTask.TaskSpec == nil {
+				// Embedded task specs are already checked by the upstream validation of the Pipeline.
+				if err := ValidateStepOnError(*taskSpec); err != nil {
+					allErrors = multierror.Append(allErrors, withRule(RuleSteps, fmt.Errorf("%s PipelineTask: %w", pipelineTask.Name, err)))
+				}
+				if err := ValidateResultPathReferences(*taskSpec); err != nil {
+					allErrors = multierror.Append(allErrors, fmt.Errorf("%s PipelineTask: %w", pipelineTask.Name, err))
+				}
+				// Embedded task specs may also use the workspaces bound by the PipelineTask, which
+				// validateWorkspaces checks.
+				if err := ValidateStepWorkspaceReferences(*taskSpec); err != nil {
+					allErrors = multierror.Append(allErrors, withRule(RuleWorkspaces, fmt.Errorf("%s PipelineTask: workspace validation: %w", pipelineTask.Name, err)))
+				}
+			}
 		}
 
 		paramSpecs := taskSpec.Params
 		allTaskResults[pipelineTask.Name] = taskSpec.Results
 		allTaskSpecs[pipelineTask.Name] = taskSpec
 
+		if pipelineTask.IsMatrixed() {
+			if err := ValidateMatrix(pipelineTask.Matrix, paramSpecs); err != nil {
+				allErrors = multierror.Append(allErrors, withRule(RuleMatrix, fmt.Errorf("ERROR: %s PipelineTask matrix: %w", pipelineTask.Name, err)))
+			}
+			// Parameters supplied through the matrix satisfy the Task's required parameters.
+			params = append(append(v1.Params{}, params...), matrixParams(pipelineTask.Matrix, paramSpecs)...)
+		}
+
 		if err := ValidateParameters(params, paramSpecs); err != nil {
-			allErrors = multierror.Append(allErrors, fmt.Errorf("ERROR: %s PipelineTask: %s", pipelineTask.Name, err))
+			allErrors = multierror.Append(allErrors, withRule(RuleParams, fmt.Errorf("ERROR: %s PipelineTask: %w", pipelineTask.Name, err)))
+		}
+
+		// Pipeline defaults and runtime values flow into the Task params through references.
+		substitutedParams := substituteParametersInParams(pipelineTask.Params, p.Spec.Params, runtimeParams)
+		if err := ValidateParamEnums(substitutedParams, paramSpecs); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleParamEnums, fmt.Errorf("ERROR: %s PipelineTask: %w", pipelineTask.Name, err)))
 		}
 
 		// Check each parameter in this task for result type validation
@@ -88,6 +251,9 @@ func ValidatePipelineWithYAMLAndParams(ctx context.Context, p v1.Pipeline, rawYA
 				// Extract result references from parameter values
 				resultRefs := extractResultReferencesFromValue(param.Value.StringVal)
 				for _, resultRef := range resultRefs {
+					if matrixedTasks[resultRef.PipelineTask] {
+						continue
+					}
 					refKey := fmt.Sprintf("%s.%s", resultRef.PipelineTask, resultRef.Result)
 
 					// Find the parameter spec to get the expected type
@@ -111,15 +277,68 @@ func ValidatePipelineWithYAMLAndParams(ctx context.Context, p v1.Pipeline, rawYA
 		}
 	}
 
+	progress.Close()
+
 	// Validate workspace usage
-	if workspaceErr := ValidateWorkspaces(p.Spec, allTaskSpecs); workspaceErr != nil {
-		allErrors = multierror.Append(allErrors, fmt.Errorf("workspace validation: %w", workspaceErr))
+	if workspaceErr := validateWorkspaces(p.Spec, allTaskSpecs, prop.workspaces, runtimeParams); workspaceErr != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleWorkspaces, fmt.Errorf("workspace validation: %w", workspaceErr)))
+	}
+
+	if err := ValidateResourceOverrides(resourceOverridesFromContext(ctx), p.Spec, allTaskSpecs); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleRunSpecs, fmt.Errorf("resource overrides: %w", err)))
+	}
+
+	if hasProfile(ctx, ProfileKonflux) {
+		if err := ValidateKonfluxBuildResults(p.Spec, allTaskSpecs); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleKonfluxResults, fmt.Errorf("konflux profile: %w", err)))
+		}
+		if err := ValidateKonfluxTrustedArtifacts(p.Spec, allTaskSpecs, specPath); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleKonfluxArtifacts, fmt.Errorf("konflux profile: %w", err)))
+		}
+		if err := ValidateKonfluxBuildPlatforms(p.Spec); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleKonfluxPlatforms, fmt.Errorf("konflux profile: %w", err)))
+		}
+		if err := ValidateKonfluxFinallyTasks(p.Spec, allTaskSpecs); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleKonfluxFinally, fmt.Errorf("konflux profile: %w", err)))
+		}
+	}
+
+	if optionsFromContext(ctx).CheckUnusedResults {
+		var external []string
+		if value := p.Annotations[ExternalResultsAnnotation]; value != "" {
+			external = strings.Split(value, ",")
+		}
+		if err := ValidateUnusedResults(p.Spec, allTaskSpecs, external, specPath); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleUnusedResults, err))
+		}
+	}
+
+	if err := ValidateUnusedPipelineParams(p.Spec, specPath); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleUnusedParams, err))
+	}
+
+	if optionsFromContext(ctx).Strict {
+		if err := validatePipelineStrict(p.Spec, specPath); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+
+	if err := ValidateWhenExpressions(p.Spec, specPath); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleWhenExpressions, err))
+	}
+
+	if err := validateDisplayNames(p.Spec, allTaskSpecs, specPath, prop.params); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+
+	if err := ValidateMatrixResultConsumption(p.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleMatrix, fmt.Errorf("matrix result validation: %w", err)))
 	}
 
 	// Verify result references in PipelineTasks are valid.
 	for pipelineTaskName, resultRefs := range allTaskResultRefs {
 		if err := ValidateResultsWithContext(resultRefs, allTaskResults, parameterTypeContexts); err != nil {
-			allErrors = multierror.Append(allErrors, fmt.Errorf("%s PipelineTask results: %w", pipelineTaskName, err))
+			allErrors = multierror.Append(allErrors, withRule(RuleResults, fmt.Errorf("%s PipelineTask results: %w", pipelineTaskName, err)))
 		}
 	}
 
@@ -140,10 +359,14 @@ func ValidatePipelineWithYAMLAndParams(ctx context.Context, p v1.Pipeline, rawYA
 		}
 
 		if err := ValidateResultsWithContext(resultRefs, allTaskResults, pipelineResultContexts); err != nil {
-			allErrors = multierror.Append(allErrors, fmt.Errorf("pipeline results: %w", err))
+			allErrors = multierror.Append(allErrors, withRule(RuleResults, fmt.Errorf("pipeline results: %w", err)))
 		}
 	}
 
+	if err := evaluatePipelinePolicies(ctx, p, allTaskSpecs); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+
 	return allErrors
 }
 
@@ -161,63 +384,242 @@ func taskSpecFromPipelineTaskWithParams(ctx context.Context, pipelineTask v1.Pip
 		return &pipelineTask.TaskSpec.TaskSpec, nil
 	}
 
-	if pipelineTask.TaskRef != nil && pipelineTask.TaskRef.Resolver == "bundles" {
-		opts, err := bundleResolverOptions(ctx, pipelineTask.TaskRef.Params)
+	if pipelineTask.TaskRef != nil {
+		return taskResolverFromContext(ctx).ResolveTask(ctx, *pipelineTask.TaskRef, pipelineParams, runtimeParams)
+	}
+
+	return nil, errors.New("unable to retrieve spec for pipeline task")
+}
+
+// resolveTaskRef resolves a taskRef through the bundles, git, or hub resolver, or by name from the
+// TaskIndex of the validation options
+func resolveTaskRef(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error) {
+	if isRemoteResolver(ref.Resolver) {
+		source, data, err := resolveRemoteResource(ctx, "task", ref.ResolverRef, pipelineParams, runtimeParams)
 		if err != nil {
 			return nil, err
 		}
-		resolvedResource, err := bundle.GetEntry(ctx, authn.DefaultKeychain, opts)
+
+		entry, err := taskindex.Decode(ctx, source, data)
 		if err != nil {
+			if ref.Resolver == "git" {
+				return nil, fmt.Errorf("failed to unmarshal task from git repository: %w", err)
+			}
 			return nil, err
 		}
 
-		var t v1.Task
-		if err := yaml.Unmarshal(resolvedResource.Data(), &t); err != nil {
-			return nil, err
+		return &entry.Spec, nil
+	}
+
+	if ref.Resolver == "" && ref.Name != "" {
+		if index := optionsFromContext(ctx).TaskIndex; index != nil {
+			entry, err := index.Lookup(string(ref.Kind), ref.Name)
+			if err != nil {
+				return nil, err
+			}
+			return &entry.Spec, nil
 		}
+	}
+
+	return nil, errors.New("unable to retrieve spec for pipeline task")
+}
 
-		return &t.Spec, nil
+// isRemotePipelineTask tells whether a PipelineTask refers to a Task or Pipeline through a remote
+// resolver
+func isRemotePipelineTask(pipelineTask v1.PipelineTask) bool {
+	switch {
+	case pipelineTask.TaskRef != nil:
+		return isRemoteResolver(pipelineTask.TaskRef.Resolver)
+	case pipelineTask.PipelineRef != nil:
+		return isRemoteResolver(pipelineTask.PipelineRef.Resolver)
 	}
+	return false
+}
 
-	if pipelineTask.TaskRef != nil && pipelineTask.TaskRef.Resolver == "git" {
-		// Validate required parameters for git resolver
-		if err := validateGitResolverParams(pipelineTask.TaskRef.Params); err != nil {
-			return nil, fmt.Errorf("git resolver parameter validation failed: %w", err)
+// countRemotePipelineTasks returns how many PipelineTasks refer to a Task or Pipeline through a
+// remote resolver
+func countRemotePipelineTasks(pipelineTasks []v1.PipelineTask) int {
+	count := 0
+	for _, pipelineTask := range pipelineTasks {
+		if isRemotePipelineTask(pipelineTask) {
+			count++
 		}
+	}
+	return count
+}
 
-		// Substitute parameter references with runtime values
-		resolverParams := substituteParametersInParams(pipelineTask.TaskRef.Params, pipelineParams, runtimeParams)
+// pipelineTaskSection is one of the lists of PipelineTasks of a pipeline spec, named after its
+// field
+type pipelineTaskSection struct {
+	name          string
+	pipelineTasks []v1.PipelineTask
+}
 
-		params, err := git.PopulateDefaultParams(ctx, resolverParams)
+// pipelineTaskSections returns the tasks and the finally sections of the pipeline spec. Their
+// PipelineTasks are those of the spec, not copies.
+func pipelineTaskSections(pipelineSpec v1.PipelineSpec) []pipelineTaskSection {
+	return []pipelineTaskSection{
+		{name: "tasks", pipelineTasks: pipelineSpec.Tasks},
+		{name: "finally", pipelineTasks: pipelineSpec.Finally},
+	}
+}
+
+// allPipelineTasks returns the PipelineTasks of the tasks and then the finally section of the
+// pipeline spec, in a new slice
+func allPipelineTasks(pipelineSpec v1.PipelineSpec) []v1.PipelineTask {
+	return append(slices.Clone(pipelineSpec.Tasks), pipelineSpec.Finally...)
+}
+
+// isRemoteResolver tells whether resolveRemoteResource supports the resolver
+func isRemoteResolver(resolver v1.ResolverName) bool {
+	return resolver == "bundles" || resolver == "git" || resolver == "hub"
+}
+
+// resolveRemoteResource fetches the definition of a task or pipeline, as told by kind, referred to
+// through the bundles, git, or hub resolver. It returns a description of where the definition comes
+// from along with its content.
+func resolveRemoteResource(ctx context.Context, kind string, ref v1.ResolverRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (string, []byte, error) {
+	if ref.Resolver == "hub" {
+		return resolveHubResource(ctx, kind, substituteParametersInParams(ref.Params, pipelineParams, runtimeParams))
+	}
+
+	if ref.Resolver == "bundles" {
+		opts, err := bundleResolverOptions(ctx, ref.Params)
 		if err != nil {
-			return nil, fmt.Errorf("failed to populate git resolver parameters: %w", err)
+			return "", nil, err
 		}
-
-		resolvedResource, err := git.ResolveAnonymousGit(ctx, params)
+		data, err := resolveBundleEntry(ctx, opts)
 		if err != nil {
-			// Extract URL and revision from params for better error messaging
-			var url, revision string
-			if urlParam := getParamValue(resolverParams, "url"); urlParam != "" {
-				url = urlParam
-			}
-			if revParam := getParamValue(resolverParams, "revision"); revParam != "" {
-				revision = revParam
-			} else {
-				revision = "default"
-			}
+			return "", nil, err
+		}
+		return fmt.Sprintf("bundle %s", opts.Bundle), data, nil
+	}
+
+	// Validate required parameters for git resolver
+	if err := validateGitResolverParams(ref.Params); err != nil {
+		return "", nil, fmt.Errorf("git resolver parameter validation failed: %w", err)
+	}
+
+	// Substitute parameter references with runtime values
+	resolverParams := substituteParametersInParams(ref.Params, pipelineParams, runtimeParams)
+
+	params, err := git.PopulateDefaultParams(ctx, resolverParams)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to populate git resolver parameters: %w", err)
+	}
 
-			return nil, fmt.Errorf("failed to resolve task from git repository (url: %s, revision: %s): %w", url, revision, err)
+	key := fmt.Sprintf("git %s %s %s", params[git.UrlParam], params[git.RevisionParam], params[git.PathParam])
+	data, err := resolveCached(ctx, key, gitCommitRegex.MatchString(params[git.RevisionParam]), func(ctx context.Context) ([]byte, error) {
+		auth, err := repoGitAuth(ctx, params[git.UrlParam])
+		if err != nil {
+			return nil, err
+		}
+		return resolveGit(ctx, params[git.UrlParam], params[git.RevisionParam], params[git.PathParam], auth)
+	})
+	if err != nil {
+		// Extract URL and revision from params for better error messaging
+		var url, revision string
+		if urlParam := getParamValue(resolverParams, "url"); urlParam != "" {
+			url = urlParam
+		}
+		if revParam := getParamValue(resolverParams, "revision"); revParam != "" {
+			revision = revParam
+		} else {
+			revision = "default"
 		}
 
-		var t v1.Task
-		if err := yaml.Unmarshal(resolvedResource.Data(), &t); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal task from git repository: %w", err)
+		return "", nil, fmt.Errorf("failed to resolve %s from git repository (url: %s, revision: %s): %w", kind, url, revision, err)
+	}
+	return "git repository", data, nil
+}
+
+// gitCommitRegex matches full SHA-1 and SHA-256 commit hashes, which pin a git revision
+var gitCommitRegex = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// resolveCached returns the content cached for key by the remote cache of the validation options,
+// or resolves and caches it. The key is pinned if its content never changes. Failing to cache the
+// content is only logged.
+func resolveCached(ctx context.Context, key string, pinned bool, resolve func(context.Context) ([]byte, error)) ([]byte, error) {
+	cache := optionsFromContext(ctx).Cache
+	if cache == nil {
+		return resolveWithRetries(ctx, resolve)
+	}
+	if data, ok := cache.Get(key, pinned); ok {
+		return data, nil
+	}
+	data, err := resolveWithRetries(ctx, resolve)
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.Put(key, data); err != nil {
+		logging.FromContext(ctx).Warnf("⚠️  %v", err)
+	}
+	return data, nil
+}
+
+// resolveWithRetries runs resolve, bounding each attempt by the resolve timeout of the validation
+// options, and retries transient failures, e.g. timeouts or unavailable registries, with an
+// exponential backoff
+func resolveWithRetries(ctx context.Context, resolve func(context.Context) ([]byte, error)) ([]byte, error) {
+	opts := optionsFromContext(ctx)
+	backoff := opts.ResolveBackoff
+	for attempt := 0; ; attempt++ {
+		data, err := resolveWithTimeout(ctx, opts.ResolveTimeout, resolve)
+		if err == nil || attempt >= opts.ResolveRetries || ctx.Err() != nil || !isTransientError(err) {
+			return data, err
+		}
+		logging.FromContext(ctx).Warnf("⚠️  Retrying in %s after a transient error: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
 		}
+		backoff *= 2
+	}
+}
 
-		return &t.Spec, nil
+// resolveWithTimeout runs resolve, giving up once timeout elapses unless it is not positive. Some
+// resolvers ignore the cancellation of their context, so they are abandoned rather than awaited.
+func resolveWithTimeout(ctx context.Context, timeout time.Duration, resolve func(context.Context) ([]byte, error)) ([]byte, error) {
+	if timeout <= 0 {
+		return resolve(ctx)
 	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	return nil, errors.New("unable to retrieve spec for pipeline task")
+	type result struct {
+		data []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		data, err := resolve(ctx)
+		done <- result{data, err}
+	}()
+	select {
+	case r := <-done:
+		return r.data, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out after %s: %w", timeout, ctx.Err())
+	}
+}
+
+// isTransientError tells whether a resolution failed for a reason which may not persist, as opposed
+// to e.g. a missing resource, denied access, an unknown host, or a refused connection
+func isTransientError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) {
+		return transportErr.Temporary()
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
 // substituteParametersInParams substitutes parameter references in resolver parameters
@@ -283,8 +685,21 @@ func bundleResolverOptions(ctx context.Context, params v1.Params) (bundle.Reques
 		})
 	}
 
-	allParams = append(allParams, params...)
-	return bundle.OptionsFromParams(ctx, allParams)
+	// Local bundles are not image references, which the resolver requires, so it is given a stand-in
+	// reference instead.
+	var local string
+	for _, p := range params {
+		if _, _, ok := localBundle(p.Value.StringVal); ok && p.Name == bundle.ParamBundle {
+			local = p.Value.StringVal
+			p.Value = *v1.NewStructuredValues("localhost/local-bundle")
+		}
+		allParams = append(allParams, p)
+	}
+	opts, err := bundle.OptionsFromParams(ctx, allParams)
+	if err == nil && local != "" {
+		opts.Bundle = local
+	}
+	return opts, err
 }
 
 // validateGitResolverParams validates the required parameters for git resolver
@@ -2,13 +2,24 @@ package validator
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/remotecache"
+	"github.com/lcarva/tektor/internal/taskindex"
 )
 
 // Helper function to unmarshal YAML into Pipeline objects
@@ -490,12 +501,13 @@ func TestValidatePipelineWithYAMLAndParams(t *testing.T) {
 	ctx := context.Background()
 
 	tests := []struct {
-		name          string
-		pipeline      v1.Pipeline
-		rawYAML       []byte
-		runtimeParams map[string]string
-		expectedError bool
-		errorContains []string
+		name             string
+		pipeline         v1.Pipeline
+		rawYAML          []byte
+		runtimeParams    map[string]string
+		expectedError    bool
+		errorContains    []string
+		expectedWarnings []string
 	}{
 		{
 			name: "pipeline with runtime parameter substitution",
@@ -615,13 +627,14 @@ spec:
 				"gitUrl": "https://github.com/example/repo.git",
 				// Missing gitRevision parameter - should still be valid since it's not used
 			},
-			expectedError: false,
+			expectedError:    false,
+			expectedWarnings: []string{"gitRevision param is declared but never referenced: spec.params[1]"},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := ValidatePipelineWithYAMLAndParams(ctx, tt.pipeline, tt.rawYAML, tt.runtimeParams)
+			err := ValidatePipelineWithYAMLAndParams(ctx, tt.pipeline, tt.rawYAML, StringParams(tt.runtimeParams))
 
 			if tt.expectedError {
 				require.Error(t, err, "Expected error for test case: %s", tt.name)
@@ -632,7 +645,8 @@ spec:
 					}
 				}
 			} else {
-				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				assert.NoError(t, WithoutWarnings(err), "Expected no error for test case: %s", tt.name)
+				assert.Equal(t, tt.expectedWarnings, Warnings(err))
 			}
 		})
 	}
@@ -894,3 +908,427 @@ func TestValidateGitResolverParams(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePipelineWithTaskIndex(t *testing.T) {
+	index := taskindex.New()
+	index.Add(taskindex.Entry{
+		Kind:   "Task",
+		Name:   "git-clone",
+		Source: "tasks/git-clone.yaml:1 (Task git-clone)",
+		Spec: v1.TaskSpec{
+			Params: v1.ParamSpecs{{Name: "url", Type: v1.ParamTypeString}},
+			Steps:  []v1.Step{{Name: "clone", Image: "alpine:latest", Script: "git clone $(params.url)"}},
+		},
+	})
+	index.Add(taskindex.Entry{
+		Kind:   "Task",
+		Name:   "lint",
+		Source: "tasks/lint.yaml:1 (Task lint)",
+		Spec: v1.TaskSpec{
+			Steps: []v1.Step{{Name: "lint", Image: "alpine:latest", Script: "make lint", OnError: "ignore"}},
+		},
+	})
+
+	tests := []struct {
+		name           string
+		pipelineYAML   string
+		index          *taskindex.Index
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "task found in index",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: clone
+spec:
+  tasks:
+    - name: clone
+      params:
+        - name: url
+          value: https://github.com/example/repo.git
+      taskRef:
+        name: git-clone
+`,
+			index:         index,
+			expectNoError: true,
+		},
+		{
+			name: "task params validated against indexed task",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: clone
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        name: git-clone
+`,
+			index:          index,
+			expectedErrors: []string{`"url" parameter is required`},
+		},
+		{
+			name: "task kind filter",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: clone
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        name: git-clone
+        kind: ClusterTask
+`,
+			index:          index,
+			expectedErrors: []string{`ClusterTask "git-clone" not found in task directories`},
+		},
+		{
+			name: "invalid onError of indexed task",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: lint
+spec:
+  tasks:
+    - name: lint
+      taskRef:
+        name: lint
+`,
+			index:          index,
+			expectedErrors: []string{`lint PipelineTask: 1 error occurred:`, `invalid value: "ignore": spec.steps[0].onError Task step onError must be either "continue" or "stopAndFail"`},
+		},
+		{
+			name: "no index",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: clone
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        name: git-clone
+`,
+			expectedErrors: []string{"unable to retrieve spec for pipeline task"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := pipelineFromYAML(tt.pipelineYAML)
+			require.NoError(t, err)
+
+			ctx := WithTaskIndex(context.Background(), tt.index)
+			err = ValidatePipeline(ctx, p)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineWithCachedRemoteTasks(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("0", 64)
+	commit := strings.Repeat("a", 40)
+	task := []byte(`
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: string
+  steps:
+    - name: build
+      image: alpine:latest
+      script: echo $(params.url)
+`)
+	// The registry and repository do not exist, so the Tasks can only come from the cache.
+	cache := remotecache.New(t.TempDir(), 0)
+	require.NoError(t, cache.Put("bundle registry.invalid/tasks/build@"+digest+" task build", task))
+	require.NoError(t, cache.Put("git https://git.invalid/tasks.git "+commit+" build.yaml", task))
+	ctx := WithOptions(context.Background(), Options{Cache: cache})
+
+	pipelineYAML := fmt.Sprintf(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: cached
+spec:
+  tasks:
+    - name: from-bundle
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: registry.invalid/tasks/build@%s
+          - name: name
+            value: build
+          - name: kind
+            value: task
+    - name: from-git
+      taskRef:
+        resolver: git
+        params:
+          - name: url
+            value: https://git.invalid/tasks.git
+          - name: revision
+            value: %s
+          - name: pathInRepo
+            value: build.yaml
+`, digest, commit)
+	p, err := pipelineFromYAML(pipelineYAML)
+	require.NoError(t, err)
+
+	err = ValidatePipeline(ctx, p)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "from-bundle PipelineTask: ")
+	assert.Contains(t, err.Error(), "from-git PipelineTask: ")
+	assert.Contains(t, err.Error(), `"url" parameter is required`)
+	assert.NotContains(t, err.Error(), "failed to resolve")
+
+	// Untrusted input does not get what credentials may have cached.
+	err = ValidatePipeline(WithUntrustedInput(ctx), p)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to resolve task from git repository")
+	assert.NotContains(t, err.Error(), `"url" parameter is required`)
+}
+
+func TestResolveWithRetries(t *testing.T) {
+	transient := &transport.Error{StatusCode: http.StatusServiceUnavailable}
+	notFound := &transport.Error{StatusCode: http.StatusNotFound}
+
+	tests := []struct {
+		name             string
+		opts             Options
+		failures         []error
+		hang             bool
+		expectedAttempts int
+		expectedError    string
+	}{
+		{
+			name:             "first attempt succeeds",
+			opts:             Options{ResolveRetries: 2},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "transient failures are retried",
+			opts:             Options{ResolveRetries: 2, ResolveBackoff: time.Millisecond},
+			failures:         []error{transient, transient},
+			expectedAttempts: 3,
+		},
+		{
+			name:             "retries are exhausted",
+			opts:             Options{ResolveRetries: 1, ResolveBackoff: time.Millisecond},
+			failures:         []error{transient, transient},
+			expectedAttempts: 2,
+			expectedError:    "503",
+		},
+		{
+			name:             "other failures are not retried",
+			opts:             Options{ResolveRetries: 2, ResolveBackoff: time.Millisecond},
+			failures:         []error{notFound},
+			expectedAttempts: 1,
+			expectedError:    "404",
+		},
+		{
+			name:             "unknown hosts are not retried",
+			opts:             Options{ResolveRetries: 2, ResolveBackoff: time.Millisecond},
+			failures:         []error{&net.DNSError{Err: "no such host", Name: "registry.invalid", IsNotFound: true}},
+			expectedAttempts: 1,
+			expectedError:    "no such host",
+		},
+		{
+			name:             "temporary DNS failures are retried",
+			opts:             Options{ResolveRetries: 2, ResolveBackoff: time.Millisecond},
+			failures:         []error{&net.DNSError{Err: "server misbehaving", Name: "registry.example", IsTemporary: true}},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "refused connections are not retried",
+			opts:             Options{ResolveRetries: 2, ResolveBackoff: time.Millisecond},
+			failures:         []error{&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+			expectedAttempts: 1,
+			expectedError:    "connection refused",
+		},
+		{
+			name:             "attempts time out",
+			opts:             Options{ResolveTimeout: 10 * time.Millisecond, ResolveRetries: 1, ResolveBackoff: time.Millisecond},
+			hang:             true,
+			expectedAttempts: 2,
+			expectedError:    "timed out after 10ms: context deadline exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			ctx := WithOptions(context.Background(), tt.opts)
+			data, err := resolveWithRetries(ctx, func(ctx context.Context) ([]byte, error) {
+				attempt := int(attempts.Add(1))
+				if tt.hang {
+					// Resolvers ignoring the cancellation of their context are abandoned.
+					time.Sleep(time.Second)
+				}
+				if attempt <= len(tt.failures) {
+					return nil, tt.failures[attempt-1]
+				}
+				return []byte("kind: Task"), nil
+			})
+
+			assert.Equal(t, tt.expectedAttempts, int(attempts.Load()))
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "kind: Task", string(data))
+		})
+	}
+}
+
+func TestValidatePipelineReportsEveryError(t *testing.T) {
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: broken
+spec:
+  tasks:
+    - name: missing
+      taskRef:
+        name: missing
+    - name: build
+      params:
+        - name: unknown
+          value: foo
+      taskSpec:
+        params:
+          - name: url
+        steps:
+          - name: build
+            image: alpine:latest
+            script: git clone $(params.url)
+    - name: deploy
+      params:
+        - name: digest
+          value: $(tasks.build.results.digest)
+      taskSpec:
+        params:
+          - name: digest
+        steps:
+          - name: deploy
+            image: alpine:latest
+            script: echo $(params.digest)
+`)
+	require.NoError(t, err)
+
+	err = ValidatePipeline(context.Background(), p)
+	require.Error(t, err)
+	var rules []string
+	for _, finding := range Findings(err) {
+		rules = append(rules, finding.Rule)
+	}
+	assert.Equal(t, []string{RuleSchema.ID, RuleSchema.ID, RuleTaskResolution.ID, RuleParams.ID, RuleParams.ID, RuleResults.ID}, rules, "Expected every finding, got: %v", err)
+	assert.Contains(t, err.Error(), "retrieving task spec from missing pipeline task")
+	assert.Contains(t, err.Error(), `"unknown" parameter is not defined by the Task`)
+	assert.Contains(t, err.Error(), `"url" parameter is required`)
+	assert.Contains(t, err.Error(), "non-existent digest result from build PipelineTask")
+}
+
+func TestValidatePipelineWithRun(t *testing.T) {
+	content := `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  params:
+    - name: revision
+      type: string
+    - name: platforms
+      type: array
+      default: []
+  workspaces:
+    - name: source
+  tasks:
+    - name: build
+      params:
+        - name: revision
+          value: $(params.revision)
+        - name: platforms
+          value: $(params.platforms[*])
+      workspaces:
+        - name: source
+          workspace: source
+      taskSpec:
+        params:
+          - name: revision
+            type: string
+          - name: platforms
+            type: array
+        workspaces:
+          - name: source
+        steps:
+          - name: build
+            image: alpine:latest
+            command: [echo, "$(params.revision)", "$(workspaces.source.path)"]
+            args: ["$(params.platforms[*])"]
+`
+	p, err := pipelineFromYAML(content)
+	require.NoError(t, err)
+
+	var pr v1.PipelineRun
+	require.NoError(t, yaml.Unmarshal([]byte(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build-run
+spec:
+  pipelineRef:
+    name: build
+  params:
+    - name: revision
+      value: main
+  workspaces:
+    - name: source
+      emptyDir: {}
+`), &pr))
+	assert.NoError(t, ValidatePipelineWithRun(context.Background(), p, []byte(content), pr, nil))
+
+	broken := *pr.DeepCopy()
+	broken.Spec.Params[0].Value = *v1.NewStructuredValues("main", "dev")
+	broken.Spec.Workspaces = nil
+	broken.Spec.TaskRunSpecs = []v1.PipelineTaskRunSpec{{PipelineTaskName: "deploy"}}
+	err = ValidatePipelineWithRun(context.Background(), p, []byte(content), broken, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"revision" parameter has the incorrect type, got "array", want "string"`)
+	assert.Contains(t, err.Error(), `"source" workspace is required`)
+	assert.Contains(t, err.Error(), `PipelineTask "deploy" does not exist: spec.taskRunSpecs[0].pipelineTaskName`)
+
+	// Values given at run time override those of the PipelineRun.
+	err = ValidatePipelineWithRun(context.Background(), p, []byte(content), pr, RuntimeParams{
+		"revision":  *v1.NewStructuredValues("main", "dev"),
+		"platforms": *v1.NewStructuredValues("linux/amd64"),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"revision" parameter has the incorrect type, got "array", want "string"`)
+	assert.Contains(t, err.Error(), `"platforms" parameter has the incorrect type, got "string", want "array"`)
+}
@@ -4,10 +4,17 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/tektoncd/pipeline/pkg/apis/config"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
 )
 
 func ValidatePipelineRun(ctx context.Context, pr v1.PipelineRun) error {
@@ -15,42 +22,527 @@ func ValidatePipelineRun(ctx context.Context, pr v1.PipelineRun) error {
 }
 
 func ValidatePipelineRunWithYAML(ctx context.Context, pr v1.PipelineRun, rawYAML []byte) error {
+	ctx = withParamEnums(ctx)
 	var allErrors error
 
-	// Validate parameter references in the raw YAML content if pipeline spec is embedded
-	if rawYAML != nil && pr.Spec.PipelineSpec != nil {
-		if err := ValidateParameterReferences(*pr.Spec.PipelineSpec, rawYAML); err != nil {
-			allErrors = multierror.Append(allErrors, fmt.Errorf("parameter reference validation: %w", err))
+	// Params and workspaces of the PipelineRun are propagated to its embedded pipeline spec, and the
+	// volumes of its pod templates can be mounted by the steps of its Tasks
+	prop := propagation{}
+	for _, param := range pr.Spec.Params {
+		prop.params = append(prop.params, param.Name)
+	}
+	for _, workspace := range pr.Spec.Workspaces {
+		prop.workspaces = append(prop.workspaces, workspace.Name)
+	}
+	prop.volumes = podTemplateVolumes(pr.Spec.TaskRunTemplate.PodTemplate)
+	for _, taskRunSpec := range pr.Spec.TaskRunSpecs {
+		prop.volumes = append(prop.volumes, podTemplateVolumes(taskRunSpec.PodTemplate)...)
+	}
+	ctx = context.WithValue(ctx, propagationKey{}, prop)
+
+	if rawYAML != nil {
+		if err := ValidatePipelineRunDebug(rawYAML); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleDebug, err))
 		}
 	}
 
-	if err := pr.Validate(ctx); err != nil {
-		var validationErrors error
-		for _, e := range err.WrappedErrors() {
-			details := e.Details
-			if len(details) > 0 {
-				details = " " + details
-			}
-			message := strings.TrimSuffix(e.Message, ": ")
-			for _, p := range e.Paths {
-				validationErrors = multierror.Append(validationErrors, fmt.Errorf("%v: %v%v", message, p, details))
-			}
-			if len(e.Paths) == 0 {
-				validationErrors = multierror.Append(validationErrors, fmt.Errorf("%v: %v", message, details))
-			}
+	overrides, err := parseResourceOverrides(pr.Annotations)
+	if err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleRunSpecs, fmt.Errorf("resource overrides: %w", err)))
+	}
+	ctx = withResourceOverrides(ctx, overrides)
+
+	ctx, err = withSuppressedRules(ctx, pr.Annotations)
+	if err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+
+	if err := ValidateWorkspaceBindingVolumes(pr.Spec.Workspaces, "spec"); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleWorkspaces, fmt.Errorf("PipelineRun workspaces: %w", err)))
+	}
+
+	// The objects the steps of its Tasks refer to are looked up in the namespace of the PipelineRun.
+	ctx = withClusterNamespace(ctx, pr.Namespace)
+	if err := validateClusterObjects(ctx, pr.Namespace, pipelineRunObjectRefs(pr.Spec)); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleClusterObjects, err))
+	}
+
+	// The parameter references of the when expressions of an embedded pipeline spec are reported by
+	// the validation of the Pipeline, names by ValidateObjectMetadata, and the volume sources of
+	// workspace bindings by ValidateWorkspaceBindingVolumes, and whole params expanded by steps by
+	// ValidateStarParameterReferences.
+	skip := func(message, path string) bool {
+		return isTaskSchemaErrorReported(message, path) || isWorkspaceBindingSourceError(message, path)
+	}
+	if pipelineSpec := pr.Spec.PipelineSpec; pipelineSpec != nil {
+		isWhenError := isWhenParameterReferenceError(undefinedWhenParameterReferences(*pipelineSpec, "spec.pipelineSpec", prop.params))
+		skip = func(message, path string) bool {
+			return isTaskSchemaErrorReported(message, path) || isWorkspaceBindingSourceError(message, path) || isWhenError(message, path)
+		}
+	}
+	if err := schemaErrors(pr.Validate(ctx), skip); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := ValidateObjectMetadata(pr.ObjectMeta); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleMetadata, err))
+	}
+	if err := ValidatePipelinesAsCodeAnnotations(pr.ObjectMeta); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RulePACAnnotations, err))
+	}
+
+	if hasProfile(ctx, ProfileKonflux) {
+		if err := ValidateKonfluxPipelineRunMetadata(pr.ObjectMeta); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleKonfluxMetadata, fmt.Errorf("konflux profile: %w", err)))
+		}
+	}
+
+	if ref := pr.Spec.PipelineRef; ref != nil && optionsFromContext(ctx).Strict {
+		if err := validateRunRef("PipelineRun", ref.ResolverRef, "spec.pipelineRef"); err != nil {
+			allErrors = multierror.Append(allErrors, err)
 		}
-		allErrors = multierror.Append(allErrors, validationErrors)
 	}
 
 	if pipelineSpec := pr.Spec.PipelineSpec; pipelineSpec != nil {
+		if err := ValidatePipelineRunChildNames(pr.ObjectMeta, *pipelineSpec); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleMetadata, err))
+		}
+
 		p := v1.Pipeline{
 			// Some name value is required for validation.
 			ObjectMeta: metav1.ObjectMeta{Name: "noname"},
 			Spec:       *pipelineSpec,
 		}
-		if err := ValidatePipelineWithYAML(ctx, p, rawYAML); err != nil {
+		if value, ok := pr.Annotations[ExternalResultsAnnotation]; ok {
+			p.Annotations = map[string]string{ExternalResultsAnnotation: value}
+		}
+		// Policies are evaluated against the PipelineRun, embedding the resolved Pipeline.
+		ctx := withPolicySubject(ctx, func(resolved v1.PipelineSpec) any {
+			subject := *pr.DeepCopy()
+			subject.TypeMeta = metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "PipelineRun"}
+			subject.Spec.PipelineSpec = &resolved
+			return subject
+		})
+		// The embedded Pipeline is validated with the params of the PipelineRun, against which its use
+		// by the PipelineRun is verified.
+		runParams := make(RuntimeParams, len(pr.Spec.Params))
+		for _, param := range pr.Spec.Params {
+			runParams[param.Name] = param.Value
+		}
+		if err := validatePipeline(ctx, p, rawYAML, runParams, &pr.Spec); err != nil {
 			allErrors = multierror.Append(allErrors, err)
 		}
+	} else if ref := pr.Spec.PipelineRef; ref != nil && ref.Resolver == "" && ref.Name != "" {
+		// The referenced Pipeline is validated on its own, only its use by the PipelineRun is checked.
+		if index := optionsFromContext(ctx).TaskIndex; index != nil {
+			entry, err := index.Lookup("Pipeline", ref.Name)
+			if err != nil {
+				allErrors = multierror.Append(allErrors, withRule(RuleTaskResolution, fmt.Errorf("PipelineRun pipelineRef: %w", err)))
+			} else {
+				if err := validatePipelineRunAgainstSpec(pr.Spec, entry.PipelineSpec, "spec"); err != nil {
+					allErrors = multierror.Append(allErrors, err)
+				}
+				if err := ValidatePipelineRunChildNames(pr.ObjectMeta, entry.PipelineSpec); err != nil {
+					allErrors = multierror.Append(allErrors, withRule(RuleMetadata, err))
+				}
+			}
+		}
+	}
+
+	// The policies are evaluated by the validation of embedded Pipelines.
+	if pr.Spec.PipelineSpec == nil && hasPolicies(ctx) {
+		if err := evaluatePipelineRunPolicies(ctx, pr); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+	return allErrors
+}
+
+// evaluatePipelineRunPolicies evaluates the policies against a PipelineRun referring to its
+// Pipeline, with the Pipeline and the Tasks of its PipelineTasks embedded like for an embedded
+// pipeline spec. Resolution errors are reported by the other validations: the policies are then
+// evaluated against what could be resolved.
+func evaluatePipelineRunPolicies(ctx context.Context, pr v1.PipelineRun) error {
+	runtimeParams := make(RuntimeParams, len(pr.Spec.Params))
+	for _, param := range pr.Spec.Params {
+		runtimeParams[param.Name] = param.Value
+	}
+	pr, _ = InlinePipelineRun(ctx, pr, runtimeParams.Strings())
+	pr.TypeMeta = metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "PipelineRun"}
+	return evaluatePolicies(ctx, pr)
+}
+
+// validatePipelineRunAgainstSpec verifies the params, workspaces, taskRunSpecs, and timeouts of a
+// PipelineRun against the spec of the Pipeline it runs, at path
+func validatePipelineRunAgainstSpec(spec v1.PipelineRunSpec, pipelineSpec v1.PipelineSpec, path string) error {
+	var allErrors error
+	if err := ValidatePipelineRunParameters(spec.Params, pipelineSpec); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleParams, fmt.Errorf("PipelineRun params: %w", err)))
+	}
+	if err := ValidateParamEnums(spec.Params, pipelineSpec.Params); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleParamEnums, fmt.Errorf("PipelineRun params: %w", err)))
+	}
+	if err := ValidatePipelineRunWorkspaces(spec.Workspaces, pipelineSpec); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleWorkspaces, fmt.Errorf("PipelineRun workspaces: %w", err)))
+	}
+	if err := ValidatePipelineRunTaskRunSpecs(spec.TaskRunSpecs, pipelineSpec); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleRunSpecs, fmt.Errorf("PipelineRun taskRunSpecs: %w", err)))
+	}
+	if err := ValidatePipelineTaskTimeouts(spec.Timeouts, pipelineSpec, path); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleTimeouts, fmt.Errorf("PipelineRun timeouts: %w", err)))
 	}
 	return allErrors
 }
+
+// ValidatePipelineRunParameters verifies the params of a PipelineRun against the params declared
+// by its pipeline spec: required params must be provided and values must be of the declared type.
+// Undeclared params are only accepted if they are propagated, i.e. referenced by the pipeline spec.
+func ValidatePipelineRunParameters(params v1.Params, pipelineSpec v1.PipelineSpec) error {
+	var err error
+
+	var propagated map[string]int
+	if content, marshalErr := yaml.Marshal(pipelineSpec); marshalErr == nil {
+		propagated = make(map[string]int)
+		for paramRef := range countParameterReferences(string(content)) {
+			propagated[paramRefName(paramRef)]++
+		}
+	}
+
+	for _, param := range params {
+		paramSpec, found := getTaskParam(param.Name, pipelineSpec.Params)
+		if !found {
+			if propagated != nil && propagated[param.Name] == 0 {
+				err = multierror.Append(err, fmt.Errorf(
+					"%q parameter is not defined by the Pipeline", param.Name))
+			}
+			continue
+		}
+
+		// Tekton uses the "string" type for parameters by default.
+		specType := paramSpec.Type
+		if specType == "" {
+			specType = v1.ParamTypeString
+		}
+		paramType := param.Value.Type
+		if paramType == "" {
+			paramType = v1.ParamTypeString
+		}
+		if paramType != specType {
+			err = multierror.Append(err, fmt.Errorf(
+				"%q parameter has the incorrect type, got %q, want %q",
+				param.Name, paramType, specType))
+		}
+	}
+
+	// Verify all "required" parameters are provided.
+	for _, paramSpec := range pipelineSpec.Params {
+		if paramSpec.Default != nil {
+			continue
+		}
+		if _, found := getPipelineTaskParam(paramSpec.Name, params); !found {
+			err = multierror.Append(err, fmt.Errorf("%q parameter is required", paramSpec.Name))
+		}
+	}
+
+	return err
+}
+
+// ValidatePipelineRunWorkspaces verifies the workspace bindings of a PipelineRun against the
+// workspaces declared by its pipeline spec: required workspaces must be bound, and undeclared
+// workspaces are only accepted if they are propagated, i.e. used by the pipeline spec. Bindings
+// sharing a name are reported by the Tekton validation of the PipelineRun.
+func ValidatePipelineRunWorkspaces(bindings []v1.WorkspaceBinding, pipelineSpec v1.PipelineSpec) error {
+	var err error
+
+	declared := make(map[string]bool)
+	for _, workspace := range pipelineSpec.Workspaces {
+		declared[workspace.Name] = true
+	}
+
+	used := make(map[string]bool)
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		for _, binding := range pipelineTask.Workspaces {
+			used[binding.Workspace] = true
+		}
+	}
+	if content, marshalErr := yaml.Marshal(pipelineSpec); marshalErr == nil {
+		for _, match := range workspaceRefRegex.FindAllStringSubmatch(string(content), -1) {
+			used[match[1]] = true
+		}
+	}
+
+	bound := make(map[string]bool)
+	for _, binding := range bindings {
+		if bound[binding.Name] {
+			continue
+		}
+		bound[binding.Name] = true
+		if !declared[binding.Name] && !used[binding.Name] {
+			err = multierror.Append(err, fmt.Errorf("%q workspace is not declared by the Pipeline", binding.Name))
+		}
+	}
+
+	for _, workspace := range pipelineSpec.Workspaces {
+		if !workspace.Optional && !bound[workspace.Name] {
+			err = multierror.Append(err, fmt.Errorf("%q workspace is required", workspace.Name))
+		}
+	}
+
+	return err
+}
+
+// ValidatePipelineRunTaskRunSpecs verifies the taskRunSpecs of a PipelineRun refer to distinct,
+// existing PipelineTasks, and checks the fields Tekton only validates once the TaskRuns are
+// created: the service account name, the pod template, and the compute resources.
+func ValidatePipelineRunTaskRunSpecs(specs []v1.PipelineTaskRunSpec, pipelineSpec v1.PipelineSpec) error {
+	var err error
+
+	pipelineTasks := make(map[string]bool)
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		pipelineTasks[pipelineTask.Name] = true
+	}
+
+	seen := make(map[string]int)
+	for i, spec := range specs {
+		path := fmt.Sprintf("spec.taskRunSpecs[%d]", i)
+
+		previous, duplicate := seen[spec.PipelineTaskName]
+		switch {
+		case spec.PipelineTaskName == "":
+			err = multierror.Append(err, fmt.Errorf("missing field(s): %s.pipelineTaskName", path))
+		case duplicate:
+			err = multierror.Append(err, fmt.Errorf(
+				"PipelineTask %q is configured more than once, at index %d and %d: %s.pipelineTaskName",
+				spec.PipelineTaskName, previous, i, path))
+		case !pipelineTasks[spec.PipelineTaskName]:
+			err = multierror.Append(err, fmt.Errorf(
+				"PipelineTask %q does not exist: %s.pipelineTaskName", spec.PipelineTaskName, path))
+		}
+		if !duplicate {
+			seen[spec.PipelineTaskName] = i
+		}
+
+		if name := spec.ServiceAccountName; name != "" && !strings.Contains(name, "$(") {
+			for _, msg := range validation.IsDNS1123Subdomain(name) {
+				err = multierror.Append(err, fmt.Errorf("invalid service account name %q, %s: %s.serviceAccountName", name, msg, path))
+			}
+		}
+
+		if spec.PodTemplate != nil {
+			if podErr := validatePodTemplate(*spec.PodTemplate, path+".podTemplate"); podErr != nil {
+				err = multierror.Append(err, podErr)
+			}
+		}
+
+		if spec.ComputeResources != nil {
+			for _, name := range requestsExceedingLimits(*spec.ComputeResources) {
+				request, limit := spec.ComputeResources.Requests[name], spec.ComputeResources.Limits[name]
+				err = multierror.Append(err, fmt.Errorf(
+					"%s request %s exceeds its limit %s: %s.computeResources", name, request.String(), limit.String(), path))
+			}
+		}
+	}
+
+	return err
+}
+
+// validatePodTemplate checks the fields of a pod template which are only validated by Kubernetes
+// once the pods are created
+func validatePodTemplate(template pod.Template, path string) error {
+	var err error
+
+	for key, value := range template.NodeSelector {
+		for _, msg := range validation.IsQualifiedName(key) {
+			err = multierror.Append(err, fmt.Errorf("invalid node selector key %q, %s: %s.nodeSelector", key, msg, path))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			err = multierror.Append(err, fmt.Errorf("invalid node selector value %q, %s: %s.nodeSelector[%s]", value, msg, path, key))
+		}
+	}
+
+	for i, toleration := range template.Tolerations {
+		tolerationPath := fmt.Sprintf("%s.tolerations[%d]", path, i)
+		switch toleration.Operator {
+		case corev1.TolerationOpExists:
+			if toleration.Value != "" {
+				err = multierror.Append(err, fmt.Errorf("value must be empty when operator is Exists: %s.value", tolerationPath))
+			}
+		case corev1.TolerationOpEqual, "":
+			if toleration.Key == "" {
+				err = multierror.Append(err, fmt.Errorf("operator must be Exists when key is empty: %s.operator", tolerationPath))
+			}
+		default:
+			err = multierror.Append(err, fmt.Errorf("invalid value: %s: %s.operator", toleration.Operator, tolerationPath))
+		}
+		switch toleration.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			err = multierror.Append(err, fmt.Errorf("invalid value: %s: %s.effect", toleration.Effect, tolerationPath))
+		}
+	}
+
+	for i, secret := range template.ImagePullSecrets {
+		if secret.Name == "" {
+			err = multierror.Append(err, fmt.Errorf("missing field(s): %s.imagePullSecrets[%d].name", path, i))
+		}
+	}
+
+	volumes := make(map[string]bool)
+	for i, volume := range template.Volumes {
+		volumePath := fmt.Sprintf("%s.volumes[%d].name", path, i)
+		for _, msg := range validation.IsDNS1123Label(volume.Name) {
+			err = multierror.Append(err, withPath(volumePath, fmt.Errorf("invalid volume name %q, %s", volume.Name, msg)))
+		}
+		if volumes[volume.Name] {
+			err = multierror.Append(err, withPath(volumePath, fmt.Errorf("volume %q is defined more than once", volume.Name)))
+		}
+		volumes[volume.Name] = true
+	}
+
+	return err
+}
+
+type propagationKey struct{}
+
+// propagation holds the names of the params and workspaces a PipelineRun propagates to its
+// embedded pipeline spec, and of the volumes its pod templates add to the pods of its TaskRuns
+type propagation struct {
+	params     []string
+	workspaces []string
+	volumes    []string
+}
+
+// propagationFromContext returns the propagated params and workspaces carried by ctx, if any
+func propagationFromContext(ctx context.Context) propagation {
+	if prop, ok := ctx.Value(propagationKey{}).(propagation); ok {
+		return prop
+	}
+	return propagation{}
+}
+
+// ValidatePipelineRunV1Beta1Timeouts verifies the use of the deprecated timeout field of a v1beta1
+// PipelineRun. It must not be combined with timeouts, and it warns about how it is converted to
+// the v1 API, which is what the rest of the validation operates on.
+func ValidatePipelineRunV1Beta1Timeouts(spec v1beta1.PipelineRunSpec) error {
+	if spec.Timeout == nil {
+		return nil
+	}
+
+	if spec.Timeouts != nil {
+		var err error
+		err = multierror.Append(err, fmt.Errorf(
+			"spec.timeout and spec.timeouts are mutually exclusive, remove spec.timeout and use spec.timeouts.pipeline instead"))
+		if spec.Timeouts.Tasks != nil || spec.Timeouts.Finally != nil {
+			err = multierror.Append(err, warningf(
+				"spec.timeout (%s) takes precedence when converting to %s, spec.timeouts.tasks and spec.timeouts.finally are dropped",
+				spec.Timeout.Duration, v1.SchemeGroupVersion))
+		}
+		return err
+	}
+
+	return warningf("spec.timeout is deprecated, it is converted to spec.timeouts.pipeline (%s) in %s",
+		spec.Timeout.Duration, v1.SchemeGroupVersion)
+}
+
+// ValidatePipelineRunTimeoutDurations verifies that the timeouts in the raw YAML of a PipelineRun
+// are durations. They are checked before decoding since a single malformed duration otherwise fails
+// decoding the whole PipelineRun without pointing at the offending field.
+func ValidatePipelineRunTimeoutDurations(rawYAML []byte) error {
+	type pipelineTask struct {
+		Timeout any `json:"timeout"`
+	}
+	var raw struct {
+		Spec struct {
+			Timeout      any            `json:"timeout"`
+			Timeouts     map[string]any `json:"timeouts"`
+			PipelineSpec struct {
+				Tasks   []pipelineTask `json:"tasks"`
+				Finally []pipelineTask `json:"finally"`
+			} `json:"pipelineSpec"`
+		} `json:"spec"`
+	}
+	if yaml.Unmarshal(rawYAML, &raw) != nil {
+		// A pipeline spec whose tasks are not lists fails decoding the PipelineRun instead.
+		return nil
+	}
+
+	var err error
+	appendErr := func(durationErr error) {
+		if durationErr != nil {
+			err = multierror.Append(err, durationErr)
+		}
+	}
+	appendErr(validateDuration(raw.Spec.Timeout, "spec.timeout"))
+	for _, field := range []string{"pipeline", "tasks", "finally"} {
+		appendErr(validateDuration(raw.Spec.Timeouts[field], "spec.timeouts."+field))
+	}
+	for i, pipelineTask := range raw.Spec.PipelineSpec.Tasks {
+		appendErr(validateDuration(pipelineTask.Timeout, fmt.Sprintf("spec.pipelineSpec.tasks[%d].timeout", i)))
+	}
+	for i, pipelineTask := range raw.Spec.PipelineSpec.Finally {
+		appendErr(validateDuration(pipelineTask.Timeout, fmt.Sprintf("spec.pipelineSpec.finally[%d].timeout", i)))
+	}
+	return err
+}
+
+// validateDuration verifies that a raw YAML value is a duration, e.g. "1h30m"
+func validateDuration(value any, path string) error {
+	if value == nil {
+		return nil
+	}
+	duration, ok := value.(string)
+	if !ok {
+		return withPath(path, fmt.Errorf("invalid value: %v is not a duration such as \"1h30m\"", value))
+	}
+	if _, err := time.ParseDuration(duration); err != nil {
+		return withPath(path, fmt.Errorf("invalid value: %q is not a duration such as \"1h30m\"", duration))
+	}
+	return nil
+}
+
+// defaultPipelineTimeout is the pipeline timeout of the PipelineRuns which set none, unless the
+// Tekton installation configures another one
+var defaultPipelineTimeout = &metav1.Duration{Duration: config.DefaultTimeoutMinutes * time.Minute}
+
+// ValidatePipelineTaskTimeouts verifies the timeouts of PipelineTasks against the timeouts of the
+// PipelineRun. A PipelineTask cannot run longer than the tasks, or finally, section it belongs to,
+// which defaults to the pipeline timeout, itself defaulting to the one of Tekton. A zero timeout
+// means no timeout. path is the one of the pipeline spec, e.g. spec.pipelineSpec for a PipelineRun
+// embedding it.
+func ValidatePipelineTaskTimeouts(timeouts *v1.TimeoutFields, pipelineSpec v1.PipelineSpec, path string) error {
+	var err error
+
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		var bound *metav1.Duration
+		if timeouts != nil {
+			bound = timeouts.Tasks
+			if section.name == "finally" {
+				bound = timeouts.Finally
+			}
+		}
+		boundPath := "spec.timeouts." + section.name
+		if bound == nil && timeouts != nil {
+			bound, boundPath = timeouts.Pipeline, "spec.timeouts.pipeline"
+		}
+		if bound == nil {
+			bound, boundPath = defaultPipelineTimeout, "the default pipeline timeout"
+		}
+
+		for i, pipelineTask := range section.pipelineTasks {
+			if pipelineTask.Timeout == nil {
+				continue
+			}
+			timeoutPath := fmt.Sprintf("%s.%s[%d].timeout", path, section.name, i)
+			timeout := pipelineTask.Timeout.Duration
+			if timeout < 0 {
+				err = multierror.Append(err, withPath(timeoutPath, fmt.Errorf("invalid value: %s should be >= 0", timeout)))
+				continue
+			}
+			if bound.Duration > 0 && timeout > bound.Duration {
+				err = multierror.Append(err, withPath(timeoutPath, fmt.Errorf(
+					"timeout %s of PipelineTask %q exceeds %s (%s)", timeout, pipelineTask.Name, boundPath, bound.Duration)))
+			}
+		}
+	}
+
+	return err
+}
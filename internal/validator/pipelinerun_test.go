@@ -2,15 +2,22 @@ package validator
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/go-multierror"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/taskindex"
 )
 
 // Helper function to unmarshal YAML into PipelineRun objects
@@ -166,7 +173,11 @@ spec:
     - name: undefinedParam
       value: value
 `,
-			expectedError: false, // Parameter validation requires raw YAML context
+			expectedError: true,
+			errorContains: []string{
+				`"undefinedParam" parameter is not defined by the Pipeline`,
+				`"gitRevision" parameter is required`,
+			},
 		},
 		{
 			name: "pipelinerun with workspace binding errors",
@@ -203,7 +214,10 @@ spec:
     - name: source
       emptyDir: {}
 `,
-			expectedError: false, // The workspace scenario isn't properly configured for error
+			expectedError: true,
+			errorContains: []string{
+				"\"cache\" workspace is required",
+			},
 		},
 		{
 			name: "pipelinerun with both pipelineRef and pipelineSpec",
@@ -541,6 +555,62 @@ spec:
           resources:
             requests:
               storage: 1Gi
+`),
+			expectedError: false,
+		},
+		{
+			name: "pipelinerun propagating params and workspaces",
+			pipelineRun: v1.PipelineRun{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "pipelinerun-propagation",
+				},
+				Spec: v1.PipelineRunSpec{
+					PipelineSpec: &v1.PipelineSpec{
+						Tasks: []v1.PipelineTask{
+							{
+								Name: "echo",
+								TaskSpec: &v1.EmbeddedTask{
+									TaskSpec: v1.TaskSpec{
+										Steps: []v1.Step{
+											{
+												Name:   "echo",
+												Image:  "alpine:latest",
+												Script: "echo $(params.message) > $(workspaces.shared.path)/message",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Params: []v1.Param{
+						{Name: "message", Value: v1.ParamValue{Type: v1.ParamTypeString, StringVal: "hello"}},
+					},
+					Workspaces: []v1.WorkspaceBinding{
+						{Name: "shared", EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					},
+				},
+			},
+			rawYAML: []byte(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: pipelinerun-propagation
+spec:
+  pipelineSpec:
+    tasks:
+      - name: echo
+        taskSpec:
+          steps:
+            - name: echo
+              image: alpine:latest
+              script: echo $(params.message) > $(workspaces.shared.path)/message
+  params:
+    - name: message
+      value: hello
+  workspaces:
+    - name: shared
+      emptyDir: {}
 `),
 			expectedError: false,
 		},
@@ -565,6 +635,83 @@ spec:
 	}
 }
 
+func TestValidatePipelineRunPipelineRef(t *testing.T) {
+	index := taskindex.New()
+	index.Add(taskindex.Entry{
+		Kind:   "Pipeline",
+		Name:   "build",
+		Source: "pipelines/build.yaml:1 (Pipeline build)",
+		PipelineSpec: v1.PipelineSpec{
+			Params:     v1.ParamSpecs{{Name: "url", Type: v1.ParamTypeString}},
+			Workspaces: []v1.PipelineWorkspaceDeclaration{{Name: "source"}},
+		},
+	})
+
+	tests := []struct {
+		name           string
+		pipelineRun    v1.PipelineRun
+		index          *taskindex.Index
+		expectedErrors []string
+	}{
+		{
+			name: "matching params and workspaces",
+			pipelineRun: v1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "run"}, Spec: v1.PipelineRunSpec{
+				PipelineRef: &v1.PipelineRef{Name: "build"},
+				Params:      v1.Params{{Name: "url", Value: *v1.NewStructuredValues("https://example.com")}},
+				Workspaces:  []v1.WorkspaceBinding{{Name: "source", EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			}},
+			index: index,
+		},
+		{
+			name: "missing param and unknown workspace",
+			pipelineRun: v1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "run"}, Spec: v1.PipelineRunSpec{
+				PipelineRef: &v1.PipelineRef{Name: "build"},
+				Workspaces: []v1.WorkspaceBinding{
+					{Name: "source", EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					{Name: "cache", EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				},
+			}},
+			index: index,
+			expectedErrors: []string{
+				`PipelineRun params: `,
+				`"url" parameter is required`,
+				`PipelineRun workspaces: `,
+				`"cache"`,
+			},
+		},
+		{
+			name: "unknown pipeline",
+			pipelineRun: v1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "run"}, Spec: v1.PipelineRunSpec{
+				PipelineRef: &v1.PipelineRef{Name: "deploy"},
+			}},
+			index:          index,
+			expectedErrors: []string{`PipelineRun pipelineRef: Pipeline "deploy" not found in task or pipeline directories`},
+		},
+		{
+			name: "without pipeline directories",
+			pipelineRun: v1.PipelineRun{ObjectMeta: metav1.ObjectMeta{Name: "run"}, Spec: v1.PipelineRunSpec{
+				PipelineRef: &v1.PipelineRef{Name: "deploy"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithOptions(context.Background(), Options{TaskIndex: tt.index})
+			err := ValidatePipelineRunWithYAML(ctx, tt.pipelineRun, nil)
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
 func TestValidatePipelineRunParameterCompatibility(t *testing.T) {
 	ctx := context.Background()
 
@@ -602,6 +749,11 @@ func TestValidatePipelineRunParameterCompatibility(t *testing.T) {
 												Image:  "alpine/git:latest",
 												Script: "git clone $(params.url) -b $(params.revision)",
 											},
+											{
+												Name:  "build",
+												Image: "alpine:latest",
+												Args:  []string{"$(params.buildArgs[*])"},
+											},
 										},
 									},
 								},
@@ -661,7 +813,10 @@ func TestValidatePipelineRunParameterCompatibility(t *testing.T) {
 					},
 				},
 			},
-			expectedError: false, // Parameter type validation requires raw YAML context
+			expectedError: true,
+			errorContains: []string{
+				`"buildArgs" parameter has the incorrect type, got "string", want "array"`,
+			},
 		},
 		{
 			name: "pipelinerun with required parameter missing",
@@ -706,7 +861,10 @@ func TestValidatePipelineRunParameterCompatibility(t *testing.T) {
 					},
 				},
 			},
-			expectedError: false, // Parameter validation requires raw YAML context
+			expectedError: true,
+			errorContains: []string{
+				`"gitRevision" parameter is required`,
+			},
 		},
 	}
 
@@ -847,6 +1005,7 @@ func TestValidatePipelineRunWorkspaceCompatibility(t *testing.T) {
 			expectedError: true,
 			errorContains: []string{
 				"pipeline workspace \"cache\" is declared but never used",
+				"PipelineRun workspaces: 1 error occurred:\n\t* \"cache\" workspace is required",
 			},
 		},
 		{
@@ -904,7 +1063,10 @@ func TestValidatePipelineRunWorkspaceCompatibility(t *testing.T) {
 					},
 				},
 			},
-			expectedError: false, // Current validation doesn't check for undefined workspace bindings
+			expectedError: true,
+			errorContains: []string{
+				"\"undefinedWorkspace\" workspace is not declared by the Pipeline",
+			},
 		},
 	}
 
@@ -926,3 +1088,664 @@ func TestValidatePipelineRunWorkspaceCompatibility(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePipelineRunV1Beta1Timeouts(t *testing.T) {
+	hour := &metav1.Duration{Duration: time.Hour}
+
+	tests := []struct {
+		name             string
+		spec             v1beta1.PipelineRunSpec
+		expectedErrors   []string
+		expectedWarnings []string
+	}{
+		{
+			name: "no timeout",
+			spec: v1beta1.PipelineRunSpec{Timeouts: &v1beta1.TimeoutFields{Pipeline: hour}},
+		},
+		{
+			name:             "deprecated timeout",
+			spec:             v1beta1.PipelineRunSpec{Timeout: hour},
+			expectedWarnings: []string{"spec.timeout is deprecated, it is converted to spec.timeouts.pipeline (1h0m0s) in tekton.dev/v1"},
+		},
+		{
+			name: "timeout with pipeline timeouts",
+			spec: v1beta1.PipelineRunSpec{Timeout: hour, Timeouts: &v1beta1.TimeoutFields{Pipeline: hour}},
+			expectedErrors: []string{
+				"spec.timeout and spec.timeouts are mutually exclusive, remove spec.timeout and use spec.timeouts.pipeline instead",
+			},
+		},
+		{
+			name: "timeout with tasks timeouts",
+			spec: v1beta1.PipelineRunSpec{Timeout: hour, Timeouts: &v1beta1.TimeoutFields{Tasks: hour}},
+			expectedErrors: []string{
+				"spec.timeout and spec.timeouts are mutually exclusive",
+			},
+			expectedWarnings: []string{
+				"spec.timeout (1h0m0s) takes precedence when converting to tekton.dev/v1, spec.timeouts.tasks and spec.timeouts.finally are dropped",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelineRunV1Beta1Timeouts(tt.spec)
+
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+			errs := WithoutWarnings(err)
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, errs, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, errs, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, errs.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidatePipelineRunParameters(t *testing.T) {
+	pipelineSpec := v1.PipelineSpec{
+		Params: []v1.ParamSpec{
+			{Name: "url"},
+			{Name: "revision", Default: &v1.ParamValue{Type: v1.ParamTypeString, StringVal: "main"}},
+			{Name: "args", Type: v1.ParamTypeArray},
+		},
+		Tasks: []v1.PipelineTask{
+			{
+				Name: "echo",
+				TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+					Steps: []v1.Step{{Name: "echo", Script: "echo $(params.message)"}},
+				}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		params         v1.Params
+		expectedErrors []string
+	}{
+		{
+			name: "matching params",
+			params: v1.Params{
+				{Name: "url", Value: *v1.NewStructuredValues("https://example.com")},
+				{Name: "args", Value: *v1.NewStructuredValues("--verbose", "--parallel")},
+			},
+		},
+		{
+			name: "propagated param",
+			params: v1.Params{
+				{Name: "url", Value: *v1.NewStructuredValues("https://example.com")},
+				{Name: "args", Value: *v1.NewStructuredValues("--verbose", "--quiet")},
+				{Name: "message", Value: *v1.NewStructuredValues("hello")},
+			},
+		},
+		{
+			name: "unknown, mistyped, and missing params",
+			params: v1.Params{
+				{Name: "revision", Value: *v1.NewStructuredValues("main", "other")},
+				{Name: "extra", Value: *v1.NewStructuredValues("value")},
+			},
+			expectedErrors: []string{
+				`"revision" parameter has the incorrect type, got "array", want "string"`,
+				`"extra" parameter is not defined by the Pipeline`,
+				`"url" parameter is required`,
+				`"args" parameter is required`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelineRunParameters(tt.params, pipelineSpec)
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidatePipelineRunWorkspaces(t *testing.T) {
+	pipelineSpec := v1.PipelineSpec{
+		Workspaces: []v1.PipelineWorkspaceDeclaration{
+			{Name: "source"},
+			{Name: "cache", Optional: true},
+		},
+		Tasks: []v1.PipelineTask{
+			{
+				Name:       "build",
+				Workspaces: []v1.WorkspacePipelineTaskBinding{{Name: "source", Workspace: "source"}, {Name: "dockerconfig", Workspace: "dockerconfig"}},
+				TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+					Steps: []v1.Step{{Name: "build", Script: "ls $(workspaces.source.path) $(workspaces.netrc.path)"}},
+				}},
+			},
+		},
+	}
+	emptyDir := &corev1.EmptyDirVolumeSource{}
+
+	tests := []struct {
+		name           string
+		bindings       []v1.WorkspaceBinding
+		expectedErrors []string
+	}{
+		{
+			name:     "required workspace bound",
+			bindings: []v1.WorkspaceBinding{{Name: "source", EmptyDir: emptyDir}},
+		},
+		{
+			name: "propagated workspaces",
+			bindings: []v1.WorkspaceBinding{
+				{Name: "source", EmptyDir: emptyDir},
+				{Name: "dockerconfig", EmptyDir: emptyDir},
+				{Name: "netrc", EmptyDir: emptyDir},
+			},
+		},
+		{
+			name: "undeclared and missing workspaces",
+			bindings: []v1.WorkspaceBinding{
+				{Name: "cache", EmptyDir: emptyDir},
+				{Name: "output", EmptyDir: emptyDir},
+			},
+			expectedErrors: []string{
+				`"output" workspace is not declared by the Pipeline`,
+				`"source" workspace is required`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelineRunWorkspaces(tt.bindings, pipelineSpec)
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidatePipelineRunDuplicateWorkspaces(t *testing.T) {
+	pr := v1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "duplicate-workspaces"},
+		Spec: v1.PipelineRunSpec{
+			PipelineSpec: &v1.PipelineSpec{
+				Workspaces: []v1.PipelineWorkspaceDeclaration{{Name: "source"}},
+				Tasks: []v1.PipelineTask{
+					{
+						Name:       "build",
+						Workspaces: []v1.WorkspacePipelineTaskBinding{{Name: "source", Workspace: "source"}},
+						TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+							Workspaces: []v1.WorkspaceDeclaration{{Name: "source"}},
+							Steps:      []v1.Step{{Name: "build", Image: "alpine:latest", Script: "ls $(workspaces.source.path)"}},
+						}},
+					},
+				},
+			},
+			Workspaces: []v1.WorkspaceBinding{
+				{Name: "source", EmptyDir: &corev1.EmptyDirVolumeSource{}},
+				{Name: "source", EmptyDir: &corev1.EmptyDirVolumeSource{}},
+			},
+		},
+	}
+
+	err := ValidatePipelineRun(context.Background(), pr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `workspace "source" provided by pipelinerun more than once, at index 0 and 1`)
+	assert.NotContains(t, err.Error(), "PipelineRun workspaces")
+}
+
+func TestValidatePipelineRunTaskRunSpecs(t *testing.T) {
+	pipelineSpec := v1.PipelineSpec{
+		Tasks:   []v1.PipelineTask{{Name: "build"}},
+		Finally: []v1.PipelineTask{{Name: "notify"}},
+	}
+
+	tests := []struct {
+		name           string
+		specs          []v1.PipelineTaskRunSpec
+		expectedErrors []string
+	}{
+		{
+			name: "valid taskRunSpecs",
+			specs: []v1.PipelineTaskRunSpec{
+				{
+					PipelineTaskName:   "build",
+					ServiceAccountName: "build-bot",
+					PodTemplate: &pod.Template{
+						NodeSelector:     map[string]string{"kubernetes.io/arch": "arm64"},
+						Tolerations:      []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "builds", Effect: corev1.TaintEffectNoSchedule}},
+						ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+					},
+					ComputeResources: &corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+					},
+				},
+				{PipelineTaskName: "notify", ServiceAccountName: "$(params.serviceAccount)"},
+			},
+		},
+		{
+			name: "unknown, duplicate, and missing PipelineTask names",
+			specs: []v1.PipelineTaskRunSpec{
+				{PipelineTaskName: "build"},
+				{PipelineTaskName: "test"},
+				{PipelineTaskName: "build"},
+				{},
+			},
+			expectedErrors: []string{
+				`PipelineTask "test" does not exist: spec.taskRunSpecs[1].pipelineTaskName`,
+				`PipelineTask "build" is configured more than once, at index 0 and 2: spec.taskRunSpecs[2].pipelineTaskName`,
+				`missing field(s): spec.taskRunSpecs[3].pipelineTaskName`,
+			},
+		},
+		{
+			name: "invalid fields",
+			specs: []v1.PipelineTaskRunSpec{
+				{
+					PipelineTaskName:   "build",
+					ServiceAccountName: "Build_Bot",
+					PodTemplate: &pod.Template{
+						NodeSelector: map[string]string{"kubernetes.io/arch": "not a label value"},
+						Tolerations: []corev1.Toleration{
+							{Operator: corev1.TolerationOpExists, Value: "builds"},
+							{Operator: "Contains", Effect: "Sometimes"},
+						},
+						ImagePullSecrets: []corev1.LocalObjectReference{{}},
+						Volumes:          []corev1.Volume{{Name: "cache"}, {Name: "cache"}},
+					},
+					ComputeResources: &corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+						Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+					},
+				},
+			},
+			expectedErrors: []string{
+				`invalid service account name "Build_Bot"`,
+				`invalid node selector value "not a label value"`,
+				`value must be empty when operator is Exists: spec.taskRunSpecs[0].podTemplate.tolerations[0].value`,
+				`invalid value: Contains: spec.taskRunSpecs[0].podTemplate.tolerations[1].operator`,
+				`invalid value: Sometimes: spec.taskRunSpecs[0].podTemplate.tolerations[1].effect`,
+				`missing field(s): spec.taskRunSpecs[0].podTemplate.imagePullSecrets[0].name`,
+				`volume "cache" is defined more than once: spec.taskRunSpecs[0].podTemplate.volumes[1].name`,
+				`cpu request 2 exceeds its limit 500m: spec.taskRunSpecs[0].computeResources`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelineRunTaskRunSpecs(tt.specs, pipelineSpec)
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidatePipelineRunTimeoutDurations(t *testing.T) {
+	tests := []struct {
+		name           string
+		rawYAML        string
+		expectedErrors []string
+	}{
+		{
+			name: "valid durations",
+			rawYAML: `
+spec:
+  timeouts:
+    pipeline: 1h
+    tasks: 40m
+    finally: "0"
+  pipelineSpec:
+    tasks:
+      - name: build
+        timeout: 30m
+`,
+		},
+		{
+			name: "invalid durations",
+			rawYAML: `
+spec:
+  timeout: 1d
+  timeouts:
+    pipeline: 60
+    tasks: forty minutes
+  pipelineSpec:
+    tasks:
+      - name: build
+        timeout: 30m
+    finally:
+      - name: notify
+        timeout: soon
+`,
+			expectedErrors: []string{
+				`invalid value: "1d" is not a duration such as "1h30m": spec.timeout`,
+				`invalid value: 60 is not a duration such as "1h30m": spec.timeouts.pipeline`,
+				`invalid value: "forty minutes" is not a duration such as "1h30m": spec.timeouts.tasks`,
+				`invalid value: "soon" is not a duration such as "1h30m": spec.pipelineSpec.finally[0].timeout`,
+			},
+		},
+		{
+			name:    "malformed YAML",
+			rawYAML: "spec: [",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelineRunTimeoutDurations([]byte(tt.rawYAML))
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+			assert.Len(t, err.(*multierror.Error).Errors, len(tt.expectedErrors))
+		})
+	}
+}
+
+func TestValidatePipelineTaskTimeouts(t *testing.T) {
+	duration := func(d time.Duration) *metav1.Duration {
+		return &metav1.Duration{Duration: d}
+	}
+	pipelineSpec := v1.PipelineSpec{
+		Tasks: []v1.PipelineTask{
+			{Name: "build", Timeout: duration(30 * time.Minute)},
+			{Name: "test"},
+		},
+		Finally: []v1.PipelineTask{
+			{Name: "notify", Timeout: duration(10 * time.Minute)},
+		},
+	}
+
+	longTask := v1.PipelineSpec{
+		Tasks: []v1.PipelineTask{{Name: "build", Timeout: duration(2 * time.Hour)}},
+	}
+
+	tests := []struct {
+		name           string
+		timeouts       *v1.TimeoutFields
+		pipelineSpec   v1.PipelineSpec
+		path           string
+		expectedErrors []string
+	}{
+		{
+			name:         "no timeouts",
+			pipelineSpec: pipelineSpec,
+		},
+		{
+			name:         "exceeding the default pipeline timeout",
+			pipelineSpec: longTask,
+			expectedErrors: []string{
+				`timeout 2h0m0s of PipelineTask "build" exceeds the default pipeline timeout (1h0m0s): spec.tasks[0].timeout`,
+			},
+		},
+		{
+			name:         "exceeding the default pipeline timeout with empty timeouts",
+			timeouts:     &v1.TimeoutFields{},
+			pipelineSpec: longTask,
+			path:         "spec.pipelineSpec",
+			expectedErrors: []string{
+				`timeout 2h0m0s of PipelineTask "build" exceeds the default pipeline timeout (1h0m0s): spec.pipelineSpec.tasks[0].timeout`,
+			},
+		},
+		{
+			name:         "within the tasks timeout exceeding the default pipeline timeout",
+			timeouts:     &v1.TimeoutFields{Tasks: duration(3 * time.Hour)},
+			pipelineSpec: longTask,
+		},
+		{
+			name:         "within the pipeline timeout",
+			timeouts:     &v1.TimeoutFields{Pipeline: duration(time.Hour)},
+			pipelineSpec: pipelineSpec,
+		},
+		{
+			name:         "no pipeline timeout",
+			timeouts:     &v1.TimeoutFields{Pipeline: duration(0)},
+			pipelineSpec: pipelineSpec,
+		},
+		{
+			name:         "exceeding the pipeline timeout",
+			timeouts:     &v1.TimeoutFields{Pipeline: duration(20 * time.Minute)},
+			pipelineSpec: pipelineSpec,
+			expectedErrors: []string{
+				`timeout 30m0s of PipelineTask "build" exceeds spec.timeouts.pipeline (20m0s): spec.tasks[0].timeout`,
+			},
+		},
+		{
+			name: "exceeding the tasks and finally timeouts",
+			timeouts: &v1.TimeoutFields{
+				Pipeline: duration(time.Hour),
+				Tasks:    duration(20 * time.Minute),
+				Finally:  duration(5 * time.Minute),
+			},
+			pipelineSpec: pipelineSpec,
+			expectedErrors: []string{
+				`timeout 30m0s of PipelineTask "build" exceeds spec.timeouts.tasks (20m0s): spec.tasks[0].timeout`,
+				`timeout 10m0s of PipelineTask "notify" exceeds spec.timeouts.finally (5m0s): spec.finally[0].timeout`,
+			},
+		},
+		{
+			name: "negative timeout",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{{Name: "build", Timeout: duration(-time.Minute)}},
+			},
+			expectedErrors: []string{
+				`invalid value: -1m0s should be >= 0: spec.tasks[0].timeout`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := tt.path
+			if path == "" {
+				path = "spec"
+			}
+			err := ValidatePipelineTaskTimeouts(tt.timeouts, tt.pipelineSpec, path)
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+			assert.Len(t, err.(*multierror.Error).Errors, len(tt.expectedErrors))
+		})
+	}
+}
+
+func TestValidateWorkspaceBindingQuantities(t *testing.T) {
+	assert.NoError(t, ValidateWorkspaceBindingQuantities([]byte(`
+spec:
+  workspaces:
+    - name: cache
+      emptyDir:
+        sizeLimit: 500Mi
+    - name: source
+      volumeClaimTemplate:
+        spec:
+          resources:
+            requests:
+              storage: 1Gi
+            limits:
+              storage: 2048
+`)))
+
+	err := ValidateWorkspaceBindingQuantities([]byte(`
+spec:
+  workspaces:
+    - name: cache
+      emptyDir:
+        sizeLimit: -1Gi
+    - name: source
+      volumeClaimTemplate:
+        spec:
+          resources:
+            requests:
+              storage: 1 GB
+            limits:
+              storage: [1Gi]
+    - name: output
+      volumeClaimTemplate:
+        spec:
+          resources:
+            requests:
+              storage: "0"
+`))
+	require.Error(t, err)
+	var messages []string
+	for _, e := range err.(*multierror.Error).Errors {
+		messages = append(messages, e.Error())
+	}
+	assert.Equal(t, []string{
+		"invalid value: -1Gi must not be negative: spec.workspaces[0].emptyDir.sizeLimit",
+		`invalid value: "1 GB" is not a quantity such as "1Gi": spec.workspaces[1].volumeClaimTemplate.spec.resources.requests.storage`,
+		`invalid value: [1Gi] is not a quantity such as "1Gi": spec.workspaces[1].volumeClaimTemplate.spec.resources.limits.storage`,
+		"invalid value: 0 must be greater than zero: spec.workspaces[2].volumeClaimTemplate.spec.resources.requests.storage",
+	}, messages)
+}
+
+func TestValidatePipelineRunWorkspaceVolumes(t *testing.T) {
+	pr, err := pipelineRunFromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  pipelineRef:
+    name: build
+  workspaces:
+    - name: source
+      volumeClaimTemplate:
+        spec:
+          accessModes: [ReadWriteOnce, ReadWriteSometimes]
+          storageClassName: ""
+    - name: cache
+      volumeClaimTemplate:
+        spec:
+          accessModes: [ReadWriteMany]
+          resources:
+            requests:
+              storage: 1Gi
+`)
+	require.NoError(t, err)
+
+	err = ValidatePipelineRun(context.Background(), pr)
+	require.Error(t, err)
+	var messages []string
+	for _, finding := range Findings(err) {
+		messages = append(messages, fmt.Sprintf("%s %s: %s", finding.Severity, finding.Message, finding.ResourcePath))
+	}
+	assert.Equal(t, []string{
+		"error PipelineRun workspaces: invalid value: ReadWriteSometimes is not an access mode, expected one of: ReadWriteOnce, ReadOnlyMany, ReadWriteMany, ReadWriteOncePod: spec.workspaces[0].volumeClaimTemplate.spec.accessModes[1]",
+		"error PipelineRun workspaces: missing field(s): spec.workspaces[0].volumeClaimTemplate.spec.resources.requests.storage",
+		"warning PipelineRun workspaces: storageClassName is empty, which disables dynamic provisioning so that the claim only binds to an existing volume; leave it out to use the default storage class: spec.workspaces[0].volumeClaimTemplate.spec.storageClassName",
+	}, messages)
+}
+
+func TestValidatePipelineRunProjectedAndCSIWorkspaces(t *testing.T) {
+	pr, err := pipelineRunFromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  pipelineRef:
+    name: build
+  workspaces:
+    - name: credentials
+      projected:
+        sources:
+          - secret:
+              name: token
+          - secret:
+              name: registry
+            configMap:
+              name: settings
+          - {}
+    - name: empty
+      projected:
+        sources: []
+    - name: inline
+      csi:
+        driver: ""
+    - name: secrets
+      csi:
+        driver: secrets-store.csi.k8s.io
+`)
+	require.NoError(t, err)
+
+	err = ValidatePipelineRun(context.Background(), pr)
+	require.Error(t, err)
+	var messages []string
+	for _, finding := range Findings(err) {
+		messages = append(messages, fmt.Sprintf("%s: %s", finding.Message, finding.ResourcePath))
+	}
+	assert.ElementsMatch(t, []string{
+		"missing field(s): spec.workspaces[1].projected.sources",
+		"missing field(s): spec.workspaces[2].csi.driver",
+		"PipelineRun workspaces: expected exactly one of secret, configMap, downwardAPI, serviceAccountToken, clusterTrustBundle, got secret and configMap: spec.workspaces[0].projected.sources[1]",
+		"PipelineRun workspaces: expected exactly one of secret, configMap, downwardAPI, serviceAccountToken, clusterTrustBundle, got neither: spec.workspaces[0].projected.sources[2]",
+	}, messages)
+}
+
+func TestValidatePipelineRunWorkspaceBindingSources(t *testing.T) {
+	pr, err := pipelineRunFromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  pipelineRef:
+    name: build
+  workspaces:
+    - name: source
+      emptyDir: {}
+      persistentVolumeClaim:
+        claimName: source
+    - name: cache
+    - name: output
+      subPath: output
+    - name: credentials
+      secret:
+        secretName: registry
+`)
+	require.NoError(t, err)
+
+	err = ValidatePipelineRun(context.Background(), pr)
+	require.Error(t, err)
+	var messages []string
+	for _, finding := range Findings(err) {
+		messages = append(messages, fmt.Sprintf("%s: %s", finding.Message, finding.ResourcePath))
+	}
+	assert.Equal(t, []string{
+		`PipelineRun workspaces: workspace binding "source" sets emptyDir and persistentVolumeClaim, expected exactly one of: emptyDir, persistentVolumeClaim, volumeClaimTemplate, configMap, secret, projected, csi: spec.workspaces[0]`,
+		`PipelineRun workspaces: workspace binding "cache" sets no volume source, expected exactly one of: emptyDir, persistentVolumeClaim, volumeClaimTemplate, configMap, secret, projected, csi: spec.workspaces[1]`,
+		`PipelineRun workspaces: workspace binding "output" sets no volume source, expected exactly one of: emptyDir, persistentVolumeClaim, volumeClaimTemplate, configMap, secret, projected, csi: spec.workspaces[2]`,
+	}, messages)
+}
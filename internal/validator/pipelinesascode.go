@@ -0,0 +1,264 @@
+package validator
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/google/cel-go/cel"
+	"github.com/hashicorp/go-multierror"
+	"github.com/openshift-pipelines/pipelines-as-code/pkg/apis/pipelinesascode"
+	"github.com/openshift-pipelines/pipelines-as-code/pkg/apis/pipelinesascode/keys"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// pacAnnotationPrefix starts the names of the annotations of Pipelines as Code
+const pacAnnotationPrefix = pipelinesascode.GroupName + "/"
+
+// pacAnnotationValuesRegex matches the values of the annotations of Pipelines as Code which hold
+// one value or a list of them, e.g. push or [pull_request, push]
+var pacAnnotationValuesRegex = regexp.MustCompile(`^\[(.*)\]$|^[^[\]\s]*$`)
+
+// pacTaskAnnotationRegex matches the numbered annotations of the remote Tasks of a PipelineRun,
+// e.g. pipelinesascode.tekton.dev/task-1
+var pacTaskAnnotationRegex = regexp.MustCompile(`^` + regexp.QuoteMeta(keys.Task) + `-[0-9]+$`)
+
+// pacEvents are the events Pipelines as Code triggers PipelineRuns on
+var pacEvents = []string{"pull_request", "push", "incoming"}
+
+// pacAnnotations are the annotations of Pipelines as Code: those set in the PipelineRuns of a
+// repository, and those it adds to the PipelineRuns it creates
+var pacAnnotations = map[string]bool{
+	keys.OnEvent: true, keys.OnTargetBranch: true, keys.OnCelExpression: true,
+	pacAnnotationPrefix + "on-path-change": true, pacAnnotationPrefix + "on-path-change-ignore": true,
+	pacAnnotationPrefix + "on-comment": true, pacAnnotationPrefix + "on-label": true,
+	pacAnnotationPrefix + "cancel-in-progress": true,
+	keys.MaxKeepRuns: true, keys.TargetNamespace: true, keys.Task: true, keys.Pipeline: true,
+	keys.URLOrg: true, keys.URLRepository: true, keys.SHA: true, keys.Sender: true, keys.EventType: true,
+	keys.Branch: true, keys.Repository: true, keys.GitProvider: true, keys.State: true, keys.ShaTitle: true,
+	keys.ShaURL: true, keys.RepoURL: true, keys.PullRequest: true, keys.InstallationID: true, keys.GHEURL: true,
+	keys.SourceProjectID: true, keys.TargetProjectID: true, keys.OriginalPRName: true, keys.GitAuthSecret: true,
+	keys.CheckRunID: true, keys.LogURL: true, keys.ExecutionOrder: true,
+}
+
+// ValidatePipelinesAsCodeAnnotations verifies the annotations of Pipelines as Code of a PipelineRun:
+// the events and the branch globs it is triggered on, its CEL expression, and the number of runs to
+// keep, and warns about unknown annotations and triggers Pipelines as Code ignores. Pipelines as
+// Code silently skips the PipelineRuns whose annotations it cannot use.
+func ValidatePipelinesAsCodeAnnotations(meta metav1.ObjectMeta) error {
+	var err error
+	for _, key := range SortedKeys(meta.Annotations) {
+		value := meta.Annotations[key]
+		if !strings.HasPrefix(key, pacAnnotationPrefix) || isTemplated(value) {
+			continue
+		}
+		path := fmt.Sprintf("metadata.annotations[%s]", key)
+
+		switch key {
+		case keys.OnEvent:
+			values, valuesErr := pacAnnotationValues(value)
+			if valuesErr != nil {
+				err = multierror.Append(err, withPath(path, valuesErr))
+			}
+			for _, event := range values {
+				if !slices.Contains(pacEvents, event) {
+					err = multierror.Append(err, withPath(path, fmt.Errorf(
+						"unknown event %q, must be one of %s", event, strings.Join(pacEvents, ", "))))
+				}
+			}
+		case keys.OnTargetBranch:
+			values, valuesErr := pacAnnotationValues(value)
+			if valuesErr != nil {
+				err = multierror.Append(err, withPath(path, valuesErr))
+			}
+			for _, branch := range values {
+				if _, globErr := glob.Compile(branch); globErr != nil {
+					err = multierror.Append(err, withPath(path, fmt.Errorf("invalid branch glob %q, %v", branch, globErr)))
+				}
+			}
+		case keys.OnCelExpression:
+			if celErr := checkPipelinesAsCodeCEL(value); celErr != nil {
+				err = multierror.Append(err, withPath(path, celErr))
+			}
+		case keys.MaxKeepRuns:
+			if count, atoiErr := strconv.Atoi(strings.TrimSpace(value)); atoiErr != nil || count <= 0 {
+				err = multierror.Append(err, withPath(path, fmt.Errorf("max-keep-runs must be a positive integer, not %q", value)))
+			}
+		default:
+			if !pacAnnotations[key] && !pacTaskAnnotationRegex.MatchString(key) {
+				message := fmt.Sprintf("unknown Pipelines as Code annotation %q, which it ignores", key)
+				if suggestion := closestField(key, pacAnnotations); suggestion != "" {
+					message += fmt.Sprintf(" (did you mean %q)", suggestion)
+				}
+				err = multierror.Append(err, withPath(path, warningf("%s", message)))
+			}
+		}
+	}
+
+	_, onEvent := meta.Annotations[keys.OnEvent]
+	_, onTargetBranch := meta.Annotations[keys.OnTargetBranch]
+	if _, onCelExpression := meta.Annotations[keys.OnCelExpression]; onCelExpression {
+		if onEvent || onTargetBranch {
+			err = multierror.Append(err, warningf(
+				"Pipelines as Code ignores the on-event and on-target-branch annotations of a PipelineRun with an on-cel-expression: metadata.annotations[%s]",
+				keys.OnCelExpression))
+		}
+	} else if onEvent != onTargetBranch {
+		missing := keys.OnTargetBranch
+		if onTargetBranch {
+			missing = keys.OnEvent
+		}
+		err = multierror.Append(err, warningf(
+			"Pipelines as Code only triggers PipelineRuns setting both the on-event and on-target-branch annotations: metadata.annotations[%s]", missing))
+	}
+	return err
+}
+
+// pacAnnotationValues returns the values of an annotation of Pipelines as Code holding one value or
+// a list of them, as parsed by Pipelines as Code
+func pacAnnotationValues(value string) ([]string, error) {
+	value = strings.TrimSpace(value)
+	if !pacAnnotationValuesRegex.MatchString(value) {
+		return nil, fmt.Errorf("invalid value %q, must be a single value or a list, e.g. [pull_request, push]", value)
+	}
+	if !strings.HasPrefix(value, "[") {
+		return []string{value}, nil
+	}
+	values := strings.Split(pacAnnotationValuesRegex.FindStringSubmatch(value)[1], ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	if values[0] == "" {
+		return nil, fmt.Errorf("invalid value %q, the list is empty", value)
+	}
+	return values, nil
+}
+
+// checkPipelinesAsCodeCEL compiles an on-cel-expression in the environment Pipelines as Code
+// evaluates it in, which must result in a bool
+func checkPipelinesAsCodeCEL(expression string) error {
+	env, err := cel.NewEnv(
+		cel.Variable("event", cel.StringType),
+		cel.Variable("event_title", cel.StringType),
+		cel.Variable("target_branch", cel.StringType),
+		cel.Variable("source_branch", cel.StringType),
+		cel.Variable("target_url", cel.StringType),
+		cel.Variable("source_url", cel.StringType),
+		cel.Variable("body", cel.DynType),
+		cel.Variable("headers", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("files", cel.DynType),
+		cel.Function("pathChanged", cel.MemberOverload("pathChanged", []*cel.Type{cel.StringType}, cel.BoolType)),
+	)
+	if err != nil {
+		return err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		// The issues are reported on one line each, without the excerpt of the expression.
+		var messages []string
+		for _, issue := range issues.Errors() {
+			messages = append(messages, fmt.Sprintf("%s at %d:%d", issue.Message, issue.Location.Line(), issue.Location.Column()+1))
+		}
+		return fmt.Errorf("invalid on-cel-expression, %s", strings.Join(messages, "; "))
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return fmt.Errorf("on-cel-expression evaluates to %s, expected bool", ast.OutputType())
+	}
+	return nil
+}
+
+// pacVariableRegex matches the placeholders of the template variables of Pipelines as Code, as
+// replaced by it, e.g. {{ revision }}
+var pacVariableRegex = regexp.MustCompile(`{{([^}]{2,})}}`)
+
+// fieldNameRegex matches the names of fields written after a dot in the paths of findings
+var fieldNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// pacVariables are the template variables Pipelines as Code replaces in the PipelineRuns it triggers
+var pacVariables = map[string]bool{
+	"revision": true, "repo_url": true, "repo_owner": true, "repo_name": true,
+	"target_branch": true, "source_branch": true, "source_url": true, "sender": true,
+	"target_namespace": true, "event_type": true, "pull_request_number": true,
+	"pull_request_labels": true, "git_auth_secret": true, "git_tag": true, "trigger_comment": true,
+}
+
+// pacVariablePrefixes start the template variables of Pipelines as Code evaluated against the
+// payload, the headers, and the changed files of the event, e.g. {{ body.pull_request.title }}
+var pacVariablePrefixes = []string{"body.", "headers", "files."}
+
+// ValidatePipelinesAsCodeVariables warns about the placeholders of a PipelineRun document which are
+// neither template variables of Pipelines as Code nor among the given custom params of the
+// Repository, suggesting the variable they are most likely a typo of. Pipelines as Code keeps such
+// placeholders as they are. Only the PipelineRun is given, as the Tasks and Pipelines its resolution
+// embeds are not templated.
+func ValidatePipelinesAsCodeVariables(pipelineRunYAML []byte, customParams []string) error {
+	var raw map[string]any
+	if yaml.Unmarshal(pipelineRunYAML, &raw) != nil {
+		// A document which is not a YAML object fails decoding the PipelineRun instead.
+		return nil
+	}
+	known := maps.Clone(pacVariables)
+	for _, param := range customParams {
+		known[param] = true
+	}
+	return withRule(RulePACVariables, unknownPACVariables(raw, "", known))
+}
+
+// unknownPACVariables reports the template variables of the keys and strings of a raw value which
+// are not known, recursively
+func unknownPACVariables(value any, path string, known map[string]bool) error {
+	var err error
+	switch value := value.(type) {
+	case map[string]any:
+		for _, key := range SortedKeys(value) {
+			keyPath := path + "[" + key + "]"
+			if fieldNameRegex.MatchString(key) {
+				keyPath = strings.TrimPrefix(path+"."+key, ".")
+			}
+			if keyErr := unknownPACVariables(key, keyPath, known); keyErr != nil {
+				err = multierror.Append(err, keyErr)
+			}
+			if valueErr := unknownPACVariables(value[key], keyPath, known); valueErr != nil {
+				err = multierror.Append(err, valueErr)
+			}
+		}
+	case []any:
+		for i, element := range value {
+			if elementErr := unknownPACVariables(element, fmt.Sprintf("%s[%d]", path, i), known); elementErr != nil {
+				err = multierror.Append(err, elementErr)
+			}
+		}
+	case string:
+		for _, match := range pacVariableRegex.FindAllStringSubmatch(value, -1) {
+			variable := strings.TrimSpace(match[1])
+			if isPACVariable(variable, known) {
+				continue
+			}
+			message := fmt.Sprintf("unknown Pipelines as Code variable %q, which it leaves as is unless the Repository defines it as a custom param", variable)
+			if suggestion := closestField(variable, known); suggestion != "" {
+				message += fmt.Sprintf(" (did you mean %q)", suggestion)
+			}
+			err = multierror.Append(err, withPath(path, warningf("%s", message)))
+		}
+	}
+	return err
+}
+
+// isPACVariable tells whether a variable is one of the known template variables of Pipelines as
+// Code, or is evaluated against the event
+func isPACVariable(variable string, known map[string]bool) bool {
+	if known[variable] {
+		return true
+	}
+	for _, prefix := range pacVariablePrefixes {
+		if strings.HasPrefix(variable, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidatePipelinesAsCodeAnnotations(t *testing.T) {
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		expectedErrors   []string
+		expectedWarnings []string
+	}{
+		{
+			name: "valid annotations",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-event":         "[pull_request, push]",
+				"pipelinesascode.tekton.dev/on-target-branch": "[main, release-*]",
+				"pipelinesascode.tekton.dev/max-keep-runs":    "3",
+				"pipelinesascode.tekton.dev/task":             "git-clone",
+				"pipelinesascode.tekton.dev/task-1":           "[buildah]",
+				"example.com/owner":                           "team",
+			},
+		},
+		{
+			name: "single values",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-event":         "push",
+				"pipelinesascode.tekton.dev/on-target-branch": "refs/heads/main",
+			},
+		},
+		{
+			name: "valid on-cel-expression",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-cel-expression": `event == "pull_request" && target_branch == "main" && "docs/***".pathChanged()`,
+			},
+		},
+		{
+			name: "templated values",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-event":         "{{ event_type }}",
+				"pipelinesascode.tekton.dev/on-target-branch": "{{ target_branch }}",
+				"pipelinesascode.tekton.dev/max-keep-runs":    "{{ keep }}",
+			},
+		},
+		{
+			name: "unknown event",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-event":         "[pull-request, push]",
+				"pipelinesascode.tekton.dev/on-target-branch": "main",
+			},
+			expectedErrors: []string{
+				`unknown event "pull-request", must be one of pull_request, push, incoming: metadata.annotations[pipelinesascode.tekton.dev/on-event]`,
+			},
+		},
+		{
+			name: "malformed lists",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-event":         "[]",
+				"pipelinesascode.tekton.dev/on-target-branch": "main, release",
+			},
+			expectedErrors: []string{
+				`invalid value "[]", the list is empty: metadata.annotations[pipelinesascode.tekton.dev/on-event]`,
+				`invalid value "main, release", must be a single value or a list, e.g. [pull_request, push]: metadata.annotations[pipelinesascode.tekton.dev/on-target-branch]`,
+			},
+		},
+		{
+			name: "invalid branch glob",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-event":         "push",
+				"pipelinesascode.tekton.dev/on-target-branch": "[main, release-[0-9]",
+			},
+			expectedErrors: []string{
+				`invalid branch glob "release-[0-9", unexpected end of input`,
+				`: metadata.annotations[pipelinesascode.tekton.dev/on-target-branch]`,
+			},
+		},
+		{
+			name: "invalid max-keep-runs",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/max-keep-runs": "-1",
+			},
+			expectedErrors: []string{
+				`max-keep-runs must be a positive integer, not "-1": metadata.annotations[pipelinesascode.tekton.dev/max-keep-runs]`,
+			},
+		},
+		{
+			name: "invalid on-cel-expression",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-cel-expression": `event == "push" &&`,
+			},
+			expectedErrors: []string{
+				`invalid on-cel-expression, Syntax error`,
+				`: metadata.annotations[pipelinesascode.tekton.dev/on-cel-expression]`,
+			},
+		},
+		{
+			name: "on-cel-expression not a bool",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-cel-expression": `target_branch`,
+			},
+			expectedErrors: []string{
+				`on-cel-expression evaluates to string, expected bool: metadata.annotations[pipelinesascode.tekton.dev/on-cel-expression]`,
+			},
+		},
+		{
+			name: "unknown annotation",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/max-keep-run": "3",
+			},
+			expectedWarnings: []string{
+				`unknown Pipelines as Code annotation "pipelinesascode.tekton.dev/max-keep-run", which it ignores (did you mean "pipelinesascode.tekton.dev/max-keep-runs"): metadata.annotations[pipelinesascode.tekton.dev/max-keep-run]`,
+			},
+		},
+		{
+			name: "on-event without on-target-branch",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-event": "push",
+			},
+			expectedWarnings: []string{
+				"Pipelines as Code only triggers PipelineRuns setting both the on-event and on-target-branch annotations: metadata.annotations[pipelinesascode.tekton.dev/on-target-branch]",
+			},
+		},
+		{
+			name: "on-cel-expression overriding other triggers",
+			annotations: map[string]string{
+				"pipelinesascode.tekton.dev/on-cel-expression": `event == "push"`,
+				"pipelinesascode.tekton.dev/on-event":          "push",
+				"pipelinesascode.tekton.dev/on-target-branch":  "main",
+			},
+			expectedWarnings: []string{
+				"Pipelines as Code ignores the on-event and on-target-branch annotations of a PipelineRun with an on-cel-expression: metadata.annotations[pipelinesascode.tekton.dev/on-cel-expression]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelinesAsCodeAnnotations(metav1.ObjectMeta{Name: "run", Annotations: tt.annotations})
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, WithoutWarnings(err))
+			} else {
+				require.Error(t, WithoutWarnings(err))
+				for _, expected := range tt.expectedErrors {
+					assert.Contains(t, WithoutWarnings(err).Error(), expected)
+				}
+			}
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+		})
+	}
+}
+
+func TestValidatePipelineRunPipelinesAsCodeAnnotations(t *testing.T) {
+	pr := v1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "run",
+			Annotations: map[string]string{"pipelinesascode.tekton.dev/max-keep-runs": "many"},
+		},
+		Spec: v1.PipelineRunSpec{
+			PipelineSpec: &v1.PipelineSpec{Tasks: []v1.PipelineTask{{
+				Name: "build",
+				TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+					Steps: []v1.Step{{Name: "build", Image: "registry.io/build:1.0", Script: "build"}},
+				}},
+			}}},
+		},
+	}
+	assert.Equal(t, []string{RulePACAnnotations.ID}, findingRules(ValidatePipelineRun(context.Background(), pr)))
+}
+
+func TestValidatePipelinesAsCodeVariables(t *testing.T) {
+	tests := []struct {
+		name         string
+		yaml         string
+		customParams []string
+		expected     []string
+	}{
+		{
+			name: "known variables",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: '{{ repo_name }}-on-push'
+  labels:
+    branch: '{{source_branch}}'
+spec:
+  params:
+  - name: url
+    value: '{{ source_url }}'
+  - name: title
+    value: '{{ body.pull_request.title }}'
+`,
+		},
+		{
+			name: "unknown variables",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: run
+  annotations:
+    example.com/revision: '{{ revison }}'
+spec:
+  params:
+  - name: image
+    value: 'quay.io/{{ repo_owner }}/{{ my_image }}:{{ revision }}'
+`,
+			expected: []string{
+				`unknown Pipelines as Code variable "revison", which it leaves as is unless the Repository defines it as a custom param (did you mean "revision"): metadata.annotations[example.com/revision]`,
+				`unknown Pipelines as Code variable "my_image", which it leaves as is unless the Repository defines it as a custom param: spec.params[0].value`,
+			},
+		},
+		{
+			name: "custom params",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: run
+spec:
+  params:
+  - name: token
+    value: '{{ registry_token }}'
+  - name: image
+    value: '{{ image_name }}'
+`,
+			customParams: []string{"registry_token", "image-name"},
+			expected: []string{
+				`unknown Pipelines as Code variable "image_name", which it leaves as is unless the Repository defines it as a custom param (did you mean "image-name"): spec.params[1].value`,
+			},
+		},
+		{
+			name: "malformed content",
+			yaml: "[",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelinesAsCodeVariables([]byte(tt.yaml), tt.customParams)
+
+			assert.NoError(t, WithoutWarnings(err))
+			assert.Equal(t, tt.expected, Warnings(err))
+			for _, finding := range Findings(err) {
+				assert.Equal(t, RulePACVariables.ID, finding.Rule)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package validator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// jsonUnmarshalerType is implemented by the types decoded from a custom format, e.g. quantities,
+// whose fields are not checked
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// taskSpecFieldTypes are the types of the fields of task specs embedding Kubernetes types, for v1
+// and v1beta1
+var taskSpecFieldTypes = map[bool]map[string]reflect.Type{
+	false: {
+		"steps":        reflect.TypeOf([]v1.Step{}),
+		"sidecars":     reflect.TypeOf([]v1.Sidecar{}),
+		"stepTemplate": reflect.TypeOf(v1.StepTemplate{}),
+		"volumes":      reflect.TypeOf([]corev1.Volume{}),
+	},
+	true: {
+		"steps":        reflect.TypeOf([]v1beta1.Step{}),
+		"sidecars":     reflect.TypeOf([]v1beta1.Sidecar{}),
+		"stepTemplate": reflect.TypeOf(v1beta1.StepTemplate{}),
+		"volumes":      reflect.TypeOf([]corev1.Volume{}),
+	},
+}
+
+// podTemplateType is the type of the pod templates of TaskRuns and PipelineRuns
+var podTemplateType = reflect.TypeOf(pod.Template{})
+
+// ValidatePodFields reports the unknown fields of the Kubernetes types embedded in the raw YAML of a
+// Task, TaskRun, Pipeline, or PipelineRun: the steps, sidecars, step template, and volumes of task
+// specs, and pod templates. Tekton keeps such fields without using them, so a typo, e.g. volumeMount
+// instead of volumeMounts, would otherwise go unnoticed.
+func ValidatePodFields(rawYAML []byte) error {
+	var raw struct {
+		APIVersion string         `json:"apiVersion"`
+		Kind       string         `json:"kind"`
+		Spec       map[string]any `json:"spec"`
+	}
+	if yaml.Unmarshal(rawYAML, &raw) != nil {
+		// A spec which is not an object fails decoding the document instead.
+		return nil
+	}
+	beta := raw.APIVersion == v1beta1.SchemeGroupVersion.String()
+	spec := raw.Spec
+
+	var err error
+	appendErr := func(fieldErr error) {
+		if fieldErr != nil {
+			err = multierror.Append(err, fieldErr)
+		}
+	}
+	switch raw.Kind {
+	case "Task":
+		appendErr(taskSpecUnknownFields(spec, "spec", beta))
+	case "TaskRun":
+		appendErr(taskSpecUnknownFields(mapField(spec, "taskSpec"), "spec.taskSpec", beta))
+		appendErr(unknownFields(podTemplateType, spec["podTemplate"], "spec.podTemplate"))
+	case "Pipeline":
+		appendErr(pipelineSpecUnknownFields(spec, "spec", beta))
+	case "PipelineRun":
+		appendErr(pipelineSpecUnknownFields(mapField(spec, "pipelineSpec"), "spec.pipelineSpec", beta))
+		podTemplateField := "podTemplate"
+		if beta {
+			podTemplateField = "taskPodTemplate"
+			appendErr(unknownFields(podTemplateType, spec["podTemplate"], "spec.podTemplate"))
+		} else {
+			appendErr(unknownFields(podTemplateType, mapField(spec, "taskRunTemplate")["podTemplate"], "spec.taskRunTemplate.podTemplate"))
+		}
+		taskRunSpecs, _ := spec["taskRunSpecs"].([]any)
+		for i, taskRunSpec := range taskRunSpecs {
+			if taskRunSpec, ok := taskRunSpec.(map[string]any); ok {
+				appendErr(unknownFields(podTemplateType, taskRunSpec[podTemplateField], fmt.Sprintf("spec.taskRunSpecs[%d].%s", i, podTemplateField)))
+			}
+		}
+	}
+	return withRule(RuleSchema, err)
+}
+
+// pipelineSpecUnknownFields reports the unknown fields of the task specs embedded in the
+// PipelineTasks of a raw pipeline spec, including those of nested pipeline specs
+func pipelineSpecUnknownFields(spec map[string]any, path string, beta bool) error {
+	var err error
+	for _, section := range []string{"tasks", "finally"} {
+		pipelineTasks, _ := spec[section].([]any)
+		for i, pipelineTask := range pipelineTasks {
+			pipelineTask, ok := pipelineTask.(map[string]any)
+			if !ok {
+				continue
+			}
+			pipelineTaskPath := fmt.Sprintf("%s.%s[%d]", path, section, i)
+			if fieldErr := taskSpecUnknownFields(mapField(pipelineTask, "taskSpec"), pipelineTaskPath+".taskSpec", beta); fieldErr != nil {
+				err = multierror.Append(err, fieldErr)
+			}
+			if fieldErr := pipelineSpecUnknownFields(mapField(pipelineTask, "pipelineSpec"), pipelineTaskPath+".pipelineSpec", beta); fieldErr != nil {
+				err = multierror.Append(err, fieldErr)
+			}
+		}
+	}
+	return err
+}
+
+// taskSpecUnknownFields reports the unknown fields of the Kubernetes types embedded in a raw task spec
+func taskSpecUnknownFields(spec map[string]any, path string, beta bool) error {
+	var err error
+	for _, field := range []string{"steps", "sidecars", "stepTemplate", "volumes"} {
+		if fieldErr := unknownFields(taskSpecFieldTypes[beta][field], spec[field], path+"."+field); fieldErr != nil {
+			err = multierror.Append(err, fieldErr)
+		}
+	}
+	return err
+}
+
+// unknownFields reports the fields of a raw value which are not fields of the JSON encoding of its
+// type, recursively. Values which do not match the type are left to the other validations.
+func unknownFields(t reflect.Type, value any, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	var err error
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		fields := jsonFields(t)
+		for _, key := range SortedKeys(object) {
+			fieldType, known := fields[key]
+			if !known {
+				message := fmt.Sprintf("unknown field %q, which Tekton ignores", key)
+				if suggestion := closestField(key, fields); suggestion != "" {
+					message += fmt.Sprintf(" (did you mean %q)", suggestion)
+				}
+				err = multierror.Append(err, withPath(path+"."+key, errors.New(message)))
+				continue
+			}
+			if fieldErr := unknownFields(fieldType, object[key], path+"."+key); fieldErr != nil {
+				err = multierror.Append(err, fieldErr)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			if fieldErr := unknownFields(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i)); fieldErr != nil {
+				err = multierror.Append(err, fieldErr)
+			}
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for _, key := range SortedKeys(object) {
+			if fieldErr := unknownFields(t.Elem(), object[key], fmt.Sprintf("%s[%s]", path, key)); fieldErr != nil {
+				err = multierror.Append(err, fieldErr)
+			}
+		}
+	}
+	return err
+}
+
+// jsonFields maps the names of the fields of the JSON encoding of a struct to their type, including
+// the fields of embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for embeddedName, embeddedType := range jsonFields(embedded) {
+					fields[embeddedName] = embeddedType
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// closestField returns the known field an unknown one is most likely a typo of, differing in case
+// or by at most two edits, or an empty string
+func closestField[V any](name string, fields map[string]V) string {
+	closest, distance := "", 3
+	for field := range fields {
+		d := editDistance(strings.ToLower(name), strings.ToLower(field))
+		if d < distance || (d == distance && field < closest) {
+			closest, distance = field, d
+		}
+	}
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// mapField returns the field of a raw object holding an object, or nil
+func mapField(object map[string]any, field string) map[string]any {
+	value, _ := object[field].(map[string]any)
+	return value
+}
@@ -0,0 +1,234 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePodFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected []string
+	}{
+		{
+			name: "known fields",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  volumes:
+    - name: cache
+      emptyDir:
+        sizeLimit: 1Gi
+  stepTemplate:
+    env:
+      - name: TOKEN
+        valueFrom:
+          secretKeyRef:
+            name: token
+            key: token
+  steps:
+    - name: build
+      image: registry.io/build:1.0
+      params:
+        - name: args
+          value: [--verbose]
+      computeResources:
+        limits:
+          memory: 1Gi
+      volumeMounts:
+        - name: cache
+          mountPath: /cache
+  sidecars:
+    - name: registry
+      image: registry.io/registry:1.0
+      readinessProbe:
+        httpGet:
+          port: http
+`,
+		},
+		{
+			name: "typos in a Task",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  volumes:
+    - name: cache
+      emptyDir:
+        sizeLimt: 1Gi
+  stepTemplate:
+    env:
+      - name: TOKEN
+        valueFrom:
+          secretKeyRef:
+            name: token
+            Key: token
+  steps:
+    - name: build
+      image: registry.io/build:1.0
+      volumeMount:
+        - name: cache
+          mountPath: /cache
+  sidecars:
+    - name: registry
+      image: registry.io/registry:1.0
+      unrelated: true
+`,
+			expected: []string{
+				`unknown field "volumeMount", which Tekton ignores (did you mean "volumeMounts"): spec.steps[0].volumeMount [TEK0101]`,
+				`unknown field "unrelated", which Tekton ignores: spec.sidecars[0].unrelated [TEK0101]`,
+				`unknown field "Key", which Tekton ignores (did you mean "key"): spec.stepTemplate.env[0].valueFrom.secretKeyRef.Key [TEK0101]`,
+				`unknown field "sizeLimt", which Tekton ignores (did you mean "sizeLimit"): spec.volumes[0].emptyDir.sizeLimt [TEK0101]`,
+			},
+		},
+		{
+			name: "v1beta1 Task",
+			yaml: `
+apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: build
+spec:
+  steps:
+    - name: build
+      image: registry.io/build:1.0
+      resources:
+        limits:
+          memory: 1Gi
+      computeResources:
+        limits:
+          memory: 1Gi
+`,
+			expected: []string{
+				`unknown field "computeResources", which Tekton ignores: spec.steps[0].computeResources [TEK0101]`,
+			},
+		},
+		{
+			name: "TaskRun pod template",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  name: build
+spec:
+  taskSpec:
+    steps:
+      - name: build
+        image: registry.io/build:1.0
+        workingdir: /src
+  podTemplate:
+    imagePullSecrets:
+      - nam: registry
+    tolerations:
+      - key: dedicated
+        efect: NoSchedule
+`,
+			expected: []string{
+				`unknown field "workingdir", which Tekton ignores (did you mean "workingDir"): spec.taskSpec.steps[0].workingdir [TEK0101]`,
+				`unknown field "nam", which Tekton ignores (did you mean "name"): spec.podTemplate.imagePullSecrets[0].nam [TEK0101]`,
+				`unknown field "efect", which Tekton ignores (did you mean "effect"): spec.podTemplate.tolerations[0].efect [TEK0101]`,
+			},
+		},
+		{
+			name: "Pipeline with nested specs",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build
+      taskSpec:
+        steps:
+          - name: build
+            image: registry.io/build:1.0
+            envv: []
+  finally:
+    - name: child
+      pipelineSpec:
+        tasks:
+          - name: notify
+            taskSpec:
+              volumes:
+                - name: config
+                  configMap:
+                    nme: config
+`,
+			expected: []string{
+				`unknown field "envv", which Tekton ignores (did you mean "env"): spec.tasks[0].taskSpec.steps[0].envv [TEK0101]`,
+				`unknown field "nme", which Tekton ignores (did you mean "name"): spec.finally[0].pipelineSpec.tasks[0].taskSpec.volumes[0].configMap.nme [TEK0101]`,
+			},
+		},
+		{
+			name: "PipelineRun pod templates",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  pipelineRef:
+    name: build
+  taskRunTemplate:
+    podTemplate:
+      nodeselector:
+        disktype: ssd
+  taskRunSpecs:
+    - pipelineTaskName: build
+      podTemplate:
+        securityContext:
+          runAsUsr: 1000
+`,
+			expected: []string{
+				`unknown field "nodeselector", which Tekton ignores (did you mean "nodeSelector"): spec.taskRunTemplate.podTemplate.nodeselector [TEK0101]`,
+				`unknown field "runAsUsr", which Tekton ignores (did you mean "runAsUser"): spec.taskRunSpecs[0].podTemplate.securityContext.runAsUsr [TEK0101]`,
+			},
+		},
+		{
+			name: "v1beta1 PipelineRun pod templates",
+			yaml: `
+apiVersion: tekton.dev/v1beta1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  pipelineRef:
+    name: build
+  podTemplate:
+    hostNetwrk: true
+  taskRunSpecs:
+    - pipelineTaskName: build
+      taskPodTemplate:
+        dnsPolicy: None
+        dnsConfig:
+          nameserver: [1.1.1.1]
+`,
+			expected: []string{
+				`unknown field "hostNetwrk", which Tekton ignores (did you mean "hostNetwork"): spec.podTemplate.hostNetwrk [TEK0101]`,
+				`unknown field "nameserver", which Tekton ignores (did you mean "nameservers"): spec.taskRunSpecs[0].taskPodTemplate.dnsConfig.nameserver [TEK0101]`,
+			},
+		},
+		{
+			name: "malformed content",
+			yaml: "kind: [",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []string
+			for _, finding := range Findings(ValidatePodFields([]byte(tt.yaml))) {
+				messages = append(messages, finding.String())
+			}
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+}
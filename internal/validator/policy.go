@@ -0,0 +1,155 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/lcarva/tektor/internal/celrule"
+	"github.com/lcarva/tektor/internal/plugin"
+	"github.com/lcarva/tektor/internal/policy"
+)
+
+type policySubjectKey struct{}
+
+// withPolicySubject returns a copy of ctx where the policies are evaluated against the resource
+// subject returns for the resolved spec of the Pipeline being validated, e.g. a PipelineRun
+// embedding it, rather than against the Pipeline
+func withPolicySubject(ctx context.Context, subject func(resolved v1.PipelineSpec) any) context.Context {
+	return context.WithValue(ctx, policySubjectKey{}, subject)
+}
+
+// withoutPolicies returns a copy of ctx where the policies and custom rules are not evaluated,
+// e.g. for child Pipelines which are part of the resource the policies are evaluated against
+func withoutPolicies(ctx context.Context) context.Context {
+	opts := optionsFromContext(ctx)
+	opts.Policies = nil
+	opts.CustomRules = nil
+	opts.Plugins = nil
+	return WithOptions(ctx, opts)
+}
+
+// hasPolicies reports whether the validation options carried by ctx have policies, custom rules, or
+// plugins
+func hasPolicies(ctx context.Context) bool {
+	opts := optionsFromContext(ctx)
+	return opts.Policies != nil || opts.CustomRules != nil || len(opts.Plugins) > 0
+}
+
+// evaluatePolicies evaluates the policies, custom rules, and plugins of the validation options
+// against resource
+func evaluatePolicies(ctx context.Context, resource any) error {
+	opts := optionsFromContext(ctx)
+	var allErrors error
+	if opts.Policies != nil {
+		if err := evaluateRego(ctx, opts.Policies, resource); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+	if opts.CustomRules != nil {
+		if err := evaluateCustomRules(ctx, opts.CustomRules, resource); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+	for _, p := range opts.Plugins {
+		if err := runPlugin(ctx, p, resource); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+	return allErrors
+}
+
+// runPlugin runs a plugin against resource. The findings of the plugin are attributed to the rules
+// it names, or else to the plugin.
+func runPlugin(ctx context.Context, p plugin.Plugin, resource any) error {
+	pluginRule := Rule{ID: plugin.Prefix + p.Name, Name: plugin.Prefix + p.Name, Description: "plugin"}
+	findings, err := p.Run(ctx, resource)
+	if err != nil {
+		return withRule(pluginRule, err)
+	}
+	var allErrors error
+	for _, finding := range findings {
+		err := fmt.Errorf("%s", finding.Message)
+		if finding.Severity == string(SeverityWarning) {
+			err = warningf("%s", finding.Message)
+		}
+		err = withPath(finding.ResourcePath, err)
+		rule := pluginRule
+		if finding.Rule != "" {
+			rule = Rule{ID: finding.Rule, Name: finding.Rule, Description: pluginRule.Description}
+		}
+		allErrors = multierror.Append(allErrors, withRule(rule, err))
+	}
+	return allErrors
+}
+
+// evaluateCustomRules evaluates custom rules against resource, attributing each violation to its
+// custom rule
+func evaluateCustomRules(ctx context.Context, rules *celrule.Set, resource any) error {
+	violations, err := rules.Evaluate(ctx, resource)
+	if err != nil {
+		return err
+	}
+	var allErrors error
+	for _, violation := range violations {
+		err := fmt.Errorf("%s", violation.Message)
+		if violation.Warning {
+			err = warningf("%s", violation.Message)
+		}
+		rule := Rule{ID: violation.Rule, Name: violation.Rule, Description: "custom rule"}
+		allErrors = multierror.Append(allErrors, withRule(rule, err))
+	}
+	return allErrors
+}
+
+// evaluateRego evaluates Rego policies against resource
+func evaluateRego(ctx context.Context, engine *policy.Engine, resource any) error {
+	violations, err := engine.Evaluate(ctx, resource)
+	if err != nil {
+		return withRule(RulePolicy, err)
+	}
+	var allErrors error
+	for _, violation := range violations {
+		var err error
+		if violation.Warning {
+			err = warningf("%s", violation.Message)
+		} else {
+			err = fmt.Errorf("%s", violation.Message)
+		}
+		allErrors = multierror.Append(allErrors, withRule(RulePolicy, withPath(violation.Path, err)))
+	}
+	return allErrors
+}
+
+// evaluatePipelinePolicies evaluates the policies against the Pipeline being validated, with the
+// Tasks its PipelineTasks refer to embedded
+func evaluatePipelinePolicies(ctx context.Context, p v1.Pipeline, taskSpecs map[string]*v1.TaskSpec) error {
+	if !hasPolicies(ctx) {
+		return nil
+	}
+	resolved := *p.Spec.DeepCopy()
+	for _, section := range pipelineTaskSections(resolved) {
+		for i := range section.pipelineTasks {
+			pipelineTask := &section.pipelineTasks[i]
+			taskSpec := taskSpecs[pipelineTask.Name]
+			if pipelineTask.TaskRef == nil || taskSpec == nil || nestedPipelineField(*pipelineTask) != "" {
+				continue
+			}
+			pipelineTask.TaskSpec = &v1.EmbeddedTask{TaskSpec: *taskSpec}
+		}
+	}
+
+	var subject any
+	if fn, ok := ctx.Value(policySubjectKey{}).(func(v1.PipelineSpec) any); ok {
+		subject = fn(resolved)
+	} else {
+		p.Spec = resolved
+		// Policies tell resources apart by their kind, which typed callers may leave out.
+		p.TypeMeta = metav1.TypeMeta{APIVersion: v1.SchemeGroupVersion.String(), Kind: "Pipeline"}
+		subject = p
+	}
+	return evaluatePolicies(ctx, subject)
+}
@@ -0,0 +1,242 @@
+package validator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+
+	"github.com/lcarva/tektor/internal/celrule"
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/plugin"
+	"github.com/lcarva/tektor/internal/policy"
+	"github.com/lcarva/tektor/internal/taskindex"
+)
+
+// bannedImagePolicy denies steps using images from docker.io, and warns about PipelineRuns in
+// another namespace than ci
+const bannedImagePolicy = `package tektor
+
+import rego.v1
+
+deny contains msg if {
+	some task in pipeline_tasks
+	some step in task.taskSpec.steps
+	startswith(step.image, "docker.io/")
+	msg := sprintf("%s PipelineTask uses the banned image %s", [task.name, step.image])
+}
+
+warn contains "PipelineRuns belong to the ci namespace" if {
+	input.kind == "PipelineRun"
+	input.metadata.namespace != "ci"
+}
+
+pipeline_tasks := input.spec.tasks if input.kind == "Pipeline"
+
+pipeline_tasks := input.spec.pipelineSpec.tasks if input.kind == "PipelineRun"
+`
+
+func TestValidateWithPolicies(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "images.rego"), []byte(bannedImagePolicy), 0o644))
+	engine, err := policy.Load(context.Background(), []string{dir})
+	require.NoError(t, err)
+
+	fake := TaskResolverFunc(func(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error) {
+		return &v1.TaskSpec{Steps: []v1.Step{{Name: "build", Image: "docker.io/library/golang:latest", Script: "go build"}}}, nil
+	})
+	ctx := WithOptions(context.Background(), Options{TaskResolver: fake, Policies: engine})
+
+	t.Run("pipeline with a resolved task", func(t *testing.T) {
+		p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+    - name: test
+      taskSpec:
+        steps:
+          - name: test
+            image: registry.access.redhat.com/ubi9/go-toolset:latest
+`)
+		require.NoError(t, err)
+
+		findings := Findings(ValidatePipeline(ctx, p))
+		require.Len(t, findings, 1)
+		assert.Equal(t, Finding{
+			Rule:     RulePolicy.ID,
+			Severity: SeverityError,
+			Message:  "build PipelineTask uses the banned image docker.io/library/golang:latest",
+		}, findings[0])
+	})
+
+	t.Run("pipelinerun with an embedded pipeline", func(t *testing.T) {
+		pr, err := pipelineRunFromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+  namespace: default
+spec:
+  pipelineSpec:
+    tasks:
+      - name: build
+        taskRef:
+          name: build
+      - name: nested
+        pipelineSpec:
+          tasks:
+            - name: build
+              taskRef:
+                name: build
+`)
+		require.NoError(t, err)
+
+		// The child Pipeline is part of the PipelineRun, which the policies are evaluated against once.
+		findings := Findings(ValidatePipelineRun(ctx, pr))
+		var policyFindings []Finding
+		for _, finding := range findings {
+			if finding.Rule == RulePolicy.ID {
+				policyFindings = append(policyFindings, finding)
+			}
+		}
+		assert.Equal(t, []Finding{
+			{Rule: RulePolicy.ID, Severity: SeverityError, Message: "build PipelineTask uses the banned image docker.io/library/golang:latest"},
+			{Rule: RulePolicy.ID, Severity: SeverityWarning, Message: "PipelineRuns belong to the ci namespace"},
+		}, policyFindings)
+	})
+
+	t.Run("pipelinerun with a pipeline reference", func(t *testing.T) {
+		index := taskindex.New()
+		index.Add(taskindex.Entry{
+			Kind: "Pipeline",
+			Name: "build",
+			PipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{{Name: "build", TaskRef: &v1.TaskRef{Name: "build"}}},
+			},
+		})
+		ctx := WithOptions(context.Background(), Options{TaskResolver: fake, TaskIndex: index, Policies: engine})
+		pr, err := pipelineRunFromYAML(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+  namespace: ci
+spec:
+  pipelineRef:
+    name: build
+`)
+		require.NoError(t, err)
+
+		// The policies are evaluated against the PipelineRun with the referenced Pipeline embedded.
+		findings := Findings(ValidatePipelineRun(ctx, pr))
+		require.Len(t, findings, 1)
+		assert.Equal(t, Finding{
+			Rule:     RulePolicy.ID,
+			Severity: SeverityError,
+			Message:  "build PipelineTask uses the banned image docker.io/library/golang:latest",
+		}, findings[0])
+	})
+
+	t.Run("no policies", func(t *testing.T) {
+		p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`)
+		require.NoError(t, err)
+		assert.NoError(t, ValidatePipeline(WithOptions(context.Background(), Options{TaskResolver: fake}), p))
+	})
+}
+
+func TestValidateWithCustomRules(t *testing.T) {
+	rules, err := celrule.Compile([]config.CustomRule{{
+		ID:         "task-timeouts",
+		Kinds:      []string{"Pipeline"},
+		Expression: "spec.tasks.all(t, has(t.timeout))",
+		Message:    "PipelineTasks of {{.metadata.name}} set a timeout",
+	}})
+	require.NoError(t, err)
+	ctx := WithOptions(context.Background(), Options{CustomRules: rules})
+
+	tests := []struct {
+		name     string
+		timeout  string
+		expected []Finding
+	}{
+		{
+			name: "missing timeout",
+			expected: []Finding{
+				{Rule: "task-timeouts", Severity: SeverityError, Message: "PipelineTasks of build set a timeout"},
+			},
+		},
+		{
+			name:    "timeout",
+			timeout: "\n      timeout: 1h",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build` + tt.timeout + `
+      taskSpec:
+        steps:
+          - name: build
+            image: alpine:latest
+`)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, Findings(ValidatePipeline(ctx, p)))
+		})
+	}
+}
+
+func TestValidateWithPlugins(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, plugin.Prefix+"org")
+	require.NoError(t, os.WriteFile(path, []byte(`#!/bin/sh
+cat >/dev/null
+echo '[{"rule": "ORG001", "message": "build is not prefixed with team-", "resourcePath": "metadata.name"}, {"severity": "warning", "message": "no owner label"}]'
+`), 0o755))
+	ctx := WithOptions(context.Background(), Options{Plugins: []plugin.Plugin{{Name: "org", Path: path}}})
+
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build
+      taskSpec:
+        steps:
+          - name: build
+            image: alpine:latest
+`)
+	require.NoError(t, err)
+	assert.Equal(t, []Finding{
+		{Rule: "ORG001", Severity: SeverityError, Message: "build is not prefixed with team-", ResourcePath: "metadata.name"},
+		{Rule: plugin.Prefix + "org", Severity: SeverityWarning, Message: "no owner label"},
+	}, Findings(ValidatePipeline(ctx, p)))
+}
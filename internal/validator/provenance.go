@@ -0,0 +1,187 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+
+	"github.com/lcarva/tektor/internal/logging"
+)
+
+// Provenance tells where the spec of a PipelineTask comes from, along with the interface it
+// resolves to
+type Provenance struct {
+	// Name is the name of the PipelineTask, and Section the list of the pipeline spec holding it,
+	// tasks or finally.
+	Name    string
+	Section string
+	// Source describes where the spec comes from, e.g. "embedded" or "bundle
+	// quay.io/example/task-build@sha256:...".
+	Source string
+	// Unpinned describes how a remote reference is not pinned to an immutable version, if it is not.
+	Unpinned string
+	// Resolved is the commit of a git reference, or the image digest of a bundle reference, the spec
+	// was resolved from. It is empty for other sources, or if it could not be retrieved.
+	Resolved string
+	// Spec holds the params, results, and workspaces of the resolved Task, or of the child Pipeline.
+	// It is nil if the resolution failed, as told by Err.
+	Spec *v1.TaskSpec
+	Err  error
+}
+
+// PipelineTaskProvenances resolves the PipelineTasks of a pipeline spec like during validation, and
+// returns where their specs come from. Runtime parameter values are substituted in the params of
+// remote references.
+func PipelineTaskProvenances(ctx context.Context, pipelineSpec v1.PipelineSpec, runtimeParams map[string]string) []Provenance {
+	var provenances []Provenance
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		for _, pipelineTask := range section.pipelineTasks {
+			provenance := Provenance{Name: pipelineTask.Name, Section: section.name}
+			provenance.Source, provenance.Unpinned = pipelineTaskSource(ctx, pipelineTask, pipelineSpec.Params, runtimeParams)
+			if field := nestedPipelineField(pipelineTask); field != "" {
+				childSpec, err := pipelineSpecFromPipelineTask(ctx, pipelineTask, pipelineSpec.Params, runtimeParams)
+				if err == nil {
+					provenance.Spec = pipelineBoundarySpec(*childSpec)
+				}
+				provenance.Err = err
+			} else if pipelineTask.TaskRef != nil && pipelineTask.TaskRef.APIVersion != "" {
+				provenance.Err = errors.New("custom Tasks are not supported")
+			} else {
+				provenance.Spec, provenance.Err = taskSpecFromPipelineTaskWithParams(ctx, pipelineTask, pipelineSpec.Params, runtimeParams)
+			}
+			if provenance.Err == nil {
+				provenance.Resolved = resolvedRevision(ctx, pipelineTask, pipelineSpec.Params, runtimeParams)
+			}
+			provenances = append(provenances, provenance)
+		}
+	}
+	return provenances
+}
+
+// pipelineTaskSource describes where the spec of a PipelineTask comes from and, for remote
+// references, how they are not pinned, if they are not
+func pipelineTaskSource(ctx context.Context, pipelineTask v1.PipelineTask, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (string, string) {
+	kind := "Task"
+	var ref *v1.ResolverRef
+	switch {
+	case pipelineTask.TaskSpec != nil:
+		return "embedded", ""
+	case pipelineTask.PipelineSpec != nil:
+		return "embedded Pipeline", ""
+	case pipelineTask.TaskRef != nil && pipelineTask.TaskRef.APIVersion != "":
+		return fmt.Sprintf("Custom Task %s %s", pipelineTask.TaskRef.APIVersion, pipelineTask.TaskRef.Kind), ""
+	case pipelineTask.TaskRef != nil && pipelineTask.TaskRef.Resolver == "":
+		if pipelineTask.TaskRef.Kind == v1.ClusterTaskRefKind {
+			kind = string(v1.ClusterTaskRefKind)
+		}
+		return localSource(ctx, kind, pipelineTask.TaskRef.Name), ""
+	case pipelineTask.TaskRef != nil:
+		ref = &pipelineTask.TaskRef.ResolverRef
+	case pipelineTask.PipelineRef != nil && pipelineTask.PipelineRef.Resolver == "":
+		return localSource(ctx, "Pipeline", pipelineTask.PipelineRef.Name), ""
+	case pipelineTask.PipelineRef != nil:
+		kind = "Pipeline"
+		ref = &pipelineTask.PipelineRef.ResolverRef
+	default:
+		return "", ""
+	}
+
+	params := substituteParametersInParams(ref.Params, pipelineParams, runtimeParams)
+	resolved := v1.ResolverRef{Resolver: ref.Resolver, Params: params}
+	unpinned := ""
+	if err := validatePinnedRef(resolved); err != nil {
+		unpinned = err.Error()
+	}
+	var source string
+	switch ref.Resolver {
+	case "bundles":
+		source = fmt.Sprintf("bundle %s, %s %s", getParamValue(params, "bundle"), kind, getParamValue(params, "name"))
+	case "git":
+		revision := getParamValue(params, "revision")
+		if revision == "" {
+			revision = "default branch"
+		}
+		source = fmt.Sprintf("git %s@%s, %s", getParamValue(params, "url"), revision, getParamValue(params, "pathInRepo"))
+	case "hub":
+		source = fmt.Sprintf("hub %s %s", getParamValue(params, "name"), getParamValue(params, "version"))
+	default:
+		var described []string
+		for _, param := range params {
+			described = append(described, fmt.Sprintf("%s=%s", param.Name, param.Value.StringVal))
+		}
+		source = fmt.Sprintf("%s resolver %s", ref.Resolver, strings.Join(described, " "))
+	}
+	return strings.TrimSpace(source), unpinned
+}
+
+// resolvedRevision returns the commit a git reference of a PipelineTask resolves to, or the image
+// digest a bundle reference resolves to, looking up the branches, tags, and default branches of git
+// repositories and the tags of bundles. It returns an empty string for other references, or if the
+// lookup fails.
+func resolvedRevision(ctx context.Context, pipelineTask v1.PipelineTask, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) string {
+	var ref v1.ResolverRef
+	switch {
+	case pipelineTask.TaskRef != nil:
+		ref = pipelineTask.TaskRef.ResolverRef
+	case pipelineTask.PipelineRef != nil:
+		ref = pipelineTask.PipelineRef.ResolverRef
+	}
+	params := substituteParametersInParams(ref.Params, pipelineParams, runtimeParams)
+
+	var lookup func(context.Context) ([]byte, error)
+	switch ref.Resolver {
+	case "bundles":
+		bundleRef := getParamValue(params, "bundle")
+		if _, digest, ok := localBundle(bundleRef); ok {
+			return digest
+		}
+		imageRef, err := name.ParseReference(bundleRef)
+		if err != nil {
+			return ""
+		}
+		if digestRef, ok := imageRef.(name.Digest); ok {
+			return digestRef.DigestStr()
+		}
+		lookup = func(ctx context.Context) ([]byte, error) {
+			digest, err := fetchBundleDigest(ctx, imageRef)
+			return []byte(digest), err
+		}
+	case "git":
+		repoURL, revision := getParamValue(params, "url"), getParamValue(params, "revision")
+		if gitCommitRegex.MatchString(revision) {
+			return revision
+		}
+		lookup = func(ctx context.Context) ([]byte, error) {
+			auth, err := repoGitAuth(ctx, repoURL)
+			if err != nil {
+				return nil, err
+			}
+			commit, err := fetchGitCommit(ctx, repoURL, revision, auth)
+			return []byte(commit), err
+		}
+	default:
+		return ""
+	}
+
+	resolved, err := resolveWithRetries(ctx, lookup)
+	if err != nil {
+		logging.FromContext(ctx).Debugf("Looking up the revision the %s pipeline task resolved to: %v", pipelineTask.Name, err)
+		return ""
+	}
+	return string(resolved)
+}
+
+// localSource describes a Task or Pipeline referred to by name, with the file defining it if it is
+// part of the TaskIndex of the validation options
+func localSource(ctx context.Context, kind, name string) string {
+	if index := optionsFromContext(ctx).TaskIndex; index != nil {
+		if entry, err := index.Lookup(kind, name); err == nil {
+			return entry.Source
+		}
+	}
+	return fmt.Sprintf("%s %s from the cluster", kind, name)
+}
@@ -0,0 +1,123 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+
+	"github.com/lcarva/tektor/internal/taskindex"
+)
+
+func TestPipelineTaskProvenances(t *testing.T) {
+	build := v1.TaskSpec{
+		Params:  v1.ParamSpecs{{Name: "image", Type: v1.ParamTypeString}},
+		Results: []v1.TaskResult{{Name: "digest"}},
+	}
+	fake := TaskResolverFunc(func(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error) {
+		if getParamValue(ref.Params, "name") == "build" || getParamValue(ref.Params, "pathInRepo") == "task/build.yaml" || ref.Name == "build" {
+			return &build, nil
+		}
+		return nil, fmt.Errorf("task %q not found", ref.Name)
+	})
+	originalDigest, originalCommit := fetchBundleDigest, fetchGitCommit
+	fetchBundleDigest = func(_ context.Context, ref name.Reference) (string, error) {
+		return "sha256:" + strings.Repeat("a", 64), nil
+	}
+	fetchGitCommit = func(_ context.Context, repoURL, revision string, _ transport.AuthMethod) (string, error) {
+		if revision == "main" {
+			return strings.Repeat("b", 40), nil
+		}
+		return "", fmt.Errorf("no branch or tag %s", revision)
+	}
+	t.Cleanup(func() {
+		fetchBundleDigest, fetchGitCommit = originalDigest, originalCommit
+	})
+	index := taskindex.New()
+	index.Add(taskindex.Entry{Kind: "Task", Name: "build", Source: "tasks/build.yaml:1 (Task build)", Spec: build})
+	ctx := WithOptions(context.Background(), Options{TaskResolver: fake, TaskIndex: index})
+
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  params:
+    - name: version
+      type: string
+  tasks:
+    - name: embedded
+      taskSpec:
+        steps:
+          - image: alpine:latest
+    - name: local
+      taskRef:
+        name: build
+    - name: bundle
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: quay.io/example/task-build:$(params.version)
+          - name: name
+            value: build
+          - name: kind
+            value: task
+    - name: git
+      taskRef:
+        resolver: git
+        params:
+          - name: url
+            value: https://github.com/example/tasks.git
+          - name: revision
+            value: 0123456789abcdef0123456789abcdef01234567
+          - name: pathInRepo
+            value: task/deploy.yaml
+    - name: branch
+      taskRef:
+        resolver: git
+        params:
+          - name: url
+            value: https://github.com/example/tasks.git
+          - name: revision
+            value: main
+          - name: pathInRepo
+            value: task/build.yaml
+  finally:
+    - name: missing
+      taskRef:
+        name: notify
+`)
+	require.NoError(t, err)
+
+	provenances := PipelineTaskProvenances(ctx, p.Spec, map[string]string{"version": "0.1"})
+	require.Len(t, provenances, 6)
+	assert.Equal(t, Provenance{Name: "embedded", Section: "tasks", Source: "embedded", Spec: &p.Spec.Tasks[0].TaskSpec.TaskSpec}, provenances[0])
+	assert.Equal(t, Provenance{Name: "local", Section: "tasks", Source: "tasks/build.yaml:1 (Task build)", Spec: &build}, provenances[1])
+	assert.Equal(t, Provenance{
+		Name:     "bundle",
+		Section:  "tasks",
+		Source:   "bundle quay.io/example/task-build:0.1, Task build",
+		Unpinned: "refers to bundle quay.io/example/task-build:0.1 by tag, pin it by digest",
+		Resolved: "sha256:" + strings.Repeat("a", 64),
+		Spec:     &build,
+	}, provenances[2])
+	assert.Equal(t, "git https://github.com/example/tasks.git@0123456789abcdef0123456789abcdef01234567, task/deploy.yaml", provenances[3].Source)
+	assert.Empty(t, provenances[3].Unpinned)
+	// The Task is not resolved by the fake, so neither is its revision.
+	assert.Empty(t, provenances[3].Resolved)
+	assert.Equal(t, "git https://github.com/example/tasks.git@main, task/build.yaml", provenances[4].Source)
+	assert.Equal(t, strings.Repeat("b", 40), provenances[4].Resolved)
+	assert.Equal(t, "finally", provenances[5].Section)
+	assert.Equal(t, "Task notify from the cluster", provenances[5].Source)
+	assert.Nil(t, provenances[5].Spec)
+	assert.Empty(t, provenances[5].Resolved)
+	assert.EqualError(t, provenances[5].Err, `task "notify" not found`)
+}
@@ -0,0 +1,36 @@
+package validator
+
+import (
+	"context"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+// TaskResolver retrieves the spec of the Task a PipelineTask refers to with its taskRef. The params
+// of the Pipeline and the runtime parameter values are given to substitute the references to them
+// in the params of remote resolvers. Swapping the TaskResolver, e.g. for an in-memory fake, validates
+// Pipelines without reaching git repositories, registries, or hubs.
+type TaskResolver interface {
+	ResolveTask(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error)
+}
+
+// TaskResolverFunc adapts a function to a TaskResolver
+type TaskResolverFunc func(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error)
+
+// ResolveTask implements TaskResolver
+func (f TaskResolverFunc) ResolveTask(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error) {
+	return f(ctx, ref, pipelineParams, runtimeParams)
+}
+
+// DefaultTaskResolver resolves Tasks through the bundles, git, and hub resolvers, or by name from the
+// TaskIndex of the validation options
+var DefaultTaskResolver TaskResolver = TaskResolverFunc(resolveTaskRef)
+
+// taskResolverFromContext returns the TaskResolver of the validation options carried by ctx, or the
+// DefaultTaskResolver
+func taskResolverFromContext(ctx context.Context) TaskResolver {
+	if resolver := optionsFromContext(ctx).TaskResolver; resolver != nil {
+		return resolver
+	}
+	return DefaultTaskResolver
+}
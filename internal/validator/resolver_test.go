@@ -0,0 +1,236 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+func TestValidatePipelineWithTaskResolver(t *testing.T) {
+	tasks := map[string]v1.TaskSpec{
+		"git-clone": {
+			Params:     v1.ParamSpecs{{Name: "url", Type: v1.ParamTypeString}},
+			Workspaces: []v1.WorkspaceDeclaration{{Name: "output"}},
+			Results:    []v1.TaskResult{{Name: "commit"}},
+			Steps:      []v1.Step{{Name: "clone", Image: "alpine:latest", Script: "git clone $(params.url) $(workspaces.output.path)"}},
+		},
+		"lint": {
+			Workspaces: []v1.WorkspaceDeclaration{{Name: "source"}},
+			Steps:      []v1.Step{{Name: "lint", Image: "alpine:latest", Script: "cd $(workspaces.source.path) && lint $(workspaces.config.path)"}},
+		},
+		"push": {
+			Results: []v1.TaskResult{{Name: "IMAGE_DIGEST"}},
+			Steps:   []v1.Step{{Name: "push", Image: "alpine:latest", Script: "push --digest-file $(results.IMAGE_DIGST.path)"}},
+		},
+	}
+	var resolved []v1.TaskRef
+	// The fake resolves Tasks from memory regardless of their resolver, so nothing is fetched.
+	fake := TaskResolverFunc(func(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error) {
+		resolved = append(resolved, ref)
+		name := ref.Name
+		for _, param := range ref.Params {
+			if param.Name == "name" {
+				name = param.Value.StringVal
+			}
+		}
+		if spec, ok := tasks[name]; ok {
+			return &spec, nil
+		}
+		return nil, fmt.Errorf("task %q not found", name)
+	})
+	ctx := WithOptions(context.Background(), Options{TaskResolver: fake})
+
+	tests := []struct {
+		name           string
+		pipelineYAML   string
+		expectedErrors []string
+		expectNoError  bool
+		// expectedResolved is how many Tasks the fake resolves
+		expectedResolved int
+	}{
+		{
+			name: "task from a bundle",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  workspaces:
+    - name: source
+  results:
+    - name: commit
+      value: $(tasks.clone.results.commit)
+  tasks:
+    - name: clone
+      params:
+        - name: url
+          value: https://github.com/example/repo.git
+      workspaces:
+        - name: output
+          workspace: source
+      taskRef:
+        resolver: bundles
+        params:
+          - name: bundle
+            value: registry.invalid/tasks/git-clone:latest
+          - name: name
+            value: git-clone
+          - name: kind
+            value: task
+`,
+			expectNoError:    true,
+			expectedResolved: 1,
+		},
+		{
+			name: "task referenced by name",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        name: git-clone
+`,
+			expectedErrors:   []string{`"url" parameter is required`, "output"},
+			expectedResolved: 1,
+		},
+		{
+			name: "unknown task",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: test
+      taskRef:
+        name: test
+`,
+			expectedErrors:   []string{`retrieving task spec from test pipeline task: task "test" not found`},
+			expectedResolved: 1,
+		},
+		{
+			name: "task referring to an undeclared workspace",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  workspaces:
+    - name: source
+  tasks:
+    - name: lint
+      workspaces:
+        - name: source
+          workspace: source
+      taskRef:
+        name: lint
+`,
+			expectedErrors:   []string{"lint PipelineTask: workspace validation", "workspace reference $(workspaces.config.path) is not declared by the Task: spec.steps[0].script"},
+			expectedResolved: 1,
+		},
+		{
+			name: "task writing an undeclared result",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: push
+      taskRef:
+        name: push
+`,
+			expectedErrors:   []string{"push PipelineTask", `non-existent result in "$(results.IMAGE_DIGST.path)": spec.steps[0].script`},
+			expectedResolved: 1,
+		},
+		{
+			name: "embedded task",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: test
+      taskSpec:
+        steps:
+          - name: test
+            image: alpine:latest
+`,
+			expectNoError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved = nil
+			p, err := pipelineFromYAML(tt.pipelineYAML)
+			require.NoError(t, err)
+
+			err = ValidatePipeline(ctx, p)
+			assert.Len(t, resolved, tt.expectedResolved)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestDefaultTaskResolver(t *testing.T) {
+	_, err := DefaultTaskResolver.ResolveTask(context.Background(), v1.TaskRef{Name: "git-clone"}, nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unable to retrieve spec for pipeline task")
+}
+
+func TestValidatePipelineProgress(t *testing.T) {
+	fake := TaskResolverFunc(func(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error) {
+		return &v1.TaskSpec{Steps: []v1.Step{{Name: "run", Image: "alpine:latest"}}}, nil
+	})
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: git-clone
+          - name: version
+            value: "0.9"
+    - name: build
+      taskSpec:
+        steps:
+          - name: build
+            image: alpine:latest
+`)
+	require.NoError(t, err)
+
+	var progress bytes.Buffer
+	ctx := WithProgress(WithOptions(context.Background(), Options{TaskResolver: fake}), &progress)
+	require.NoError(t, ValidatePipeline(ctx, p))
+	assert.Equal(t, "\r\033[K⏳ Resolving 0/1: hub git-clone 0.9 (0s)\r\033[K⏳ Resolving 1/1 (0s)\r\033[K", progress.String())
+}
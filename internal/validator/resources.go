@@ -0,0 +1,260 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ResourceOverrideAnnotationPrefix prefixes the PipelineRun annotations which override the compute
+// resources of a step, e.g.
+//
+//	build.appstudio.openshift.io/compute-resources.build-container.build: requests.memory=4Gi,limits.memory=8Gi
+const ResourceOverrideAnnotationPrefix = "build.appstudio.openshift.io/compute-resources."
+
+// resourceOverride is the compute resources override of a step parsed from a PipelineRun annotation
+type resourceOverride struct {
+	Annotation   string
+	PipelineTask string
+	Step         string
+	Resources    corev1.ResourceRequirements
+}
+
+// overridableResources lists the resources a resourceOverride may set
+var overridableResources = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage}
+
+type resourceOverridesKey struct{}
+
+// withResourceOverrides returns a copy of ctx carrying the given resource overrides
+func withResourceOverrides(ctx context.Context, overrides []resourceOverride) context.Context {
+	return context.WithValue(ctx, resourceOverridesKey{}, overrides)
+}
+
+// resourceOverridesFromContext returns the resource overrides carried by ctx, if any
+func resourceOverridesFromContext(ctx context.Context) []resourceOverride {
+	overrides, _ := ctx.Value(resourceOverridesKey{}).([]resourceOverride)
+	return overrides
+}
+
+// parseResourceOverrides parses the resource override annotations of a PipelineRun, sorted by
+// annotation. Other annotations are ignored.
+func parseResourceOverrides(annotations map[string]string) ([]resourceOverride, error) {
+	var err error
+	var overrides []resourceOverride
+
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		if strings.HasPrefix(key, ResourceOverrideAnnotationPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		pipelineTask, step, found := strings.Cut(strings.TrimPrefix(key, ResourceOverrideAnnotationPrefix), ".")
+		if !found || pipelineTask == "" || step == "" {
+			err = multierror.Append(err, fmt.Errorf(
+				"annotation %s must be of the form %s<pipelineTask>.<step>", key, ResourceOverrideAnnotationPrefix))
+			continue
+		}
+
+		override := resourceOverride{
+			Annotation:   key,
+			PipelineTask: pipelineTask,
+			Step:         step,
+			Resources:    corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}},
+		}
+		valid := true
+		for _, entry := range strings.Split(annotations[key], ",") {
+			field, value, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			if parseErr := override.set(field, value); parseErr != nil {
+				err = multierror.Append(err, fmt.Errorf("annotation %s: %w", key, parseErr))
+				valid = false
+			}
+		}
+		if valid {
+			overrides = append(overrides, override)
+		}
+	}
+
+	return overrides, err
+}
+
+// set records the quantity of a field such as "requests.cpu"
+func (o *resourceOverride) set(field, value string) error {
+	kind, name, _ := strings.Cut(field, ".")
+	var list corev1.ResourceList
+	switch kind {
+	case "requests":
+		list = o.Resources.Requests
+	case "limits":
+		list = o.Resources.Limits
+	default:
+		return fmt.Errorf("%q is not one of requests.<resource> or limits.<resource>", field)
+	}
+
+	resourceName := corev1.ResourceName(name)
+	known := false
+	for _, overridable := range overridableResources {
+		known = known || overridable == resourceName
+	}
+	if !known {
+		return fmt.Errorf("%q does not refer to one of the resources %s", field, joinResourceNames(overridableResources))
+	}
+
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return fmt.Errorf("%s value %q is not a valid quantity", field, value)
+	}
+	list[resourceName] = quantity
+	return nil
+}
+
+// ValidateResourceOverrides verifies that resource overrides refer to existing steps of existing
+// PipelineTasks, and that the resources of the steps remain consistent once overridden, i.e. no
+// request exceeds its limit
+func ValidateResourceOverrides(overrides []resourceOverride, pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec) error {
+	var err error
+
+	pipelineTasks := make(map[string]bool)
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		pipelineTasks[pipelineTask.Name] = true
+	}
+
+	for _, override := range overrides {
+		if !pipelineTasks[override.PipelineTask] {
+			err = multierror.Append(err, fmt.Errorf(
+				"annotation %s: PipelineTask %q does not exist", override.Annotation, override.PipelineTask))
+			continue
+		}
+		taskSpec, exists := allTaskSpecs[override.PipelineTask]
+		if !exists {
+			// The steps of PipelineTasks whose Task could not be resolved, or which run custom Tasks
+			// or child Pipelines, are unknown.
+			continue
+		}
+
+		var step *v1.Step
+		for i := range taskSpec.Steps {
+			if taskSpec.Steps[i].Name == override.Step {
+				step = &taskSpec.Steps[i]
+				break
+			}
+		}
+		if step == nil {
+			err = multierror.Append(err, fmt.Errorf(
+				"annotation %s: PipelineTask %q has no step %q", override.Annotation, override.PipelineTask, override.Step))
+			continue
+		}
+
+		effective := effectiveResources(step.ComputeResources, override.Resources)
+		for _, name := range requestsExceedingLimits(effective) {
+			request, limit := effective.Requests[name], effective.Limits[name]
+			err = multierror.Append(err, fmt.Errorf(
+				"annotation %s: %s request %s of step %q exceeds its limit %s",
+				override.Annotation, name, request.String(), override.Step, limit.String()))
+		}
+	}
+
+	return err
+}
+
+// StepResources are the compute resources of a step of a PipelineTask, merged with the stepTemplate
+// of its Task, once the resource override annotations of the PipelineRun are applied
+type StepResources struct {
+	PipelineTask string
+	Step         string
+	Resources    corev1.ResourceRequirements
+	// Overridden is the annotation overriding the resources of the step, if any.
+	Overridden string
+}
+
+// ResourceReport returns the compute resources of the steps of the PipelineTasks of a pipeline spec
+// whose Task is known, in order, with the resource override annotations of a PipelineRun applied,
+// along with their total. Invalid annotations are left out, ValidateResourceOverrides reports them.
+func ResourceReport(annotations map[string]string, pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec) ([]StepResources, corev1.ResourceRequirements) {
+	overrides, _ := parseResourceOverrides(annotations)
+	total := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+	var steps []StepResources
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		taskSpec := allTaskSpecs[pipelineTask.Name]
+		if taskSpec == nil {
+			continue
+		}
+		var template corev1.ResourceRequirements
+		if taskSpec.StepTemplate != nil {
+			template = taskSpec.StepTemplate.ComputeResources
+		}
+		for _, step := range taskSpec.Steps {
+			report := StepResources{
+				PipelineTask: pipelineTask.Name,
+				Step:         step.Name,
+				Resources:    effectiveResources(template, step.ComputeResources),
+			}
+			for _, override := range overrides {
+				if override.PipelineTask == pipelineTask.Name && override.Step == step.Name {
+					report.Resources = effectiveResources(report.Resources, override.Resources)
+					report.Overridden = override.Annotation
+				}
+			}
+			addResources(total.Requests, report.Resources.Requests)
+			addResources(total.Limits, report.Resources.Limits)
+			steps = append(steps, report)
+		}
+	}
+	return steps, total
+}
+
+// addResources adds the quantities of a resource list to those of total
+func addResources(total, list corev1.ResourceList) {
+	for name, quantity := range list {
+		sum := total[name]
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
+
+// effectiveResources returns the compute resources of a step once the override is applied
+func effectiveResources(step corev1.ResourceRequirements, override corev1.ResourceRequirements) corev1.ResourceRequirements {
+	effective := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+	for name, quantity := range step.Requests {
+		effective.Requests[name] = quantity
+	}
+	for name, quantity := range step.Limits {
+		effective.Limits[name] = quantity
+	}
+	for name, quantity := range override.Requests {
+		effective.Requests[name] = quantity
+	}
+	for name, quantity := range override.Limits {
+		effective.Limits[name] = quantity
+	}
+	return effective
+}
+
+// requestsExceedingLimits returns the names of the resources whose request exceeds their limit,
+// sorted by name
+func requestsExceedingLimits(resources corev1.ResourceRequirements) []corev1.ResourceName {
+	var names []corev1.ResourceName
+	for name, request := range resources.Requests {
+		if limit, hasLimit := resources.Limits[name]; hasLimit && request.Cmp(limit) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func joinResourceNames(names []corev1.ResourceName) string {
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, string(name))
+	}
+	return strings.Join(parts, ", ")
+}
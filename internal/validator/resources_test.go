@@ -0,0 +1,255 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseResourceOverrides(t *testing.T) {
+	tests := []struct {
+		name           string
+		annotations    map[string]string
+		expected       []resourceOverride
+		expectedErrors []string
+	}{
+		{
+			name: "valid override",
+			annotations: map[string]string{
+				ResourceOverrideAnnotationPrefix + "build.buildah": "requests.memory=4Gi, limits.memory=8Gi,requests.cpu=500m",
+				"pipelinesascode.tekton.dev/on-event":              "[push]",
+			},
+			expected: []resourceOverride{
+				{
+					Annotation:   ResourceOverrideAnnotationPrefix + "build.buildah",
+					PipelineTask: "build",
+					Step:         "buildah",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("4Gi"),
+							corev1.ResourceCPU:    resource.MustParse("500m"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("8Gi"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "missing step",
+			annotations: map[string]string{
+				ResourceOverrideAnnotationPrefix + "build": "requests.memory=4Gi",
+			},
+			expectedErrors: []string{
+				"annotation build.appstudio.openshift.io/compute-resources.build must be of the form build.appstudio.openshift.io/compute-resources.<pipelineTask>.<step>",
+			},
+		},
+		{
+			name: "invalid fields and quantities",
+			annotations: map[string]string{
+				ResourceOverrideAnnotationPrefix + "build.buildah": "requests.memory=lots,limits.gpu=1,memory=1Gi",
+			},
+			expectedErrors: []string{
+				`requests.memory value "lots" is not a valid quantity`,
+				`"limits.gpu" does not refer to one of the resources cpu, memory, ephemeral-storage`,
+				`"memory" is not one of requests.<resource> or limits.<resource>`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overrides, err := parseResourceOverrides(tt.annotations)
+
+			if len(tt.expectedErrors) == 0 {
+				require.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				require.Len(t, overrides, len(tt.expected))
+				for i, expected := range tt.expected {
+					assert.Equal(t, expected.Annotation, overrides[i].Annotation)
+					assert.Equal(t, expected.PipelineTask, overrides[i].PipelineTask)
+					assert.Equal(t, expected.Step, overrides[i].Step)
+					assert.True(t, equalResourceLists(expected.Resources.Requests, overrides[i].Resources.Requests))
+					assert.True(t, equalResourceLists(expected.Resources.Limits, overrides[i].Resources.Limits))
+				}
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			assert.Empty(t, overrides)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidateResourceOverrides(t *testing.T) {
+	pipelineSpec := v1.PipelineSpec{
+		Tasks: []v1.PipelineTask{{Name: "build"}},
+	}
+	allTaskSpecs := map[string]*v1.TaskSpec{
+		"build": {
+			Steps: []v1.Step{
+				{
+					Name: "buildah",
+					ComputeResources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+						Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+					},
+				},
+			},
+		},
+	}
+	override := func(pipelineTask, step string, requests, limits corev1.ResourceList) resourceOverride {
+		return resourceOverride{
+			Annotation:   ResourceOverrideAnnotationPrefix + pipelineTask + "." + step,
+			PipelineTask: pipelineTask,
+			Step:         step,
+			Resources:    corev1.ResourceRequirements{Requests: requests, Limits: limits},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		overrides      []resourceOverride
+		expectedErrors []string
+	}{
+		{
+			name: "override within the step limits",
+			overrides: []resourceOverride{
+				override("build", "buildah", corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("3Gi")}, nil),
+			},
+		},
+		{
+			name: "override raising both request and limit",
+			overrides: []resourceOverride{
+				override("build", "buildah",
+					corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")},
+					corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")}),
+			},
+		},
+		{
+			name: "override request exceeding the step limit",
+			overrides: []resourceOverride{
+				override("build", "buildah", corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("6Gi")}, nil),
+			},
+			expectedErrors: []string{
+				`annotation build.appstudio.openshift.io/compute-resources.build.buildah: memory request 6Gi of step "buildah" exceeds its limit 4Gi`,
+			},
+		},
+		{
+			name: "unknown PipelineTask and step",
+			overrides: []resourceOverride{
+				override("test", "run", nil, nil),
+				override("build", "push", nil, nil),
+			},
+			expectedErrors: []string{
+				`annotation build.appstudio.openshift.io/compute-resources.test.run: PipelineTask "test" does not exist`,
+				`annotation build.appstudio.openshift.io/compute-resources.build.push: PipelineTask "build" has no step "push"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateResourceOverrides(tt.overrides, pipelineSpec, allTaskSpecs)
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidatePipelineRunResourceOverrides(t *testing.T) {
+	pr := v1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "overrides",
+			Annotations: map[string]string{
+				ResourceOverrideAnnotationPrefix + "build.buildah": "limits.memory=1Gi",
+				ResourceOverrideAnnotationPrefix + "build.missing": "limits.memory=1Gi",
+			},
+		},
+		Spec: v1.PipelineRunSpec{
+			PipelineSpec: &v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{
+					{
+						Name: "build",
+						TaskSpec: &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{
+							Steps: []v1.Step{{Name: "buildah", Image: "quay.io/buildah/stable:latest", Script: "buildah bud ."}},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	err := ValidatePipelineRun(context.Background(), pr)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `resource overrides: 1 error occurred`)
+	assert.Contains(t, err.Error(), `PipelineTask "build" has no step "missing"`)
+}
+
+func equalResourceLists(expected, actual corev1.ResourceList) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for name, quantity := range expected {
+		if other, found := actual[name]; !found || quantity.Cmp(other) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestResourceReport(t *testing.T) {
+	pipelineSpec := v1.PipelineSpec{
+		Tasks: []v1.PipelineTask{
+			{Name: "build", TaskRef: &v1.TaskRef{Name: "build"}},
+			{Name: "deploy", TaskRef: &v1.TaskRef{Name: "deploy"}},
+		},
+		Finally: []v1.PipelineTask{{Name: "notify", TaskRef: &v1.TaskRef{Name: "notify"}}},
+	}
+	allTaskSpecs := map[string]*v1.TaskSpec{
+		"build": {Steps: []v1.Step{{
+			Name: "build",
+			ComputeResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		}}},
+		"notify": {
+			StepTemplate: &v1.StepTemplate{ComputeResources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+			}},
+			Steps: []v1.Step{{Name: "notify"}},
+		},
+	}
+	annotations := map[string]string{
+		ResourceOverrideAnnotationPrefix + "build.build": "requests.memory=2Gi,limits.memory=4Gi",
+		ResourceOverrideAnnotationPrefix + "build.push":  "limits.cpu=many",
+	}
+
+	steps, total := ResourceReport(annotations, pipelineSpec, allTaskSpecs)
+	require.Len(t, steps, 2)
+	assert.Equal(t, "build", steps[0].PipelineTask)
+	assert.Equal(t, ResourceOverrideAnnotationPrefix+"build.build", steps[0].Overridden)
+	assert.True(t, equalResourceLists(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}, steps[0].Resources.Requests))
+	assert.Equal(t, "notify", steps[1].PipelineTask)
+	assert.Empty(t, steps[1].Overridden)
+	assert.True(t, equalResourceLists(corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("2Gi"),
+	}, total.Requests))
+	assert.True(t, equalResourceLists(corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}, total.Limits))
+}
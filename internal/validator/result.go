@@ -7,6 +7,7 @@ import (
 
 	"github.com/hashicorp/go-multierror"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
 )
 
 // resultUsageContext represents the context where a result is being used
@@ -209,3 +210,149 @@ func determineExpectedTypeFromUsage(fullUsage, suffix string) string {
 	// Default to string for simple usage
 	return "string"
 }
+
+// stepResultRefRegex matches step result references, e.g. $(steps.build.results.digest), along
+// with the index or property they access, if any
+var stepResultRefRegex = regexp.MustCompile(`\$\(steps\.([^.)\s]+)\.results\.([^.)\[\s]+)(\[[^\]]*\]|\.[^)\s]+)?\)`)
+
+// ValidateStepResultReferences verifies the step result references of the steps of a Task. They
+// must refer to a result declared by an earlier step, and be used according to its type. Sidecars
+// run alongside the steps, so they cannot use step results.
+func ValidateStepResultReferences(taskSpec v1.TaskSpec) error {
+	var err error
+
+	stepIndexes := make(map[string]int)
+	for i, step := range taskSpec.Steps {
+		stepIndexes[step.Name] = i
+	}
+
+	for i, step := range taskSpec.Steps {
+		fields := containerFields(fmt.Sprintf("spec.steps[%d]", i),
+			step.Image, step.Command, step.Args, step.Env, step.Script, step.WorkingDir)
+		for _, field := range fields {
+			for _, match := range stepResultRefRegex.FindAllStringSubmatch(field.value, -1) {
+				usage, stepName, resultName, access := match[0], match[1], match[2], match[3]
+
+				producer, found := stepIndexes[stepName]
+				if !found {
+					err = multierror.Append(err, withPath(field.path, fmt.Errorf("non-existent step %q in %q", stepName, usage)))
+					continue
+				}
+				if producer >= i {
+					err = multierror.Append(err, withPath(field.path, fmt.Errorf(
+						"step %q does not run before step %q, so its results cannot be used in %q",
+						stepName, step.Name, usage)))
+					continue
+				}
+
+				var result *v1.StepResult
+				for j := range taskSpec.Steps[producer].Results {
+					if taskSpec.Steps[producer].Results[j].Name == resultName {
+						result = &taskSpec.Steps[producer].Results[j]
+						break
+					}
+				}
+				if result == nil {
+					err = multierror.Append(err, withPath(field.path, fmt.Errorf(
+						"non-existent %s result from step %q in %q", resultName, stepName, usage)))
+					continue
+				}
+
+				if typeErr := validateStepResultAccess(*result, access); typeErr != nil {
+					err = multierror.Append(err, withPath(field.path, fmt.Errorf(
+						"result type mismatch: %s result from step %q %s in %q", resultName, stepName, typeErr, usage)))
+				}
+			}
+		}
+	}
+
+	for i, sidecar := range taskSpec.Sidecars {
+		fields := containerFields(fmt.Sprintf("spec.sidecars[%d]", i),
+			sidecar.Image, sidecar.Command, sidecar.Args, sidecar.Env, sidecar.Script, sidecar.WorkingDir)
+		for _, field := range fields {
+			for _, match := range stepResultRefRegex.FindAllStringSubmatch(field.value, -1) {
+				err = multierror.Append(err, withPath(field.path, fmt.Errorf(
+					"sidecars run alongside the steps, so they cannot use step results in %q", match[0])))
+			}
+		}
+	}
+
+	return err
+}
+
+// validateStepResultAccess verifies that the index or property accessed on a step result, e.g.
+// "[0]" or ".url", is consistent with its type
+func validateStepResultAccess(result v1.StepResult, access string) error {
+	definedType := string(result.Type)
+	if definedType == "" {
+		definedType = string(v1.ResultsTypeString)
+	}
+
+	switch definedType {
+	case string(v1.ResultsTypeArray):
+		if !strings.HasPrefix(access, "[") {
+			return fmt.Errorf("is defined as type %q but is not indexed", definedType)
+		}
+	case string(v1.ResultsTypeObject):
+		if access == "[*]" {
+			return nil
+		}
+		if !strings.HasPrefix(access, ".") {
+			return fmt.Errorf("is defined as type %q but none of its properties is accessed", definedType)
+		}
+		if property := strings.TrimPrefix(access, "."); len(result.Properties) > 0 {
+			if _, found := result.Properties[property]; !found {
+				return fmt.Errorf("is defined as type %q without a %q property", definedType, property)
+			}
+		}
+	default:
+		if access != "" {
+			return fmt.Errorf("is defined as type %q but accessed with %q", definedType, access)
+		}
+	}
+	return nil
+}
+
+// ExternalResultsAnnotation lists, separated by commas, the results of the PipelineTasks of the
+// annotated Pipeline or PipelineRun which are consumed outside of it, e.g. by Tekton Chains. An
+// entry is either the name of a result of any PipelineTask, e.g. IMAGE_DIGEST, or the name of a
+// PipelineTask and of one of its results, e.g. build.IMAGE_DIGEST.
+const ExternalResultsAnnotation = "tektor.dev/external-results"
+
+// ValidateUnusedResults warns about the results of the Tasks of PipelineTasks which neither another
+// PipelineTask nor a pipeline result consumes. The external results, as listed by the
+// ExternalResultsAnnotation, are not reported. The path is the one of the pipeline spec.
+func ValidateUnusedResults(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec, external []string, path string) error {
+	content, err := yaml.Marshal(pipelineSpec)
+	if err != nil {
+		return err
+	}
+	consumed := make(map[string]bool)
+	for _, match := range resultConsumptionRegex.FindAllStringSubmatch(string(content), -1) {
+		consumed[match[1]+"."+match[2]] = true
+	}
+	isExternal := make(map[string]bool)
+	for _, entry := range external {
+		isExternal[strings.TrimSpace(entry)] = true
+	}
+
+	var unusedErr error
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		for i, pipelineTask := range section.pipelineTasks {
+			taskSpec, exists := allTaskSpecs[pipelineTask.Name]
+			if !exists {
+				continue
+			}
+			for _, result := range taskSpec.Results {
+				key := pipelineTask.Name + "." + result.Name
+				if consumed[key] || isExternal[key] || isExternal[result.Name] {
+					continue
+				}
+				unusedErr = multierror.Append(unusedErr, warningf(
+					"%s result of the %s PipelineTask is never consumed, list it in the %s annotation if it is consumed outside of the Pipeline: %s.%s[%d]",
+					result.Name, pipelineTask.Name, ExternalResultsAnnotation, path, section.name, i))
+			}
+		}
+	}
+	return unusedErr
+}
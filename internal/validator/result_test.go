@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -726,3 +727,223 @@ params:
 		})
 	}
 }
+
+func TestValidateStepResultReferences(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskSpecYAML   string
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "valid step result references",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: alpine:latest
+    results:
+      - name: digest
+      - name: tags
+        type: array
+      - name: image
+        type: object
+        properties:
+          url: {type: string}
+  - name: push
+    image: alpine:latest
+    args: ["$(steps.build.results.digest)", "$(steps.build.results.tags[*])", "$(steps.build.results.image.url)"]
+    env:
+      - name: TAG
+        value: $(steps.build.results.tags[0])
+    script: echo $(steps.build.results.image[*])
+`,
+			expectNoError: true,
+		},
+		{
+			name: "unknown steps and results",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: alpine:latest
+    args: ["$(steps.push.results.digest)", "$(steps.build.results.digest)"]
+    results:
+      - name: digest
+  - name: push
+    image: alpine:latest
+    command: ["push", "$(steps.build.results.url)", "$(steps.clone.results.commit)"]
+    results:
+      - name: digest
+sidecars:
+  - name: registry
+    image: registry:2
+    env:
+      - name: DIGEST
+        value: $(steps.build.results.digest)
+`,
+			expectedErrors: []string{
+				`step "push" does not run before step "build", so its results cannot be used in "$(steps.push.results.digest)": spec.steps[0].args[0]`,
+				`step "build" does not run before step "build", so its results cannot be used in "$(steps.build.results.digest)": spec.steps[0].args[1]`,
+				`non-existent url result from step "build" in "$(steps.build.results.url)": spec.steps[1].command[1]`,
+				`non-existent step "clone" in "$(steps.clone.results.commit)": spec.steps[1].command[2]`,
+				`sidecars run alongside the steps, so they cannot use step results in "$(steps.build.results.digest)": spec.sidecars[0].env[DIGEST]`,
+			},
+		},
+		{
+			name: "type mismatches",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: alpine:latest
+    results:
+      - name: digest
+      - name: tags
+        type: array
+      - name: image
+        type: object
+        properties:
+          url: {type: string}
+  - name: push
+    image: alpine:latest
+    args:
+      - $(steps.build.results.digest[0])
+      - $(steps.build.results.tags)
+      - $(steps.build.results.image)
+      - $(steps.build.results.image.digest)
+`,
+			expectedErrors: []string{
+				`result type mismatch: digest result from step "build" is defined as type "string" but accessed with "[0]" in "$(steps.build.results.digest[0])": spec.steps[1].args[0]`,
+				`result type mismatch: tags result from step "build" is defined as type "array" but is not indexed in "$(steps.build.results.tags)": spec.steps[1].args[1]`,
+				`result type mismatch: image result from step "build" is defined as type "object" but none of its properties is accessed in "$(steps.build.results.image)": spec.steps[1].args[2]`,
+				`result type mismatch: image result from step "build" is defined as type "object" without a "digest" property in "$(steps.build.results.image.digest)": spec.steps[1].args[3]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateStepResultReferences(taskSpec)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateUnusedResults(t *testing.T) {
+	pipelineSpec, err := pipelineSpecFromYAML(`
+tasks:
+  - name: clone
+    taskRef:
+      name: git-clone
+  - name: build
+    params:
+      - name: revision
+        value: $(tasks.clone.results.commit)
+    taskRef:
+      name: buildah
+  - name: scan
+    when:
+      - input: $(tasks.build.results.IMAGE_URL)
+        operator: notin
+        values: [""]
+    matrix:
+      params:
+        - name: platform
+          value: $(tasks.build.results.platforms[*])
+    taskRef:
+      name: scan
+finally:
+  - name: notify
+    taskRef:
+      name: notify
+results:
+  - name: report
+    value: $(tasks.scan.results.report)
+`)
+	require.NoError(t, err)
+	allTaskSpecs := map[string]*v1.TaskSpec{
+		"clone":  {Results: []v1.TaskResult{{Name: "commit"}, {Name: "url"}}},
+		"build":  {Results: []v1.TaskResult{{Name: "IMAGE_URL"}, {Name: "IMAGE_DIGEST"}, {Name: "platforms", Type: v1.ResultsTypeArray}}},
+		"scan":   {Results: []v1.TaskResult{{Name: "report"}}},
+		"notify": {Results: []v1.TaskResult{{Name: "status"}}},
+	}
+
+	tests := []struct {
+		name             string
+		external         []string
+		expectedWarnings []string
+	}{
+		{
+			name: "unused results",
+			expectedWarnings: []string{
+				"url result of the clone PipelineTask is never consumed, list it in the tektor.dev/external-results annotation if it is consumed outside of the Pipeline: spec.tasks[0]",
+				"IMAGE_DIGEST result of the build PipelineTask is never consumed, list it in the tektor.dev/external-results annotation if it is consumed outside of the Pipeline: spec.tasks[1]",
+				"status result of the notify PipelineTask is never consumed, list it in the tektor.dev/external-results annotation if it is consumed outside of the Pipeline: spec.finally[0]",
+			},
+		},
+		{
+			name:     "external results",
+			external: []string{"IMAGE_DIGEST", " clone.url", "notify.status"},
+		},
+		{
+			name:     "external result of another PipelineTask",
+			external: []string{"IMAGE_DIGEST", "build.url", "status"},
+			expectedWarnings: []string{
+				"url result of the clone PipelineTask is never consumed, list it in the tektor.dev/external-results annotation if it is consumed outside of the Pipeline: spec.tasks[0]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateUnusedResults(pipelineSpec, allTaskSpecs, tt.external, "spec")
+
+			assert.NoError(t, WithoutWarnings(err), "Expected only warnings for test case: %s", tt.name)
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+		})
+	}
+}
+
+func TestValidatePipelineWithUnusedResults(t *testing.T) {
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+  annotations:
+    tektor.dev/external-results: digest
+spec:
+  tasks:
+    - name: build
+      taskSpec:
+        results:
+          - name: digest
+          - name: url
+        steps:
+          - name: build
+            image: alpine:latest
+            script: echo -n digest > $(results.digest.path) && echo -n url > $(results.url.path)
+`)
+	require.NoError(t, err)
+
+	assert.NoError(t, ValidatePipeline(context.Background(), p), "Expected the check to be disabled by default")
+
+	ctx := WithOptions(context.Background(), Options{CheckUnusedResults: true})
+	err = ValidatePipeline(ctx, p)
+	assert.Equal(t, []Finding{{
+		Rule:         RuleUnusedResults.ID,
+		Severity:     SeverityWarning,
+		Message:      "url result of the build PipelineTask is never consumed, list it in the tektor.dev/external-results annotation if it is consumed outside of the Pipeline",
+		ResourcePath: "spec.tasks[0]",
+	}}, Findings(err))
+}
@@ -0,0 +1,226 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"knative.dev/pkg/apis"
+
+	"github.com/lcarva/tektor/internal/config"
+)
+
+// Rule is a check of the validation. Its ID is stable, so findings can be filtered, tuned, and
+// tracked across releases.
+type Rule struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+// Rules of the validation
+var (
+	RuleSchema             = Rule{"TEK0101", "schema", "resources pass the validation of the Tekton API"}
+	RuleTaskResolution     = Rule{"TEK0102", "task-resolution", "Tasks and Pipelines referenced by resources can be retrieved"}
+	RuleNestedPipelines    = Rule{"TEK0103", "nested-pipelines", "PipelineTasks nest Pipelines only in the fields Tekton supports"}
+	RuleMetadata           = Rule{"TEK0104", "metadata", "names, labels, and annotations follow the syntax and length limits of Kubernetes"}
+	RulePACAnnotations     = Rule{"TEK0105", "pac-annotations", "Pipelines as Code annotations of PipelineRuns are well-formed"}
+	RulePACVariables       = Rule{"TEK0106", "pac-variables", "Pipelines as Code template variables in PipelineRuns are known"}
+	RuleParamReferences    = Rule{"TEK0201", "param-references", "referenced params are declared"}
+	RuleParams             = Rule{"TEK0202", "params", "params passed to Tasks and Pipelines are declared, required ones are passed, and types match"}
+	RuleParamEnums         = Rule{"TEK0203", "param-enums", "values of params with an enum are allowed"}
+	RuleMatrix             = Rule{"TEK0204", "matrix", "matrices fan out declared array params, and their results are consumed as arrays"}
+	RuleResults            = Rule{"TEK0301", "results", "referenced results exist and their types match their usage"}
+	RuleUnusedResults      = Rule{"TEK0302", "unused-results", "results of PipelineTasks are consumed (--check-unused-results)"}
+	RuleWorkspaces         = Rule{"TEK0401", "workspaces", "workspaces are declared, bound, and mounted consistently"}
+	RuleUnusedWorkspace    = Rule{"TEK0402", "unused-workspace", "workspaces declared by Pipelines and Tasks are used"}
+	RuleSteps              = Rule{"TEK0501", "steps", "steps, sidecars, and their results and outputs are well-formed"}
+	RuleStepImages         = Rule{"TEK0502", "step-images", "step images can start, as told by their configuration"}
+	RuleVolumes            = Rule{"TEK0503", "volumes", "volumeMounts refer to declared volumes, which are mounted"}
+	RuleRunSpecs           = Rule{"TEK0601", "run-specs", "taskRunSpecs and compute resource overrides match the Pipeline"}
+	RuleTimeouts           = Rule{"TEK0602", "timeouts", "timeouts are valid durations that fit within each other"}
+	RuleDebug              = Rule{"TEK0603", "debug", "runs do not pause on breakpoints"}
+	RuleWhenExpressions    = Rule{"TEK0604", "when-expressions", "when expressions of PipelineTasks can be satisfied"}
+	RuleClusterObjects     = Rule{"TEK0605", "cluster-objects", "ServiceAccounts, Secrets, and ConfigMaps runs refer to exist in their namespace (--cluster-checks)"}
+	RuleKonfluxResults     = Rule{"TEK0701", "konflux-results", "build Pipelines declare the results required by Enterprise Contract (konflux profile)"}
+	RuleKonfluxMetadata    = Rule{"TEK0702", "konflux-metadata", "PipelineRuns carry the labels and annotations Konflux relies on (konflux profile)"}
+	RuleKonfluxArtifacts   = Rule{"TEK0703", "konflux-trusted-artifacts", "trusted artifact params are passed the results of the same name (konflux profile)"}
+	RuleKonfluxPlatforms   = Rule{"TEK0704", "konflux-build-platforms", "the build-platforms param is an array fanned out over PLATFORM by a matrix (konflux profile)"}
+	RuleKonfluxFinally     = Rule{"TEK0705", "konflux-finally", "build Pipelines run the Tasks Konflux expects in finally (konflux profile)"}
+	RuleSecurityPrivileged = Rule{"TEK0801", RulePrivileged, "containers do not run privileged (security profile)"}
+	RuleSecurityRoot       = Rule{"TEK0802", RuleRunAsRoot, "containers do not run as root (security profile)"}
+	RuleSecurityCaps       = Rule{"TEK0803", RuleAddedCapabilities, "containers do not add capabilities (security profile)"}
+	RuleSecurityHostPath   = Rule{"TEK0804", RuleHostPathVolume, "volumes do not mount host paths (security profile)"}
+	RuleSecurityContext    = Rule{"TEK0805", RuleMissingSecurityContext, "containers set a securityContext (security profile)"}
+	RulePolicy             = Rule{"TEK0901", "policy", "resources comply with the custom Rego policies"}
+	RuleDescriptions       = Rule{"TEK1001", "descriptions", "Pipelines and Tasks describe themselves, their params, results, and workspaces (strict mode, and lint profile for Tasks)"}
+	RulePinnedRefs         = Rule{"TEK1002", "pinned-refs", "remote Tasks and Pipelines are referred to by digest, commit, or version (strict mode)"}
+	RuleUnusedParams       = Rule{"TEK1003", "unused-params", "params declared by Pipelines are referenced, and those declared by Tasks in strict mode"}
+	RuleFloatingRefs       = Rule{"TEK1004", "floating-refs", "remote Tasks and Pipelines are not referred to by a mutable bundle tag, default branch, or latest hub version (strict mode)"}
+	RuleLintStepNames      = Rule{"TEK1102", RuleUnnamedStep, "steps of Tasks are named (lint profile)"}
+	RuleLintShebangs       = Rule{"TEK1103", RuleMissingShebang, "scripts of steps start with a shebang (lint profile)"}
+	RuleLintLatestImages   = Rule{"TEK1104", RuleLatestImage, "steps and sidecars do not use the latest tag of images (lint profile)"}
+	RuleLintLongScripts    = Rule{"TEK1105", RuleLongScript, "scripts of steps are short enough to be kept inline (lint profile)"}
+	RuleLintLargeResults   = Rule{"TEK1106", RuleLargeResult, "scripts of steps do not write output too large for results (lint profile)"}
+)
+
+// Rules lists every Rule, ordered by ID
+var Rules = []Rule{
+	RuleSchema, RuleTaskResolution, RuleNestedPipelines, RuleMetadata, RulePACAnnotations, RulePACVariables,
+	RuleParamReferences, RuleParams, RuleParamEnums, RuleMatrix,
+	RuleResults, RuleUnusedResults,
+	RuleWorkspaces, RuleUnusedWorkspace,
+	RuleSteps, RuleStepImages, RuleVolumes,
+	RuleRunSpecs, RuleTimeouts, RuleDebug, RuleWhenExpressions, RuleClusterObjects,
+	RuleKonfluxResults, RuleKonfluxMetadata, RuleKonfluxArtifacts, RuleKonfluxPlatforms, RuleKonfluxFinally,
+	RuleSecurityPrivileged, RuleSecurityRoot, RuleSecurityCaps, RuleSecurityHostPath, RuleSecurityContext,
+	RulePolicy,
+	RuleDescriptions, RulePinnedRefs, RuleUnusedParams, RuleFloatingRefs,
+	RuleLintStepNames, RuleLintShebangs, RuleLintLatestImages, RuleLintLongScripts, RuleLintLargeResults,
+}
+
+// LookupRule returns the Rule with the given ID or name
+func LookupRule(idOrName string) (Rule, bool) {
+	for _, rule := range Rules {
+		if strings.EqualFold(rule.ID, idOrName) || rule.Name == idOrName {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// ruleError attributes the errors it wraps to a rule, unless a ruleError nested within attributes
+// them to another one. Its message is the one of the wrapped error.
+type ruleError struct {
+	rule Rule
+	err  error
+}
+
+func (e *ruleError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ruleError) Unwrap() error {
+	return e.err
+}
+
+// withRule attributes the errors of err to rule. The errors of a multierror are attributed one by
+// one, so that appending the result to another multierror still flattens them. It returns nil if
+// err is nil.
+func withRule(rule Rule, err error) error {
+	if err == nil {
+		return nil
+	}
+	if merr, ok := err.(*multierror.Error); ok {
+		var result error
+		for _, e := range merr.Errors {
+			result = multierror.Append(result, withRule(rule, e))
+		}
+		return result
+	}
+	return &ruleError{rule: rule, err: err}
+}
+
+// OverrideRules returns err with the findings of the rules of settings, keyed by rule ID or name,
+// reported as warnings or errors, or removed for the rules which are off. Findings attributed to no
+// rule are unchanged.
+func OverrideRules(err error, settings map[string]config.RuleSetting) error {
+	if len(settings) == 0 {
+		return err
+	}
+	return overrideRules(err, settings, "")
+}
+
+// overrideRules is OverrideRules applying setting to the findings not attributed to a nested rule
+func overrideRules(err error, settings map[string]config.RuleSetting, setting config.RuleSetting) error {
+	if err == nil {
+		return nil
+	}
+
+	if rerr, ok := err.(*ruleError); ok {
+		return withRule(rerr.rule, overrideRules(rerr.err, settings, ruleSetting(settings, rerr.rule)))
+	}
+
+	if merr, ok := err.(*multierror.Error); ok {
+		var result error
+		for _, e := range merr.Errors {
+			if overridden := overrideRules(e, settings, setting); overridden != nil {
+				result = multierror.Append(result, overridden)
+			}
+		}
+		return result
+	}
+
+	if w, ok := err.(*Warning); ok {
+		switch setting {
+		case config.RuleOff:
+			return nil
+		case config.SeverityError:
+			return w.Err
+		}
+		return err
+	}
+
+	if inner := errors.Unwrap(err); inner != nil {
+		if prefix, ok := strings.CutSuffix(err.Error(), inner.Error()); ok {
+			overridden := overrideRules(inner, settings, setting)
+			if overridden == nil {
+				return nil
+			}
+			if overridden == inner {
+				return err
+			}
+			return fmt.Errorf("%s%w", prefix, overridden)
+		}
+	}
+
+	switch setting {
+	case config.RuleOff:
+		return nil
+	case config.SeverityWarning:
+		return &Warning{Err: err}
+	}
+	return err
+}
+
+// ruleSetting returns the setting of a rule, looked up by ID or name, or an empty one
+func ruleSetting(settings map[string]config.RuleSetting, rule Rule) config.RuleSetting {
+	for key, setting := range settings {
+		if strings.EqualFold(key, rule.ID) || key == rule.Name {
+			return setting
+		}
+	}
+	return ""
+}
+
+// schemaErrors returns an error for every path of every error reported by the validation of the
+// Tekton API, attributed to RuleSchema. The paths of the errors whose message and path skip matches
+// are left out, skip may be nil.
+func schemaErrors(fieldErr *apis.FieldError, skip func(message, path string) bool) error {
+	if fieldErr == nil {
+		return nil
+	}
+	var err error
+	for _, e := range fieldErr.WrappedErrors() {
+		details := e.Details
+		if len(details) > 0 {
+			details = " " + details
+		}
+		message := strings.TrimSuffix(e.Message, ": ")
+		for _, p := range e.Paths {
+			if skip != nil && skip(e.Message, p) {
+				continue
+			}
+			if details == "" {
+				err = multierror.Append(err, withPath(p, errors.New(message)))
+			} else {
+				err = multierror.Append(err, fmt.Errorf("%v: %v%v", message, p, details))
+			}
+		}
+		if len(e.Paths) == 0 && (skip == nil || !skip(e.Message, "")) {
+			err = multierror.Append(err, fmt.Errorf("%v: %v", message, details))
+		}
+	}
+	return withRule(RuleSchema, err)
+}
@@ -0,0 +1,95 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lcarva/tektor/internal/config"
+)
+
+func TestLookupRule(t *testing.T) {
+	tests := []struct {
+		idOrName string
+		expected Rule
+		found    bool
+	}{
+		{idOrName: "TEK0402", expected: RuleUnusedWorkspace, found: true},
+		{idOrName: "tek0402", expected: RuleUnusedWorkspace, found: true},
+		{idOrName: "unused-workspace", expected: RuleUnusedWorkspace, found: true},
+		{idOrName: RulePrivileged, expected: RuleSecurityPrivileged, found: true},
+		{idOrName: "TEK9999"},
+		{idOrName: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.idOrName, func(t *testing.T) {
+			rule, found := LookupRule(tt.idOrName)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.expected, rule)
+		})
+	}
+}
+
+func TestRulesAreUnique(t *testing.T) {
+	ids := map[string]bool{}
+	names := map[string]bool{}
+	for _, rule := range Rules {
+		assert.False(t, ids[rule.ID], "duplicate rule ID %s", rule.ID)
+		assert.False(t, names[rule.Name], "duplicate rule name %s", rule.Name)
+		assert.NotEmpty(t, rule.Description, "rule %s has no description", rule.ID)
+		ids[rule.ID] = true
+		names[rule.Name] = true
+	}
+	for _, name := range SecurityRules {
+		_, ok := securityRules[name]
+		assert.True(t, ok, "security rule %s has no Rule", name)
+	}
+}
+
+func TestWithRule(t *testing.T) {
+	assert.NoError(t, withRule(RuleSteps, nil))
+
+	err := withRule(RuleSteps, errors.New("boom"))
+	assert.EqualError(t, err, "boom")
+
+	// The errors of a multierror are attributed one by one, so they are still flattened.
+	err = multierror.Append(errors.New("first"), withRule(RuleSteps, multierror.Append(errors.New("second"), errors.New("third"))))
+	var merr *multierror.Error
+	require.ErrorAs(t, err, &merr)
+	assert.Len(t, merr.Errors, 3)
+
+	wrapped := fmt.Errorf("build PipelineTask: %w", withRule(RuleParams, errors.New("boom")))
+	assert.EqualError(t, wrapped, "build PipelineTask: boom")
+}
+
+func TestOverrideRules(t *testing.T) {
+	err := multierror.Append(
+		withRule(RuleSchema, errors.New("invalid value: spec.tasks[0].name")),
+		fmt.Errorf("build PipelineTask: %w", withRule(RuleMatrix, errors.New("matrix param is not an array"))),
+		withRule(RuleUnusedWorkspace, errors.New(`pipeline workspace "cache" is declared but never used`)),
+		withRule(RuleNestedPipelines, warningf("PipelineTask nests a Pipeline")),
+		errors.New("unattributed"),
+	)
+
+	assert.Equal(t, err, OverrideRules(err, nil))
+
+	overridden := OverrideRules(err, map[string]config.RuleSetting{
+		"TEK0101":          config.RuleOff,
+		"tek0204":          config.SeverityWarning,
+		"unused-workspace": config.RuleOff,
+		"TEK0103":          config.SeverityError,
+		"TEK0301":          config.RuleOff,
+	})
+	assert.Equal(t, []Finding{
+		{Rule: RuleMatrix.ID, Severity: SeverityWarning, Message: "build PipelineTask: matrix param is not an array"},
+		{Rule: RuleNestedPipelines.ID, Severity: SeverityError, Message: "PipelineTask nests a Pipeline"},
+		{Severity: SeverityError, Message: "unattributed"},
+	}, Findings(overridden))
+
+	assert.NoError(t, OverrideRules(withRule(RuleSchema, errors.New("boom")), map[string]config.RuleSetting{"schema": config.RuleOff}))
+}
@@ -0,0 +1,304 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// RuntimeParams are the values given to the params of Pipelines at run time, by param name. Their
+// type is the one of the value, so that array and object params can be given too.
+type RuntimeParams map[string]v1.ParamValue
+
+// StringParams returns the RuntimeParams giving the string values of params
+func StringParams(params map[string]string) RuntimeParams {
+	if params == nil {
+		return nil
+	}
+	runtimeParams := make(RuntimeParams, len(params))
+	for name, value := range params {
+		runtimeParams[name] = *v1.NewStructuredValues(value)
+	}
+	return runtimeParams
+}
+
+// ParseRuntimeParam parses the value of a param given at run time: a JSON array of strings is the
+// value of an array param, e.g. ["linux/amd64","linux/arm64"], a JSON object of strings the one of
+// an object param, e.g. {"url":"https://example.com"}, and anything else a string, including values
+// which merely start like JSON, e.g. [skip ci] fix.
+func ParseRuntimeParam(value string) (v1.ParamValue, error) {
+	if !json.Valid([]byte(value)) {
+		return *v1.NewStructuredValues(value), nil
+	}
+	switch {
+	case strings.HasPrefix(value, "["):
+		var items []string
+		if err := json.Unmarshal([]byte(value), &items); err != nil {
+			return v1.ParamValue{}, fmt.Errorf("invalid array %s, expected a JSON array of strings: %w", value, err)
+		}
+		return v1.ParamValue{Type: v1.ParamTypeArray, ArrayVal: items}, nil
+	case strings.HasPrefix(value, "{"):
+		var keys map[string]string
+		if err := json.Unmarshal([]byte(value), &keys); err != nil {
+			return v1.ParamValue{}, fmt.Errorf("invalid object %s, expected a JSON object of strings: %w", value, err)
+		}
+		return v1.ParamValue{Type: v1.ParamTypeObject, ObjectVal: keys}, nil
+	}
+	return *v1.NewStructuredValues(value), nil
+}
+
+// ParseRuntimeParams parses a YAML or JSON map of the values of params given at run time, by param
+// name. Values are strings, lists of strings for array params, or maps of strings for object params.
+// Numbers and booleans stand for their string.
+func ParseRuntimeParams(content []byte) (RuntimeParams, error) {
+	data, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var values map[string]any
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("expected a map of param values: %w", err)
+	}
+
+	params := make(RuntimeParams, len(values))
+	var allErrors error
+	for _, name := range SortedKeys(values) {
+		switch value := values[name].(type) {
+		case []any:
+			items := make([]string, 0, len(value))
+			for i, item := range value {
+				s, ok := scalarString(item)
+				if !ok {
+					allErrors = multierror.Append(allErrors, fmt.Errorf("expected a string: %s[%d]", name, i))
+				}
+				items = append(items, s)
+			}
+			params[name] = v1.ParamValue{Type: v1.ParamTypeArray, ArrayVal: items}
+		case map[string]any:
+			keys := make(map[string]string, len(value))
+			for _, key := range SortedKeys(value) {
+				s, ok := scalarString(value[key])
+				if !ok {
+					allErrors = multierror.Append(allErrors, fmt.Errorf("expected a string: %s.%s", name, key))
+				}
+				keys[key] = s
+			}
+			params[name] = v1.ParamValue{Type: v1.ParamTypeObject, ObjectVal: keys}
+		default:
+			s, ok := scalarString(value)
+			if !ok {
+				allErrors = multierror.Append(allErrors, fmt.Errorf("expected a string, a list of strings, or a map of strings: %s", name))
+			}
+			params[name] = *v1.NewStructuredValues(s)
+		}
+	}
+	if allErrors != nil {
+		return nil, allErrors
+	}
+	return params, nil
+}
+
+// scalarString returns the string a decoded JSON scalar stands for, and whether it is a scalar
+func scalarString(value any) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case json.Number, bool:
+		return fmt.Sprint(value), true
+	}
+	return "", false
+}
+
+// FormatRuntimeParam returns a param value as it is given at run time, see ParseRuntimeParam
+func FormatRuntimeParam(value v1.ParamValue) string {
+	if value.Type == v1.ParamTypeArray || value.Type == v1.ParamTypeObject {
+		if content, err := json.Marshal(value); err == nil {
+			return string(content)
+		}
+	}
+	return value.StringVal
+}
+
+// Strings returns the string each reference to the params resolves to, keyed by the reference
+// without its $(params. prefix and ) suffix: the names of string params, the items of array
+// params, e.g. platforms[0], and the keys of object params, e.g. repo.url. References to whole
+// arrays and objects are left out.
+func (p RuntimeParams) Strings() map[string]string {
+	if p == nil {
+		return nil
+	}
+	values := make(map[string]string, len(p))
+	for name, value := range p {
+		switch value.Type {
+		case v1.ParamTypeArray:
+			for i, item := range value.ArrayVal {
+				values[fmt.Sprintf("%s[%d]", name, i)] = item
+			}
+		case v1.ParamTypeObject:
+			for key, field := range value.ObjectVal {
+				values[name+"."+key] = field
+			}
+		default:
+			values[name] = value.StringVal
+		}
+	}
+	return values
+}
+
+// Params returns the params given at run time as the params of a run, sorted by name
+func (p RuntimeParams) Params() v1.Params {
+	params := make(v1.Params, 0, len(p))
+	for name, value := range p {
+		params = append(params, v1.Param{Name: name, Value: value})
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params
+}
+
+// ValidateRuntimeParams verifies that the values given at run time to the params of a Pipeline
+// match the types the Pipeline declares, and that the values of object params set the properties
+// declared, unless the param has a default, and no others. Values of params the Pipeline does not
+// declare are ignored. The path is the one of the pipeline spec.
+func ValidateRuntimeParams(params RuntimeParams, paramSpecs v1.ParamSpecs, path string) error {
+	var err error
+	for i, paramSpec := range paramSpecs {
+		value, ok := params[paramSpec.Name]
+		if !ok {
+			continue
+		}
+		expected := paramSpec.Type
+		if expected == "" {
+			expected = v1.ParamTypeString
+		}
+		actual := value.Type
+		if actual == "" {
+			actual = v1.ParamTypeString
+		}
+		if actual != expected {
+			err = multierror.Append(err, fmt.Errorf("%s param is of type %s, but its runtime value %s is of type %s: %s.params[%d]",
+				paramSpec.Name, expected, FormatRuntimeParam(value), actual, path, i))
+			continue
+		}
+		if expected != v1.ParamTypeObject {
+			continue
+		}
+		for _, key := range SortedKeys(paramSpec.Properties) {
+			if _, set := value.ObjectVal[key]; !set && paramSpec.Default == nil {
+				err = multierror.Append(err, fmt.Errorf("%s param requires the %s property, which its runtime value lacks: %s.params[%d]", paramSpec.Name, key, path, i))
+			}
+		}
+		for _, key := range SortedKeys(value.ObjectVal) {
+			if _, declared := paramSpec.Properties[key]; !declared && len(paramSpec.Properties) > 0 {
+				err = multierror.Append(err, fmt.Errorf("%s param has no %s property, but its runtime value sets it: %s.params[%d]", paramSpec.Name, key, path, i))
+			}
+		}
+	}
+	return err
+}
+
+// SubstituteRuntimeParams returns YAML content with the references to params replaced with their
+// runtime values. References to string params, and to the items and keys of array and object
+// params, are replaced within strings. A string made up of a reference to a whole array or object,
+// e.g. $(params.platforms[*]), is replaced with its value, the items of an array being spliced into
+// the list holding the string. Content which is not valid YAML only has strings replaced.
+func SubstituteRuntimeParams(content []byte, params RuntimeParams) []byte {
+	for ref, value := range params.Strings() {
+		content = bytes.ReplaceAll(content, []byte("$(params."+ref+")"), []byte(value))
+	}
+
+	whole := false
+	for name, value := range params {
+		if (value.Type == v1.ParamTypeArray || value.Type == v1.ParamTypeObject) && bytes.Contains(content, []byte("$(params."+name+"[*])")) {
+			whole = true
+		}
+	}
+	if !whole {
+		return content
+	}
+
+	data, err := yaml.YAMLToJSON(content)
+	if err != nil {
+		return content
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Numbers are kept as they are written, rather than as floats.
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return content
+	}
+	data, err = json.Marshal(substituteWholeParams(doc, params))
+	if err != nil {
+		return content
+	}
+	substituted, err := yaml.JSONToYAML(data)
+	if err != nil {
+		return content
+	}
+	return substituted
+}
+
+// substituteWholeParams replaces the strings of a decoded JSON document made up of a reference to a
+// whole array or object param with its value
+func substituteWholeParams(node any, params RuntimeParams) any {
+	switch node := node.(type) {
+	case map[string]any:
+		for key, value := range node {
+			node[key] = substituteWholeParams(value, params)
+		}
+	case []any:
+		items := make([]any, 0, len(node))
+		for _, item := range node {
+			if value, ok := wholeParam(item, params); ok && value.Type == v1.ParamTypeArray {
+				for _, element := range value.ArrayVal {
+					items = append(items, element)
+				}
+				continue
+			}
+			items = append(items, substituteWholeParams(item, params))
+		}
+		return items
+	case string:
+		if value, ok := wholeParam(node, params); ok {
+			if value.Type == v1.ParamTypeArray {
+				items := make([]any, 0, len(value.ArrayVal))
+				for _, item := range value.ArrayVal {
+					items = append(items, item)
+				}
+				return items
+			}
+			keys := make(map[string]any, len(value.ObjectVal))
+			for key, field := range value.ObjectVal {
+				keys[key] = field
+			}
+			return keys
+		}
+	}
+	return node
+}
+
+// wholeParam returns the value of the array or object param a node refers to, if the node is a string
+// made up of a star reference to it, e.g. $(params.platforms[*])
+func wholeParam(node any, params RuntimeParams) (v1.ParamValue, bool) {
+	s, ok := node.(string)
+	if !ok || !wholeValueRefRegex.MatchString(s) {
+		return v1.ParamValue{}, false
+	}
+	name, ok := strings.CutPrefix(strings.TrimSuffix(s, "[*])"), "$(params.")
+	if !ok {
+		return v1.ParamValue{}, false
+	}
+	value, ok := params[name]
+	if !ok || value.Type == v1.ParamTypeString || value.Type == "" {
+		return v1.ParamValue{}, false
+	}
+	return value, true
+}
@@ -0,0 +1,198 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+)
+
+func TestParseRuntimeParam(t *testing.T) {
+	value, err := ParseRuntimeParam(`["linux/amd64", "linux/arm64"]`)
+	require.NoError(t, err)
+	assert.Equal(t, *v1.NewStructuredValues("linux/amd64", "linux/arm64"), value)
+	assert.Equal(t, `["linux/amd64","linux/arm64"]`, FormatRuntimeParam(value))
+
+	value, err = ParseRuntimeParam(`{"url": "https://example.com"}`)
+	require.NoError(t, err)
+	assert.Equal(t, *v1.NewObject(map[string]string{"url": "https://example.com"}), value)
+	assert.Equal(t, `{"url":"https://example.com"}`, FormatRuntimeParam(value))
+
+	value, err = ParseRuntimeParam("main")
+	require.NoError(t, err)
+	assert.Equal(t, *v1.NewStructuredValues("main"), value)
+	assert.Equal(t, "main", FormatRuntimeParam(value))
+
+	value, err = ParseRuntimeParam("[]")
+	require.NoError(t, err)
+	assert.Equal(t, v1.ParamTypeArray, value.Type)
+
+	// Values which are not JSON are strings even if they start like JSON.
+	for _, s := range []string{"[skip ci] fix", "[linux/amd64]", "{url}"} {
+		value, err = ParseRuntimeParam(s)
+		require.NoError(t, err)
+		assert.Equal(t, *v1.NewStructuredValues(s), value)
+	}
+
+	_, err = ParseRuntimeParam("[1, 2]")
+	assert.ErrorContains(t, err, "invalid array [1, 2], expected a JSON array of strings")
+	_, err = ParseRuntimeParam(`{"url": 1}`)
+	assert.ErrorContains(t, err, `invalid object {"url": 1}, expected a JSON object of strings`)
+}
+
+func TestRuntimeParamsStrings(t *testing.T) {
+	params := RuntimeParams{
+		"revision":  *v1.NewStructuredValues("main"),
+		"platforms": *v1.NewStructuredValues("linux/amd64", "linux/arm64"),
+		"repo":      *v1.NewObject(map[string]string{"url": "https://example.com"}),
+	}
+	assert.Equal(t, map[string]string{
+		"revision":     "main",
+		"platforms[0]": "linux/amd64",
+		"platforms[1]": "linux/arm64",
+		"repo.url":     "https://example.com",
+	}, params.Strings())
+	assert.Nil(t, RuntimeParams(nil).Strings())
+	assert.Equal(t, v1.Params{
+		{Name: "platforms", Value: *v1.NewStructuredValues("linux/amd64", "linux/arm64")},
+		{Name: "repo", Value: *v1.NewObject(map[string]string{"url": "https://example.com"})},
+		{Name: "revision", Value: *v1.NewStructuredValues("main")},
+	}, params.Params())
+}
+
+func TestSubstituteRuntimeParams(t *testing.T) {
+	params := RuntimeParams{
+		"revision":  *v1.NewStructuredValues("main"),
+		"platforms": *v1.NewStructuredValues("linux/amd64", "linux/arm64"),
+		"repo":      *v1.NewObject(map[string]string{"url": "https://example.com"}),
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "strings only",
+			content:  "script: echo $(params.revision) $(params.platforms[1]) $(params.repo.url) $(params.unknown)\n",
+			expected: "script: echo main linux/arm64 https://example.com $(params.unknown)\n",
+		},
+		{
+			name: "whole array and object",
+			content: `spec:
+  matrix:
+    params:
+      - name: PLATFORM
+        value: $(params.platforms[*])
+  args:
+    - --build
+    - $(params.platforms[*])
+  config: $(params.repo[*])
+  revision: $(params.revision)
+  count: 3
+  retries: 1000000
+`,
+			expected: `spec:
+  args:
+  - --build
+  - linux/amd64
+  - linux/arm64
+  config:
+    url: https://example.com
+  count: 3
+  matrix:
+    params:
+    - name: PLATFORM
+      value:
+      - linux/amd64
+      - linux/arm64
+  retries: 1000000
+  revision: main
+`,
+		},
+		{
+			name:     "star reference within a string",
+			content:  "script: echo $(params.platforms[*]) $(params.revision)\n",
+			expected: "script: echo $(params.platforms[*]) main\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(SubstituteRuntimeParams([]byte(tt.content), params)))
+		})
+	}
+}
+
+func TestValidateRuntimeParams(t *testing.T) {
+	paramSpecs := v1.ParamSpecs{
+		{Name: "revision"},
+		{Name: "platforms", Type: v1.ParamTypeArray},
+		{Name: "repo", Type: v1.ParamTypeObject, Properties: map[string]v1.PropertySpec{"url": {}, "branch": {}}},
+		{Name: "settings", Type: v1.ParamTypeObject, Properties: map[string]v1.PropertySpec{"level": {}},
+			Default: v1.NewObject(map[string]string{"level": "info"})},
+	}
+
+	assert.NoError(t, ValidateRuntimeParams(RuntimeParams{
+		"revision":  *v1.NewStructuredValues("main"),
+		"platforms": *v1.NewStructuredValues("linux/amd64", "linux/arm64"),
+		"repo":      *v1.NewObject(map[string]string{"url": "https://example.com", "branch": "main"}),
+		"settings":  *v1.NewObject(map[string]string{}),
+		"unknown":   *v1.NewStructuredValues("a", "b"),
+	}, paramSpecs, "spec"))
+
+	err := ValidateRuntimeParams(RuntimeParams{
+		"revision":  *v1.NewStructuredValues("main", "dev"),
+		"platforms": *v1.NewStructuredValues("linux/amd64"),
+		"repo":      *v1.NewObject(map[string]string{"url": "https://example.com", "tag": "v1"}),
+	}, paramSpecs, "spec")
+	require.Error(t, err)
+	var messages []string
+	for _, e := range err.(*multierror.Error).Errors {
+		messages = append(messages, e.Error())
+	}
+	assert.Equal(t, []string{
+		`revision param is of type string, but its runtime value ["main","dev"] is of type array: spec.params[0]`,
+		"platforms param is of type array, but its runtime value linux/amd64 is of type string: spec.params[1]",
+		"repo param requires the branch property, which its runtime value lacks: spec.params[2]",
+		"repo param has no tag property, but its runtime value sets it: spec.params[2]",
+	}, messages)
+}
+
+func TestParseRuntimeParams(t *testing.T) {
+	params, err := ParseRuntimeParams([]byte(`revision: main
+retries: 3
+debug: false
+platforms: [linux/amd64, linux/arm64]
+repo:
+  url: https://example.com
+  depth: 1
+`))
+	require.NoError(t, err)
+	assert.Equal(t, RuntimeParams{
+		"revision":  *v1.NewStructuredValues("main"),
+		"retries":   *v1.NewStructuredValues("3"),
+		"debug":     *v1.NewStructuredValues("false"),
+		"platforms": *v1.NewStructuredValues("linux/amd64", "linux/arm64"),
+		"repo":      *v1.NewObject(map[string]string{"url": "https://example.com", "depth": "1"}),
+	}, params)
+
+	params, err = ParseRuntimeParams([]byte(`{"platforms": []}`))
+	require.NoError(t, err)
+	assert.Equal(t, RuntimeParams{"platforms": {Type: v1.ParamTypeArray, ArrayVal: []string{}}}, params)
+
+	_, err = ParseRuntimeParams([]byte(`revision: null
+platforms: [[linux/amd64]]
+repo:
+  url: {host: example.com}
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected a string, a list of strings, or a map of strings: revision")
+	assert.Contains(t, err.Error(), "expected a string: platforms[0]")
+	assert.Contains(t, err.Error(), "expected a string: repo.url")
+
+	_, err = ParseRuntimeParams([]byte("- revision"))
+	assert.ErrorContains(t, err, "expected a map of param values")
+}
@@ -0,0 +1,145 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Rules of the security profile
+const (
+	RulePrivileged             = "privileged"
+	RuleRunAsRoot              = "run-as-root"
+	RuleAddedCapabilities      = "added-capabilities"
+	RuleHostPathVolume         = "host-path-volume"
+	RuleMissingSecurityContext = "missing-security-context"
+)
+
+// SecurityRules lists the rules of the security profile
+var SecurityRules = []string{
+	RulePrivileged, RuleRunAsRoot, RuleAddedCapabilities, RuleHostPathVolume, RuleMissingSecurityContext,
+}
+
+// securityRules maps the names of the rules of the security profile to their Rule
+var securityRules = map[string]Rule{
+	RulePrivileged:             RuleSecurityPrivileged,
+	RuleRunAsRoot:              RuleSecurityRoot,
+	RuleAddedCapabilities:      RuleSecurityCaps,
+	RuleHostPathVolume:         RuleSecurityHostPath,
+	RuleMissingSecurityContext: RuleSecurityContext,
+}
+
+// SuppressRulesAnnotation lists, separated by commas, the rules of the security or lint profile
+// which do not apply to the Tasks of the annotated resource
+const SuppressRulesAnnotation = "tektor.dev/suppress-rules"
+
+type suppressedRulesKey struct{}
+
+// suppressibleRules are the rules of the profiles which can be suppressed, by profile
+var suppressibleRules = map[string][]string{
+	ProfileSecurity: SecurityRules,
+	ProfileLint:     LintRules,
+}
+
+// withSuppressedRules returns a copy of ctx which also suppresses the rules listed by the
+// SuppressRulesAnnotation of annotations. Unknown rules are reported, unless neither the security
+// nor the lint profile is enabled, in which case the annotation is ignored. The rules of every
+// enabled profile are known.
+func withSuppressedRules(ctx context.Context, annotations map[string]string) (context.Context, error) {
+	value, ok := annotations[SuppressRulesAnnotation]
+	var known []string
+	for _, profile := range Profiles {
+		if hasProfile(ctx, profile) {
+			known = append(known, suppressibleRules[profile]...)
+		}
+	}
+	if !ok || known == nil {
+		return ctx, nil
+	}
+
+	var err error
+	suppressed := slices.Clone(suppressedRulesFromContext(ctx))
+	for _, rule := range strings.Split(value, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if !slices.Contains(known, rule) {
+			err = multierror.Append(err, fmt.Errorf("unknown rule %q, expected one of: %s: metadata.annotations.%s",
+				rule, strings.Join(known, ", "), SuppressRulesAnnotation))
+			continue
+		}
+		suppressed = append(suppressed, rule)
+	}
+	return context.WithValue(ctx, suppressedRulesKey{}, suppressed), err
+}
+
+// suppressedRulesFromContext returns the rules suppressed by the resources being validated
+func suppressedRulesFromContext(ctx context.Context) []string {
+	suppressed, _ := ctx.Value(suppressedRulesKey{}).([]string)
+	return suppressed
+}
+
+// ValidateStepSecurity verifies the steps, sidecars, step template, and volumes of a Task do not
+// weaken the isolation of its pod: containers must set a securityContext, must not run privileged
+// or as root, and must not add capabilities, and volumes must not mount host paths. The suppressed
+// rules are not verified.
+func ValidateStepSecurity(taskSpec v1.TaskSpec, suppressed []string) error {
+	var err error
+	report := func(rule, path, format string, args ...any) {
+		if !slices.Contains(suppressed, rule) {
+			err = multierror.Append(err, withRule(securityRules[rule], withPath(path, fmt.Errorf("%s (%s rule)", fmt.Sprintf(format, args...), rule))))
+		}
+	}
+	check := func(securityContext *corev1.SecurityContext, path string) {
+		if securityContext == nil {
+			return
+		}
+		if securityContext.Privileged != nil && *securityContext.Privileged {
+			report(RulePrivileged, path+".securityContext.privileged", "container runs privileged")
+		}
+		if securityContext.RunAsUser != nil && *securityContext.RunAsUser == 0 {
+			report(RuleRunAsRoot, path+".securityContext.runAsUser", "container runs as root")
+		}
+		if capabilities := securityContext.Capabilities; capabilities != nil && len(capabilities.Add) > 0 {
+			added := make([]string, 0, len(capabilities.Add))
+			for _, capability := range capabilities.Add {
+				added = append(added, string(capability))
+			}
+			report(RuleAddedCapabilities, path+".securityContext.capabilities.add",
+				"container adds the %s capabilities", strings.Join(added, ", "))
+		}
+	}
+
+	var templateContext *corev1.SecurityContext
+	if taskSpec.StepTemplate != nil {
+		templateContext = taskSpec.StepTemplate.SecurityContext
+		check(templateContext, "spec.stepTemplate")
+	}
+	for i, step := range taskSpec.Steps {
+		path := fmt.Sprintf("spec.steps[%d]", i)
+		if step.SecurityContext == nil && templateContext == nil {
+			report(RuleMissingSecurityContext, path, "step %q does not set a securityContext, nor does the stepTemplate", step.Name)
+		}
+		check(step.SecurityContext, path)
+	}
+	for i, sidecar := range taskSpec.Sidecars {
+		path := fmt.Sprintf("spec.sidecars[%d]", i)
+		if sidecar.SecurityContext == nil {
+			report(RuleMissingSecurityContext, path, "sidecar %q does not set a securityContext", sidecar.Name)
+		}
+		check(sidecar.SecurityContext, path)
+	}
+	for i, volume := range taskSpec.Volumes {
+		if volume.HostPath != nil {
+			report(RuleHostPathVolume, fmt.Sprintf("spec.volumes[%d].hostPath", i),
+				"volume %q mounts the %s host path", volume.Name, volume.HostPath.Path)
+		}
+	}
+	return err
+}
@@ -0,0 +1,195 @@
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateStepSecurity(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskSpecYAML   string
+		suppressed     []string
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "restricted containers",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: alpine:latest
+    securityContext:
+      runAsUser: 1000
+      capabilities:
+        drop: ["ALL"]
+sidecars:
+  - name: registry
+    image: registry:2
+    securityContext:
+      runAsNonRoot: true
+volumes:
+  - name: cache
+    emptyDir: {}
+`,
+			expectNoError: true,
+		},
+		{
+			name: "step template applies to steps",
+			taskSpecYAML: `
+stepTemplate:
+  securityContext:
+    runAsNonRoot: true
+steps:
+  - name: build
+    image: alpine:latest
+`,
+			expectNoError: true,
+		},
+		{
+			name: "insecure containers and volumes",
+			taskSpecYAML: `
+stepTemplate:
+  securityContext:
+    runAsUser: 0
+steps:
+  - name: build
+    image: quay.io/buildah/stable:latest
+    securityContext:
+      privileged: true
+      capabilities:
+        add: ["SETFCAP", "SYS_ADMIN"]
+sidecars:
+  - name: docker
+    image: docker:dind
+volumes:
+  - name: socket
+    hostPath:
+      path: /var/run/docker.sock
+`,
+			expectedErrors: []string{
+				"container runs as root (run-as-root rule): spec.stepTemplate.securityContext.runAsUser",
+				"container runs privileged (privileged rule): spec.steps[0].securityContext.privileged",
+				"container adds the SETFCAP, SYS_ADMIN capabilities (added-capabilities rule): spec.steps[0].securityContext.capabilities.add",
+				`sidecar "docker" does not set a securityContext (missing-security-context rule): spec.sidecars[0]`,
+				`volume "socket" mounts the /var/run/docker.sock host path (host-path-volume rule): spec.volumes[0].hostPath`,
+			},
+		},
+		{
+			name: "missing security context",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: alpine:latest
+`,
+			expectedErrors: []string{
+				`step "build" does not set a securityContext, nor does the stepTemplate (missing-security-context rule): spec.steps[0]`,
+			},
+		},
+		{
+			name: "suppressed rules",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: quay.io/buildah/stable:latest
+    securityContext:
+      privileged: true
+volumes:
+  - name: socket
+    hostPath:
+      path: /var/run/docker.sock
+`,
+			suppressed:    []string{RulePrivileged, RuleHostPathVolume},
+			expectNoError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateStepSecurity(taskSpec, tt.suppressed)
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+			assert.Equal(t, len(tt.expectedErrors), strings.Count(err.Error(), " rule): "))
+		})
+	}
+}
+
+func TestSecurityProfileSuppression(t *testing.T) {
+	task, err := taskFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: buildah
+  annotations:
+    tektor.dev/suppress-rules: "privileged, missing-security-context, host-path"
+spec:
+  steps:
+    - name: build
+      image: quay.io/buildah/stable:latest
+      securityContext:
+        privileged: true
+        runAsUser: 0
+`)
+	require.NoError(t, err)
+
+	// The rules of the profile, and the annotation, only apply once the profile is enabled.
+	assert.NoError(t, ValidateTaskV1(context.Background(), task))
+
+	ctx := WithOptions(context.Background(), Options{Profiles: NewProfileSet(ProfileSecurity)})
+	err = ValidateTaskV1(ctx, task)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "security profile: 1 error occurred:\n\t* container runs as root (run-as-root rule): spec.steps[0].securityContext.runAsUser")
+	assert.Contains(t, err.Error(), `unknown rule "host-path", expected one of: privileged, run-as-root, added-capabilities, host-path-volume, missing-security-context: metadata.annotations.tektor.dev/suppress-rules`)
+	assert.NotContains(t, err.Error(), "privileged rule")
+}
+
+func TestSecurityProfileEmbeddedTaskSuppression(t *testing.T) {
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+  annotations:
+    tektor.dev/suppress-rules: missing-security-context
+spec:
+  tasks:
+    - name: build
+      taskSpec:
+        metadata:
+          annotations:
+            tektor.dev/suppress-rules: privileged
+        steps:
+          - name: build
+            image: quay.io/buildah/stable:latest
+            securityContext:
+              privileged: true
+    - name: push
+      taskSpec:
+        steps:
+          - name: push
+            image: quay.io/buildah/stable:latest
+            securityContext:
+              privileged: true
+`)
+	require.NoError(t, err)
+
+	ctx := WithOptions(context.Background(), Options{Profiles: NewProfileSet(ProfileSecurity)})
+	err = ValidatePipeline(ctx, p)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "push PipelineTask: 1 error occurred:\n\t* security profile: 1 error occurred:\n\t* container runs privileged (privileged rule): spec.steps[0].securityContext.privileged")
+	assert.NotContains(t, err.Error(), "build PipelineTask")
+	assert.NotContains(t, err.Error(), "missing-security-context rule")
+}
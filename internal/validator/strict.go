@@ -0,0 +1,250 @@
+package validator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// describedItem is something a Pipeline or Task describes: itself, one of its params, results, or
+// workspaces
+type describedItem struct {
+	// name tells what is described, e.g. "Task" or "url param".
+	name        string
+	description string
+	path        string
+}
+
+// ValidatePipelineDescriptions verifies that a Pipeline describes itself, its params, its results,
+// and its workspaces. The path is the one of the pipeline spec, e.g. spec.
+func ValidatePipelineDescriptions(pipelineSpec v1.PipelineSpec, path string) error {
+	return missingDescriptions(pipelineDescribedItems(pipelineSpec, path))
+}
+
+// ValidateTaskDescriptions verifies that a Task describes itself, its params, its results, and its
+// workspaces. The path is the one of the task spec, e.g. spec.
+func ValidateTaskDescriptions(taskSpec v1.TaskSpec, path string) error {
+	return missingDescriptions(taskDescribedItems(taskSpec, path))
+}
+
+// pipelineDescribedItems returns the items a pipeline spec at path describes
+func pipelineDescribedItems(pipelineSpec v1.PipelineSpec, path string) []describedItem {
+	items := specDescribedItems("Pipeline", pipelineSpec.Description, pipelineSpec.Params, path)
+	for i, result := range pipelineSpec.Results {
+		items = append(items, describedItem{result.Name + " result", result.Description, fmt.Sprintf("%s.results[%d]", path, i)})
+	}
+	for i, workspace := range pipelineSpec.Workspaces {
+		items = append(items, describedItem{workspace.Name + " workspace", workspace.Description, fmt.Sprintf("%s.workspaces[%d]", path, i)})
+	}
+	return items
+}
+
+// taskDescribedItems returns the items a task spec at path describes
+func taskDescribedItems(taskSpec v1.TaskSpec, path string) []describedItem {
+	items := specDescribedItems("Task", taskSpec.Description, taskSpec.Params, path)
+	for i, result := range taskSpec.Results {
+		items = append(items, describedItem{result.Name + " result", result.Description, fmt.Sprintf("%s.results[%d]", path, i)})
+	}
+	for i, workspace := range taskSpec.Workspaces {
+		items = append(items, describedItem{workspace.Name + " workspace", workspace.Description, fmt.Sprintf("%s.workspaces[%d]", path, i)})
+	}
+	return items
+}
+
+// specDescribedItems returns the items a spec at path of the given kind describes which Pipelines
+// and Tasks have in common: the spec itself and its params
+func specDescribedItems(kind, description string, params v1.ParamSpecs, path string) []describedItem {
+	items := []describedItem{{kind, description, path + ".description"}}
+	for i, param := range params {
+		items = append(items, describedItem{param.Name + " param", param.Description, fmt.Sprintf("%s.params[%d]", path, i)})
+	}
+	return items
+}
+
+// missingDescriptions returns an error for every item lacking a description
+func missingDescriptions(items []describedItem) error {
+	var err error
+	for _, item := range items {
+		if item.description == "" {
+			err = multierror.Append(err, withPath(item.path, fmt.Errorf("%s has no description", item.name)))
+		}
+	}
+	return err
+}
+
+// ValidatePinnedRefs verifies that the PipelineTasks of a Pipeline refer to remote Tasks and
+// Pipelines by an immutable reference: bundles by digest, git repositories by commit, and the hub by
+// version. References substituting params are not verified. The path is the one of the pipeline spec.
+func ValidatePinnedRefs(pipelineSpec v1.PipelineSpec, path string) error {
+	return validatePipelineTaskRefs(pipelineSpec, path, validatePinnedRef)
+}
+
+// ValidateFloatingRefs warns about the PipelineTasks of a Pipeline which refer to remote Tasks and
+// Pipelines by a reference that moves: bundles by tag, git repositories by their default branch,
+// main, or master, and the hub by its latest version. Unlike ValidatePinnedRefs, other branches and
+// tags of git repositories are accepted. The path is the one of the pipeline spec.
+//
+// In strict mode, the references which move are reported by this rule, whose severity is
+// configurable, and the other unpinned references by the pinned-refs rule.
+func ValidateFloatingRefs(pipelineSpec v1.PipelineSpec, path string) error {
+	var err error
+	if refErr, ok := validatePipelineTaskRefs(pipelineSpec, path, validateFloatingRef).(*multierror.Error); ok {
+		for _, e := range refErr.Errors {
+			err = multierror.Append(err, &Warning{Err: e})
+		}
+	}
+	return err
+}
+
+// validatePipelineTaskRefs returns an error for every remote reference of the PipelineTasks of a
+// Pipeline which check rejects, naming the PipelineTask and the path of the reference
+func validatePipelineTaskRefs(pipelineSpec v1.PipelineSpec, path string, check func(v1.ResolverRef) error) error {
+	var err error
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		for i, pipelineTask := range section.pipelineTasks {
+			if ref := pipelineTask.TaskRef; ref != nil {
+				if refErr := check(ref.ResolverRef); refErr != nil {
+					err = multierror.Append(err, fmt.Errorf("%s PipelineTask %v: %s.%s[%d].taskRef", pipelineTask.Name, refErr, path, section.name, i))
+				}
+			}
+			if ref := pipelineTask.PipelineRef; ref != nil {
+				if refErr := check(ref.ResolverRef); refErr != nil {
+					err = multierror.Append(err, fmt.Errorf("%s PipelineTask %v: %s.%s[%d].pipelineRef", pipelineTask.Name, refErr, path, section.name, i))
+				}
+			}
+		}
+	}
+	return err
+}
+
+// floatingRevisions are the git revisions which name the tip of the main line of development, the
+// default branch being named by no revision
+var floatingRevisions = []string{"", "main", "master", "HEAD"}
+
+// validateFloatingRef returns an error describing how a resolver reference moves, or nil if it
+// names a fixed version or does not refer to a remote resource
+func validateFloatingRef(ref v1.ResolverRef) error {
+	if ref.Resolver == "git" && !slices.Contains(floatingRevisions, getParamValue(ref.Params, "revision")) {
+		return nil
+	}
+	return validatePinnedRef(ref)
+}
+
+// validateFixedRef returns an error describing how a resolver reference which does not move, e.g. a
+// git tag, is not pinned, or nil if it is pinned or moves, which validateFloatingRef reports
+func validateFixedRef(ref v1.ResolverRef) error {
+	if validateFloatingRef(ref) != nil {
+		return nil
+	}
+	return validatePinnedRef(ref)
+}
+
+// validateRunRef verifies the remote reference at path of a TaskRun or PipelineRun in strict mode:
+// references which move are warned about by the floating-refs rule, and the other unpinned ones are
+// reported by the pinned-refs rule
+func validateRunRef(kind string, ref v1.ResolverRef, path string) error {
+	if err := validateFloatingRef(ref); err != nil {
+		return withRule(RuleFloatingRefs, &Warning{Err: fmt.Errorf("%s %v: %s", kind, err, path)})
+	}
+	if err := validateFixedRef(ref); err != nil {
+		return withRule(RulePinnedRefs, fmt.Errorf("%s %v: %s", kind, err, path))
+	}
+	return nil
+}
+
+// validatePinnedRef returns an error describing how a resolver reference is not pinned, or nil if it
+// is pinned or does not refer to a remote resource
+func validatePinnedRef(ref v1.ResolverRef) error {
+	switch ref.Resolver {
+	case "bundles":
+		bundle := getParamValue(ref.Params, "bundle")
+		// Local bundles are part of the repository, so they are pinned along with it.
+		if _, _, local := localBundle(bundle); local {
+			return nil
+		}
+		if bundle != "" && !strings.Contains(bundle, "$(") && !strings.Contains(bundle, "@sha256:") {
+			return fmt.Errorf("refers to bundle %s by tag, pin it by digest", bundle)
+		}
+	case "git":
+		revision := getParamValue(ref.Params, "revision")
+		if revision == "" {
+			return fmt.Errorf("refers to the default branch of %s, pin it to a commit", getParamValue(ref.Params, "url"))
+		}
+		if !strings.Contains(revision, "$(") && !gitCommitRegex.MatchString(revision) {
+			return fmt.Errorf("refers to revision %s of %s, pin it to a commit", revision, getParamValue(ref.Params, "url"))
+		}
+	case "hub":
+		if getParamValue(ref.Params, "version") == "" {
+			return fmt.Errorf("refers to the latest version of %s, pin it to a version", getParamValue(ref.Params, "name"))
+		}
+	}
+	return nil
+}
+
+// ValidateUnusedParams verifies that every param declared by a Pipeline or Task is referenced within
+// its spec. The path is the one of the spec.
+func ValidateUnusedParams(params v1.ParamSpecs, spec any, path string) error {
+	unused, err := unusedParams(params, spec)
+	if err != nil {
+		return err
+	}
+	var unusedErr error
+	for _, i := range unused {
+		unusedErr = multierror.Append(unusedErr, fmt.Errorf("%s param is declared but never referenced: %s.params[%d]", params[i].Name, path, i))
+	}
+	return unusedErr
+}
+
+// unusedParams returns the indexes of the params which are not referenced within spec
+func unusedParams(params v1.ParamSpecs, spec any) ([]int, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	content, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	referenced := make(map[string]bool)
+	for paramRef := range countParameterReferences(string(content)) {
+		referenced[paramRefName(paramRef)] = true
+	}
+
+	var unused []int
+	for i, param := range params {
+		if !referenced[param.Name] {
+			unused = append(unused, i)
+		}
+	}
+	return unused, nil
+}
+
+// validatePipelineStrict runs the pedantic rules of strict mode against a pipeline spec
+func validatePipelineStrict(pipelineSpec v1.PipelineSpec, path string) error {
+	var err error
+	if descErr := ValidatePipelineDescriptions(pipelineSpec, path); descErr != nil {
+		err = multierror.Append(err, withRule(RuleDescriptions, descErr))
+	}
+	if floatErr := ValidateFloatingRefs(pipelineSpec, path); floatErr != nil {
+		err = multierror.Append(err, withRule(RuleFloatingRefs, floatErr))
+	}
+	if pinErr := validatePipelineTaskRefs(pipelineSpec, path, validateFixedRef); pinErr != nil {
+		err = multierror.Append(err, withRule(RulePinnedRefs, pinErr))
+	}
+	return err
+}
+
+// validateTaskStrict runs the pedantic rules of strict mode against the spec of a Task
+func validateTaskStrict(taskSpec v1.TaskSpec) error {
+	var err error
+	if descErr := ValidateTaskDescriptions(taskSpec, "spec"); descErr != nil {
+		err = multierror.Append(err, withRule(RuleDescriptions, descErr))
+	}
+	if unusedErr := ValidateUnusedParams(taskSpec.Params, taskSpec, "spec"); unusedErr != nil {
+		err = multierror.Append(err, withRule(RuleUnusedParams, unusedErr))
+	}
+	return err
+}
@@ -0,0 +1,350 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+
+	"github.com/lcarva/tektor/internal/config"
+)
+
+func TestValidatePipelineDescriptions(t *testing.T) {
+	tests := []struct {
+		name           string
+		pipelineSpec   v1.PipelineSpec
+		expectedErrors []string
+	}{
+		{
+			name: "everything described",
+			pipelineSpec: v1.PipelineSpec{
+				Description: "Builds the image",
+				Params:      []v1.ParamSpec{{Name: "url", Description: "URL of the repository"}},
+				Results:     []v1.PipelineResult{{Name: "digest", Description: "Digest of the image"}},
+				Workspaces:  []v1.PipelineWorkspaceDeclaration{{Name: "source", Description: "Cloned sources"}},
+			},
+		},
+		{
+			name: "nothing described",
+			pipelineSpec: v1.PipelineSpec{
+				Params:     []v1.ParamSpec{{Name: "url"}},
+				Results:    []v1.PipelineResult{{Name: "digest"}},
+				Workspaces: []v1.PipelineWorkspaceDeclaration{{Name: "source"}},
+			},
+			expectedErrors: []string{
+				"Pipeline has no description: spec.description",
+				"url param has no description: spec.params[0]",
+				"digest result has no description: spec.results[0]",
+				"source workspace has no description: spec.workspaces[0]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelineDescriptions(tt.pipelineSpec, "spec")
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidateTaskDescriptions(t *testing.T) {
+	assert.NoError(t, ValidateTaskDescriptions(v1.TaskSpec{
+		Description: "Builds the image",
+		Params:      []v1.ParamSpec{{Name: "url", Description: "URL of the repository"}},
+		Results:     []v1.TaskResult{{Name: "digest", Description: "Digest of the image"}},
+		Workspaces:  []v1.WorkspaceDeclaration{{Name: "source", Description: "Cloned sources"}},
+	}, "spec"))
+
+	err := ValidateTaskDescriptions(v1.TaskSpec{
+		Params:     []v1.ParamSpec{{Name: "url"}},
+		Results:    []v1.TaskResult{{Name: "digest"}},
+		Workspaces: []v1.WorkspaceDeclaration{{Name: "source"}},
+	}, "spec")
+	require.Error(t, err)
+	for _, expectedErr := range []string{
+		"Task has no description: spec.description",
+		"url param has no description: spec.params[0]",
+		"digest result has no description: spec.results[0]",
+		"source workspace has no description: spec.workspaces[0]",
+	} {
+		assert.Contains(t, err.Error(), expectedErr)
+	}
+}
+
+func TestValidatePinnedRefs(t *testing.T) {
+	resolverRef := func(resolver string, params ...string) v1.ResolverRef {
+		ref := v1.ResolverRef{Resolver: v1.ResolverName(resolver)}
+		for i := 0; i < len(params); i += 2 {
+			ref.Params = append(ref.Params, v1.Param{Name: params[i], Value: *v1.NewStructuredValues(params[i+1])})
+		}
+		return ref
+	}
+
+	tests := []struct {
+		name          string
+		ref           v1.ResolverRef
+		expectedError string
+	}{
+		{
+			name: "local task",
+			ref:  v1.ResolverRef{},
+		},
+		{
+			name: "bundle by digest",
+			ref:  resolverRef("bundles", "bundle", "quay.io/example/task-build@sha256:0123456789abcdef", "name", "build"),
+		},
+		{
+			name:          "bundle by tag",
+			ref:           resolverRef("bundles", "bundle", "quay.io/example/task-build:0.1", "name", "build"),
+			expectedError: "build PipelineTask refers to bundle quay.io/example/task-build:0.1 by tag, pin it by digest: spec.tasks[0].taskRef",
+		},
+		{
+			name: "local bundle",
+			ref:  resolverRef("bundles", "bundle", "oci-layout:./bundles/build", "name", "build"),
+		},
+		{
+			name: "bundle substituting a param",
+			ref:  resolverRef("bundles", "bundle", "quay.io/example/task-build:$(params.version)", "name", "build"),
+		},
+		{
+			name: "git commit",
+			ref:  resolverRef("git", "url", "https://github.com/example/tasks.git", "revision", "0123456789abcdef0123456789abcdef01234567"),
+		},
+		{
+			name:          "git branch",
+			ref:           resolverRef("git", "url", "https://github.com/example/tasks.git", "revision", "main"),
+			expectedError: "build PipelineTask refers to revision main of https://github.com/example/tasks.git, pin it to a commit: spec.tasks[0].taskRef",
+		},
+		{
+			name:          "git default branch",
+			ref:           resolverRef("git", "url", "https://github.com/example/tasks.git"),
+			expectedError: "build PipelineTask refers to the default branch of https://github.com/example/tasks.git, pin it to a commit: spec.tasks[0].taskRef",
+		},
+		{
+			name: "hub version",
+			ref:  resolverRef("hub", "name", "git-clone", "version", "0.9"),
+		},
+		{
+			name:          "hub latest version",
+			ref:           resolverRef("hub", "name", "git-clone"),
+			expectedError: "build PipelineTask refers to the latest version of git-clone, pin it to a version: spec.tasks[0].taskRef",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePinnedRefs(v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{{Name: "build", TaskRef: &v1.TaskRef{ResolverRef: tt.ref}}},
+			}, "spec")
+			if tt.expectedError == "" {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+
+	t.Run("finally pipelineRef", func(t *testing.T) {
+		err := ValidatePinnedRefs(v1.PipelineSpec{
+			Finally: []v1.PipelineTask{{Name: "notify", PipelineRef: &v1.PipelineRef{ResolverRef: resolverRef("hub", "name", "notify")}}},
+		}, "spec.pipelineSpec")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "notify PipelineTask refers to the latest version of notify, pin it to a version: spec.pipelineSpec.finally[0].pipelineRef")
+	})
+}
+
+func TestValidateFloatingRefs(t *testing.T) {
+	taskRef := func(resolver string, params ...string) *v1.TaskRef {
+		ref := &v1.TaskRef{ResolverRef: v1.ResolverRef{Resolver: v1.ResolverName(resolver)}}
+		for i := 0; i < len(params); i += 2 {
+			ref.Params = append(ref.Params, v1.Param{Name: params[i], Value: *v1.NewStructuredValues(params[i+1])})
+		}
+		return ref
+	}
+	pipelineSpec := v1.PipelineSpec{
+		Tasks: []v1.PipelineTask{
+			{Name: "digest", TaskRef: taskRef("bundles", "bundle", "quay.io/example/task-build@sha256:0123456789abcdef", "name", "build")},
+			{Name: "tag", TaskRef: taskRef("bundles", "bundle", "quay.io/example/task-build:0.1", "name", "build")},
+			{Name: "release", TaskRef: taskRef("git", "url", "https://github.com/example/tasks.git", "revision", "v1.2.0")},
+			{Name: "main", TaskRef: taskRef("git", "url", "https://github.com/example/tasks.git", "revision", "main")},
+			{Name: "default", TaskRef: taskRef("git", "url", "https://github.com/example/tasks.git")},
+			{Name: "version", TaskRef: taskRef("hub", "name", "git-clone", "version", "0.9")},
+			{Name: "latest", TaskRef: taskRef("hub", "name", "git-clone")},
+			{Name: "local", TaskRef: &v1.TaskRef{Name: "build"}},
+		},
+		Finally: []v1.PipelineTask{
+			{Name: "notify", TaskRef: taskRef("git", "url", "https://github.com/example/tasks.git", "revision", "master")},
+		},
+	}
+
+	err := ValidateFloatingRefs(pipelineSpec, "spec")
+	require.Error(t, err)
+	var messages []string
+	for _, e := range err.(*multierror.Error).Errors {
+		assert.True(t, isWarning(e), "Expected a warning: %v", e)
+		messages = append(messages, e.Error())
+	}
+	assert.Equal(t, []string{
+		"tag PipelineTask refers to bundle quay.io/example/task-build:0.1 by tag, pin it by digest: spec.tasks[1].taskRef",
+		"main PipelineTask refers to revision main of https://github.com/example/tasks.git, pin it to a commit: spec.tasks[3].taskRef",
+		"default PipelineTask refers to the default branch of https://github.com/example/tasks.git, pin it to a commit: spec.tasks[4].taskRef",
+		"latest PipelineTask refers to the latest version of git-clone, pin it to a version: spec.tasks[6].taskRef",
+		"notify PipelineTask refers to revision master of https://github.com/example/tasks.git, pin it to a commit: spec.finally[0].taskRef",
+	}, messages)
+
+	assert.NoError(t, ValidateFloatingRefs(v1.PipelineSpec{Tasks: pipelineSpec.Tasks[:1]}, "spec"))
+}
+
+func TestValidateUnusedParams(t *testing.T) {
+	pipelineSpec := v1.PipelineSpec{
+		Params: []v1.ParamSpec{{Name: "url"}, {Name: "config", Type: v1.ParamTypeObject}, {Name: "unused"}},
+		Tasks: []v1.PipelineTask{{
+			Name: "build",
+			Params: v1.Params{
+				{Name: "url", Value: *v1.NewStructuredValues("$(params.url)")},
+				{Name: "env", Value: *v1.NewStructuredValues("$(params.config.env)")},
+			},
+		}},
+	}
+
+	err := ValidateUnusedParams(pipelineSpec.Params, pipelineSpec, "spec")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 error occurred")
+	assert.Contains(t, err.Error(), "unused param is declared but never referenced: spec.params[2]")
+
+	assert.NoError(t, ValidateUnusedParams(nil, pipelineSpec, "spec"))
+}
+
+func TestValidateWithStrictMode(t *testing.T) {
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: string
+  tasks:
+    - name: build
+      taskSpec:
+        steps:
+          - name: build
+            image: alpine:latest
+            script: echo build
+`)
+	require.NoError(t, err)
+
+	err = ValidatePipeline(context.Background(), p)
+	assert.NoError(t, WithoutWarnings(err), "Expected strict rules to be disabled by default")
+	assert.Equal(t, []string{"url param is declared but never referenced: spec.params[0]"}, Warnings(err))
+
+	ctx := WithOptions(context.Background(), Options{Strict: true})
+	err = ValidatePipeline(ctx, p)
+	require.Error(t, err)
+	assert.Equal(t, []Finding{
+		{Rule: RuleUnusedParams.ID, Severity: SeverityError, Message: "url param is declared but never referenced", ResourcePath: "spec.params[0]"},
+		{Rule: RuleDescriptions.ID, Severity: SeverityError, Message: "Pipeline has no description", ResourcePath: "spec.description"},
+		{Rule: RuleDescriptions.ID, Severity: SeverityError, Message: "url param has no description", ResourcePath: "spec.params[0]"},
+	}, Findings(PromoteWarnings(err)))
+
+	task, err := taskFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  description: Builds the image
+  params:
+    - name: url
+      type: string
+      description: URL of the repository
+  steps:
+    - name: build
+      image: alpine:latest
+      script: echo build
+`)
+	require.NoError(t, err)
+	require.NoError(t, ValidateTaskV1(context.Background(), task))
+	err = ValidateTaskV1(ctx, task)
+	require.Error(t, err)
+	assert.Equal(t, []Finding{
+		{Rule: RuleUnusedParams.ID, Severity: SeverityError, Message: "url param is declared but never referenced", ResourcePath: "spec.params[0]"},
+	}, Findings(err))
+}
+
+func TestValidateWithFloatingRefs(t *testing.T) {
+	p, err := pipelineFromYAML(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  description: Builds the image
+  tasks:
+    - name: build
+      taskRef:
+        resolver: git
+        params:
+          - name: url
+            value: https://github.com/example/tasks.git
+          - name: revision
+            value: main
+          - name: pathInRepo
+            value: build.yaml
+`)
+	require.NoError(t, err)
+	fake := TaskResolverFunc(func(context.Context, v1.TaskRef, []v1.ParamSpec, map[string]string) (*v1.TaskSpec, error) {
+		return &v1.TaskSpec{Steps: []v1.Step{{Name: "build", Image: "alpine:latest", Script: "echo build"}}}, nil
+	})
+
+	// The rule is one of strict mode.
+	assert.NoError(t, ValidatePipeline(WithOptions(context.Background(), Options{TaskResolver: fake}), p))
+
+	ctx := WithOptions(context.Background(), Options{TaskResolver: fake, Strict: true})
+	err = ValidatePipeline(ctx, p)
+	assert.Equal(t, []Finding{
+		{Rule: RuleFloatingRefs.ID, Severity: SeverityWarning, Message: "build PipelineTask refers to revision main of https://github.com/example/tasks.git, pin it to a commit", ResourcePath: "spec.tasks[0].taskRef"},
+	}, Findings(err))
+	assert.Equal(t, []Finding{
+		{Rule: RuleFloatingRefs.ID, Severity: SeverityError, Message: "build PipelineTask refers to revision main of https://github.com/example/tasks.git, pin it to a commit", ResourcePath: "spec.tasks[0].taskRef"},
+	}, Findings(OverrideRules(err, map[string]config.RuleSetting{"floating-refs": config.SeverityError})))
+
+	// Other unpinned references are reported by the pinned-refs rule.
+	p.Spec.Tasks[0].TaskRef.Params[1].Value = *v1.NewStructuredValues("v1.2.0")
+	assert.Equal(t, []Finding{
+		{Rule: RulePinnedRefs.ID, Severity: SeverityError, Message: "build PipelineTask refers to revision v1.2.0 of https://github.com/example/tasks.git, pin it to a commit", ResourcePath: "spec.tasks[0].taskRef"},
+	}, Findings(ValidatePipeline(ctx, p)))
+
+	t.Run("runs", func(t *testing.T) {
+		hub := v1.ResolverRef{Resolver: "hub", Params: v1.Params{{Name: "name", Value: *v1.NewStructuredValues("git-clone")}}}
+		assert.Equal(t, []Finding{
+			{Rule: RuleFloatingRefs.ID, Severity: SeverityWarning, Message: "TaskRun refers to the latest version of git-clone, pin it to a version", ResourcePath: "spec.taskRef"},
+		}, Findings(validateRunRef("TaskRun", hub, "spec.taskRef")))
+
+		bundle := v1.ResolverRef{Resolver: "bundles", Params: v1.Params{{Name: "bundle", Value: *v1.NewStructuredValues("quay.io/example/pipeline-build:0.1")}}}
+		assert.Equal(t, []Finding{
+			{Rule: RuleFloatingRefs.ID, Severity: SeverityWarning, Message: "PipelineRun refers to bundle quay.io/example/pipeline-build:0.1 by tag, pin it by digest", ResourcePath: "spec.pipelineRef"},
+		}, Findings(validateRunRef("PipelineRun", bundle, "spec.pipelineRef")))
+
+		git := v1.ResolverRef{Resolver: "git", Params: v1.Params{
+			{Name: "url", Value: *v1.NewStructuredValues("https://github.com/example/tasks.git")},
+			{Name: "revision", Value: *v1.NewStructuredValues("v1.2.0")},
+		}}
+		assert.Equal(t, []Finding{
+			{Rule: RulePinnedRefs.ID, Severity: SeverityError, Message: "TaskRun refers to revision v1.2.0 of https://github.com/example/tasks.git, pin it to a commit", ResourcePath: "spec.taskRef"},
+		}, Findings(validateRunRef("TaskRun", git, "spec.taskRef")))
+	})
+}
@@ -3,53 +3,409 @@ package validator
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
 	"github.com/tektoncd/pipeline/pkg/apis/pipeline/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 )
 
 func ValidateTaskV1(ctx context.Context, t v1.Task) error {
-	if err := t.Validate(ctx); err != nil {
-		var allErrors error
-		for _, e := range err.WrappedErrors() {
-			details := e.Details
-			if len(details) > 0 {
-				details = " " + details
+	ctx = withParamEnums(ctx)
+	var allErrors error
+	ctx, err := withSuppressedRules(ctx, t.Annotations)
+	if err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := schemaErrors(t.Validate(ctx), isTaskSchemaErrorReported); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := ValidateObjectMetadata(t.ObjectMeta); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleMetadata, err))
+	}
+
+	if err := validateTaskSpec(ctx, t.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := ValidateStepReferences(t.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := ValidateIndexedParameterReferences(t.Spec, nil, "spec"); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleParams, err))
+	}
+	if err := ValidateStepWorkspaceReferences(t.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleWorkspaces, fmt.Errorf("workspace validation: %w", err)))
+	}
+	if optionsFromContext(ctx).Strict {
+		if err := validateTaskStrict(t.Spec); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+	if err := lintTask(ctx, t.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+
+	return allErrors
+}
+
+func ValidateTaskV1Beta1(ctx context.Context, t v1beta1.Task) error {
+	ctx = withParamEnums(ctx)
+	var allErrors error
+	ctx, err := withSuppressedRules(ctx, t.Annotations)
+	if err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := schemaErrors(t.Validate(ctx), isTaskSchemaErrorReported); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := ValidateObjectMetadata(t.ObjectMeta); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleMetadata, err))
+	}
+
+	var converted v1.Task
+	if err := t.ConvertTo(ctx, &converted); err != nil {
+		return multierror.Append(allErrors, fmt.Errorf("converting Task to %s: %w", v1.SchemeGroupVersion, err))
+	}
+	if err := validateTaskSpec(ctx, converted.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := ValidateStepReferences(converted.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := ValidateIndexedParameterReferences(converted.Spec, nil, "spec"); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleParams, err))
+	}
+	if err := ValidateStepWorkspaceReferences(converted.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleWorkspaces, fmt.Errorf("workspace validation: %w", err)))
+	}
+	if optionsFromContext(ctx).Strict {
+		if err := validateTaskStrict(converted.Spec); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+	if err := lintTask(ctx, converted.Spec); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+
+	return allErrors
+}
+
+// lintTask runs the lint profile, if enabled, against the spec of a standalone Task. Missing
+// descriptions are left to strict mode when it is enabled too, which reports them as errors.
+func lintTask(ctx context.Context, taskSpec v1.TaskSpec) error {
+	if !hasProfile(ctx, ProfileLint) {
+		return nil
+	}
+	suppressed := suppressedRulesFromContext(ctx)
+	if optionsFromContext(ctx).Strict {
+		suppressed = append(slices.Clone(suppressed), RuleMissingDescription)
+	}
+	if err := ValidateTaskBestPractices(taskSpec, suppressed); err != nil {
+		return fmt.Errorf("lint profile: %w", err)
+	}
+	return nil
+}
+
+// isTaskSchemaErrorReported tells whether the schema error of a Task with message at path is
+// reported by the checks of tektor instead: names by ValidateObjectMetadata, and whole params
+// expanded by steps by ValidateStarParameterReferences
+func isTaskSchemaErrorReported(message, path string) bool {
+	return isObjectNameError(message, path) || isStarParameterSchemaError(message, path)
+}
+
+// validateTaskSpec runs the checks tektor performs on top of the upstream Tekton validation. It
+// applies to standalone Tasks as well as to the Tasks used by PipelineTasks.
+func validateTaskSpec(ctx context.Context, taskSpec v1.TaskSpec) error {
+	var err error
+
+	if duplicateErr := ValidateUniqueNames(taskSpec); duplicateErr != nil {
+		err = multierror.Append(err, withRule(RuleSteps, duplicateErr))
+	}
+
+	if stepResultErr := ValidateStepResultReferences(taskSpec); stepResultErr != nil {
+		err = multierror.Append(err, withRule(RuleResults, stepResultErr))
+	}
+
+	if outputErr := ValidateStepOutputs(taskSpec); outputErr != nil {
+		err = multierror.Append(err, withRule(RuleSteps, outputErr))
+	}
+
+	if overrideErr := ValidateStepTemplateOverrides(taskSpec); overrideErr != nil {
+		err = multierror.Append(err, withRule(RuleSteps, overrideErr))
+	}
+
+	if starErr := ValidateStarParameterReferences(taskSpec, "spec"); starErr != nil {
+		err = multierror.Append(err, withRule(RuleParams, starErr))
+	}
+
+	// The volumes of the pod templates are only known for the Tasks of a run.
+	_, inRun := ctx.Value(propagationKey{}).(propagation)
+	if volumeErr := ValidateVolumeMounts(taskSpec, propagationFromContext(ctx).volumes, inRun); volumeErr != nil {
+		err = multierror.Append(err, withRule(RuleVolumes, volumeErr))
+	}
+
+	if readOnlyErr := ValidateReadOnlyWorkspaces(taskSpec); readOnlyErr != nil {
+		err = multierror.Append(err, withRule(RuleWorkspaces, fmt.Errorf("workspace validation: %w", readOnlyErr)))
+	}
+
+	if unusedErr := ValidateUnusedTaskWorkspaces(taskSpec); unusedErr != nil {
+		err = multierror.Append(err, fmt.Errorf("workspace validation: %w", unusedErr))
+	}
+
+	if hasProfile(ctx, ProfileSecurity) {
+		if securityErr := ValidateStepSecurity(taskSpec, suppressedRulesFromContext(ctx)); securityErr != nil {
+			err = multierror.Append(err, fmt.Errorf("security profile: %w", securityErr))
+		}
+	}
+
+	if optionsFromContext(ctx).CheckImages {
+		if imageErr := ValidateStepImages(ctx, taskSpec); imageErr != nil {
+			err = multierror.Append(err, withRule(RuleStepImages, fmt.Errorf("image validation: %w", imageErr)))
+		}
+	}
+
+	if objectErr := validateClusterObjects(ctx, "", stepObjectRefs(taskSpec, "spec")); objectErr != nil {
+		err = multierror.Append(err, withRule(RuleClusterObjects, objectErr))
+	}
+
+	return err
+}
+
+// ValidateUniqueNames verifies the names which must be unique within a Task, but which the upstream
+// Tekton validation does not check: results, step results, and sidecars.
+func ValidateUniqueNames(taskSpec v1.TaskSpec) error {
+	var err error
+
+	results := map[string]bool{}
+	for _, result := range taskSpec.Results {
+		if results[result.Name] {
+			err = multierror.Append(err, fmt.Errorf("result appears more than once: spec.results[%s]", result.Name))
+		}
+		results[result.Name] = true
+	}
+
+	for i, step := range taskSpec.Steps {
+		stepResults := map[string]bool{}
+		for _, result := range step.Results {
+			if stepResults[result.Name] {
+				err = multierror.Append(err, fmt.Errorf("step result appears more than once: spec.steps[%d].results[%s]", i, result.Name))
+			}
+			stepResults[result.Name] = true
+		}
+	}
+
+	sidecars := map[string]bool{}
+	for i, sidecar := range taskSpec.Sidecars {
+		if sidecar.Name == "" {
+			continue
+		}
+		if sidecars[sidecar.Name] {
+			err = multierror.Append(err, fmt.Errorf("sidecar name appears more than once: %s: spec.sidecars[%d].name", sidecar.Name, i))
+		}
+		sidecars[sidecar.Name] = true
+	}
+
+	return err
+}
+
+// ValidateStepOutputs verifies the files the steps of a Task write their output streams to with
+// stdoutConfig and stderrConfig. Each path must be set, and must not be used by another stream
+// since the streams would overwrite each other.
+func ValidateStepOutputs(taskSpec v1.TaskSpec) error {
+	var err error
+
+	used := make(map[string]string)
+	for i, step := range taskSpec.Steps {
+		streams := []struct {
+			field  string
+			config *v1.StepOutputConfig
+		}{
+			{"stdoutConfig", step.StdoutConfig},
+			{"stderrConfig", step.StderrConfig},
+		}
+		for _, stream := range streams {
+			if stream.config == nil {
+				continue
 			}
-			message := strings.TrimSuffix(e.Message, ": ")
-			for _, p := range e.Paths {
-				allErrors = multierror.Append(allErrors, fmt.Errorf("%v: %v%v", message, p, details))
+			field := fmt.Sprintf("spec.steps[%d].%s", i, stream.field)
+			path := strings.TrimSpace(stream.config.Path)
+			if path == "" {
+				err = multierror.Append(err, fmt.Errorf("missing field(s): %s.path", field))
+				continue
 			}
-			if len(e.Paths) == 0 {
-				allErrors = multierror.Append(allErrors, fmt.Errorf("%v: %v", message, details))
+			if previous, found := used[path]; found {
+				err = multierror.Append(err, fmt.Errorf(
+					"output path %q is already used by %s: %s.path", path, previous, field))
+				continue
 			}
+			used[path] = field
 		}
-		return allErrors
 	}
 
-	return nil
+	return err
 }
 
-func ValidateTaskV1Beta1(ctx context.Context, t v1beta1.Task) error {
-	if err := t.Validate(ctx); err != nil {
-		var allErrors error
-		for _, e := range err.WrappedErrors() {
-			details := e.Details
-			if len(details) > 0 {
-				details = " " + details
+// ValidateStepOnError verifies the onError values of the steps of a Task. The upstream Tekton
+// validation checks them too, but not for the Tasks tektor resolves from references.
+func ValidateStepOnError(taskSpec v1.TaskSpec) error {
+	var err error
+	for i, step := range taskSpec.Steps {
+		switch step.OnError {
+		case "", v1.Continue, v1.StopAndFail:
+		default:
+			err = multierror.Append(err, fmt.Errorf(
+				"invalid value: %q: spec.steps[%d].onError Task step onError must be either %q or %q",
+				step.OnError, i, v1.Continue, v1.StopAndFail))
+		}
+	}
+	return err
+}
+
+// ValidateStepTemplateOverrides warns about the steps of a Task which override what its stepTemplate
+// sets with a different value: env variables of the same name, the workingDir, and the quantities of
+// computeResources. Tekton merges the stepTemplate into each step, the step winning, so the
+// stepTemplate silently does not apply to such steps.
+func ValidateStepTemplateOverrides(taskSpec v1.TaskSpec) error {
+	template := taskSpec.StepTemplate
+	if template == nil {
+		return nil
+	}
+
+	templateEnv := make(map[string]int)
+	for i, env := range template.Env {
+		templateEnv[env.Name] = i
+	}
+
+	var err error
+	for i, step := range taskSpec.Steps {
+		stepPath := fmt.Sprintf("spec.steps[%d]", i)
+		for j, env := range step.Env {
+			k, found := templateEnv[env.Name]
+			if found && !equality.Semantic.DeepEqual(env, template.Env[k]) {
+				err = multierror.Append(err, warningf(
+					"env %q overrides the value set by spec.stepTemplate.env[%d]: %s.env[%d]", env.Name, k, stepPath, j))
 			}
-			message := strings.TrimSuffix(e.Message, ": ")
-			for _, p := range e.Paths {
-				allErrors = multierror.Append(allErrors, fmt.Errorf("%v: %v%v", message, p, details))
+		}
+		if step.WorkingDir != "" && template.WorkingDir != "" && step.WorkingDir != template.WorkingDir {
+			err = multierror.Append(err, warningf(
+				"workingDir %q overrides %q set by spec.stepTemplate.workingDir: %s.workingDir", step.WorkingDir, template.WorkingDir, stepPath))
+		}
+		resources := []struct {
+			field              string
+			step, stepTemplate corev1.ResourceList
+		}{
+			{"requests", step.ComputeResources.Requests, template.ComputeResources.Requests},
+			{"limits", step.ComputeResources.Limits, template.ComputeResources.Limits},
+		}
+		for _, resource := range resources {
+			for _, name := range SortedKeys(resource.step) {
+				quantity := resource.step[name]
+				if templateQuantity, found := resource.stepTemplate[name]; found && quantity.Cmp(templateQuantity) != 0 {
+					field := fmt.Sprintf("computeResources.%s.%s", resource.field, name)
+					err = multierror.Append(err, warningf(
+						"%s of %s overrides %s set by spec.stepTemplate.%s: %s.%s",
+						field, quantity.String(), templateQuantity.String(), field, stepPath, field))
+				}
 			}
-			if len(e.Paths) == 0 {
-				allErrors = multierror.Append(allErrors, fmt.Errorf("%v: %v", message, details))
+		}
+	}
+	return err
+}
+
+// taskResultRefRegex matches references to the result files of a Task, e.g. $(results.digest.path)
+var taskResultRefRegex = regexp.MustCompile(`\$\(results\.([^.)]+)\.path\)`)
+
+// containerField is a string field of a step or sidecar, along with its path
+type containerField struct {
+	path  string
+	value string
+}
+
+// containerFields lists the fields of a step or sidecar which may hold references, one per
+// element of command, args, and env so that errors point at the offending element
+func containerFields(path, image string, command, args []string, env []corev1.EnvVar, script, workingDir string) []containerField {
+	fields := []containerField{{path: path + ".image", value: image}}
+	for i, value := range command {
+		fields = append(fields, containerField{path: fmt.Sprintf("%s.command[%d]", path, i), value: value})
+	}
+	for i, value := range args {
+		fields = append(fields, containerField{path: fmt.Sprintf("%s.args[%d]", path, i), value: value})
+	}
+	for _, envVar := range env {
+		fields = append(fields, containerField{path: fmt.Sprintf("%s.env[%s]", path, envVar.Name), value: envVar.Value})
+	}
+	return append(fields,
+		containerField{path: path + ".script", value: script},
+		containerField{path: path + ".workingDir", value: workingDir})
+}
+
+// taskContainerFields lists the fields of the steps and of the sidecars of a task spec which may
+// hold references, with paths starting at path, the path of the task spec
+func taskContainerFields(taskSpec v1.TaskSpec, path string) (stepFields, sidecarFields []containerField) {
+	for i, step := range taskSpec.Steps {
+		stepFields = append(stepFields, containerFields(fmt.Sprintf("%s.steps[%d]", path, i),
+			step.Image, step.Command, step.Args, step.Env, step.Script, step.WorkingDir)...)
+	}
+	for i, sidecar := range taskSpec.Sidecars {
+		sidecarFields = append(sidecarFields, containerFields(fmt.Sprintf("%s.sidecars[%d]", path, i),
+			sidecar.Image, sidecar.Command, sidecar.Args, sidecar.Env, sidecar.Script, sidecar.WorkingDir)...)
+	}
+	return stepFields, sidecarFields
+}
+
+// ValidateStepReferences verifies the parameter and result references of the steps and sidecars of
+// a standalone Task. The upstream Tekton validation checks the parameters used by steps, but
+// neither those used by sidecars nor the results whose files are written.
+func ValidateStepReferences(taskSpec v1.TaskSpec) error {
+	var err error
+
+	params := make(map[string]bool)
+	for _, param := range taskSpec.Params {
+		params[param.Name] = true
+	}
+
+	_, sidecarFields := taskContainerFields(taskSpec, "spec")
+
+	// Parameters used by steps are already reported upstream.
+	for _, field := range sidecarFields {
+		for _, match := range paramRefRegex.FindAllStringSubmatch(field.value, -1) {
+			if !params[paramRefName(strings.TrimSpace(match[1]))] {
+				err = multierror.Append(err, withRule(RuleParamReferences, withPath(field.path, fmt.Errorf("non-existent variable in %q", match[0]))))
 			}
 		}
-		return allErrors
 	}
 
-	return nil
+	if resultErr := ValidateResultPathReferences(taskSpec); resultErr != nil {
+		err = multierror.Append(err, resultErr)
+	}
+
+	return err
+}
+
+// ValidateResultPathReferences verifies the $(results.<name>.path) references of the steps and
+// sidecars of a Task refer to results the Task declares, since whatever is written to the file of
+// an undeclared result is lost. The upstream Tekton validation checks the references of the Tasks
+// it validates, but not those of the Tasks resolved from references.
+func ValidateResultPathReferences(taskSpec v1.TaskSpec) error {
+	var err error
+
+	results := make(map[string]bool)
+	for _, result := range taskSpec.Results {
+		results[result.Name] = true
+	}
+
+	stepFields, sidecarFields := taskContainerFields(taskSpec, "spec")
+	for _, field := range append(stepFields, sidecarFields...) {
+		for _, match := range taskResultRefRegex.FindAllStringSubmatch(field.value, -1) {
+			if !results[match[1]] {
+				err = multierror.Append(err, withRule(RuleResults, withPath(field.path, fmt.Errorf("non-existent result in %q", match[0]))))
+			}
+		}
+	}
+
+	return err
 }
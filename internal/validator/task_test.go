@@ -2,6 +2,7 @@ package validator
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -261,8 +262,8 @@ spec:
       image: alpine:latest
       script: echo 'Test'
 `,
-			expectedError: false, // Tekton validation doesn't catch this
-			errorContains: "",
+			expectedError: true, // Tekton validation doesn't catch this, tektor does
+			errorContains: "result appears more than once: spec.results[result1]",
 		},
 		{
 			name: "task with duplicate workspace names",
@@ -333,7 +334,8 @@ spec:
 					assert.Contains(t, err.Error(), tt.errorContains, "Expected error message to contain: %s", tt.errorContains)
 				}
 			} else {
-				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				// Warnings, e.g. about the workspaces the fixtures leave unused, do not fail validation.
+				assert.NoError(t, WithoutWarnings(err), "Expected no error for test case: %s", tt.name)
 			}
 		})
 	}
@@ -550,8 +552,8 @@ spec:
       image: alpine:latest
       script: echo 'Hello World'
 `,
-			expectedError: false, // Tekton validation doesn't catch this
-			errorContains: "",
+			expectedError: true, // Tekton validation doesn't catch this, tektor does
+			errorContains: "result appears more than once: spec.results[commit]",
 		},
 		{
 			name: "v1beta1 task with invalid workspace name",
@@ -605,7 +607,8 @@ spec:
 					assert.Contains(t, err.Error(), tt.errorContains, "Expected error message to contain: %s", tt.errorContains)
 				}
 			} else {
-				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				// Warnings, e.g. about the workspaces the fixtures leave unused, do not fail validation.
+				assert.NoError(t, WithoutWarnings(err), "Expected no error for test case: %s", tt.name)
 			}
 		})
 	}
@@ -735,8 +738,371 @@ spec:
 			if tt.expectedError {
 				require.Error(t, err, "Expected error for test case: %s", tt.name)
 			} else {
-				assert.NoError(t, err, "Expected no error for test case: %s - %s", tt.name, tt.description)
+				// Warnings, e.g. about the workspaces the fixtures leave unused, do not fail validation.
+				assert.NoError(t, WithoutWarnings(err), "Expected no error for test case: %s - %s", tt.name, tt.description)
+			}
+		})
+	}
+}
+
+func TestValidateUniqueNames(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskSpecYAML   string
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "unique names",
+			taskSpecYAML: `
+results:
+  - name: digest
+  - name: url
+steps:
+  - name: build
+    image: alpine:latest
+    results:
+      - name: digest
+  - name: push
+    image: alpine:latest
+    results:
+      - name: digest
+sidecars:
+  - name: registry
+    image: registry:2
+  - name: docker
+    image: docker:dind
+`,
+			expectNoError: true,
+		},
+		{
+			name: "duplicate names",
+			taskSpecYAML: `
+results:
+  - name: digest
+  - name: digest
+steps:
+  - name: build
+    image: alpine:latest
+    results:
+      - name: digest
+      - name: digest
+sidecars:
+  - name: registry
+    image: registry:2
+  - name: registry
+    image: registry:2
+`,
+			expectedErrors: []string{
+				"result appears more than once: spec.results[digest]",
+				"step result appears more than once: spec.steps[0].results[digest]",
+				"sidecar name appears more than once: registry: spec.sidecars[1].name",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateUniqueNames(taskSpec)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateStepReferences(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskSpecYAML   string
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "known references",
+			taskSpecYAML: `
+params:
+  - name: port
+  - name: config
+    type: object
+    properties:
+      path: {}
+results:
+  - name: digest
+steps:
+  - name: build
+    image: alpine:latest
+    args: ["--digest-file", "$(results.digest.path)"]
+sidecars:
+  - name: registry
+    image: registry:2
+    args: ["--port", "$(params.port)", "$(params.config.path)"]
+    env:
+      - name: PORT
+        value: $(params.port)
+`,
+			expectNoError: true,
+		},
+		{
+			name: "unknown references",
+			taskSpecYAML: `
+params:
+  - name: port
+results:
+  - name: digest
+steps:
+  - name: build
+    image: alpine:latest
+    command: ["build"]
+    args: ["--digest-file", "$(results.digest.path)", "--url-file", "$(results.url.path)"]
+sidecars:
+  - name: registry
+    image: registry:2
+    command: ["registry", "$(params.config)"]
+    args: ["--port", "$(params.port)", "--host", "$(params.host)"]
+    env:
+      - name: TLS
+        value: $(params.tls)
+    script: echo $(results.url.path)
+`,
+			expectedErrors: []string{
+				`non-existent variable in "$(params.config)": spec.sidecars[0].command[1]`,
+				`non-existent variable in "$(params.host)": spec.sidecars[0].args[3]`,
+				`non-existent variable in "$(params.tls)": spec.sidecars[0].env[TLS]`,
+				`non-existent result in "$(results.url.path)": spec.steps[0].args[3]`,
+				`non-existent result in "$(results.url.path)": spec.sidecars[0].script`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateStepReferences(taskSpec)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+				assert.Equal(t, len(tt.expectedErrors), strings.Count(errStr, "non-existent"))
+			}
+		})
+	}
+}
+
+func TestValidateStepOutputs(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskSpecYAML   string
+		expectedErrors []string
+		expectNoError  bool
+	}{
+		{
+			name: "distinct output paths",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: alpine:latest
+    stdoutConfig:
+      path: /data/build.out
+    stderrConfig:
+      path: /data/build.err
+  - name: test
+    image: alpine:latest
+    stdoutConfig:
+      path: $(results.report.path)
+  - name: push
+    image: alpine:latest
+`,
+			expectNoError: true,
+		},
+		{
+			name: "missing and colliding output paths",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: alpine:latest
+    stdoutConfig:
+      path: /data/build.out
+    stderrConfig:
+      path: /data/build.out
+  - name: test
+    image: alpine:latest
+    stdoutConfig:
+      path: " "
+    stderrConfig: {}
+  - name: push
+    image: alpine:latest
+    stdoutConfig:
+      path: /data/build.out
+`,
+			expectedErrors: []string{
+				`output path "/data/build.out" is already used by spec.steps[0].stdoutConfig: spec.steps[0].stderrConfig.path`,
+				"missing field(s): spec.steps[1].stdoutConfig.path",
+				"missing field(s): spec.steps[1].stderrConfig.path",
+				`output path "/data/build.out" is already used by spec.steps[0].stdoutConfig: spec.steps[2].stdoutConfig.path`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateStepOutputs(taskSpec)
+
+			if tt.expectNoError {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			} else {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				errStr := err.Error()
+				for _, expectedErr := range tt.expectedErrors {
+					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
 			}
 		})
 	}
 }
+
+func TestValidateStepOnError(t *testing.T) {
+	taskSpec, err := taskSpecFromYAML(`
+steps:
+  - name: build
+    image: alpine:latest
+    onError: continue
+  - name: test
+    image: alpine:latest
+    onError: stopAndFail
+  - name: push
+    image: alpine:latest
+  - name: notify
+    image: alpine:latest
+    onError: ignore
+`)
+	require.NoError(t, err)
+
+	err = ValidateStepOnError(taskSpec)
+	require.Error(t, err)
+	assert.Equal(t, 1, strings.Count(err.Error(), "invalid value"))
+	assert.Contains(t, err.Error(), `invalid value: "ignore": spec.steps[3].onError Task step onError must be either "continue" or "stopAndFail"`)
+}
+
+func TestValidateStepTemplateOverrides(t *testing.T) {
+	tests := []struct {
+		name         string
+		taskSpecYAML string
+		expected     []string
+	}{
+		{
+			name: "no stepTemplate",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: alpine:latest
+    workingDir: /src
+`,
+		},
+		{
+			name: "steps adding to the stepTemplate",
+			taskSpecYAML: `
+stepTemplate:
+  workingDir: /src
+  env:
+    - name: HOME
+      value: /tekton/home
+  computeResources:
+    limits:
+      memory: 1Gi
+steps:
+  - name: build
+    image: alpine:latest
+    workingDir: /src
+    env:
+      - name: HOME
+        value: /tekton/home
+      - name: GOFLAGS
+        value: -mod=vendor
+    computeResources:
+      limits:
+        memory: 1024Mi
+        cpu: "2"
+      requests:
+        memory: 512Mi
+`,
+		},
+		{
+			name: "steps overriding the stepTemplate",
+			taskSpecYAML: `
+stepTemplate:
+  workingDir: /src
+  env:
+    - name: HOME
+      value: /tekton/home
+    - name: TOKEN
+      valueFrom:
+        secretKeyRef:
+          name: token
+          key: token
+  computeResources:
+    requests:
+      cpu: 500m
+    limits:
+      memory: 1Gi
+steps:
+  - name: build
+    image: alpine:latest
+    workingDir: /workspace
+    env:
+      - name: TOKEN
+        valueFrom:
+          secretKeyRef:
+            name: other-token
+            key: token
+      - name: HOME
+        value: /root
+  - name: test
+    image: alpine:latest
+    computeResources:
+      requests:
+        cpu: "1"
+      limits:
+        memory: 1Gi
+`,
+			expected: []string{
+				`env "TOKEN" overrides the value set by spec.stepTemplate.env[1]: spec.steps[0].env[0]`,
+				`env "HOME" overrides the value set by spec.stepTemplate.env[0]: spec.steps[0].env[1]`,
+				`workingDir "/workspace" overrides "/src" set by spec.stepTemplate.workingDir: spec.steps[0].workingDir`,
+				`computeResources.requests.cpu of 1 overrides 500m set by spec.stepTemplate.computeResources.requests.cpu: spec.steps[1].computeResources.requests.cpu`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateStepTemplateOverrides(taskSpec)
+
+			assert.NoError(t, WithoutWarnings(err))
+			assert.Equal(t, tt.expected, Warnings(err))
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func ValidateTaskRun(ctx context.Context, tr v1.TaskRun) error {
+	ctx = withParamEnums(ctx)
+	var allErrors error
+	ctx, err := withSuppressedRules(ctx, tr.Annotations)
+	if err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	// The volume sources of workspace bindings are reported by ValidateWorkspaceBindingVolumes.
+	skip := func(message, path string) bool {
+		return isTaskSchemaErrorReported(message, path) || isWorkspaceBindingSourceError(message, path)
+	}
+	if err := schemaErrors(tr.Validate(ctx), skip); err != nil {
+		allErrors = multierror.Append(allErrors, err)
+	}
+	if err := ValidateObjectMetadata(tr.ObjectMeta); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleMetadata, err))
+	}
+
+	if err := ValidateWorkspaceBindingVolumes(tr.Spec.Workspaces, "spec"); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleWorkspaces, fmt.Errorf("TaskRun workspaces: %w", err)))
+	}
+
+	ctx = withClusterNamespace(ctx, tr.Namespace)
+	if err := validateClusterObjects(ctx, tr.Namespace, taskRunObjectRefs(tr.Spec)); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleClusterObjects, err))
+	}
+
+	if ref := tr.Spec.TaskRef; ref != nil && optionsFromContext(ctx).Strict {
+		if err := validateRunRef("TaskRun", ref.ResolverRef, "spec.taskRef"); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+	}
+
+	var taskSpec *v1.TaskSpec
+	if tr.Spec.TaskSpec != nil {
+		taskSpec = tr.Spec.TaskSpec
+	} else if ref := tr.Spec.TaskRef; ref != nil && ref.Resolver == "" && ref.Name != "" {
+		if index := optionsFromContext(ctx).TaskIndex; index != nil {
+			entry, err := index.Lookup(string(ref.Kind), ref.Name)
+			if err != nil {
+				allErrors = multierror.Append(allErrors, withRule(RuleTaskResolution, err))
+			} else {
+				taskSpec = &entry.Spec
+				if err := ValidateStepOnError(*taskSpec); err != nil {
+					allErrors = multierror.Append(allErrors, withRule(RuleSteps, err))
+				}
+				if err := ValidateResultPathReferences(*taskSpec); err != nil {
+					allErrors = multierror.Append(allErrors, err)
+				}
+			}
+		}
+	}
+
+	if taskSpec != nil {
+		// The volumes of the pod template can be mounted by the steps of the Task.
+		taskCtx := context.WithValue(ctx, propagationKey{}, propagation{volumes: podTemplateVolumes(tr.Spec.PodTemplate)})
+		if err := validateTaskSpec(taskCtx, *taskSpec); err != nil {
+			allErrors = multierror.Append(allErrors, err)
+		}
+		if err := ValidateIndexedParameterReferences(*taskSpec, tr.Spec.Params, "spec"); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleParams, fmt.Errorf("TaskRun params: %w", err)))
+		}
+		if err := ValidateParameters(tr.Spec.Params, taskSpec.Params); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleParams, fmt.Errorf("TaskRun params: %w", err)))
+		}
+		if err := ValidateParamEnums(tr.Spec.Params, taskSpec.Params); err != nil {
+			allErrors = multierror.Append(allErrors, withRule(RuleParamEnums, fmt.Errorf("TaskRun params: %w", err)))
+		}
+	}
+
+	if err := ValidateTaskRunDebug(tr.Spec.Debug, taskSpec); err != nil {
+		allErrors = multierror.Append(allErrors, withRule(RuleDebug, err))
+	}
+
+	return allErrors
+}
+
+// ValidateTaskRunDebug reports the breakpoints of a TaskRun. They pause the TaskRun until someone
+// resumes it manually, so they are most likely debug settings committed by accident and are
+// reported as warnings. Breakpoints before steps the Task does not have are errors.
+func ValidateTaskRunDebug(debug *v1.TaskRunDebug, taskSpec *v1.TaskSpec) error {
+	if debug == nil || debug.Breakpoints == nil {
+		return nil
+	}
+	var err error
+
+	if debug.Breakpoints.OnFailure == v1.EnabledOnFailureBreakpoint {
+		err = multierror.Append(err, warningf(
+			"spec.debug.breakpoints.onFailure is enabled, failing steps wait for manual intervention, which blocks automation"))
+	}
+
+	if len(debug.Breakpoints.BeforeSteps) > 0 {
+		err = multierror.Append(err, warningf(
+			"spec.debug.breakpoints.beforeSteps pauses the TaskRun before steps [%s] until resumed manually, which blocks automation",
+			strings.Join(debug.Breakpoints.BeforeSteps, ", ")))
+	}
+
+	if taskSpec != nil {
+		steps := make(map[string]bool)
+		for _, step := range taskSpec.Steps {
+			steps[step.Name] = true
+		}
+		for i, name := range debug.Breakpoints.BeforeSteps {
+			if !steps[name] {
+				err = multierror.Append(err, fmt.Errorf(
+					"breakpoint before step %q which does not exist: spec.debug.breakpoints.beforeSteps[%d]", name, i))
+			}
+		}
+	}
+
+	return err
+}
+
+// ValidatePipelineRunDebug reports debug settings in the raw YAML of a PipelineRun. PipelineRuns do
+// not support them, so Tekton drops them, but their presence suggests a TaskRun debug session was
+// committed by accident.
+func ValidatePipelineRunDebug(rawYAML []byte) error {
+	var raw struct {
+		Spec struct {
+			Debug        any `json:"debug"`
+			TaskRunSpecs []struct {
+				PipelineTaskName string `json:"pipelineTaskName"`
+				Debug            any    `json:"debug"`
+			} `json:"taskRunSpecs"`
+		} `json:"spec"`
+	}
+	if yaml.Unmarshal(rawYAML, &raw) != nil {
+		// A spec whose taskRunSpecs are not a list fails decoding the PipelineRun instead.
+		return nil
+	}
+
+	var err error
+	if raw.Spec.Debug != nil {
+		err = multierror.Append(err, warningf(
+			"spec.debug is not supported by PipelineRuns and is ignored, remove the committed debug settings"))
+	}
+	for i, spec := range raw.Spec.TaskRunSpecs {
+		if spec.Debug != nil {
+			err = multierror.Append(err, warningf(
+				"spec.taskRunSpecs[%d].debug is not supported by PipelineRuns and is ignored, remove the debug settings committed for PipelineTask %q",
+				i, spec.PipelineTaskName))
+		}
+	}
+	return err
+}
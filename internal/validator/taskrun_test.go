@@ -0,0 +1,289 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/taskindex"
+)
+
+func TestValidateTaskRun(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		taskRunYAML   string
+		expectedError bool
+		errorContains []string
+	}{
+		{
+			name: "valid taskrun with embedded task",
+			taskRunYAML: `
+apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  name: hello
+spec:
+  params:
+    - name: message
+      value: hello
+  taskSpec:
+    params:
+      - name: message
+        type: string
+    steps:
+      - name: hello
+        image: alpine:latest
+        script: echo $(params.message)
+`,
+		},
+		{
+			name: "taskrun with unknown and missing params",
+			taskRunYAML: `
+apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  name: hello
+spec:
+  params:
+    - name: greeting
+      value: hello
+  taskSpec:
+    params:
+      - name: message
+        type: string
+    steps:
+      - name: hello
+        image: alpine:latest
+        script: echo $(params.message)
+`,
+			expectedError: true,
+			errorContains: []string{
+				`TaskRun params: 2 errors occurred`,
+				`"greeting" parameter is not defined by the Task`,
+				`"message" parameter is required`,
+			},
+		},
+		{
+			name: "taskrun with breakpoints",
+			taskRunYAML: `
+apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  name: hello
+spec:
+  debug:
+    breakpoints:
+      beforeSteps: [hello]
+  taskSpec:
+    steps:
+      - name: hello
+        image: alpine:latest
+        script: echo hello
+`,
+			expectedError: true,
+			errorContains: []string{
+				`debug requires "enable-api-fields" feature gate to be "alpha"`,
+			},
+		},
+		{
+			name: "taskrun binding a workspace to two volume sources",
+			taskRunYAML: `
+apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  name: hello
+spec:
+  workspaces:
+    - name: source
+      emptyDir: {}
+      csi:
+        driver: secrets-store.csi.k8s.io
+  taskSpec:
+    workspaces:
+      - name: source
+    steps:
+      - name: hello
+        image: alpine:latest
+        script: ls $(workspaces.source.path)
+`,
+			expectedError: true,
+			errorContains: []string{
+				`TaskRun workspaces: `,
+				`workspace binding "source" sets emptyDir and csi, expected exactly one of: emptyDir, persistentVolumeClaim, volumeClaimTemplate, configMap, secret, projected, csi: spec.workspaces[0]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var taskRun v1.TaskRun
+			require.NoError(t, yaml.Unmarshal([]byte(tt.taskRunYAML), &taskRun))
+
+			err := WithoutWarnings(ValidateTaskRun(ctx, taskRun))
+
+			if tt.expectedError {
+				require.Error(t, err, "Expected error for test case: %s", tt.name)
+				for _, expectedErr := range tt.errorContains {
+					assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+				}
+			} else {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestValidateTaskRunWithTaskIndex(t *testing.T) {
+	index := taskindex.New()
+	index.Add(taskindex.Entry{
+		Kind: "Task",
+		Name: "hello",
+		Spec: v1.TaskSpec{
+			Params: []v1.ParamSpec{{Name: "message", Type: v1.ParamTypeString}},
+			Steps:  []v1.Step{{Name: "hello", Image: "alpine:latest", Script: "echo $(params.message)"}},
+		},
+	})
+	ctx := WithTaskIndex(context.Background(), index)
+
+	taskRun := v1.TaskRun{}
+	taskRun.Name = "hello"
+	taskRun.Spec.TaskRef = &v1.TaskRef{Name: "hello"}
+
+	err := ValidateTaskRun(ctx, taskRun)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"message" parameter is required`)
+
+	taskRun.Spec.TaskRef = &v1.TaskRef{Name: "goodbye"}
+	err = ValidateTaskRun(ctx, taskRun)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `Task "goodbye" not found in task directories`)
+}
+
+func TestValidateTaskRunDebug(t *testing.T) {
+	taskSpec := &v1.TaskSpec{Steps: []v1.Step{{Name: "build"}, {Name: "push"}}}
+
+	tests := []struct {
+		name             string
+		debug            *v1.TaskRunDebug
+		taskSpec         *v1.TaskSpec
+		expectedErrors   []string
+		expectedWarnings []string
+	}{
+		{
+			name:     "no debug settings",
+			taskSpec: taskSpec,
+		},
+		{
+			name:     "no breakpoints",
+			debug:    &v1.TaskRunDebug{},
+			taskSpec: taskSpec,
+		},
+		{
+			name: "breakpoints",
+			debug: &v1.TaskRunDebug{Breakpoints: &v1.TaskBreakpoints{
+				OnFailure:   v1.EnabledOnFailureBreakpoint,
+				BeforeSteps: []string{"build", "push"},
+			}},
+			taskSpec: taskSpec,
+			expectedWarnings: []string{
+				"spec.debug.breakpoints.onFailure is enabled, failing steps wait for manual intervention, which blocks automation",
+				"spec.debug.breakpoints.beforeSteps pauses the TaskRun before steps [build, push] until resumed manually, which blocks automation",
+			},
+		},
+		{
+			name: "breakpoint before unknown step",
+			debug: &v1.TaskRunDebug{Breakpoints: &v1.TaskBreakpoints{
+				BeforeSteps: []string{"test"},
+			}},
+			taskSpec: taskSpec,
+			expectedErrors: []string{
+				`breakpoint before step "test" which does not exist: spec.debug.breakpoints.beforeSteps[0]`,
+			},
+			expectedWarnings: []string{
+				"spec.debug.breakpoints.beforeSteps pauses the TaskRun before steps [test] until resumed manually, which blocks automation",
+			},
+		},
+		{
+			name: "unknown task spec",
+			debug: &v1.TaskRunDebug{Breakpoints: &v1.TaskBreakpoints{
+				BeforeSteps: []string{"test"},
+			}},
+			expectedWarnings: []string{
+				"spec.debug.breakpoints.beforeSteps pauses the TaskRun before steps [test] until resumed manually, which blocks automation",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTaskRunDebug(tt.debug, tt.taskSpec)
+
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+			errs := WithoutWarnings(err)
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, errs, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, errs, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, errs.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidatePipelineRunDebug(t *testing.T) {
+	tests := []struct {
+		name             string
+		rawYAML          string
+		expectedWarnings []string
+	}{
+		{
+			name: "no debug settings",
+			rawYAML: `
+spec:
+  taskRunSpecs:
+    - pipelineTaskName: build
+      serviceAccountName: builder
+`,
+		},
+		{
+			name: "debug settings",
+			rawYAML: `
+spec:
+  debug:
+    breakpoints:
+      onFailure: enabled
+  taskRunSpecs:
+    - pipelineTaskName: clone
+    - pipelineTaskName: build
+      debug:
+        breakpoints:
+          beforeSteps: [build]
+`,
+			expectedWarnings: []string{
+				"spec.debug is not supported by PipelineRuns and is ignored, remove the committed debug settings",
+				`spec.taskRunSpecs[1].debug is not supported by PipelineRuns and is ignored, remove the debug settings committed for PipelineTask "build"`,
+			},
+		},
+		{
+			name:    "malformed YAML",
+			rawYAML: "spec: [",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePipelineRunDebug([]byte(tt.rawYAML))
+
+			assert.NoError(t, WithoutWarnings(err), "Expected only warnings for test case: %s", tt.name)
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ValidateVolumeMounts verifies that the volumeMounts and volumeDevices of the steps, sidecars, and
+// step template of a Task refer to the volumes it declares, or to the given volumes of the pod
+// templates of the run, and warns about the volumes of the Task which are never mounted. The
+// upstream Tekton validation leaves it to Kubernetes, which only rejects the pod once the TaskRun
+// runs. Outside of a run, the volumes may be added by the pod templates of the runs to come, so
+// undeclared volumes are warnings rather than errors.
+func ValidateVolumeMounts(taskSpec v1.TaskSpec, podVolumes []string, inRun bool) error {
+	declared := make(map[string]bool)
+	for _, volume := range taskSpec.Volumes {
+		declared[volume.Name] = true
+	}
+	for _, name := range podVolumes {
+		declared[name] = true
+	}
+
+	var err error
+	undeclared := func(kind, name, path string) {
+		if inRun {
+			err = multierror.Append(err, fmt.Errorf(
+				"%s %q does not refer to a volume declared by the Task: %s", kind, name, path))
+		} else {
+			err = multierror.Append(err, warningf(
+				"%s %q does not refer to a volume declared by the Task, it must be added by the pod template of its runs: %s", kind, name, path))
+		}
+	}
+	mounted := make(map[string]bool)
+	check := func(mounts []corev1.VolumeMount, devices []corev1.VolumeDevice, path string) {
+		for i, mount := range mounts {
+			mounted[mount.Name] = true
+			if !declared[mount.Name] && !isInternalVolume(mount.Name) {
+				undeclared("volumeMount", mount.Name, fmt.Sprintf("%s.volumeMounts[%d].name", path, i))
+			}
+		}
+		for i, device := range devices {
+			mounted[device.Name] = true
+			if !declared[device.Name] && !isInternalVolume(device.Name) {
+				undeclared("volumeDevice", device.Name, fmt.Sprintf("%s.volumeDevices[%d].name", path, i))
+			}
+		}
+	}
+	if taskSpec.StepTemplate != nil {
+		check(taskSpec.StepTemplate.VolumeMounts, taskSpec.StepTemplate.VolumeDevices, "spec.stepTemplate")
+	}
+	for i, step := range taskSpec.Steps {
+		check(step.VolumeMounts, step.VolumeDevices, fmt.Sprintf("spec.steps[%d]", i))
+	}
+	for i, sidecar := range taskSpec.Sidecars {
+		check(sidecar.VolumeMounts, sidecar.VolumeDevices, fmt.Sprintf("spec.sidecars[%d]", i))
+	}
+
+	for i, volume := range taskSpec.Volumes {
+		if !mounted[volume.Name] {
+			err = multierror.Append(err, warningf("volume %q is declared but never mounted: spec.volumes[%d]", volume.Name, i))
+		}
+	}
+	return err
+}
+
+// internalVolumePrefix starts the names of the volumes Tekton adds to pods, which the upstream
+// validation rejects for volumeMounts
+const internalVolumePrefix = "tekton-internal-"
+
+// isInternalVolume tells whether a volume name is reserved by Tekton
+func isInternalVolume(name string) bool {
+	return strings.HasPrefix(name, internalVolumePrefix)
+}
+
+// podTemplateVolumes returns the names of the volumes of pod templates
+func podTemplateVolumes(templates ...*pod.Template) []string {
+	var names []string
+	for _, template := range templates {
+		if template == nil {
+			continue
+		}
+		for _, volume := range template.Volumes {
+			names = append(names, volume.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,179 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline/pod"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateVolumeMounts(t *testing.T) {
+	tests := []struct {
+		name             string
+		taskSpecYAML     string
+		podVolumes       []string
+		standalone       bool
+		expectedErrors   []string
+		expectedWarnings []string
+	}{
+		{
+			name: "mounted volumes",
+			taskSpecYAML: `
+volumes:
+  - name: cache
+    emptyDir: {}
+  - name: config
+    configMap:
+      name: config
+  - name: disk
+    emptyDir: {}
+stepTemplate:
+  volumeMounts:
+    - name: cache
+      mountPath: /cache
+steps:
+  - name: build
+    image: registry.io/build:1.0
+    volumeDevices:
+      - name: disk
+        devicePath: /dev/disk
+sidecars:
+  - name: registry
+    image: registry.io/registry:1.0
+    volumeMounts:
+      - name: config
+        mountPath: /config
+`,
+		},
+		{
+			name: "undeclared volumes",
+			taskSpecYAML: `
+volumes:
+  - name: cache
+    emptyDir: {}
+stepTemplate:
+  volumeMounts:
+    - name: cahce
+      mountPath: /cache
+steps:
+  - name: build
+    image: registry.io/build:1.0
+    volumeMounts:
+      - name: cache
+        mountPath: /cache
+      - name: config
+        mountPath: /config
+    volumeDevices:
+      - name: disk
+        devicePath: /dev/disk
+sidecars:
+  - name: registry
+    image: registry.io/registry:1.0
+    volumeMounts:
+      - name: config
+        mountPath: /config
+`,
+			expectedErrors: []string{
+				`volumeMount "cahce" does not refer to a volume declared by the Task: spec.stepTemplate.volumeMounts[0].name`,
+				`volumeMount "config" does not refer to a volume declared by the Task: spec.steps[0].volumeMounts[1].name`,
+				`volumeDevice "disk" does not refer to a volume declared by the Task: spec.steps[0].volumeDevices[0].name`,
+				`volumeMount "config" does not refer to a volume declared by the Task: spec.sidecars[0].volumeMounts[0].name`,
+			},
+		},
+		{
+			name: "undeclared volumes outside of a run",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: registry.io/build:1.0
+    volumeMounts:
+      - name: docker-config
+        mountPath: /docker
+`,
+			standalone: true,
+			expectedWarnings: []string{
+				`volumeMount "docker-config" does not refer to a volume declared by the Task, it must be added by the pod template of its runs: spec.steps[0].volumeMounts[0].name`,
+			},
+		},
+		{
+			name: "volumes of pod templates",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: registry.io/build:1.0
+    volumeMounts:
+      - name: docker-config
+        mountPath: /docker
+`,
+			podVolumes: []string{"docker-config"},
+		},
+		{
+			name: "internal volumes are left to the upstream validation",
+			taskSpecYAML: `
+steps:
+  - name: build
+    image: registry.io/build:1.0
+    volumeMounts:
+      - name: tekton-internal-results
+        mountPath: /results
+`,
+		},
+		{
+			name: "volumes mounted nowhere",
+			taskSpecYAML: `
+volumes:
+  - name: cache
+    emptyDir: {}
+  - name: config
+    configMap:
+      name: config
+steps:
+  - name: build
+    image: registry.io/build:1.0
+    volumeMounts:
+      - name: config
+        mountPath: /config
+`,
+			expectedWarnings: []string{`volume "cache" is declared but never mounted: spec.volumes[0]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateVolumeMounts(taskSpec, tt.podVolumes, !tt.standalone)
+
+			var messages []string
+			for _, finding := range Findings(WithoutWarnings(err)) {
+				messages = append(messages, finding.String())
+			}
+			assert.Equal(t, tt.expectedErrors, messages)
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+		})
+	}
+}
+
+func TestValidateTaskRunPodTemplateVolumes(t *testing.T) {
+	tr := v1.TaskRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "build"},
+		Spec: v1.TaskRunSpec{
+			TaskSpec: &v1.TaskSpec{Steps: []v1.Step{{
+				Name:         "build",
+				Image:        "registry.io/build:1.0",
+				Script:       "build",
+				VolumeMounts: []corev1.VolumeMount{{Name: "docker-config", MountPath: "/docker"}},
+			}}},
+		},
+	}
+	assert.Equal(t, []string{RuleVolumes.ID}, findingRules(ValidateTaskRun(context.Background(), tr)))
+
+	tr.Spec.PodTemplate = &pod.Template{Volumes: []corev1.Volume{{Name: "docker-config"}}}
+	assert.NoError(t, ValidateTaskRun(context.Background(), tr))
+}
@@ -0,0 +1,200 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// Warning is a validation finding that is reported to the user but does not fail validation
+type Warning struct {
+	Err error
+}
+
+func (w *Warning) Error() string {
+	return w.Err.Error()
+}
+
+func (w *Warning) Unwrap() error {
+	return w.Err
+}
+
+// warningf creates a new Warning from a format string
+func warningf(format string, args ...any) error {
+	return &Warning{Err: fmt.Errorf(format, args...)}
+}
+
+// isWarning reports whether err itself is a Warning. Wrapped errors are not inspected since a
+// multierror wraps every error it holds.
+func isWarning(err error) bool {
+	_, ok := err.(*Warning)
+	return ok
+}
+
+// Warnings returns the message of every Warning found in err, including the context added by
+// the errors wrapping it
+func Warnings(err error) []string {
+	var warnings []string
+	walkErrors(err, "", func(prefix string, leaf error) {
+		if isWarning(leaf) {
+			warnings = append(warnings, prefix+leaf.Error())
+		}
+	})
+	return warnings
+}
+
+// WithoutWarnings returns err with every Warning removed. It returns nil if err only contains
+// warnings.
+func WithoutWarnings(err error) error {
+	if err == nil || isWarning(err) {
+		return nil
+	}
+
+	if rerr, ok := err.(*ruleError); ok {
+		return withRule(rerr.rule, WithoutWarnings(rerr.err))
+	}
+
+	if merr, ok := err.(*multierror.Error); ok {
+		var result error
+		for _, e := range merr.Errors {
+			if filtered := WithoutWarnings(e); filtered != nil {
+				result = multierror.Append(result, filtered)
+			}
+		}
+		return result
+	}
+
+	if inner := errors.Unwrap(err); inner != nil {
+		prefix, ok := strings.CutSuffix(err.Error(), inner.Error())
+		if !ok {
+			return err
+		}
+		filtered := WithoutWarnings(inner)
+		if filtered == nil {
+			return nil
+		}
+		if filtered == inner {
+			return err
+		}
+		return fmt.Errorf("%s%w", prefix, filtered)
+	}
+
+	return err
+}
+
+// PromoteWarnings returns err with every Warning turned into an error, as strict mode reports them
+func PromoteWarnings(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if w, ok := err.(*Warning); ok {
+		return w.Err
+	}
+
+	if rerr, ok := err.(*ruleError); ok {
+		return withRule(rerr.rule, PromoteWarnings(rerr.err))
+	}
+
+	if merr, ok := err.(*multierror.Error); ok {
+		var result error
+		for _, e := range merr.Errors {
+			result = multierror.Append(result, PromoteWarnings(e))
+		}
+		return result
+	}
+
+	if inner := errors.Unwrap(err); inner != nil {
+		if prefix, ok := strings.CutSuffix(err.Error(), inner.Error()); ok {
+			if promoted := PromoteWarnings(inner); promoted != inner {
+				return fmt.Errorf("%s%w", prefix, promoted)
+			}
+		}
+	}
+
+	return err
+}
+
+// walkErrors calls fn for every leaf error in err. Errors which wrap another error by appending
+// its message, e.g. fmt.Errorf("context: %w", err), contribute their message as a prefix.
+func walkErrors(err error, prefix string, fn func(prefix string, leaf error)) {
+	walkRuleErrors(err, prefix, nil, func(prefix string, _ *Rule, leaf error) {
+		fn(prefix, leaf)
+	})
+}
+
+// walkRuleErrors is walkErrors also passing fn the rule which the innermost ruleError wrapping a
+// leaf attributes it to, or nil
+func walkRuleErrors(err error, prefix string, rule *Rule, fn func(prefix string, rule *Rule, leaf error)) {
+	if err == nil {
+		return
+	}
+
+	if isWarning(err) {
+		fn(prefix, rule, err)
+		return
+	}
+
+	if rerr, ok := err.(*ruleError); ok {
+		walkRuleErrors(rerr.err, prefix, &rerr.rule, fn)
+		return
+	}
+
+	if merr, ok := err.(*multierror.Error); ok {
+		for _, e := range merr.Errors {
+			walkRuleErrors(e, prefix, rule, fn)
+		}
+		return
+	}
+
+	if inner := errors.Unwrap(err); inner != nil {
+		if p, ok := strings.CutSuffix(err.Error(), inner.Error()); ok {
+			walkRuleErrors(inner, prefix+p, rule, fn)
+			return
+		}
+	}
+
+	fn(prefix, rule, err)
+}
+
+// RewriteErrors returns a copy of err with every message rewritten by fn. The structure of err is
+// preserved, so Warnings and WithoutWarnings work on the result as they do on err.
+func RewriteErrors(err error, fn func(string) string) error {
+	if err == nil {
+		return nil
+	}
+
+	if perr, ok := leafPathError(err); ok {
+		rewritten := withPath(fn(perr.path), errors.New(fn(perr.err.Error())))
+		if isWarning(err) {
+			return &Warning{Err: rewritten}
+		}
+		return rewritten
+	}
+
+	if isWarning(err) {
+		return &Warning{Err: errors.New(fn(err.Error()))}
+	}
+
+	if rerr, ok := err.(*ruleError); ok {
+		return withRule(rerr.rule, RewriteErrors(rerr.err, fn))
+	}
+
+	if merr, ok := err.(*multierror.Error); ok {
+		var result error
+		for _, e := range merr.Errors {
+			result = multierror.Append(result, RewriteErrors(e, fn))
+		}
+		return result
+	}
+
+	if inner := errors.Unwrap(err); inner != nil {
+		if prefix, ok := strings.CutSuffix(err.Error(), inner.Error()); ok {
+			return fmt.Errorf("%s%w", fn(prefix), RewriteErrors(inner, fn))
+		}
+	}
+
+	return errors.New(fn(err.Error()))
+}
@@ -0,0 +1,139 @@
+package validator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected []string
+	}{
+		{
+			name:     "nil error",
+			err:      nil,
+			expected: nil,
+		},
+		{
+			name:     "plain error",
+			err:      errors.New("boom"),
+			expected: nil,
+		},
+		{
+			name:     "single warning",
+			err:      warningf("careful %s", "now"),
+			expected: []string{"careful now"},
+		},
+		{
+			name: "warnings nested in wrapped multierrors",
+			err: multierror.Append(
+				errors.New("boom"),
+				fmt.Errorf("build PipelineTask: %w", multierror.Append(
+					warningf("first"),
+					fmt.Errorf("image validation: %w", multierror.Append(nil, warningf("second"))),
+				)),
+			),
+			expected: []string{
+				"build PipelineTask: first",
+				"build PipelineTask: image validation: second",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Warnings(tt.err))
+		})
+	}
+}
+
+func TestWithoutWarnings(t *testing.T) {
+	t.Run("nil error", func(t *testing.T) {
+		assert.NoError(t, WithoutWarnings(nil))
+	})
+
+	t.Run("only warnings", func(t *testing.T) {
+		err := multierror.Append(
+			warningf("first"),
+			fmt.Errorf("context: %w", multierror.Append(nil, warningf("second"))),
+		)
+		assert.NoError(t, WithoutWarnings(err))
+	})
+
+	t.Run("errors are kept with their context", func(t *testing.T) {
+		err := multierror.Append(
+			warningf("first"),
+			fmt.Errorf("build PipelineTask: %w", multierror.Append(
+				warningf("second"),
+				errors.New("boom"),
+			)),
+		)
+
+		filtered := WithoutWarnings(err)
+		require.Error(t, filtered)
+		assert.Contains(t, filtered.Error(), "build PipelineTask: 1 error occurred:\n\t* boom")
+		assert.NotContains(t, filtered.Error(), "first")
+		assert.NotContains(t, filtered.Error(), "second")
+	})
+
+	t.Run("errors without warnings are unchanged", func(t *testing.T) {
+		err := fmt.Errorf("context: %w", errors.New("boom"))
+		assert.Equal(t, err, WithoutWarnings(err))
+	})
+}
+
+func TestRewriteErrors(t *testing.T) {
+	rewrite := func(msg string) string {
+		return strings.ReplaceAll(msg, "spec.", "pipelineSpec.")
+	}
+
+	assert.NoError(t, RewriteErrors(nil, rewrite))
+
+	err := multierror.Append(
+		warningf("unused param: spec.params[0]"),
+		fmt.Errorf("spec.tasks[0] PipelineTask: %w", multierror.Append(
+			warningf("deprecated field: spec.tasks[0].timeout"),
+			errors.New("invalid value: spec.tasks[0].name"),
+		)),
+	)
+
+	rewritten := RewriteErrors(err, rewrite)
+	assert.Equal(t, []string{
+		"unused param: pipelineSpec.params[0]",
+		"pipelineSpec.tasks[0] PipelineTask: deprecated field: pipelineSpec.tasks[0].timeout",
+	}, Warnings(rewritten))
+
+	filtered := WithoutWarnings(rewritten)
+	require.Error(t, filtered)
+	assert.Contains(t, filtered.Error(), "pipelineSpec.tasks[0] PipelineTask: 1 error occurred:\n\t* invalid value: pipelineSpec.tasks[0].name")
+	assert.NotContains(t, filtered.Error(), " spec.")
+}
+
+func TestPromoteWarnings(t *testing.T) {
+	assert.NoError(t, PromoteWarnings(nil))
+
+	err := multierror.Append(
+		withRule(RuleUnusedWorkspace, warningf("unused workspace")),
+		fmt.Errorf("build PipelineTask: %w", multierror.Append(
+			warningf("deprecated field: spec.tasks[0].timeout"),
+			errors.New("invalid value: spec.tasks[0].name"),
+		)),
+	)
+
+	promoted := PromoteWarnings(err)
+	assert.Empty(t, Warnings(promoted))
+	assert.Equal(t, promoted.Error(), WithoutWarnings(promoted).Error())
+	assert.Equal(t, []Finding{
+		{Rule: RuleUnusedWorkspace.ID, Severity: SeverityError, Message: "unused workspace"},
+		{Severity: SeverityError, Message: "build PipelineTask: deprecated field", ResourcePath: "spec.tasks[0].timeout"},
+		{Severity: SeverityError, Message: "build PipelineTask: invalid value", ResourcePath: "spec.tasks[0].name"},
+	}, Findings(promoted))
+}
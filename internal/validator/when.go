@@ -0,0 +1,139 @@
+package validator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// ValidateWhenExpressions warns about the PipelineTasks guarded by a when expression which is always
+// false, since its input and values are literals which the operator never matches, e.g. input foo
+// in values [bar]. Such PipelineTasks never run. The path is the one of the pipeline spec.
+func ValidateWhenExpressions(pipelineSpec v1.PipelineSpec, path string) error {
+	var err error
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		for i, pipelineTask := range section.pipelineTasks {
+			for j, when := range pipelineTask.When {
+				if !isAlwaysFalse(when) {
+					continue
+				}
+				err = multierror.Append(err, warningf(
+					"when expression of the %s PipelineTask is always false, %q %s %v, so the PipelineTask never runs: %s.%s[%d].when[%d]",
+					pipelineTask.Name, when.Input, when.Operator, when.Values, path, section.name, i, j))
+			}
+		}
+	}
+	return err
+}
+
+// isAlwaysFalse tells whether a when expression made up of literals only never matches. CEL
+// expressions and expressions substituting variables are evaluated at runtime, so they are not.
+func isAlwaysFalse(when v1.WhenExpression) bool {
+	if when.CEL != "" || strings.Contains(when.Input, "$(") {
+		return false
+	}
+	for _, value := range when.Values {
+		if strings.Contains(value, "$(") {
+			return false
+		}
+	}
+	switch when.Operator {
+	case selection.In:
+		return !slices.Contains(when.Values, when.Input)
+	case selection.NotIn:
+		return slices.Contains(when.Values, when.Input)
+	}
+	return false
+}
+
+// whenParameterReference is a parameter reference in a when expression of a PipelineTask, along with
+// the path of the field holding it
+type whenParameterReference struct {
+	ref  string
+	path string
+}
+
+// whenParameterReferences returns the parameter references in the input, values, and CEL of the
+// when expressions of the PipelineTasks of a pipeline spec. The path is the one of the pipeline spec.
+func whenParameterReferences(pipelineSpec v1.PipelineSpec, path string) []whenParameterReference {
+	var refs []whenParameterReference
+	collect := func(value, path string) {
+		for _, match := range paramRefRegex.FindAllStringSubmatch(value, -1) {
+			refs = append(refs, whenParameterReference{ref: strings.TrimSpace(match[1]), path: path})
+		}
+	}
+	for _, section := range pipelineTaskSections(pipelineSpec) {
+		for i, pipelineTask := range section.pipelineTasks {
+			for j, when := range pipelineTask.When {
+				whenPath := fmt.Sprintf("%s.%s[%d].when[%d]", path, section.name, i, j)
+				collect(when.Input, whenPath+".input")
+				for k, value := range when.Values {
+					collect(value, fmt.Sprintf("%s.values[%d]", whenPath, k))
+				}
+				collect(when.CEL, whenPath+".cel")
+			}
+		}
+	}
+	return refs
+}
+
+// ValidateWhenParameterReferences verifies that the parameter references in the when expressions of
+// the PipelineTasks of a pipeline spec refer to its params. The path is the one of the pipeline spec.
+func ValidateWhenParameterReferences(pipelineSpec v1.PipelineSpec, path string) error {
+	return validateWhenParameterReferences(pipelineSpec, path, nil)
+}
+
+// validateWhenParameterReferences is like ValidateWhenParameterReferences but also accepts references
+// to the given parameters, which are propagated from a PipelineRun embedding the pipeline spec
+func validateWhenParameterReferences(pipelineSpec v1.PipelineSpec, path string, propagatedParams []string) error {
+	var err error
+	for _, ref := range undefinedWhenParameterReferences(pipelineSpec, path, propagatedParams) {
+		err = multierror.Append(err, withPath(ref.path, fmt.Errorf("parameter reference $(params.%s) not defined in pipeline spec", ref.ref)))
+	}
+	return err
+}
+
+// undefinedWhenParameterReferences returns the parameter references in the when expressions of a
+// pipeline spec which refer to neither its params nor the propagated ones
+func undefinedWhenParameterReferences(pipelineSpec v1.PipelineSpec, path string, propagatedParams []string) []whenParameterReference {
+	defined := make(map[string]bool)
+	for _, param := range pipelineSpec.Params {
+		defined[param.Name] = true
+	}
+	for _, name := range propagatedParams {
+		defined[name] = true
+	}
+	var undefined []whenParameterReference
+	for _, ref := range whenParameterReferences(pipelineSpec, path) {
+		if ref.ref == "" || !defined[paramRefName(ref.ref)] {
+			undefined = append(undefined, ref)
+		}
+	}
+	return undefined
+}
+
+// isWhenParameterReferenceError returns whether a Tekton validation error reports a variable of a
+// when expression which is one of the undefined parameter references reported by
+// ValidateWhenParameterReferences, with a more precise path
+func isWhenParameterReferenceError(undefined []whenParameterReference) func(message, path string) bool {
+	refs := make(map[string]bool)
+	for _, ref := range undefined {
+		refs[ref.ref] = true
+	}
+	return func(message, path string) bool {
+		matches := paramRefRegex.FindAllStringSubmatch(message, -1)
+		if !strings.HasPrefix(message, "non-existent variable in ") || !strings.Contains(path, ".when[") || len(matches) == 0 {
+			return false
+		}
+		for _, match := range matches {
+			if !refs[strings.TrimSpace(match[1])] {
+				return false
+			}
+		}
+		return true
+	}
+}
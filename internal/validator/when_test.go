@@ -0,0 +1,255 @@
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestValidateWhenExpressions(t *testing.T) {
+	tests := []struct {
+		name             string
+		pipelineSpecYAML string
+		expectedWarnings []string
+	}{
+		{
+			name: "satisfiable when expressions",
+			pipelineSpecYAML: `
+tasks:
+  - name: build
+    when:
+      - input: foo
+        operator: in
+        values: [bar, foo]
+      - input: foo
+        operator: notin
+        values: [bar]
+      - input: $(params.deploy)
+        operator: in
+        values: ["true"]
+      - input: "true"
+        operator: in
+        values: [$(params.deploy)]
+      - cel: "'foo' == 'bar'"
+    taskRef:
+      name: build
+`,
+		},
+		{
+			name: "always false when expressions",
+			pipelineSpecYAML: `
+tasks:
+  - name: build
+    taskRef:
+      name: build
+  - name: deploy
+    when:
+      - input: foo
+        operator: in
+        values: [bar]
+    taskRef:
+      name: deploy
+finally:
+  - name: notify
+    when:
+      - input: "true"
+        operator: in
+        values: ["true"]
+      - input: "true"
+        operator: notin
+        values: ["false", "true"]
+    taskRef:
+      name: notify
+`,
+			expectedWarnings: []string{
+				`when expression of the deploy PipelineTask is always false, "foo" in [bar], so the PipelineTask never runs: spec.tasks[1].when[0]`,
+				`when expression of the notify PipelineTask is always false, "true" notin [false true], so the PipelineTask never runs: spec.finally[0].when[1]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipelineSpec, err := pipelineSpecFromYAML(tt.pipelineSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateWhenExpressions(pipelineSpec, "spec")
+
+			assert.NoError(t, WithoutWarnings(err), "Expected only warnings for test case: %s", tt.name)
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+		})
+	}
+}
+
+func TestValidateWhenParameterReferences(t *testing.T) {
+	tests := []struct {
+		name             string
+		pipelineSpecYAML string
+		propagatedParams []string
+		expectedErrors   []string
+	}{
+		{
+			name: "declared and propagated params",
+			pipelineSpecYAML: `
+params:
+  - name: env
+  - name: config
+    type: object
+    properties:
+      region: {}
+tasks:
+  - name: deploy
+    when:
+      - input: $(params.env)
+        operator: in
+        values: [$(params.config.region), "$(params.region)-$(params.env)"]
+      - cel: "'$(params.env)' == 'prod'"
+    taskRef:
+      name: deploy
+`,
+			propagatedParams: []string{"region"},
+		},
+		{
+			name: "undeclared params",
+			pipelineSpecYAML: `
+params:
+  - name: env
+tasks:
+  - name: deploy
+    when:
+      - input: $(params.envv)
+        operator: in
+        values: [$(params.env), "$(params.region)-$(params.env)"]
+    taskRef:
+      name: deploy
+finally:
+  - name: notify
+    when:
+      - cel: "'$(params.)' == 'prod'"
+    taskRef:
+      name: notify
+`,
+			expectedErrors: []string{
+				"parameter reference $(params.envv) not defined in pipeline spec: spec.tasks[0].when[0].input",
+				"parameter reference $(params.region) not defined in pipeline spec: spec.tasks[0].when[0].values[1]",
+				"parameter reference $(params.) not defined in pipeline spec: spec.finally[0].when[0].cel",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipelineSpec, err := pipelineSpecFromYAML(tt.pipelineSpecYAML)
+			require.NoError(t, err)
+
+			err = validateWhenParameterReferences(pipelineSpec, "spec", tt.propagatedParams)
+
+			var messages []string
+			for _, finding := range Findings(err) {
+				messages = append(messages, finding.String())
+			}
+			assert.Equal(t, tt.expectedErrors, messages)
+		})
+	}
+}
+
+func TestValidateWhenParameterReferencesOnce(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		validate func(ctx context.Context, rawYAML []byte) error
+		expected []Finding
+	}{
+		{
+			name: "pipeline",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: deploy
+spec:
+  params:
+    - name: env
+      type: string
+      default: prod
+  tasks:
+    - name: deploy
+      when:
+        - input: $(params.envv)
+          operator: in
+          values: [$(params.env)]
+      taskSpec:
+        steps:
+          - name: deploy
+            image: registry.io/deploy:1.0
+            script: deploy
+`,
+			validate: func(ctx context.Context, rawYAML []byte) error {
+				var p v1.Pipeline
+				if err := yaml.Unmarshal(rawYAML, &p); err != nil {
+					return err
+				}
+				return ValidatePipelineWithYAML(ctx, p, rawYAML)
+			},
+			expected: []Finding{{
+				Rule:         RuleParamReferences.ID,
+				Severity:     SeverityError,
+				Message:      "parameter reference validation: parameter reference $(params.envv) not defined in pipeline spec",
+				ResourcePath: "spec.tasks[0].when[0].input",
+			}},
+		},
+		{
+			name: "pipelinerun",
+			yaml: `
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: deploy
+spec:
+  params:
+    - name: region
+      value: eu
+  pipelineSpec:
+    params:
+      - name: env
+        type: string
+        default: prod
+    tasks:
+      - name: deploy
+        when:
+          - input: $(params.envv)
+            operator: in
+            values: [$(params.env), $(params.region)]
+        taskSpec:
+          steps:
+            - name: deploy
+              image: registry.io/deploy:1.0
+              script: deploy
+`,
+			validate: func(ctx context.Context, rawYAML []byte) error {
+				var pr v1.PipelineRun
+				if err := yaml.Unmarshal(rawYAML, &pr); err != nil {
+					return err
+				}
+				return ValidatePipelineRunWithYAML(ctx, pr, rawYAML)
+			},
+			expected: []Finding{{
+				Rule:         RuleParamReferences.ID,
+				Severity:     SeverityError,
+				Message:      "parameter reference validation: parameter reference $(params.envv) not defined in pipeline spec",
+				ResourcePath: "spec.pipelineSpec.tasks[0].when[0].input",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate(context.Background(), []byte(tt.yaml))
+			assert.Equal(t, tt.expected, Findings(err))
+		})
+	}
+}
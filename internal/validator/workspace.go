@@ -2,14 +2,27 @@ package validator
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/go-multierror"
 	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
 )
 
 // ValidateWorkspaces validates workspace usage across the pipeline
 func ValidateWorkspaces(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec) error {
+	return validateWorkspaces(pipelineSpec, allTaskSpecs, nil, nil)
+}
+
+// validateWorkspaces is like ValidateWorkspaces but also accepts bindings to the given workspaces,
+// which are propagated from a PipelineRun embedding the pipeline spec, and resolves the references
+// of the subPaths of bindings to the given runtime params
+func validateWorkspaces(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec, propagatedWorkspaces []string, runtimeParams map[string]string) error {
 	var err error
 
 	// Create a map of pipeline workspaces for quick lookup
@@ -18,6 +31,15 @@ func ValidateWorkspaces(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v
 		pipelineWorkspaces[workspace.Name] = workspace
 	}
 
+	// Propagated workspaces can be bound, but are not required to be used
+	availableWorkspaces := make(map[string]v1.PipelineWorkspaceDeclaration)
+	for _, name := range propagatedWorkspaces {
+		availableWorkspaces[name] = v1.PipelineWorkspaceDeclaration{Name: name}
+	}
+	for name, workspace := range pipelineWorkspaces {
+		availableWorkspaces[name] = workspace
+	}
+
 	// Validate workspace usage in each pipeline task
 	allTasks := append(pipelineSpec.Tasks, pipelineSpec.Finally...)
 	for _, pipelineTask := range allTasks {
@@ -28,9 +50,21 @@ func ValidateWorkspaces(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v
 		}
 
 		// Validate task workspace requirements
-		if taskErr := validateTaskWorkspaces(pipelineTask, taskSpec, pipelineWorkspaces); taskErr != nil {
+		if taskErr := validateTaskWorkspaces(pipelineTask, taskSpec, availableWorkspaces, pipelineSpec.Params, runtimeParams); taskErr != nil {
 			err = multierror.Append(err, fmt.Errorf("task %s workspace validation: %w", pipelineTask.Name, taskErr))
 		}
+
+		// Validate workspace references of embedded task specs
+		if pipelineTask.TaskSpec != nil {
+			if refErr := validateEmbeddedWorkspaceReferences(pipelineTask, propagatedWorkspaces); refErr != nil {
+				err = multierror.Append(err, fmt.Errorf("task %s workspace validation: %w", pipelineTask.Name, refErr))
+			}
+		}
+	}
+
+	// Validate that read-only and writable uses of the same pipeline workspace are ordered
+	if readOnlyErr := validateReadOnlyWorkspaceUsage(pipelineSpec, allTaskSpecs); readOnlyErr != nil {
+		err = multierror.Append(err, readOnlyErr)
 	}
 
 	// Validate that declared pipeline workspaces are actually used
@@ -41,8 +75,9 @@ func ValidateWorkspaces(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v
 	return err
 }
 
-// validateTaskWorkspaces validates workspace usage for a specific task
-func validateTaskWorkspaces(pipelineTask v1.PipelineTask, taskSpec *v1.TaskSpec, pipelineWorkspaces map[string]v1.PipelineWorkspaceDeclaration) error {
+// validateTaskWorkspaces validates workspace usage for a specific task, resolving the subPaths of
+// its bindings with the params of the pipeline and their runtime values
+func validateTaskWorkspaces(pipelineTask v1.PipelineTask, taskSpec *v1.TaskSpec, pipelineWorkspaces map[string]v1.PipelineWorkspaceDeclaration, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) error {
 	var err error
 
 	// Create maps for quick lookup
@@ -76,39 +111,136 @@ func validateTaskWorkspaces(pipelineTask v1.PipelineTask, taskSpec *v1.TaskSpec,
 		}
 
 		// Validate workspace requirements (readOnly, mountPath conflicts, etc.)
-		if reqErr := validateWorkspaceRequirements(workspaceDecl, binding); reqErr != nil {
+		if reqErr := validateWorkspaceRequirements(workspaceDecl, binding, resolveWorkspaceSubPath(binding.SubPath, pipelineParams, runtimeParams)); reqErr != nil {
 			err = multierror.Append(err, reqErr)
 		}
 	}
 
-	// Check that all workspace bindings reference valid task workspaces
+	// Check that all workspace bindings reference valid task workspaces. Workspaces bound to an
+	// embedded task spec are propagated to it, so they don't need to be declared.
 	for _, binding := range pipelineTask.Workspaces {
-		if _, exists := taskWorkspaceDeclarations[binding.Name]; !exists {
+		if _, exists := taskWorkspaceDeclarations[binding.Name]; exists {
+			continue
+		}
+		if pipelineTask.TaskSpec == nil {
 			err = multierror.Append(err, fmt.Errorf("workspace binding %q does not match any task workspace declaration", binding.Name))
+			continue
+		}
+		if binding.Workspace != "" {
+			if _, exists := pipelineWorkspaces[binding.Workspace]; !exists {
+				err = multierror.Append(err, fmt.Errorf("workspace binding %q references non-existent pipeline workspace %q", binding.Name, binding.Workspace))
+			}
+		}
+	}
+
+	return err
+}
+
+// workspaceRefRegex matches workspace variables, e.g. $(workspaces.source.path)
+var workspaceRefRegex = regexp.MustCompile(`\$\(workspaces\.([^.)]+)\.[^)]*\)`)
+
+// ValidateStepWorkspaceReferences verifies the workspace variables of the steps and sidecars of a
+// Task, e.g. $(workspaces.source.path), $(workspaces.source.bound), or $(workspaces.source.claim),
+// refer to workspaces the Task declares
+func ValidateStepWorkspaceReferences(taskSpec v1.TaskSpec) error {
+	var err error
+
+	declared := make(map[string]bool)
+	for _, decl := range taskSpec.Workspaces {
+		declared[decl.Name] = true
+	}
+
+	stepFields, sidecarFields := taskContainerFields(taskSpec, "spec")
+	for _, field := range append(stepFields, sidecarFields...) {
+		reported := make(map[string]bool)
+		for _, match := range workspaceRefRegex.FindAllStringSubmatch(field.value, -1) {
+			name := match[1]
+			if !declared[name] && !reported[name] {
+				reported[name] = true
+				err = multierror.Append(err, withPath(field.path, fmt.Errorf("workspace reference %s is not declared by the Task", match[0])))
+			}
 		}
 	}
 
 	return err
 }
 
-// validateWorkspaceRequirements validates specific workspace requirements
-func validateWorkspaceRequirements(decl v1.WorkspaceDeclaration, binding v1.WorkspacePipelineTaskBinding) error {
+// validateEmbeddedWorkspaceReferences checks that the workspaces referenced by the steps and
+// sidecars of an embedded task spec are either declared by it, bound by the PipelineTask, or
+// propagated from the PipelineRun
+func validateEmbeddedWorkspaceReferences(pipelineTask v1.PipelineTask, propagatedWorkspaces []string) error {
 	var err error
 
-	// Validate readOnly requirements (check if binding has readOnly field and compare)
-	// Note: In Tekton v1, readOnly is typically handled at runtime, but we can check for basic compatibility
+	available := make(map[string]bool)
+	for _, decl := range pipelineTask.TaskSpec.Workspaces {
+		available[decl.Name] = true
+	}
+	for _, binding := range pipelineTask.Workspaces {
+		available[binding.Name] = true
+	}
+	for _, name := range propagatedWorkspaces {
+		available[name] = true
+	}
 
-	// Validate mountPath conflicts - if task declares a mountPath and binding also has subPath
-	if decl.MountPath != "" && binding.SubPath != "" {
-		// This could potentially cause path conflicts
-		if strings.HasPrefix(binding.SubPath, "/") {
-			err = multierror.Append(err, fmt.Errorf("workspace %q: task declares mountPath %q but binding uses absolute subPath %q which may cause conflicts", decl.Name, decl.MountPath, binding.SubPath))
+	stepFields, sidecarFields := taskContainerFields(pipelineTask.TaskSpec.TaskSpec, "taskSpec")
+	for _, field := range append(stepFields, sidecarFields...) {
+		reported := make(map[string]bool)
+		for _, match := range workspaceRefRegex.FindAllStringSubmatch(field.value, -1) {
+			name := match[1]
+			if !available[name] && !reported[name] {
+				reported[name] = true
+				err = multierror.Append(err, withPath(field.path, fmt.Errorf(
+					"workspace reference %s is not declared by the task spec nor provided by the PipelineTask", match[0])))
+			}
 		}
 	}
 
 	return err
 }
 
+// validateWorkspaceRequirements validates specific workspace requirements, subPath being the
+// subPath of the binding with its variables resolved
+func validateWorkspaceRequirements(decl v1.WorkspaceDeclaration, binding v1.WorkspacePipelineTaskBinding, subPath string) error {
+	var err error
+
+	// The readOnly semantics of the declaration are checked against the steps of the Task by
+	// ValidateReadOnlyWorkspaces, and across PipelineTasks by validateReadOnlyWorkspaceUsage.
+
+	// Validate mountPath conflicts - if task declares a mountPath and binding also has subPath.
+	// This could potentially cause path conflicts, hence a warning.
+	if decl.MountPath != "" && subPath != "" {
+		if strings.HasPrefix(subPath, "/") {
+			resolved := ""
+			if subPath != binding.SubPath {
+				resolved = fmt.Sprintf(", resolving to %q,", subPath)
+			}
+			err = multierror.Append(err, warningf("workspace %q: task declares mountPath %q but binding uses absolute subPath %q%s which may cause conflicts", decl.Name, decl.MountPath, binding.SubPath, resolved))
+		}
+	}
+
+	return err
+}
+
+// contextVariableRegex matches context variables, e.g. $(context.pipelineRun.uid)
+var contextVariableRegex = regexp.MustCompile(`\$\(context\.[A-Za-z]+\.[A-Za-z-]+\)`)
+
+// resolveWorkspaceSubPath returns the subPath of a workspace binding with the references to params
+// replaced with their runtime values, or else their defaults. Context variables are replaced with a
+// name, since the names, namespaces, and uids they resolve to are never absolute paths. A subPath
+// still starting with a variable, e.g. the result of another PipelineTask, is returned empty since
+// what it resolves to is only known at runtime.
+func resolveWorkspaceSubPath(subPath string, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) string {
+	if !strings.Contains(subPath, "$(") {
+		return subPath
+	}
+	resolved := substituteParameterReferences(subPath, pipelineParams, runtimeParams)
+	resolved = contextVariableRegex.ReplaceAllString(resolved, "context")
+	if strings.HasPrefix(resolved, "$(") {
+		return ""
+	}
+	return resolved
+}
+
 // validateUnusedPipelineWorkspaces checks for declared but unused pipeline workspaces
 func validateUnusedPipelineWorkspaces(pipelineSpec v1.PipelineSpec, pipelineWorkspaces map[string]v1.PipelineWorkspaceDeclaration) error {
 	var err error
@@ -126,25 +258,399 @@ func validateUnusedPipelineWorkspaces(pipelineSpec v1.PipelineSpec, pipelineWork
 		}
 	}
 
-	// Report unused pipeline workspaces as warnings (not errors)
+	// Report unused pipeline workspaces as warnings (not errors), in a stable order
+	workspaceNames := make([]string, 0, len(pipelineWorkspaces))
 	for workspaceName := range pipelineWorkspaces {
+		workspaceNames = append(workspaceNames, workspaceName)
+	}
+	sort.Strings(workspaceNames)
+	for _, workspaceName := range workspaceNames {
 		if !usedWorkspaces[workspaceName] {
-			// Note: This is more of a warning than an error, but we'll report it
-			err = multierror.Append(err, fmt.Errorf("pipeline workspace %q is declared but never used", workspaceName))
+			err = multierror.Append(err, withRule(RuleUnusedWorkspace, warningf("pipeline workspace %q is declared but never used", workspaceName)))
 		}
 	}
 
 	return err
 }
 
+// workspaceUsageRegex matches the usage of a workspace, given its variables and mount path: a
+// $(workspaces.<name>.path), .bound, .claim, or .volume variable, or the mount path as a whole path
+// or the parent of one, e.g. /workspace/source/Makefile but not /workspace/source-cache
+func workspaceUsageRegex(name, mountPath string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`\$\(workspaces\.%s\.(path|bound|claim|volume)\)|(^|[^\w./-])%s($|[^\w.-])`,
+		regexp.QuoteMeta(name), regexp.QuoteMeta(strings.TrimSuffix(mountPath, "/"))))
+}
+
+// ValidateUnusedTaskWorkspaces warns about workspaces declared by a Task which none of its steps,
+// sidecars, or step template use, through a $(workspaces.<name>.*) variable, their mountPath, or an
+// isolated workspace
+func ValidateUnusedTaskWorkspaces(taskSpec v1.TaskSpec) error {
+	if len(taskSpec.Workspaces) == 0 {
+		return nil
+	}
+	used := make(map[string]bool)
+	for _, step := range taskSpec.Steps {
+		for _, usage := range step.Workspaces {
+			used[usage.Name] = true
+		}
+	}
+	for _, sidecar := range taskSpec.Sidecars {
+		for _, usage := range sidecar.Workspaces {
+			used[usage.Name] = true
+		}
+	}
+
+	usages := taskSpec.DeepCopy()
+	usages.Workspaces = nil
+	content, err := yaml.Marshal(usages)
+	if err != nil {
+		return err
+	}
+
+	var unusedErr error
+	for i, workspace := range taskSpec.Workspaces {
+		mountPath := workspace.MountPath
+		if mountPath == "" {
+			mountPath = "/workspace/" + workspace.Name
+		}
+		if used[workspace.Name] || workspaceUsageRegex(workspace.Name, mountPath).Match(content) {
+			continue
+		}
+		unusedErr = multierror.Append(unusedErr, withRule(RuleUnusedWorkspace,
+			warningf("task workspace %q is declared but never used: spec.workspaces[%d]", workspace.Name, i)))
+	}
+	return unusedErr
+}
+
 // ValidateWorkspaceBindings validates workspace bindings in pipeline tasks against task specifications
 func ValidateWorkspaceBindings(pipelineTask v1.PipelineTask, taskSpec *v1.TaskSpec, availableWorkspaces map[string]v1.PipelineWorkspaceDeclaration) error {
 	var err error
 
 	// Quick validation for a single task - used by the main pipeline validator
-	if taskErr := validateTaskWorkspaces(pipelineTask, taskSpec, availableWorkspaces); taskErr != nil {
+	if taskErr := validateTaskWorkspaces(pipelineTask, taskSpec, availableWorkspaces, nil, nil); taskErr != nil {
 		err = multierror.Append(err, taskErr)
 	}
 
 	return err
 }
+
+// workspaceWriteCommandRegex matches shell commands which modify the paths given to them
+var workspaceWriteCommandRegex = regexp.MustCompile(`^\s*(sudo\s+)?(mkdir|touch|rm|tee|chmod|chown|ln|git\s+clone|git\s+init|unzip|tar\s+-?[a-zA-Z]*x)\b`)
+
+// ValidateReadOnlyWorkspaces reports steps which appear to write to a workspace the Task declares
+// as readOnly. Writes are detected heuristically from step scripts and commands, so findings are
+// reported as warnings.
+func ValidateReadOnlyWorkspaces(taskSpec v1.TaskSpec) error {
+	var err error
+	for _, workspace := range taskSpec.Workspaces {
+		if !workspace.ReadOnly {
+			continue
+		}
+		ref := regexp.QuoteMeta(fmt.Sprintf("$(workspaces.%s.path)", workspace.Name))
+		redirectRegex := regexp.MustCompile(`>>?\s*["']?` + ref)
+		copyRegex := regexp.MustCompile(`^\s*(sudo\s+)?(cp|mv|rsync)\b.*\s["']?` + ref + `[^\s]*\s*$`)
+		refRegex := regexp.MustCompile(ref)
+
+		for _, step := range taskSpec.Steps {
+			lines := strings.Split(step.Script, "\n")
+			if len(step.Command) > 0 {
+				lines = append(lines, strings.Join(append(append([]string{}, step.Command...), step.Args...), " "))
+			}
+			for _, line := range lines {
+				if !refRegex.MatchString(line) {
+					continue
+				}
+				if redirectRegex.MatchString(line) || copyRegex.MatchString(line) || workspaceWriteCommandRegex.MatchString(line) {
+					err = multierror.Append(err, warningf(
+						"step %q appears to write to read-only workspace %q: %s",
+						step.Name, workspace.Name, strings.TrimSpace(line)))
+				}
+			}
+		}
+	}
+	return err
+}
+
+// validateReadOnlyWorkspaceUsage reports pipeline workspaces which are bound to a Task that
+// declares them readOnly and to a Task that may write to them, without the former running after
+// the latter. Finally tasks always run after the regular tasks.
+func validateReadOnlyWorkspaceUsage(pipelineSpec v1.PipelineSpec, allTaskSpecs map[string]*v1.TaskSpec) error {
+	var err error
+
+	finally := make(map[string]bool)
+	for _, pipelineTask := range pipelineSpec.Finally {
+		finally[pipelineTask.Name] = true
+	}
+	deps := v1.PipelineTaskList(pipelineSpec.Tasks).Deps()
+
+	readers := make(map[string][]string)
+	writers := make(map[string][]string)
+	for _, pipelineTask := range allPipelineTasks(pipelineSpec) {
+		taskSpec, exists := allTaskSpecs[pipelineTask.Name]
+		if !exists {
+			continue
+		}
+		for _, binding := range pipelineTask.Workspaces {
+			if binding.Workspace == "" {
+				continue
+			}
+			for _, decl := range taskSpec.Workspaces {
+				if decl.Name != binding.Name {
+					continue
+				}
+				if decl.ReadOnly {
+					readers[binding.Workspace] = append(readers[binding.Workspace], pipelineTask.Name)
+				} else {
+					writers[binding.Workspace] = append(writers[binding.Workspace], pipelineTask.Name)
+				}
+			}
+		}
+	}
+
+	workspaces := make([]string, 0, len(readers))
+	for workspace := range readers {
+		workspaces = append(workspaces, workspace)
+	}
+	sort.Strings(workspaces)
+
+	for _, workspace := range workspaces {
+		for _, reader := range readers[workspace] {
+			for _, writer := range writers[workspace] {
+				if reader == writer || finally[reader] != finally[writer] || runsAfter(reader, writer, deps) || runsAfter(writer, reader, deps) {
+					continue
+				}
+				err = multierror.Append(err, warningf(
+					"pipeline workspace %q is bound read-only to PipelineTask %q and writable to PipelineTask %q, which may run concurrently, order them with runAfter",
+					workspace, reader, writer))
+			}
+		}
+	}
+
+	return err
+}
+
+// runsAfter reports whether the PipelineTask named task depends, directly or not, on the
+// PipelineTask named other
+func runsAfter(task, other string, deps map[string][]string) bool {
+	visited := make(map[string]bool)
+	pending := append([]string{}, deps[task]...)
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		if current == other {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		pending = append(pending, deps[current]...)
+	}
+	return false
+}
+
+// accessModes are the access modes of PersistentVolumeClaims
+var accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce, corev1.ReadOnlyMany, corev1.ReadWriteMany, corev1.ReadWriteOncePod}
+
+// ValidateWorkspaceBindingVolumes verifies the volumes workspaces are bound to by a run: the
+// volumeClaimTemplates must use known access modes and request storage, and an empty
+// storageClassName, which disables dynamic provisioning, is reported as a warning. Each binding
+// must set exactly one volume source, and each source of a projected volume exactly one type of
+// projection. The path is the one of the spec of the run. Projected volumes without sources and CSI
+// volumes without a driver are reported by the schema validation of runs.
+func ValidateWorkspaceBindingVolumes(bindings []v1.WorkspaceBinding, path string) error {
+	var err error
+	for i, binding := range bindings {
+		if sourceErr := validateWorkspaceBindingSource(binding, fmt.Sprintf("%s.workspaces[%d]", path, i)); sourceErr != nil {
+			err = multierror.Append(err, sourceErr)
+		}
+		if projected := binding.Projected; projected != nil {
+			for j, source := range projected.Sources {
+				if sourceErr := validateVolumeProjection(source, fmt.Sprintf("%s.workspaces[%d].projected.sources[%d]", path, i, j)); sourceErr != nil {
+					err = multierror.Append(err, sourceErr)
+				}
+			}
+		}
+
+		template := binding.VolumeClaimTemplate
+		if template == nil {
+			continue
+		}
+		specPath := fmt.Sprintf("%s.workspaces[%d].volumeClaimTemplate.spec", path, i)
+		for j, mode := range template.Spec.AccessModes {
+			if !slices.Contains(accessModes, mode) {
+				err = multierror.Append(err, fmt.Errorf("invalid value: %s is not an access mode, expected one of: %s: %s.accessModes[%d]",
+					mode, joinAccessModes(), specPath, j))
+			}
+		}
+		if _, ok := template.Spec.Resources.Requests[corev1.ResourceStorage]; !ok {
+			err = multierror.Append(err, fmt.Errorf("missing field(s): %s.resources.requests.storage", specPath))
+		}
+		if name := template.Spec.StorageClassName; name != nil && *name == "" {
+			err = multierror.Append(err, warningf("storageClassName is empty, which disables dynamic provisioning so that the claim only binds to an existing volume; leave it out to use the default storage class: %s.storageClassName", specPath))
+		}
+	}
+	return err
+}
+
+// volumeSources are the fields of the volume sources of workspace bindings
+var volumeSources = []string{"emptyDir", "persistentVolumeClaim", "volumeClaimTemplate", "configMap", "secret", "projected", "csi"}
+
+// validateWorkspaceBindingSource verifies a workspace binding, at path, sets exactly one volume
+// source. The schema validation of runs reports these as one error per volume source, leaving out
+// the projected and csi sources, so they are skipped by isWorkspaceBindingSourceError.
+func validateWorkspaceBindingSource(binding v1.WorkspaceBinding, path string) error {
+	set := map[string]bool{
+		"emptyDir":              binding.EmptyDir != nil,
+		"persistentVolumeClaim": binding.PersistentVolumeClaim != nil,
+		"volumeClaimTemplate":   binding.VolumeClaimTemplate != nil,
+		"configMap":             binding.ConfigMap != nil,
+		"secret":                binding.Secret != nil,
+		"projected":             binding.Projected != nil,
+		"csi":                   binding.CSI != nil,
+	}
+	var sources []string
+	for _, source := range volumeSources {
+		if set[source] {
+			sources = append(sources, source)
+		}
+	}
+	switch len(sources) {
+	case 1:
+		return nil
+	case 0:
+		return withPath(path, fmt.Errorf("workspace binding %q sets no volume source, expected exactly one of: %s", binding.Name, strings.Join(volumeSources, ", ")))
+	default:
+		return withPath(path, fmt.Errorf("workspace binding %q sets %s, expected exactly one of: %s", binding.Name, strings.Join(sources, " and "), strings.Join(volumeSources, ", ")))
+	}
+}
+
+// workspaceBindingSourcePath matches the paths of the volume sources of the workspace bindings of
+// runs, as reported by their schema validation
+var workspaceBindingSourcePath = regexp.MustCompile(`^spec\.workspaces\[\d+\]\.[a-z]+$`)
+
+// isWorkspaceBindingSourceError tells whether the schema error with message at path reports a
+// workspace binding setting more than one volume source, or none, which
+// validateWorkspaceBindingSource reports instead
+func isWorkspaceBindingSourceError(message, path string) bool {
+	return strings.HasPrefix(message, "expected exactly one, got ") && workspaceBindingSourcePath.MatchString(path)
+}
+
+// projectionTypes are the types of sources of projected volumes
+var projectionTypes = []string{"secret", "configMap", "downwardAPI", "serviceAccountToken", "clusterTrustBundle"}
+
+// validateVolumeProjection verifies a source of a projected volume, at path, sets exactly one type
+// of projection
+func validateVolumeProjection(source corev1.VolumeProjection, path string) error {
+	set := map[string]bool{
+		"secret":              source.Secret != nil,
+		"configMap":           source.ConfigMap != nil,
+		"downwardAPI":         source.DownwardAPI != nil,
+		"serviceAccountToken": source.ServiceAccountToken != nil,
+		"clusterTrustBundle":  source.ClusterTrustBundle != nil,
+	}
+	var types []string
+	for _, projectionType := range projectionTypes {
+		if set[projectionType] {
+			types = append(types, projectionType)
+		}
+	}
+	switch len(types) {
+	case 1:
+		return nil
+	case 0:
+		return withPath(path, fmt.Errorf("expected exactly one of %s, got neither", strings.Join(projectionTypes, ", ")))
+	default:
+		return withPath(path, fmt.Errorf("expected exactly one of %s, got %s", strings.Join(projectionTypes, ", "), strings.Join(types, " and ")))
+	}
+}
+
+// joinAccessModes returns the access modes of PersistentVolumeClaims separated by commas
+func joinAccessModes() string {
+	names := make([]string, 0, len(accessModes))
+	for _, mode := range accessModes {
+		names = append(names, string(mode))
+	}
+	return strings.Join(names, ", ")
+}
+
+// ValidateWorkspaceBindingQuantities verifies that the storage requested and limited by the
+// volumeClaimTemplates of the workspace bindings in the raw YAML of a run, and the sizeLimit of
+// their emptyDirs, are quantities, e.g. "1Gi". The requested storage must be greater than zero and
+// the sizeLimit must not be negative. They are checked before decoding since a single malformed
+// quantity otherwise fails decoding the whole run without pointing at the offending field.
+func ValidateWorkspaceBindingQuantities(rawYAML []byte) error {
+	var raw struct {
+		Spec struct {
+			Workspaces []struct {
+				EmptyDir *struct {
+					SizeLimit any `json:"sizeLimit"`
+				} `json:"emptyDir"`
+				VolumeClaimTemplate *struct {
+					Spec struct {
+						Resources struct {
+							Requests map[string]any `json:"requests"`
+							Limits   map[string]any `json:"limits"`
+						} `json:"resources"`
+					} `json:"spec"`
+				} `json:"volumeClaimTemplate"`
+			} `json:"workspaces"`
+		} `json:"spec"`
+	}
+	if yaml.Unmarshal(rawYAML, &raw) != nil {
+		// Workspace bindings which are not objects fail decoding the run instead.
+		return nil
+	}
+
+	var err error
+	appendErr := func(quantityErr error) {
+		if quantityErr != nil {
+			err = multierror.Append(err, quantityErr)
+		}
+	}
+	for i, workspace := range raw.Spec.Workspaces {
+		path := fmt.Sprintf("spec.workspaces[%d]", i)
+		if workspace.EmptyDir != nil && workspace.EmptyDir.SizeLimit != nil {
+			quantity, quantityErr := parseQuantity(workspace.EmptyDir.SizeLimit, path+".emptyDir.sizeLimit")
+			appendErr(quantityErr)
+			if quantityErr == nil && quantity.Sign() < 0 {
+				appendErr(fmt.Errorf("invalid value: %s must not be negative: %s.emptyDir.sizeLimit", quantity.String(), path))
+			}
+		}
+		if workspace.VolumeClaimTemplate == nil {
+			continue
+		}
+		resources := workspace.VolumeClaimTemplate.Spec.Resources
+		resourcesPath := path + ".volumeClaimTemplate.spec.resources"
+		if value, ok := resources.Requests["storage"]; ok {
+			quantity, quantityErr := parseQuantity(value, resourcesPath+".requests.storage")
+			appendErr(quantityErr)
+			if quantityErr == nil && quantity.Sign() <= 0 {
+				appendErr(fmt.Errorf("invalid value: %s must be greater than zero: %s.requests.storage", quantity.String(), resourcesPath))
+			}
+		}
+		if value, ok := resources.Limits["storage"]; ok {
+			_, quantityErr := parseQuantity(value, resourcesPath+".limits.storage")
+			appendErr(quantityErr)
+		}
+	}
+	return err
+}
+
+// parseQuantity parses a raw YAML value as a quantity, e.g. "1Gi" or 1024
+func parseQuantity(value any, path string) (resource.Quantity, error) {
+	var s string
+	switch value := value.(type) {
+	case string:
+		s = value
+	case float64:
+		s = fmt.Sprint(value)
+	default:
+		return resource.Quantity{}, withPath(path, fmt.Errorf("invalid value: %v is not a quantity such as \"1Gi\"", value))
+	}
+	quantity, err := resource.ParseQuantity(s)
+	if err != nil {
+		return resource.Quantity{}, withPath(path, fmt.Errorf("invalid value: %q is not a quantity such as \"1Gi\"", s))
+	}
+	return quantity, nil
+}
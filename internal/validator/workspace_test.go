@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -444,7 +445,7 @@ func TestValidateTaskWorkspaces(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateTaskWorkspaces(tt.pipelineTask, tt.taskSpec, tt.pipelineWorkspaces)
+			err := validateTaskWorkspaces(tt.pipelineTask, tt.taskSpec, tt.pipelineWorkspaces, nil, nil)
 
 			if tt.expectNoError {
 				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
@@ -539,13 +540,14 @@ func TestValidateWorkspaceRequirements(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validateWorkspaceRequirements(tt.declaration, tt.binding)
+			err := validateWorkspaceRequirements(tt.declaration, tt.binding, tt.binding.SubPath)
 
 			if tt.expectNoError {
 				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
 			} else {
 				require.Error(t, err, "Expected error for test case: %s", tt.name)
 				assert.Contains(t, err.Error(), tt.expectedError, "Expected error message to contain: %s", tt.expectedError)
+				assert.NoError(t, WithoutWarnings(err), "Expected absolute subPath conflicts to be warnings")
 			}
 		})
 	}
@@ -653,6 +655,7 @@ func TestValidateUnusedPipelineWorkspaces(t *testing.T) {
 				for _, expectedErr := range tt.expectedErrors {
 					assert.Contains(t, errStr, expectedErr, "Expected error message to contain: %s", expectedErr)
 				}
+				assert.NoError(t, WithoutWarnings(err), "Expected unused pipeline workspaces to be warnings")
 			}
 		})
 	}
@@ -724,3 +727,470 @@ func TestValidateWorkspaceBindings(t *testing.T) {
 	}
 }
 
+
+func TestValidateReadOnlyWorkspaces(t *testing.T) {
+	tests := []struct {
+		name             string
+		taskSpecYAML     string
+		expectedWarnings []string
+	}{
+		{
+			name: "reading from read-only workspace",
+			taskSpecYAML: `
+workspaces:
+  - name: source
+    readOnly: true
+steps:
+  - name: build
+    image: alpine:latest
+    script: |
+      cat $(workspaces.source.path)/Dockerfile
+      cp $(workspaces.source.path)/config.yaml /tmp/config.yaml
+      make -C $(workspaces.source.path) > /tmp/build.log
+`,
+		},
+		{
+			name: "writing to writable workspace",
+			taskSpecYAML: `
+workspaces:
+  - name: source
+steps:
+  - name: clone
+    image: alpine/git:latest
+    script: git clone https://github.com/example/repo.git $(workspaces.source.path)
+`,
+		},
+		{
+			name: "writing to read-only workspace",
+			taskSpecYAML: `
+workspaces:
+  - name: source
+    readOnly: true
+steps:
+  - name: clone
+    image: alpine/git:latest
+    script: |
+      git clone https://github.com/example/repo.git $(workspaces.source.path)
+      echo done > "$(workspaces.source.path)/status"
+      cp /tmp/output $(workspaces.source.path)/output
+  - name: cleanup
+    image: alpine:latest
+    command: [rm, -rf]
+    args: [$(workspaces.source.path)/tmp]
+`,
+			expectedWarnings: []string{
+				`step "clone" appears to write to read-only workspace "source": git clone https://github.com/example/repo.git $(workspaces.source.path)`,
+				`step "clone" appears to write to read-only workspace "source": echo done > "$(workspaces.source.path)/status"`,
+				`step "clone" appears to write to read-only workspace "source": cp /tmp/output $(workspaces.source.path)/output`,
+				`step "cleanup" appears to write to read-only workspace "source": rm -rf $(workspaces.source.path)/tmp`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateReadOnlyWorkspaces(taskSpec)
+
+			assert.NoError(t, WithoutWarnings(err), "Expected only warnings for test case: %s", tt.name)
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+		})
+	}
+}
+
+func TestValidateUnusedTaskWorkspaces(t *testing.T) {
+	tests := []struct {
+		name             string
+		taskSpecYAML     string
+		expectedWarnings []string
+	}{
+		{
+			name: "workspaces used through variables, mount paths, and isolated workspaces",
+			taskSpecYAML: `
+workspaces:
+  - name: source
+  - name: cache
+    mountPath: /cache
+  - name: config
+    optional: true
+  - name: output
+  - name: credentials
+  - name: sidecar-data
+stepTemplate:
+  env:
+    - name: OUTPUT
+      value: $(workspaces.output.path)
+steps:
+  - name: build
+    image: alpine:latest
+    script: |
+      if [ "$(workspaces.config.bound)" = "true" ]; then cp /workspace/config/* .; fi
+      make -C $(workspaces.source.path) CACHE_DIR=/cache
+  - name: push
+    image: alpine:latest
+    workspaces:
+      - name: credentials
+sidecars:
+  - name: server
+    image: alpine:latest
+    workspaces:
+      - name: sidecar-data
+`,
+		},
+		{
+			name: "unused workspaces",
+			taskSpecYAML: `
+workspaces:
+  - name: source
+  - name: cache
+    mountPath: /cache
+  - name: output
+steps:
+  - name: build
+    image: alpine:latest
+    script: make -C $(workspaces.source.path)
+`,
+			expectedWarnings: []string{
+				`task workspace "cache" is declared but never used: spec.workspaces[1]`,
+				`task workspace "output" is declared but never used: spec.workspaces[2]`,
+			},
+		},
+		{
+			name: "paths only sharing a prefix with the mount paths",
+			taskSpecYAML: `
+workspaces:
+  - name: source
+  - name: cache
+    mountPath: /cache
+  - name: output
+steps:
+  - name: build
+    image: alpine:latest
+    script: |
+      cd /workspace/source-copy && make CACHE_DIR=/cache2 OUT=/tmp/workspace/output
+      echo $(workspaces.output.paths)
+`,
+			expectedWarnings: []string{
+				`task workspace "source" is declared but never used: spec.workspaces[0]`,
+				`task workspace "cache" is declared but never used: spec.workspaces[1]`,
+				`task workspace "output" is declared but never used: spec.workspaces[2]`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateUnusedTaskWorkspaces(taskSpec)
+
+			assert.NoError(t, WithoutWarnings(err), "Expected only warnings for test case: %s", tt.name)
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+			for _, finding := range Findings(err) {
+				assert.Equal(t, RuleUnusedWorkspace.ID, finding.Rule)
+			}
+		})
+	}
+}
+
+func TestValidateStepWorkspaceReferences(t *testing.T) {
+	tests := []struct {
+		name           string
+		taskSpecYAML   string
+		expectedErrors []string
+	}{
+		{
+			name: "declared workspaces",
+			taskSpecYAML: `
+workspaces:
+  - name: source
+  - name: cache
+    optional: true
+steps:
+  - name: build
+    image: alpine
+    workingDir: $(workspaces.source.path)
+    script: |
+      if [ "$(workspaces.cache.bound)" = "true" ]; then cp -r $(workspaces.cache.path) .; fi
+sidecars:
+  - name: server
+    image: alpine
+    env:
+      - name: CLAIM
+        value: $(workspaces.source.claim)
+`,
+		},
+		{
+			name: "undeclared workspaces",
+			taskSpecYAML: `
+workspaces:
+  - name: source
+steps:
+  - name: build
+    image: alpine
+    args: ["$(workspaces.cache.path)", "$(workspaces.source.path)"]
+    script: echo $(workspaces.output.path) $(workspaces.output.bound)
+sidecars:
+  - name: server
+    image: alpine
+    env:
+      - name: CLAIM
+        value: $(workspaces.data.claim)
+`,
+			expectedErrors: []string{
+				"workspace reference $(workspaces.cache.path) is not declared by the Task: spec.steps[0].args[0]",
+				"workspace reference $(workspaces.output.path) is not declared by the Task: spec.steps[0].script",
+				"workspace reference $(workspaces.data.claim) is not declared by the Task: spec.sidecars[0].env[CLAIM]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taskSpec, err := taskSpecFromYAML(tt.taskSpecYAML)
+			require.NoError(t, err)
+
+			err = ValidateStepWorkspaceReferences(taskSpec)
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			var messages []string
+			for _, finding := range Findings(err) {
+				messages = append(messages, finding.Message+": "+finding.ResourcePath)
+			}
+			assert.Equal(t, tt.expectedErrors, messages)
+		})
+	}
+}
+
+func TestValidateReadOnlyWorkspaceUsage(t *testing.T) {
+	readerSpec := &v1.TaskSpec{Workspaces: []v1.WorkspaceDeclaration{{Name: "source", ReadOnly: true}}}
+	writerSpec := &v1.TaskSpec{Workspaces: []v1.WorkspaceDeclaration{{Name: "source"}}}
+	allTaskSpecs := map[string]*v1.TaskSpec{
+		"clone":  writerSpec,
+		"lint":   readerSpec,
+		"build":  readerSpec,
+		"report": readerSpec,
+		"cleanup": writerSpec,
+	}
+	binding := []v1.WorkspacePipelineTaskBinding{{Name: "source", Workspace: "shared"}}
+
+	tests := []struct {
+		name             string
+		pipelineSpec     v1.PipelineSpec
+		expectedWarnings []string
+	}{
+		{
+			name: "readers run after the writer",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{
+					{Name: "clone", Workspaces: binding},
+					{Name: "lint", Workspaces: binding, RunAfter: []string{"clone"}},
+					{Name: "build", Workspaces: binding, RunAfter: []string{"lint"}},
+				},
+				Finally: []v1.PipelineTask{
+					{Name: "report", Workspaces: binding},
+				},
+			},
+		},
+		{
+			name: "reader may run concurrently with the writer",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{
+					{Name: "clone", Workspaces: binding},
+					{Name: "lint", Workspaces: binding},
+					{Name: "build", Workspaces: binding, RunAfter: []string{"clone"}},
+				},
+			},
+			expectedWarnings: []string{
+				`pipeline workspace "shared" is bound read-only to PipelineTask "lint" and writable to PipelineTask "clone", which may run concurrently, order them with runAfter`,
+			},
+		},
+		{
+			name: "writer in finally runs after the readers",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{
+					{Name: "lint", Workspaces: binding},
+					{Name: "build", Workspaces: binding},
+				},
+				Finally: []v1.PipelineTask{
+					{Name: "cleanup", Workspaces: binding},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateReadOnlyWorkspaceUsage(tt.pipelineSpec, allTaskSpecs)
+
+			assert.NoError(t, WithoutWarnings(err), "Expected only warnings for test case: %s", tt.name)
+			assert.Equal(t, tt.expectedWarnings, Warnings(err))
+		})
+	}
+}
+
+func TestValidateWorkspacesPropagated(t *testing.T) {
+	embedded := func(script string) *v1.EmbeddedTask {
+		return &v1.EmbeddedTask{TaskSpec: v1.TaskSpec{Steps: []v1.Step{{Name: "echo", Script: script}}}}
+	}
+
+	tests := []struct {
+		name                 string
+		pipelineSpec         v1.PipelineSpec
+		propagatedWorkspaces []string
+		expectedErrors       []string
+	}{
+		{
+			name: "binding propagated to an embedded task spec",
+			pipelineSpec: v1.PipelineSpec{
+				Workspaces: []v1.PipelineWorkspaceDeclaration{{Name: "shared"}},
+				Tasks: []v1.PipelineTask{
+					{
+						Name:       "echo",
+						Workspaces: []v1.WorkspacePipelineTaskBinding{{Name: "shared", Workspace: "shared"}},
+						TaskSpec:   embedded("ls $(workspaces.shared.path)"),
+					},
+				},
+			},
+		},
+		{
+			name: "propagated binding to a non-existent pipeline workspace",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{
+					{
+						Name:       "echo",
+						Workspaces: []v1.WorkspacePipelineTaskBinding{{Name: "shared", Workspace: "missing"}},
+						TaskSpec:   embedded("ls $(workspaces.shared.path)"),
+					},
+				},
+			},
+			expectedErrors: []string{
+				`workspace binding "shared" references non-existent pipeline workspace "missing"`,
+			},
+		},
+		{
+			name: "undeclared workspace referenced by an embedded task spec",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{
+					{
+						Name:     "echo",
+						TaskSpec: embedded("ls $(workspaces.missing.path) $(workspaces.missing.bound)"),
+					},
+				},
+			},
+			expectedErrors: []string{
+				"workspace reference $(workspaces.missing.path) is not declared by the task spec nor provided by the PipelineTask: taskSpec.steps[0].script",
+			},
+		},
+		{
+			name: "workspaces propagated from the PipelineRun",
+			pipelineSpec: v1.PipelineSpec{
+				Tasks: []v1.PipelineTask{
+					{
+						Name:       "echo",
+						Workspaces: []v1.WorkspacePipelineTaskBinding{{Name: "cache", Workspace: "cache"}},
+						TaskSpec:   embedded("ls $(workspaces.cache.path) $(workspaces.shared.path)"),
+					},
+				},
+			},
+			propagatedWorkspaces: []string{"shared", "cache"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allTaskSpecs := map[string]*v1.TaskSpec{}
+			for _, pipelineTask := range tt.pipelineSpec.Tasks {
+				allTaskSpecs[pipelineTask.Name] = &pipelineTask.TaskSpec.TaskSpec
+			}
+
+			err := validateWorkspaces(tt.pipelineSpec, allTaskSpecs, tt.propagatedWorkspaces, nil)
+
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr)
+			}
+			assert.Equal(t, len(tt.expectedErrors), strings.Count(err.Error(), "workspace validation: "))
+		})
+	}
+}
+
+func TestValidateWorkspacesSubPathVariables(t *testing.T) {
+	taskSpec := &v1.TaskSpec{
+		Workspaces: []v1.WorkspaceDeclaration{{Name: "source", MountPath: "/workspace/source"}},
+		Steps:      []v1.Step{{Name: "ls", Script: "ls /workspace/source"}},
+	}
+	pipelineSpec := func(subPath string) v1.PipelineSpec {
+		return v1.PipelineSpec{
+			Params: v1.ParamSpecs{
+				{Name: "cache-dir", Default: v1.NewStructuredValues("/cache")},
+				{Name: "dir", Default: v1.NewStructuredValues("build")},
+			},
+			Workspaces: []v1.PipelineWorkspaceDeclaration{{Name: "shared"}},
+			Tasks: []v1.PipelineTask{{
+				Name:       "ls",
+				TaskRef:    &v1.TaskRef{Name: "ls"},
+				Workspaces: []v1.WorkspacePipelineTaskBinding{{Name: "source", Workspace: "shared", SubPath: subPath}},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		subPath         string
+		runtimeParams   map[string]string
+		expectedWarning string
+	}{
+		{
+			name:    "context variables",
+			subPath: "$(context.pipelineRun.uid)/$(context.pipelineTask.retries)",
+		},
+		{
+			name:    "param default",
+			subPath: "$(params.dir)/$(context.pipelineRun.name)",
+		},
+		{
+			name:            "absolute param default",
+			subPath:         "$(params.cache-dir)",
+			expectedWarning: `workspace "source": task declares mountPath "/workspace/source" but binding uses absolute subPath "$(params.cache-dir)", resolving to "/cache", which may cause conflicts`,
+		},
+		{
+			name:          "runtime param overriding an absolute default",
+			subPath:       "$(params.cache-dir)",
+			runtimeParams: map[string]string{"cache-dir": "cache"},
+		},
+		{
+			name:            "absolute runtime param",
+			subPath:         "$(params.dir)/$(context.pipelineRun.uid)",
+			runtimeParams:   map[string]string{"dir": "/builds"},
+			expectedWarning: `binding uses absolute subPath "$(params.dir)/$(context.pipelineRun.uid)", resolving to "/builds/context",`,
+		},
+		{
+			name:    "result only known at runtime",
+			subPath: "$(tasks.clone.results.dir)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateWorkspaces(pipelineSpec(tt.subPath), map[string]*v1.TaskSpec{"ls": taskSpec}, nil, tt.runtimeParams)
+			if tt.expectedWarning == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedWarning)
+			assert.NoError(t, WithoutWarnings(err))
+		})
+	}
+}
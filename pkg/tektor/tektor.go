@@ -0,0 +1,258 @@
+// Package tektor validates Tekton resources. It is the stable API for tools embedding tektor rather
+// than running the tektor CLI, and validates resources the same way as `tektor validate`.
+//
+// The validation functions return nil for valid resources. Otherwise, the returned error combines
+// every problem found with github.com/hashicorp/go-multierror, including warnings which do not make
+// the resource invalid; use Warnings and WithoutWarnings to tell them apart.
+package tektor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/internal/celrule"
+	"github.com/lcarva/tektor/internal/config"
+	"github.com/lcarva/tektor/internal/plugin"
+	"github.com/lcarva/tektor/internal/policy"
+	"github.com/lcarva/tektor/internal/remotecache"
+	"github.com/lcarva/tektor/internal/taskindex"
+	"github.com/lcarva/tektor/internal/validator"
+)
+
+// Rule profiles, see Options.Profiles
+const (
+	// ProfileKonflux verifies the conventions of Konflux build pipelines.
+	ProfileKonflux = validator.ProfileKonflux
+	// ProfileSecurity verifies steps do not weaken the isolation of their pod.
+	ProfileSecurity = validator.ProfileSecurity
+)
+
+// TaskResolver retrieves the spec of the Task a PipelineTask refers to with its taskRef
+type TaskResolver = validator.TaskResolver
+
+// TaskResolverFunc adapts a function to a TaskResolver
+type TaskResolverFunc = validator.TaskResolverFunc
+
+// DefaultTaskResolver resolves Tasks through the bundles, git, and hub resolvers, or by name from
+// Options.TaskDirs
+var DefaultTaskResolver = validator.DefaultTaskResolver
+
+// Credentials authenticate the git repositories and registries remote Tasks are resolved from
+type Credentials = config.Credentials
+
+// GitCredential authenticates the git repositories of a host
+type GitCredential = config.GitCredential
+
+// RegistryCredential authenticates the bundles of an OCI registry
+type RegistryCredential = config.RegistryCredential
+
+// CustomRule is a rule defined with a CEL expression, as in the customRules of .tektor.yaml
+type CustomRule = config.CustomRule
+
+// RuleSetting is the severity of the findings of a rule, SeverityWarning or SeverityError, or
+// RuleOff, as in the rules of .tektor.yaml
+type RuleSetting = config.RuleSetting
+
+// RuleOff disables a rule, see Options.Rules
+const RuleOff RuleSetting = config.RuleOff
+
+// Options controls the validation. The zero value validates like `tektor validate` without flags,
+// except that remote resolutions are neither cached, bounded, nor retried.
+type Options struct {
+	// CheckImages enables checks that fetch the configuration of step images from their registry.
+	CheckImages bool
+	// Profiles enables additional sets of rules, e.g. ProfileKonflux, which combine.
+	Profiles []string
+	// TaskDirs are directories of Task and Pipeline definitions, resolving the PipelineTasks and
+	// PipelineRuns which refer to them by name only.
+	TaskDirs []string
+	// Params are runtime parameter values substituted for the references to the params of a Pipeline.
+	Params map[string]string
+	// TaskResolver retrieves the Tasks PipelineTasks refer to, defaults to DefaultTaskResolver.
+	TaskResolver TaskResolver
+	// HubURL overrides the hub which the hub resolver fetches from.
+	HubURL string
+	// Credentials authenticate the git and bundles resolvers.
+	Credentials Credentials
+	// GitEnvTokens authenticates the HTTPS URLs of github.com and gitlab.com without credentials
+	// with the GITHUB_TOKEN and GITLAB_TOKEN environment variables.
+	GitEnvTokens bool
+	// CacheDir, if set, caches the Tasks resolved from bundles and git repositories across calls.
+	CacheDir string
+	// CacheTTL is how long cached resolutions of tags and branches are reused.
+	CacheTTL time.Duration
+	// GitCacheDir, if set, keeps the checkouts of the commits of the git repositories Tasks are
+	// resolved from across calls. Each call clones a repository once per revision regardless.
+	GitCacheDir string
+	// ResolveTimeout bounds each attempt to resolve a remote Task, unless zero.
+	ResolveTimeout time.Duration
+	// ResolveRetries is how many times a resolution failing for a transient reason is retried.
+	ResolveRetries int
+	// ResolveBackoff is the delay before the first retry, which doubles for each further retry.
+	ResolveBackoff time.Duration
+	// Policies are Rego policy files, or directories of policy files, evaluated against Pipelines
+	// and PipelineRuns.
+	Policies []string
+	// CustomRules are evaluated against Pipelines and PipelineRuns.
+	CustomRules []CustomRule
+	// PluginDirs are the directories of the tektor-validate-* plugins run against Pipelines and
+	// PipelineRuns. Plugins are never looked up on the PATH.
+	PluginDirs []string
+	// Strict reports warnings as errors, and enables the pedantic rules verifying descriptions,
+	// pinned remote references, and unused params.
+	Strict bool
+	// Rules override the severity of the findings of rules, keyed by rule ID or name, e.g.
+	// {"TEK0402": RuleOff}. They take precedence over Strict.
+	Rules map[string]RuleSetting
+}
+
+// taskCache is shared by the calls indexing Options.TaskDirs, so that each file is parsed once
+// unless it changes
+var taskCache = taskindex.NewCache()
+
+// withOptions returns a copy of ctx carrying the validation options of opts
+func withOptions(ctx context.Context, opts Options) (context.Context, error) {
+	for _, name := range opts.Profiles {
+		if !validator.IsKnownProfile(name) {
+			return nil, fmt.Errorf("unknown profile %q", name)
+		}
+	}
+	var index *taskindex.Index
+	if len(opts.TaskDirs) > 0 {
+		index = taskindex.NewWithCache(taskCache)
+		for _, dir := range opts.TaskDirs {
+			if err := index.AddDir(ctx, dir); err != nil {
+				return nil, err
+			}
+		}
+	}
+	var cache *remotecache.Cache
+	if opts.CacheDir != "" {
+		cache = remotecache.New(opts.CacheDir, opts.CacheTTL)
+	}
+	var policies *policy.Engine
+	if len(opts.Policies) > 0 {
+		var err error
+		if policies, err = policy.Load(ctx, opts.Policies); err != nil {
+			return nil, err
+		}
+	}
+	customRules, err := celrule.Compile(opts.CustomRules)
+	if err != nil {
+		return nil, err
+	}
+	plugins := plugin.Discover(opts.PluginDirs)
+	return validator.WithOptions(ctx, validator.Options{
+		CheckImages:    opts.CheckImages,
+		Profiles:       validator.NewProfileSet(opts.Profiles...),
+		TaskIndex:      index,
+		TaskResolver:   opts.TaskResolver,
+		HubURL:         opts.HubURL,
+		Cache:          cache,
+		GitRepos:       validator.NewGitRepoCache(opts.GitCacheDir),
+		Credentials:    opts.Credentials,
+		GitEnvTokens:   opts.GitEnvTokens,
+		ResolveTimeout: opts.ResolveTimeout,
+		ResolveRetries: opts.ResolveRetries,
+		ResolveBackoff: opts.ResolveBackoff,
+		Policies:       policies,
+		CustomRules:    customRules,
+		Plugins:        plugins,
+		Strict:         opts.Strict,
+	}), nil
+}
+
+// applySeverities turns the warnings of err into errors in strict mode, then applies Options.Rules
+func applySeverities(opts Options, err error) error {
+	if opts.Strict {
+		err = validator.PromoteWarnings(err)
+	}
+	return validator.OverrideRules(err, opts.Rules)
+}
+
+// ValidatePipeline validates a Pipeline along with the Tasks its PipelineTasks run
+func ValidatePipeline(ctx context.Context, p v1.Pipeline, opts Options) error {
+	ctx, err := withOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+	// The references to params are verified against the YAML of the Pipeline.
+	rawYAML, err := yaml.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return applySeverities(opts, validator.ValidatePipelineWithYAMLAndParams(ctx, p, rawYAML, validator.StringParams(opts.Params)))
+}
+
+// ValidatePipelineRun validates a PipelineRun, and the Pipeline it embeds or refers to by name from
+// Options.TaskDirs
+func ValidatePipelineRun(ctx context.Context, pr v1.PipelineRun, opts Options) error {
+	ctx, err := withOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+	rawYAML, err := yaml.Marshal(pr)
+	if err != nil {
+		return err
+	}
+	return applySeverities(opts, validator.ValidatePipelineRunWithYAML(ctx, pr, rawYAML))
+}
+
+// ValidateTask validates a Task
+func ValidateTask(ctx context.Context, t v1.Task, opts Options) error {
+	ctx, err := withOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+	return applySeverities(opts, validator.ValidateTaskV1(ctx, t))
+}
+
+// ValidateTaskRun validates a TaskRun, and the Task it embeds or refers to by name from
+// Options.TaskDirs
+func ValidateTaskRun(ctx context.Context, tr v1.TaskRun, opts Options) error {
+	ctx, err := withOptions(ctx, opts)
+	if err != nil {
+		return err
+	}
+	return applySeverities(opts, validator.ValidateTaskRun(ctx, tr))
+}
+
+// Finding is a problem reported by the validation, attributed to a rule
+type Finding = validator.Finding
+
+// Severity tells whether a Finding fails the validation
+type Severity = validator.Severity
+
+// Severities of findings
+const (
+	SeverityError   = validator.SeverityError
+	SeverityWarning = validator.SeverityWarning
+)
+
+// Findings returns a Finding for every error and warning contained in an error returned by the
+// validation functions
+func Findings(err error) []Finding {
+	return validator.Findings(err)
+}
+
+// DeduplicatedFindings is like Findings but collapses the findings reporting the same problem in
+// several places into one, which counts their Occurrences and lists their Locations
+func DeduplicatedFindings(err error) []Finding {
+	return validator.DeduplicatedFindings(err)
+}
+
+// Warnings returns the messages of the warnings contained in an error returned by the validation
+// functions
+func Warnings(err error) []string {
+	return validator.Warnings(err)
+}
+
+// WithoutWarnings returns an error returned by the validation functions without its warnings, or
+// nil if it only contains warnings
+func WithoutWarnings(err error) error {
+	return validator.WithoutWarnings(err)
+}
@@ -0,0 +1,241 @@
+package tektor_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/lcarva/tektor/pkg/tektor"
+)
+
+const buildTask = `
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: string
+  steps:
+    - name: build
+      image: alpine:latest
+      script: echo $(params.url)
+`
+
+func TestValidatePipeline(t *testing.T) {
+	taskDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "build.yaml"), []byte(buildTask), 0o644))
+
+	var task v1.Task
+	require.NoError(t, yaml.Unmarshal([]byte(buildTask), &task))
+	fake := tektor.TaskResolverFunc(func(ctx context.Context, ref v1.TaskRef, pipelineParams []v1.ParamSpec, runtimeParams map[string]string) (*v1.TaskSpec, error) {
+		return &task.Spec, nil
+	})
+
+	tests := []struct {
+		name           string
+		pipelineYAML   string
+		opts           tektor.Options
+		expectedErrors []string
+	}{
+		{
+			name: "task from a task directory",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: string
+  tasks:
+    - name: build
+      params:
+        - name: url
+          value: $(params.url)
+      taskRef:
+        name: build
+`,
+			opts: tektor.Options{TaskDirs: []string{taskDir}},
+		},
+		{
+			name: "task from a custom resolver",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        resolver: git
+        params:
+          - name: url
+            value: https://git.invalid/tasks.git
+          - name: pathInRepo
+            value: build.yaml
+`,
+			opts:           tektor.Options{TaskResolver: fake},
+			expectedErrors: []string{`"url" parameter is required`},
+		},
+		{
+			name: "undeclared param",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks:
+    - name: build
+      params:
+        - name: url
+          value: $(params.url)
+      taskRef:
+        name: build
+`,
+			opts:           tektor.Options{TaskDirs: []string{taskDir}},
+			expectedErrors: []string{"non-existent variable"},
+		},
+		{
+			name: "missing task directory",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks: []
+`,
+			opts:           tektor.Options{TaskDirs: []string{filepath.Join(taskDir, "missing")}},
+			expectedErrors: []string{"missing"},
+		},
+		{
+			name: "unknown profile",
+			pipelineYAML: `
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  tasks: []
+`,
+			opts:           tektor.Options{Profiles: []string{"strict"}},
+			expectedErrors: []string{`unknown profile "strict"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p v1.Pipeline
+			require.NoError(t, yaml.Unmarshal([]byte(tt.pipelineYAML), &p))
+
+			err := tektor.WithoutWarnings(tektor.ValidatePipeline(context.Background(), p, tt.opts))
+			if len(tt.expectedErrors) == 0 {
+				assert.NoError(t, err, "Expected no error for test case: %s", tt.name)
+				return
+			}
+			require.Error(t, err, "Expected error for test case: %s", tt.name)
+			for _, expectedErr := range tt.expectedErrors {
+				assert.Contains(t, err.Error(), expectedErr, "Expected error message to contain: %s", expectedErr)
+			}
+		})
+	}
+}
+
+func TestValidatePipelineRun(t *testing.T) {
+	taskDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(taskDir, "pipeline.yaml"), []byte(`
+apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build
+spec:
+  params:
+    - name: url
+      type: string
+  tasks:
+    - name: build
+      taskSpec:
+        steps:
+          - name: build
+            image: alpine:latest
+`), 0o644))
+
+	var pr v1.PipelineRun
+	require.NoError(t, yaml.Unmarshal([]byte(`
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: build
+spec:
+  pipelineRef:
+    name: build
+`), &pr))
+
+	err := tektor.ValidatePipelineRun(context.Background(), pr, tektor.Options{TaskDirs: []string{taskDir}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"url" parameter is required`)
+
+	pr.Spec.Params = v1.Params{{Name: "url", Value: *v1.NewStructuredValues("https://example.com")}}
+	assert.NoError(t, tektor.ValidatePipelineRun(context.Background(), pr, tektor.Options{TaskDirs: []string{taskDir}}))
+}
+
+func TestValidateTask(t *testing.T) {
+	var task v1.Task
+	require.NoError(t, yaml.Unmarshal([]byte(buildTask), &task))
+	assert.NoError(t, tektor.ValidateTask(context.Background(), task, tektor.Options{}))
+
+	err := tektor.ValidateTask(context.Background(), task, tektor.Options{Strict: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Task has no description: spec.description")
+	assert.NoError(t, tektor.ValidateTask(context.Background(), task, tektor.Options{
+		Strict: true,
+		Rules:  map[string]tektor.RuleSetting{"descriptions": tektor.RuleOff},
+	}))
+
+	task.Spec.Steps[0].SecurityContext = nil
+	err = tektor.ValidateTask(context.Background(), task, tektor.Options{Profiles: []string{tektor.ProfileSecurity}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing-security-context rule")
+
+	findings := tektor.Findings(err)
+	require.NotEmpty(t, findings)
+	for _, finding := range findings {
+		assert.Equal(t, "TEK0805", finding.Rule)
+		assert.Equal(t, tektor.SeverityError, finding.Severity)
+		assert.Equal(t, "spec.steps[0]", finding.ResourcePath)
+	}
+}
+
+func TestValidateTaskRun(t *testing.T) {
+	var tr v1.TaskRun
+	require.NoError(t, yaml.Unmarshal([]byte(`
+apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  name: build
+spec:
+  taskSpec:
+    params:
+      - name: url
+        type: string
+    steps:
+      - name: build
+        image: alpine:latest
+        script: echo $(params.url)
+`), &tr))
+
+	err := tektor.ValidateTaskRun(context.Background(), tr, tektor.Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"url" parameter is required`)
+}